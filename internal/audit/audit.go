@@ -0,0 +1,353 @@
+// Package audit records every tool call made through the CLI or the daemon
+// as one JSON line in audit.jsonl in the data directory. Recording
+// is best effort: a call never waits on, or fails because of, the audit log.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/redact"
+)
+
+// FileName is the audit log inside the data directory
+const FileName = "audit.jsonl"
+
+// DefaultMaxSize is the size at which the log rotates when the configuration
+// doesn't say
+const DefaultMaxSize = 10 << 20
+
+// keptFiles is how many rotated logs are kept besides the current one, as
+// audit.jsonl.1 (newest) to audit.jsonl.3
+const keptFiles = 3
+
+// Where a call was made from
+const (
+	SourceCall      = "call"      // call
+	SourceTool      = "tool"      // tool, with flags generated from the schema
+	SourceBroadcast = "broadcast" // call --servers or --all-servers
+	SourceRerun     = "rerun"     // history rerun
+	SourceRun       = "run"       // A step of a run pipeline
+	SourceBench     = "bench"     // bench
+	SourceDaemon    = "daemon"    // The daemon API, for the CLI or another client
+	SourceJob       = "job"       // A daemon job
+	SourceSchedule  = "schedule"  // A daemon schedule
+	SourceAttach    = "attach"    // The session attach prompt
+)
+
+// How a call ended
+const (
+	ExitOK        = "ok"
+	ExitToolError = "tool_error" // The tool reported a failure
+	ExitError     = "error"      // The call failed
+	ExitCancelled = "cancelled"
+	ExitTimeout   = "timeout"
+)
+
+// Entry is one recorded tool call
+type Entry struct {
+	ID         string                 `json:"id"`
+	Time       time.Time              `json:"time"` // When the call started
+	Server     string                 `json:"server"`
+	Tool       string                 `json:"tool"`
+	ArgsHash   string                 `json:"argsHash"`
+	Args       map[string]interface{} `json:"args,omitempty"`      // Only with audit.includeArgs
+	ExpandEnv  bool                   `json:"expandEnv,omitempty"` // Args are as written; environment variables in them were expanded for the call
+	SentHash   string                 `json:"sentHash,omitempty"`  // With ExpandEnv, the hash of the arguments actually sent
+	Redacted   []string               `json:"redacted,omitempty"`  // Arguments recorded with fingerprints in place of secrets
+	DurationMS int64                  `json:"durationMs"`
+	IsError    bool                   `json:"isError"`
+	Error      string                 `json:"error,omitempty"`
+	Transport  string                 `json:"transport"`        // stdio, http, docker or ssh
+	Source     string                 `json:"source"`           // One of the Source constants
+	Exit       string                 `json:"exit"`             // One of the Exit constants
+	Cached     bool                   `json:"cached,omitempty"` // Answered from the result cache
+}
+
+// Duration returns how long the call took
+func (e *Entry) Duration() time.Duration {
+	return time.Duration(e.DurationMS) * time.Millisecond
+}
+
+// Log appends entries to an audit log file. A nil Log records nothing.
+type Log struct {
+	path        string
+	maxSize     int64
+	includeArgs bool
+}
+
+// New creates a log at path configured by cfg, or returns nil if cfg
+// disables auditing
+func New(path string, cfg *config.AuditConfig) *Log {
+	if !cfg.IsEnabled() {
+		return nil
+	}
+	l := &Log{path: path, maxSize: DefaultMaxSize}
+	if cfg != nil {
+		l.includeArgs = cfg.IncludeArgs
+		if cfg.MaxSizeMB > 0 {
+			l.maxSize = int64(cfg.MaxSizeMB) << 20
+		}
+	}
+	return l
+}
+
+// Default creates a log in the data directory, or returns nil
+// without one
+func Default(cfg *config.AuditConfig) *Log {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil
+	}
+	return New(path, cfg)
+}
+
+// DefaultPath returns audit.jsonl in the data directory
+func DefaultPath() (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, FileName), nil
+}
+
+// Transport names the way serverConfig's server is reached
+func Transport(serverConfig config.ServerConfig) string {
+	return strings.ToLower(serverConfig.GetServerType())
+}
+
+// recordedArgsKey keys the arguments calls are recorded with in a context
+type recordedArgsKey struct{}
+
+// WithRecordedArgs returns ctx saying that tool calls made with it are
+// recorded with args, the arguments as written before environment variables
+// in them were expanded, instead of the arguments sent. Such entries are
+// marked ExpandEnv and keep the hash of the arguments sent, so arguments
+// recorded on a client's word can be told from those the call used.
+func WithRecordedArgs(ctx context.Context, args map[string]interface{}) context.Context {
+	return context.WithValue(ctx, recordedArgsKey{}, args)
+}
+
+// RecordedArgs returns the arguments ctx says calls are recorded with, or
+// args if it doesn't say
+func RecordedArgs(ctx context.Context, args map[string]interface{}) map[string]interface{} {
+	if recorded, ok := ctx.Value(recordedArgsKey{}).(map[string]interface{}); ok {
+		return recorded
+	}
+	return args
+}
+
+// Record appends an entry for a call with args that started at start and
+// ended with result or err, recording the arguments ctx says to. Failures to
+// write are logged at debug level and otherwise ignored.
+func (l *Log) Record(ctx context.Context, source, transport, server, tool string, args map[string]interface{}, start time.Time, result *mcp.ToolResult, err error) {
+	l.record(ctx, source, transport, server, tool, args, start, result, err, false)
+}
+
+// RecordCached appends an entry for a call answered from the result cache
+// without reaching the server
+func (l *Log) RecordCached(ctx context.Context, source, transport, server, tool string, args map[string]interface{}, start time.Time, result *mcp.ToolResult) {
+	l.record(ctx, source, transport, server, tool, args, start, result, nil, true)
+}
+
+func (l *Log) record(ctx context.Context, source, transport, server, tool string, sent map[string]interface{}, start time.Time, result *mcp.ToolResult, err error, cached bool) {
+	if l == nil {
+		return
+	}
+
+	args := sent
+	written, expandEnv := ctx.Value(recordedArgsKey{}).(map[string]interface{})
+	if expandEnv {
+		args = written
+	}
+
+	entry := Entry{
+		Time:       start,
+		Server:     server,
+		Tool:       tool,
+		ArgsHash:   HashArgs(args),
+		ExpandEnv:  expandEnv,
+		DurationMS: time.Since(start).Milliseconds(),
+		Transport:  transport,
+		Source:     source,
+		Exit:       ExitOK,
+		Cached:     cached,
+	}
+	if expandEnv {
+		entry.SentHash = HashArgs(sent)
+	}
+	if l.includeArgs {
+		entry.Args = redact.Members(args)
+		entry.Redacted = redactedArgs(args, entry.Args)
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		entry.Exit, entry.Error = ExitCancelled, err.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		entry.Exit, entry.Error = ExitTimeout, err.Error()
+	case err != nil:
+		entry.Exit, entry.Error = ExitError, err.Error()
+	case result != nil && result.IsError:
+		entry.Exit, entry.IsError = ExitToolError, true
+	}
+
+	if err := l.append(entry); err != nil {
+		logging.Debug("failed to write audit entry", "path", l.path, "error", err)
+	}
+}
+
+// redactedArgs returns the names of the arguments, in order, whose recorded
+// values differ from those given because secrets in them were redacted
+func redactedArgs(given, recorded map[string]interface{}) []string {
+	var names []string
+	for name, value := range given {
+		if !reflect.DeepEqual(value, recorded[name]) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// append writes the entry as one line, rotating the log first if it is full.
+// The line goes out in a single O_APPEND write, so lines from concurrent
+// processes don't interleave.
+func (l *Log) append(entry Entry) error {
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+	entry.ID = id
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if info, err := os.Stat(l.path); err == nil && info.Size()+int64(len(data)) > l.maxSize {
+		l.rotate()
+	}
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rotate shifts the current log to .1, .1 to .2 and so on, dropping the
+// oldest
+func (l *Log) rotate() {
+	for i := keptFiles; i > 1; i-- {
+		_ = os.Rename(rotatedPath(l.path, i-1), rotatedPath(l.path, i))
+	}
+	_ = os.Rename(l.path, rotatedPath(l.path, 1))
+}
+
+// rotatedPath is the path of the nth rotated log
+func rotatedPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// Read returns the entries in the log at path and its rotated logs, oldest
+// first. Lines that don't parse, such as one cut short by a crash, are
+// skipped.
+func Read(path string) ([]Entry, error) {
+	var entries []Entry
+	for i := keptFiles; i >= 0; i-- {
+		file := path
+		if i > 0 {
+			file = rotatedPath(path, i)
+		}
+		fileEntries, err := readFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+	return entries, nil
+}
+
+// readFile reads the entries of one log file
+func readFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20) // Full arguments can be long
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Find returns the entry with the given ID, or one whose ID starts with it
+// if that is unambiguous
+func Find(entries []Entry, id string) (*Entry, error) {
+	var match *Entry
+	for i := range entries {
+		switch {
+		case entries[i].ID == id:
+			return &entries[i], nil
+		case id != "" && strings.HasPrefix(entries[i].ID, id):
+			if match != nil {
+				return nil, fmt.Errorf("audit entry ID %s is ambiguous", id)
+			}
+			match = &entries[i]
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("no audit entry %s", id)
+	}
+	return match, nil
+}
+
+// HashArgs returns a hash identifying the arguments. Object keys are
+// encoded sorted, so equal arguments always hash the same.
+func HashArgs(args map[string]interface{}) string {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	data, _ := json.Marshal(args)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// newID returns a random entry ID
+func newID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,260 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	log := New(path, nil)
+	args := map[string]interface{}{"query": "go"}
+	start := time.Now().Add(-1500 * time.Millisecond)
+
+	log.Record(context.Background(), SourceCall, "stdio", "search", "find", args, start, &mcp.ToolResult{}, nil)
+	log.Record(context.Background(), SourceDaemon, "http", "search", "find", args, start, &mcp.ToolResult{IsError: true}, nil)
+	log.Record(context.Background(), SourceJob, "http", "search", "find", nil, start, nil, context.Canceled)
+	log.Record(context.Background(), SourceSchedule, "http", "search", "find", nil, start, nil, errors.New("connection refused"))
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("read %d entries, want 4", len(entries))
+	}
+
+	first := entries[0]
+	if first.ID == "" || first.Server != "search" || first.Tool != "find" || first.Transport != "stdio" || first.Source != SourceCall {
+		t.Errorf("first entry = %+v", first)
+	}
+	if first.Args != nil {
+		t.Errorf("arguments recorded without includeArgs: %v", first.Args)
+	}
+	if first.ArgsHash != HashArgs(args) {
+		t.Errorf("ArgsHash = %s, want %s", first.ArgsHash, HashArgs(args))
+	}
+	if first.Duration() < time.Second {
+		t.Errorf("Duration = %s, want at least 1.5s", first.Duration())
+	}
+
+	for i, want := range []string{ExitOK, ExitToolError, ExitCancelled, ExitError} {
+		if entries[i].Exit != want {
+			t.Errorf("entry %d exit = %s, want %s", i, entries[i].Exit, want)
+		}
+	}
+	if !entries[1].IsError {
+		t.Error("a tool error should set isError")
+	}
+	if entries[3].Error != "connection refused" {
+		t.Errorf("error = %q", entries[3].Error)
+	}
+}
+
+func TestIncludeArgs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	args := map[string]interface{}{"query": "go", "page_token": "c2VjcmV0LWN1cnNvcg", "filter": map[string]interface{}{"api_key": "sk-live"}}
+	New(path, &config.AuditConfig{IncludeArgs: true}).Record(context.Background(), SourceCall, "stdio", "search", "find", args, time.Now(), &mcp.ToolResult{}, nil)
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Args["query"] != "go" {
+		t.Fatalf("entries = %+v, want the arguments recorded", entries)
+	}
+	if token, _ := entries[0].Args["page_token"].(string); !strings.HasPrefix(token, "[redacted") {
+		t.Errorf("page_token recorded as %q, want a fingerprint", token)
+	}
+	if got := strings.Join(entries[0].Redacted, ","); got != "filter,page_token" {
+		t.Errorf("redacted = %s, want the arguments holding secrets", got)
+	}
+}
+
+func TestRecordCached(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	log := New(path, nil)
+	log.Record(context.Background(), SourceCall, "stdio", "docs", "get-library-docs", nil, time.Now(), &mcp.ToolResult{}, nil)
+	log.RecordCached(context.Background(), SourceCall, "stdio", "docs", "get-library-docs", nil, time.Now(), &mcp.ToolResult{})
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Cached || !entries[1].Cached || entries[1].Exit != ExitOK {
+		t.Fatalf("entries = %+v, want only the second marked cached", entries)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	disabled := false
+	if log := New(filepath.Join(t.TempDir(), FileName), &config.AuditConfig{Enabled: &disabled}); log != nil {
+		t.Fatal("a disabled audit log should be nil")
+	}
+
+	// A nil log records nothing and doesn't panic
+	var log *Log
+	log.Record(context.Background(), SourceCall, "stdio", "search", "find", nil, time.Now(), nil, nil)
+}
+
+func TestRecordFailureIsIgnored(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", FileName)
+	New(path, nil).Record(context.Background(), SourceCall, "stdio", "search", "find", nil, time.Now(), nil, nil)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no log to be written, got %v", err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	log := New(path, nil)
+	log.maxSize = 1024
+
+	for i := 0; i < 40; i++ {
+		log.Record(context.Background(), SourceCall, "stdio", "search", "find", nil, time.Now(), &mcp.ToolResult{}, nil)
+	}
+
+	for _, file := range []string{path, rotatedPath(path, 1), rotatedPath(path, keptFiles)} {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", file, err)
+		}
+		if info.Size() > log.maxSize {
+			t.Errorf("%s is %d bytes, over the %d limit", file, info.Size(), log.maxSize)
+		}
+	}
+	if _, err := os.Stat(rotatedPath(path, keptFiles+1)); !os.IsNotExist(err) {
+		t.Errorf("only %d rotated logs should be kept", keptFiles)
+	}
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Time.Before(entries[i-1].Time) {
+			t.Fatal("entries should be read oldest first across rotated logs")
+		}
+	}
+}
+
+func TestConcurrentRecordsStayWholeLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	log := New(path, &config.AuditConfig{IncludeArgs: true})
+	args := map[string]interface{}{"text": strings.Repeat("x", 4096)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Record(context.Background(), SourceCall, "stdio", "search", "find", args, time.Now(), &mcp.ToolResult{}, nil)
+		}()
+	}
+	wg.Wait()
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 20 {
+		t.Fatalf("read %d whole entries, want 20", len(entries))
+	}
+}
+
+func TestReadSkipsBadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	content := `{"id":"a1","server":"search","tool":"find","exit":"ok"}
+not json
+{"id":"b2","server":"search","tool":"find","exit":"ok"}
+{"id":"c3","ser`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].ID != "a1" || entries[1].ID != "b2" {
+		t.Fatalf("entries = %+v, want a1 and b2", entries)
+	}
+}
+
+func TestReadMissingLog(t *testing.T) {
+	entries, err := Read(filepath.Join(t.TempDir(), FileName))
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Read = %v, %v; want no entries", entries, err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	entries := []Entry{{ID: "abc123"}, {ID: "abd456"}, {ID: "ff0000"}}
+
+	for _, tt := range []struct {
+		id      string
+		want    string
+		wantErr string
+	}{
+		{id: "abc123", want: "abc123"},
+		{id: "ff", want: "ff0000"},
+		{id: "ab", wantErr: "ambiguous"},
+		{id: "99", wantErr: "no audit entry"},
+		{id: "", wantErr: "no audit entry"},
+	} {
+		got, err := Find(entries, tt.id)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Find(%q) error = %v, want %q", tt.id, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got.ID != tt.want {
+			t.Errorf("Find(%q) = %v, %v; want %s", tt.id, got, err, tt.want)
+		}
+	}
+}
+
+func TestHashArgs(t *testing.T) {
+	a := map[string]interface{}{"b": 2, "a": 1}
+	b := map[string]interface{}{"a": 1, "b": 2}
+	if HashArgs(a) != HashArgs(b) {
+		t.Error("equal arguments should hash the same")
+	}
+	if HashArgs(a) == HashArgs(map[string]interface{}{"a": 1}) {
+		t.Error("different arguments should hash differently")
+	}
+	if HashArgs(nil) != HashArgs(map[string]interface{}{}) {
+		t.Error("no arguments should hash like empty arguments")
+	}
+}
+
+func TestRecordWrittenArgs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	written := map[string]interface{}{"cursor": "${PAGE_TOKEN}"}
+	sent := map[string]interface{}{"cursor": "secret-cursor"}
+
+	ctx := WithRecordedArgs(context.Background(), written)
+	New(path, &config.AuditConfig{IncludeArgs: true}).Record(ctx, SourceDaemon, "stdio", "search", "find", sent, time.Now(), &mcp.ToolResult{}, nil)
+
+	entries, err := Read(path)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("want one entry, got %d (%v)", len(entries), err)
+	}
+	entry := entries[0]
+	if !entry.ExpandEnv || entry.Args["cursor"] != "${PAGE_TOKEN}" || entry.ArgsHash != HashArgs(written) {
+		t.Errorf("entry should record the arguments as written: %+v", entry)
+	}
+	if entry.SentHash != HashArgs(sent) {
+		t.Errorf("sentHash = %q, want the hash of the arguments sent", entry.SentHash)
+	}
+}
@@ -0,0 +1,142 @@
+// Package caches is where the on-disk caches register themselves, so the
+// cache command can report on, clear and collect every one of them without
+// knowing which there are.
+package caches
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// Stats describes what a cache holds
+type Stats struct {
+	Path    string
+	Entries int
+	Size    int64     // In bytes, corrupt files included
+	Oldest  time.Time // Zero without entries
+	Newest  time.Time
+	Corrupt []string // Files that could not be read, which GC removes
+}
+
+// Cache is what a cache implements to be managed by the cache command
+type Cache interface {
+	// Stats describes the cache's content
+	Stats() (Stats, error)
+	// Clear removes the entries of serverName, or every entry if it is
+	// empty, and returns how many were removed
+	Clear(serverName string) (int, error)
+	// GC removes expired and corrupt entries and enforces the cache's size
+	// cap now, returning how many entries were removed
+	GC() (int, error)
+}
+
+// Opener opens a cache set up as cfg says
+type Opener func(cfg *config.Configuration) (Cache, error)
+
+var (
+	mutex      sync.Mutex
+	registered = make(map[string]Opener)
+)
+
+// Register makes a cache known to the cache command under name. Caches call
+// it from an init function.
+func Register(name string, open Opener) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	registered[name] = open
+}
+
+// Names returns the names of the registered caches, sorted
+func Names() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	names := make([]string, 0, len(registered))
+	for name := range registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open returns the cache registered as name, set up as cfg says
+func Open(name string, cfg *config.Configuration) (Cache, error) {
+	mutex.Lock()
+	open, ok := registered[name]
+	mutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown cache '%s' (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return open(cfg)
+}
+
+// Scan gathers the Stats of a cache keeping each entry as a JSON file under
+// dir. stamp returns when the entry in a file was stored, failing for files
+// that don't hold one; those are reported as corrupt.
+func Scan(dir string, stamp func(data []byte) (time.Time, error)) (Stats, error) {
+	stats := Stats{Path: dir}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // No cache yet, or removed concurrently
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		stats.Size += info.Size()
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			stats.Corrupt = append(stats.Corrupt, path)
+			return nil
+		}
+		stored, err := stamp(data)
+		if err != nil {
+			stats.Corrupt = append(stats.Corrupt, path)
+			return nil
+		}
+		stats.Entries++
+		if stats.Oldest.IsZero() || stored.Before(stats.Oldest) {
+			stats.Oldest = stored
+		}
+		if stored.After(stats.Newest) {
+			stats.Newest = stored
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return stats, nil
+}
+
+// Stamp returns a stamp function for Scan reading the time from the named
+// member of each file's JSON object
+func Stamp(member string) func(data []byte) (time.Time, error) {
+	return func(data []byte) (time.Time, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return time.Time{}, err
+		}
+		var stored time.Time
+		if err := json.Unmarshal(fields[member], &stored); err != nil {
+			return time.Time{}, fmt.Errorf("no %s time: %w", member, err)
+		}
+		return stored, nil
+	}
+}
@@ -0,0 +1,68 @@
+package caches
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestScanReportsCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"old.json":       `{"stored":"2026-01-01T00:00:00Z"}`,
+		"new.json":       `{"stored":"2026-03-01T00:00:00Z"}`,
+		"truncated.json": `{"stored":"2026-`,
+		"nostamp.json":   `{"tools":[]}`,
+		"notes.txt":      "not an entry",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := Scan(dir, Stamp("stored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 2 {
+		t.Errorf("Entries = %d, want 2", stats.Entries)
+	}
+	if want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC); !stats.Oldest.Equal(want) {
+		t.Errorf("Oldest = %v, want %v", stats.Oldest, want)
+	}
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC); !stats.Newest.Equal(want) {
+		t.Errorf("Newest = %v, want %v", stats.Newest, want)
+	}
+	if len(stats.Corrupt) != 2 {
+		t.Errorf("Corrupt = %v, want the truncated and unstamped files", stats.Corrupt)
+	}
+	if stats.Size == 0 {
+		t.Error("Size should count the files")
+	}
+}
+
+func TestScanMissingDirectory(t *testing.T) {
+	stats, err := Scan(filepath.Join(t.TempDir(), "absent"), Stamp("stored"))
+	if err != nil || stats.Entries != 0 || len(stats.Corrupt) != 0 {
+		t.Errorf("Scan = %+v, %v; want an empty cache", stats, err)
+	}
+}
+
+func TestOpenUnknownCache(t *testing.T) {
+	Register("test-cache", func(*config.Configuration) (Cache, error) { return nil, nil })
+	t.Cleanup(func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		delete(registered, "test-cache")
+	})
+
+	_, err := Open("tokens", &config.Configuration{})
+	if err == nil || !strings.Contains(err.Error(), "test-cache") {
+		t.Errorf("want an error listing the registered caches, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/session"
+)
+
+var sessionAdoptCmd = &cobra.Command{
+	Use:   "adopt [server-name] --pid <pid>",
+	Short: "Take over a server process a crashed session lost track of",
+	Long: `Record a running server process, such as one left behind by a crash, as the
+server's session and reattach to it, instead of starting a duplicate that
+fights it over its browser profile.
+
+Only processes that can be reached again can be adopted, such as local HTTP
+servers; a stdio server's pipes are gone with the command that started it.
+When reattaching is impossible, --terminate stops the process and its
+children instead.
+
+--scan lists processes that look like browser automation servers (Chrome with
+remote debugging, Playwright, Puppeteer) and that no session tracks, with
+their command lines, to choose the PID from.`,
+	Args: adoptArgs,
+	RunE: runSessionAdopt,
+}
+
+var (
+	adoptPID       int
+	adoptScan      bool
+	adoptTerminate bool
+)
+
+func init() {
+	sessionAdoptCmd.Flags().IntVar(&adoptPID, "pid", 0, "the process to adopt or terminate")
+	sessionAdoptCmd.Flags().BoolVar(&adoptScan, "scan", false, "list untracked processes that look like browser automation servers")
+	sessionAdoptCmd.Flags().BoolVar(&adoptTerminate, "terminate", false, "stop the process and its children instead of adopting it")
+	sessionAdoptCmd.MarkFlagsMutuallyExclusive("scan", "pid")
+	sessionAdoptCmd.MarkFlagsMutuallyExclusive("scan", "terminate")
+}
+
+// adoptArgs checks adopt's arguments: --scan takes none, adopting a server
+// name and terminating neither needs one
+func adoptArgs(cmd *cobra.Command, args []string) error {
+	switch {
+	case adoptScan:
+		return cobra.NoArgs(cmd, args)
+	case adoptPID <= 0:
+		return fmt.Errorf("give the process with --pid, or find it with --scan")
+	case adoptTerminate:
+		return cobra.MaximumNArgs(1)(cmd, args)
+	}
+	return cobra.ExactArgs(1)(cmd, args)
+}
+
+func runSessionAdopt(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true // Failures are about the process, not the usage
+	manager, err := getSessionManager()
+	if err != nil {
+		return fmt.Errorf("failed to create session manager: %w", err)
+	}
+
+	if adoptScan {
+		candidates, err := manager.OrphanCandidates()
+		if err != nil {
+			return err
+		}
+		writeOrphanCandidates(os.Stdout, candidates)
+		return nil
+	}
+
+	if adoptTerminate {
+		processInfo, err := manager.TerminateOrphan(adoptPID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Terminated process %d and its children: %s\n", processInfo.PID, processInfo.CmdLine)
+		return nil
+	}
+
+	serverName := args[0]
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	serverConfig, exists := cfg.GetServer(serverName)
+	if !exists {
+		return displayServerNotFoundError(serverName, cfg)
+	}
+
+	info, err := manager.AdoptProcess(serverName, serverConfig, adoptPID)
+	if err != nil {
+		return fmt.Errorf("%w\n\nRun 'mcp-cli-ent session adopt --pid %d --terminate' to stop the process instead", err, adoptPID)
+	}
+	fmt.Printf("Adopted process %d as the session of %s.\n", info.PID, serverName)
+	fmt.Printf("Status: %s\n", info.Status.String())
+	return nil
+}
+
+// writeOrphanCandidates lists the processes session adopt --scan found
+func writeOrphanCandidates(out io.Writer, candidates []*session.ProcessInfo) {
+	if len(candidates) == 0 {
+		fmt.Fprintln(out, "No untracked browser automation processes found.")
+		return
+	}
+	fmt.Fprintf(out, "Untracked browser automation processes (%d):\n", len(candidates))
+	for _, candidate := range candidates {
+		fmt.Fprintf(out, "  %d  %s\n", candidate.PID, candidate.CmdLine)
+	}
+	fmt.Fprintln(out, "\nAdopt one with 'mcp-cli-ent session adopt <server> --pid <pid>', or stop it with --terminate.")
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestSessionAdoptTerminate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the orphan is a Unix process")
+	}
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {"browser": {"command": "npx", "args": ["@playwright/mcp"]}}}`)
+	defer func() {
+		adoptPID, adoptTerminate = 0, false
+		for _, name := range []string{"pid", "terminate"} {
+			sessionAdoptCmd.Flags().Lookup(name).Changed = false
+		}
+	}()
+
+	orphan := exec.Command("sleep", "60")
+	if err := orphan.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = orphan.Process.Kill() }()
+	pid := strconv.Itoa(orphan.Process.Pid)
+
+	if _, err := runCLI(t, configPath, "session", "adopt", "browser"); err == nil || !strings.Contains(err.Error(), "--pid") {
+		t.Errorf("want adopting without --pid to ask for it, got %v", err)
+	}
+
+	// A stdio server cannot be reattached, so the error offers --terminate
+	_, err := runCLI(t, configPath, "session", "adopt", "browser", "--pid", pid)
+	if err == nil || !strings.Contains(err.Error(), "--pid "+pid+" --terminate") {
+		t.Fatalf("want adopting a stdio orphan to suggest --terminate, got %v", err)
+	}
+
+	stdout, err := runCLI(t, configPath, "session", "adopt", "--pid", pid, "--terminate")
+	if err != nil {
+		t.Fatalf("session adopt --terminate failed: %v", err)
+	}
+	if !strings.Contains(stdout, "Terminated process "+pid) || !strings.Contains(stdout, "sleep 60") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if err := orphan.Wait(); err == nil {
+		t.Error("the orphan should have been killed")
+	}
+}
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolcache"
+)
+
+// callArgFlags are the arguments given one at a time with call's --arg
+var callArgFlags []string
+
+// parseArgFlag splits a --arg value into its key and value
+func parseArgFlag(text string) (string, string, error) {
+	key, value, ok := strings.Cut(text, "=")
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid --arg '%s' (use key=value)", text)
+	}
+	return key, value, nil
+}
+
+// applyArgFlags sets the arguments given with --arg in arguments. A value
+// takes the type the tool's schema gives its property; for properties the
+// schema lacks, or without a schema, a value that is valid JSON is sent
+// decoded and any other as a string.
+func applyArgFlags(arguments map[string]interface{}, values []string, schema map[string]interface{}) error {
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, text := range values {
+		key, value, err := parseArgFlag(text)
+		if err != nil {
+			return err
+		}
+		prop, known := properties[key].(map[string]interface{})
+		if arguments[key], err = argValue(prop, known, value); err != nil {
+			return fmt.Errorf("invalid value for --arg %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// argValue converts a --arg value to the type of its property. Arrays are
+// given as JSON.
+func argValue(prop map[string]interface{}, known bool, text string) (interface{}, error) {
+	if !known {
+		var value interface{}
+		if json.Unmarshal([]byte(text), &value) == nil {
+			return value, nil
+		}
+		return text, nil
+	}
+	kind, _ := schemaKind(prop)
+	switch kind {
+	case kindString:
+		return text, nil
+	case kindArray:
+		kind = kindJSON
+	}
+	return parseItem(kind, text)
+}
+
+// cachedTools returns a server's tools from the tool cache, without those
+// the configuration hides. Completion only reads the cache, so it never
+// waits on a server.
+func cachedTools(cfg *config.Configuration, serverName string) []mcp.Tool {
+	serverConfig, ok := cfg.GetServer(serverName)
+	if !ok {
+		return nil
+	}
+	cache, err := toolcache.Default(cfg.ToolCacheDuration())
+	if err != nil {
+		return nil
+	}
+	tools, _ := cache.Get(serverName, serverConfig)
+	return client.VisibleTools(serverConfig, tools)
+}
+
+// callServerNames returns the servers a call with args goes to: the one it
+// names, or those --servers or --all-servers broadcast to
+func callServerNames(cfg *config.Configuration, args []string) []string {
+	switch {
+	case len(callServers) > 0:
+		return callServers
+	case callAllServers:
+		var names []string
+		for name := range cfg.GetEnabledServers() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	case len(args) > 0:
+		return []string{args[0]}
+	}
+	return nil
+}
+
+// cachedCallTool returns the cached definition of the tool a call with args
+// targets, or nil. A broadcast call takes it from the first server that
+// has the tool cached.
+func cachedCallTool(cfg *config.Configuration, args []string) *mcp.Tool {
+	toolIndex := 1
+	if len(callServers) > 0 || callAllServers {
+		toolIndex = 0
+	}
+	if len(args) <= toolIndex {
+		return nil
+	}
+	for _, serverName := range callServerNames(cfg, args) {
+		if tool := findTool(cachedTools(cfg, serverName), args[toolIndex]); tool != nil {
+			return tool
+		}
+	}
+	return nil
+}
+
+// callArgSchema returns the input schema the tool cache holds for the tool a
+// call with args targets, which types its --arg values, or nil
+func callArgSchema(cfg *config.Configuration, args []string) map[string]interface{} {
+	if noCache {
+		return nil
+	}
+	if tool := cachedCallTool(cfg, args); tool != nil {
+		return tool.InputSchema
+	}
+	return nil
+}
+
+// completeCall completes call's server name, then its tool name
+func completeCall(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	broadcasting := len(callServers) > 0 || callAllServers
+	switch {
+	case !broadcasting && len(args) == 0:
+		var completions []string
+		for name, serverConfig := range cfg.GetEnabledServers() {
+			completions = append(completions, completion(name, serverConfig.Description))
+		}
+		sort.Strings(completions)
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	case broadcasting && len(args) == 0, !broadcasting && len(args) == 1:
+		seen := make(map[string]bool)
+		var completions []string
+		for _, serverName := range callServerNames(cfg, args) {
+			for _, tool := range cachedTools(cfg, serverName) {
+				if !seen[tool.Name] {
+					seen[tool.Name] = true
+					completions = append(completions, completion(tool.Name, tool.Description))
+				}
+			}
+		}
+		sort.Strings(completions)
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeArgFlag completes --arg with the property names of the targeted
+// tool's input schema, and after "key=" with the property's values
+func completeArgFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	tool := cachedCallTool(cfg, args)
+	if tool == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	given, _ := cmd.Flags().GetStringArray("arg")
+	return argCompletions(tool.InputSchema, toComplete, given)
+}
+
+// argCompletions suggests --arg values for a tool with schema: "name="
+// for each property not given yet, described by its help, or once toComplete
+// names a property, "name=value" for each value its schema allows
+func argCompletions(schema map[string]interface{}, toComplete string, given []string) ([]string, cobra.ShellCompDirective) {
+	flags := schemaFlags(schema)
+	if key, prefix, ok := strings.Cut(toComplete, "="); ok {
+		var completions []string
+		for _, flag := range flags {
+			if flag.property != key {
+				continue
+			}
+			values := flag.enum
+			if flag.kind == kindBoolean {
+				values = []string{"true", "false"}
+			}
+			for _, value := range values {
+				if strings.HasPrefix(value, prefix) {
+					completions = append(completions, key+"="+value)
+				}
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	taken := make(map[string]bool)
+	for _, text := range given {
+		if key, _, err := parseArgFlag(text); err == nil {
+			taken[key] = true
+		}
+	}
+	var completions []string
+	for _, flag := range flags {
+		if !taken[flag.property] && strings.HasPrefix(flag.property, toComplete) {
+			completions = append(completions, completion(flag.property+"=", flag.usage()))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completion is a completion with its help text, kept to one line
+func completion(value, help string) string {
+	if help = strings.Join(strings.Fields(help), " "); help == "" {
+		return value
+	}
+	return value + "\t" + help
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolcache"
+)
+
+func TestArgCompletionsKeys(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		schema     string
+		toComplete string
+		given      []string
+		want       []string
+	}{
+		{
+			name: "required first, with descriptions",
+			schema: `{"type": "object", "required": ["query"], "properties": {
+				"limit": {"type": "integer", "description": "Most results"},
+				"query": {"type": "string", "description": "What to\nsearch for"}
+			}}`,
+			want: []string{"query=\t(required) What to search for", "limit=\tMost results"},
+		},
+		{
+			name:       "prefix",
+			schema:     `{"type": "object", "properties": {"libraryId": {"type": "string"}, "limit": {"type": "integer"}, "topic": {"type": "string"}}}`,
+			toComplete: "li",
+			want:       []string{"libraryId=", "limit="},
+		},
+		{
+			name:   "keys already given are skipped",
+			schema: `{"type": "object", "properties": {"a": {"type": "string"}, "b": {"type": "string"}}}`,
+			given:  []string{"a=1"},
+			want:   []string{"b="},
+		},
+		{
+			name:   "no properties",
+			schema: `{"type": "object"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, directive := argCompletions(parseSchema(t, tc.schema), tc.toComplete, tc.given)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("completions = %q, want %q", got, tc.want)
+			}
+			if directive&cobra.ShellCompDirectiveNoSpace == 0 {
+				t.Error("key completions should not add a space after '='")
+			}
+		})
+	}
+}
+
+func TestArgCompletionsValues(t *testing.T) {
+	schema := parseSchema(t, `{"type": "object", "properties": {
+		"format": {"type": "string", "enum": ["json", "text", "table"]},
+		"exact": {"type": "boolean"},
+		"query": {"type": "string"}
+	}}`)
+	for toComplete, want := range map[string][]string{
+		"format=":  {"format=json", "format=text", "format=table"},
+		"format=t": {"format=text", "format=table"},
+		"exact=":   {"exact=true", "exact=false"},
+		"query=":   nil,
+		"other=":   nil,
+	} {
+		got, directive := argCompletions(schema, toComplete, nil)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("argCompletions(%q) = %q, want %q", toComplete, got, want)
+		}
+		if directive&cobra.ShellCompDirectiveNoSpace != 0 {
+			t.Errorf("argCompletions(%q) should end the word", toComplete)
+		}
+	}
+}
+
+func TestApplyArgFlags(t *testing.T) {
+	schema := parseSchema(t, `{"type": "object", "properties": {
+		"query": {"type": "string"},
+		"limit": {"type": "integer"},
+		"exact": {"type": "boolean"},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	}}`)
+	arguments := map[string]interface{}{"query": "from JSON", "topic": "kept"}
+	err := applyArgFlags(arguments, []string{"query=42", "limit=5", "exact=true", `tags=["a","b"]`, "extra=3", "note=a=b"}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"query": "42",
+		"limit": int64(5),
+		"exact": true,
+		"tags":  []interface{}{"a", "b"},
+		"extra": float64(3),
+		"note":  "a=b",
+		"topic": "kept",
+	}
+	if !reflect.DeepEqual(arguments, want) {
+		t.Errorf("arguments = %#v, want %#v", arguments, want)
+	}
+
+	for _, bad := range []string{"limit=many", "novalue", "=x"} {
+		if err := applyArgFlags(map[string]interface{}{}, []string{bad}, schema); err == nil {
+			t.Errorf("--arg %s should fail", bad)
+		}
+	}
+}
+
+func TestCompleteArgFlagReadsCacheOnly(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {"docs": {"command": "docs-server", "description": "Library docs"}}}`)
+	cfgFile = configPath
+	t.Cleanup(func() { cfgFile = "" })
+
+	if got, _ := completeArgFlag(callToolCmd, []string{"docs", "search"}, ""); got != nil {
+		t.Errorf("with nothing cached, completions = %q, want none", got)
+	}
+
+	cache, err := toolcache.Default(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := []mcp.Tool{{
+		Name:        "search",
+		Description: "Search the docs",
+		InputSchema: parseSchema(t, `{"type": "object", "properties": {"query": {"type": "string"}}}`),
+	}}
+	if err := cache.Put("docs", config.ServerConfig{Command: "docs-server", Description: "Library docs"}, tools); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := completeCall(callToolCmd, nil, ""); !reflect.DeepEqual(got, []string{"docs\tLibrary docs"}) {
+		t.Errorf("server completions = %q", got)
+	}
+	if got, _ := completeCall(callToolCmd, []string{"docs"}, ""); !reflect.DeepEqual(got, []string{"search\tSearch the docs"}) {
+		t.Errorf("tool completions = %q", got)
+	}
+	if got, _ := completeArgFlag(callToolCmd, []string{"docs", "search"}, ""); !reflect.DeepEqual(got, []string{"query="}) {
+		t.Errorf("--arg completions = %q", got)
+	}
+}
+
+func TestCompleteCallSkipsHiddenTools(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {"docs": {"command": "docs-server", "disabledTools": ["delete_page"]}}}`)
+	cfgFile = configPath
+	t.Cleanup(func() { cfgFile = "" })
+
+	cache, err := toolcache.Default(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := []mcp.Tool{{Name: "search"}, {Name: "delete_page"}}
+	if err := cache.Put("docs", config.ServerConfig{Command: "docs-server", DisabledTools: []string{"delete_page"}}, tools); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := completeCall(callToolCmd, []string{"docs"}, ""); !reflect.DeepEqual(got, []string{"search"}) {
+		t.Errorf("tool completions = %q, want the hidden tool left out", got)
+	}
+}
@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/session"
+)
+
+// attachYes starts a session that is not running without asking
+var attachYes bool
+
+func init() {
+	sessionAttachCmd.Flags().BoolVarP(&attachYes, "yes", "y", false, "start the session without asking if it is not running")
+}
+
+// attachHelp lists what the session attach prompt understands
+const attachHelp = `Commands:
+  <tool> [json-arguments]  call a tool, e.g. navigate_page {"url": "https://example.com"}
+  tools                    list the server's tools
+  help                     show this help
+  exit                     detach (so does Ctrl-D); the session keeps running`
+
+// attachedSession is the persistent session a session attach prompt drives
+type attachedSession struct {
+	manager   *session.Manager
+	name      string
+	config    config.ServerConfig
+	session   session.Session
+	mcpClient mcp.MCPClient
+}
+
+// runSessionAttach binds an interactive prompt to a server's persistent
+// session, starting the session first if the user agrees
+func runSessionAttach(cmd *cobra.Command, args []string) error {
+	serverName := args[0]
+
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	serverConfig, exists := cfg.GetServer(serverName)
+	if !exists {
+		return displayServerNotFoundError(serverName, cfg)
+	}
+	if !serverConfig.IsEnabled() {
+		return serverDisabledError(serverName, serverConfig)
+	}
+	if session.DetectSessionType(serverConfig) == session.Stateless {
+		return fmt.Errorf("%s has no session to attach to: it is stateless, so each call connects anew", serverName)
+	}
+
+	manager, err := getSessionManager()
+	if err != nil {
+		return fmt.Errorf("failed to create session manager: %w", err)
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	if !sessionRunning(manager, serverName) && !attachYes {
+		confirmed, err := askYesNo(in, os.Stderr, fmt.Sprintf("Session %s is not running. Start it?", serverName), false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Fprintln(os.Stderr, "Not started.")
+			return nil
+		}
+	}
+
+	a := &attachedSession{manager: manager, name: serverName, config: serverConfig}
+	status := startStatus()
+	status.Phase("attaching to %s…", serverName)
+	err = a.connect()
+	status.Stop()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Attached to %s. Type help for commands, Ctrl-D to detach.\n", serverName)
+	return a.repl(commandContext(cmd), cfg, in, os.Stdout, os.Stderr)
+}
+
+// sessionRunning reports whether serverName has an active session, in this
+// process or recorded by another (the daemon, for brokered sessions)
+func sessionRunning(manager *session.Manager, serverName string) bool {
+	if sess, err := manager.GetSessionByName(serverName); err == nil {
+		return sess.Status() == session.Active
+	}
+	info, err := manager.GetFileStore().FindExistingSession(serverName)
+	return err == nil && info.Status == session.Active
+}
+
+// connect gets a client for the session, reattaching to or starting it, and
+// refuses sessions that fell back to stateless
+func (a *attachedSession) connect() error {
+	mcpClient, err := client.NewSessionAwareClientFactory(a.manager).CreateClient(a.name, a.config)
+	if err != nil {
+		return withServerHint(fmt.Errorf("failed to attach to session %s: %w", a.name, err))
+	}
+	aware, ok := mcpClient.(*client.SessionAwareClient)
+	if !ok || !aware.IsPersistent() {
+		_ = mcpClient.Close()
+		return fmt.Errorf("session %s could not start persistently, so there is nothing to attach to", a.name)
+	}
+	a.session, a.mcpClient = aware.GetSession(), mcpClient
+	return nil
+}
+
+// restart restarts the session and reconnects to it
+func (a *attachedSession) restart() error {
+	if err := a.session.Restart(); err != nil {
+		return withServerHint(fmt.Errorf("failed to restart session %s: %w", a.name, err))
+	}
+	return a.connect()
+}
+
+// repl reads commands from in until EOF or exit. Tool results go to out;
+// prompts, errors and everything else to errOut. When a failed call leaves
+// the session dead, the user is offered a restart.
+func (a *attachedSession) repl(ctx context.Context, cfg *config.Configuration, in *bufio.Reader, out, errOut io.Writer) error {
+	for {
+		fmt.Fprintf(errOut, "%s> ", a.name)
+		line, err := in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err == io.EOF && line == "" {
+			fmt.Fprintf(errOut, "\nDetached; session %s is still running.\n", a.name)
+			return nil
+		}
+
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+			continue
+		case "exit", "quit":
+			fmt.Fprintf(errOut, "Detached; session %s is still running.\n", a.name)
+			return nil
+		case "help":
+			fmt.Fprintln(errOut, attachHelp)
+			continue
+		case "tools":
+			err = a.listTools(ctx, out)
+		default:
+			err = a.call(ctx, cfg, line, out, errOut)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			continue
+		}
+
+		fmt.Fprintf(errOut, "Error: %v\n", err)
+		if a.session.Status() == session.Active {
+			continue
+		}
+		restart, askErr := askYesNo(in, errOut, fmt.Sprintf("Session %s is %s. Restart it?", a.name, a.session.Status()), true)
+		if askErr != nil {
+			return askErr
+		}
+		if !restart {
+			return fmt.Errorf("session %s is %s", a.name, a.session.Status())
+		}
+		if err := a.restart(); err != nil {
+			return err
+		}
+		fmt.Fprintf(errOut, "Restarted session %s.\n", a.name)
+	}
+}
+
+// listTools prints the session's tools, one line each
+func (a *attachedSession) listTools(ctx context.Context, out io.Writer) error {
+	tools, err := a.mcpClient.ListTools(ctx)
+	if err != nil {
+		return withServerHint(fmt.Errorf("failed to list tools: %w", err))
+	}
+	for _, tool := range tools {
+		if a.config.IsToolHidden(tool.Name) {
+			continue
+		}
+		if tool.Description != "" {
+			fmt.Fprintf(out, "  %s: %s\n", tool.Name, tool.Description)
+		} else {
+			fmt.Fprintf(out, "  %s\n", tool.Name)
+		}
+	}
+	return nil
+}
+
+// call runs a line of the form <tool> [json-arguments]
+func (a *attachedSession) call(ctx context.Context, cfg *config.Configuration, line string, out, errOut io.Writer) error {
+	toolName, rawArgs, _ := strings.Cut(line, " ")
+	arguments := make(map[string]interface{})
+	if rawArgs = strings.TrimSpace(rawArgs); rawArgs != "" {
+		if err := json.Unmarshal([]byte(rawArgs), &arguments); err != nil {
+			return fmt.Errorf("invalid JSON arguments: %w (use <tool> {\"name\": \"value\"})", err)
+		}
+	}
+	if !noToolDefaults {
+		arguments = a.config.ApplyToolDefaults(toolName, arguments)
+	}
+
+	status := startStatus()
+	defer status.Stop()
+	result, err := callTool(ctx, cfg, status, a.mcpClient, a.name, toolName, arguments, audit.SourceAttach)
+	if err != nil {
+		return err
+	}
+	renderToolResult(out, errOut, result, markdownFormatter())
+	return nil
+}
+
+// askYesNo asks a yes/no question on out and reads the answer from in. An
+// empty answer is def; no answer at all, at EOF, is no.
+func askYesNo(in *bufio.Reader, out io.Writer, question string, def bool) (bool, error) {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	fmt.Fprintf(out, "%s %s ", question, hint)
+
+	answer, err := in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return false, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
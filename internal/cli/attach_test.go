@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/session"
+)
+
+// attachClient echoes tool names until the crash tool breaks it for good
+type attachClient struct {
+	mcp.MCPClient // Unused methods panic
+
+	broken  atomic.Bool
+	crashed *atomic.Bool // The factory fails once after a crash
+}
+
+func (c *attachClient) Initialize(context.Context, *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	if c.broken.Load() {
+		return nil, errors.New("server gone")
+	}
+	return &mcp.InitializeResult{}, nil
+}
+
+func (c *attachClient) ListTools(context.Context) ([]mcp.Tool, error) {
+	if c.broken.Load() {
+		return nil, errors.New("server gone")
+	}
+	return []mcp.Tool{{Name: "echo", Description: "Says its name"}, {Name: "crash"}}, nil
+}
+
+func (c *attachClient) CallTool(_ context.Context, name string, _ map[string]interface{}) (*mcp.ToolResult, error) {
+	if name == "crash" {
+		c.broken.Store(true)
+		c.crashed.Store(true)
+	}
+	if c.broken.Load() {
+		return nil, errors.New("server gone")
+	}
+	return &mcp.ToolResult{Content: []interface{}{map[string]interface{}{"type": "text", "text": "said " + name}}}, nil
+}
+
+func (c *attachClient) Close() error { return nil }
+
+// attachTarget returns an attached session for a fake persistent server
+func attachTarget(t *testing.T) (*attachedSession, *config.Configuration, *atomic.Int32) {
+	t.Helper()
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+
+	var crashed atomic.Bool
+	var sessions atomic.Int32
+	factory := func(config.ServerConfig) (mcp.MCPClient, error) {
+		if crashed.CompareAndSwap(true, false) {
+			return nil, errors.New("server failed to start")
+		}
+		sessions.Add(1)
+		return &attachClient{crashed: &crashed}, nil
+	}
+	manager, err := session.NewManager(t.TempDir(), factory)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Close() })
+
+	serverConfig := config.ServerConfig{Command: "npx", Args: []string{"-y", "chrome-devtools-mcp@latest"}}
+	cfg := &config.Configuration{MCPServers: map[string]config.ServerConfig{"chrome-devtools": serverConfig}}
+	a := &attachedSession{manager: manager, name: "chrome-devtools", config: serverConfig}
+	if err := a.connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	return a, cfg, &sessions
+}
+
+func TestAttachREPL(t *testing.T) {
+	a, cfg, _ := attachTarget(t)
+	in := bufio.NewReader(strings.NewReader("help\ntools\n\necho {}\necho {\n"))
+	var out, errOut bytes.Buffer
+	if err := a.repl(context.Background(), cfg, in, &out, &errOut); err != nil {
+		t.Fatalf("repl failed: %v", err)
+	}
+
+	if got := out.String(); got != "  echo: Says its name\n  crash\nsaid echo\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+	for _, want := range []string{"Commands:", "Error: invalid JSON arguments", "Detached; session chrome-devtools is still running."} {
+		if !strings.Contains(errOut.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, errOut.String())
+		}
+	}
+	if strings.Contains(errOut.String(), "Restart it?") {
+		t.Errorf("bad arguments must not offer a restart:\n%s", errOut.String())
+	}
+	if a.session.Status() != session.Active {
+		t.Errorf("detaching must leave the session running, got %s", a.session.Status())
+	}
+	if info := a.session.(*session.PersistentSession).GetInfo(); info.ToolCallCount != 1 || info.LastTool != "echo" {
+		t.Errorf("expected the call recorded on the session, got %+v", info.SessionMetrics)
+	}
+}
+
+func TestAttachREPLOffersRestart(t *testing.T) {
+	a, cfg, sessions := attachTarget(t)
+	in := bufio.NewReader(strings.NewReader("crash\ny\necho\nexit\n"))
+	var out, errOut bytes.Buffer
+	if err := a.repl(context.Background(), cfg, in, &out, &errOut); err != nil {
+		t.Fatalf("repl failed: %v\n%s", err, errOut.String())
+	}
+
+	if !strings.Contains(errOut.String(), "Restart it? [Y/n]") || !strings.Contains(errOut.String(), "Restarted session chrome-devtools.") {
+		t.Errorf("expected a restart to be offered and made:\n%s", errOut.String())
+	}
+	if out.String() != "said echo\n" {
+		t.Errorf("expected the call after the restart to work, got %q", out.String())
+	}
+	if sessions.Load() != 2 {
+		t.Errorf("expected a second server start, got %d", sessions.Load())
+	}
+}
+
+func TestAttachREPLDeclinedRestart(t *testing.T) {
+	a, cfg, _ := attachTarget(t)
+	in := bufio.NewReader(strings.NewReader("crash\nn\n"))
+	var out, errOut bytes.Buffer
+	err := a.repl(context.Background(), cfg, in, &out, &errOut)
+	if err == nil || !strings.Contains(err.Error(), "session chrome-devtools is") {
+		t.Errorf("expected the dead session reported, got %v", err)
+	}
+}
+
+func TestAskYesNo(t *testing.T) {
+	tests := []struct {
+		input string
+		def   bool
+		want  bool
+	}{
+		{"y\n", false, true},
+		{"YES\n", false, true},
+		{"n\n", true, false},
+		{"\n", true, true},
+		{"\n", false, false},
+		{"", true, false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		got, err := askYesNo(bufio.NewReader(strings.NewReader(tt.input)), &out, "Go?", tt.def)
+		if err != nil {
+			t.Fatalf("askYesNo(%q) failed: %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Errorf("askYesNo(%q, %v) = %v, want %v", tt.input, tt.def, got, tt.want)
+		}
+	}
+}
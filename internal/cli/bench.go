@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench <server> <tool> [arguments]",
+	Short: "Measure a tool's latency by calling it repeatedly",
+	Long: `Call a tool --n times over one client, or the server's persistent session,
+and report the latency percentiles, errors and throughput. Starting the
+server is not measured unless --include-startup is given, which reports the
+time to the first result separately. --warmup calls are made first and not
+counted. An interrupt stops the run and prints what was measured so far.`,
+	Args: cobra.RangeArgs(2, 3),
+	RunE: runBench,
+}
+
+var (
+	benchCalls          int
+	benchConcurrency    int
+	benchWarmup         int
+	benchIncludeStartup bool
+	benchJSON           bool
+)
+
+func init() {
+	benchCmd.Flags().IntVar(&benchCalls, "n", 50, "number of measured calls")
+	benchCmd.Flags().IntVar(&benchConcurrency, "concurrency", 1, "calls in flight at once")
+	benchCmd.Flags().IntVar(&benchWarmup, "warmup", 3, "calls made before measuring")
+	benchCmd.Flags().BoolVar(&benchIncludeStartup, "include-startup", false, "also measure starting the client up to its first result")
+	benchCmd.Flags().BoolVar(&benchJSON, "json", false, "print the report as JSON")
+}
+
+// benchReport summarises a bench run. Latencies are of the calls that
+// succeeded; failed calls count as errors.
+type benchReport struct {
+	Server      string  `json:"server"`
+	Tool        string  `json:"tool"`
+	Calls       int     `json:"calls"` // Measured calls that finished
+	Errors      int     `json:"errors"`
+	Concurrency int     `json:"concurrency"`
+	Warmup      int     `json:"warmup"`
+	StartupMS   float64 `json:"startupMs,omitempty"` // With --include-startup
+	MinMS       float64 `json:"minMs"`
+	P50MS       float64 `json:"p50Ms"`
+	P90MS       float64 `json:"p90Ms"`
+	P99MS       float64 `json:"p99Ms"`
+	MaxMS       float64 `json:"maxMs"`
+	Throughput  float64 `json:"throughput"` // Finished calls per second
+	Interrupted bool    `json:"interrupted,omitempty"`
+}
+
+// summarise fills in the latency and throughput figures from the durations
+// of the successful calls, elapsed being how long the measured calls took
+func (r *benchReport) summarise(latencies []time.Duration, elapsed time.Duration) {
+	if elapsed > 0 {
+		r.Throughput = float64(r.Calls) / elapsed.Seconds()
+	}
+	if len(latencies) == 0 {
+		return
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	r.MinMS = milliseconds(sorted[0])
+	r.P50MS = milliseconds(percentile(sorted, 50))
+	r.P90MS = milliseconds(percentile(sorted, 90))
+	r.P99MS = milliseconds(percentile(sorted, 99))
+	r.MaxMS = milliseconds(sorted[len(sorted)-1])
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// benchToolCalls makes n calls of the tool over mcpClient, concurrency at a
+// time, until they are done or ctx is cancelled. It returns the latencies
+// of the successful calls and how many failed; calls cut short by the
+// cancellation count as neither.
+func benchToolCalls(ctx context.Context, mcpClient mcp.MCPClient, record func(time.Time, *mcp.ToolResult, error), toolName string, arguments map[string]interface{}, n, concurrency int) ([]time.Duration, int) {
+	var mu sync.Mutex
+	var latencies []time.Duration
+	failures := 0
+
+	next := make(chan struct{})
+	go func() {
+		defer close(next)
+		for i := 0; i < n; i++ {
+			select {
+			case next <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range next {
+				start := time.Now()
+				result, err := mcpClient.CallTool(ctx, toolName, arguments)
+				latency := time.Since(start)
+				record(start, result, err)
+				if err != nil && ctx.Err() != nil && errors.Is(err, context.Canceled) {
+					continue
+				}
+
+				mu.Lock()
+				if err != nil || result.IsError {
+					failures++
+				} else {
+					latencies = append(latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return latencies, failures
+}
+
+// writeBenchText prints the report for people
+func writeBenchText(out io.Writer, r benchReport) {
+	fmt.Fprintf(out, "%s/%s: %d call(s), concurrency %d, %d warmup\n", r.Server, r.Tool, r.Calls, r.Concurrency, r.Warmup)
+	if r.Interrupted {
+		fmt.Fprintln(out, "interrupted: partial results")
+	}
+	if r.StartupMS > 0 {
+		fmt.Fprintf(out, "startup:    %.1fms (to the first result)\n", r.StartupMS)
+	}
+	if r.Calls > r.Errors {
+		fmt.Fprintf(out, "latency:    min %.1fms  p50 %.1fms  p90 %.1fms  p99 %.1fms  max %.1fms\n", r.MinMS, r.P50MS, r.P90MS, r.P99MS, r.MaxMS)
+	}
+	fmt.Fprintf(out, "errors:     %d\n", r.Errors)
+	fmt.Fprintf(out, "throughput: %.1f calls/s\n", r.Throughput)
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return err
+	}
+	switch {
+	case benchCalls < 1:
+		return fmt.Errorf("--n must be at least 1")
+	case benchConcurrency < 1:
+		return fmt.Errorf("--concurrency must be at least 1")
+	case benchWarmup < 0:
+		return fmt.Errorf("--warmup cannot be negative")
+	}
+
+	serverName, toolName := args[0], args[1]
+	arguments := make(map[string]interface{})
+	if len(args) >= 3 {
+		if err := json.Unmarshal([]byte(args[2]), &arguments); err != nil {
+			return fmt.Errorf("invalid JSON arguments: %w", err)
+		}
+	}
+	serverConfig, exists := cfg.GetServer(serverName)
+	if !exists {
+		return displayServerNotFoundError(serverName, cfg)
+	}
+	if !serverConfig.IsEnabled() {
+		return serverDisabledError(serverName, serverConfig)
+	}
+	arguments = serverConfig.ApplyToolDefaults(toolName, arguments)
+
+	ctx := commandContext(cmd)
+	log := auditLog(cfg)
+	report := benchReport{Server: serverName, Tool: toolName, Concurrency: benchConcurrency, Warmup: benchWarmup}
+
+	status := startStatus()
+	defer status.Stop()
+
+	status.Phase("starting %s…", serverName)
+	started := time.Now()
+	mcpClient, err := daemon.NewSmartClient().CreateClient(serverName, serverConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer func() { _ = mcpClient.Close() }()
+	// Measure the server, never the daemon's result cache
+	if daemonClient, ok := mcpClient.(*daemon.DaemonMCPClient); ok {
+		daemonClient.BypassResultCache()
+	}
+	record := func(start time.Time, result *mcp.ToolResult, err error) {
+		recordCall(ctx, log, audit.SourceBench, mcpClient, serverName, serverConfig, toolName, arguments, start, result, err)
+	}
+
+	// The first call pays for connecting, so it is the startup measurement
+	// or the first warmup call
+	warmup := benchWarmup
+	if benchIncludeStartup || warmup > 0 {
+		status.Phase("warming up…")
+		start := time.Now()
+		result, err := mcpClient.CallTool(ctx, toolName, arguments)
+		record(start, result, err)
+		if err != nil {
+			return withServerHint(fmt.Errorf("failed to call tool: %w", err))
+		}
+		if benchIncludeStartup {
+			report.StartupMS = milliseconds(time.Since(started))
+		} else {
+			warmup--
+		}
+	}
+	for i := 0; i < warmup && ctx.Err() == nil; i++ {
+		start := time.Now()
+		result, err := mcpClient.CallTool(ctx, toolName, arguments)
+		record(start, result, err)
+	}
+
+	status.Phase("calling %s %d time(s)…", toolName, benchCalls)
+	start := time.Now()
+	latencies, failures := benchToolCalls(ctx, mcpClient, record, toolName, arguments, benchCalls, benchConcurrency)
+	elapsed := time.Since(start)
+	status.Stop()
+
+	report.Calls, report.Errors = len(latencies)+failures, failures
+	report.Interrupted = ctx.Err() != nil
+	report.summarise(latencies, elapsed)
+
+	if benchJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		writeBenchText(os.Stdout, report)
+	}
+	return ctx.Err()
+}
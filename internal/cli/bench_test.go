@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+func TestBenchReportSummarise(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	report := benchReport{Calls: 102, Errors: 2}
+	report.summarise(latencies, 2*time.Second)
+
+	want := benchReport{Calls: 102, Errors: 2, MinMS: 1, P50MS: 50, P90MS: 90, P99MS: 99, MaxMS: 100, Throughput: 51}
+	if report != want {
+		t.Errorf("report = %+v, want %+v", report, want)
+	}
+
+	// Without successful calls only the throughput is known
+	report = benchReport{Calls: 3, Errors: 3}
+	report.summarise(nil, time.Second)
+	if report.Throughput != 3 || report.MaxMS != 0 {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestBenchToolCalls(t *testing.T) {
+	var running, peak, closed atomic.Int32
+	server := &fakeServer{text: "ok", delay: 5 * time.Millisecond, running: &running, peak: &peak, closed: &closed}
+	var recorded atomic.Int32
+	record := func(time.Time, *mcp.ToolResult, error) { recorded.Add(1) }
+
+	latencies, failures := benchToolCalls(context.Background(), server, record, "echo", nil, 12, 3)
+	if len(latencies) != 12 || failures != 0 || recorded.Load() != 12 {
+		t.Errorf("got %d latencies, %d failures, %d recorded; want 12, 0, 12", len(latencies), failures, recorded.Load())
+	}
+	if peak.Load() != 3 {
+		t.Errorf("peak concurrency = %d, want 3", peak.Load())
+	}
+	for _, latency := range latencies {
+		if latency < 5*time.Millisecond {
+			t.Errorf("latency %s is shorter than the call", latency)
+		}
+	}
+
+	server.err = errors.New("rate limited")
+	if latencies, failures := benchToolCalls(context.Background(), server, record, "echo", nil, 4, 2); len(latencies) != 0 || failures != 4 {
+		t.Errorf("got %d latencies and %d failures, want 0 and 4", len(latencies), failures)
+	}
+}
+
+func TestBenchToolCallsStopsWhenInterrupted(t *testing.T) {
+	var running, peak, closed atomic.Int32
+	server := &fakeServer{text: "ok", delay: time.Hour, running: &running, peak: &peak, closed: &closed}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Calls cut short by the interrupt are neither latencies nor failures
+		if latencies, failures := benchToolCalls(ctx, server, func(time.Time, *mcp.ToolResult, error) {}, "echo", nil, 50, 4); len(latencies) != 0 || failures != 0 {
+			t.Errorf("got %d latencies and %d failures, want none", len(latencies), failures)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("bench did not stop when interrupted")
+	}
+}
+
+func TestBenchBypassesDaemonResultCache(t *testing.T) {
+	dir := t.TempDir()
+	configDir := filepath.Join(dir, "config")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// A stand-in daemon that counts the calls allowed to use its cache
+	var calls, cacheable atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			NoCache bool `json:"noCache"`
+		}
+		if strings.Contains(r.URL.Path, "/call-tool/") {
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			calls.Add(1)
+			if !req.NoCache {
+				cacheable.Add(1)
+			}
+		}
+		_, _ = w.Write([]byte(`{"success": true, "data": {"content": [{"type": "text", "text": "pong"}]}}`))
+	}))
+	t.Cleanup(server.Close)
+	writeTestFile(t, filepath.Join(configDir, "daemon.pid"), strconv.Itoa(os.Getpid()))
+	writeTestFile(t, filepath.Join(configDir, "daemon.endpoint"), strings.TrimPrefix(server.URL, "http://"))
+	writeTestFile(t, filepath.Join(configDir, "mcp_servers.json"), `{"mcpServers": {"ping": {"command": "/nonexistent/ping-server"}}}`)
+
+	// The process's daemon client is bound to the data directory it first
+	// saw, so the CLI runs in a process of its own
+	cmd := cliProcess(t, dir, configDir, "--quiet --use-daemon bench ping ping --n 3 --warmup 1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("bench failed: %v\n%s", err, out)
+	}
+	if calls.Load() != 4 || cacheable.Load() != 0 {
+		t.Errorf("got %d calls through the daemon, %d of them allowed to use its result cache; want 4 and 0", calls.Load(), cacheable.Load())
+	}
+}
@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/serve"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolcache"
+)
+
+// maxBroadcastCalls bounds how many servers a broadcast call, or a listing of
+// every server's resources, talks to at once
+const maxBroadcastCalls = 4
+
+// Outcomes of one server's part in a broadcast call
+const (
+	broadcastOK      = "ok"
+	broadcastFailed  = "failed"
+	broadcastSkipped = "skipped"
+)
+
+// broadcastResult is one server's outcome of a broadcast call
+type broadcastResult struct {
+	Server string          `json:"-"`
+	Status string          `json:"status"` // broadcastOK, broadcastFailed or broadcastSkipped
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+
+	result *mcp.ToolResult
+}
+
+// broadcastServers resolves --servers or --all-servers to the servers to
+// call, in the order given or by name
+func broadcastServers(cfg *config.Configuration, names []string, all bool) ([]string, error) {
+	if all {
+		enabled := cfg.GetEnabledServers()
+		servers := make([]string, 0, len(enabled))
+		for name := range enabled {
+			servers = append(servers, name)
+		}
+		sort.Strings(servers)
+		if len(servers) == 0 {
+			return nil, fmt.Errorf("no enabled servers")
+		}
+		return servers, nil
+	}
+
+	var servers []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		serverConfig, exists := cfg.GetServer(name)
+		if !exists {
+			return nil, serverNotFoundError(name, cfg)
+		}
+		if !serverConfig.IsEnabled() {
+			return nil, serverDisabledError(name, serverConfig)
+		}
+		servers = append(servers, name)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("--servers names no servers")
+	}
+	return servers, nil
+}
+
+// broadcastCall calls the tool on every server concurrently, at most
+// maxBroadcastCalls at a time. Each server gets its own client, so its
+// configured timeout applies to it alone. Servers without the tool are
+// skipped. Results come back in the order of servers.
+func broadcastCall(ctx context.Context, cfg *config.Configuration, servers []string, newClient serve.ClientFactory, cache *toolcache.Cache, auditLog *audit.Log, toolName string, arguments map[string]interface{}) []broadcastResult {
+	results := make([]broadcastResult, len(servers))
+	eachServer(servers, func(i int, serverName string) {
+		serverConfig, _ := cfg.GetServer(serverName)
+		results[i] = callOnServer(ctx, serverName, serverConfig, newClient, cache, auditLog, toolName, arguments)
+	})
+	return results
+}
+
+// eachServer runs fn for every server concurrently, at most
+// maxBroadcastCalls at a time, and waits for them all. fn gets the
+// server's index in servers.
+func eachServer(servers []string, fn func(i int, serverName string)) {
+	slots := make(chan struct{}, maxBroadcastCalls)
+
+	var wg sync.WaitGroup
+	for i, serverName := range servers {
+		wg.Add(1)
+		go func(i int, serverName string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			fn(i, serverName)
+		}(i, serverName)
+	}
+	wg.Wait()
+}
+
+// callOnServer is one server's part of a broadcast call
+func callOnServer(ctx context.Context, serverName string, serverConfig config.ServerConfig, newClient serve.ClientFactory, cache *toolcache.Cache, auditLog *audit.Log, toolName string, arguments map[string]interface{}) broadcastResult {
+	result := broadcastResult{Server: serverName}
+	fail := func(err error) broadcastResult {
+		result.Status, result.Error = broadcastFailed, err.Error()
+		return result
+	}
+
+	if ctx.Err() != nil {
+		return fail(ctx.Err())
+	}
+	mcpClient, err := newClient(serverName, serverConfig)
+	if err != nil {
+		return fail(fmt.Errorf("failed to create client: %w", err))
+	}
+	defer func() { _ = mcpClient.Close() }()
+
+	// A cached tool list saves asking the server
+	tools, cached := cache.Get(serverName, serverConfig)
+	if !cached || refreshCache {
+		if tools, err = mcpClient.ListTools(ctx); err != nil {
+			return fail(err)
+		}
+		cacheTools(cache, serverName, serverConfig, tools)
+	}
+	if !hasTool(tools, toolName) {
+		result.Status, result.Error = broadcastSkipped, fmt.Sprintf("no tool '%s'", toolName)
+		return result
+	}
+
+	if !noToolDefaults {
+		arguments = serverConfig.ApplyToolDefaults(toolName, arguments)
+	}
+	start := time.Now()
+	toolResult, err := mcpClient.CallTool(ctx, toolName, arguments)
+	recordCall(ctx, auditLog, audit.SourceBroadcast, mcpClient, serverName, serverConfig, toolName, arguments, start, toolResult, err)
+	if err != nil {
+		return fail(fmt.Errorf("failed to call tool: %w", err))
+	}
+
+	result.Status, result.result = broadcastOK, toolResult
+	if toolResult.IsError {
+		result.Status, result.Error = broadcastFailed, "the tool reported a failure"
+	}
+	if result.Result = toolResult.Raw; len(result.Result) == 0 {
+		result.Result, _ = json.Marshal(toolResult)
+	}
+	return result
+}
+
+// hasTool reports whether tools include one named name
+func hasTool(tools []mcp.Tool, name string) bool {
+	for _, tool := range tools {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBroadcastText writes each server's result, or why there is none,
+// under a header naming the server. A non-nil format is applied to text
+// blocks.
+func writeBroadcastText(out io.Writer, results []broadcastResult, format func(string) string) {
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		if result.Status == broadcastOK {
+			fmt.Fprintf(out, "=== %s ===\n", result.Server)
+		} else {
+			fmt.Fprintf(out, "=== %s (%s) ===\n", result.Server, result.Status)
+		}
+		if result.result != nil {
+			renderToolResult(out, out, result.result, format) // Flags a failure reported by the tool
+		} else {
+			fmt.Fprintln(out, result.Error)
+		}
+	}
+}
+
+// writeBroadcastJSON writes the results as a JSON object keyed by server
+func writeBroadcastJSON(out io.Writer, results []broadcastResult) error {
+	byServer := make(map[string]broadcastResult, len(results))
+	for _, result := range results {
+		byServer[result.Server] = result
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(byServer)
+}
+
+// broadcastFailures returns the servers whose calls failed. Skipped servers
+// took no part in the call.
+func broadcastFailures(results []broadcastResult) *serverFailures {
+	called := 0
+	for _, result := range results {
+		if result.Status != broadcastSkipped {
+			called++
+		}
+	}
+	failures := newServerFailures(called)
+	for _, result := range results {
+		if result.Status == broadcastFailed {
+			failures.add(result.Server, errors.New(result.Error))
+		}
+	}
+	return failures
+}
+
+// broadcastError returns the call's error under policy, a --fail-on-error
+// value. Calling no server at all is always an error.
+func broadcastError(results []broadcastResult, toolName string, policy string) error {
+	failures := broadcastFailures(results)
+	if failures.total == 0 {
+		return fmt.Errorf("no server has tool '%s'", toolName)
+	}
+	return failures.check(policy, "the call")
+}
+
+// runBroadcastCall is call with --servers or --all-servers: args are the tool
+// and its optional arguments
+func runBroadcastCall(cmd *cobra.Command, cfg *config.Configuration, args []string) error {
+	if callRaw || callExtract != "" {
+		return fmt.Errorf("--raw and --extract cannot be combined with --servers or --all-servers")
+	}
+	policy := callFailOn
+	if callBestEffort {
+		policy = failOnNone
+	}
+	if err := checkFailOnError(policy); err != nil {
+		return err
+	}
+	servers, err := broadcastServers(cfg, callServers, callAllServers)
+	if err != nil {
+		return err
+	}
+
+	toolName := args[0]
+	arguments := make(map[string]interface{})
+	if len(args) >= 2 {
+		if err := json.Unmarshal([]byte(args[1]), &arguments); err != nil {
+			return fmt.Errorf("invalid JSON arguments: %w", err)
+		}
+	}
+	if arguments == nil { // "null"
+		arguments = make(map[string]interface{})
+	}
+	if err := applyArgFlags(arguments, callArgFlags, callArgSchema(cfg, args)); err != nil {
+		return err
+	}
+	ctx := commandContext(cmd)
+	if callExpandEnv {
+		if ctx, arguments, err = expandArgumentsEnv(ctx, cfg, arguments); err != nil {
+			return err
+		}
+	}
+
+	status := startStatus()
+	defer status.Stop()
+	status.Phase("calling %s on %d server(s)…", toolName, len(servers))
+	results := broadcastCall(ctx, cfg, servers, daemon.NewSmartClient().CreateClient, toolCache(cfg), auditLog(cfg), toolName, arguments)
+	status.Stop()
+
+	if callOutput == outputJSON {
+		if err := writeBroadcastJSON(os.Stdout, results); err != nil {
+			return err
+		}
+	} else {
+		writeBroadcastText(os.Stdout, results, markdownFormatter())
+	}
+	cmd.SilenceUsage = true // The results above explain any failure
+	broadcastFailures(results).write(os.Stderr)
+	return broadcastError(results, toolName, policy)
+}
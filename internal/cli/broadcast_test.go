@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/serve"
+)
+
+// fakeServer answers a broadcast call with a canned result, counting how many
+// servers are being called at once
+type fakeServer struct {
+	mcp.MCPClient // Unused methods panic
+
+	tools   []string
+	text    string
+	err     error
+	delay   time.Duration
+	closed  *atomic.Int32
+	running *atomic.Int32
+	peak    *atomic.Int32
+}
+
+func (f *fakeServer) ListTools(context.Context) ([]mcp.Tool, error) {
+	var tools []mcp.Tool
+	for _, name := range f.tools {
+		tools = append(tools, mcp.Tool{Name: name})
+	}
+	return tools, nil
+}
+
+func (f *fakeServer) CallTool(ctx context.Context, _ string, _ map[string]interface{}) (*mcp.ToolResult, error) {
+	running := f.running.Add(1)
+	defer f.running.Add(-1)
+	for {
+		peak := f.peak.Load()
+		if running <= peak || f.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &mcp.ToolResult{Content: []interface{}{map[string]interface{}{"type": "text", "text": f.text}}}, nil
+}
+
+func (f *fakeServer) Close() error {
+	f.closed.Add(1)
+	return nil
+}
+
+// fakeServers builds a configuration and client factory for the fakes
+func fakeServers(servers map[string]*fakeServer) (*config.Configuration, serve.ClientFactory, *atomic.Int32, *atomic.Int32) {
+	var closed, running, peak atomic.Int32
+	cfg := &config.Configuration{MCPServers: map[string]config.ServerConfig{}}
+	for name, server := range servers {
+		server.closed, server.running, server.peak = &closed, &running, &peak
+		cfg.MCPServers[name] = config.ServerConfig{Command: "fake-" + name}
+	}
+	newClient := func(name string, _ config.ServerConfig) (mcp.MCPClient, error) {
+		return servers[name], nil
+	}
+	return cfg, newClient, &closed, &peak
+}
+
+func TestBroadcastCall(t *testing.T) {
+	cfg, newClient, closed, _ := fakeServers(map[string]*fakeServer{
+		"docs-a": {tools: []string{"search"}, text: "from a"},
+		"docs-b": {tools: []string{"search"}, text: "from b"},
+		"other":  {tools: []string{"fetch"}},
+		"broken": {tools: []string{"search"}, err: errors.New("connection reset")},
+	})
+	servers, err := broadcastServers(cfg, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := broadcastCall(context.Background(), cfg, servers, newClient, nil, nil, "search", nil)
+	want := map[string]string{"broken": broadcastFailed, "docs-a": broadcastOK, "docs-b": broadcastOK, "other": broadcastSkipped}
+	for i, result := range results {
+		if result.Server != servers[i] {
+			t.Errorf("result %d is for %s, want %s", i, result.Server, servers[i])
+		}
+		if result.Status != want[result.Server] {
+			t.Errorf("%s: status %s, want %s", result.Server, result.Status, want[result.Server])
+		}
+	}
+	if closed.Load() != 4 {
+		t.Errorf("%d clients closed, want 4", closed.Load())
+	}
+
+	var out bytes.Buffer
+	writeBroadcastText(&out, results, nil)
+	for _, line := range []string{"=== docs-a ===\nfrom a", "=== docs-b ===\nfrom b", "=== other (skipped) ===\nno tool 'search'", "=== broken (failed) ===\nfailed to call tool: connection reset"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output lacks %q:\n%s", line, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := writeBroadcastJSON(&out, results); err != nil {
+		t.Fatal(err)
+	}
+	var byServer map[string]struct {
+		Status string          `json:"status"`
+		Result *mcp.ToolResult `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &byServer); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+	}
+	if a := byServer["docs-a"]; a.Status != broadcastOK || a.Result == nil || a.Result.Blocks()[0].Text != "from a" {
+		t.Errorf("docs-a = %+v", a)
+	}
+	if b := byServer["broken"]; b.Status != broadcastFailed || b.Error == "" || b.Result != nil {
+		t.Errorf("broken = %+v", b)
+	}
+
+	if err := broadcastError(results, "search", failOnAny); err == nil || !strings.Contains(err.Error(), "1 of 3") {
+		t.Errorf("want the failure reported, got %v", err)
+	}
+	for _, policy := range []string{failOnAll, failOnNone} {
+		if err := broadcastError(results, "search", policy); err != nil {
+			t.Errorf("--fail-on-error %s should ignore one failure, got %v", policy, err)
+		}
+	}
+	out.Reset()
+	broadcastFailures(results).write(&out)
+	if want := "Failed on 1 of 3 servers:\n  broken: failed to call tool: connection reset\n"; out.String() != want {
+		t.Errorf("failures =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestBroadcastCallIsBounded(t *testing.T) {
+	servers := make(map[string]*fakeServer)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		servers[name] = &fakeServer{tools: []string{"search"}, delay: 20 * time.Millisecond}
+	}
+	cfg, newClient, _, peak := fakeServers(servers)
+	names, _ := broadcastServers(cfg, nil, true)
+
+	broadcastCall(context.Background(), cfg, names, newClient, nil, nil, "search", nil)
+	if got := peak.Load(); got < 2 || got > maxBroadcastCalls {
+		t.Errorf("%d calls ran at once, want between 2 and %d", got, maxBroadcastCalls)
+	}
+}
+
+func TestBroadcastErrorWhenNoServerHasTool(t *testing.T) {
+	results := []broadcastResult{{Server: "a", Status: broadcastSkipped}, {Server: "b", Status: broadcastSkipped}}
+	if err := broadcastError(results, "search", failOnNone); err == nil {
+		t.Error("calling no server should fail even with --fail-on-error none")
+	}
+}
+
+func TestBroadcastServers(t *testing.T) {
+	disabled := false
+	cfg := &config.Configuration{MCPServers: map[string]config.ServerConfig{
+		"a":   {Command: "a"},
+		"b":   {Command: "b"},
+		"off": {Command: "off", Enabled: &disabled},
+	}}
+
+	servers, err := broadcastServers(cfg, []string{"b", "a", "b"}, false)
+	if err != nil || strings.Join(servers, ",") != "b,a" {
+		t.Errorf("servers = %v, %v; want b,a", servers, err)
+	}
+	if servers, _ := broadcastServers(cfg, nil, true); strings.Join(servers, ",") != "a,b" {
+		t.Errorf("all servers = %v, want the enabled ones by name", servers)
+	}
+	for _, names := range [][]string{{"a", "missing"}, {"off"}} {
+		if _, err := broadcastServers(cfg, names, false); err == nil {
+			t.Errorf("%v should be refused", names)
+		}
+	}
+}
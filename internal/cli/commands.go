@@ -2,25 +2,38 @@ package cli
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/caches"
 	"github.com/mcp-cli-ent/mcp-cli/internal/client"
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
 	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/jsonpath"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/render"
+	"github.com/mcp-cli-ent/mcp-cli/internal/resultcache"
+	"github.com/mcp-cli-ent/mcp-cli/internal/serve"
+	"github.com/mcp-cli-ent/mcp-cli/internal/serverstate"
 	"github.com/mcp-cli-ent/mcp-cli/internal/session"
+	"github.com/mcp-cli-ent/mcp-cli/internal/suggest"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolcache"
+	"github.com/mcp-cli-ent/mcp-cli/pkg/mcpclient"
 	"github.com/mcp-cli-ent/mcp-cli/pkg/version"
 )
 
@@ -32,27 +45,82 @@ var (
 	VerboseMode           bool
 )
 
+// configDirOnce guards the first-run creation of the config directory
+var configDirOnce sync.Once
+
 var listServersCmd = &cobra.Command{
 	Use:   "list-servers",
 	Short: "List enabled MCP servers",
 	Long: `List enabled MCP servers with their status, type, and configuration details.
-Use --all to include disabled servers.`,
+Use --all to include disabled servers.
+
+--state adds what runs: each server's saved session and whether its process is
+alive, the daemon's session of it and the latest activity. It reads the
+sessions directory and asks the daemon, if it runs, but changes neither.
+--output json prints the same as a versioned JSON report.`,
 	RunE: runListServers,
 }
 
+var (
+	listServersState  bool
+	listServersOutput string
+)
+
 func init() {
 	// Add local flags for list-servers command
 	listServersCmd.Flags().BoolVar(&showAllServers, "all", false, "show disabled servers as well")
+	listServersCmd.Flags().StringVarP(&serverGroup, "group", "g", "", "only show servers carrying this tag")
+	listServersCmd.Flags().BoolVar(&listServersState, "state", false, "show each server's session and daemon state")
+	listServersCmd.Flags().StringVar(&listServersOutput, "output", outputText, "output format: text or json")
+	listToolsCmd.Flags().StringVarP(&serverGroup, "group", "g", "", "only list tools from servers carrying this tag")
+	listToolsCmd.Flags().BoolVar(&showHiddenTools, "all", false, "show tools hidden by disabledTools as well")
+	listToolsCmd.Flags().BoolVar(&snapshotTools, "snapshot", false, "save the server's live tool list as the snapshot to compare against")
+	listToolsCmd.Flags().BoolVar(&diffTools, "diff", false, "compare the server's live tool list with its snapshot; fails if they differ")
+	listToolsCmd.Flags().StringVar(&snapshotFile, "snapshot-file", "", "snapshot file for --snapshot and --diff (default is one per server in the data directory)")
+	listToolsCmd.MarkFlagsMutuallyExclusive("snapshot", "diff")
+	addFailOnErrorFlag(listToolsCmd, &listToolsFailOn, failOnNone)
 }
 
-var showAllServers bool
+var (
+	showAllServers  bool
+	showHiddenTools bool
+	serverGroup     string
+	snapshotTools   bool
+	diffTools       bool
+	snapshotFile    string
+	listToolsFailOn = failOnNone // Also the root command's, which has no flag
+)
+
+// serverDisabledError explains why a server can't be used
+func serverDisabledError(serverName string, serverConfig config.ServerConfig) error {
+	if missing := serverConfig.MissingRequired(); len(missing) > 0 {
+		return fmt.Errorf("server '%s' is missing required environment variables: %s", serverName, strings.Join(missing, ", "))
+	}
+	return fmt.Errorf("server '%s' is disabled", serverName)
+}
+
+// applyGroupFilter restricts cfg to the servers tagged with --group, if given
+func applyGroupFilter(cfg *config.Configuration) (*config.Configuration, error) {
+	if serverGroup == "" {
+		return cfg, nil
+	}
+	return cfg.WithGroup(serverGroup)
+}
 
 var listToolsCmd = &cobra.Command{
 	Use:   "list-tools [server-name]",
 	Short: "List tools from MCP servers",
 	Long: `List available tools from MCP servers.
 If server-name is provided, lists tools from that server only.
-If omitted, lists tools from all enabled servers.`,
+If omitted, lists tools from all enabled servers. Servers that fail are
+reported on stderr and, unless --fail-on-error says otherwise, do not fail
+the command.
+
+With --snapshot, the server's live tool list (names, descriptions and input
+schemas) is saved as a snapshot. --diff compares the live list with the
+snapshot, prints the added and removed tools and the changed fields, and
+fails if there are any, so CI can gate on it. Snapshots are kept in the
+data directory unless --snapshot-file names one, e.g. in a repository.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runListTools,
 }
@@ -62,9 +130,81 @@ var callToolCmd = &cobra.Command{
 	Aliases: []string{"call-tool"},
 	Short:   "Call a specific tool on an MCP server",
 	Long: `Call a specific tool on an MCP server with optional JSON arguments.
-Arguments should be a valid JSON string, e.g., '{"libraryName": "react"}'`,
-	Args: cobra.RangeArgs(2, 3),
-	RunE: runCallTool,
+Arguments should be a valid JSON string, e.g., '{"libraryName": "react"}'
+Give them one at a time with --arg key=value instead, or as well; --arg wins.
+Values are typed by the tool's cached schema, and shell completion offers its
+argument names and enum values.
+
+With --servers a,b or --all-servers, omit the server name: the tool is called
+on each server at once and the results are printed per server. Servers without
+the tool are skipped. Failed calls are also reported on stderr, and the command
+fails if any call fails, unless --fail-on-error says otherwise.
+
+With --async, the call runs in the daemon as a background job and its ID is
+printed; follow it with the job commands.
+
+With --print-request, nothing is called: the JSON-RPC request the call would
+send, with defaults merged and --arg values typed, is printed as it would go on
+the wire.
+
+With --expand-env, environment variables in the arguments' string values,
+however deeply nested, are expanded as in the configuration: $VAR, ${VAR},
+${VAR:-default}, ${VAR:?message} and %VAR%, with $$ and %% for literal signs.
+The audit log records the arguments as written unless the audit section's
+expandedArgs allows the expanded ones.`,
+	Args:              callArgs,
+	ValidArgsFunction: completeCall,
+	RunE:              runCallTool,
+}
+
+var (
+	noToolDefaults bool
+	callOutput     string
+	callRaw        bool
+	callRender     bool
+	callExtract    string
+	callServers    []string
+	callAllServers bool
+	callBestEffort bool
+	callFailOn     string
+	callAsync      bool
+	callSaveDir    string
+	callPrintReq   bool
+	callExpandEnv  bool
+)
+
+// callArgs checks call's arguments, which name no server when broadcasting
+func callArgs(cmd *cobra.Command, args []string) error {
+	if len(callServers) > 0 || callAllServers {
+		return cobra.RangeArgs(1, 2)(cmd, args)
+	}
+	return cobra.RangeArgs(2, 3)(cmd, args)
+}
+
+func init() {
+	callToolCmd.Flags().BoolVar(&noToolDefaults, "no-defaults", false, "don't merge the server's toolDefaults into the arguments")
+	callToolCmd.Flags().StringVar(&callOutput, "output", outputText, "result format: text or json")
+	callToolCmd.Flags().BoolVar(&callRaw, "raw", false, "print the full result JSON exactly as the server sent it")
+	callToolCmd.Flags().BoolVar(&callRender, "render", false, "render Markdown in text results for the terminal")
+	callToolCmd.Flags().StringVar(&callSaveDir, "save-dir", "", "save image, audio and binary resource contents of text results to files in this directory")
+	callToolCmd.Flags().StringVar(&callExtract, "extract", "", "print only the value at this path (e.g. items[0].id) of the structured or JSON result")
+	callToolCmd.Flags().StringSliceVar(&callServers, "servers", nil, "call the tool on each of these servers (comma-separated)")
+	callToolCmd.Flags().BoolVar(&callAllServers, "all-servers", false, "call the tool on every enabled server")
+	callToolCmd.Flags().BoolVar(&callBestEffort, "best-effort", false, "with --servers or --all-servers, succeed even if some calls fail (same as --fail-on-error none)")
+	addFailOnErrorFlag(callToolCmd, &callFailOn, failOnAny)
+	callToolCmd.MarkFlagsMutuallyExclusive("best-effort", "fail-on-error")
+	callToolCmd.Flags().BoolVar(&callAsync, "async", false, "run the call in the daemon as a background job and print its ID")
+	callToolCmd.Flags().StringArrayVar(&callArgFlags, "arg", nil, "set one argument as key=value (repeatable)")
+	callToolCmd.MarkFlagsMutuallyExclusive("servers", "all-servers")
+	callToolCmd.MarkFlagsMutuallyExclusive("async", "servers")
+	callToolCmd.MarkFlagsMutuallyExclusive("async", "all-servers")
+	callToolCmd.Flags().BoolVar(&callPrintReq, "print-request", false, "print the JSON-RPC request the call would send instead of calling the tool")
+	callToolCmd.MarkFlagsMutuallyExclusive("print-request", "async")
+	callToolCmd.MarkFlagsMutuallyExclusive("print-request", "servers")
+	callToolCmd.MarkFlagsMutuallyExclusive("print-request", "all-servers")
+	callToolCmd.Flags().BoolVar(&callExpandEnv, "expand-env", false, "expand environment variables in the arguments' string values")
+	callToolCmd.MarkFlagsMutuallyExclusive("expand-env", "async")
+	_ = callToolCmd.RegisterFlagCompletionFunc("arg", completeArgFlag)
 }
 
 var requestInputCmd = &cobra.Command{
@@ -103,6 +243,42 @@ If filename is omitted, creates 'mcp_servers.json' in the current directory.`,
 	RunE: runCreateConfig,
 }
 
+var validateConfigCmd = &cobra.Command{
+	Use:   "validate-config",
+	Short: "Validate the configuration file",
+	Long: `Load and validate the configuration file, including its .env file.
+Reports servers whose headers, env or args still reference unset environment variables.`,
+	Args: cobra.NoArgs,
+	RunE: runValidateConfig,
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve all enabled MCP servers as one MCP server",
+	Long: `Speak MCP on stdin/stdout, exposing the tools, resources and prompts of every
+enabled server. Tools and prompts are named <server>__<name>; servers that fail
+to start are left out. Persistent servers are reached through the daemon.
+
+With --http, serve the same over Streamable HTTP at /mcp instead. Each client
+session gets its own server connections, up to "serve": {"maxSessions": n}
+sessions (default 100); set "serve": {"token": "..."} in the main
+configuration to require a bearer token.
+
+The "exports" section of the configuration limits what is served. With
+--watch-config (or "configWatch": true), edits to it apply without a restart.`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVarP(&serverGroup, "group", "g", "", "only serve servers carrying this tag")
+	serveCmd.Flags().StringVar(&serveHTTPAddr, "http", "", "serve over Streamable HTTP on this address (e.g. :9000) instead of stdio")
+	serveCmd.Flags().BoolVar(&serveWatchConfig, "watch-config", false, "apply edits to the exports section without restarting")
+}
+
+var serveHTTPAddr string
+var serveWatchConfig bool
+
 // Session management commands
 var sessionCmd = &cobra.Command{
 	Use:   "session",
@@ -155,19 +331,38 @@ This stops and then starts the session, useful for troubleshooting or recovering
 	RunE: runSessionRestart,
 }
 
+var sessionResetStatsCmd = &cobra.Command{
+	Use:   "reset-stats <server-name>",
+	Short: "Reset a session's call, restart and error counts",
+	Long: `Reset the metrics of a session: tool call and error counts, restarts and the
+last error. Nothing else resets them, so they show how often a flapping server
+failed. The daemon's session of the server, if it has one, is reset too.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSessionResetStats,
+}
+
 var sessionCleanupCmd = &cobra.Command{
 	Use:   "cleanup",
 	Short: "Clean up dead or expired sessions",
 	Long: `Clean up dead, expired, or unhealthy sessions.
-This removes sessions that are no longer responding or have exceeded their idle timeout.`,
+This removes sessions that are no longer responding or have exceeded their idle timeout.
+Saved session records are kept according to each server's session.retention and
+session.errorRetention settings; --older-than overrides them for every server.`,
 	RunE: runSessionCleanup,
 }
 
 var sessionAttachCmd = &cobra.Command{
 	Use:   "attach <server-name>",
-	Short: "Attach to an existing session",
-	Long: `Attach to an existing MCP server session.
-This attempts to reconnect to a previously started persistent session, allowing you to resume work with browser-based servers without restarting them.`,
+	Short: "Drive a persistent session from an interactive prompt",
+	Long: `Attach to a server's persistent session and call its tools from an interactive
+prompt, without resolving the configuration again for every call. Calls go
+through the session, reusing its server (the daemon's, when the daemon owns
+it) and counting as its activity. A session that is not running is started
+after asking; --yes skips the question.
+
+At the prompt, type a tool name followed by its JSON arguments, "tools" to
+list tools, or "help". Ctrl-D or "exit" detaches and leaves the session
+running. If a call leaves the session dead, you are offered a restart.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSessionAttach,
 }
@@ -197,10 +392,11 @@ This will terminate all persistent MCP server connections.`,
 }
 
 var daemonStatusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [--all]",
 	Short: "Show MCP daemon status",
-	Long:  `Display the current status of the MCP daemon, including active sessions and system information.`,
-	RunE:  runDaemonStatus,
+	Long: `Display the current status of the MCP daemon, including active sessions and system information.
+With --all, list every daemon instance found in the data directory.`,
+	RunE: runDaemonStatus,
 }
 
 var daemonRestartCmd = &cobra.Command{
@@ -217,23 +413,82 @@ var daemonLogsCmd = &cobra.Command{
 	RunE:  runDaemonLogs,
 }
 
+// Cache command and subcommands
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and manage the on-disk caches",
+	Long: `Listed tools are cached on disk per server, so repeated commands need not start
+the server. Entries expire after toolCacheTTL (default 10m) and are dropped when
+the server's configuration changes.
+
+Results of the tools a server's cacheTools names are cached too, for the TTL
+configured there; the least recently used go first once the cache outgrows
+resultCacheMaxMB (default 50).
+
+Files a cache cannot read are reported by 'cache status' and removed by
+'cache gc'.`,
+}
+
+var cacheStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show where each cache is and what it holds",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheStatus,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear [<cache>|all] [--server <name>]",
+	Short: "Remove cached entries, of one cache or all of them",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runCacheClear,
+}
+
+var cacheGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove expired and corrupt entries and enforce the size caps now",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheGC,
+}
+
+// Cache flags
+var cacheClearServer string
+
 // Daemon flags
 var daemonForeground bool
 var daemonLogsTail int
+var daemonWatchConfig bool
+
+// Session flags
+var sessionListDetail bool
+var sessionCleanupOlderThan string
 
 func init() {
 	// Add daemon command flags
 	daemonStartCmd.Flags().BoolVar(&daemonForeground, "foreground", false, "Run daemon in foreground instead of background")
+	daemonStartCmd.Flags().BoolVar(&daemonWatchConfig, "watch-config", false, "Reload the server configuration when it changes")
 	daemonLogsCmd.Flags().IntVar(&daemonLogsTail, "tail", 50, "Number of lines to show from the end of the log file")
+	daemonStatusCmd.Flags().BoolVar(&daemonStatusAll, "all", false, "Show every daemon instance in the data directory")
+	sessionListCmd.Flags().BoolVar(&sessionListDetail, "detail", false, "Show tool call metrics for each session")
+	cacheClearCmd.Flags().StringVar(&cacheClearServer, "server", "", "only clear what is cached for this server")
+	sessionCleanupCmd.Flags().StringVar(&sessionCleanupOlderThan, "older-than", "", "Remove sessions inactive longer than this (e.g. 12h, 7d), overriding per-server retention")
 
 	// Add list-tools command (flags are now global: --refresh, --clear-cache)
 	rootCmd.AddCommand(listServersCmd)
 	rootCmd.AddCommand(listToolsCmd)
+	rootCmd.AddCommand(listResourcesCmd)
 	rootCmd.AddCommand(callToolCmd)
+	rootCmd.AddCommand(toolCmd)
 	rootCmd.AddCommand(requestInputCmd)
 	rootCmd.AddCommand(createMessageCmd)
 	rootCmd.AddCommand(initializeCmd)
 	rootCmd.AddCommand(createConfigCmd)
+	rootCmd.AddCommand(validateConfigCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(exportToolsCmd)
+	rootCmd.AddCommand(selftestCmd)
+
+	configCmd.AddCommand(configMigrateRemoteCmd)
+	rootCmd.AddCommand(configCmd)
 
 	// Add session management commands
 	sessionCmd.AddCommand(sessionListCmd)
@@ -242,6 +497,8 @@ func init() {
 	sessionCmd.AddCommand(sessionAttachCmd)
 	sessionCmd.AddCommand(sessionStopCmd)
 	sessionCmd.AddCommand(sessionRestartCmd)
+	sessionCmd.AddCommand(sessionResetStatsCmd)
+	sessionCmd.AddCommand(sessionAdoptCmd)
 	sessionCmd.AddCommand(sessionCleanupCmd)
 	rootCmd.AddCommand(sessionCmd)
 
@@ -251,8 +508,41 @@ func init() {
 	daemonCmd.AddCommand(daemonStatusCmd)
 	daemonCmd.AddCommand(daemonRestartCmd)
 	daemonCmd.AddCommand(daemonLogsCmd)
+	daemonCmd.AddCommand(daemonInstallCmd)
+	daemonCmd.AddCommand(daemonUninstallCmd)
+	daemonConfigCmd.AddCommand(daemonConfigSetCmd)
+	daemonCmd.AddCommand(daemonConfigCmd)
 	rootCmd.AddCommand(daemonCmd)
 
+	cacheCmd.AddCommand(cacheStatusCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheGCCmd)
+	rootCmd.AddCommand(cacheCmd)
+
+	serverCmd.AddCommand(serverInfoCmd)
+	rootCmd.AddCommand(serverCmd)
+
+	jobCmd.AddCommand(jobStatusCmd)
+	jobCmd.AddCommand(jobResultCmd)
+	jobCmd.AddCommand(jobWaitCmd)
+	jobCmd.AddCommand(jobCancelCmd)
+	rootCmd.AddCommand(jobCmd)
+
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleAddCmd)
+	scheduleCmd.AddCommand(scheduleRemoveCmd)
+	scheduleCmd.AddCommand(scheduleRunNowCmd)
+	scheduleCmd.AddCommand(scheduleHistoryCmd)
+	rootCmd.AddCommand(scheduleCmd)
+
+	historyCmd.AddCommand(historyListCmd)
+	historyCmd.AddCommand(historyShowCmd)
+	historyCmd.AddCommand(historyRerunCmd)
+	rootCmd.AddCommand(historyCmd)
+
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(benchCmd)
+
 	// Add version command
 	versionCmd := &cobra.Command{
 		Use:   "version",
@@ -264,10 +554,12 @@ func init() {
 	rootCmd.AddCommand(versionCmd)
 }
 
-// displayServerNotFoundError shows available servers when a server name is not found
-func displayServerNotFoundError(serverName string, cfg *config.Configuration) {
+// displayServerNotFoundError shows available servers when a server name is
+// not found, and returns the error for the command to fail with
+func displayServerNotFoundError(serverName string, cfg *config.Configuration) error {
 	// Show error with available servers
-	fmt.Fprintf(os.Stderr, "Error: server '%s' not found in configuration\n\n", serverName)
+	err := serverNotFoundError(serverName, cfg)
+	fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
 
 	// Display available servers to help the agent
 	enabledServers := cfg.GetEnabledServers()
@@ -285,6 +577,13 @@ func displayServerNotFoundError(serverName string, cfg *config.Configuration) {
 		fmt.Fprintf(os.Stderr, "No enabled MCP servers found.\n")
 		fmt.Fprintf(os.Stderr, "💡 Run 'mcp-cli-ent create-config' to create a sample configuration\n")
 	}
+	return &reportedError{err: err}
+}
+
+// serverNotFoundError reports an unknown server, suggesting the configured
+// servers whose names are close to it
+func serverNotFoundError(serverName string, cfg *config.Configuration) error {
+	return mcp.Mark(suggest.Wrap(fmt.Errorf("server '%s' not found in configuration", serverName), serverName, cfg.GetServerNames()), mcp.ErrServerNotFound)
 }
 
 func runListServers(cmd *cobra.Command, args []string) error {
@@ -295,17 +594,50 @@ func runListServers(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if cfg, err = applyGroupFilter(cfg); err != nil {
+		return err
+	}
+	if listServersOutput != outputText && listServersOutput != outputJSON {
+		return fmt.Errorf("invalid --output '%s' (use %s or %s)", listServersOutput, outputText, outputJSON)
+	}
+
+	var report *serverstate.Report
+	if listServersState {
+		report = serverstate.Gather(commandContext(cmd), cfg, session.NewFileStore(session.DefaultSessionsDir()), daemon.SharedDaemonClient())
+	} else {
+		report = serverstate.FromConfig(cfg)
+	}
+	if listServersOutput == outputJSON {
+		shown := report.Servers[:0]
+		for _, server := range report.Servers {
+			if showAllServers || server.Enabled {
+				shown = append(shown, server)
+			}
+		}
+		report.Servers = shown
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	states := make(map[string]serverstate.Server, len(report.Servers))
+	for _, server := range report.Servers {
+		states[server.Name] = server
+	}
 
 	// Get server statuses
 	statuses := cfg.GetServerStatus()
 
 	// Filter servers based on --all flag
 	var filteredStatuses []config.ServerStatus
+	var unmetRequirements []string
 	for _, status := range statuses {
 		if showAllServers || status.Status == "enabled" {
 			filteredStatuses = append(filteredStatuses, status)
+		} else if server, _ := cfg.GetServer(status.Name); len(server.MissingRequired()) > 0 {
+			unmetRequirements = append(unmetRequirements, fmt.Sprintf("%s (%s)", status.Name, status.Reason))
 		}
 	}
+	sort.Strings(unmetRequirements)
 
 	if len(filteredStatuses) == 0 {
 		if showAllServers {
@@ -323,6 +655,13 @@ func runListServers(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Enabled MCP servers (%d):\n", len(filteredStatuses))
 	}
 
+	// Only show where servers come from when more than one file contributed
+	sources := make(map[string]bool)
+	for _, status := range filteredStatuses {
+		sources[status.Source] = true
+	}
+	showSources := len(sources) > 1
+
 	for _, status := range filteredStatuses {
 		statusIcon := "✓"
 		if status.Status == "disabled" {
@@ -347,14 +686,73 @@ func runListServers(cmd *cobra.Command, args []string) error {
 		var statusLabel string
 		if showAllServers {
 			statusLabel = fmt.Sprintf(" [%s]", status.Status)
+			if len(serverConfig.MissingRequired()) > 0 {
+				statusLabel = fmt.Sprintf(" [%s: %s]", status.Status, status.Reason)
+			}
+		}
+
+		if len(status.Tags) > 0 {
+			description += " | tags: " + strings.Join(status.Tags, ", ")
+		}
+
+		var sourceLabel string
+		if showSources {
+			sourceLabel = " | from " + status.Source
+		}
+
+		var stateLabel string
+		if listServersState {
+			stateLabel = serverStateLabel(states[status.Name], time.Now())
 		}
 
-		fmt.Printf("  %s %s%s%s%s | %s\n", statusIcon, status.Name, statusLabel, sessionInfo, description, status.Details)
+		fmt.Printf("  %s %s%s%s%s | %s%s%s\n", statusIcon, status.Name, statusLabel, sessionInfo, description, status.Details, sourceLabel, stateLabel)
+	}
+	if listServersState && !report.DaemonRunning {
+		fmt.Println("\nThe daemon is not running.")
+	} else if report.DaemonError != "" {
+		fmt.Printf("\nThe daemon's sessions could not be listed: %s\n", report.DaemonError)
+	}
+
+	// Servers hidden only because the environment is incomplete deserve a note
+	if len(unmetRequirements) > 0 {
+		fmt.Printf("\nDisabled by missing environment variables: %s\n", strings.Join(unmetRequirements, "; "))
 	}
 
 	return nil
 }
 
+// serverStateLabel describes a server's session and daemon state as the
+// columns list-servers --state adds
+func serverStateLabel(server serverstate.Server, now time.Time) string {
+	label := " | session: none"
+	if saved := server.Session; saved != nil {
+		label = " | session: " + saved.Status
+		switch {
+		case saved.Status != session.Active.String():
+		case saved.Live && saved.DaemonOwned:
+			label += ", in the daemon"
+		case saved.Live:
+			label += fmt.Sprintf(", live (pid %d)", saved.PID)
+		default:
+			label += ", dead"
+		}
+	}
+
+	if server.Daemon != nil {
+		label += " | daemon: " + server.Daemon.Status
+		if server.Daemon.ConfigOutdated {
+			label += " (config outdated)"
+		}
+	} else {
+		label += " | daemon: -"
+	}
+
+	if server.LastActivity != nil {
+		label += fmt.Sprintf(" | active %s ago", now.Sub(*server.LastActivity).Round(time.Second))
+	}
+	return label
+}
+
 func runListTools(cmd *cobra.Command, args []string) error {
 	// Initialize verbose mode to set environment variable
 	_ = isVerbose()
@@ -367,9 +765,15 @@ func runListTools(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	ctx := context.Background()
+	ctx := commandContext(cmd)
 
 	if len(args) == 0 {
+		if err := checkFailOnError(listToolsFailOn); err != nil {
+			return err
+		}
+		if snapshotTools || diffTools {
+			return fmt.Errorf("--snapshot and --diff need a server name")
+		}
 		// Show all tools from all servers with usage examples (same behavior as root command)
 		return showRootHelpWithServers(cmd)
 	} else {
@@ -377,73 +781,70 @@ func runListTools(cmd *cobra.Command, args []string) error {
 		serverName := args[0]
 		serverConfig, exists := cfg.GetServer(serverName)
 		if !exists {
-			displayServerNotFoundError(serverName, cfg)
-			return nil
+			return displayServerNotFoundError(serverName, cfg)
 		}
 
 		if !serverConfig.IsEnabled() {
-			return fmt.Errorf("server '%s' is disabled", serverName)
+			return serverDisabledError(serverName, serverConfig)
+		}
+		if serverGroup != "" && !serverConfig.HasTag(serverGroup) {
+			return fmt.Errorf("server '%s' is not in group '%s'", serverName, serverGroup)
 		}
 
-		return listToolsFromServer(ctx, serverName, serverConfig)
+		if snapshotTools || diffTools {
+			return runToolSnapshot(ctx, cmd, toolCache(cfg), serverName, serverConfig)
+		}
+		return listToolsFromServer(ctx, toolCache(cfg), serverName, serverConfig)
 	}
 }
 
-func listToolsFromServer(ctx context.Context, serverName string, serverConfig config.ServerConfig) error {
-	// Ensure verbose mode is set (called from session management)
-	_ = isVerbose()
+// serverTools returns a server's tools, from the cache unless it has none
+// or fresh is set. A fresh listing updates the cache.
+func serverTools(ctx context.Context, cache *toolcache.Cache, serverName string, serverConfig config.ServerConfig, fresh bool) ([]mcp.Tool, error) {
+	// A cached tool list saves starting the server
+	if tools, cached := cache.Get(serverName, serverConfig); cached && !fresh {
+		return tools, nil
+	}
 
-	// If clearCache is set, clear cache file before proceeding
-	if clearCache {
-		cachePath, err := GetCachePath()
-		if err == nil {
-			_ = os.Remove(cachePath)
-			fmt.Println("Cache cleared.")
-		}
+	// Create session-aware client factory
+	factory, err := getSessionAwareClientFactory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client factory: %w", err)
 	}
 
-	// Try to load from cache first (unless forced refresh or cache was cleared)
-	var tools []mcp.Tool
-	if !refreshCache && !clearCache {
-		cache, err := LoadToolsFromCache()
-		if err == nil && cache != nil {
-			if entry, ok := cache.Servers[serverName]; ok && time.Since(entry.LastUpdate) < CacheTTL {
-				tools = entry.Tools
-			}
-		}
+	status := startStatus()
+	defer status.Stop()
+
+	// Create session-aware client
+	status.Phase("starting %s…", serverName)
+	mcpClient, err := factory.CreateClient(serverName, serverConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
+	defer func() { _ = mcpClient.Close() }()
 
-	// If not in cache or forced refresh, fetch from server
-	if tools == nil {
-		// Create session-aware client factory
-		factory, err := getSessionAwareClientFactory()
-		if err != nil {
-			return fmt.Errorf("failed to create client factory: %w", err)
-		}
+	// List tools
+	status.Phase("listing tools…")
+	tools, err := mcpClient.ListTools(ctx)
+	status.Stop()
+	if err != nil {
+		return nil, withServerHint(fmt.Errorf("failed to list tools: %w", err))
+	}
+	cacheTools(cache, serverName, serverConfig, tools)
+	return tools, nil
+}
 
-		// Create session-aware client
-		mcpClient, err := factory.CreateClient(serverName, serverConfig)
-		if err != nil {
-			return fmt.Errorf("failed to create client: %w", err)
-		}
-		defer func() { _ = mcpClient.Close() }()
+func listToolsFromServer(ctx context.Context, cache *toolcache.Cache, serverName string, serverConfig config.ServerConfig) error {
+	// Ensure verbose mode is set (called from session management)
+	_ = isVerbose()
 
-		// List tools
-		tools, err = mcpClient.ListTools(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to list tools: %w", err)
-		}
+	tools, err := serverTools(ctx, cache, serverName, serverConfig, refreshCache)
+	if err != nil {
+		return err
+	}
 
-		// Update cache
-		cache, err := LoadToolsFromCache()
-		if err != nil || cache == nil {
-			cache = &ToolsCache{Servers: make(map[string]ToolsCacheEntry)}
-		}
-		cache.Servers[serverName] = ToolsCacheEntry{
-			Tools:      tools,
-			LastUpdate: time.Now(),
-		}
-		_ = SaveToolsToCache(cache)
+	if !showHiddenTools {
+		tools = client.VisibleTools(serverConfig, tools)
 	}
 
 	if len(tools) == 0 {
@@ -483,6 +884,9 @@ func listToolsFromServer(ctx context.Context, serverName string, serverConfig co
 				Description: tool.Description,
 				Params:      extractParamNames(tool.InputSchema),
 				Call:        buildCallString(serverName, tool.Name, BuildExampleArgs(&tool)),
+				Defaults:    serverConfig.DefaultsFor(tool.Name),
+				Hidden:      serverConfig.IsToolHidden(tool.Name),
+				Annotations: tool.Annotations,
 			}
 			if verbose {
 				jt.Schema = tool.InputSchema
@@ -500,7 +904,7 @@ func listToolsFromServer(ctx context.Context, serverName string, serverConfig co
 		fmt.Printf("%s - %s\n\n", serverName, serverConfig.Description)
 	}
 	fmt.Printf("Available tools (%d):\n", len(tools))
-	printToolsHuman(tools, serverName, verbose)
+	printToolsHuman(tools, serverName, serverConfig, verbose)
 	return nil
 }
 
@@ -513,6 +917,27 @@ func runCallTool(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if callOutput != outputText && callOutput != outputJSON {
+		return fmt.Errorf("invalid --output '%s' (use %s or %s)", callOutput, outputText, outputJSON)
+	}
+	if len(callServers) > 0 || callAllServers {
+		return runBroadcastCall(cmd, cfg, args)
+	}
+
+	if callAsync && (callRaw || callExtract != "") {
+		return fmt.Errorf("--raw and --extract cannot be combined with --async; use them with job result")
+	}
+
+	var extractPath jsonpath.Path
+	if callExtract != "" {
+		if callRaw || callOutput == outputJSON {
+			return fmt.Errorf("--extract cannot be combined with --raw or --output json")
+		}
+		if extractPath, err = jsonpath.Parse(callExtract); err != nil {
+			return err
+		}
+	}
+
 	serverName := args[0]
 	toolName := args[1]
 	var arguments map[string]interface{}
@@ -522,42 +947,200 @@ func runCallTool(cmd *cobra.Command, args []string) error {
 		if err := json.Unmarshal([]byte(args[2]), &arguments); err != nil {
 			return fmt.Errorf("invalid JSON arguments: %w", err)
 		}
-	} else {
-		// Initialize as empty object if no arguments provided
+	}
+	if arguments == nil {
+		// No arguments, or "null", call with an empty object
 		arguments = make(map[string]interface{})
 	}
 
 	// Get server configuration
 	serverConfig, exists := cfg.GetServer(serverName)
 	if !exists {
-		displayServerNotFoundError(serverName, cfg)
-		return nil
+		return displayServerNotFoundError(serverName, cfg)
 	}
 
 	if !serverConfig.IsEnabled() {
-		return fmt.Errorf("server '%s' is disabled", serverName)
+		return serverDisabledError(serverName, serverConfig)
+	}
+
+	if err := applyArgFlags(arguments, callArgFlags, callArgSchema(cfg, args)); err != nil {
+		return err
+	}
+
+	if serverConfig.IsToolHidden(toolName) {
+		fmt.Fprintf(os.Stderr, "Warning: tool '%s' is hidden by disabledTools on server '%s'\n", toolName, serverName)
+	}
+
+	ctx := commandContext(cmd)
+	if callExpandEnv {
+		if ctx, arguments, err = expandArgumentsEnv(ctx, cfg, arguments); err != nil {
+			return err
+		}
+	}
+
+	// Configured defaults fill in arguments the user didn't give
+	if !noToolDefaults {
+		arguments = serverConfig.ApplyToolDefaults(toolName, arguments)
+	}
+
+	if callPrintReq {
+		return printCallRequest(ctx, os.Stdout, toolName, arguments)
+	}
+	if callAsync {
+		return runAsyncCall(serverName, serverConfig, toolName, arguments)
+	}
+
+	result, err := callToolCached(ctx, cfg, serverName, serverConfig, toolName, arguments)
+	if err != nil {
+		return err
+	}
+
+	if callRaw {
+		return writeToolResultRaw(os.Stdout, result)
+	}
+	if callOutput == outputJSON {
+		return writeToolResultJSON(os.Stdout, result)
+	}
+	if callExtract != "" {
+		if result != nil && result.IsError {
+			renderToolResult(os.Stderr, os.Stderr, result, nil)
+			return fmt.Errorf("cannot extract '%s': the tool reported a failure", extractPath)
+		}
+		value, err := extractFromResult(result, extractPath)
+		if err != nil {
+			return err
+		}
+		return writeExtracted(os.Stdout, value)
+	}
+	renderToolResult(os.Stdout, os.Stderr, result, markdownFormatter())
+	return nil
+}
+
+// printCallRequest prints the tools/call request calling toolName sends,
+// byte for byte as the clients write it
+func printCallRequest(ctx context.Context, out io.Writer, toolName string, arguments map[string]interface{}) error {
+	data, err := mcp.MarshalRequest(mcp.NewCallToolRequest(ctx, toolName, arguments))
+	if err != nil {
+		return fmt.Errorf("failed to encode the request: %w", err)
+	}
+	fmt.Fprintln(out, string(data))
+	return nil
+}
+
+// callToolCached answers a call from the result cache when the server's
+// cacheTools covers the tool, and otherwise calls the server and caches the
+// result. A cached result saves starting the server at all.
+func callToolCached(ctx context.Context, cfg *config.Configuration, serverName string, serverConfig config.ServerConfig, toolName string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
+	var cache *resultcache.Cache
+	ttl := resultTTL(cfg, serverName, serverConfig, toolName)
+	if ttl > 0 {
+		cache = resultCache(cfg)
+	}
+	if hit, ok := cache.Get(serverName, serverConfig, toolName, arguments); ok {
+		if isVerbose() && !quiet {
+			fmt.Fprintf(os.Stderr, "[cache] %s result from %s ago (use --no-cache to call the server)\n", toolName, time.Since(hit.Stored).Round(time.Second))
+		}
+		auditLog(cfg).RecordCached(ctx, audit.SourceCall, audit.Transport(serverConfig), serverName, toolName, arguments, time.Now(), hit.Result)
+		return hit.Result, nil
 	}
 
 	// Create smart client that uses daemon when appropriate
 	smartClient := daemon.NewSmartClient()
 
+	status := startStatus()
+	defer status.Stop()
+
 	// Create client (will use daemon if persistent, direct connection otherwise)
+	status.Phase("starting %s…", serverName)
 	mcpClient, err := smartClient.CreateClient(serverName, serverConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
+		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
 	defer func() { _ = mcpClient.Close() }()
 
-	// Call tool
-	ctx := context.Background()
+	// The daemon keeps the same cache, so it caches what it calls itself
+	daemonClient, viaDaemon := mcpClient.(*daemon.DaemonMCPClient)
+	if viaDaemon && noCache {
+		daemonClient.BypassResultCache()
+	}
+	// On a terminal, progress the server reports through the daemon is live
+	if viaDaemon && !quiet && render.IsTerminal(os.Stderr) {
+		daemonClient.StreamProgress(func(event daemon.StreamEvent) {
+			showStreamEvent(status, toolName, event)
+		})
+	}
+
+	result, err := callTool(ctx, cfg, status, mcpClient, serverName, toolName, arguments, audit.SourceCall)
+	if err != nil {
+		return nil, err
+	}
+	if !viaDaemon || !daemonClient.ViaDaemon() { // Not when the call fell back to a direct client
+		if err := cache.Put(serverName, serverConfig, toolName, arguments, ttl, result); err != nil {
+			logging.Debug("failed to cache the result", "server", serverName, "tool", toolName, "error", err)
+		}
+	}
+	return result, nil
+}
+
+// callTool calls the tool, dropping the server's cached tool list if the
+// server announces new tools meanwhile. The call is recorded in the audit log
+// as coming from source.
+func callTool(ctx context.Context, cfg *config.Configuration, status *statusReporter, mcpClient mcp.MCPClient, serverName, toolName string, arguments map[string]interface{}, source string) (*mcp.ToolResult, error) {
+	var toolsChanged atomic.Bool
+	if source, ok := mcpClient.(mcp.NotificationSource); ok {
+		source.SetNotificationHandler(func(method string, _ json.RawMessage) {
+			if method == mcp.ToolsListChanged {
+				toolsChanged.Store(true)
+			}
+		})
+	}
+
+	status.Phase("calling %s…", toolName)
+	start := time.Now()
 	result, err := mcpClient.CallTool(ctx, toolName, arguments)
+	status.Stop()
+	serverConfig, _ := cfg.GetServer(serverName)
+	recordCall(ctx, auditLog(cfg), source, mcpClient, serverName, serverConfig, toolName, arguments, start, result, err)
+	if toolsChanged.Load() {
+		_ = toolCache(cfg).Invalidate(serverName)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to call tool: %w", err)
+		err = withServerHint(fmt.Errorf("failed to call tool: %w", err))
+		if ctx.Err() == nil {
+			err = suggestTool(ctx, cfg, mcpClient, serverName, toolName, err)
+		}
+		return nil, err
 	}
+	return result, nil
+}
 
-	// Handle result display with binary data detection
-	displayToolResult(result)
-	return nil
+// suggestTool marks err, the failure of calling toolName, as the tool not
+// being found when the server has no such tool, adding a did-you-mean hint.
+// The tool list comes from the cache, or else from mcpClient.
+func suggestTool(ctx context.Context, cfg *config.Configuration, mcpClient mcp.MCPClient, serverName, toolName string, err error) error {
+	serverConfig, _ := cfg.GetServer(serverName)
+	cache := toolCache(cfg)
+	tools, cached := cache.Get(serverName, serverConfig)
+	if !cached {
+		var listErr error
+		if tools, listErr = mcpClient.ListTools(ctx); listErr != nil {
+			return err
+		}
+		cacheTools(cache, serverName, serverConfig, tools)
+	}
+	if findTool(tools, toolName) != nil {
+		return err
+	}
+	return mcp.Mark(suggest.Wrap(err, toolName, toolNames(tools)), mcp.ErrToolNotFound)
+}
+
+// toolNames returns the names of tools
+func toolNames(tools []mcp.Tool) []string {
+	names := make([]string, len(tools))
+	for i := range tools {
+		names[i] = tools[i].Name
+	}
+	return names
 }
 
 func runCreateConfig(cmd *cobra.Command, args []string) error {
@@ -588,7 +1171,53 @@ func runCreateConfig(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runValidateConfig validates the configuration and reports unresolved variables
+func runValidateConfig(cmd *cobra.Command, args []string) error {
+	configPath := GetConfigPath()
+	cfg, err := LoadConfiguration(configPath)
+	if err != nil {
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			for _, issue := range validationErr.Issues {
+				fmt.Println(issue.Error())
+			}
+			return fmt.Errorf("configuration '%s' has %d problem(s)", configPath, len(validationErr.Issues))
+		}
+		return err
+	}
+
+	names := cfg.GetServerNames()
+	sort.Strings(names)
+
+	unresolved, missingRuntimes := 0, 0
+	for _, name := range names {
+		server := cfg.MCPServers[name]
+		if err := server.CheckRuntime(); err != nil {
+			fmt.Printf("Server '%s': %v\n", name, err)
+			missingRuntimes++
+		}
+		if missing := server.MissingRequired(); len(missing) > 0 {
+			fmt.Printf("Server '%s': missing required variables: %s\n", name, strings.Join(missing, ", "))
+			unresolved++
+		} else if missing := server.UnresolvedVariables(); len(missing) > 0 {
+			fmt.Printf("Server '%s': unresolved variables: %s\n", name, strings.Join(missing, ", "))
+			unresolved++
+		}
+	}
+
+	if unresolved > 0 {
+		return fmt.Errorf("%d server(s) reference unset environment variables; set them or add them to your .env file", unresolved)
+	}
+	if missingRuntimes > 0 {
+		return fmt.Errorf("%d server(s) need a program that is not installed", missingRuntimes)
+	}
+
+	fmt.Printf("Configuration '%s' is valid (%d servers)\n", configPath, len(names))
+	return nil
+}
+
 func GetConfigPath() string {
+	ensureConfigDir()
 	if cfgFile != "" {
 		return cfgFile
 	}
@@ -601,206 +1230,149 @@ func GetConfigPath() string {
 	return "mcp_servers.json" // Default fallback
 }
 
-func LoadConfiguration(configPath string) (*config.Configuration, error) {
-	cfg, err := config.LoadConfig(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load configuration from '%s': %w", configPath, err)
-	}
-	return cfg, nil
+// ensureConfigDir creates the config directory and its starter files, once,
+// when a command first needs configuration. Commands that don't, such as
+// version and help, leave the filesystem untouched.
+func ensureConfigDir() {
+	configDirOnce.Do(func() {
+		if err := config.EnsureConfigDirectory(); err != nil {
+			logging.Debug("failed to ensure config directory", "error", err)
+		}
+	})
 }
 
-// displayToolResult intelligently displays tool results, handling binary data gracefully
-func displayToolResult(result *mcp.ToolResult) {
-	if result == nil {
-		fmt.Println("Result: null")
-		return
+// configLoadOptions returns the load options selected by the global flags
+func configLoadOptions() config.LoadOptions {
+	opts := config.LoadOptions{
+		EnvFile:     envFile,
+		EnvOverride: envOverride,
+		AllowExec:   allowExec,
+	}
+
+	// A project-local config extends the discovered one, not an explicit --config
+	if !noLocal && cfgFile == "" {
+		localPath, err := config.FindLocalConfigFile()
+		var untrusted *config.UntrustedLocalConfigError
+		switch {
+		case err == nil:
+			opts.LocalConfig = localPath
+		case errors.As(err, &untrusted):
+			logging.Warn("not merging the project-local configuration", "path", untrusted.Path, "reason", untrusted.Reason)
+		}
 	}
+	return opts
+}
 
-	fmt.Println("Result:")
-	if result.IsError {
-		fmt.Printf("Error: %v\n", result.IsError)
+// configFlagArgs returns the global flags that select and load the
+// configuration, as given on the command line, for child processes
+func configFlagArgs(cmd *cobra.Command) []string {
+	var args []string
+	for _, name := range []string{"config", "env-file", "env-override", "no-local", "allow-exec"} {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			args = append(args, "--"+name+"="+flag.Value.String())
+		}
 	}
+	return args
+}
 
-	if len(result.Content) == 0 {
-		fmt.Println("No content returned")
-		return
+func LoadConfiguration(configPath string) (*config.Configuration, error) {
+	ensureConfigDir()
+	logging.Debug("loading configuration", "path", configPath)
+	cfg, err := mcpclient.LoadConfigWithOptions(configPath, configLoadOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration from '%s': %w", configPath, err)
 	}
 
-	for i, content := range result.Content {
-		fmt.Printf("Content %d:\n", i+1)
-
-		// Handle content as a map (typical for MCP responses)
-		if contentMap, ok := content.(map[string]interface{}); ok {
-			// Check if this is binary data
-			if dataType, hasType := contentMap["type"].(string); hasType {
-				switch dataType {
-				case "image", "binary":
-					displayBinaryContent(contentMap)
-					continue
-				}
-			}
-
-			// Check for data field (base64 encoded content)
-			if data, hasData := contentMap["data"].(string); hasData {
-				if isBase64Image(data) {
-					displayBinaryContent(contentMap)
-					continue
-				}
-				// For other base64 data, show summary
-				fmt.Printf("  Data (base64): %d bytes\n", len(data))
-				if len(data) <= 100 {
-					fmt.Printf("  Content: %s\n", data)
-				} else {
-					truncLen := 50
-					if len(data) < truncLen {
-						truncLen = len(data)
-					}
-					fmt.Printf("  Content: %s... (truncated)\n", data[:truncLen])
-				}
-				continue
-			}
-
-			// Check for text field
-			if text, hasText := contentMap["text"].(string); hasText {
-				fmt.Printf("  Text: %s\n", text)
-				continue
-			}
-
-			// Generic object display - show structure but truncate large values
-			displayMapStructure(contentMap, "  ")
-			continue
+	// Clients started directly cap responses like the daemon's sessions
+	client.SetMaxResponseSize(cfg.MaxResponseMB)
+	// and retry calls by the annotations of the tools listed earlier
+	client.SetKnownTools(func(serverName string, serverConfig config.ServerConfig) []mcp.Tool {
+		if noCache {
+			return nil
+		}
+		cache, err := toolcache.Default(cfg.ToolCacheDuration())
+		if err != nil {
+			return nil
 		}
+		tools, _ := cache.Get(serverName, serverConfig)
+		return tools
+	})
 
-		// Handle content as a simple string or other primitive
-		switch v := content.(type) {
-		case string:
-			if len(v) > 200 {
-				fmt.Printf("  String (length %d): %s... (truncated)\n", len(v), v[:100])
-			} else {
-				fmt.Printf("  String: %s\n", v)
-			}
-		default:
-			// Convert to JSON for display
-			if jsonBytes, err := json.MarshalIndent(v, "  ", "  "); err == nil {
-				fmt.Printf("  %s", string(jsonBytes))
-			} else {
-				fmt.Printf("  %v\n", v)
-			}
+	if isVerbose() {
+		for _, conflict := range cfg.Conflicts {
+			fmt.Printf("Config: %s\n", conflict)
 		}
 	}
+	return cfg, nil
 }
 
-// displayBinaryContent handles binary data (images, files) with user-friendly output
-func displayBinaryContent(content map[string]interface{}) {
-	data, hasData := content["data"].(string)
-	mimeType, hasMimeType := content["mimeType"].(string)
+func runServe(cmd *cobra.Command, args []string) error {
+	// Initialize verbose mode to set environment variable
+	_ = isVerbose()
 
-	fmt.Printf("  Binary data detected:\n")
-	if hasMimeType {
-		fmt.Printf("    MIME type: %s\n", mimeType)
+	loaded, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return err
 	}
-	if hasData {
-		fmt.Printf("    Size: %d bytes (base64 encoded)\n", len(data))
-
-		// Try to decode base64 to get actual size
-		if decoded, err := base64.StdEncoding.DecodeString(data); err == nil {
-			fmt.Printf("    Actual size: %d bytes\n", len(decoded))
-
-			// For images, show dimensions if it's a PNG/JPEG
-			if strings.HasPrefix(mimeType, "image/") {
-				if strings.HasPrefix(mimeType, "image/png") && len(decoded) > 8 {
-					// Basic PNG dimension detection
-					if len(decoded) >= 24 {
-						width := int(decoded[16])<<24 | int(decoded[17])<<16 | int(decoded[18])<<8 | int(decoded[19])
-						height := int(decoded[20])<<24 | int(decoded[21])<<16 | int(decoded[22])<<8 | int(decoded[23])
-						fmt.Printf("    Image dimensions: %dx%d (PNG)\n", width, height)
-					}
-				} else if strings.HasPrefix(mimeType, "image/jpeg") && len(decoded) > 4 {
-					// Basic JPEG detection (look for SOF markers)
-					fmt.Printf("    Image format: JPEG\n")
-				}
-			}
-		}
-
-		truncLen := 50
-		if len(data) < truncLen {
-			truncLen = len(data)
-		}
-		fmt.Printf("    Data: %s... (base64 data truncated)\n", data[:truncLen])
+	cfg, err := applyGroupFilter(loaded)
+	if err != nil {
+		return err
 	}
 
-	// Show other fields
-	for key, value := range content {
-		if key != "data" && key != "type" && key != "mimeType" {
-			fmt.Printf("    %s: %v\n", key, value)
+	newClient := daemon.NewSmartClient().CreateClient
+	if serveHTTPAddr == "" {
+		server := serve.New(cfg, newClient)
+		if watcher := watchServeConfig(loaded, server.SetExports); watcher != nil {
+			defer watcher.Close()
 		}
+		// stdout carries the protocol; warnings go to stderr through the logger
+		return server.Serve(commandContext(cmd), os.Stdin, os.Stdout)
 	}
 
-	fmt.Printf("    💡 Tip: Use a file save option or script to extract binary data\n")
-}
-
-// isBase64Image checks if a string appears to be base64-encoded image data
-func isBase64Image(s string) bool {
-	// Basic base64 pattern check
-	base64Pattern := regexp.MustCompile(`^[A-Za-z0-9+/]+=*$`)
-	if !base64Pattern.MatchString(s) || len(s) < 100 {
-		return false
+	listener, err := net.Listen("tcp", serveHTTPAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", serveHTTPAddr, err)
 	}
-
-	// Try to decode and check for common image signatures
-	if decoded, err := base64.StdEncoding.DecodeString(s); err == nil {
-		if len(decoded) >= 4 {
-			// PNG signature: 89 50 4E 47
-			if len(decoded) >= 8 &&
-				decoded[0] == 0x89 && decoded[1] == 0x50 &&
-				decoded[2] == 0x4E && decoded[3] == 0x47 {
-				return true
-			}
-			// JPEG signature: FF D8 FF
-			if decoded[0] == 0xFF && decoded[1] == 0xD8 && decoded[2] == 0xFF {
-				return true
-			}
-			// WebP signature: RIFF...WEBP
-			if len(decoded) >= 12 &&
-				string(decoded[0:4]) == "RIFF" &&
-				string(decoded[8:12]) == "WEBP" {
-				return true
-			}
-		}
+	if cfg.Serve.Token == "" && !serve.IsLoopback(serveHTTPAddr) {
+		logging.Warn("serving without authentication; set serve.token in the configuration", "address", serveHTTPAddr)
 	}
+	fmt.Fprintf(os.Stderr, "Serving MCP on http://%s%s\n", listener.Addr(), serve.HTTPPath)
 
-	return false
+	// SIGTERM and Ctrl-C stop accepting requests and let tool calls finish
+	server := serve.NewHTTP(cfg, newClient, cfg.Serve.Token)
+	if watcher := watchServeConfig(loaded, server.SetExports); watcher != nil {
+		defer watcher.Close()
+	}
+	return server.Serve(commandContext(cmd), listener)
 }
 
-// displayMapStructure recursively displays map structure with truncation for large values
-func displayMapStructure(m map[string]interface{}, indent string) {
-	for key, value := range m {
-		switch v := value.(type) {
-		case string:
-			if len(v) > 100 {
-				fmt.Printf("%s%s: \"[string length %d - truncated]\"\n", indent, key, len(v))
+// watchServeConfig applies edits to the exports section while serving, when
+// requested with --watch-config or "configWatch": true. Other edits are
+// reported as needing a restart. It returns nil when not watching.
+func watchServeConfig(cfg *config.Configuration, setExports func(*config.ExportsConfig) error) *config.Watcher {
+	if !serveWatchConfig && !cfg.ConfigWatch {
+		return nil
+	}
+
+	watcher, err := config.WatchConfig(GetConfigPath(), configLoadOptions(), cfg, func(change config.ConfigChange) {
+		if change.ExportsChanged {
+			if err := setExports(change.Config.Exports); err != nil {
+				logging.Warn("failed to apply the new exports", "error", err)
 			} else {
-				fmt.Printf("%s%s: %q\n", indent, key, v)
-			}
-		case map[string]interface{}:
-			fmt.Printf("%s%s:\n", indent, key)
-			displayMapStructure(v, indent+"  ")
-		case []interface{}:
-			fmt.Printf("%s%s: [array with %d items]\n", indent, key, len(v))
-			if len(v) > 0 && len(v) <= 3 {
-				for i, item := range v {
-					fmt.Printf("%s  [%d]: ", indent, i)
-					if itemMap, ok := item.(map[string]interface{}); ok {
-						displayMapStructure(itemMap, indent+"    ")
-					} else {
-						fmt.Printf("%v\n", item)
-					}
-				}
+				logging.Debug("applied the new exports")
 			}
-		default:
-			fmt.Printf("%s%s: %v\n", indent, key, v)
 		}
+		if len(change.Added)+len(change.Removed)+len(change.Changed) > 0 {
+			logging.Warn("server changes take effect when serve restarts", "added", change.Added, "removed", change.Removed, "changed", change.Changed)
+		}
+	}, func(err error) {
+		logging.Warn(err.Error())
+	})
+	if err != nil {
+		logging.Warn("not watching the configuration", "error", err)
+		return nil
 	}
+	return watcher
 }
 
 func runInitialize(cmd *cobra.Command, args []string) error {
@@ -817,12 +1389,11 @@ func runInitialize(cmd *cobra.Command, args []string) error {
 	// Get server configuration
 	serverConfig, exists := cfg.GetServer(serverName)
 	if !exists {
-		displayServerNotFoundError(serverName, cfg)
-		return nil
+		return displayServerNotFoundError(serverName, cfg)
 	}
 
 	if !serverConfig.IsEnabled() {
-		return fmt.Errorf("server '%s' is disabled", serverName)
+		return serverDisabledError(serverName, serverConfig)
 	}
 
 	// Create session-aware client factory
@@ -839,20 +1410,14 @@ func runInitialize(cmd *cobra.Command, args []string) error {
 	defer func() { _ = mcpClient.Close() }()
 
 	// Initialize connection
-	ctx := context.Background()
+	ctx := commandContext(cmd)
 
 	// Create initialization parameters
-	initParams := &mcp.InitializeParams{
-		ProtocolVersion: "2024-11-05",
-		Capabilities: mcp.ClientCapabilities{
-			Experimental: make(map[string]interface{}),
-			Sampling:     &mcp.SamplingCapability{},
-			Roots:        &mcp.RootsCapability{},
-		},
-		ClientInfo: mcp.ClientInfo{
-			Name:    "mcp-cli-ent",
-			Version: "0.1.0",
-		},
+	initParams := mcpclient.DefaultInitializeParams()
+	initParams.Capabilities = mcp.ClientCapabilities{
+		Experimental: make(map[string]interface{}),
+		Sampling:     &mcp.SamplingCapability{},
+		Roots:        &mcp.RootsCapability{},
 	}
 
 	result, err := mcpClient.Initialize(ctx, initParams)
@@ -885,11 +1450,11 @@ func runRequestInput(cmd *cobra.Command, args []string) error {
 	// Get server configuration
 	serverConfig, exists := cfg.GetServer(serverName)
 	if !exists {
-		return fmt.Errorf("server '%s' not found in configuration", serverName)
+		return serverNotFoundError(serverName, cfg)
 	}
 
 	if !serverConfig.IsEnabled() {
-		return fmt.Errorf("server '%s' is disabled", serverName)
+		return serverDisabledError(serverName, serverConfig)
 	}
 
 	// Create session-aware client factory
@@ -934,7 +1499,7 @@ func runRequestInput(cmd *cobra.Command, args []string) error {
 	}
 
 	// Request input
-	ctx := context.Background()
+	ctx := commandContext(cmd)
 	result, err := mcpClient.RequestInput(ctx, params)
 	if err != nil {
 		return fmt.Errorf("failed to request input: %w", err)
@@ -965,11 +1530,11 @@ func runCreateMessage(cmd *cobra.Command, args []string) error {
 	// Get server configuration
 	serverConfig, exists := cfg.GetServer(serverName)
 	if !exists {
-		return fmt.Errorf("server '%s' not found in configuration", serverName)
+		return serverNotFoundError(serverName, cfg)
 	}
 
 	if !serverConfig.IsEnabled() {
-		return fmt.Errorf("server '%s' is disabled", serverName)
+		return serverDisabledError(serverName, serverConfig)
 	}
 
 	// Create session-aware client factory
@@ -1008,7 +1573,7 @@ func runCreateMessage(cmd *cobra.Command, args []string) error {
 	request.SystemPrompt = "You are a helpful AI assistant."
 
 	// Create message
-	ctx := context.Background()
+	ctx := commandContext(cmd)
 	result, err := mcpClient.CreateMessage(ctx, request)
 	if err != nil {
 		return fmt.Errorf("failed to create message: %w", err)
@@ -1027,32 +1592,47 @@ func runCreateMessage(cmd *cobra.Command, args []string) error {
 
 // Session command implementations
 
+// withServerHint appends advice to errors whose server stderr points at a
+// likely cause, such as a missing API key
+func withServerHint(err error) error {
+	var exitErr *client.ServerExitError
+	if errors.As(err, &exitErr) {
+		if hint := exitErr.Hint(); hint != "" {
+			return fmt.Errorf("%w\nHint: %s", err, hint)
+		}
+	}
+	return err
+}
+
 // isVerbose returns true if verbose flag is set and updates global VerboseMode
 func isVerbose() bool {
 	VerboseMode = viper.GetBool("verbose")
-	// Set environment variable for session managers to check
+	// Set environment variable so child processes such as the daemon log at the same level
 	if VerboseMode {
-		_ = os.Setenv("MCP_VERBOSE", "true")
+		_ = os.Setenv(logging.VerboseEnv, "true")
 	} else {
-		_ = os.Setenv("MCP_VERBOSE", "false")
+		_ = os.Setenv(logging.VerboseEnv, "false")
 	}
 	return VerboseMode
 }
 
 func getSessionManager() (*session.Manager, error) {
 	sessionManagerOnce.Do(func() {
-		configDir, err := config.GetConfigDir()
+		dir, err := config.GetDataDir()
 		if err != nil {
-			sessionManagerInitErr = fmt.Errorf("failed to get config directory: %w", err)
+			sessionManagerInitErr = fmt.Errorf("failed to get data directory: %w", err)
 			return
 		}
 
-		manager, err := client.NewSessionManager(configDir)
+		manager, err := client.NewSessionManager(dir)
 		if err != nil {
 			sessionManagerInitErr = fmt.Errorf("failed to create session manager: %w", err)
 			return
 		}
 
+		// Let the daemon own persistent stdio processes so they survive this command
+		manager.SetProcessBroker(daemon.NewSessionBroker())
+
 		// sync.Once.Do provides memory barrier, no mutex needed
 		globalSessionManager = manager
 	})
@@ -1068,6 +1648,13 @@ func getSessionManager() (*session.Manager, error) {
 	return globalSessionManager, nil
 }
 
+// closeSessionManager stops background health checks and flushes session metadata before exit
+func closeSessionManager() {
+	if globalSessionManager != nil {
+		_ = globalSessionManager.Close()
+	}
+}
+
 func getSessionAwareClientFactory() (*client.SessionAwareClientFactory, error) {
 	manager, err := getSessionManager()
 	if err != nil {
@@ -1115,9 +1702,24 @@ func runSessionList(cmd *cobra.Command, args []string) error {
 		if sessionInfo.PID > 0 {
 			fmt.Printf("    PID: %d\n", sessionInfo.PID)
 		}
-		if len(sessionInfo.Endpoints) > 0 {
+		if sessionInfo.ConnectionInfo != nil && sessionInfo.ConnectionInfo.Type == session.BrokeredConnectionType {
+			fmt.Printf("    Owner: daemon (%s)\n", sessionInfo.ConnectionInfo.URL)
+		} else if len(sessionInfo.Endpoints) > 0 {
 			fmt.Printf("    Endpoints: %v\n", sessionInfo.Endpoints)
 		}
+		if sessionInfo.FallbackReason != "" {
+			fmt.Printf("    Fallback: stateless (%s)\n", sessionInfo.FallbackReason)
+		} else if sessionInfo.Type == session.Stateless {
+			fmt.Println("    Stateless: each call connects anew; there is no process to stop")
+		}
+		if sessionListDetail {
+			fmt.Printf("    Calls: %d, Errors: %d, Tool time: %s\n",
+				sessionInfo.ToolCallCount, sessionInfo.ErrorCount, sessionInfo.ToolTime.Round(time.Millisecond))
+			if sessionInfo.LastTool != "" {
+				fmt.Printf("    Last tool: %s\n", sessionInfo.LastTool)
+			}
+			writeSessionFailures(os.Stdout, "    ", sessionInfo.SessionMetrics, time.Now())
+		}
 		fmt.Println()
 	}
 
@@ -1137,8 +1739,7 @@ func runSessionStatus(cmd *cobra.Command, args []string) error {
 
 	serverConfig, exists := cfg.MCPServers[serverName]
 	if !exists {
-		displayServerNotFoundError(serverName, cfg)
-		return nil
+		return displayServerNotFoundError(serverName, cfg)
 	}
 
 	manager, err := getSessionManager()
@@ -1169,7 +1770,13 @@ func runSessionStatus(cmd *cobra.Command, args []string) error {
 
 	// Show server configuration
 	fmt.Printf("\nServer Configuration:\n")
-	fmt.Printf("Command: %s %v\n", serverConfig.Command, serverConfig.Args)
+	if serverConfig.IsDocker() {
+		fmt.Printf("Image: %s %v\n", serverConfig.Image, serverConfig.Args)
+	} else if serverConfig.IsSSH() {
+		fmt.Printf("Remote: %s\n", serverConfig.GetServerDetails())
+	} else {
+		fmt.Printf("Command: %s %v\n", serverConfig.Command, serverConfig.Args)
+	}
 	if serverConfig.URL != "" {
 		fmt.Printf("URL: %s\n", serverConfig.URL)
 	}
@@ -1193,8 +1800,7 @@ func runSessionStart(cmd *cobra.Command, args []string) error {
 
 	serverConfig, exists := cfg.MCPServers[serverName]
 	if !exists {
-		displayServerNotFoundError(serverName, cfg)
-		return nil
+		return displayServerNotFoundError(serverName, cfg)
 	}
 
 	// Check if server supports persistent sessions
@@ -1261,92 +1867,75 @@ func runSessionRestart(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// runSessionCleanup cleans up dead or expired sessions
-func runSessionCleanup(cmd *cobra.Command, args []string) error {
+// runSessionResetStats resets the metrics of a session, here and in the daemon
+func runSessionResetStats(cmd *cobra.Command, args []string) error {
+	serverName := args[0]
+
 	manager, err := getSessionManager()
 	if err != nil {
 		return fmt.Errorf("failed to create session manager: %w", err)
 	}
+	localErr := manager.ResetStats(serverName)
 
-	fmt.Println("Cleaning up dead and expired sessions...")
-	if err := manager.CleanupSessions(); err != nil {
-		return fmt.Errorf("failed to cleanup sessions: %w", err)
+	daemonErr := localErr
+	if client := daemon.SharedDaemonClient(); client.IsDaemonRunning() {
+		daemonErr = client.ResetSessionStats(commandContext(cmd), serverName)
+	}
+	if localErr != nil && daemonErr != nil {
+		return localErr
 	}
 
-	fmt.Println("Session cleanup completed.")
+	fmt.Printf("Stats of session %s reset.\n", serverName)
 	return nil
 }
 
-// runSessionAttach attaches to an existing session
-func runSessionAttach(cmd *cobra.Command, args []string) error {
-	serverName := args[0]
-
-	// Load configuration to check if server exists
-	configPath := GetConfigPath()
-	cfg, err := LoadConfiguration(configPath)
-	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+// writeSessionFailures prints a session's restarts and latest error, if it
+// has any
+func writeSessionFailures(out io.Writer, indent string, metrics session.SessionMetrics, now time.Time) {
+	if metrics.RestartCount > 0 {
+		fmt.Fprintf(out, "%sRestarts: %d\n", indent, metrics.RestartCount)
 	}
-
-	serverConfig, exists := cfg.MCPServers[serverName]
-	if !exists {
-		displayServerNotFoundError(serverName, cfg)
-		return nil
+	if metrics.LastError != "" {
+		fmt.Fprintf(out, "%sLast error: %s", indent, metrics.LastError)
+		if metrics.LastErrorTime != nil {
+			fmt.Fprintf(out, " (%s ago)", now.Sub(*metrics.LastErrorTime).Round(time.Second))
+		}
+		fmt.Fprintln(out)
 	}
+}
 
-	manager, err := getSessionManager()
+// runSessionCleanup cleans up dead or expired sessions
+func runSessionCleanup(cmd *cobra.Command, args []string) error {
+	olderThan, err := config.ParseRetention(sessionCleanupOlderThan)
 	if err != nil {
-		return fmt.Errorf("failed to create session manager: %w", err)
+		return fmt.Errorf("invalid --older-than value: %w", err)
 	}
 
-	// Try to find and attach to an existing session
-	fileStore := manager.GetFileStore()
-	sessionInfo, err := fileStore.FindExistingSession(serverName)
+	manager, err := getSessionManager()
 	if err != nil {
-		return fmt.Errorf("no existing session found for %s: %w", serverName, err)
+		return fmt.Errorf("failed to create session manager: %w", err)
 	}
 
-	fmt.Printf("Found existing session for %s:\n", serverName)
-	fmt.Printf("  Session ID: %s\n", sessionInfo.SessionID)
-	fmt.Printf("  Status: %s\n", sessionInfo.Status.String())
-	fmt.Printf("  PID: %d\n", sessionInfo.PID)
-	if !sessionInfo.StartTime.IsZero() {
-		fmt.Printf("  Started: %s\n", sessionInfo.StartTime.Format("2006-01-02 15:04:05"))
-	}
-	if !sessionInfo.LastActivity.IsZero() {
-		fmt.Printf("  Last Activity: %s\n", sessionInfo.LastActivity.Format("2006-01-02 15:04:05"))
+	fmt.Println("Cleaning up dead and expired sessions...")
+	if err := manager.CleanupSessions(); err != nil {
+		return fmt.Errorf("failed to cleanup sessions: %w", err)
 	}
 
-	// Attempt to create and start the session (which will try reattachment)
-	sess, err := manager.GetSession(serverName, serverConfig)
+	removed, err := manager.CleanupStaleSessions(olderThan)
 	if err != nil {
-		return fmt.Errorf("failed to attach to session: %w", err)
+		return fmt.Errorf("failed to cleanup stale sessions: %w", err)
 	}
 
-	// Verify session is actually active
-	if sess.Status() != session.Active {
-		return fmt.Errorf("session attachment failed - session status: %s", sess.Status().String())
-	}
-
-	fmt.Printf("\nSuccessfully attached to session %s!\n", serverName)
-
-	// If it's a persistent session, provide additional info
-	if sess.Type() == session.Persistent {
-		if persistentSession, ok := sess.(*session.PersistentSession); ok {
-			info := persistentSession.GetInfo()
-			if info.ConnectionInfo != nil {
-				fmt.Printf("Connection Type: %s\n", info.ConnectionInfo.Type)
-				if info.ConnectionInfo.URL != "" {
-					fmt.Printf("Endpoint: %s\n", info.ConnectionInfo.URL)
-				}
-				if len(info.Endpoints) > 0 {
-					fmt.Printf("Endpoints: %v\n", info.Endpoints)
-				}
-			}
+	if len(removed) == 0 {
+		fmt.Println("No stale sessions found.")
+	} else {
+		fmt.Printf("Removed %d stale session(s):\n", len(removed))
+		for _, result := range removed {
+			fmt.Printf("  %s (%s): %s\n", result.Name, result.SessionID, result.Reason)
 		}
 	}
 
-	fmt.Println("\nSession is ready for use. The session will remain active and can be reused across commands.")
+	fmt.Println("Session cleanup completed.")
 	return nil
 }
 
@@ -1355,6 +1944,7 @@ func runSessionAttach(cmd *cobra.Command, args []string) error {
 // runDaemonStart starts the MCP daemon
 func runDaemonStart(cmd *cobra.Command, args []string) error {
 	manager := daemon.NewDaemonManager()
+	configureConfigWatch(cmd, manager)
 
 	if daemonForeground {
 		fmt.Println("Starting MCP daemon in foreground...")
@@ -1371,6 +1961,23 @@ func runDaemonStart(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// configureConfigWatch enables config hot-reload in the daemon when requested
+// with --watch-config or "configWatch": true
+func configureConfigWatch(cmd *cobra.Command, manager *daemon.DaemonManager) {
+	configPath := GetConfigPath()
+	opts := configLoadOptions()
+
+	watch := daemonWatchConfig
+	if !watch {
+		cfg, err := config.LoadConfigWithOptions(configPath, opts)
+		watch = err == nil && cfg.ConfigWatch
+	}
+
+	if watch {
+		manager.WatchConfig(configPath, opts, configFlagArgs(cmd))
+	}
+}
+
 // runDaemonStop stops the MCP daemon
 func runDaemonStop(cmd *cobra.Command, args []string) error {
 	manager := daemon.NewDaemonManager()
@@ -1386,7 +1993,10 @@ func runDaemonStop(cmd *cobra.Command, args []string) error {
 
 // runDaemonStatus shows the MCP daemon status
 func runDaemonStatus(cmd *cobra.Command, args []string) error {
-	client := daemon.NewDaemonClient()
+	if daemonStatusAll {
+		return runDaemonStatusAll(os.Stdout)
+	}
+	client := daemon.SharedDaemonClient()
 
 	status, err := client.GetStatus()
 	if err != nil {
@@ -1401,6 +2011,9 @@ func runDaemonStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("MCP daemon is running (PID: %d)\n", status.PID)
+	if status.Name != "" {
+		fmt.Printf("Instance: %s\n", status.Name)
+	}
 	fmt.Printf("Platform: %s\n", status.Platform)
 	fmt.Printf("Endpoint: %s\n", status.Endpoint)
 	if !status.StartTime.IsZero() {
@@ -1427,15 +2040,36 @@ func runDaemonStatus(cmd *cobra.Command, args []string) error {
 			if session.Error != "" {
 				fmt.Printf("    Error: %s\n", session.Error)
 			}
+			if session.RunningCalls > 0 {
+				fmt.Printf("    Running: %s for %s", session.RunningTool, session.RunningFor.Round(time.Second))
+				if session.RunningCalls > 1 {
+					fmt.Printf(" (%d calls in progress)", session.RunningCalls)
+				}
+				fmt.Println()
+			}
+			if session.ToolCallCount > 0 {
+				fmt.Printf("    Calls: %d, Errors: %d, Last tool: %s, Tool time: %s\n",
+					session.ToolCallCount, session.ErrorCount, session.LastTool, session.ToolTime.Round(time.Millisecond))
+			}
+			if session.ListCacheHits+session.ListCacheMisses > 0 {
+				fmt.Printf("    List cache: %d hits, %d misses\n", session.ListCacheHits, session.ListCacheMisses)
+			}
+			writeSessionFailures(os.Stdout, "    ", session.SessionMetrics, time.Now())
 		}
 	}
 
+	if len(status.Schedules) > 0 {
+		fmt.Println("\nSchedules:")
+		writeScheduleList(os.Stdout, status.Schedules, time.Now())
+	}
+
 	return nil
 }
 
 // runDaemonRestart restarts the MCP daemon
 func runDaemonRestart(cmd *cobra.Command, args []string) error {
 	manager := daemon.NewDaemonManager()
+	configureConfigWatch(cmd, manager)
 
 	fmt.Println("Restarting MCP daemon...")
 	if err := manager.Restart(); err != nil {
@@ -1447,6 +2081,125 @@ func runDaemonRestart(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// openCaches opens the caches named, or every registered one for "all", set
+// up as the configuration says. The caches are managed without a
+// configuration file too, with the default settings.
+func openCaches(name string) ([]string, []caches.Cache, error) {
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		logging.Debug("managing the caches with default settings", "error", err)
+		cfg = &config.Configuration{}
+	}
+
+	names := []string{name}
+	if name == "all" {
+		names = caches.Names()
+	}
+	opened := make([]caches.Cache, 0, len(names))
+	for _, name := range names {
+		cache, err := caches.Open(name, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		opened = append(opened, cache)
+	}
+	return names, opened, nil
+}
+
+func runCacheStatus(cmd *cobra.Command, args []string) error {
+	names, opened, err := openCaches("all")
+	if err != nil {
+		return err
+	}
+
+	corrupt := 0
+	for i, cache := range opened {
+		stats, err := cache.Stats()
+		if err != nil {
+			return fmt.Errorf("failed to inspect the %s cache: %w", names[i], err)
+		}
+		fmt.Printf("%s:\n", names[i])
+		fmt.Printf("  Path:    %s\n", stats.Path)
+		fmt.Printf("  Entries: %d\n", stats.Entries)
+		fmt.Printf("  Size:    %s\n", formatSize(stats.Size))
+		if stats.Entries > 0 {
+			fmt.Printf("  Oldest:  %s\n", stats.Oldest.Local().Format(time.RFC3339))
+			fmt.Printf("  Newest:  %s\n", stats.Newest.Local().Format(time.RFC3339))
+		}
+		for _, path := range stats.Corrupt {
+			fmt.Printf("  Corrupt: %s\n", path)
+		}
+		corrupt += len(stats.Corrupt)
+	}
+	if corrupt > 0 {
+		fmt.Printf("\n%d corrupt file(s); run 'mcp-cli-ent cache gc' to remove them.\n", corrupt)
+	}
+	return nil
+}
+
+// legacyCacheFile is the single-file tool cache older versions wrote
+const legacyCacheFile = "tools_cache.json"
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	name := "all"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	names, opened, err := openCaches(name)
+	if err != nil {
+		return err
+	}
+
+	for i, cache := range opened {
+		removed, err := cache.Clear(cacheClearServer)
+		if err != nil {
+			return fmt.Errorf("failed to clear the %s cache: %w", names[i], err)
+		}
+		if cacheClearServer != "" {
+			fmt.Printf("Removed %d %s entr(ies) of '%s'\n", removed, names[i], cacheClearServer)
+		} else {
+			fmt.Printf("Removed %d %s entr(ies)\n", removed, names[i])
+		}
+	}
+
+	if name == "all" && cacheClearServer == "" {
+		if configDir, err := config.GetConfigDir(); err == nil {
+			_ = os.Remove(filepath.Join(configDir, legacyCacheFile))
+		}
+	}
+	return nil
+}
+
+func runCacheGC(cmd *cobra.Command, args []string) error {
+	names, opened, err := openCaches("all")
+	if err != nil {
+		return err
+	}
+
+	for i, cache := range opened {
+		removed, err := cache.GC()
+		if err != nil {
+			return fmt.Errorf("failed to collect the %s cache: %w", names[i], err)
+		}
+		fmt.Printf("Removed %d %s entr(ies)\n", removed, names[i])
+	}
+	return nil
+}
+
+// formatSize renders a size in bytes for people
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
 // runDaemonLogs shows the MCP daemon logs
 func runDaemonLogs(cmd *cobra.Command, args []string) error {
 	logFile := daemon.GetLogFilePath()
@@ -0,0 +1,452 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/serverstate"
+	"github.com/mcp-cli-ent/mcp-cli/internal/session"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolcache"
+)
+
+func TestWithServerHint(t *testing.T) {
+	exitErr := &client.ServerExitError{
+		Stderr: "Error: BRAVE_API_KEY is not set",
+		Err:    fmt.Errorf("failed to read response: %w", io.EOF),
+	}
+	err := withServerHint(fmt.Errorf("failed to list tools: %w", exitErr))
+
+	if !strings.HasPrefix(err.Error(), "failed to list tools: server exited: Error: BRAVE_API_KEY is not set\nHint: ") {
+		t.Errorf("unexpected message: %q", err)
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("hinted error should still match io.EOF")
+	}
+	var target *client.ServerExitError
+	if !errors.As(err, &target) || target != exitErr {
+		t.Errorf("hinted error should still unwrap to the ServerExitError")
+	}
+
+	plain := errors.New("failed to list tools: request timeout")
+	if got := withServerHint(plain); got != plain {
+		t.Errorf("errors without server output should be returned unchanged, got %q", got)
+	}
+}
+
+func TestToolCacheFlags(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	defer func() { noCache, clearCache, refreshCache = false, false, false }()
+
+	cfg := &config.Configuration{}
+	serverConfig := config.ServerConfig{Command: "uvx", Args: []string{"mcp-server-time"}}
+	cacheTools(toolCache(cfg), "time", serverConfig, []mcp.Tool{{Name: "get_current_time"}})
+	if _, ok := toolCache(cfg).Get("time", serverConfig); !ok {
+		t.Fatal("expected the listed tools to be cached")
+	}
+
+	noCache = true
+	if toolCache(cfg) != nil {
+		t.Error("--no-cache should disable the cache")
+	}
+
+	noCache, clearCache = false, true
+	cache := toolCache(cfg)
+	if _, ok := cache.Get("time", serverConfig); ok {
+		t.Error("--clear-cache should empty the cache")
+	}
+	if clearCache || !refreshCache {
+		t.Error("--clear-cache should clear once, then refresh")
+	}
+}
+
+func TestTrivialCommandsWriteNothing(t *testing.T) {
+	root := t.TempDir()
+	configDir := filepath.Join(root, "config")
+	t.Setenv(config.ConfigDirEnv, configDir)
+	t.Setenv("HOME", root)
+	configDirOnce = sync.Once{} // Earlier tests may have bootstrapped already
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+	defer func() {
+		configDirOnce = sync.Once{}
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		_ = rootCmd.Flags().Set("help", "false")
+	}()
+
+	for _, args := range [][]string{{"version"}, {"--help"}, {"help", "call"}} {
+		captureStdout(t, func() {
+			rootCmd.SetArgs(args)
+			if err := rootCmd.Execute(); err != nil {
+				t.Errorf("%v failed: %v", args, err)
+			}
+		})
+		if entries, _ := os.ReadDir(root); len(entries) > 0 {
+			t.Fatalf("%v wrote to the filesystem: %v", args, entries)
+		}
+	}
+
+	// A command that needs configuration creates the directory on first use
+	captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"validate-config"})
+		_ = rootCmd.Execute()
+	})
+	if _, err := os.Stat(filepath.Join(configDir, "mcp_servers.json")); err != nil {
+		t.Errorf("expected validate-config to create the config directory: %v", err)
+	}
+}
+
+func TestSessionListMarksStatelessSessions(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	resetSessionManager()
+	t.Cleanup(func() {
+		closeSessionManager()
+		resetSessionManager()
+	})
+
+	manager, err := getSessionManager()
+	if err != nil {
+		t.Fatalf("getSessionManager failed: %v", err)
+	}
+	if _, err := manager.GetSession("context7", config.ServerConfig{Type: "http", URL: "https://mcp.context7.com/mcp"}); err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+
+	stdout := captureStdout(t, func() {
+		if err := runSessionList(nil, nil); err != nil {
+			t.Errorf("session list failed: %v", err)
+		}
+	})
+	for _, want := range []string{"context7", "[stateless]", "https://mcp.context7.com/mcp", "no process to stop"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("session list output lacks %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestCacheCommandsHandleCorruptFiles(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, configDir)
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {}}`)
+	t.Cleanup(func() { cacheClearServer = "" })
+
+	cache, err := toolcache.Default(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"time", "fetch"} {
+		if err := cache.Put(name, config.ServerConfig{Command: "uvx"}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	corrupt := filepath.Join(configDir, toolcache.DirName, "broken.json")
+	writeTestFile(t, corrupt, "{")
+
+	stdout, err := runCLI(t, configPath, "cache", "status")
+	if err != nil {
+		t.Fatalf("cache status failed: %v", err)
+	}
+	for _, want := range []string{"tool-lists:", "results:", "Entries: 2", "Corrupt: " + corrupt, "cache gc"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("status output lacks %q:\n%s", want, stdout)
+		}
+	}
+
+	if _, err := runCLI(t, configPath, "cache", "gc"); err != nil {
+		t.Fatalf("cache gc failed: %v", err)
+	}
+	if _, err := os.Stat(corrupt); !os.IsNotExist(err) {
+		t.Error("cache gc should remove the corrupt file")
+	}
+
+	if _, err := runCLI(t, configPath, "cache", "clear", "tool-lists", "--server", "time"); err != nil {
+		t.Fatalf("cache clear failed: %v", err)
+	}
+	if _, ok := cache.Get("time", config.ServerConfig{Command: "uvx"}); ok {
+		t.Error("the cleared server's tools should be gone")
+	}
+	if _, ok := cache.Get("fetch", config.ServerConfig{Command: "uvx"}); !ok {
+		t.Error("other servers' tools should be kept")
+	}
+
+	if _, err := runCLI(t, configPath, "cache", "clear", "tokens"); err == nil || !strings.Contains(err.Error(), "tool-lists") {
+		t.Errorf("want an unknown cache to list the available ones, got %v", err)
+	}
+}
+
+func TestCallPrintRequest(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {"fetch": {
+		"command": "/nonexistent/fetch-server",
+		"toolDefaults": {"fetch": {"maxLength": 5000, "raw": false}}
+	}}}`)
+	defer func() {
+		callPrintReq = false
+		callToolCmd.Flags().Lookup("print-request").Changed = false
+	}()
+
+	// The server cannot start, so the request is printed without connecting
+	stdout, err := runCLI(t, configPath, "call", "fetch", "fetch", `{"url": "https://example.com", "raw": true}`, "--print-request")
+	if err != nil {
+		t.Fatalf("call --print-request failed: %v", err)
+	}
+
+	arguments := map[string]interface{}{"url": "https://example.com", "raw": true, "maxLength": 5000}
+	want, _ := mcp.MarshalRequest(mcp.NewCallToolRequest(context.Background(), "fetch", arguments))
+	if stdout != string(want)+"\n" {
+		t.Errorf("stdout = %s, want %s", stdout, want)
+	}
+	if !strings.Contains(stdout, `"id":2,"method":"tools/call"`) {
+		t.Errorf("stdout should be the tools/call request: %s", stdout)
+	}
+}
+
+func TestCallNullArgumentsWithArgFlags(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {
+		"fetch": {"command": "/nonexistent/fetch-server"},
+		"mirror": {"command": "/nonexistent/fetch-server"}
+	}}`)
+	defer func() {
+		callPrintReq, callArgFlags, callServers = false, nil, nil
+		for _, name := range []string{"print-request", "arg", "servers"} {
+			callToolCmd.Flags().Lookup(name).Changed = false
+		}
+	}()
+
+	// "null" arguments leave --arg an object to set values in
+	stdout, err := runCLI(t, configPath, "call", "fetch", "fetch", "null", "--arg", "url=https://example.com", "--print-request")
+	if err != nil {
+		t.Fatalf("call with null arguments failed: %v", err)
+	}
+	if !strings.Contains(stdout, `"arguments":{"url":"https://example.com"}`) {
+		t.Errorf("stdout should carry the --arg value: %s", stdout)
+	}
+
+	// Broadcasting too; neither server starts, so only the failures are reported
+	callPrintReq = false
+	callToolCmd.Flags().Lookup("print-request").Changed = false
+	_, err = runCLI(t, configPath, "call", "--servers", "fetch,mirror", "fetch", "null", "--arg", "url=https://example.com")
+	if err == nil || strings.Contains(err.Error(), "invalid") {
+		t.Errorf("want the servers' failures reported, got %v", err)
+	}
+}
+
+func TestCallExpandEnv(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	t.Setenv("MCP_TEST_SITE", "example.com")
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {"fetch": {"command": "/nonexistent/fetch-server"}}}`)
+	defer func() {
+		callPrintReq, callExpandEnv = false, false
+		for _, name := range []string{"print-request", "expand-env"} {
+			callToolCmd.Flags().Lookup(name).Changed = false
+		}
+	}()
+
+	args := `{"url": "https://${MCP_TEST_SITE}/a%20b", "headers": ["Host: %MCP_TEST_SITE%"], "price": "$5"}`
+	stdout, err := runCLI(t, configPath, "call", "fetch", "fetch", args, "--expand-env", "--print-request")
+	if err != nil {
+		t.Fatalf("call --expand-env failed: %v", err)
+	}
+	for _, want := range []string{`"url":"https://example.com/a%20b"`, `"headers":["Host: example.com"]`, `"price":"$5"`} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout should contain %s: %s", want, stdout)
+		}
+	}
+
+	// Without the flag arguments are sent as written
+	callExpandEnv = false
+	callToolCmd.Flags().Lookup("expand-env").Changed = false
+	stdout, err = runCLI(t, configPath, "call", "fetch", "fetch", args, "--print-request")
+	if err != nil {
+		t.Fatalf("call --print-request failed: %v", err)
+	}
+	if !strings.Contains(stdout, `"url":"https://${MCP_TEST_SITE}/a%20b"`) {
+		t.Errorf("arguments were expanded without --expand-env: %s", stdout)
+	}
+
+	_, err = runCLI(t, configPath, "call", "fetch", "fetch", `{"token": "${MCP_TEST_UNSET:?export a token}"}`, "--expand-env", "--print-request")
+	if err == nil || !strings.Contains(err.Error(), "export a token") {
+		t.Errorf("want the required variable reported, got %v", err)
+	}
+}
+
+func TestCallExpandEnvAudit(t *testing.T) {
+	t.Setenv("MCP_TEST_PAGE_TOKEN", "secret-cursor")
+	defer func() {
+		callExpandEnv = false
+		callToolCmd.Flags().Lookup("expand-env").Changed = false
+	}()
+
+	for _, expanded := range []bool{false, true} {
+		configDir := t.TempDir()
+		t.Setenv(config.ConfigDirEnv, configDir)
+		configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+		serversJSON, _ := json.Marshal(map[string]interface{}{
+			"mcpServers": map[string]interface{}{
+				"count": map[string]interface{}{"command": "sh", "args": []string{"-c", countServerScript}},
+			},
+			"audit": map[string]interface{}{"includeArgs": true, "expandedArgs": expanded},
+		})
+		writeTestFile(t, configPath, string(serversJSON))
+
+		if _, err := runCLI(t, configPath, "call", "count", "count", `{"cursor": "${MCP_TEST_PAGE_TOKEN}"}`, "--expand-env"); err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+		entries, err := audit.Read(filepath.Join(configDir, audit.FileName))
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("want one audit entry, got %d (%v)", len(entries), err)
+		}
+		want := "${MCP_TEST_PAGE_TOKEN}"
+		if expanded {
+			want = "secret-cursor"
+		}
+		if got := entries[0].Args["cursor"]; got != want {
+			t.Errorf("expandedArgs %v: recorded cursor %v, want %s", expanded, got, want)
+		}
+		if entries[0].ExpandEnv == expanded {
+			t.Errorf("expandedArgs %v: entry marked expandEnv %v", expanded, entries[0].ExpandEnv)
+		}
+
+		// A rerun expands the variables again and is recorded the same way
+		if _, err := runCLI(t, configPath, "history", "rerun", entries[0].ID, "--yes"); err != nil {
+			t.Fatalf("rerun failed: %v", err)
+		}
+		historyYes = false
+		historyRerunCmd.Flags().Lookup("yes").Changed = false
+		entries, err = audit.Read(filepath.Join(configDir, audit.FileName))
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("want two audit entries, got %d (%v)", len(entries), err)
+		}
+		rerun := entries[1]
+		if rerun.Source != audit.SourceRerun || rerun.Args["cursor"] != want || rerun.ExpandEnv != entries[0].ExpandEnv || rerun.SentHash != entries[0].SentHash {
+			t.Errorf("expandedArgs %v: rerun recorded as %+v, want it like %+v", expanded, rerun, entries[0])
+		}
+	}
+}
+
+// slowFirstCallScript is a fake MCP server that never answers its first
+// tools/call, like a server busy warming up
+const slowFirstCallScript = `n=0
+while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed -n 's/.*"id":\([0-9]*\).*/\1/p')
+  case "$line" in
+    *'"initialize"'*) echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"slow","version":"1.0.0"}}}' ;;
+    *'"tools/call"'*) n=$((n + 1)); [ "$n" -gt 1 ] && echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"content":[{"type":"text","text":"call '"$n"'"}]}}' ;;
+  esac
+done`
+
+func TestCallRetriesCachedIdempotentTools(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	serversJSON, _ := json.Marshal(map[string]interface{}{
+		"mcpServers": map[string]interface{}{
+			"slow": map[string]interface{}{
+				"command": "sh",
+				"args":    []string{"-c", slowFirstCallScript},
+				"timeout": 1,
+				"session": map[string]string{"type": "stateless"},
+				"retry":   map[string]int{"maxAttempts": 2, "initialDelayMs": 1},
+			},
+		},
+	})
+	writeTestFile(t, configPath, string(serversJSON))
+
+	// Without the tool's annotations a timed out call may have run, so it fails
+	if _, err := runCLI(t, configPath, "--no-daemon", "call", "slow", "read_page", "{}"); err == nil {
+		t.Fatal("expected the timed out call of an unknown tool to fail")
+	}
+
+	cfg, err := LoadConfiguration(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := toolcache.Default(cfg.ToolCacheDuration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	readOnly := true
+	tools := []mcp.Tool{{Name: "read_page", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: &readOnly}}}
+	if err := cache.Put("slow", cfg.MCPServers["slow"], tools); err != nil {
+		t.Fatal(err)
+	}
+
+	// The call path never lists tools, so the retry relies on the cached list
+	stdout, err := runCLI(t, configPath, "--no-daemon", "call", "slow", "read_page", "{}")
+	if err != nil {
+		t.Fatalf("expected the cached read-only tool to be retried: %v", err)
+	}
+	if !strings.Contains(stdout, "call 2") {
+		t.Errorf("unexpected result:\n%s", stdout)
+	}
+}
+
+func TestListServersState(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {
+		"browser": {"command": "npx", "args": ["-y", "chrome-devtools-mcp@latest"]},
+		"time": {"command": "uvx", "args": ["mcp-server-time"]}
+	}}`)
+	defer func() {
+		listServersState, listServersOutput = false, outputText
+		for _, name := range []string{"state", "output"} {
+			listServersCmd.Flags().Lookup(name).Changed = false
+		}
+	}()
+
+	// A session this process runs is live
+	store := session.NewFileStore(session.DefaultSessionsDir())
+	if err := store.SaveSession(&session.SessionInfo{
+		SessionID:    "browser-live",
+		Name:         "browser",
+		Status:       session.Active,
+		PID:          os.Getpid(),
+		LastActivity: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, err := runCLI(t, configPath, "list-servers", "--state")
+	if err != nil {
+		t.Fatalf("list-servers --state failed: %v", err)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("session: active, live (pid %d) | daemon: -", os.Getpid()),
+		"time | uvx mcp-server-time | session: none | daemon: -",
+		"The daemon is not running.",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output lacks %q:\n%s", want, stdout)
+		}
+	}
+
+	stdout, err = runCLI(t, configPath, "list-servers", "--state", "--output", "json")
+	if err != nil {
+		t.Fatalf("list-servers --state --output json failed: %v", err)
+	}
+	var report serverstate.Report
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("stdout is not a report: %v\n%s", err, stdout)
+	}
+	if !report.State || len(report.Servers) != 2 || report.Servers[0].Session == nil || !report.Servers[0].Session.Live {
+		t.Errorf("report = %s", stdout)
+	}
+}
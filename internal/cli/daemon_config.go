@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+)
+
+var daemonConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show the daemon configuration in effect",
+	Long: `Show the daemon configuration the running daemon uses, with defaults applied
+and secrets redacted, and the daemon.json it came from. Without a running
+daemon, show what one would load.`,
+	Args: cobra.NoArgs,
+	RunE: runDaemonConfig,
+}
+
+var daemonConfigSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a daemon.json setting",
+	Long: `Set a daemon.json setting, such as maxIdleTime or sampling.model, and have a
+running daemon reload the file. The value is read as JSON, or else taken as a
+string; null removes the setting so its default applies. Values the daemon
+would reject are reported and nothing is written.
+
+address, jobSpillDir and sampling apply once the daemon restarts.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDaemonConfigSet,
+}
+
+func init() {
+	// A config command fails because of the file or the daemon, not its usage
+	daemonConfigCmd.SilenceUsage = true
+	daemonConfigSetCmd.SilenceUsage = true
+}
+
+func runDaemonConfig(cmd *cobra.Command, args []string) error {
+	client := daemon.SharedDaemonClient()
+	if !client.IsDaemonRunning() {
+		fmt.Fprintln(os.Stderr, "The daemon is not running; this is the configuration it would load.")
+		return writeDaemonConfig(os.Stdout, daemon.LoadDaemonConfig(daemon.GetDaemonConfigPath()))
+	}
+
+	loaded, err := client.GetConfig()
+	if err != nil {
+		return err
+	}
+	return writeDaemonConfig(os.Stdout, loaded)
+}
+
+// writeDaemonConfig prints a daemon configuration and where it came from
+func writeDaemonConfig(out io.Writer, loaded *daemon.LoadedConfig) error {
+	if loaded.Defaults {
+		fmt.Fprintf(out, "Source: defaults (%s)\n", loaded.Warning)
+	} else if loaded.Path != "" {
+		fmt.Fprintf(out, "Source: %s\n", loaded.Path)
+	}
+	if len(loaded.Restart) > 0 {
+		fmt.Fprintf(out, "Changed, applied on restart: %s\n", strings.Join(loaded.Restart, ", "))
+	}
+
+	data, err := json.MarshalIndent(loaded.Config, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, string(data))
+	return nil
+}
+
+func runDaemonConfigSet(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+	path := daemon.GetDaemonConfigPath()
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	updated, err := daemon.SetDaemonConfigValue(data, key, value)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, updated, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("Set %s in %s\n", key, path)
+
+	client := daemon.SharedDaemonClient()
+	if !client.IsDaemonRunning() {
+		return nil
+	}
+	loaded, err := client.ReloadConfig()
+	if err != nil {
+		return fmt.Errorf("the daemon did not reload its config: %w", err)
+	}
+	if len(loaded.Restart) > 0 {
+		fmt.Printf("Restart the daemon to apply: %s\n", strings.Join(loaded.Restart, ", "))
+	} else {
+		fmt.Println("The daemon reloaded its config")
+	}
+	return nil
+}
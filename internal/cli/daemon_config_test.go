@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestDaemonConfigSet(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, configDir)
+	configPath := filepath.Join(configDir, "mcp_servers.json")
+	writeTestFile(t, configPath, timeServerConfig)
+	daemonPath := filepath.Join(configDir, "daemon.json")
+
+	stdout, err := runCLI(t, configPath, "daemon", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "Source: defaults") || !strings.Contains(stdout, `"maxIdleTime": 3600`) {
+		t.Errorf("without daemon.json: %s", stdout)
+	}
+
+	if _, err := runCLI(t, configPath, "daemon", "config", "set", "maxIdleTime", "600"); err != nil {
+		t.Fatal(err)
+	}
+	stdout, err = runCLI(t, configPath, "daemon", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "Source: "+daemonPath) || !strings.Contains(stdout, `"maxIdleTime": 600`) {
+		t.Errorf("after set: %s", stdout)
+	}
+
+	// Values the daemon would reject are not written
+	before, _ := os.ReadFile(daemonPath)
+	if _, err := runCLI(t, configPath, "daemon", "config", "set", "--", "maxSessions", "-2"); err == nil || !strings.Contains(err.Error(), "maxSessions") {
+		t.Errorf("error = %v", err)
+	}
+	if after, _ := os.ReadFile(daemonPath); string(after) != string(before) {
+		t.Errorf("daemon.json changed by a rejected set:\n%s", after)
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+)
+
+var (
+	daemonName      string
+	daemonStatusAll bool
+	useDaemon       bool
+	noDaemon        bool
+)
+
+// selectDaemon picks the daemon instance this command talks to and which
+// servers' calls go through it
+func selectDaemon() error {
+	section := config.ReadDaemonSection(daemonConfigPath(), configLoadOptions())
+	if err := selectDaemonInstance(section.Name); err != nil {
+		return err
+	}
+	mode, err := daemonRouting(section)
+	if err != nil {
+		return err
+	}
+	daemon.SetRouting(mode)
+	return nil
+}
+
+// selectDaemonInstance picks the daemon instance: --daemon-name, else the one
+// the environment already selects, else configured, the effective
+// configuration's daemon.name. The choice goes into the environment, so a
+// daemon the command starts runs as that instance.
+func selectDaemonInstance(configured string) error {
+	name := daemonName
+	if name == "" {
+		name = config.DaemonName()
+	}
+	if name == "" {
+		name = configured
+	}
+	if name == "" {
+		return nil
+	}
+	if err := config.ValidateDaemonName(name); err != nil {
+		return err
+	}
+	return os.Setenv(config.DaemonNameEnv, name)
+}
+
+// daemonRouting returns how this command routes calls through the daemon:
+// --use-daemon or --no-daemon, else daemon.routing
+func daemonRouting(section config.DaemonSection) (string, error) {
+	switch {
+	case useDaemon && noDaemon: // The tool command parses flags without cobra's check
+		return "", fmt.Errorf("--use-daemon and --no-daemon cannot be combined")
+	case useDaemon:
+		return config.DaemonRoutingAlways, nil
+	case noDaemon:
+		return config.DaemonRoutingNever, nil
+	}
+	if issues := section.Validate(); len(issues) > 0 {
+		return "", fmt.Errorf("invalid %s: %s", issues[0].Field, issues[0].Message)
+	}
+	return section.RoutingMode(), nil
+}
+
+// daemonConfigPath returns the configuration file GetConfigPath would, without
+// creating the config directory for commands that never load it
+func daemonConfigPath() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	if path, err := config.FindConfigFile(); err == nil {
+		return path
+	}
+	return ""
+}
+
+// instanceLabel names a daemon instance for display
+func instanceLabel(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// runDaemonStatusAll shows every daemon instance found in the data directory
+func runDaemonStatusAll(out io.Writer) error {
+	names, err := daemon.Instances()
+	if err != nil {
+		return fmt.Errorf("failed to find daemon instances: %w", err)
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(out, "No daemon instances found")
+		return nil
+	}
+
+	for _, name := range names {
+		status, err := daemon.NewNamedDaemonManager(name).Status()
+		if err != nil {
+			fmt.Fprintf(out, "%s: %v\n", instanceLabel(name), err)
+			continue
+		}
+		if !status.Running {
+			fmt.Fprintf(out, "%s: not running\n", instanceLabel(name))
+			continue
+		}
+		fmt.Fprintf(out, "%s: running (PID: %d) on %s, %d active sessions\n", instanceLabel(name), status.PID, status.Endpoint, status.SessionCount)
+		if status.Error != "" {
+			fmt.Fprintf(out, "  Error: %s\n", status.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestDaemonNameSelectsInstance(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, configDir)
+	t.Setenv(config.DaemonNameEnv, "")
+	defer func() { daemonName, daemonStatusAll = "", false }()
+
+	configPath := filepath.Join(configDir, "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {}, "daemon": {"name": "project"}}`)
+	for _, name := range []string{"daemon.log", "daemon-project.log", "daemon-other.pid"} {
+		writeTestFile(t, filepath.Join(configDir, name), "")
+	}
+
+	stdout, err := runCLI(t, configPath, "daemon", "status", "--all")
+	if err != nil {
+		t.Fatalf("status --all failed: %v", err)
+	}
+	want := "default: not running\nother: not running\nproject: not running\n"
+	if stdout != want {
+		t.Errorf("unexpected instances:\n%s\nwant:\n%s", stdout, want)
+	}
+	if got := os.Getenv(config.DaemonNameEnv); got != "project" {
+		t.Errorf("expected daemon.name to select the instance, got %q", got)
+	}
+
+	// The flag wins over the configuration
+	t.Setenv(config.DaemonNameEnv, "")
+	if _, err := runCLI(t, configPath, "--daemon-name", "scratch", "daemon", "status"); err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if got := os.Getenv(config.DaemonNameEnv); got != "scratch" {
+		t.Errorf("expected --daemon-name to select the instance, got %q", got)
+	}
+
+	t.Setenv(config.DaemonNameEnv, "")
+	daemonName = ""
+	if _, err := runCLI(t, configPath, "--daemon-name", "../escape", "daemon", "status"); err == nil || !strings.Contains(err.Error(), "invalid daemon name") {
+		t.Errorf("expected an invalid name error, got %v", err)
+	}
+}
+
+func TestDaemonRouting(t *testing.T) {
+	defer func() { useDaemon, noDaemon = false, false }()
+
+	tests := []struct {
+		useDaemon, noDaemon bool
+		routing             string
+		want                string
+	}{
+		{false, false, "", config.DaemonRoutingAuto},
+		{false, false, config.DaemonRoutingNever, config.DaemonRoutingNever},
+		{true, false, config.DaemonRoutingNever, config.DaemonRoutingAlways},
+		{false, true, config.DaemonRoutingAlways, config.DaemonRoutingNever},
+	}
+	for _, tt := range tests {
+		useDaemon, noDaemon = tt.useDaemon, tt.noDaemon
+		got, err := daemonRouting(config.DaemonSection{Routing: tt.routing})
+		if err != nil || got != tt.want {
+			t.Errorf("daemonRouting(use %v, no %v, %q) = %q, %v; want %q", tt.useDaemon, tt.noDaemon, tt.routing, got, err, tt.want)
+		}
+	}
+
+	useDaemon, noDaemon = false, false
+	if _, err := daemonRouting(config.DaemonSection{Routing: "sometimes"}); err == nil || !strings.Contains(err.Error(), "daemon.routing") {
+		t.Errorf("expected an invalid routing error, got %v", err)
+	}
+	useDaemon, noDaemon = true, true
+	if _, err := daemonRouting(config.DaemonSection{}); err == nil {
+		t.Error("expected --use-daemon and --no-daemon to conflict")
+	}
+}
+
+func TestDaemonRoutingFlagsConflict(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, configDir)
+	configPath := filepath.Join(configDir, "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {}}`)
+
+	_, err := runCLI(t, configPath, "--use-daemon", "--no-daemon", "list-servers")
+	if err == nil || !strings.Contains(err.Error(), "use-daemon") {
+		t.Errorf("expected the flags to conflict, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/service"
+)
+
+var daemonInstallCmd = &cobra.Command{
+	Use:   "install [--dry-run]",
+	Short: "Start the daemon at login as a system service",
+	Long: `Register the daemon with the platform's service manager so it starts at
+login: a systemd user unit on Linux, a launchd agent on macOS or a Task
+Scheduler task on Windows. The service runs this executable, by its absolute
+path, as "daemon start --foreground" with the current configuration
+directory. --dry-run prints the generated definition without writing it.`,
+	Args: cobra.NoArgs,
+	RunE: runDaemonInstall,
+}
+
+var daemonUninstallCmd = &cobra.Command{
+	Use:   "uninstall [--dry-run]",
+	Short: "Remove the service daemon install created",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonUninstall,
+}
+
+var daemonServiceDryRun bool
+
+// serviceRunner runs the service manager's commands
+var serviceRunner service.Runner = service.Exec
+
+func init() {
+	daemonInstallCmd.Flags().BoolVar(&daemonServiceDryRun, "dry-run", false, "print the service definition and commands without running them")
+	daemonUninstallCmd.Flags().BoolVar(&daemonServiceDryRun, "dry-run", false, "print what would be removed without removing it")
+}
+
+// serviceDefinition returns the daemon's service definition for this platform
+func serviceDefinition() (*service.Definition, error) {
+	opts, err := service.DefaultOptions()
+	if err != nil {
+		return nil, err
+	}
+	return service.For(runtime.GOOS, opts)
+}
+
+func runDaemonInstall(cmd *cobra.Command, args []string) error {
+	def, err := serviceDefinition()
+	if err != nil {
+		return err
+	}
+	if daemonServiceDryRun {
+		writeServiceDryRun(os.Stdout, def, true)
+		return nil
+	}
+
+	if err := def.Install(serviceRunner); err != nil {
+		return err
+	}
+	fmt.Printf("Created %s (%s)\n", def.Path, def.Manager)
+	for _, command := range def.Register {
+		fmt.Printf("Ran: %s\n", strings.Join(command, " "))
+	}
+	fmt.Println("The daemon now starts at login. Use 'mcp-cli-ent daemon uninstall' to remove it.")
+	return nil
+}
+
+func runDaemonUninstall(cmd *cobra.Command, args []string) error {
+	def, err := serviceDefinition()
+	if err != nil {
+		return err
+	}
+	if daemonServiceDryRun {
+		writeServiceDryRun(os.Stdout, def, false)
+		return nil
+	}
+
+	if err := def.Uninstall(serviceRunner); err != nil {
+		return err
+	}
+	for _, command := range def.Unregister {
+		fmt.Printf("Ran: %s\n", strings.Join(command, " "))
+	}
+	fmt.Printf("Removed %s (%s)\n", def.Path, def.Manager)
+	for _, command := range def.Cleanup {
+		fmt.Printf("Ran: %s\n", strings.Join(command, " "))
+	}
+	return nil
+}
+
+// writeServiceDryRun prints what install, or uninstall, would do
+func writeServiceDryRun(out io.Writer, def *service.Definition, install bool) {
+	if !install {
+		for _, command := range def.Unregister {
+			fmt.Fprintf(out, "Would run: %s\n", strings.Join(command, " "))
+		}
+		fmt.Fprintf(out, "Would remove %s (%s)\n", def.Path, def.Manager)
+		for _, command := range def.Cleanup {
+			fmt.Fprintf(out, "Would run: %s\n", strings.Join(command, " "))
+		}
+		return
+	}
+
+	fmt.Fprintf(out, "Would write %s (%s):\n\n", def.Path, def.Manager)
+	_, _ = out.Write(def.Content)
+	fmt.Fprintln(out)
+	for _, command := range def.Register {
+		fmt.Fprintf(out, "Would run: %s\n", strings.Join(command, " "))
+	}
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestDaemonInstallAndUninstall(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, configDir)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	configPath := filepath.Join(configDir, "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {}}`)
+
+	var ran []string
+	original := serviceRunner
+	serviceRunner = func(name string, args ...string) error {
+		ran = append(ran, strings.Join(append([]string{name}, args...), " "))
+		return nil
+	}
+	defer func() { serviceRunner = original }()
+	defer func() { daemonServiceDryRun = false }()
+
+	def, err := serviceDefinition()
+	if err != nil {
+		t.Skip(err) // No service manager on this platform
+	}
+
+	stdout, err := runCLI(t, configPath, "daemon", "install", "--dry-run")
+	if err != nil {
+		t.Fatalf("install --dry-run failed: %v", err)
+	}
+	if !strings.Contains(stdout, "Would write "+def.Path) || !strings.Contains(stdout, configDir) || !strings.Contains(stdout, "daemon start --foreground") {
+		t.Errorf("unexpected dry run:\n%s", stdout)
+	}
+	if _, err := os.Stat(def.Path); !os.IsNotExist(err) || len(ran) > 0 {
+		t.Fatalf("a dry run should change nothing (ran %q)", ran)
+	}
+	daemonServiceDryRun = false
+
+	stdout, err = runCLI(t, configPath, "daemon", "install")
+	if err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	content, err := os.ReadFile(def.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "daemon start --foreground") || !strings.Contains(stdout, "Created "+def.Path) || len(ran) != len(def.Register) {
+		t.Errorf("unexpected install (ran %q):\n%s", ran, stdout)
+	}
+	if string(content) != string(def.Content) {
+		t.Errorf("install wrote something other than the definition:\n%s", content)
+	}
+
+	ran = nil
+	stdout, err = runCLI(t, configPath, "daemon", "uninstall")
+	if err != nil {
+		t.Fatalf("uninstall failed: %v", err)
+	}
+	if _, err := os.Stat(def.Path); !os.IsNotExist(err) || !strings.Contains(stdout, "Removed "+def.Path) {
+		t.Errorf("definition not removed:\n%s", stdout)
+	}
+	if _, err := runCLI(t, configPath, "daemon", "uninstall"); err == nil {
+		t.Error("uninstalling twice should fail")
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// Exit codes of failed commands, so scripts can tell failures apart without
+// parsing messages. Failures none of them covers exit with ExitFailure.
+const (
+	ExitFailure           = 1
+	ExitServerNotFound    = 2 // The server is not configured
+	ExitToolNotFound      = 3 // The server has no such tool
+	ExitServerError       = 4 // The server answered with a JSON-RPC error
+	ExitProcessExited     = 5 // The server process went away
+	ExitTimeout           = 6
+	ExitSessionNotActive  = 7
+	ExitDaemonUnavailable = 8
+	ExitInterrupted       = 130 // The shell convention for SIGINT
+)
+
+// exitCodes maps the mcp sentinels to exit codes, most specific first
+var exitCodes = []struct {
+	err  error
+	code int
+	hint string
+}{
+	{mcp.ErrDaemonUnavailable, ExitDaemonUnavailable, "start it with 'mcp-cli-ent daemon start', or connect directly with --no-daemon"},
+	{mcp.ErrServerNotFound, ExitServerNotFound, "run 'mcp-cli-ent list-servers' to see the configured servers"},
+	{mcp.ErrToolNotFound, ExitToolNotFound, "run 'mcp-cli-ent list-tools <server>' to see the server's tools"},
+	{mcp.ErrSessionNotActive, ExitSessionNotActive, "start it with 'mcp-cli-ent session start <server>'"},
+	{mcp.ErrProcessExited, ExitProcessExited, "run with --verbose to see what the server logged"},
+	{mcp.ErrTimeout, ExitTimeout, "the server took too long to answer; allow it more with --timeout"},
+}
+
+// ExitCode returns the exit code for err, a command's failure
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if errors.Is(err, ErrInterrupted) {
+		return ExitInterrupted
+	}
+	for _, exit := range exitCodes {
+		if errors.Is(err, exit.err) {
+			return exit.code
+		}
+	}
+	var rpcErr *mcp.JSONRPCError
+	if errors.As(err, &rpcErr) {
+		return ExitServerError
+	}
+	return ExitFailure
+}
+
+// errorHint suggests what to do about err, or returns "" when it has no
+// suggestion or err already carries one
+func errorHint(err error) string {
+	var exitErr *client.ServerExitError
+	if errors.Is(err, ErrInterrupted) || errors.As(err, &exitErr) {
+		return "" // withServerHint explains exits the server's stderr accounts for
+	}
+	for _, exit := range exitCodes {
+		if errors.Is(err, exit.err) {
+			return exit.hint
+		}
+	}
+	return ""
+}
+
+// reportedError is a failure the command has explained on stderr already
+type reportedError struct {
+	err error
+}
+
+func (e *reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reportedError) Unwrap() error {
+	return e.err
+}
+
+// Report writes err, the failure Execute returned, to w with a hint when one
+// helps, and returns the code to exit with
+func Report(w io.Writer, err error) int {
+	var reported *reportedError
+	if !errors.As(err, &reported) {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		if hint := errorHint(err); hint != "" {
+			fmt.Fprintf(w, "Hint: %s\n", hint)
+		}
+	}
+	return ExitCode(err)
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, 0},
+		{"plain failure", errors.New("failed to load configuration"), ExitFailure},
+		{"interrupted", fmt.Errorf("%w", ErrInterrupted), ExitInterrupted},
+		{"unknown server", serverNotFoundError("weather", &config.Configuration{}), ExitServerNotFound},
+		{"unknown tool", mcp.Mark(errors.New("server 'docs' has no tool 'serch'"), mcp.ErrToolNotFound), ExitToolNotFound},
+		{"server error", fmt.Errorf("failed to call tool: %w", mcp.NewError(mcp.InvalidParams, "url is required", nil)), ExitServerError},
+		{"process exited", fmt.Errorf("failed to list tools: %w", &client.ServerExitError{Stderr: "panic", Err: io.EOF}), ExitProcessExited},
+		{"timeout", mcp.Mark(errors.New("request timeout"), mcp.ErrTimeout), ExitTimeout},
+		{"session", mcp.Mark(errors.New("session chrome is not active"), mcp.ErrSessionNotActive), ExitSessionNotActive},
+		{"daemon", mcp.Mark(errors.New("daemon is not running"), mcp.ErrDaemonUnavailable), ExitDaemonUnavailable},
+		{"daemon reporting a server error", mcp.Mark(mcp.NewError(mcp.InternalError, "boom", nil), mcp.ErrTimeout), ExitTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	var out bytes.Buffer
+	if code := Report(&out, mcp.Mark(errors.New("daemon is not running"), mcp.ErrDaemonUnavailable)); code != ExitDaemonUnavailable {
+		t.Errorf("Report returned %d, want %d", code, ExitDaemonUnavailable)
+	}
+	if !strings.HasPrefix(out.String(), "Error: daemon is not running\nHint: start it with 'mcp-cli-ent daemon start'") {
+		t.Errorf("unexpected report:\n%s", out.String())
+	}
+
+	// Failures the command explained itself only set the exit code
+	out.Reset()
+	if code := Report(&out, &reportedError{err: serverNotFoundError("weather", &config.Configuration{})}); code != ExitServerNotFound || out.Len() != 0 {
+		t.Errorf("want exit code %d and no output, got %d and %q", ExitServerNotFound, code, out.String())
+	}
+}
+
+func TestUnknownServerFails(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, configPath, `{"mcpServers": {"time": {"command": "uvx", "args": ["mcp-server-time"]}}}`)
+
+	for _, args := range [][]string{{"call-tool", "tiem", "now"}, {"list-tools", "tiem"}} {
+		_, err := runCLI(t, configPath, args...)
+		if !errors.Is(err, mcp.ErrServerNotFound) || ExitCode(err) != ExitServerNotFound {
+			t.Errorf("%v: want the server reported missing, got %v", args, err)
+		}
+	}
+}
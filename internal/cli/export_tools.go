@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/exports"
+	"github.com/mcp-cli-ent/mcp-cli/internal/serve"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolexport"
+)
+
+var exportToolsCmd = &cobra.Command{
+	Use:   "export-tools",
+	Short: "Export tool schemas as a JSON Schema bundle or an OpenAPI document",
+	Long: `Export the tools of every enabled server, or only --server's, for generating
+typed wrappers from their schemas.
+
+--format jsonschema writes a JSON Schema bundle with each tool's input schema
+under $defs, and its output schema, if it declares one, beside it with
+".output" appended to the name. --format openapi writes an OpenAPI 3.1
+document where each tool is a POST operation on /tools/{name} taking its
+input schema as the request body.
+
+Tools are named as serve publishes them, following serve.namespaceStyle,
+serve.separator and exportAs, and only the tools serve exports are included.
+Tool lists come from the tool cache unless --refresh is given. The output
+is sorted, so exports committed to a repository diff cleanly.`,
+	Args: cobra.NoArgs,
+	RunE: runExportTools,
+}
+
+var (
+	exportToolsServer string
+	exportToolsFormat string
+	exportToolsOut    string
+)
+
+func init() {
+	exportToolsCmd.Flags().StringVar(&exportToolsServer, "server", "", "only export this server's tools")
+	exportToolsCmd.Flags().StringVar(&exportToolsFormat, "format", toolexport.FormatJSONSchema, "jsonschema or openapi")
+	exportToolsCmd.Flags().StringVar(&exportToolsOut, "out", "", "write the export to this file instead of stdout")
+}
+
+func runExportTools(cmd *cobra.Command, args []string) error {
+	if exportToolsFormat != toolexport.FormatJSONSchema && exportToolsFormat != toolexport.FormatOpenAPI {
+		return fmt.Errorf("unknown format %q: use %s or %s", exportToolsFormat, toolexport.FormatJSONSchema, toolexport.FormatOpenAPI)
+	}
+	cmd.SilenceUsage = true // Failures from here on are about the servers
+
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return err
+	}
+	policy, err := exports.New(cfg.Exports)
+	if err != nil {
+		return err
+	}
+
+	var serverNames []string
+	if exportToolsServer != "" {
+		serverConfig, exists := cfg.GetServer(exportToolsServer)
+		if !exists {
+			return displayServerNotFoundError(exportToolsServer, cfg)
+		}
+		if !serverConfig.IsEnabled() {
+			return serverDisabledError(exportToolsServer, serverConfig)
+		}
+		serverNames = []string{exportToolsServer}
+	} else {
+		grouped, err := applyGroupFilter(cfg)
+		if err != nil {
+			return err
+		}
+		for name := range grouped.GetEnabledServers() {
+			serverNames = append(serverNames, name)
+		}
+		sort.Strings(serverNames)
+	}
+
+	ctx := commandContext(cmd)
+	cache := toolCache(cfg)
+	var exported []toolexport.Tool
+	for _, serverName := range serverNames {
+		serverConfig, _ := cfg.GetServer(serverName)
+		tools, err := serverTools(ctx, cache, serverName, serverConfig, refreshCache)
+		if err != nil {
+			return fmt.Errorf("%s: %w", serverName, err)
+		}
+		for _, tool := range client.VisibleTools(serverConfig, tools) {
+			if !policy.Allows(exports.KindTool, serverName, tool.Name) {
+				continue
+			}
+			exported = append(exported, toolexport.Tool{
+				Name:   serve.ExportName(cfg.Serve, serverName, serverConfig, tool.Name),
+				Server: serverName,
+				Tool:   tool,
+			})
+		}
+	}
+
+	data, err := toolexport.Render(exportToolsFormat, exported)
+	if err != nil {
+		return err
+	}
+	if exportToolsOut == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(exportToolsOut, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", exportToolsOut, err)
+	}
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Exported %d tool(s) to %s\n", len(exported), exportToolsOut)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportTools(t *testing.T) {
+	defer func() {
+		exportToolsServer, exportToolsFormat, exportToolsOut = "", "jsonschema", ""
+		for _, name := range []string{"server", "format", "out"} {
+			exportToolsCmd.Flags().Lookup(name).Changed = false
+		}
+	}()
+	fixtures, _ := filepath.Abs(filepath.Join("testdata", "fixtures"))
+
+	out := filepath.Join(t.TempDir(), "tools.openapi.json")
+	if _, err := runWithFixtures(t, timeServerConfig, "--replay", fixtures, "--refresh", "export-tools", "--server", "time", "--format", "openapi", "--out", out); err != nil {
+		t.Fatalf("export-tools failed: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		OpenAPI string                            `json:"openapi"`
+		Paths   map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("the export is not JSON: %v\n%s", err, data)
+	}
+	if doc.OpenAPI != "3.1.0" || doc.Paths["/tools/time__get_current_time"]["post"] == nil {
+		t.Errorf("OpenAPI export = %s", data)
+	}
+
+	// Names follow serve's namespacing
+	exportToolsServer, exportToolsOut = "", ""
+	unprefixed := `{"mcpServers": {"time": {"command": "uvx", "args": ["mcp-server-time"]}}, "serve": {"namespaceStyle": "none"}}`
+	stdout, err := runWithFixtures(t, unprefixed, "--replay", fixtures, "--refresh", "export-tools", "--format", "jsonschema")
+	if err != nil {
+		t.Fatalf("export-tools failed: %v", err)
+	}
+	var bundle struct {
+		Defs map[string]map[string]interface{} `json:"$defs"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &bundle); err != nil {
+		t.Fatalf("the export is not JSON: %v\n%s", err, stdout)
+	}
+	if tool := bundle.Defs["get_current_time"]; tool == nil || tool["x-mcp-server"] != "time" {
+		t.Errorf("JSON Schema export = %s", stdout)
+	}
+
+	if _, err := runWithFixtures(t, timeServerConfig, "export-tools", "--format", "yaml"); err == nil {
+		t.Error("an unknown format should fail")
+	}
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// timeServerConfig names the server the fixtures in testdata/fixtures were
+// recorded from; replaying them starts nothing
+const timeServerConfig = `{"mcpServers": {"time": {"command": "uvx", "args": ["mcp-server-time"]}}}`
+
+// runWithFixtures runs the CLI with args against the configuration in
+// serversJSON and returns what it printed
+func runWithFixtures(t *testing.T, serversJSON string, args ...string) (string, error) {
+	t.Helper()
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, configPath, serversJSON)
+	return runCLI(t, configPath, args...)
+}
+
+// runCLI runs the CLI with args against the configuration at configPath,
+// in whatever configuration directory the test set, and returns what it
+// printed
+func runCLI(t *testing.T, configPath string, args ...string) (string, error) {
+	t.Helper()
+	resetSessionManager()
+	rootCmd.SetErr(io.Discard)
+	defer func() {
+		for _, name := range []string{"config", "data-dir", "quiet", "refresh", "record", "replay", "no-cache", "use-daemon", "no-daemon"} {
+			flag := rootCmd.PersistentFlags().Lookup(name)
+			_ = flag.Value.Set(flag.DefValue)
+			flag.Changed = false // Later runs may set --record and --replay the other way round
+		}
+		callOutput, runOutput, runContinueOnError = outputText, outputText, false
+		rootCmd.SetErr(nil)
+		closeSessionManager()
+		resetSessionManager()
+	}()
+
+	var err error
+	stdout := captureStdout(t, func() {
+		rootCmd.SetArgs(append([]string{"--config", configPath, "--quiet"}, args...))
+		err = rootCmd.Execute()
+	})
+	return stdout, err
+}
+
+// cliHelperEnv carries the CLI arguments when the test binary is run as the
+// CLI, by tests that need a process of its own
+const cliHelperEnv = "MCP_CLI_TEST_ARGS"
+
+func TestCLIHelper(t *testing.T) {
+	args := os.Getenv(cliHelperEnv)
+	if args == "" {
+		t.Skip("run by tests that start the CLI as a process")
+	}
+	rootCmd.SetArgs(strings.Fields(args))
+	if err := Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// cliProcess returns a command running the CLI with args in a process of its
+// own, with configDir as its configuration directory and dir as its home
+func cliProcess(t *testing.T, dir, configDir, args string) *exec.Cmd {
+	t.Helper()
+	// An existing alias keeps the CLI from installing one
+	binDir := filepath.Join(dir, "bin")
+	alias := "mcpclient"
+	if runtime.GOOS == "windows" {
+		alias = "mcpclient.cmd"
+	}
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(binDir, alias), "")
+	if err := os.Chmod(filepath.Join(binDir, alias), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCLIHelper$")
+	cmd.Env = append(os.Environ(),
+		cliHelperEnv+"="+args,
+		config.ConfigDirEnv+"="+configDir,
+		config.DataDirEnv+"=",
+		"HOME="+dir,
+		"PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"),
+	)
+	return cmd
+}
+
+// resetSessionManager forgets the session manager, which is bound to the
+// configuration directory it was created in
+func resetSessionManager() {
+	sessionManagerOnce = sync.Once{}
+	globalSessionManager, sessionManagerInitErr = nil, nil
+}
+
+func TestReplayListTools(t *testing.T) {
+	fixtures, _ := filepath.Abs(filepath.Join("testdata", "fixtures"))
+	stdout, err := runWithFixtures(t, timeServerConfig, "--replay", fixtures, "list-tools", "time", "--refresh")
+	if err != nil {
+		t.Fatalf("list-tools failed: %v", err)
+	}
+
+	var tools []JSONTool
+	if err := json.Unmarshal([]byte(stdout), &tools); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+	if len(tools) != 1 || tools[0].Name != "get_current_time" {
+		t.Errorf("unexpected tools: %+v", tools)
+	}
+}
+
+func TestReplayCall(t *testing.T) {
+	fixtures, _ := filepath.Abs(filepath.Join("testdata", "fixtures"))
+	stdout, err := runWithFixtures(t, timeServerConfig, "--replay", fixtures, "call", "time", "get_current_time", `{"timezone": "UTC"}`, "--output", "json")
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if !strings.Contains(stdout, `2026-10-16T09:30:00+00:00`) {
+		t.Errorf("unexpected result:\n%s", stdout)
+	}
+
+	// A call that was never recorded fails, naming what was asked for
+	_, err = runWithFixtures(t, timeServerConfig, "--replay", fixtures, "call", "time", "get_current_time", `{"timezone": "Europe/Paris"}`)
+	if err == nil || !strings.Contains(err.Error(), `requested: {"arguments":{"timezone":"Europe/Paris"},"name":"get_current_time"}`) {
+		t.Errorf("want a missing fixture error, got %v", err)
+	}
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	fixtures := t.TempDir()
+	serversJSON, _ := json.Marshal(map[string]interface{}{
+		"mcpServers": map[string]interface{}{
+			"fake": map[string]interface{}{"command": "sh", "args": []string{"-c", fakeServerScript}},
+		},
+	})
+
+	if _, err := runWithFixtures(t, string(serversJSON), "--record", fixtures, "list-tools", "fake", "--refresh"); err != nil {
+		t.Fatalf("recording failed: %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(fixtures, "tools_list-*.json")); len(files) != 1 {
+		t.Fatalf("want one tools/list fixture, got %v", files)
+	}
+
+	// Without a shell to run, only the fixtures can answer
+	t.Setenv("PATH", "")
+	stdout, err := runWithFixtures(t, string(serversJSON), "--replay", fixtures, "list-tools", "fake", "--refresh")
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if !strings.Contains(stdout, `"echo"`) {
+		t.Errorf("unexpected tools:\n%s", stdout)
+	}
+}
@@ -0,0 +1,325 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/redact"
+)
+
+// History command and subcommands
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the tool calls recorded in the audit log",
+	Long: `Every tool call made with call, tool or the daemon is recorded in audit.jsonl
+in the data directory. Arguments are kept as a hash unless
+audit.includeArgs is set in the configuration; set audit.enabled to false to
+record nothing.`,
+}
+
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded tool calls, oldest first",
+	Args:  cobra.NoArgs,
+	RunE:  runHistoryList,
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Print a recorded tool call in full",
+	Long:  `Print a recorded tool call as JSON. An unambiguous prefix of the ID will do.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runHistoryShow,
+}
+
+var historyRerunCmd = &cobra.Command{
+	Use:   "rerun <id>",
+	Short: "Make a recorded tool call again",
+	Long: `Make a recorded tool call again with the same arguments, after asking for
+confirmation. Only calls recorded with audit.includeArgs can be rerun. The
+arguments are used as recorded, so the server's toolDefaults are not merged
+in a second time.
+
+Arguments named like secrets are recorded as fingerprints rather than their
+values, so a call that had any must be given them again with --arg
+key=value. --arg can change other arguments too. A call made with
+--expand-env is recorded with its variables as written, and they are
+expanded again for the rerun.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistoryRerun,
+}
+
+var (
+	historyServer string
+	historySince  string
+	historyJSON   bool
+	historyYes    bool
+	historyArgs   []string
+)
+
+func init() {
+	historyListCmd.Flags().StringVar(&historyServer, "server", "", "only calls to this server")
+	historyListCmd.Flags().StringVar(&historySince, "since", "", "only calls made within this long, e.g. 1h or 7d")
+	historyListCmd.Flags().BoolVar(&historyJSON, "json", false, "print the entries as JSON lines")
+	historyRerunCmd.Flags().BoolVarP(&historyYes, "yes", "y", false, "don't ask for confirmation")
+	historyRerunCmd.Flags().StringArrayVar(&historyArgs, "arg", nil, "set one argument as key=value, replacing the recorded one (repeatable)")
+}
+
+// auditLog returns the audit log tool calls are recorded in, or nil if the
+// configuration disables it
+func auditLog(cfg *config.Configuration) *audit.Log {
+	return audit.Default(cfg.Audit)
+}
+
+// recordCall records a call made with mcpClient and ctx in the audit log,
+// with the arguments ctx says to record. Calls made through the daemon are
+// left to the daemon, which records them itself, unless they fell back to a
+// direct client.
+func recordCall(ctx context.Context, log *audit.Log, source string, mcpClient mcp.MCPClient, serverName string, serverConfig config.ServerConfig, toolName string, arguments map[string]interface{}, start time.Time, result *mcp.ToolResult, err error) {
+	if daemonClient, ok := mcpClient.(*daemon.DaemonMCPClient); ok && daemonClient.ViaDaemon() {
+		return
+	}
+	log.Record(ctx, source, audit.Transport(serverConfig), serverName, toolName, arguments, start, result, err)
+}
+
+// expandArgumentsEnv expands environment variables in the arguments given
+// to a call, for --expand-env and pipeline steps with expandEnv; the
+// server's toolDefaults are merged in later as they are. Unless the audit
+// section allows expanded arguments, the returned ctx has the call recorded
+// with the arguments as written, keeping the values of the variables, such
+// as secrets, out of the audit log.
+func expandArgumentsEnv(ctx context.Context, cfg *config.Configuration, arguments map[string]interface{}) (context.Context, map[string]interface{}, error) {
+	expanded, err := config.ExpandArguments(arguments)
+	if err != nil {
+		return ctx, nil, fmt.Errorf("failed to expand environment variables in the arguments: %w", err)
+	}
+	if !cfg.Audit.RecordsExpandedArgs() {
+		ctx = audit.WithRecordedArgs(ctx, arguments)
+	}
+	return ctx, expanded, nil
+}
+
+// readHistory reads the audit log in the data directory
+func readHistory() ([]audit.Entry, error) {
+	path, err := audit.DefaultPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine data directory: %w", err)
+	}
+	entries, err := audit.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the audit log: %w", err)
+	}
+	return entries, nil
+}
+
+func runHistoryList(cmd *cobra.Command, args []string) error {
+	var since time.Duration
+	if historySince != "" {
+		var err error
+		if since, err = config.ParseRetention(historySince); err != nil || since <= 0 {
+			return fmt.Errorf("invalid --since '%s' (use a duration such as 1h or 7d)", historySince)
+		}
+	}
+
+	entries, err := readHistory()
+	if err != nil {
+		return err
+	}
+	entries = filterHistory(entries, historyServer, since, time.Now())
+
+	if historyJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(entries) == 0 {
+		fmt.Println("No tool calls recorded.")
+		return nil
+	}
+	writeHistoryList(os.Stdout, entries)
+	return nil
+}
+
+// filterHistory keeps the entries for server, if given, made no longer than
+// since before now, if given
+func filterHistory(entries []audit.Entry, server string, since time.Duration, now time.Time) []audit.Entry {
+	var kept []audit.Entry
+	for _, entry := range entries {
+		if server != "" && entry.Server != server {
+			continue
+		}
+		if since > 0 && entry.Time.Before(now.Add(-since)) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// writeHistoryList writes one line per entry
+func writeHistoryList(out io.Writer, entries []audit.Entry) {
+	for _, entry := range entries {
+		outcome := entry.Exit
+		if entry.Error != "" {
+			outcome += ": " + entry.Error
+		}
+		if entry.Cached {
+			outcome += ", cached"
+		}
+		fmt.Fprintf(out, "%s  %s  %s/%s  %s  %s (%s)\n",
+			entry.ID,
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			entry.Server, entry.Tool,
+			entry.Duration(),
+			outcome, entry.Source)
+	}
+}
+
+func runHistoryShow(cmd *cobra.Command, args []string) error {
+	entries, err := readHistory()
+	if err != nil {
+		return err
+	}
+	entry, err := audit.Find(entries, args[0])
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entry)
+}
+
+func runHistoryRerun(cmd *cobra.Command, args []string) error {
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return err
+	}
+	entries, err := readHistory()
+	if err != nil {
+		return err
+	}
+	entry, err := audit.Find(entries, args[0])
+	if err != nil {
+		return err
+	}
+	if entry.Args == nil && entry.ArgsHash != audit.HashArgs(nil) {
+		return fmt.Errorf("entry %s was recorded without its arguments; set audit.includeArgs in the configuration to rerun calls", entry.ID)
+	}
+
+	serverConfig, exists := cfg.GetServer(entry.Server)
+	if !exists {
+		return displayServerNotFoundError(entry.Server, cfg)
+	}
+	if !serverConfig.IsEnabled() {
+		return serverDisabledError(entry.Server, serverConfig)
+	}
+
+	arguments, err := rerunArguments(entry, historyArgs, callArgSchema(cfg, []string{entry.Server, entry.Tool}))
+	if err != nil {
+		return err
+	}
+	entry.Args = arguments
+
+	if !historyYes {
+		confirmed, err := confirmRerun(os.Stdin, os.Stderr, entry)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Fprintln(os.Stderr, "Not rerun.")
+			return nil
+		}
+	}
+
+	// A call made with --expand-env was recorded as written, so the rerun
+	// expands the variables again
+	ctx := commandContext(cmd)
+	if entry.ExpandEnv {
+		if ctx, arguments, err = expandArgumentsEnv(ctx, cfg, arguments); err != nil {
+			return err
+		}
+	}
+
+	status := startStatus()
+	defer status.Stop()
+
+	status.Phase("starting %s…", entry.Server)
+	mcpClient, err := daemon.NewSmartClient().CreateClient(entry.Server, serverConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer func() { _ = mcpClient.Close() }()
+
+	result, err := callTool(ctx, cfg, status, mcpClient, entry.Server, entry.Tool, arguments, audit.SourceRerun)
+	if err != nil {
+		return err
+	}
+	renderToolResult(os.Stdout, os.Stderr, result, markdownFormatter())
+	return nil
+}
+
+// rerunArguments returns the arguments to rerun entry with: the recorded
+// ones, with those given with --arg set. The fingerprints of redacted secrets
+// are never sent, so every redacted argument must be given again.
+func rerunArguments(entry *audit.Entry, argFlags []string, schema map[string]interface{}) (map[string]interface{}, error) {
+	arguments := make(map[string]interface{}, len(entry.Args))
+	for name, value := range entry.Args {
+		arguments[name] = value
+	}
+	given := make(map[string]bool, len(argFlags))
+	for _, text := range argFlags {
+		if name, _, err := parseArgFlag(text); err == nil {
+			given[name] = true
+		}
+	}
+	if err := applyArgFlags(arguments, argFlags, schema); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, name := range entry.Redacted {
+		if !given[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("entry %s was recorded with secrets redacted from %s; give them again with --arg, e.g. --arg %s=...", entry.ID, strings.Join(missing, ", "), missing[0])
+	}
+	return arguments, nil
+}
+
+// confirmRerun describes the call, with its secrets redacted, and asks
+// whether to make it again
+func confirmRerun(in io.Reader, out io.Writer, entry *audit.Entry) (bool, error) {
+	args, err := json.Marshal(redact.Members(entry.Args))
+	if err != nil {
+		return false, err
+	}
+	if entry.Args == nil {
+		args = []byte("{}")
+	}
+	fmt.Fprintf(out, "Call %s on %s with %s? [y/N] ", entry.Tool, entry.Server, args)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+)
+
+func TestFilterHistory(t *testing.T) {
+	now := time.Now()
+	entries := []audit.Entry{
+		{ID: "old", Server: "search", Time: now.Add(-3 * time.Hour)},
+		{ID: "recent", Server: "search", Time: now.Add(-10 * time.Minute)},
+		{ID: "other", Server: "docs", Time: now.Add(-5 * time.Minute)},
+	}
+
+	ids := func(entries []audit.Entry) string {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.ID)
+		}
+		return strings.Join(names, ",")
+	}
+
+	if got := ids(filterHistory(entries, "", 0, now)); got != "old,recent,other" {
+		t.Errorf("no filter = %s", got)
+	}
+	if got := ids(filterHistory(entries, "search", 0, now)); got != "old,recent" {
+		t.Errorf("--server search = %s", got)
+	}
+	if got := ids(filterHistory(entries, "", time.Hour, now)); got != "recent,other" {
+		t.Errorf("--since 1h = %s", got)
+	}
+	if got := ids(filterHistory(entries, "search", time.Hour, now)); got != "recent" {
+		t.Errorf("--server search --since 1h = %s", got)
+	}
+}
+
+func TestWriteHistoryList(t *testing.T) {
+	entries := []audit.Entry{
+		{ID: "a1b2c3d4e5f6", Time: time.Now(), Server: "search", Tool: "find", DurationMS: 1500, Exit: audit.ExitOK, Source: audit.SourceCall},
+		{ID: "0f0f0f0f0f0f", Time: time.Now(), Server: "docs", Tool: "fetch", DurationMS: 30, Exit: audit.ExitError, Error: "connection refused", Source: audit.SourceDaemon},
+	}
+
+	var out bytes.Buffer
+	writeHistoryList(&out, entries)
+	got := out.String()
+
+	for _, want := range []string{
+		"a1b2c3d4e5f6  ",
+		"  search/find  1.5s  ok (call)\n",
+		"  docs/fetch  30ms  error: connection refused (daemon)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestConfirmRerun(t *testing.T) {
+	entry := &audit.Entry{Server: "search", Tool: "find", Args: map[string]interface{}{"query": "go"}}
+
+	for _, tt := range []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	} {
+		var out bytes.Buffer
+		got, err := confirmRerun(strings.NewReader(tt.input), &out, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("answer %q = %v, want %v", tt.input, got, tt.want)
+		}
+		if !strings.Contains(out.String(), `Call find on search with {"query":"go"}? [y/N]`) {
+			t.Errorf("prompt = %q", out.String())
+		}
+	}
+}
+
+func TestRerunArguments(t *testing.T) {
+	entry := &audit.Entry{
+		ID:       "a1b2c3d4e5f6",
+		Args:     map[string]interface{}{"query": "go", "page_token": "[redacted c2Vj…1a2b3c4d]"},
+		Redacted: []string{"page_token"},
+	}
+
+	// The fingerprint is never sent in place of the secret
+	if _, err := rerunArguments(entry, nil, nil); err == nil || !strings.Contains(err.Error(), "--arg page_token=") {
+		t.Errorf("want a redacted argument to be refused, got %v", err)
+	}
+
+	got, err := rerunArguments(entry, []string{"page_token=secret-cursor"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["page_token"] != "secret-cursor" || got["query"] != "go" {
+		t.Errorf("arguments = %v", got)
+	}
+	if entry.Args["page_token"] != "[redacted c2Vj…1a2b3c4d]" {
+		t.Error("the recorded arguments were changed")
+	}
+
+	// The prompt doesn't show the secret given again
+	var out bytes.Buffer
+	if _, err := confirmRerun(strings.NewReader("n\n"), &out, &audit.Entry{Server: "search", Tool: "find", Args: got}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "secret-cursor") {
+		t.Errorf("prompt shows the secret: %q", out.String())
+	}
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// jobPollInterval is how often job wait asks the daemon about the job
+const jobPollInterval = 500 * time.Millisecond
+
+// Job command and subcommands
+var jobCmd = &cobra.Command{
+	Use:   "job",
+	Short: "Follow tool calls running in the daemon",
+	Long: `Follow tool calls started with call --async. The daemon runs them in the
+background and keeps finished jobs for an hour (jobRetention in daemon.json).`,
+}
+
+var jobStatusCmd = &cobra.Command{
+	Use:   "status <job-id>",
+	Short: "Show whether a job is queued, running, done or failed",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobStatus,
+}
+
+var jobResultCmd = &cobra.Command{
+	Use:   "result <job-id>",
+	Short: "Print the result of a finished job",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobResult,
+}
+
+var jobWaitCmd = &cobra.Command{
+	Use:   "wait <job-id>",
+	Short: "Wait for a job to finish and print its result",
+	Long: `Wait for a job to finish and print its result. Interrupting the wait leaves
+the job running; use job cancel to stop it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobWait,
+}
+
+var jobCancelCmd = &cobra.Command{
+	Use:   "cancel <job-id>",
+	Short: "Stop a queued or running job",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobCancel,
+}
+
+var (
+	jobJSON    bool
+	jobMaxWait time.Duration
+)
+
+func init() {
+	jobCmd.PersistentFlags().BoolVar(&jobJSON, "json", false, "print the job, or its result, as JSON")
+	jobWaitCmd.Flags().DurationVar(&jobMaxWait, "max-wait", 0, "give up waiting after this long, e.g. 10m (default: wait until the job finishes)")
+
+	// A job command fails because of the job or the daemon, not its usage
+	for _, cmd := range []*cobra.Command{jobStatusCmd, jobResultCmd, jobWaitCmd, jobCancelCmd} {
+		cmd.SilenceUsage = true
+	}
+}
+
+// runAsyncCall is call --async: the tool runs as a daemon job, whose ID is
+// printed
+func runAsyncCall(serverName string, serverConfig config.ServerConfig, toolName string, arguments map[string]interface{}) error {
+	status := startStatus()
+	defer status.Stop()
+
+	status.Phase("starting %s in the daemon…", serverName)
+	mcpClient, err := daemon.NewSessionBroker().Acquire(serverName, serverConfig)
+	if err != nil {
+		return err
+	}
+	_ = mcpClient.Close()
+
+	job, err := daemon.SharedDaemonClient().SubmitJob(serverName, toolName, arguments)
+	if err != nil {
+		return fmt.Errorf("failed to submit job: %w", err)
+	}
+	status.Stop()
+
+	if callOutput == outputJSON {
+		return writeJob(os.Stdout, job)
+	}
+	fmt.Println(job.ID)
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Job %s; follow it with: mcp-cli-ent job wait %s\n", job.State, job.ID)
+	}
+	return nil
+}
+
+func runJobStatus(cmd *cobra.Command, args []string) error {
+	job, err := daemon.SharedDaemonClient().GetJob(commandContext(cmd), args[0])
+	if err != nil {
+		return err
+	}
+	if jobJSON {
+		job.Result = nil // job result prints it
+		return writeJob(os.Stdout, job)
+	}
+	writeJobStatus(os.Stdout, job, time.Now())
+	return nil
+}
+
+func runJobResult(cmd *cobra.Command, args []string) error {
+	job, err := daemon.SharedDaemonClient().GetJob(commandContext(cmd), args[0])
+	if err != nil {
+		return err
+	}
+	if !job.State.Finished() {
+		return fmt.Errorf("job %s is still %s; use 'mcp-cli-ent job wait %s'", job.ID, job.State, job.ID)
+	}
+	return writeJobResult(job)
+}
+
+func runJobWait(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	if jobMaxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, jobMaxWait)
+		defer cancel()
+	}
+
+	status := startStatus()
+	defer status.Stop()
+	status.Phase("waiting for job %s…", args[0])
+	job, err := daemon.SharedDaemonClient().WaitJob(ctx, args[0], jobPollInterval)
+	status.Stop()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("job %s is still %s after %s", job.ID, job.State, jobMaxWait)
+	}
+	if err != nil {
+		return err
+	}
+	return writeJobResult(job)
+}
+
+func runJobCancel(cmd *cobra.Command, args []string) error {
+	job, err := daemon.SharedDaemonClient().CancelJob(args[0])
+	if err != nil {
+		return err
+	}
+	if jobJSON {
+		return writeJob(os.Stdout, job)
+	}
+	fmt.Printf("Cancelled job %s\n", job.ID)
+	return nil
+}
+
+// writeJobResult prints a finished job's result the way call prints one, or
+// returns the job's error
+func writeJobResult(job *daemon.Job) error {
+	if job.State == daemon.JobFailed {
+		return fmt.Errorf("job %s failed: %s", job.ID, job.Error)
+	}
+
+	result, err := jobToolResult(job)
+	if err != nil {
+		return err
+	}
+	if jobJSON {
+		return writeToolResultRaw(os.Stdout, result)
+	}
+	renderToolResult(os.Stdout, os.Stderr, result, markdownFormatter())
+	return nil
+}
+
+// jobToolResult decodes a finished job's tool result
+func jobToolResult(job *daemon.Job) (*mcp.ToolResult, error) {
+	var result mcp.ToolResult
+	if err := json.Unmarshal(job.Result, &result); err != nil {
+		return nil, fmt.Errorf("invalid result of job %s: %w", job.ID, err)
+	}
+	result.Raw = job.Result
+	return &result, nil
+}
+
+// writeJob writes a job as indented JSON
+func writeJob(out io.Writer, job *daemon.Job) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(job)
+}
+
+// writeJobStatus describes a job for people
+func writeJobStatus(out io.Writer, job *daemon.Job, now time.Time) {
+	fmt.Fprintf(out, "Job:     %s\n", job.ID)
+	fmt.Fprintf(out, "Call:    %s on %s\n", job.Tool, job.Server)
+	fmt.Fprintf(out, "State:   %s\n", job.State)
+
+	switch {
+	case job.Started == nil && job.Finished == nil:
+		fmt.Fprintf(out, "Queued:  %s\n", now.Sub(job.Created).Round(time.Second))
+	case job.Started == nil:
+		// Cancelled before it ran
+	case job.Finished == nil:
+		fmt.Fprintf(out, "Running: %s\n", now.Sub(*job.Started).Round(time.Second))
+	default:
+		fmt.Fprintf(out, "Took:    %s\n", job.Finished.Sub(*job.Started).Round(time.Millisecond))
+	}
+	if job.Error != "" {
+		fmt.Fprintf(out, "Error:   %s\n", job.Error)
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+)
+
+// fakeServerScript answers initialize and tools/list like a minimal MCP server
+const fakeServerScript = `while IFS= read -r line; do
+  case "$line" in
+    *'"initialize"'*) echo '{"jsonrpc":"2.0","id":0,"result":{"protocolVersion":"2024-11-05","capabilities":{},"serverInfo":{"name":"fake","version":"1.0.0"}}}' ;;
+    *'"tools/list"'*) echo '{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"echo","description":"Echo text","inputSchema":{"type":"object"}}]}}' ;;
+  esac
+done`
+
+func TestJSONOutputKeepsWarningsOffStdout(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, configDir)
+
+	// An invalid session whose file can't be deleted makes the session
+	// manager log a warning while it loads
+	sessionsDir := filepath.Join(configDir, "sessions")
+	writeTestFile(t, filepath.Join(sessionsDir, "broken.json"), `{"sessionId": "stuck"}`)
+	writeTestFile(t, filepath.Join(sessionsDir, "stuck.json", "keep"), "")
+
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	serverConfig, _ := json.Marshal(map[string]interface{}{
+		"mcpServers": map[string]interface{}{
+			"fake": map[string]interface{}{
+				"command": "sh",
+				"args":    []string{"-c", fakeServerScript},
+			},
+		},
+	})
+	writeTestFile(t, configPath, string(serverConfig))
+
+	var logOutput bytes.Buffer
+	restore := logging.RedirectStderr(&logOutput)
+	defer restore()
+
+	stdout := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"--config", configPath, "list-tools", "fake", "--refresh"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("list-tools failed: %v", err)
+		}
+		closeSessionManager()
+	})
+
+	var tools []JSONTool
+	if err := json.Unmarshal([]byte(stdout), &tools); err != nil {
+		t.Fatalf("stdout is not clean JSON: %v\n%s", err, stdout)
+	}
+	if len(tools) != 1 || tools[0].Name != "echo" {
+		t.Errorf("unexpected tools: %+v", tools)
+	}
+	if !strings.Contains(logOutput.String(), "Warning: failed to delete invalid session") {
+		t.Errorf("expected the warning on stderr, got %q", logOutput.String())
+	}
+}
+
+// writeTestFile creates path and its parent directories with content
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = original }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+
+	fn()
+	_ = w.Close()
+	return <-done
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Maintain the configuration file",
+}
+
+var configMigrateRemoteCmd = &cobra.Command{
+	Use:   "migrate-remote",
+	Short: "Connect directly to servers started through npx mcp-remote",
+	Long: `Find servers started as "npx mcp-remote <url>", a Node.js bridge from stdio to a
+remote server, and rewrite them to connect to the URL over HTTP, with the
+--header arguments as headers. Node.js is then no longer needed for them.
+
+Servers using what a direct connection cannot carry over, such as OAuth or the
+legacy SSE transport, are left as they are with a warning.
+
+Shows the rewrites; --write applies them, keeping the old file as <config>.bak.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigMigrateRemote,
+}
+
+var migrateRemoteWrite bool
+
+func init() {
+	configMigrateRemoteCmd.Flags().BoolVar(&migrateRemoteWrite, "write", false, "rewrite the configuration file")
+}
+
+func runConfigMigrateRemote(cmd *cobra.Command, args []string) error {
+	configPath := GetConfigPath()
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file: %w", err)
+	}
+	migrated, err := migrateRemoteServers(data, os.Stdout, os.Stderr)
+	if err != nil {
+		return fmt.Errorf("failed to parse configuration file '%s': %w", configPath, err)
+	}
+	if migrated == nil {
+		fmt.Println("No servers to migrate")
+		return nil
+	}
+
+	if !migrateRemoteWrite {
+		fmt.Printf("Run again with --write to rewrite '%s'\n", configPath)
+		return nil
+	}
+	backupPath := configPath + ".bak"
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to back up the configuration file: %w", err)
+	}
+	if err := os.WriteFile(configPath, migrated, 0644); err != nil {
+		return fmt.Errorf("failed to write configuration file: %w", err)
+	}
+	fmt.Printf("Rewrote '%s' (the old file is '%s')\n", configPath, backupPath)
+	return nil
+}
+
+// migrateRemoteServers rewrites the mcp-remote servers of a configuration
+// file, describing each rewrite to out and each server left as it is to
+// warnings. It returns the new file, or nil if no server was rewritten.
+func migrateRemoteServers(data []byte, out, warnings io.Writer) ([]byte, error) {
+	var file map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Numbers are written back as they were
+	if err := decoder.Decode(&file); err != nil {
+		return nil, err
+	}
+	servers, _ := file["mcpServers"].(map[string]interface{})
+
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rewritten := 0
+	for _, name := range names {
+		entry, ok := servers[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		migrated, ok, err := config.MigrateMCPRemote(entry)
+		if !ok {
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(warnings, "Warning: server '%s' left as it is: %v\n", name, err)
+			continue
+		}
+		servers[name] = migrated
+		rewritten++
+
+		fmt.Fprintf(out, "Server '%s': %s\n", name, migrated["url"])
+		if headers, ok := migrated["headers"].(map[string]interface{}); ok && len(headers) > 0 {
+			headerNames := make([]string, 0, len(headers))
+			for header := range headers {
+				headerNames = append(headerNames, header)
+			}
+			sort.Strings(headerNames)
+			fmt.Fprintf(out, "  headers: %s\n", strings.Join(headerNames, ", "))
+		}
+	}
+	if rewritten == 0 {
+		return nil, nil
+	}
+
+	migrated, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(migrated, '\n'), nil
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+const remoteServersConfig = `{
+  "mcpServers": {
+    "deepwiki": {
+      "command": "npx",
+      "args": ["-y", "mcp-remote", "https://mcp.deepwiki.com/mcp"],
+      "timeout": 45
+    },
+    "linear": {
+      "command": "npx",
+      "args": ["mcp-remote@latest", "https://mcp.linear.app/mcp", "--header", "Authorization:${AUTH_HEADER}"],
+      "env": {"AUTH_HEADER": "Bearer ${LINEAR_TOKEN}"},
+      "tags": ["work"]
+    },
+    "legacy": {
+      "command": "npx",
+      "args": ["-y", "mcp-remote", "https://mcp.example.com/sse"]
+    },
+    "time": {
+      "command": "uvx",
+      "args": ["mcp-server-time"]
+    }
+  }
+}
+`
+
+func TestMigrateRemoteServers(t *testing.T) {
+	var out, warnings bytes.Buffer
+	migrated, err := migrateRemoteServers([]byte(remoteServersConfig), &out, &warnings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantOut := "Server 'deepwiki': https://mcp.deepwiki.com/mcp\n" +
+		"Server 'linear': https://mcp.linear.app/mcp\n" +
+		"  headers: Authorization\n"
+	if out.String() != wantOut {
+		t.Errorf("output = %q, want %q", out.String(), wantOut)
+	}
+	if !strings.Contains(warnings.String(), "server 'legacy' left as it is") {
+		t.Errorf("warnings = %q, want one for the SSE server", warnings.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, path, string(migrated))
+	t.Setenv("LINEAR_TOKEN", "secret")
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("migrated configuration does not load: %v\n%s", err, migrated)
+	}
+
+	deepwiki := cfg.MCPServers["deepwiki"]
+	if deepwiki.Type != "http" || deepwiki.URL != "https://mcp.deepwiki.com/mcp" || deepwiki.Command != "" || deepwiki.Timeout != 45 {
+		t.Errorf("deepwiki = %+v", deepwiki)
+	}
+	linear := cfg.MCPServers["linear"]
+	if linear.Headers["Authorization"] != "Bearer secret" || len(linear.Env) != 0 || len(linear.Tags) != 1 {
+		t.Errorf("linear = %+v", linear)
+	}
+	if cfg.MCPServers["legacy"].Command != "npx" || cfg.MCPServers["time"].Command != "uvx" {
+		t.Error("servers that are not migrated should be left as they are")
+	}
+}
+
+func TestConfigMigrateRemoteWrite(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	path := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeTestFile(t, path, remoteServersConfig)
+	defer func() { migrateRemoteWrite = false }()
+
+	stdout, err := runCLI(t, path, "config", "migrate-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "--write") {
+		t.Errorf("stdout = %q, want a hint to use --write", stdout)
+	}
+	if data, _ := os.ReadFile(path); string(data) != remoteServersConfig {
+		t.Fatal("the configuration file changed without --write")
+	}
+
+	if _, err := runCLI(t, path, "config", "migrate-remote", "--write"); err != nil {
+		t.Fatal(err)
+	}
+	if backup, _ := os.ReadFile(path + ".bak"); string(backup) != remoteServersConfig {
+		t.Error("the old configuration file was not kept")
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Count(string(data), `"type": "http"`) != 2 {
+		t.Errorf("configuration file not rewritten:\n%s", data)
+	}
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// Values of --fail-on-error, which decides whether servers failing their
+// part in a command run on several servers fail the command
+const (
+	failOnAny  = "any"  // Any server failing fails the command
+	failOnAll  = "all"  // Only every server failing does
+	failOnNone = "none" // Failures are reported but never fail the command
+)
+
+// addFailOnErrorFlag adds --fail-on-error to cmd, defaulting to policy
+func addFailOnErrorFlag(cmd *cobra.Command, p *string, policy string) {
+	cmd.Flags().StringVar(p, "fail-on-error", policy, "with several servers, fail if any, all or none of them fail")
+	_ = cmd.RegisterFlagCompletionFunc("fail-on-error", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{failOnAny, failOnAll, failOnNone}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// checkFailOnError checks a --fail-on-error value
+func checkFailOnError(policy string) error {
+	switch policy {
+	case failOnAny, failOnAll, failOnNone:
+		return nil
+	}
+	return fmt.Errorf("invalid --fail-on-error '%s' (use %s, %s or %s)", policy, failOnAny, failOnAll, failOnNone)
+}
+
+// serverFailures collects the servers that failed their part in a command
+// run on several servers. It is safe for concurrent use.
+type serverFailures struct {
+	mutex  sync.Mutex
+	total  int              // Servers the command ran on
+	errors map[string]error // By server
+}
+
+// newServerFailures returns an empty collection for a command run on total
+// servers
+func newServerFailures(total int) *serverFailures {
+	return &serverFailures{total: total, errors: make(map[string]error)}
+}
+
+// runOnServers runs fn for every server concurrently, at most
+// maxBroadcastCalls at a time, and collects the errors it returns
+func runOnServers(servers []string, fn func(i int, serverName string) error) *serverFailures {
+	failures := newServerFailures(len(servers))
+	eachServer(servers, func(i int, serverName string) {
+		if err := fn(i, serverName); err != nil {
+			failures.add(serverName, err)
+		}
+	})
+	return failures
+}
+
+// add records that the server failed with err
+func (f *serverFailures) add(serverName string, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.errors[serverName] = err
+}
+
+// count returns how many servers failed
+func (f *serverFailures) count() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.errors)
+}
+
+// write writes the failed servers and their errors, sorted by server, or
+// nothing if none failed
+func (f *serverFailures) write(out io.Writer) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.errors) == 0 {
+		return
+	}
+	servers := make([]string, 0, len(f.errors))
+	for serverName := range f.errors {
+		servers = append(servers, serverName)
+	}
+	sort.Strings(servers)
+	fmt.Fprintf(out, "Failed on %d of %d servers:\n", len(servers), f.total)
+	for _, serverName := range servers {
+		fmt.Fprintf(out, "  %s: %v\n", serverName, f.errors[serverName])
+	}
+}
+
+// check returns the command's error under policy, a --fail-on-error value,
+// or nil when the policy accepts the failures. operation names what failed,
+// e.g. "listing tools".
+func (f *serverFailures) check(policy, operation string) error {
+	failed := f.count()
+	switch {
+	case failed == 0, policy == failOnNone:
+		return nil
+	case policy == failOnAll && failed < f.total:
+		return nil
+	}
+	return fmt.Errorf("%s failed on %d of %d servers", operation, failed, f.total)
+}
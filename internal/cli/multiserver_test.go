@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestServerFailuresExitCodeMatrix(t *testing.T) {
+	for _, tt := range []struct {
+		failed  int
+		total   int
+		policy  string
+		wantErr bool
+	}{
+		{0, 3, failOnAny, false},
+		{1, 3, failOnAny, true},
+		{3, 3, failOnAny, true},
+		{0, 3, failOnAll, false},
+		{1, 3, failOnAll, false},
+		{3, 3, failOnAll, true},
+		{0, 3, failOnNone, false},
+		{1, 3, failOnNone, false},
+		{3, 3, failOnNone, false},
+		{1, 1, failOnAll, true},
+	} {
+		failures := newServerFailures(tt.total)
+		for i := 0; i < tt.failed; i++ {
+			failures.add(fmt.Sprintf("server-%d", i), errors.New("connection refused"))
+		}
+		if err := failures.check(tt.policy, "listing tools"); (err != nil) != tt.wantErr {
+			t.Errorf("%d of %d failed with --fail-on-error %s: error %v, want error %v", tt.failed, tt.total, tt.policy, err, tt.wantErr)
+		}
+	}
+
+	for _, policy := range []string{"", "some", "ANY"} {
+		if checkFailOnError(policy) == nil {
+			t.Errorf("--fail-on-error %q should be refused", policy)
+		}
+	}
+}
+
+func TestRunOnServersCollectsFailures(t *testing.T) {
+	failures := runOnServers([]string{"b", "a", "c"}, func(_ int, serverName string) error {
+		if serverName == "c" {
+			return nil
+		}
+		return fmt.Errorf("%s is down", serverName)
+	})
+
+	var out bytes.Buffer
+	failures.write(&out)
+	if want := "Failed on 2 of 3 servers:\n  a: a is down\n  b: b is down\n"; out.String() != want {
+		t.Errorf("failures =\n%s\nwant\n%s", out.String(), want)
+	}
+	if err := failures.check(failOnAny, "listing tools"); err == nil || err.Error() != "listing tools failed on 2 of 3 servers" {
+		t.Errorf("error = %v", err)
+	}
+
+	out.Reset()
+	runOnServers([]string{"a"}, func(int, string) error { return nil }).write(&out)
+	if out.Len() != 0 {
+		t.Errorf("nothing should be written without failures, got %q", out.String())
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// showStreamEvent shows an event of a tool call streamed through the
+// daemon: progress on the status line, log messages above it
+func showStreamEvent(status *statusReporter, toolName string, event daemon.StreamEvent) {
+	switch {
+	case event.Progress != nil:
+		status.Update("calling %s… %s", toolName, progressText(event.Progress))
+	case event.Message != nil:
+		status.Printf("[%s] %s\n", event.Message.Level, messageText(event.Message.Data))
+	}
+}
+
+// progressText renders a progress notification as "3/10 message", or as a
+// bare count when the total is unknown
+func progressText(progress *mcp.ProgressParams) string {
+	text := formatProgressNumber(progress.Progress)
+	if progress.Total > 0 {
+		text += "/" + formatProgressNumber(progress.Total)
+	}
+	if progress.Message != "" {
+		text += " " + progress.Message
+	}
+	return text
+}
+
+// formatProgressNumber drops the decimals of whole numbers
+func formatProgressNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// messageText returns a log message's data as text: strings as they are,
+// anything else as its JSON
+func messageText(data json.RawMessage) string {
+	var text string
+	if json.Unmarshal(data, &text) == nil {
+		return text
+	}
+	return string(data)
+}
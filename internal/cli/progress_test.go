@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+func TestProgressText(t *testing.T) {
+	cases := []struct {
+		progress mcp.ProgressParams
+		want     string
+	}{
+		{mcp.ProgressParams{Progress: 3, Total: 10, Message: "page 3"}, "3/10 page 3"},
+		{mcp.ProgressParams{Progress: 0.5, Total: 1}, "0.5/1"},
+		{mcp.ProgressParams{Progress: 42}, "42"},
+	}
+	for _, c := range cases {
+		if got := progressText(&c.progress); got != c.want {
+			t.Errorf("progressText(%+v) = %q, want %q", c.progress, got, c.want)
+		}
+	}
+}
+
+func TestShowStreamEventMessage(t *testing.T) {
+	var out syncBuffer
+	status := newStatusReporter(&out, statusLog)
+	status.Phase("calling %s…", "crawl")
+
+	showStreamEvent(status, "crawl", daemon.StreamEvent{Type: daemon.StreamEventProgress, Progress: &mcp.ProgressParams{Progress: 2, Total: 4}})
+	showStreamEvent(status, "crawl", daemon.StreamEvent{Type: daemon.StreamEventMessage, Message: &mcp.LoggingMessageParams{Level: "info", Data: json.RawMessage(`"fetched /about"`)}})
+	showStreamEvent(status, "crawl", daemon.StreamEvent{Type: daemon.StreamEventMessage, Message: &mcp.LoggingMessageParams{Level: "info", Data: json.RawMessage(`{"url":"/docs"}`)}})
+	status.Stop()
+
+	want := "[status] calling crawl…\n" +
+		"[status] calling crawl… 2/4\n" +
+		"[info] fetched /about\n" +
+		"[info] {\"url\":\"/docs\"}\n"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/serve"
+)
+
+var listResourcesCmd = &cobra.Command{
+	Use:   "list-resources [server-name]",
+	Short: "List resources from MCP servers",
+	Long: `List the resources MCP servers offer, with their URIs and types.
+If server-name is provided, lists resources from that server only.
+If omitted, lists resources from all enabled servers, several at once, and
+names the servers that offer none. Servers that fail are reported on stderr;
+--fail-on-error decides whether they fail the command. A server without
+resources is not a failure.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runListResources,
+}
+
+// listResourcesFailOn is list-resources' --fail-on-error
+var listResourcesFailOn string
+
+func init() {
+	listResourcesCmd.Flags().StringVarP(&serverGroup, "group", "g", "", "only list resources from servers carrying this tag")
+	addFailOnErrorFlag(listResourcesCmd, &listResourcesFailOn, failOnAny)
+}
+
+// resourceListing is one server's part in listing resources
+type resourceListing struct {
+	server      string
+	resources   []mcp.Resource
+	unsupported bool // The server has no resources/list
+	err         error
+}
+
+func runListResources(cmd *cobra.Command, args []string) error {
+	if err := checkFailOnError(listResourcesFailOn); err != nil {
+		return err
+	}
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return err
+	}
+	factory, err := getSessionAwareClientFactory()
+	if err != nil {
+		return fmt.Errorf("failed to create client factory: %w", err)
+	}
+	ctx := commandContext(cmd)
+
+	var servers []string
+	if len(args) == 1 {
+		serverName := args[0]
+		serverConfig, exists := cfg.GetServer(serverName)
+		if !exists {
+			return displayServerNotFoundError(serverName, cfg)
+		}
+		if !serverConfig.IsEnabled() {
+			return serverDisabledError(serverName, serverConfig)
+		}
+		if serverGroup != "" && !serverConfig.HasTag(serverGroup) {
+			return fmt.Errorf("server '%s' is not in group '%s'", serverName, serverGroup)
+		}
+		servers = []string{serverName}
+	} else {
+		if cfg, err = applyGroupFilter(cfg); err != nil {
+			return err
+		}
+		if servers, err = broadcastServers(cfg, nil, true); err != nil {
+			return err
+		}
+	}
+
+	status := startStatus()
+	defer status.Stop()
+	status.Phase("listing resources from %d server(s)…", len(servers))
+	listings, failures := listResources(ctx, cfg, servers, factory.CreateClient)
+	status.Stop()
+
+	if len(args) == 1 {
+		listing := listings[0]
+		if listing.err != nil {
+			return withServerHint(fmt.Errorf("failed to list resources: %w", listing.err))
+		}
+		if listing.unsupported || len(listing.resources) == 0 {
+			fmt.Printf("%s offers no resources.\n", listing.server)
+			return nil
+		}
+		writeResources(os.Stdout, listing.resources)
+		return nil
+	}
+	writeResourceListings(os.Stdout, listings)
+	failures.write(os.Stderr)
+	return failures.check(listResourcesFailOn, "listing resources")
+}
+
+// listResources lists the resources of every server concurrently, at most
+// maxBroadcastCalls at a time. Listings come back in the order of servers,
+// with the servers that failed.
+func listResources(ctx context.Context, cfg *config.Configuration, servers []string, newClient serve.ClientFactory) ([]resourceListing, *serverFailures) {
+	listings := make([]resourceListing, len(servers))
+	failures := runOnServers(servers, func(i int, serverName string) error {
+		serverConfig, _ := cfg.GetServer(serverName)
+		listings[i] = listServerResources(ctx, serverName, serverConfig, newClient)
+		return listings[i].err
+	})
+	return listings, failures
+}
+
+// listServerResources lists one server's resources. A server answering that
+// it has no resources/list method is unsupported rather than failed.
+func listServerResources(ctx context.Context, serverName string, serverConfig config.ServerConfig, newClient serve.ClientFactory) resourceListing {
+	listing := resourceListing{server: serverName}
+	if listing.err = ctx.Err(); listing.err != nil {
+		return listing
+	}
+	mcpClient, err := newClient(serverName, serverConfig)
+	if err != nil {
+		listing.err = fmt.Errorf("failed to create client: %w", err)
+		return listing
+	}
+	defer func() { _ = mcpClient.Close() }()
+
+	resources, err := mcpClient.ListResources(ctx)
+	var rpcErr *mcp.JSONRPCError
+	switch {
+	case errors.As(err, &rpcErr) && rpcErr.Code == mcp.MethodNotFound:
+		listing.unsupported = true
+	case err != nil:
+		listing.err = err
+	default:
+		listing.resources = resources
+	}
+	return listing
+}
+
+// writeResources writes resources sorted by URI, one per line with their
+// name, type and description
+func writeResources(out io.Writer, resources []mcp.Resource) {
+	resources = append([]mcp.Resource(nil), resources...)
+	sort.Slice(resources, func(i, j int) bool { return resources[i].URI < resources[j].URI })
+	for _, resource := range resources {
+		line := resource.URI
+		if resource.Name != "" && resource.Name != resource.URI {
+			line += "  " + resource.Name
+		}
+		if resource.MimeType != "" {
+			line += " (" + resource.MimeType + ")"
+		}
+		fmt.Fprintln(out, line)
+		if resource.Description != "" {
+			fmt.Fprintf(out, "    %s\n", strings.Join(strings.Fields(resource.Description), " "))
+		}
+	}
+}
+
+// writeResourceListings writes each server's resources under a header, then
+// names the servers without resources. Servers that failed are left out.
+func writeResourceListings(out io.Writer, listings []resourceListing) {
+	var without []string
+	printed := 0
+	for _, listing := range listings {
+		switch {
+		case listing.err != nil:
+		case len(listing.resources) == 0:
+			without = append(without, listing.server)
+		default:
+			if printed > 0 {
+				fmt.Fprintln(out)
+			}
+			printed++
+			fmt.Fprintf(out, "=== %s ===\n", listing.server)
+			writeResources(out, listing.resources)
+		}
+	}
+
+	if printed == 0 {
+		fmt.Fprintln(out, "No resources found.")
+	}
+	if len(without) > 0 {
+		fmt.Fprintf(out, "\nNo resources: %s\n", strings.Join(without, ", "))
+	}
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// fakeResourceServer answers resources/list with canned resources or an error
+type fakeResourceServer struct {
+	mcp.MCPClient // Unused methods panic
+
+	resources []mcp.Resource
+	err       error
+}
+
+func (f *fakeResourceServer) ListResources(context.Context) ([]mcp.Resource, error) {
+	return f.resources, f.err
+}
+
+func (f *fakeResourceServer) Close() error { return nil }
+
+func TestListResourcesAcrossServers(t *testing.T) {
+	servers := map[string]*fakeResourceServer{
+		"docs": {resources: []mcp.Resource{
+			{URI: "file:///b.md", Name: "b", MimeType: "text/markdown"},
+			{URI: "file:///a.md", Name: "a", Description: "The\nfirst"},
+		}},
+		"time":   {err: fmt.Errorf("wrapped: %w", &mcp.JSONRPCError{Code: mcp.MethodNotFound, Message: "Method not found"})},
+		"empty":  {},
+		"broken": {err: errors.New("connection refused")},
+	}
+	cfg := &config.Configuration{MCPServers: map[string]config.ServerConfig{}}
+	for name := range servers {
+		cfg.MCPServers[name] = config.ServerConfig{Command: "fake-" + name}
+	}
+	newClient := func(name string, _ config.ServerConfig) (mcp.MCPClient, error) {
+		return servers[name], nil
+	}
+
+	listings, failures := listResources(context.Background(), cfg, []string{"broken", "docs", "empty", "time"}, newClient)
+	for _, listing := range listings {
+		if listing.server == "time" && (!listing.unsupported || listing.err != nil) {
+			t.Errorf("a server without resources/list should be unsupported, not failed: %+v", listing)
+		}
+	}
+
+	var out, errOut bytes.Buffer
+	writeResourceListings(&out, listings)
+	want := `=== docs ===
+file:///a.md  a
+    The first
+file:///b.md  b (text/markdown)
+
+No resources: empty, time
+`
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+	failures.write(&errOut)
+	if want := "Failed on 1 of 4 servers:\n  broken: connection refused\n"; errOut.String() != want {
+		t.Errorf("failures =\n%s\nwant\n%s", errOut.String(), want)
+	}
+	if err := failures.check(failOnAny, "listing resources"); err == nil || !strings.Contains(err.Error(), "1 of 4 servers") {
+		t.Errorf("error = %v, want only the failed server counted", err)
+	}
+
+	out.Reset()
+	listings, failures = listResources(context.Background(), cfg, []string{"empty", "time"}, newClient)
+	if err := failures.check(failOnAny, "listing resources"); err != nil {
+		t.Errorf("servers without resources should not fail the command: %v", err)
+	}
+	writeResourceListings(&out, listings)
+	if !strings.HasPrefix(out.String(), "No resources found.") {
+		t.Errorf("output = %q", out.String())
+	}
+}
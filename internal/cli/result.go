@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/jsonpath"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/render"
+)
+
+// Tool result output formats for call
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// renderToolResult writes a tool result for reading: text blocks verbatim and
+// separated by blank lines, a one-line header for everything else. Error
+// results are flagged on errOut. A non-nil format is applied to text blocks.
+func renderToolResult(out, errOut io.Writer, result *mcp.ToolResult, format func(string) string) {
+	if result != nil && result.IsError {
+		fmt.Fprintln(errOut, "Error: the tool reported a failure")
+	}
+	if result == nil || len(result.Content) == 0 {
+		fmt.Fprintln(errOut, "(no content)")
+		return
+	}
+
+	for i, block := range result.Blocks() {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		writeBlock(out, block, format)
+	}
+}
+
+// markdownFormatter returns the text formatter for --render, or nil when
+// rendering is off or stdout is not a terminal
+func markdownFormatter() func(string) string {
+	if !callRender || !render.IsTerminal(os.Stdout) {
+		return nil
+	}
+	width := render.Width(os.Stdout)
+	return func(text string) string {
+		return render.Markdown(text, width)
+	}
+}
+
+// extractFromResult applies path to the result's structuredContent or, when
+// there is none, to the first text block parsed as JSON
+func extractFromResult(result *mcp.ToolResult, path jsonpath.Path) (interface{}, error) {
+	if result == nil {
+		return nil, fmt.Errorf("no result to extract from")
+	}
+
+	source := result.StructuredContent
+	if source == nil {
+		var text *mcp.ContentBlock
+		for _, block := range result.Blocks() {
+			if block.Type == "text" {
+				text = &block
+				break
+			}
+		}
+		if text == nil {
+			return nil, fmt.Errorf("cannot extract '%s': the result has no structured content or text", path)
+		}
+
+		dec := json.NewDecoder(strings.NewReader(text.Text))
+		dec.UseNumber()
+		if err := dec.Decode(&source); err != nil {
+			return nil, fmt.Errorf("cannot extract '%s': the result has no structured content and its text is not JSON", path)
+		}
+	}
+
+	value, err := path.Eval(source)
+	if err != nil {
+		return nil, fmt.Errorf("cannot extract '%s': %w", path, err)
+	}
+	return value, nil
+}
+
+// writeExtracted prints an extracted string as-is and any other value as JSON
+func writeExtracted(out io.Writer, value interface{}) error {
+	if s, ok := value.(string); ok {
+		_, err := fmt.Fprintln(out, s)
+		return err
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	default:
+		return json.NewEncoder(out).Encode(value)
+	}
+}
+
+// writeBlock writes one content block, ending with a newline
+func writeBlock(out io.Writer, block mcp.ContentBlock, format func(string) string) {
+	switch block.Type {
+	case "text":
+		text := block.Text
+		if format != nil {
+			text = format(text)
+		}
+		fmt.Fprint(out, text)
+		if !strings.HasSuffix(text, "\n") {
+			fmt.Fprintln(out)
+		}
+	case "image", "audio":
+		if callSaveDir != "" {
+			fmt.Fprintf(out, "[%s: %s]\n", block.Type, saveBinary(callSaveDir, block.Type, block.MimeType, block.Data))
+			return
+		}
+		fmt.Fprintf(out, "[%s: %s - not shown, use --raw for the data]\n", block.Type, describeBinary(block.MimeType, block.Data))
+	case "resource":
+		if block.Resource == nil {
+			fmt.Fprintln(out, "[resource]")
+			return
+		}
+		header := block.Resource.URI
+		if block.Resource.MimeType != "" {
+			header += " (" + block.Resource.MimeType + ")"
+		}
+		fmt.Fprintf(out, "[resource: %s]\n", header)
+		if block.Resource.Text != "" {
+			writeBlock(out, mcp.ContentBlock{Type: "text", Text: block.Resource.Text}, format)
+		} else if block.Resource.Blob != "" && callSaveDir != "" {
+			fmt.Fprintf(out, "[%s]\n", saveBinary(callSaveDir, "resource", block.Resource.MimeType, block.Resource.Blob))
+		} else if block.Resource.Blob != "" {
+			fmt.Fprintf(out, "[%s - not shown, use --raw for the data]\n", describeBinary(block.Resource.MimeType, block.Resource.Blob))
+		}
+	case "resource_link":
+		header := block.URI
+		if block.Name != "" {
+			header = block.Name + " <" + block.URI + ">"
+		}
+		fmt.Fprintf(out, "[resource link: %s]\n", header)
+	default:
+		data := block.Raw
+		if data == nil {
+			data, _ = json.Marshal(block)
+		}
+		fmt.Fprintf(out, "[%s content] %s\n", block.Type, data)
+	}
+}
+
+// describeBinary summarizes base64 data: its type, size and, for PNG
+// images, dimensions
+func describeBinary(mimeType, data string) string {
+	if mimeType == "" {
+		mimeType = "unknown type"
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return fmt.Sprintf("%s, %d bytes base64", mimeType, len(data))
+	}
+
+	if strings.HasPrefix(mimeType, "image/png") && len(decoded) >= 24 {
+		width := int(decoded[16])<<24 | int(decoded[17])<<16 | int(decoded[18])<<8 | int(decoded[19])
+		height := int(decoded[20])<<24 | int(decoded[21])<<16 | int(decoded[22])<<8 | int(decoded[23])
+		return fmt.Sprintf("%s, %dx%d, %d bytes", mimeType, width, height, len(decoded))
+	}
+	return fmt.Sprintf("%s, %d bytes", mimeType, len(decoded))
+}
+
+// binaryExtensions name the files saveBinary writes, by content type
+var binaryExtensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/svg+xml":   ".svg",
+	"audio/wav":       ".wav",
+	"audio/x-wav":     ".wav",
+	"audio/mpeg":      ".mp3",
+	"audio/ogg":       ".ogg",
+	"audio/webm":      ".webm",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+}
+
+// saveBinary writes base64 data to a file in dir named after kind and a hash
+// of the data, so the same content is saved once, and says where it went
+func saveBinary(dir, kind, mimeType, data string) string {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return fmt.Sprintf("not saved, the data is not base64 (%s)", describeBinary(mimeType, data))
+	}
+
+	mediaType, _, _ := strings.Cut(mimeType, ";")
+	ext, known := binaryExtensions[strings.ToLower(strings.TrimSpace(mediaType))]
+	if !known {
+		ext = ".bin"
+	}
+	sum := sha256.Sum256(decoded)
+	path := filepath.Join(dir, fmt.Sprintf("%s-%x%s", kind, sum[:6], ext))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Sprintf("not saved: %v", err)
+	}
+	if err := os.WriteFile(path, decoded, 0644); err != nil {
+		return fmt.Sprintf("not saved: %v", err)
+	}
+	return fmt.Sprintf("saved to %s (%s)", path, describeBinary(mimeType, data))
+}
+
+// writeToolResultRaw writes the tool result exactly as the server sent it,
+// falling back to indented JSON when the client doesn't have its bytes
+func writeToolResultRaw(out io.Writer, result *mcp.ToolResult) error {
+	if result == nil || len(result.Raw) == 0 {
+		return writeToolResultJSON(out, result)
+	}
+	if _, err := out.Write(result.Raw); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+// writeToolResultJSON writes the full tool result as indented JSON
+func writeToolResultJSON(out io.Writer, result *mcp.ToolResult) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// countServerScript is a fake MCP server whose "count" tool answers how often
+// it was called in this process
+const countServerScript = `n=0
+while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed -n 's/.*"id":\([0-9]*\).*/\1/p')
+  case "$line" in
+    *'"initialize"'*) echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"count","version":"1.0.0"}}}' ;;
+    *'"tools/call"'*) n=$((n + 1)); echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"content":[{"type":"text","text":"call '"$n"'"}]}}' ;;
+  esac
+done`
+
+func TestCallUsesResultCache(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, configDir)
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	serversJSON, _ := json.Marshal(map[string]interface{}{
+		"mcpServers": map[string]interface{}{
+			"count": map[string]interface{}{
+				"command":    "sh",
+				"args":       []string{"-c", countServerScript},
+				"cacheTools": map[string]interface{}{"count": map[string]string{"ttl": "1h"}},
+			},
+		},
+	})
+	writeTestFile(t, configPath, string(serversJSON))
+
+	stdout, err := runCLI(t, configPath, "call", "count", "count", `{"page": 1}`)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if !strings.Contains(stdout, "call 1") {
+		t.Fatalf("unexpected result:\n%s", stdout)
+	}
+
+	// Without a shell to run, only the cache can answer
+	t.Setenv("PATH", "")
+	stdout, err = runCLI(t, configPath, "call", "count", "count", `{"page": 1}`)
+	if err != nil {
+		t.Fatalf("cached call failed: %v", err)
+	}
+	if !strings.Contains(stdout, "call 1") {
+		t.Errorf("unexpected cached result:\n%s", stdout)
+	}
+
+	// Other arguments and --no-cache need the server
+	if _, err := runCLI(t, configPath, "call", "count", "count", `{"page": 2}`); err == nil {
+		t.Error("a call with other arguments should reach the server")
+	}
+	if _, err := runCLI(t, configPath, "--no-cache", "call", "count", "count", `{"page": 1}`); err == nil {
+		t.Error("a call with --no-cache should reach the server")
+	}
+
+	entries, err := audit.Read(filepath.Join(configDir, audit.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cached []bool
+	for _, entry := range entries {
+		cached = append(cached, entry.Cached)
+	}
+	if len(cached) < 2 || cached[0] || !cached[1] {
+		t.Errorf("want the second call recorded as cached, got %v", cached)
+	}
+}
@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/jsonpath"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// pngHeader is the start of a 640x480 PNG, enough for describeBinary
+var pngHeader = []byte{
+	0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n',
+	0, 0, 0, 13, 'I', 'H', 'D', 'R',
+	0, 0, 0x02, 0x80, 0, 0, 0x01, 0xe0,
+}
+
+func TestRenderToolResult(t *testing.T) {
+	wav := base64.StdEncoding.EncodeToString([]byte("RIFF\x24\x00\x00\x00WAVEfmt "))
+	pdf := base64.StdEncoding.EncodeToString([]byte("%PDF-1.7\n"))
+	mixed := `{"content": [
+		{"type": "text", "text": "Recorded the call"},
+		{"type": "image", "mimeType": "image/png", "data": "` + base64.StdEncoding.EncodeToString(pngHeader) + `"},
+		{"type": "audio", "mimeType": "audio/wav", "data": "` + wav + `"},
+		{"type": "resource", "resource": {"uri": "file:///tmp/notes.md", "mimeType": "text/markdown", "text": "# Notes"}},
+		{"type": "resource", "resource": {"uri": "file:///tmp/report.pdf", "mimeType": "application/pdf", "blob": "` + pdf + `"}}
+	]}`
+	tests := []struct {
+		name    string
+		result  string
+		saveDir bool // Render with --save-dir, shown as $SAVE_DIR
+	}{
+		{"single_text", `{"content": [{"type": "text", "text": "# React\n\nA JavaScript library for building user interfaces."}]}`, false},
+		{"multiple_text", `{"content": [{"type": "text", "text": "Page loaded\n"}, {"type": "text", "text": "3 console messages"}]}`, false},
+		{"image", `{"content": [{"type": "text", "text": "Took a screenshot"}, {"type": "image", "mimeType": "image/png", "data": "` + base64.StdEncoding.EncodeToString(pngHeader) + `"}]}`, false},
+		{"resources", `{"content": [{"type": "resource", "resource": {"uri": "file:///tmp/trace.json", "mimeType": "application/json", "text": "{\"events\": 12}"}}, {"type": "resource_link", "uri": "https://react.dev/reference", "name": "React reference"}]}`, false},
+		{"error", `{"isError": true, "content": [{"type": "text", "text": "Navigation timeout of 30000 ms exceeded"}]}`, false},
+		{"empty", `{"content": []}`, false},
+		{"unknown_block", `{"content": [{"type": "chart", "series": [{"x": 1, "y": 2}], "title": "Load"}, "bare string"]}`, false},
+		{"mixed", mixed, false},
+		{"mixed_saved", mixed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result mcp.ToolResult
+			if err := json.Unmarshal([]byte(tt.result), &result); err != nil {
+				t.Fatalf("invalid test result: %v", err)
+			}
+
+			saveDir := t.TempDir()
+			if tt.saveDir {
+				callSaveDir = saveDir
+				defer func() { callSaveDir = "" }()
+			}
+
+			var out, errOut bytes.Buffer
+			renderToolResult(&out, &errOut, &result, nil)
+			got := "--- stdout\n" + out.String() + "--- stderr\n" + errOut.String()
+			got = strings.ReplaceAll(got, saveDir, "$SAVE_DIR")
+
+			golden := filepath.Join("testdata", "results", tt.name+".golden")
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatalf("failed to update golden file: %v", err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("output changed:\n got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestExtractFromResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "structured content wins over text",
+			result: `{"content": [{"type": "text", "text": "{\"id\": \"from text\"}"}], "structuredContent": {"id": "from structure"}}`,
+			path:   "id",
+			want:   "from structure\n",
+		},
+		{
+			name:   "first text block parsed as JSON",
+			result: `{"content": [{"type": "image", "data": "", "mimeType": "image/png"}, {"type": "text", "text": "{\"libraries\": [{\"id\": \"/facebook/react\"}]}"}]}`,
+			path:   "libraries[0].id",
+			want:   "/facebook/react\n",
+		},
+		{
+			name:   "numbers keep their precision",
+			result: `{"content": [{"type": "text", "text": "{\"big\": 9007199254740993}"}]}`,
+			path:   "big",
+			want:   "9007199254740993\n",
+		},
+		{
+			name:   "objects print as JSON",
+			result: `{"structuredContent": {"meta": {"ok": true}}}`,
+			path:   "meta",
+			want:   "{\n  \"ok\": true\n}\n",
+		},
+		{
+			name:   "wildcards print as a JSON array",
+			result: `{"structuredContent": {"items": [{"id": 1}, {"id": 2}]}}`,
+			path:   "items[*].id",
+			want:   "[\n  1,\n  2\n]\n",
+		},
+		{
+			name:    "missing path",
+			result:  `{"structuredContent": {"items": []}}`,
+			path:    "items[0]",
+			wantErr: "cannot extract 'items[0]': path not found: index 0 is out of range for $.items (length 0)",
+		},
+		{
+			name:    "text that is not JSON",
+			result:  `{"content": [{"type": "text", "text": "plain words"}]}`,
+			path:    "id",
+			wantErr: "cannot extract 'id': the result has no structured content and its text is not JSON",
+		},
+		{
+			name:    "no text or structure",
+			result:  `{"content": []}`,
+			path:    "id",
+			wantErr: "cannot extract 'id': the result has no structured content or text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result mcp.ToolResult
+			if err := json.Unmarshal([]byte(tt.result), &result); err != nil {
+				t.Fatal(err)
+			}
+			path, err := jsonpath.Parse(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			value, err := extractFromResult(&result, path)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			if err := writeExtracted(&out, value); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteToolResultRawIsByteIdentical(t *testing.T) {
+	raw := `{"content": [{"text": "café", "type": "text"}],  "isError":false}`
+	var result mcp.ToolResult
+	if err := json.Unmarshal([]byte(raw), &result); err != nil {
+		t.Fatal(err)
+	}
+	result.Raw = json.RawMessage(raw)
+
+	var out bytes.Buffer
+	if err := writeToolResultRaw(&out, &result); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != raw+"\n" {
+		t.Errorf("--raw output = %q, want the server's bytes %q", out.String(), raw+"\n")
+	}
+
+	// Without the server's bytes, the result is encoded as --output json does
+	result.Raw = nil
+	var fallback, encoded bytes.Buffer
+	if err := writeToolResultRaw(&fallback, &result); err != nil {
+		t.Fatal(err)
+	}
+	_ = writeToolResultJSON(&encoded, &result)
+	if fallback.String() != encoded.String() {
+		t.Errorf("fallback output = %q, want %q", fallback.String(), encoded.String())
+	}
+}
+
+func TestWriteToolResultJSONKeepsEveryBlock(t *testing.T) {
+	raw := `{"content": [
+		{"type": "audio", "mimeType": "audio/wav", "data": "UklGRg=="},
+		{"type": "resource", "resource": {"uri": "file:///tmp/report.pdf", "mimeType": "application/pdf", "blob": "JVBERg=="}},
+		{"type": "chart", "series": [1, 2]}
+	]}`
+	var result mcp.ToolResult
+	if err := json.Unmarshal([]byte(raw), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := writeToolResultJSON(&out, &result); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{`"data": "UklGRg=="`, `"blob": "JVBERg=="`, `"uri": "file:///tmp/report.pdf"`, `"series": [`} {
+		if !strings.Contains(out.String(), field) {
+			t.Errorf("--output json dropped %s:\n%s", field, out.String())
+		}
+	}
+}
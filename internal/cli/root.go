@@ -3,46 +3,53 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/resultcache"
+	"github.com/mcp-cli-ent/mcp-cli/internal/sampling"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolcache"
+	"github.com/mcp-cli-ent/mcp-cli/pkg/mcpclient"
 	"github.com/mcp-cli-ent/mcp-cli/pkg/version"
 )
 
 var (
 	cfgFile      string
+	dataDir      string
 	verbose      bool
 	timeout      int
 	refreshCache bool
 	clearCache   bool
+	noCache      bool
 	humanOutput  bool
 	searchQuery  string
-)
-
-// ToolsCacheEntry represents a cached tool listing for a server
-type ToolsCacheEntry struct {
-	Tools      []mcp.Tool `json:"tools"`
-	LastUpdate time.Time  `json:"lastUpdate"`
-}
-
-// ToolsCache represents the full cache structure
-type ToolsCache struct {
-	Servers map[string]ToolsCacheEntry `json:"servers"`
-}
-
-const (
-	CacheFileName = "tools_cache.json"
-	CacheTTL      = 30 * 24 * time.Hour // Cache expires after 30 days
+	envFile      string
+	envOverride  bool
+	noLocal      bool
+	allowExec    bool
+	quiet        bool
+	logFile      string
+	recordDir    string
+	replayDir    string
+
+	closeLog = func() error { return nil }
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -54,6 +61,17 @@ var rootCmd = &cobra.Command{
 Use "mcp-cli-ent --help verbose" for detailed information.`,
 		version.Version),
 	Version: version.Version,
+	// Report prints errors, with hints and exit codes cobra knows nothing of
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// The flags and arguments were accepted, so later failures are not
+		// about the command's usage
+		cmd.SilenceUsage = true
+		if cmd.DisableFlagParsing {
+			return nil // The command parses the global flags, then selects the instance itself
+		}
+		return selectDaemon()
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// If no command was specified, show help with available servers
 		if len(args) == 0 {
@@ -68,73 +86,105 @@ Use "mcp-cli-ent --help verbose" for detailed information.`,
 func Execute() error {
 	autoInstallAlias()
 
-	// Override the help function to include available servers
-	originalHelpFunc := rootCmd.HelpFunc()
-	rootCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
-		originalHelpFunc(cmd, args) // Show standard help
-		if err := showAvailableServers(cmd); err != nil {
-			fmt.Printf("Warning: Failed to load servers: %v\n", err)
-		}
-	})
-	return rootCmd.Execute()
-}
+	ctx, cancel := interruptContext(context.Background(), os.Stderr)
+	defer cancel()
 
-// GetCachePath returns the path to the tools cache file
-func GetCachePath() (string, error) {
-	configDir, err := config.GetConfigDir()
-	if err != nil {
-		return "", err
+	err := rootCmd.ExecuteContext(ctx)
+	closeSessionManager()
+	daemon.CloseIdleConnections()
+	_ = closeLog()
+	if err != nil && ctx.Err() != nil {
+		return ErrInterrupted
 	}
-	return filepath.Join(configDir, CacheFileName), nil
+	return err
 }
 
-// LoadToolsFromCache loads cached tool listings
-func LoadToolsFromCache() (*ToolsCache, error) {
-	cachePath, err := GetCachePath()
-	if err != nil {
-		return nil, err
-	}
+// ErrInterrupted is returned by Execute when a command failed because it was
+// interrupted
+var ErrInterrupted = errors.New("interrupted")
+
+// interruptContext returns a context cancelled by the first Ctrl-C or
+// SIGTERM, so commands stop waiting on servers and still close their clients
+// on the way out. The signals are then no longer caught: a second one exits
+// at once.
+func interruptContext(parent context.Context, notice io.Writer) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			fmt.Fprintln(notice, "Interrupted, shutting down (interrupt again to exit now)")
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(signals)
+		}
+	}()
+	return ctx, cancel
+}
 
-	// Check if cache file exists
-	if _, err := os.Stat(cachePath); os.IsNotExist(err) {
-		return nil, nil // No cache yet, not an error
+// commandContext returns the context a command runs under. Commands run
+// directly, as in tests, have none.
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
 	}
+	return context.Background()
+}
 
-	data, err := os.ReadFile(cachePath)
-	if err != nil {
-		return nil, err
+// toolCache returns the on-disk tool list cache, or nil with --no-cache.
+// With --clear-cache it is emptied first.
+func toolCache(cfg *config.Configuration) *toolcache.Cache {
+	if noCache {
+		return nil
 	}
-
-	var cache ToolsCache
-	if err := json.Unmarshal(data, &cache); err != nil {
-		return nil, err
+	cache, err := toolcache.Default(cfg.ToolCacheDuration())
+	if err != nil {
+		logging.Debug("tool cache unavailable", "error", err)
+		return nil
 	}
-
-	// Check if cache is still valid
-	now := time.Now()
-	for serverName, entry := range cache.Servers {
-		if now.Sub(entry.LastUpdate) > CacheTTL {
-			// Cache expired for this server, remove it
-			delete(cache.Servers, serverName)
+	if clearCache {
+		if _, err := cache.Clear(); err != nil {
+			logging.Warn("failed to clear the tool cache", "error", err)
+		} else {
+			fmt.Fprintln(os.Stderr, "Cache cleared.")
 		}
+		clearCache, refreshCache = false, true // Clear once, then list fresh
 	}
-
-	return &cache, nil
+	return cache
 }
 
-// SaveToolsToCache saves tool listings to cache
-func SaveToolsToCache(cache *ToolsCache) error {
-	cachePath, err := GetCachePath()
-	if err != nil {
-		return err
+// cacheTools stores a server's freshly listed tools
+func cacheTools(cache *toolcache.Cache, serverName string, serverConfig config.ServerConfig, tools []mcp.Tool) {
+	if err := cache.Put(serverName, serverConfig, tools); err != nil {
+		logging.Debug("failed to cache tools", "server", serverName, "error", err)
 	}
+}
 
-	data, err := json.MarshalIndent(cache, "", "  ")
+// resultCache returns the on-disk tool result cache, or nil with --no-cache
+func resultCache(cfg *config.Configuration) *resultcache.Cache {
+	if noCache {
+		return nil
+	}
+	cache, err := resultcache.Default(cfg.ResultCacheMaxMB)
 	if err != nil {
-		return err
+		logging.Debug("result cache unavailable", "error", err)
+		return nil
 	}
+	return cache
+}
 
-	return os.WriteFile(cachePath, data, 0644)
+// resultTTL returns how long results of toolName are cached. Whether the
+// "*" entry of cacheTools covers the tool depends on its readOnlyHint, which
+// is known only once the server's tool list is cached.
+func resultTTL(cfg *config.Configuration, serverName string, serverConfig config.ServerConfig, toolName string) time.Duration {
+	if len(serverConfig.CacheTools) == 0 {
+		return 0
+	}
+	tools, _ := toolCache(cfg).Get(serverName, serverConfig)
+	return serverConfig.ResultTTL(toolName, resultcache.ReadOnly(tools, toolName))
 }
 
 // showRootHelpWithServers displays available tools from all MCP servers with usage examples
@@ -146,6 +196,9 @@ func showRootHelpWithServers(cmd *cobra.Command) error {
 		fmt.Fprintln(os.Stderr, "No configuration found - run 'mcp-cli-ent create-config'")
 		return nil
 	}
+	if cfg, err = applyGroupFilter(cfg); err != nil {
+		return err
+	}
 
 	enabledServers := cfg.GetEnabledServers()
 	if len(enabledServers) == 0 {
@@ -153,53 +206,20 @@ func showRootHelpWithServers(cmd *cobra.Command) error {
 		return nil
 	}
 
-	// If clearCache or refreshCache is set, clear cache file
-	// These flags are aliases - both trigger cache refresh
-	if clearCache || refreshCache {
-		cachePath, err := GetCachePath()
-		if err == nil {
-			_ = os.Remove(cachePath)
-			fmt.Println("Cache cleared.")
-		}
-		// Force refresh after clearing
-		refreshCache = true
-		clearCache = true
-	}
-
-	cache, err := LoadToolsFromCache()
-	if err != nil {
-		cache = nil
-	}
-	useCache := cache != nil && !refreshCache && !clearCache
-
-	var totalTools int
-	var toolsByServer map[string][]mcp.Tool
-
-	if useCache {
-		// Check if all servers are in cache
-		toolsByServer = make(map[string][]mcp.Tool)
-		allCached := true
-		for serverName := range enabledServers {
-			if entry, ok := cache.Servers[serverName]; ok {
-				toolsByServer[serverName] = entry.Tools
-			} else {
-				allCached = false
-				break
-			}
-		}
-
-		if !allCached {
-			useCache = false
-		} else {
-			// Count tools from cache
-			for _, tools := range toolsByServer {
-				totalTools += len(tools)
-			}
+	// Servers with a cached tool list need not be started
+	cache := toolCache(cfg)
+	toolsByServer := make(map[string][]mcp.Tool)
+	var uncached []string
+	for serverName, serverConfig := range enabledServers {
+		if tools, ok := cache.Get(serverName, serverConfig); ok && !refreshCache {
+			toolsByServer[serverName] = tools
+			continue
 		}
+		uncached = append(uncached, serverName)
 	}
 
-	if !useCache {
-		// Discover tools from all servers
+	if len(uncached) > 0 {
+		// Discover tools from the remaining servers
 		factory, err := getSessionAwareClientFactory()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: failed to create client factory: %v\n", err)
@@ -207,53 +227,54 @@ func showRootHelpWithServers(cmd *cobra.Command) error {
 			return nil
 		}
 
-		toolsByServer = make(map[string][]mcp.Tool)
-		var wg sync.WaitGroup
+		status := startStatus()
+		defer status.Stop()
+		status.Phase("listing tools from %d server(s)…", len(uncached))
+		ctx := commandContext(cmd)
+
+		// Servers with cached tools count as listed
+		failures := newServerFailures(len(enabledServers))
 		var mu sync.Mutex
+		eachServer(uncached, func(_ int, name string) {
+			serverConfig := enabledServers[name]
+			mcpClient, err := factory.CreateClient(name, serverConfig)
+			if err != nil {
+				failures.add(name, fmt.Errorf("failed to connect: %w", err))
+				return
+			}
 
-		// Start workers for parallel discovery
-		for serverName := range enabledServers {
-			wg.Add(1)
-			go func(name string) {
-				defer wg.Done()
-				ctx := context.Background()
-				serverConfig := enabledServers[name]
-
-				mcpClient, err := factory.CreateClient(name, serverConfig)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "%s: (failed to connect: %v)\n", name, err)
-					return
-				}
+			tools, err := mcpClient.ListTools(ctx)
+			_ = mcpClient.Close()
+			if err != nil {
+				failures.add(name, fmt.Errorf("failed to list tools: %w", err))
+				return
+			}
 
-				tools, err := mcpClient.ListTools(ctx)
-				_ = mcpClient.Close()
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "%s: (failed to list tools: %v)\n", name, err)
-					return
-				}
+			cacheTools(cache, name, serverConfig, tools)
+			mu.Lock()
+			toolsByServer[name] = tools
+			mu.Unlock()
+		})
+		status.Stop()
 
-				mu.Lock()
-				toolsByServer[name] = tools
-				mu.Unlock()
-			}(serverName)
+		failures.write(os.Stderr)
+		if err := failures.check(listToolsFailOn, "listing tools"); err != nil {
+			return err
 		}
+	}
 
-		// Wait for all workers to complete
-		wg.Wait()
+	totalTools := 0
+	for _, tools := range toolsByServer {
+		totalTools += len(tools)
+	}
 
-		// Count total tools and build cache
+	// Leave out tools hidden by disabledTools unless --all is given
+	if !showHiddenTools {
 		totalTools = 0
-		newCache := &ToolsCache{Servers: make(map[string]ToolsCacheEntry)}
 		for serverName, tools := range toolsByServer {
-			totalTools += len(tools)
-			newCache.Servers[serverName] = ToolsCacheEntry{
-				Tools:      tools,
-				LastUpdate: time.Now(),
-			}
+			toolsByServer[serverName] = client.VisibleTools(enabledServers[serverName], tools)
+			totalTools += len(toolsByServer[serverName])
 		}
-
-		// Save to cache
-		_ = SaveToolsToCache(newCache)
 	}
 
 	// Filter by search query if provided
@@ -322,7 +343,7 @@ func showRootHelpWithServers(cmd *cobra.Command) error {
 				fmt.Printf("%s [%d]\n", displayName, len(tools))
 			}
 
-			printToolsHuman(tools, serverName, verbose)
+			printToolsHuman(tools, serverName, serverConfig, verbose)
 			fmt.Println()
 		}
 
@@ -446,11 +467,16 @@ func toolMatches(tool mcp.Tool, query string) bool {
 
 // printToolsHuman prints tools in human-readable format.
 // Default: terse 1-line-per-tool ("name: description").
-// With verbose: expanded 4-line format (desc, params, call).
-func printToolsHuman(tools []mcp.Tool, serverName string, isVerbose bool) {
+// With verbose: expanded format (desc, params, defaults, call).
+func printToolsHuman(tools []mcp.Tool, serverName string, serverConfig config.ServerConfig, isVerbose bool) {
 	for _, tool := range tools {
+		name := tool.Name + toolBadges(&tool)
+		if serverConfig.IsToolHidden(tool.Name) {
+			name += " (hidden)"
+		}
+
 		if isVerbose {
-			fmt.Printf("  • %s\n", tool.Name)
+			fmt.Printf("  • %s\n", name)
 			if tool.Description != "" {
 				fmt.Printf("    desc: %s\n", tool.Description)
 			}
@@ -460,19 +486,36 @@ func printToolsHuman(tools []mcp.Tool, serverName string, isVerbose bool) {
 					fmt.Printf("    params: %s\n", strings.Join(names, ", "))
 				}
 			}
+			if defaults := serverConfig.DefaultsFor(tool.Name); defaults != nil {
+				if data, err := json.Marshal(defaults); err == nil {
+					fmt.Printf("    defaults: %s\n", data)
+				}
+			}
 			exampleArgs := BuildExampleArgs(&tool)
 			fmt.Printf("    call: %s\n\n", buildCallString(serverName, tool.Name, exampleArgs))
 		} else {
 			// Terse: single line per tool
 			if tool.Description != "" {
-				fmt.Printf("  %s: %s\n", tool.Name, tool.Description)
+				fmt.Printf("  %s: %s\n", name, tool.Description)
 			} else {
-				fmt.Printf("  %s\n", tool.Name)
+				fmt.Printf("  %s\n", name)
 			}
 		}
 	}
 }
 
+// toolBadges returns the compact form of the tool's annotations for tool
+// lists: " [read-only]" or " [destructive]", or "" for tools with neither
+func toolBadges(tool *mcp.Tool) string {
+	switch {
+	case tool.IsReadOnly():
+		return " [read-only]"
+	case tool.IsDestructive():
+		return " [destructive]"
+	}
+	return ""
+}
+
 // buildCallString constructs the mcp-cli-ent call command string
 func buildCallString(serverName, toolName, exampleArgs string) string {
 	if exampleArgs == "" || exampleArgs == "'{}'" {
@@ -506,6 +549,9 @@ type JSONTool struct {
 	Params      []string               `json:"params,omitempty"`
 	Call        string                 `json:"call"`
 	Schema      map[string]interface{} `json:"schema,omitempty"`
+	Defaults    map[string]interface{} `json:"defaults,omitempty"`
+	Hidden      bool                   `json:"hidden,omitempty"`
+	Annotations *mcp.ToolAnnotations   `json:"annotations,omitempty"`
 }
 
 // indexTool is a compact tool entry for the bare-invocation discovery index.
@@ -527,11 +573,19 @@ func encodeErrorJSON(code, format string, args ...interface{}) error {
 
 // showAvailableServers displays a simple list of available MCP servers
 func showAvailableServers(cmd *cobra.Command) error {
-	// Load configuration
-	configPath := GetConfigPath()
-	cfg, err := LoadConfiguration(configPath)
+	// Help lists the servers already configured, without creating the
+	// config directory on a first run
+	configPath := cfgFile
+	if configPath == "" {
+		path, err := config.FindConfigFile()
+		if err != nil {
+			return nil // Skip if config not found
+		}
+		configPath = path
+	}
+	cfg, err := mcpclient.LoadConfigWithOptions(configPath, configLoadOptions())
 	if err != nil {
-		return nil // Skip if config not found
+		return nil
 	}
 
 	enabledServers := cfg.GetEnabledServers()
@@ -557,12 +611,36 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "configuration file path (default is mcp_servers.json)")
+	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", "", "keep sessions, caches, snapshots, the audit log and daemon files in this directory (default is $"+config.DataDirEnv+", else the configuration directory)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output (full schema in JSON, expanded details in --human)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "hide progress and warnings; only errors are shown")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "append warnings and debug logs to this file instead of stderr")
 	rootCmd.PersistentFlags().IntVar(&timeout, "timeout", 30, "request timeout in seconds")
-	rootCmd.PersistentFlags().BoolVar(&refreshCache, "refresh", false, "force refresh of tools cache (alias: --clear-cache)")
-	rootCmd.PersistentFlags().BoolVar(&clearCache, "clear-cache", false, "clear tools cache (alias: --refresh)")
+	rootCmd.PersistentFlags().BoolVar(&refreshCache, "refresh", false, "list tools from the servers and update the tool cache")
+	rootCmd.PersistentFlags().BoolVar(&clearCache, "clear-cache", false, "clear the tool cache, then list tools from the servers")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "neither read nor write the tool and result caches")
 	rootCmd.PersistentFlags().BoolVar(&humanOutput, "human", false, "human-readable terminal output (default is JSON)")
 	rootCmd.PersistentFlags().StringVar(&searchQuery, "search", "", "filter tools by name or description (case-insensitive)")
+	rootCmd.PersistentFlags().StringVar(&envFile, "env-file", "", "load environment variables from this file (default is .env in the current directory)")
+	rootCmd.PersistentFlags().BoolVar(&envOverride, "env-override", false, "let env file values replace variables already set in the environment")
+	rootCmd.PersistentFlags().BoolVar(&noLocal, "no-local", false, "ignore the project-local .mcp_servers.json")
+	rootCmd.PersistentFlags().BoolVar(&allowExec, "allow-exec", false, "run $(command) substitutions in config values")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "save every request to the servers, with its response, as fixtures in this directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "answer requests from the fixtures in this directory instead of the servers")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	rootCmd.PersistentFlags().BoolVar(&useDaemon, "use-daemon", false, "route every server's calls through the daemon, connecting directly only if it cannot start (default is daemon.routing in the configuration)")
+	rootCmd.PersistentFlags().BoolVar(&noDaemon, "no-daemon", false, "connect to every server directly, bypassing the daemon")
+	rootCmd.MarkFlagsMutuallyExclusive("use-daemon", "no-daemon")
+	rootCmd.PersistentFlags().StringVar(&daemonName, "daemon-name", "", "use the named daemon instance, with its own endpoint, PID file, logs and sessions (default is daemon.name in the configuration)")
+
+	// Override the help function to include available servers
+	originalHelpFunc := rootCmd.HelpFunc()
+	rootCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		originalHelpFunc(cmd, args) // Show standard help
+		if err := showAvailableServers(cmd); err != nil {
+			logging.Warn("failed to load servers", "error", err)
+		}
+	})
 
 	// Bind flags to viper
 	_ = viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
@@ -573,31 +651,59 @@ func init() {
 	_ = viper.BindPFlag("search", rootCmd.PersistentFlags().Lookup("search"))
 }
 
-// initConfig reads in config file and ENV variables if set.
+// initConfig sets up logging. The configuration itself is loaded, and its
+// directory created, only by the commands that need it; see ensureConfigDir.
 func initConfig() {
-	// Ensure config directory and files exist on first run
-	if err := config.EnsureConfigDirectory(); err != nil && verbose {
-		fmt.Fprintf(os.Stderr, "Warning: failed to ensure config directory: %v\n", err)
-	}
-
-	if cfgFile != "" {
-		// Use config file from the flag
-		viper.SetConfigFile(cfgFile)
+	// Warnings go to stderr or --log-file, never stdout
+	closer, err := logging.Configure(logging.Options{
+		Verbose: viper.GetBool("verbose"),
+		Quiet:   quiet,
+		File:    logFile,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	} else {
-		// Find config file
-		viper.SetConfigName("mcp_servers")
-		viper.SetConfigType("json")
-		viper.AddConfigPath(".")
-		viper.AddConfigPath("$HOME/.config")
-		viper.AddConfigPath("$HOME")
+		closeLog = closer
 	}
 
 	viper.AutomaticEnv() // read in environment variables that match
 
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		if verbose {
-			fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+	// --data-dir goes into the environment, so a daemon the command starts
+	// keeps its files there too
+	if dataDir != "" {
+		if dir, err := filepath.Abs(dataDir); err == nil {
+			_ = os.Setenv(config.DataDirEnv, dir)
 		}
 	}
+
+	// Fixtures apply to every client the command creates, bypassing the daemon
+	switch {
+	case replayDir != "":
+		client.SetFixtures(client.FixturesReplay, replayDir)
+	case recordDir != "":
+		client.SetFixtures(client.FixturesRecord, recordDir)
+	default:
+		client.SetFixtures(client.FixturesOff, "")
+	}
+
+	// Clients started directly answer sampling requests like the daemon's
+	client.SetSamplingHandler(samplingHandler())
+}
+
+// samplingHandler returns the handler for daemon.json's sampling section,
+// or nil when there is none or it cannot be used
+func samplingHandler() mcp.SamplingHandler {
+	samplingConfig, err := daemon.LoadSamplingConfig()
+	if err == nil && samplingConfig == nil {
+		return nil
+	}
+	var handler *sampling.Handler
+	if err == nil {
+		handler, err = sampling.New(*samplingConfig)
+	}
+	if err != nil {
+		logging.Warn("not answering sampling requests", "error", err)
+		return nil
+	}
+	return handler
 }
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/resultcache"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolcache"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolsnapshot"
+)
+
+func TestPrintToolsHumanShowsBadges(t *testing.T) {
+	no, yes := false, true
+	tools := []mcp.Tool{
+		{Name: "read", Description: "Read a file", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: &yes}},
+		{Name: "delete", Annotations: &mcp.ToolAnnotations{DestructiveHint: &yes}},
+		{Name: "write", Annotations: &mcp.ToolAnnotations{DestructiveHint: &no}},
+		{Name: "ping"},
+	}
+	got := captureStdout(t, func() {
+		printToolsHuman(tools, "files", config.ServerConfig{DisabledTools: []string{"delete"}}, false)
+	})
+	want := "  read [read-only]: Read a file\n  delete [destructive] (hidden)\n  write\n  ping\n"
+	if got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+// toolServerScript is a fake MCP server with one tool, "echo"
+const toolServerScript = `while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed -n 's/.*"id":\([0-9]*\).*/\1/p')
+  case "$line" in
+    *'"initialize"'*) echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"echo","version":"1.0.0"}}}' ;;
+    *'"tools/list"'*) echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"tools":[{"name":"echo","inputSchema":{"type":"object"}}]}}' ;;
+    *'"tools/call"'*) echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"content":[{"type":"text","text":"echoed"}]}}' ;;
+  esac
+done`
+
+func TestDataDirKeepsEveryWriteInside(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	configDir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, configDir)
+	t.Setenv(config.DataDirEnv, "") // Restored after --data-dir sets it
+	dataDir := t.TempDir()
+
+	configPath := filepath.Join(configDir, "mcp_servers.json")
+	serversJSON, _ := json.Marshal(map[string]interface{}{
+		"mcpServers": map[string]interface{}{
+			"echo": map[string]interface{}{
+				"command":    "sh",
+				"args":       []string{"-c", toolServerScript},
+				"cacheTools": map[string]interface{}{"echo": map[string]string{"ttl": "1h"}},
+			},
+		},
+	})
+	writeTestFile(t, configPath, string(serversJSON))
+
+	for _, args := range [][]string{
+		{"list-tools", "echo", "--refresh"},
+		{"list-tools", "echo", "--snapshot"},
+		{"call", "echo", "echo", `{"text": "hi"}`},
+		{"history"},
+	} {
+		if _, err := runCLI(t, configPath, append([]string{"--data-dir", dataDir}, args...)...); err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+	}
+
+	for _, want := range []string{toolcache.DirName, toolsnapshot.DirName, resultcache.DirName, audit.FileName} {
+		if _, err := os.Stat(filepath.Join(dataDir, want)); err != nil {
+			t.Errorf("the data directory lacks %s: %v", want, err)
+		}
+	}
+	if got := daemon.GetLogFilePath(); !strings.HasPrefix(got, dataDir) {
+		t.Errorf("daemon log at %s, want it in the data directory", got)
+	}
+	entries, _ := os.ReadDir(configDir)
+	for _, entry := range entries {
+		if name := entry.Name(); name != "mcp_servers.json" && name != "config.json" { // First-run configuration files
+			t.Errorf("%s was written to the configuration directory", name)
+		}
+	}
+	if entries, _ := os.ReadDir(home); len(entries) != 0 {
+		t.Errorf("nothing should be written to the home directory, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,92 @@
+//go:build !windows
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInterruptClosesClient(t *testing.T) {
+	dir := t.TempDir()
+	configDir := filepath.Join(dir, "config")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server records its PID and the requests it reads, answers none of
+	// them, and outlives the end of its input unless it is closed
+	pidFile := filepath.Join(dir, "server.pid")
+	requestsFile := filepath.Join(dir, "requests")
+	script := filepath.Join(dir, "server.sh")
+	body := fmt.Sprintf("echo $$ > %q\nwhile read -r line; do echo \"$line\" >> %q; done\nexec sleep 30\n", pidFile, requestsFile)
+	if err := os.WriteFile(script, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	serversJSON := fmt.Sprintf(`{"mcpServers": {"slow": {"command": "sh", "args": [%q]}}}`, script)
+	if err := os.WriteFile(filepath.Join(configDir, "mcp_servers.json"), []byte(serversJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := cliProcess(t, dir, configDir, "call slow wait")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	// Interrupt once the tool call is in flight
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if data, _ := os.ReadFile(requestsFile); bytes.Contains(data, []byte("tools/call")) {
+			break
+		}
+		if time.Now().After(deadline) {
+			_ = cmd.Process.Kill()
+			t.Fatalf("the tool call never reached the server; stderr: %s", stderr.String())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-exited:
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			t.Errorf("want exit status 1, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("the CLI did not exit promptly after an interrupt")
+	}
+	if !strings.Contains(stderr.String(), "Error: interrupted") {
+		t.Errorf("stderr should report the interrupt, got: %s", stderr.String())
+	}
+
+	// Closing the client stops the server; otherwise it would still be sleeping
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(pid, 0); !errors.Is(err, syscall.ESRCH) {
+		_ = syscall.Kill(pid, syscall.SIGKILL)
+		t.Errorf("the server is still running (kill -0: %v); the client was not closed", err)
+	}
+}
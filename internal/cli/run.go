@@ -0,0 +1,446 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/jsonpath"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/serve"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <pipeline.json>",
+	Short: "Run a pipeline of tool calls, feeding outputs of one step to later ones",
+	Long: `Run the tool calls listed in a pipeline file, in order. A step may extract
+outputs from its result with the paths --extract takes, and later steps may
+use them in their arguments:
+
+  {"steps": [
+    {"name": "resolve", "server": "context7", "tool": "resolve-library-id",
+     "args": {"libraryName": "react"},
+     "extract": {"libId": "$.content[0].text"}},
+    {"name": "docs", "server": "context7", "tool": "get-library-docs",
+     "args": {"context7CompatibleLibraryID": "{{steps.resolve.libId}}"}}
+  ]}
+
+Paths apply to the whole result. An argument that is only a reference takes
+the output's value as-is; references inside longer strings are replaced by
+the output as text. The run stops at the first step that fails; with
+--continue-on-error it goes on, skipping only the steps that use outputs of
+a step that did not succeed.
+
+A step with "expandEnv": true expands ${VAR} and %VAR% in its arguments as
+call --expand-env does. Variables are expanded before outputs are filled in,
+so text a server returned is never expanded.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRun,
+}
+
+var (
+	runContinueOnError bool
+	runOutput          string
+)
+
+func init() {
+	runCmd.Flags().BoolVar(&runContinueOnError, "continue-on-error", false, "go on after a step fails, skipping the steps that depend on it")
+	runCmd.Flags().StringVar(&runOutput, "output", outputText, "result format: text or json")
+}
+
+// Outcomes of a pipeline step
+const (
+	stepOK      = "ok"
+	stepFailed  = "failed"
+	stepSkipped = "skipped"
+)
+
+// stepReference matches {{steps.<step>.<output>}} in step arguments
+var stepReference = regexp.MustCompile(`\{\{\s*steps\.([A-Za-z0-9_-]+)\.([A-Za-z0-9_-]+)\s*\}\}`)
+
+// validStepName matches the names steps may have
+var validStepName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// pipeline is the content of a pipeline file
+type pipeline struct {
+	Steps []pipelineStep `json:"steps"`
+}
+
+// pipelineStep is one tool call of a pipeline
+type pipelineStep struct {
+	Name    string                 `json:"name"`
+	Server  string                 `json:"server"`
+	Tool    string                 `json:"tool"`
+	Args    map[string]interface{} `json:"args,omitempty"`
+	Extract map[string]string      `json:"extract,omitempty"` // Output name to path into the result
+
+	ExpandEnv bool `json:"expandEnv,omitempty"` // Expand environment variables in args, as call --expand-env does
+
+	paths     map[string]jsonpath.Path
+	dependsOn []string // Steps whose outputs the arguments use
+}
+
+// stepResult is the outcome of one pipeline step
+type stepResult struct {
+	Step    string                 `json:"-"`
+	Server  string                 `json:"server"`
+	Tool    string                 `json:"tool"`
+	Status  string                 `json:"status"` // stepOK, stepFailed or stepSkipped
+	Result  json.RawMessage        `json:"result,omitempty"`
+	Outputs map[string]interface{} `json:"outputs,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+
+	result *mcp.ToolResult
+}
+
+// loadPipeline reads a pipeline file and checks it against the
+// configuration before anything runs: every step needs a unique name, a
+// known server and a tool, its paths must parse, and its references must
+// name outputs of earlier steps
+func loadPipeline(path string, cfg *config.Configuration) (*pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pipeline: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	var p pipeline
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid pipeline '%s': %w", path, err)
+	}
+	if len(p.Steps) == 0 {
+		return nil, fmt.Errorf("pipeline '%s' has no steps", path)
+	}
+
+	extracts := make(map[string]map[string]string)
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if !validStepName.MatchString(step.Name) {
+			return nil, fmt.Errorf("step %d: name '%s' must be letters, digits, '-' or '_'", i+1, step.Name)
+		}
+		if _, seen := extracts[step.Name]; seen {
+			return nil, fmt.Errorf("step '%s': another step has the same name", step.Name)
+		}
+		if _, exists := cfg.GetServer(step.Server); !exists {
+			return nil, fmt.Errorf("step '%s': %w", step.Name, serverNotFoundError(step.Server, cfg))
+		}
+		if step.Tool == "" {
+			return nil, fmt.Errorf("step '%s': missing tool", step.Name)
+		}
+		if step.ExpandEnv {
+			if _, err := config.ExpandArguments(step.Args); err != nil {
+				return nil, fmt.Errorf("step '%s': failed to expand environment variables in the arguments: %w", step.Name, err)
+			}
+		}
+
+		step.paths = make(map[string]jsonpath.Path, len(step.Extract))
+		for output, expr := range step.Extract {
+			if !validStepName.MatchString(output) {
+				return nil, fmt.Errorf("step '%s': output name '%s' must be letters, digits, '-' or '_'", step.Name, output)
+			}
+			if step.paths[output], err = jsonpath.Parse(expr); err != nil {
+				return nil, fmt.Errorf("step '%s': output '%s': %w", step.Name, output, err)
+			}
+		}
+
+		for _, ref := range stepReferences(step.Args) {
+			outputs, earlier := extracts[ref[0]]
+			switch {
+			case !earlier:
+				return nil, fmt.Errorf("step '%s': {{steps.%s.%s}} does not name an earlier step", step.Name, ref[0], ref[1])
+			case outputs[ref[1]] == "":
+				return nil, fmt.Errorf("step '%s': {{steps.%s.%s}}: step '%s' extracts no '%s'", step.Name, ref[0], ref[1], ref[0], ref[1])
+			}
+			if !containsString(step.dependsOn, ref[0]) {
+				step.dependsOn = append(step.dependsOn, ref[0])
+			}
+		}
+		extracts[step.Name] = step.Extract
+	}
+	return &p, nil
+}
+
+// stepReferences returns the step and output of every reference in value
+func stepReferences(value interface{}) [][2]string {
+	var refs [][2]string
+	switch v := value.(type) {
+	case string:
+		for _, match := range stepReference.FindAllStringSubmatch(v, -1) {
+			refs = append(refs, [2]string{match[1], match[2]})
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			refs = append(refs, stepReferences(item)...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			refs = append(refs, stepReferences(item)...)
+		}
+	}
+	return refs
+}
+
+// renderReferences returns value with every reference replaced by the
+// output it names. A string that is only a reference becomes the output
+// itself; references inside longer strings become the output as text.
+func renderReferences(value interface{}, outputs map[string]map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if match := stepReference.FindStringSubmatchIndex(v); match != nil && match[0] == 0 && match[1] == len(v) {
+			return outputs[v[match[2]:match[3]]][v[match[4]:match[5]]]
+		}
+		return stepReference.ReplaceAllStringFunc(v, func(ref string) string {
+			match := stepReference.FindStringSubmatch(ref)
+			return referenceText(outputs[match[1]][match[2]])
+		})
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered[key] = renderReferences(item, outputs)
+		}
+		return rendered
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered[i] = renderReferences(item, outputs)
+		}
+		return rendered
+	default:
+		return v
+	}
+}
+
+// renderArguments returns a step's arguments with its references replaced
+func renderArguments(args map[string]interface{}, outputs map[string]map[string]interface{}) map[string]interface{} {
+	rendered, _ := renderReferences(args, outputs).(map[string]interface{})
+	if rendered == nil {
+		rendered = make(map[string]interface{})
+	}
+	return rendered
+}
+
+// referenceText is an output as it appears inside a longer string: strings
+// as-is and other values as JSON
+func referenceText(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// runPipeline runs the steps in order, each server's steps through one
+// client. A step whose dependencies did not all succeed is skipped; unless
+// continueOnError is set, so is every step after the first failure.
+func runPipeline(ctx context.Context, cfg *config.Configuration, p *pipeline, newClient serve.ClientFactory, auditLog *audit.Log, continueOnError bool) []stepResult {
+	clients := make(map[string]mcp.MCPClient)
+	defer func() {
+		for _, mcpClient := range clients {
+			_ = mcpClient.Close()
+		}
+	}()
+
+	outputs := make(map[string]map[string]interface{})
+	results := make([]stepResult, 0, len(p.Steps))
+	stoppedAt := ""
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		result := stepResult{Step: step.Name, Server: step.Server, Tool: step.Tool, Status: stepSkipped}
+		if stoppedAt != "" {
+			result.Error = fmt.Sprintf("stopped after step '%s' failed", stoppedAt)
+		} else if dep := failedDependency(step, outputs); dep != "" {
+			result.Error = fmt.Sprintf("step '%s' it uses outputs of did not succeed", dep)
+		} else {
+			result = runStep(ctx, cfg, step, clients, newClient, auditLog, outputs)
+		}
+
+		if result.Status == stepOK {
+			outputs[step.Name] = result.Outputs
+		} else if result.Status == stepFailed && !continueOnError {
+			stoppedAt = step.Name
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// failedDependency returns a step the step uses outputs of that has none,
+// having failed or been skipped, or "" if all succeeded
+func failedDependency(step *pipelineStep, outputs map[string]map[string]interface{}) string {
+	for _, dep := range step.dependsOn {
+		if _, succeeded := outputs[dep]; !succeeded {
+			return dep
+		}
+	}
+	return ""
+}
+
+// runStep makes one step's call and extracts its outputs
+func runStep(ctx context.Context, cfg *config.Configuration, step *pipelineStep, clients map[string]mcp.MCPClient, newClient serve.ClientFactory, auditLog *audit.Log, outputs map[string]map[string]interface{}) stepResult {
+	result := stepResult{Step: step.Name, Server: step.Server, Tool: step.Tool}
+	fail := func(err error) stepResult {
+		result.Status, result.Error = stepFailed, err.Error()
+		return result
+	}
+
+	if ctx.Err() != nil {
+		return fail(ctx.Err())
+	}
+	serverConfig, _ := cfg.GetServer(step.Server)
+	mcpClient, ok := clients[step.Server]
+	if !ok {
+		var err error
+		if mcpClient, err = newClient(step.Server, serverConfig); err != nil {
+			return fail(fmt.Errorf("failed to create client: %w", err))
+		}
+		clients[step.Server] = mcpClient
+	}
+
+	// Environment variables are expanded before outputs of earlier steps
+	// are filled in, so that text a server returned is never expanded
+	stepArgs := step.Args
+	if step.ExpandEnv {
+		var err error
+		if ctx, stepArgs, err = expandArgumentsEnv(ctx, cfg, step.Args); err != nil {
+			return fail(err)
+		}
+		if written := audit.RecordedArgs(ctx, nil); written != nil {
+			ctx = audit.WithRecordedArgs(ctx, renderArguments(written, outputs))
+		}
+	}
+	arguments := renderArguments(stepArgs, outputs)
+	arguments = serverConfig.ApplyToolDefaults(step.Tool, arguments)
+	start := time.Now()
+	toolResult, err := mcpClient.CallTool(ctx, step.Tool, arguments)
+	recordCall(ctx, auditLog, audit.SourceRun, mcpClient, step.Server, serverConfig, step.Tool, arguments, start, toolResult, err)
+	if err != nil {
+		return fail(fmt.Errorf("failed to call tool: %w", err))
+	}
+
+	result.result = toolResult
+	if result.Result = toolResult.Raw; len(result.Result) == 0 {
+		result.Result, _ = json.Marshal(toolResult)
+	}
+	if toolResult.IsError {
+		return fail(fmt.Errorf("the tool reported a failure"))
+	}
+
+	var source interface{}
+	dec := json.NewDecoder(bytes.NewReader(result.Result))
+	dec.UseNumber()
+	if err := dec.Decode(&source); err != nil {
+		return fail(fmt.Errorf("cannot extract outputs: %w", err))
+	}
+	names := make([]string, 0, len(step.paths))
+	for output := range step.paths {
+		names = append(names, output)
+	}
+	sort.Strings(names) // The first failure reported doesn't vary between runs
+	result.Outputs = make(map[string]interface{}, len(step.paths))
+	for _, output := range names {
+		path := step.paths[output]
+		value, err := path.Eval(source)
+		if err != nil {
+			return fail(fmt.Errorf("cannot extract '%s' (%s): %w", output, path, err))
+		}
+		result.Outputs[output] = value
+	}
+	result.Status = stepOK
+	return result
+}
+
+// writeRunText writes each step's result, or why there is none, under a
+// header naming the step. A non-nil format is applied to text blocks.
+func writeRunText(out io.Writer, results []stepResult, format func(string) string) {
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		if result.Status == stepOK {
+			fmt.Fprintf(out, "=== %s ===\n", result.Step)
+		} else {
+			fmt.Fprintf(out, "=== %s (%s) ===\n", result.Step, result.Status)
+		}
+		if result.result != nil {
+			renderToolResult(out, out, result.result, format) // Flags a failure reported by the tool
+		}
+		if result.Error != "" && (result.result == nil || !result.result.IsError) {
+			fmt.Fprintln(out, result.Error)
+		}
+	}
+}
+
+// writeRunJSON writes the results as a JSON object keyed by step
+func writeRunJSON(out io.Writer, results []stepResult) error {
+	byStep := make(map[string]stepResult, len(results))
+	for _, result := range results {
+		byStep[result.Step] = result
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(byStep)
+}
+
+// runError summarises the steps that did not succeed, or returns nil
+func runError(results []stepResult) error {
+	failed, skipped := 0, 0
+	for _, result := range results {
+		switch result.Status {
+		case stepFailed:
+			failed++
+		case stepSkipped:
+			skipped++
+		}
+	}
+	if failed == 0 && skipped == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d steps did not succeed (%d failed, %d skipped)", failed+skipped, len(results), failed, skipped)
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return err
+	}
+	if runOutput != outputText && runOutput != outputJSON {
+		return fmt.Errorf("invalid --output '%s' (use %s or %s)", runOutput, outputText, outputJSON)
+	}
+	p, err := loadPipeline(args[0], cfg)
+	if err != nil {
+		return err
+	}
+	for _, step := range p.Steps {
+		if serverConfig, _ := cfg.GetServer(step.Server); !serverConfig.IsEnabled() {
+			return serverDisabledError(step.Server, serverConfig)
+		}
+	}
+
+	status := startStatus()
+	defer status.Stop()
+	status.Phase("running %d step(s)…", len(p.Steps))
+	results := runPipeline(commandContext(cmd), cfg, p, daemon.NewSmartClient().CreateClient, auditLog(cfg), runContinueOnError)
+	status.Stop()
+
+	if runOutput == outputJSON {
+		if err := writeRunJSON(os.Stdout, results); err != nil {
+			return err
+		}
+	} else {
+		writeRunText(os.Stdout, results, markdownFormatter())
+	}
+	cmd.SilenceUsage = true // The results above explain any failure
+	return runError(results)
+}
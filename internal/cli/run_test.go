@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// runPipelineFile runs the pipeline in testdata/pipelines against the fake
+// library server and returns the JSON results by step
+func runPipelineFile(t *testing.T, name string, flags ...string) (map[string]stepResult, error) {
+	t.Helper()
+	script, _ := filepath.Abs(filepath.Join("testdata", "pipelines", "library.sh"))
+	serversJSON, _ := json.Marshal(map[string]interface{}{
+		"mcpServers": map[string]interface{}{
+			"library": map[string]interface{}{"command": "sh", "args": []string{script}},
+		},
+	})
+
+	args := append([]string{"run", filepath.Join("testdata", "pipelines", name), "--output", "json"}, flags...)
+	stdout, err := runWithFixtures(t, string(serversJSON), args...)
+	var results map[string]stepResult
+	if jsonErr := json.Unmarshal([]byte(stdout), &results); jsonErr != nil {
+		t.Fatalf("stdout is not JSON: %v (run error: %v)\n%s", jsonErr, err, stdout)
+	}
+	return results, err
+}
+
+func TestRunPipelineFeedsOutputsForward(t *testing.T) {
+	results, err := runPipelineFile(t, "docs.json")
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if results["resolve"].Status != stepOK || results["resolve"].Outputs["libId"] != "/facebook/react" {
+		t.Errorf("resolve = %+v", results["resolve"])
+	}
+	if results["docs"].Status != stepOK || !strings.Contains(string(results["docs"].Result), "React docs") {
+		t.Errorf("docs = %+v, want the docs of the resolved library", results["docs"])
+	}
+}
+
+func TestRunPipelineFailedExtraction(t *testing.T) {
+	// The run stops at the failure
+	results, err := runPipelineFile(t, "partial.json")
+	if err == nil {
+		t.Fatal("expected the run to fail")
+	}
+	if results["broken"].Status != stepFailed || !strings.Contains(results["broken"].Error, "cannot extract 'libId'") {
+		t.Errorf("broken = %+v", results["broken"])
+	}
+	if results["resolve"].Status != stepSkipped || !strings.Contains(results["resolve"].Error, "stopped after step 'broken' failed") {
+		t.Errorf("resolve = %+v, want it skipped", results["resolve"])
+	}
+
+	// With --continue-on-error only the dependent step is skipped
+	results, err = runPipelineFile(t, "partial.json", "--continue-on-error")
+	if err == nil || !strings.Contains(err.Error(), "2 of 3 steps did not succeed") {
+		t.Errorf("want the failure summarised, got %v", err)
+	}
+	for step, want := range map[string]string{"broken": stepFailed, "docs": stepSkipped, "resolve": stepOK} {
+		if results[step].Status != want {
+			t.Errorf("%s status = %s, want %s (%s)", step, results[step].Status, want, results[step].Error)
+		}
+	}
+	if !strings.Contains(results["docs"].Error, "step 'broken' it uses outputs of did not succeed") {
+		t.Errorf("docs error = %q", results["docs"].Error)
+	}
+}
+
+func TestRunPipelineExpandEnv(t *testing.T) {
+	t.Setenv("MCP_TEST_LIBRARY", "react")
+	t.Setenv("MCP_TEST_TOPIC", "hooks")
+	results, err := runPipelineFile(t, "env.json")
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if results["docs"].Status != stepOK {
+		t.Errorf("docs = %+v", results["docs"])
+	}
+}
+
+func TestLoadPipelineChecksReferences(t *testing.T) {
+	cfg := &config.Configuration{MCPServers: map[string]config.ServerConfig{"library": {Command: "sh"}}}
+	tests := []struct {
+		name     string
+		pipeline string
+		want     string
+	}{
+		{"later step", `{"steps": [
+			{"name": "docs", "server": "library", "tool": "get-library-docs", "args": {"id": "{{steps.resolve.libId}}"}},
+			{"name": "resolve", "server": "library", "tool": "resolve-library-id", "extract": {"libId": "content[0].text"}}]}`,
+			"{{steps.resolve.libId}} does not name an earlier step"},
+		{"unknown output", `{"steps": [
+			{"name": "resolve", "server": "library", "tool": "resolve-library-id", "extract": {"libId": "content[0].text"}},
+			{"name": "docs", "server": "library", "tool": "get-library-docs", "args": {"id": "{{steps.resolve.id}}"}}]}`,
+			"step 'resolve' extracts no 'id'"},
+		{"unknown server", `{"steps": [{"name": "resolve", "server": "docs", "tool": "resolve-library-id"}]}`, "server 'docs' not found"},
+		{"duplicate name", `{"steps": [
+			{"name": "a", "server": "library", "tool": "x"},
+			{"name": "a", "server": "library", "tool": "y"}]}`, "another step has the same name"},
+		{"bad path", `{"steps": [{"name": "a", "server": "library", "tool": "x", "extract": {"v": "content["}}]}`, "output 'v'"},
+		{"unknown field", `{"steps": [{"name": "a", "server": "library", "tool": "x", "arguments": {}}]}`, "unknown field"},
+		{"required variable", `{"steps": [{"name": "a", "server": "library", "tool": "x", "expandEnv": true,
+			"args": {"key": "${MCP_TEST_UNSET:?export a key}"}}]}`, "step 'a': failed to expand environment variables in the arguments: MCP_TEST_UNSET: export a key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pipeline.json")
+			writeTestFile(t, path, tt.pipeline)
+			if _, err := loadPipeline(path, cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("want an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRenderReferences(t *testing.T) {
+	outputs := map[string]map[string]interface{}{
+		"resolve": {"libId": "/facebook/react", "tokens": json.Number("5000")},
+	}
+	args := map[string]interface{}{
+		"id":     "{{steps.resolve.libId}}",
+		"tokens": "{{ steps.resolve.tokens }}",
+		"topic":  "hooks in {{steps.resolve.libId}} ({{steps.resolve.tokens}})",
+		"nested": []interface{}{map[string]interface{}{"id": "{{steps.resolve.libId}}"}},
+	}
+
+	rendered := renderReferences(args, outputs).(map[string]interface{})
+	if rendered["id"] != "/facebook/react" || rendered["tokens"] != json.Number("5000") {
+		t.Errorf("whole references should take the value as-is: %v", rendered)
+	}
+	if rendered["topic"] != "hooks in /facebook/react (5000)" {
+		t.Errorf("topic = %v", rendered["topic"])
+	}
+	if nested := rendered["nested"].([]interface{})[0].(map[string]interface{}); nested["id"] != "/facebook/react" {
+		t.Errorf("nested = %v", nested)
+	}
+	if args["id"] != "{{steps.resolve.libId}}" {
+		t.Error("rendering must not change the pipeline's arguments")
+	}
+}
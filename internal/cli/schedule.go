@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/schedule"
+)
+
+// Schedule command and subcommands
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage tool calls the daemon makes on a schedule",
+	Long: `Schedules are tool calls the daemon makes every interval or on a cron
+expression, reusing its warm sessions. They are kept in schedules.json in the
+configuration directory and run only while the daemon is running: runs missed
+while it was stopped are skipped, not made up.`,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List schedules with their next and last runs",
+	Args:  cobra.NoArgs,
+	RunE:  runScheduleList,
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add <name> <server> <tool> [json-args]",
+	Short: "Add a scheduled tool call",
+	Long: `Add a scheduled tool call. Give --every with a duration such as 30m or 1d, or
+--cron with a five-field expression (minute hour day-of-month month
+day-of-week), e.g. --cron "*/30 * * * *". The server's toolDefaults apply when
+the call is made.`,
+	Args: cobra.RangeArgs(3, 4),
+	RunE: runScheduleAdd,
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a schedule",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runScheduleRemove,
+}
+
+var scheduleRunNowCmd = &cobra.Command{
+	Use:   "run-now <name>",
+	Short: "Run a schedule now and print its result",
+	Long: `Run a schedule now, even if it is disabled, and print its result. The run is
+recorded in the schedule's history like any other.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runScheduleRunNow,
+}
+
+var scheduleHistoryCmd = &cobra.Command{
+	Use:   "history <name>",
+	Short: "Show the outcomes of a schedule's recent runs",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runScheduleHistory,
+}
+
+// schedulePollInterval is how often run-now asks the daemon whether the run
+// has finished
+const schedulePollInterval = 250 * time.Millisecond
+
+var (
+	scheduleJSON     bool
+	scheduleEvery    string
+	scheduleCron     string
+	scheduleDisabled bool
+)
+
+func init() {
+	scheduleCmd.PersistentFlags().BoolVar(&scheduleJSON, "json", false, "print schedules, or the result, as JSON")
+	scheduleAddCmd.Flags().StringVar(&scheduleEvery, "every", "", "run at this interval, e.g. 30m or 1d")
+	scheduleAddCmd.Flags().StringVar(&scheduleCron, "cron", "", `run when this cron expression matches, e.g. "0 9 * * 1-5"`)
+	scheduleAddCmd.Flags().BoolVar(&scheduleDisabled, "disabled", false, "add the schedule without running it")
+	scheduleAddCmd.MarkFlagsMutuallyExclusive("every", "cron")
+
+	// A schedule command fails because of the schedule or the daemon, not its usage
+	for _, cmd := range []*cobra.Command{scheduleListCmd, scheduleAddCmd, scheduleRemoveCmd, scheduleRunNowCmd, scheduleHistoryCmd} {
+		cmd.SilenceUsage = true
+	}
+}
+
+func runScheduleList(cmd *cobra.Command, args []string) error {
+	client := daemon.SharedDaemonClient()
+	if !client.IsDaemonRunning() {
+		// Without the daemon there are only the definitions to show
+		path, err := schedule.DefaultPath()
+		if err != nil {
+			return err
+		}
+		schedules, err := schedule.Load(path)
+		if err != nil {
+			return err
+		}
+		statuses := make([]daemon.ScheduleStatus, len(schedules))
+		for i := range schedules {
+			statuses[i].Schedule = schedules[i]
+		}
+		if scheduleJSON {
+			return writeScheduleJSON(os.Stdout, statuses)
+		}
+		writeScheduleList(os.Stdout, statuses, time.Now())
+		if len(statuses) > 0 {
+			fmt.Fprintln(os.Stderr, "The daemon is not running, so none of these run; start it with 'mcp-cli-ent daemon start'.")
+		}
+		return nil
+	}
+
+	statuses, err := client.ListSchedules()
+	if err != nil {
+		return err
+	}
+	if scheduleJSON {
+		return writeScheduleJSON(os.Stdout, statuses)
+	}
+	writeScheduleList(os.Stdout, statuses, time.Now())
+	return nil
+}
+
+func runScheduleAdd(cmd *cobra.Command, args []string) error {
+	sched := schedule.Schedule{
+		Name:     args[0],
+		Server:   args[1],
+		Tool:     args[2],
+		Interval: scheduleEvery,
+		Cron:     scheduleCron,
+	}
+	if len(args) == 4 {
+		if err := json.Unmarshal([]byte(args[3]), &sched.Args); err != nil {
+			return fmt.Errorf("invalid JSON arguments: %w", err)
+		}
+	}
+	if scheduleDisabled {
+		enabled := false
+		sched.Enabled = &enabled
+	}
+	if err := sched.Validate(); err != nil {
+		return err
+	}
+
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return err
+	}
+	if _, exists := cfg.GetServer(sched.Server); !exists {
+		return displayServerNotFoundError(sched.Server, cfg)
+	}
+
+	return updateSchedules(func(schedules []schedule.Schedule) ([]schedule.Schedule, error) {
+		for _, existing := range schedules {
+			if existing.Name == sched.Name {
+				return nil, fmt.Errorf("schedule %s already exists; remove it first", sched.Name)
+			}
+		}
+		return append(schedules, sched), nil
+	}, fmt.Sprintf("Added schedule %s (%s)", sched.Name, sched.Every()))
+}
+
+func runScheduleRemove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	return updateSchedules(func(schedules []schedule.Schedule) ([]schedule.Schedule, error) {
+		for i, existing := range schedules {
+			if existing.Name == name {
+				return append(schedules[:i], schedules[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("schedule %s not found", name)
+	}, fmt.Sprintf("Removed schedule %s", name))
+}
+
+// updateSchedules edits schedules.json with edit, tells a running daemon
+// and prints done
+func updateSchedules(edit func([]schedule.Schedule) ([]schedule.Schedule, error), done string) error {
+	ensureConfigDir()
+	path, err := schedule.DefaultPath()
+	if err != nil {
+		return err
+	}
+	schedules, err := schedule.Load(path)
+	if err != nil {
+		return err
+	}
+	if schedules, err = edit(schedules); err != nil {
+		return err
+	}
+	if err := schedule.Save(path, schedules); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Println(done)
+
+	client := daemon.SharedDaemonClient()
+	if !client.IsDaemonRunning() {
+		if !quiet {
+			fmt.Fprintln(os.Stderr, "The daemon is not running; schedules run once it is started with 'mcp-cli-ent daemon start'.")
+		}
+		return nil
+	}
+	if err := client.ReloadSchedules(); err != nil {
+		return fmt.Errorf("the daemon did not reload schedules: %w", err)
+	}
+	return nil
+}
+
+func runScheduleRunNow(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	client := daemon.SharedDaemonClient()
+
+	status := startStatus()
+	defer status.Stop()
+	if !client.IsDaemonRunning() {
+		status.Phase("starting the daemon…")
+		if err := client.StartDaemon(); err != nil {
+			return fmt.Errorf("failed to start daemon: %w", err)
+		}
+	}
+
+	requested := time.Now()
+	if _, err := client.RunSchedule(args[0]); err != nil {
+		return err
+	}
+
+	status.Phase("running schedule %s…", args[0])
+	ticker := time.NewTicker(schedulePollInterval)
+	defer ticker.Stop()
+	var sched *daemon.ScheduleStatus
+	for {
+		var err error
+		if sched, err = client.GetSchedule(args[0]); err != nil {
+			return err
+		}
+		if !sched.Running && sched.LastRun != nil && !sched.LastRun.Started.Before(requested) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting; schedule %s is still running in the daemon", args[0])
+		case <-ticker.C:
+		}
+	}
+	status.Stop()
+
+	if sched.LastRun.Error != "" {
+		return fmt.Errorf("schedule %s failed: %s", sched.Name, sched.LastRun.Error)
+	}
+	var result mcp.ToolResult
+	if err := json.Unmarshal(sched.LastResult, &result); err != nil {
+		return fmt.Errorf("invalid result of schedule %s: %w", sched.Name, err)
+	}
+	result.Raw = sched.LastResult
+	if scheduleJSON {
+		return writeToolResultRaw(os.Stdout, &result)
+	}
+	renderToolResult(os.Stdout, os.Stderr, &result, markdownFormatter())
+	return nil
+}
+
+func runScheduleHistory(cmd *cobra.Command, args []string) error {
+	client := daemon.SharedDaemonClient()
+	if !client.IsDaemonRunning() {
+		return mcp.Mark(errors.New("daemon is not running; history is kept by the daemon while it runs"), mcp.ErrDaemonUnavailable)
+	}
+
+	sched, err := client.GetSchedule(args[0])
+	if err != nil {
+		return err
+	}
+	if scheduleJSON {
+		return writeScheduleJSON(os.Stdout, sched.History)
+	}
+	writeScheduleHistory(os.Stdout, sched)
+	return nil
+}
+
+// writeScheduleJSON writes v as indented JSON
+func writeScheduleJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeScheduleList describes schedules for people
+func writeScheduleList(out io.Writer, statuses []daemon.ScheduleStatus, now time.Time) {
+	if len(statuses) == 0 {
+		fmt.Fprintln(out, "No schedules. Add one with 'mcp-cli-ent schedule add'.")
+		return
+	}
+
+	for _, s := range statuses {
+		fmt.Fprintf(out, "%s: %s on %s, %s", s.Name, s.Tool, s.Server, s.Every())
+		if !s.IsEnabled() {
+			fmt.Fprint(out, " (disabled)")
+		}
+		fmt.Fprintln(out)
+
+		if s.Running {
+			fmt.Fprintln(out, "    Running now")
+		} else if s.Next != nil {
+			fmt.Fprintf(out, "    Next: %s (in %s)\n", s.Next.Local().Format(time.DateTime), s.Next.Sub(now).Round(time.Second))
+		}
+		if s.LastRun != nil {
+			fmt.Fprintf(out, "    Last: %s\n", describeScheduleRun(*s.LastRun))
+		}
+	}
+}
+
+// writeScheduleHistory lists a schedule's recent runs, newest first
+func writeScheduleHistory(out io.Writer, sched *daemon.ScheduleStatus) {
+	if len(sched.History) == 0 {
+		fmt.Fprintf(out, "Schedule %s has not run since the daemon started.\n", sched.Name)
+		return
+	}
+	for _, run := range sched.History {
+		fmt.Fprintln(out, describeScheduleRun(run))
+	}
+}
+
+// describeScheduleRun summarizes one run on a line
+func describeScheduleRun(run daemon.ScheduleRun) string {
+	outcome := "ok"
+	switch {
+	case run.Error != "":
+		outcome = "failed: " + run.Error
+	case run.IsError:
+		outcome = "tool error"
+	}
+	line := fmt.Sprintf("%s  %s  %s", run.Started.Local().Format(time.DateTime), run.Duration.Round(time.Millisecond), outcome)
+	if run.Manual {
+		line += " (run-now)"
+	}
+	return line
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/schedule"
+)
+
+func TestWriteScheduleList(t *testing.T) {
+	now := time.Now()
+	next := now.Add(20 * time.Minute)
+	disabled := false
+	statuses := []daemon.ScheduleStatus{
+		{
+			Schedule: schedule.Schedule{Name: "digest", Server: "scraper", Tool: "summarize", Interval: "30m"},
+			Next:     &next,
+			LastRun:  &daemon.ScheduleRun{Started: now.Add(-10 * time.Minute), Duration: 1500 * time.Millisecond, Error: "tool call failed: timeout"},
+		},
+		{
+			Schedule: schedule.Schedule{Name: "weekly", Server: "github", Tool: "report", Cron: "0 9 * * 1", Enabled: &disabled},
+		},
+	}
+
+	var out bytes.Buffer
+	writeScheduleList(&out, statuses, now)
+	got := out.String()
+
+	for _, want := range []string{
+		"digest: summarize on scraper, every 30m\n",
+		"Next: ",
+		"(in 20m0s)",
+		"1.5s  failed: tool call failed: timeout",
+		"weekly: report on github, cron 0 9 * * 1 (disabled)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestDescribeScheduleRun(t *testing.T) {
+	run := daemon.ScheduleRun{Started: time.Now(), Duration: 42 * time.Millisecond, IsError: true, Manual: true}
+	if got := describeScheduleRun(run); !strings.HasSuffix(got, "42ms  tool error (run-now)") {
+		t.Errorf("describeScheduleRun = %q", got)
+	}
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/selftest"
+)
+
+var selftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Check that the whole stack works on this machine against a built-in server",
+	Long: `Run a built-in MCP server with one echo tool and call it every way the CLI
+can, reporting each stage as it passes or fails, with its timing:
+
+  stdio     a stdio client starting the server (this binary again)
+  http      an HTTP client of the server on a loopback port
+  session   a persistent session reused by a second client
+
+With --with-daemon, a daemon is also started in the background, asked to call
+the server, and stopped again.
+
+Nothing outside a temporary directory is touched: the configuration is not
+read, and the daemon is an instance of its own, so a daemon already running
+keeps running. A failed stage shows what the server, the session manager or
+the daemon logged. The command fails if any stage did, so it can run in CI
+against the built binary.`,
+	Args: cobra.NoArgs,
+	RunE: runSelftest,
+}
+
+var (
+	selftestWithDaemon bool
+	selftestOutput     string
+	selftestServeEcho  bool
+)
+
+func init() {
+	selftestCmd.Flags().BoolVar(&selftestWithDaemon, "with-daemon", false, "also start a daemon, call the server through it and stop it")
+	selftestCmd.Flags().StringVar(&selftestOutput, "output", outputText, "report format: text or json")
+	selftestCmd.Flags().BoolVar(&selftestServeEcho, "serve-echo", false, "run the built-in server on stdin and stdout")
+	_ = selftestCmd.Flags().MarkHidden("serve-echo")
+}
+
+// selftestServer is how selftest starts the built-in server over stdio
+var selftestServer = echoServerConfig
+
+// echoServerConfig starts this binary with --serve-echo
+func echoServerConfig() (config.ServerConfig, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return config.ServerConfig{}, fmt.Errorf("failed to find this executable: %w", err)
+	}
+	return config.ServerConfig{Command: executable, Args: []string{"selftest", "--serve-echo"}}, nil
+}
+
+func runSelftest(cmd *cobra.Command, args []string) error {
+	if selftestServeEcho {
+		// stdout carries the protocol; what the server logs goes to stderr
+		return selftest.ServeEcho(os.Stdin, os.Stdout, os.Stderr)
+	}
+	if selftestOutput != outputText && selftestOutput != outputJSON {
+		return fmt.Errorf("invalid --output '%s' (use %s or %s)", selftestOutput, outputText, outputJSON)
+	}
+	server, err := selftestServer()
+	if err != nil {
+		return err
+	}
+
+	opts := selftest.Options{
+		Server:     server,
+		WithDaemon: selftestWithDaemon,
+		Timeout:    time.Duration(timeout) * time.Second,
+	}
+	var report func(selftest.Result)
+	if selftestOutput == outputText {
+		report = func(result selftest.Result) { writeSelftestResult(os.Stdout, result) }
+	}
+	results, err := selftest.Run(commandContext(cmd), opts, report)
+	if err != nil {
+		return err
+	}
+
+	if selftestOutput == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	}
+	return selftestError(results)
+}
+
+// writeSelftestResult writes one line for a stage, followed, if it failed, by
+// its logs indented beneath
+func writeSelftestResult(out io.Writer, result selftest.Result) {
+	if result.OK {
+		fmt.Fprintf(out, "PASS  %-13s %s\n", result.Stage, result.Duration())
+		return
+	}
+	fmt.Fprintf(out, "FAIL  %-13s %s  %s\n", result.Stage, result.Duration(), result.Error)
+	if result.Logs != "" {
+		fmt.Fprintf(out, "      %s\n", strings.ReplaceAll(result.Logs, "\n", "\n      "))
+	}
+}
+
+// selftestError summarises the stages that failed, or returns nil
+func selftestError(results []selftest.Result) error {
+	var failed []string
+	for _, result := range results {
+		if !result.OK {
+			failed = append(failed, result.Stage)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d stages failed: %s", len(failed), len(results), strings.Join(failed, ", "))
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/selftest"
+)
+
+// selftestServerEnv makes the test binary run the built-in server; see
+// TestSelftestServerHelper
+const selftestServerEnv = "MCP_CLI_TEST_SELFTEST_SERVER"
+
+// TestSelftestServerHelper is the built-in server when selftest starts the
+// test binary in place of mcp-cli-ent, and does nothing otherwise
+func TestSelftestServerHelper(t *testing.T) {
+	if os.Getenv(selftestServerEnv) == "" {
+		return
+	}
+	if err := selftest.ServeEcho(os.Stdin, os.Stdout, os.Stderr); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestSelftest(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	serverEnv := map[string]string{selftestServerEnv: "1"}
+	selftestServer = func() (config.ServerConfig, error) {
+		return config.ServerConfig{Command: os.Args[0], Args: []string{"-test.run=^TestSelftestServerHelper$"}, Env: serverEnv}, nil
+	}
+	defer func() {
+		selftestServer = echoServerConfig
+		selftestOutput = outputText
+		selftestCmd.Flags().Lookup("output").Changed = false
+	}()
+
+	stdout, err := runCLI(t, configPath, "selftest")
+	if err != nil {
+		t.Fatalf("selftest failed: %v\n%s", err, stdout)
+	}
+	for _, stage := range []string{selftest.StageStdio, selftest.StageHTTP, selftest.StageSession} {
+		if !strings.Contains(stdout, "PASS  "+stage) {
+			t.Errorf("stage %s did not pass:\n%s", stage, stdout)
+		}
+	}
+
+	// Without the server the stages needing it fail, showing how it was started
+	serverEnv = nil
+	stdout, err = runCLI(t, configPath, "selftest", "--output", "json")
+	if err == nil || !strings.Contains(err.Error(), "2 of 3 stages failed: stdio, session") {
+		t.Errorf("want the failed stages summarised, got %v", err)
+	}
+	var results []selftest.Result
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+	if len(results) != 3 || results[0].OK || !strings.Contains(results[0].Logs, "server command: "+os.Args[0]) {
+		t.Errorf("results = %+v", results)
+	}
+}
@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/pkg/mcpclient"
+)
+
+// infoCountTimeout bounds each listing server info makes to count tools,
+// resources and prompts; a slower server's counts are left out
+const infoCountTimeout = 5 * time.Second
+
+// Server command and subcommands
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Inspect MCP servers",
+}
+
+var serverInfoCmd = &cobra.Command{
+	Use:   "info <server-name>",
+	Short: "Show what a server reports about itself",
+	Long: `Initialize a server and show its name and version, the protocol version it
+confirmed beside the one requested (the server's protocolVersion, if pinned)
+and the capabilities it advertises, with counts of its tools, resources
+and prompts. With --json, print the initialize result as the server sent it.
+With --verbose, also print the initialize request sent, including the
+server's initializeOptions.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runServerInfo,
+}
+
+var serverInfoJSON bool
+
+func init() {
+	serverInfoCmd.Flags().BoolVar(&serverInfoJSON, "json", false, "print the initialize result as the server sent it")
+}
+
+// serverCounts are the sizes of a server's lists; -1 means unknown
+type serverCounts struct {
+	tools, resources, prompts int
+}
+
+func runServerInfo(cmd *cobra.Command, args []string) error {
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return err
+	}
+
+	serverName := args[0]
+	serverConfig, exists := cfg.GetServer(serverName)
+	if !exists {
+		return displayServerNotFoundError(serverName, cfg)
+	}
+	if !serverConfig.IsEnabled() {
+		return serverDisabledError(serverName, serverConfig)
+	}
+
+	factory, err := getSessionAwareClientFactory()
+	if err != nil {
+		return fmt.Errorf("failed to create client factory: %w", err)
+	}
+
+	status := startStatus()
+	defer status.Stop()
+
+	status.Phase("starting %s…", serverName)
+	mcpClient, err := factory.CreateClient(serverName, serverConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer func() { _ = mcpClient.Close() }()
+
+	ctx := commandContext(cmd)
+	status.Phase("initializing…")
+	params := mcpclient.DefaultInitializeParams()
+	result, err := mcpClient.Initialize(ctx, params)
+	if err != nil {
+		return withServerHint(fmt.Errorf("failed to initialize: %w", err))
+	}
+
+	sent := client.InitializeParams(serverConfig, params)
+	if result.ProtocolVersion != sent.ProtocolVersion {
+		status.Printf("Warning: %s speaks protocol %s; this client asked for %s\n", serverName, result.ProtocolVersion, sent.ProtocolVersion)
+	}
+
+	if serverInfoJSON {
+		status.Stop()
+		return writeInitializeResult(os.Stdout, result)
+	}
+
+	status.Phase("counting tools, resources and prompts…")
+	counts := countServerLists(ctx, mcpClient, result.Capabilities)
+	status.Stop()
+	writeServerInfo(os.Stdout, result, sent.ProtocolVersion, counts)
+	if isVerbose() {
+		return writeInitializeSent(os.Stdout, sent)
+	}
+	return nil
+}
+
+// countServerLists lists what the server advertises, giving each listing a
+// few seconds
+func countServerLists(ctx context.Context, mcpClient mcp.MCPClient, capabilities mcp.ServerCapabilities) serverCounts {
+	counts := serverCounts{tools: -1, resources: -1, prompts: -1}
+	count := func(list func(context.Context) (int, error)) int {
+		listCtx, cancel := context.WithTimeout(ctx, infoCountTimeout)
+		defer cancel()
+		n, err := list(listCtx)
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+
+	if capabilities.Tools != nil {
+		counts.tools = count(func(ctx context.Context) (int, error) {
+			tools, err := mcpClient.ListTools(ctx)
+			return len(tools), err
+		})
+	}
+	if capabilities.Resources != nil {
+		counts.resources = count(func(ctx context.Context) (int, error) {
+			resources, err := mcpClient.ListResources(ctx)
+			return len(resources), err
+		})
+	}
+	if sender, ok := mcpClient.(mcp.RequestSender); ok && capabilities.Prompts != nil {
+		counts.prompts = count(func(ctx context.Context) (int, error) {
+			data, err := sender.SendRequest(ctx, "prompts/list", nil)
+			if err != nil {
+				return 0, err
+			}
+			var prompts mcp.ListPromptsResult
+			err = json.Unmarshal(data, &prompts)
+			return len(prompts.Prompts), err
+		})
+	}
+	return counts
+}
+
+// writeInitializeResult writes the initialize result as the server sent it,
+// falling back to indented JSON when the client doesn't have its bytes
+func writeInitializeResult(out io.Writer, result *mcp.InitializeResult) error {
+	if len(result.Raw) == 0 {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	if _, err := out.Write(result.Raw); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+// writeInitializeSent writes the initialize request's parameters as sent
+func writeInitializeSent(out io.Writer, params *mcp.InitializeParams) error {
+	fmt.Fprintln(out, "\nSent:")
+	enc := json.NewEncoder(out)
+	enc.SetIndent("  ", "  ")
+	fmt.Fprint(out, "  ")
+	return enc.Encode(params)
+}
+
+// writeServerInfo writes the server's identity, the protocol version it
+// confirmed for the one requested, and a capability matrix
+func writeServerInfo(out io.Writer, result *mcp.InitializeResult, requested string, counts serverCounts) {
+	fmt.Fprintf(out, "Server:   %s %s\n", result.ServerInfo.Name, result.ServerInfo.Version)
+	fmt.Fprintf(out, "Protocol: %s (requested %s)\n", result.ProtocolVersion, requested)
+	fmt.Fprintln(out, "\nCapabilities:")
+
+	capabilities := result.Capabilities
+	rows := []struct {
+		name    string
+		present bool
+		details []string
+		count   int
+		noun    string
+	}{
+		{"tools", capabilities.Tools != nil, listChanged(capabilities.Tools != nil && capabilities.Tools.ListChanged), counts.tools, "tool"},
+		{"resources", capabilities.Resources != nil, resourceDetails(capabilities.Resources), counts.resources, "resource"},
+		{"prompts", capabilities.Prompts != nil, listChanged(capabilities.Prompts != nil && capabilities.Prompts.ListChanged), counts.prompts, "prompt"},
+		{"logging", capabilities.Logging != nil, nil, -1, ""},
+		{"sampling", capabilities.Sampling != nil, nil, -1, ""},
+		{"elicitation", capabilities.Elicitation != nil, nil, -1, ""},
+		{"experimental", len(capabilities.Experimental) > 0, sortedKeys(capabilities.Experimental), -1, ""},
+	}
+	for _, row := range rows {
+		if !row.present {
+			fmt.Fprintf(out, "  %-13s no\n", row.name)
+			continue
+		}
+		line := "yes"
+		if len(row.details) > 0 {
+			line += " (" + strings.Join(row.details, ", ") + ")"
+		}
+		if row.count >= 0 {
+			line += fmt.Sprintf(", %d %s", row.count, row.noun)
+			if row.count != 1 {
+				line += "s"
+			}
+		}
+		fmt.Fprintf(out, "  %-13s %s\n", row.name, line)
+	}
+}
+
+// listChanged describes a list capability's change notifications
+func listChanged(notifies bool) []string {
+	if notifies {
+		return []string{"listChanged"}
+	}
+	return nil
+}
+
+// resourceDetails describes the resources capability's options
+func resourceDetails(capability *mcp.ResourcesCapability) []string {
+	if capability == nil {
+		return nil
+	}
+	var details []string
+	if capability.Subscribe {
+		details = append(details, "subscribe")
+	}
+	return append(details, listChanged(capability.ListChanged)...)
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
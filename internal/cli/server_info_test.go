@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+func TestWriteServerInfo(t *testing.T) {
+	result := &mcp.InitializeResult{
+		ProtocolVersion: "2025-03-26",
+		ServerInfo:      mcp.ServerInfo{Name: "docs", Version: "2.1"},
+		Capabilities: mcp.ServerCapabilities{
+			Tools:        &mcp.ToolsCapability{ListChanged: true},
+			Resources:    &mcp.ResourcesCapability{Subscribe: true},
+			Logging:      &mcp.LoggingCapability{},
+			Experimental: map[string]interface{}{"streaming": true},
+		},
+	}
+
+	var out bytes.Buffer
+	writeServerInfo(&out, result, "2024-11-05", serverCounts{tools: 12, resources: 1, prompts: -1})
+	for _, line := range []string{
+		"Server:   docs 2.1",
+		"Protocol: 2025-03-26 (requested 2024-11-05)",
+		"tools         yes (listChanged), 12 tools",
+		"resources     yes (subscribe), 1 resource",
+		"prompts       no",
+		"logging       yes",
+		"sampling      no",
+		"experimental  yes (streaming)",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output lacks %q:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestCountServerLists(t *testing.T) {
+	// Advertises tools and prompts; resources/list is never asked for
+	script := `while IFS= read -r line; do
+  case "$line" in
+    *'"tools/list"'*) echo '{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"a"},{"name":"b"}]}}' ;;
+    *'"prompts/list"'*) echo '{"jsonrpc":"2.0","id":0,"result":{"prompts":[{"name":"p"}]}}' ;;
+    *) echo '{"jsonrpc":"2.0","id":0,"error":{"code":-32601,"message":"unexpected"}}' ;;
+  esac
+done`
+	c, err := client.NewStdioClient("sh", []string{"-c", script}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	capabilities := mcp.ServerCapabilities{Tools: &mcp.ToolsCapability{}, Prompts: &mcp.PromptsCapability{}}
+	counts := countServerLists(context.Background(), c, capabilities)
+	if counts != (serverCounts{tools: 2, resources: -1, prompts: 1}) {
+		t.Errorf("counts = %+v, want 2 tools, 1 prompt and unknown resources", counts)
+	}
+}
+
+func TestWriteInitializeResultIsByteIdentical(t *testing.T) {
+	raw := `{"protocolVersion":"2024-11-05", "serverInfo":{"name":"x"}}`
+	var out bytes.Buffer
+	if err := writeInitializeResult(&out, &mcp.InitializeResult{Raw: []byte(raw)}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != raw+"\n" {
+		t.Errorf("got %q, want the server's bytes", out.String())
+	}
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolcache"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolsnapshot"
+)
+
+// snapshotDiff is list-tools --diff output as JSON
+type snapshotDiff struct {
+	Server   string `json:"server"`
+	Snapshot string `json:"snapshot"` // The snapshot file compared against
+	toolsnapshot.Diff
+}
+
+// snapshotPath returns --snapshot-file, or the server's snapshot in the
+// data directory
+func snapshotPath(serverName string) (string, error) {
+	if snapshotFile != "" {
+		return snapshotFile, nil
+	}
+	path, err := toolsnapshot.DefaultPath(serverName)
+	if err != nil {
+		return "", fmt.Errorf("failed to determine data directory: %w", err)
+	}
+	return path, nil
+}
+
+// runToolSnapshot is list-tools with --snapshot or --diff. Both list the
+// server's tools live, hidden ones included.
+func runToolSnapshot(ctx context.Context, cmd *cobra.Command, cache *toolcache.Cache, serverName string, serverConfig config.ServerConfig) error {
+	path, err := snapshotPath(serverName)
+	if err != nil {
+		return err
+	}
+
+	var baseline *toolsnapshot.Snapshot
+	if diffTools {
+		// Check for the snapshot before starting the server
+		if baseline, err = toolsnapshot.Load(path); errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no snapshot at %s; take one with 'list-tools %s --snapshot'", path, serverName)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	tools, err := serverTools(ctx, cache, serverName, serverConfig, true)
+	if err != nil {
+		return err
+	}
+	current := toolsnapshot.New(serverName, tools)
+
+	if snapshotTools {
+		if err := current.Save(path); err != nil {
+			return err
+		}
+		fmt.Printf("Saved %d tool(s) of '%s' to %s\n", len(current.Tools), serverName, path)
+		return nil
+	}
+
+	diff := toolsnapshot.Compare(baseline, current)
+	if humanOutput {
+		if diff.Empty() {
+			fmt.Printf("No changes since the snapshot %s\n", path)
+		} else {
+			diff.Write(os.Stdout)
+		}
+	} else {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(snapshotDiff{Server: serverName, Snapshot: path, Diff: diff}); err != nil {
+			return err
+		}
+	}
+	if !diff.Empty() {
+		cmd.SilenceUsage = true // The differences above explain the failure
+		return fmt.Errorf("tools of '%s' differ from the snapshot %s", serverName, path)
+	}
+	return nil
+}
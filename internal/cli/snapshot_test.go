@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// listToolsSnapshot runs list-tools against the fake server with args,
+// forgetting the snapshot flags afterwards
+func listToolsSnapshot(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	defer func() {
+		for _, name := range []string{"snapshot", "diff", "snapshot-file"} {
+			flag := listToolsCmd.Flags().Lookup(name)
+			_ = flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+	}()
+	serversJSON, _ := json.Marshal(map[string]interface{}{
+		"mcpServers": map[string]interface{}{
+			"fake": map[string]interface{}{"command": "sh", "args": []string{"-c", fakeServerScript}},
+		},
+	})
+	return runWithFixtures(t, string(serversJSON), append([]string{"list-tools", "fake"}, args...)...)
+}
+
+func TestListToolsSnapshotAndDiff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fake.json")
+	if _, err := listToolsSnapshot(t, "--snapshot", "--snapshot-file", path); err != nil {
+		t.Fatalf("--snapshot failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"name": "echo"`) {
+		t.Fatalf("snapshot = %s, %v", data, err)
+	}
+
+	stdout, err := listToolsSnapshot(t, "--diff", "--snapshot-file", path)
+	if err != nil {
+		t.Fatalf("an unchanged server should pass --diff: %v\n%s", err, stdout)
+	}
+
+	// A tool renamed since the snapshot fails the diff
+	writeTestFile(t, path, strings.Replace(string(data), `"echo"`, `"say"`, 1))
+	stdout, err = listToolsSnapshot(t, "--diff", "--snapshot-file", path)
+	if err == nil {
+		t.Fatal("want --diff to fail when the tools changed")
+	}
+	var diff snapshotDiff
+	if err := json.Unmarshal([]byte(stdout), &diff); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "echo" || len(diff.Removed) != 1 || diff.Removed[0] != "say" {
+		t.Errorf("diff = %+v", diff)
+	}
+
+	// Without a snapshot there is nothing to compare with
+	if _, err := listToolsSnapshot(t, "--diff", "--snapshot-file", filepath.Join(t.TempDir(), "none.json")); err == nil || !strings.Contains(err.Error(), "no snapshot at") {
+		t.Errorf("want a missing snapshot error, got %v", err)
+	}
+}
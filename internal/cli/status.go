@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/render"
+)
+
+// statusMode selects how a statusReporter shows progress
+type statusMode int
+
+const (
+	statusOff     statusMode = iota // no progress output
+	statusSpinner                   // spinner line redrawn in place
+	statusLog                       // plain log lines under --verbose
+)
+
+// Progress timing defaults
+const (
+	spinnerInterval   = 100 * time.Millisecond
+	statusLogInterval = 10 * time.Second
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// statusReporter shows what a long-running command is doing on stderr. All
+// writes to its writer go through the reporter so that progress and messages
+// never interleave mid-line; call Stop before printing results.
+type statusReporter struct {
+	w        io.Writer
+	mode     statusMode
+	interval time.Duration
+
+	mu      sync.Mutex
+	phase   string
+	since   time.Time
+	frame   int
+	drawn   bool
+	lastLog time.Time
+	stop    chan struct{}
+	done    chan struct{}
+
+	restoreLog func() // Undoes the log redirect made for the spinner
+}
+
+// newStatusReporter creates a reporter writing to w in the given mode
+func newStatusReporter(w io.Writer, mode statusMode) *statusReporter {
+	interval := spinnerInterval
+	if mode == statusLog {
+		interval = time.Second
+	}
+	return &statusReporter{w: w, mode: mode, interval: interval}
+}
+
+// startStatus returns a reporter for the current command: log lines under
+// --verbose (other verbose output would break a spinner line), a spinner
+// when stderr is a terminal, and nothing otherwise or with --quiet
+func startStatus() *statusReporter {
+	mode := statusOff
+	switch {
+	case quiet:
+	case isVerbose():
+		mode = statusLog
+	case render.IsTerminal(os.Stderr):
+		mode = statusSpinner
+	}
+
+	status := newStatusReporter(os.Stderr, mode)
+	if mode == statusSpinner {
+		// Warnings logged mid-operation must not land on the spinner line
+		status.restoreLog = logging.RedirectStderr(status)
+	}
+	return status
+}
+
+// Phase sets the operation in progress, resetting its elapsed time
+func (s *statusReporter) Phase(format string, args ...interface{}) {
+	if s.mode == statusOff {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.phase = fmt.Sprintf(format, args...)
+	s.since = time.Now()
+	switch s.mode {
+	case statusSpinner:
+		s.draw()
+	case statusLog:
+		fmt.Fprintf(s.w, "[status] %s\n", s.phase)
+		s.lastLog = s.since
+	}
+
+	if s.stop == nil {
+		s.stop = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.loop(s.stop, s.done)
+	}
+}
+
+// Update redescribes the operation in progress, keeping its elapsed time
+func (s *statusReporter) Update(format string, args ...interface{}) {
+	if s.mode == statusOff {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.phase == "" {
+		return // Stopped
+	}
+	s.phase = fmt.Sprintf(format, args...)
+	switch s.mode {
+	case statusSpinner:
+		s.draw()
+	case statusLog:
+		fmt.Fprintf(s.w, "[status] %s\n", s.phase)
+		s.lastLog = time.Now()
+	}
+}
+
+// Printf writes a message line without disturbing the progress display
+func (s *statusReporter) Printf(format string, args ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clear()
+	fmt.Fprintf(s.w, format, args...)
+	if s.mode == statusSpinner && s.phase != "" {
+		s.draw()
+	}
+}
+
+// Write lets the reporter stand in for stderr, keeping each write on its
+// own line clear of the spinner
+func (s *statusReporter) Write(p []byte) (int, error) {
+	s.Printf("%s", p)
+	return len(p), nil
+}
+
+// Stop clears the progress display. It is safe to call more than once.
+func (s *statusReporter) Stop() {
+	s.mu.Lock()
+	s.clear()
+	s.phase = ""
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	restoreLog := s.restoreLog
+	s.restoreLog = nil
+	s.mu.Unlock()
+
+	if restoreLog != nil {
+		restoreLog()
+	}
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// loop refreshes the display until stop is closed
+func (s *statusReporter) loop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			if s.phase != "" {
+				switch s.mode {
+				case statusSpinner:
+					s.frame++
+					s.draw()
+				case statusLog:
+					if now.Sub(s.lastLog) >= statusLogInterval {
+						fmt.Fprintf(s.w, "[status] still %s (%s)\n", s.phase, formatElapsed(now.Sub(s.since)))
+						s.lastLog = now
+					}
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// draw redraws the spinner line; the caller holds s.mu
+func (s *statusReporter) draw() {
+	line := spinnerFrames[s.frame%len(spinnerFrames)] + " " + s.phase
+	if elapsed := time.Since(s.since); elapsed >= time.Second {
+		line += " " + formatElapsed(elapsed)
+	}
+	fmt.Fprint(s.w, "\r\x1b[K"+line)
+	s.drawn = true
+}
+
+// clear erases the spinner line if one is shown; the caller holds s.mu
+func (s *statusReporter) clear() {
+	if s.drawn {
+		fmt.Fprint(s.w, "\r\x1b[K")
+		s.drawn = false
+	}
+}
+
+// formatElapsed renders a duration as whole seconds or minutes and seconds
+func formatElapsed(d time.Duration) string {
+	secs := int(d.Seconds())
+	if secs < 60 {
+		return fmt.Sprintf("%ds", secs)
+	}
+	return fmt.Sprintf("%dm%02ds", secs/60, secs%60)
+}
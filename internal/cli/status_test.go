@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for use from the reporter's goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStatusReporterSpinner(t *testing.T) {
+	var out syncBuffer
+	status := newStatusReporter(&out, statusSpinner)
+	status.interval = time.Millisecond
+
+	status.Phase("starting %s…", "docs")
+	time.Sleep(20 * time.Millisecond)
+	status.Printf("docs: (failed to connect: %s)\n", "boom")
+	status.Stop()
+	status.Stop()
+
+	got := out.String()
+	if !strings.Contains(got, "starting docs…") {
+		t.Errorf("spinner output missing phase: %q", got)
+	}
+	// Messages start on a cleared line and end before the spinner returns
+	if !strings.Contains(got, "\r\x1b[Kdocs: (failed to connect: boom)\n") {
+		t.Errorf("message was not written on a clean line: %q", got)
+	}
+	// Nothing is left on screen after Stop
+	if !strings.HasSuffix(got, "\r\x1b[K") {
+		t.Errorf("output should end by clearing the spinner line: %q", got)
+	}
+}
+
+func TestStatusReporterLog(t *testing.T) {
+	var out syncBuffer
+	status := newStatusReporter(&out, statusLog)
+
+	status.Phase("calling %s…", "search_docs")
+	status.Stop()
+
+	if got, want := out.String(), "[status] calling search_docs…\n"; got != want {
+		t.Errorf("log output = %q, want %q", got, want)
+	}
+}
+
+func TestStatusReporterOff(t *testing.T) {
+	var out syncBuffer
+	status := newStatusReporter(&out, statusOff)
+
+	status.Phase("starting docs…")
+	status.Printf("warning\n")
+	status.Stop()
+
+	if got := out.String(); got != "warning\n" {
+		t.Errorf("output = %q, want only the message", got)
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := map[time.Duration]string{
+		12 * time.Second:                      "12s",
+		59*time.Second + 900*time.Millisecond: "59s",
+		2*time.Minute + 5*time.Second:         "2m05s",
+	}
+	for d, want := range tests {
+		if got := formatElapsed(d); got != want {
+			t.Errorf("formatElapsed(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// docsServerScript is a fake MCP server with two tools that rejects every
+// call as an unknown tool
+const docsServerScript = `while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed -n 's/.*"id":\([0-9]*\).*/\1/p')
+  case "$line" in
+    *'"initialize"'*) echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"docs","version":"1.0.0"}}}' ;;
+    *'"tools/list"'*) echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"tools":[{"name":"resolve-library-id","inputSchema":{"type":"object"}},{"name":"get-library-docs","inputSchema":{"type":"object"}}]}}' ;;
+    *'"tools/call"'*) echo '{"jsonrpc":"2.0","id":'"$id"',"error":{"code":-32602,"message":"Unknown tool"}}' ;;
+  esac
+done`
+
+func TestServerNotFoundErrorSuggestsServers(t *testing.T) {
+	cfg := &config.Configuration{MCPServers: map[string]config.ServerConfig{
+		"context7": {Command: "npx"},
+		"github":   {Command: "npx"},
+	}}
+	if err := serverNotFoundError("contex7", cfg); err.Error() != "server 'contex7' not found in configuration; did you mean 'context7'?" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := serverNotFoundError("weather", cfg); err.Error() != "server 'weather' not found in configuration" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUnknownToolSuggestsTools(t *testing.T) {
+	serversJSON, _ := json.Marshal(map[string]interface{}{
+		"mcpServers": map[string]interface{}{
+			"docs": map[string]interface{}{"command": "sh", "args": []string{"-c", docsServerScript}},
+		},
+	})
+
+	_, err := runWithFixtures(t, string(serversJSON), "call", "docs", "get-library-doc")
+	if err == nil || !strings.HasSuffix(err.Error(), "did you mean 'get-library-docs'?") {
+		t.Errorf("call: want a suggested tool, got %v", err)
+	}
+	_, err = runWithFixtures(t, string(serversJSON), "call", "docs", "weather")
+	if err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("call: want no suggestion for a name nothing is close to, got %v", err)
+	}
+
+	_, err = runWithFixtures(t, string(serversJSON), "tool", "docs", "resolve-libary-id")
+	if err == nil || !strings.HasSuffix(err.Error(), "has no tool 'resolve-libary-id'; did you mean 'resolve-library-id'?") {
+		t.Errorf("tool: want a suggested tool, got %v", err)
+	}
+}
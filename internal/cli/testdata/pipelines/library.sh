@@ -0,0 +1,19 @@
+#!/bin/sh
+# Fake documentation server: resolve-library-id answers a library ID,
+# get-library-docs knows only /facebook/react, and broken answers a result
+# without content.
+while IFS= read -r line; do
+  id=$(printf '%s' "$line" | sed -n 's/.*"id":\([0-9]*\).*/\1/p')
+  case "$line" in
+    *'"method":"initialize"'*)
+      echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"library","version":"1.0.0"}}}' ;;
+    *'"name":"resolve-library-id"'*)
+      echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"content":[{"type":"text","text":"/facebook/react"}]}}' ;;
+    *'"name":"get-library-docs"'*'"/facebook/react"'* | *'"/facebook/react"'*'"name":"get-library-docs"'*)
+      echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"content":[{"type":"text","text":"React docs: useState, useEffect"}]}}' ;;
+    *'"name":"get-library-docs"'*)
+      echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"content":[{"type":"text","text":"unknown library"}],"isError":true}}' ;;
+    *'"name":"broken"'*)
+      echo '{"jsonrpc":"2.0","id":'"$id"',"result":{"content":[]}}' ;;
+  esac
+done
@@ -0,0 +1,532 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/suggest"
+)
+
+var toolCmd = &cobra.Command{
+	Use:   "tool <server-name> <tool-name> [--<argument> <value>...]",
+	Short: "Call a tool with flags generated from its input schema",
+	Long: `Call a tool giving its arguments as flags instead of a JSON object. Each
+top-level property of the tool's input schema becomes a flag in kebab case
+(libraryId becomes --library-id). Object properties, and any property without
+a simple type, take a JSON value. Array properties can be repeated.
+
+Use "mcp-cli-ent tool <server-name> <tool-name> --help" to see a tool's flags.`,
+	// Flags come from the tool's schema, so they are parsed in runToolCommand
+	DisableFlagParsing: true,
+	RunE:               runToolCommand,
+}
+
+// Kinds of value a schema flag takes
+const (
+	kindString  = "string"
+	kindInteger = "integer"
+	kindNumber  = "number"
+	kindBoolean = "boolean"
+	kindArray   = "array"
+	kindJSON    = "json" // Objects and anything without a simple type, given as JSON
+)
+
+// schemaFlag is the flag for one top-level property of a tool's input schema
+type schemaFlag struct {
+	name        string // The flag name, the property name in kebab case
+	property    string
+	kind        string
+	itemKind    string // For arrays, the kind of each item
+	required    bool
+	enum        []string
+	description string
+	defaultText string // The schema's default, shown in help but not sent
+}
+
+// toolFlags parses a tool's arguments from flags
+type toolFlags struct {
+	flags []schemaFlag
+	set   *pflag.FlagSet
+	help  bool
+}
+
+func runToolCommand(cmd *cobra.Command, args []string) error {
+	// Global flags are parsed here too, since cobra leaves every flag to us
+	globals := pflag.NewFlagSet(cmd.Name(), pflag.ContinueOnError)
+	globals.ParseErrorsWhitelist.UnknownFlags = true
+	globals.SetOutput(io.Discard)
+	globals.AddFlagSet(cmd.InheritedFlags())
+	help := globals.BoolP("help", "h", false, "")
+	if err := globals.Parse(args); err != nil {
+		return err
+	}
+	positional := globals.Args()
+	if len(positional) < 2 {
+		if *help || len(positional) == 0 {
+			return cmd.Help()
+		}
+		return fmt.Errorf("requires <server-name> and <tool-name>, e.g. mcp-cli-ent tool %s <tool-name> --help", positional[0])
+	}
+	_ = closeLog()
+	initConfig()            // Again, now that --verbose, --quiet and --log-file are known
+	cmd.SilenceUsage = true // The command's usage says nothing about the tool's flags
+	if err := selectDaemon(); err != nil {
+		return err
+	}
+
+	cfg, err := LoadConfiguration(GetConfigPath())
+	if err != nil {
+		return err
+	}
+	serverName, toolName := positional[0], positional[1]
+	serverConfig, exists := cfg.GetServer(serverName)
+	if !exists {
+		return displayServerNotFoundError(serverName, cfg)
+	}
+	if !serverConfig.IsEnabled() {
+		return serverDisabledError(serverName, serverConfig)
+	}
+
+	status := startStatus()
+	defer status.Stop()
+
+	// The schema comes from the tool cache when it can; the server is only
+	// started once it is needed
+	var mcpClient mcp.MCPClient
+	defer func() {
+		if mcpClient != nil {
+			_ = mcpClient.Close()
+		}
+	}()
+	connect := func() error {
+		if mcpClient != nil {
+			return nil
+		}
+		status.Phase("starting %s…", serverName)
+		c, err := daemon.NewSmartClient().CreateClient(serverName, serverConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		mcpClient = c
+		return nil
+	}
+
+	cache := toolCache(cfg)
+	tools, cached := cache.Get(serverName, serverConfig)
+	if !cached || refreshCache {
+		if err := connect(); err != nil {
+			return err
+		}
+		status.Phase("listing tools…")
+		if tools, err = mcpClient.ListTools(commandContext(cmd)); err != nil {
+			return withServerHint(fmt.Errorf("failed to list tools: %w", err))
+		}
+		cacheTools(cache, serverName, serverConfig, tools)
+	}
+	tool := findTool(tools, toolName)
+	if tool == nil {
+		return mcp.Mark(suggest.Wrap(fmt.Errorf("server '%s' has no tool '%s'", serverName, toolName), toolName, toolNames(tools)), mcp.ErrToolNotFound)
+	}
+
+	flags := newToolFlags(tool.InputSchema, cmd.InheritedFlags())
+	if err := flags.set.Parse(args); err != nil {
+		return fmt.Errorf("%w\nRun 'mcp-cli-ent tool %s %s --help' for its flags", err, serverName, toolName)
+	}
+	if flags.help {
+		status.Stop()
+		writeToolUsage(os.Stdout, serverName, tool, flags)
+		return nil
+	}
+	if extra := flags.set.Args(); len(extra) > 2 {
+		return fmt.Errorf("unexpected argument '%s'; arguments are given as flags", extra[2])
+	}
+
+	arguments, err := flags.arguments()
+	if err != nil {
+		return err
+	}
+	if !noToolDefaults {
+		arguments = serverConfig.ApplyToolDefaults(toolName, arguments)
+	}
+	if missing := flags.missing(arguments); len(missing) > 0 {
+		return fmt.Errorf("missing required flags: --%s", strings.Join(missing, ", --"))
+	}
+
+	if err := connect(); err != nil {
+		return err
+	}
+	result, err := callTool(commandContext(cmd), cfg, status, mcpClient, serverName, toolName, arguments, audit.SourceTool)
+	if err != nil {
+		return err
+	}
+	renderToolResult(os.Stdout, os.Stderr, result, markdownFormatter())
+	return nil
+}
+
+// findTool returns the tool named name, or nil
+func findTool(tools []mcp.Tool, name string) *mcp.Tool {
+	for i := range tools {
+		if tools[i].Name == name {
+			return &tools[i]
+		}
+	}
+	return nil
+}
+
+// newToolFlags creates the flags for a tool's input schema. reserved holds
+// flags the command already has (the global ones); they are parsed along with
+// the tool's, and a property whose flag would clash with one is prefixed with
+// "arg-".
+func newToolFlags(schema map[string]interface{}, reserved *pflag.FlagSet) *toolFlags {
+	f := &toolFlags{flags: schemaFlags(schema), set: pflag.NewFlagSet("tool", pflag.ContinueOnError)}
+	f.set.SetOutput(io.Discard)
+	f.set.SortFlags = false
+	if reserved != nil {
+		f.set.AddFlagSet(reserved)
+	}
+	f.set.BoolVarP(&f.help, "help", "h", false, "show the tool's flags")
+
+	for i := range f.flags {
+		flag := &f.flags[i]
+		for f.set.Lookup(flag.name) != nil {
+			flag.name = "arg-" + flag.name
+		}
+		usage := flag.usage()
+		switch flag.kind {
+		case kindString:
+			f.set.String(flag.name, "", usage)
+		case kindInteger:
+			f.set.Int64(flag.name, 0, usage)
+		case kindNumber:
+			f.set.Float64(flag.name, 0, usage)
+		case kindBoolean:
+			f.set.Bool(flag.name, false, usage)
+		case kindArray:
+			f.set.StringArray(flag.name, nil, usage)
+		default:
+			f.set.String(flag.name, "", usage)
+		}
+		if flag.kind == kindJSON {
+			f.set.Lookup(flag.name).Value = &jsonValue{}
+		}
+		// Shown in help only: an unset flag is left to the server's default
+		if flag.defaultText != "" {
+			f.set.Lookup(flag.name).DefValue = flag.defaultText
+		}
+	}
+	return f
+}
+
+// jsonValue holds a JSON flag's text; its type name shows in help
+type jsonValue struct{ text string }
+
+func (v *jsonValue) String() string     { return v.text }
+func (v *jsonValue) Set(s string) error { v.text = s; return nil }
+func (v *jsonValue) Type() string       { return "json" }
+
+// schemaFlags derives a flag for each top-level property of schema, sorted
+// with the required ones first
+func schemaFlags(schema map[string]interface{}) []schemaFlag {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := make(map[string]bool)
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	// Two properties may map to one kebab-case name; they keep their own
+	taken := make(map[string]int)
+	for property := range properties {
+		taken[flagName(property)]++
+	}
+
+	flags := make([]schemaFlag, 0, len(properties))
+	for property, raw := range properties {
+		prop, _ := raw.(map[string]interface{})
+		flag := schemaFlag{name: flagName(property), property: property, required: required[property]}
+		if taken[flag.name] > 1 {
+			flag.name = property
+		}
+		flag.kind, flag.itemKind = schemaKind(prop)
+		flag.description, _ = prop["description"].(string)
+		if values, ok := prop["enum"].([]interface{}); ok {
+			for _, value := range values {
+				flag.enum = append(flag.enum, fmt.Sprint(value))
+			}
+		}
+		if value, ok := prop["default"]; ok {
+			flag.defaultText = formatDefault(value)
+		}
+		flags = append(flags, flag)
+	}
+
+	sort.Slice(flags, func(i, j int) bool {
+		if flags[i].required != flags[j].required {
+			return flags[i].required
+		}
+		return flags[i].name < flags[j].name
+	})
+	return flags
+}
+
+// schemaKind returns the kind of flag for a property schema, and for arrays
+// the kind of their items
+func schemaKind(prop map[string]interface{}) (string, string) {
+	switch schemaType(prop) {
+	case "string":
+		return kindString, ""
+	case "integer":
+		return kindInteger, ""
+	case "number":
+		return kindNumber, ""
+	case "boolean":
+		return kindBoolean, ""
+	case "array":
+		items, _ := prop["items"].(map[string]interface{})
+		itemKind, _ := schemaKind(items)
+		if itemKind == kindArray {
+			itemKind = kindJSON
+		}
+		return kindArray, itemKind
+	case "":
+		if _, ok := prop["enum"]; ok {
+			return kindString, ""
+		}
+	}
+	return kindJSON, ""
+}
+
+// schemaType returns a property's type. Of a list of types, the first other
+// than "null" counts.
+func schemaType(prop map[string]interface{}) string {
+	switch t := prop["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// flagName converts a property name to kebab case: libraryId, library_id and
+// LibraryID all become library-id
+func flagName(property string) string {
+	var b strings.Builder
+	runes := []rune(property)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == ' ':
+			b.WriteByte('-')
+		case unicode.IsUpper(r):
+			// A new word starts at an upper case letter after a lower case one,
+			// or at the last upper case letter of an acronym
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// formatDefault writes a schema default as a flag value
+func formatDefault(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64, bool:
+		return fmt.Sprint(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// usage describes the flag in help
+func (f *schemaFlag) usage() string {
+	var parts []string
+	if f.required {
+		parts = append(parts, "(required)")
+	}
+	if f.description != "" {
+		parts = append(parts, strings.Join(strings.Fields(f.description), " "))
+	}
+	if len(f.enum) > 0 {
+		parts = append(parts, "one of: "+strings.Join(f.enum, ", "))
+	}
+	if f.kind == kindArray {
+		parts = append(parts, "(repeatable)")
+	}
+	return strings.Join(parts, " ")
+}
+
+// arguments builds the tool arguments from the flags that were given
+func (f *toolFlags) arguments() (map[string]interface{}, error) {
+	arguments := make(map[string]interface{})
+	for _, flag := range f.flags {
+		if !f.set.Changed(flag.name) {
+			continue
+		}
+		value, err := f.value(flag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for --%s: %w", flag.name, err)
+		}
+		arguments[flag.property] = value
+	}
+	return arguments, nil
+}
+
+// value converts one flag's value to the type its property expects
+func (f *toolFlags) value(flag schemaFlag) (interface{}, error) {
+	switch flag.kind {
+	case kindString:
+		value, _ := f.set.GetString(flag.name)
+		if len(flag.enum) > 0 && !containsString(flag.enum, value) {
+			return nil, fmt.Errorf("'%s' is not one of: %s", value, strings.Join(flag.enum, ", "))
+		}
+		return value, nil
+	case kindInteger:
+		return f.set.GetInt64(flag.name)
+	case kindNumber:
+		return f.set.GetFloat64(flag.name)
+	case kindBoolean:
+		return f.set.GetBool(flag.name)
+	case kindArray:
+		values, _ := f.set.GetStringArray(flag.name)
+		if len(values) == 1 && strings.HasPrefix(strings.TrimSpace(values[0]), "[") {
+			var items []interface{}
+			if err := json.Unmarshal([]byte(values[0]), &items); err != nil {
+				return nil, fmt.Errorf("invalid JSON array: %w", err)
+			}
+			return items, nil
+		}
+		items := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			item, err := parseItem(flag.itemKind, value)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		text := f.set.Lookup(flag.name).Value.String()
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return value, nil
+	}
+}
+
+// parseItem converts one array item to its kind
+func parseItem(kind, text string) (interface{}, error) {
+	switch kind {
+	case kindInteger:
+		return strconv.ParseInt(text, 10, 64)
+	case kindNumber:
+		return strconv.ParseFloat(text, 64)
+	case kindBoolean:
+		return strconv.ParseBool(text)
+	case kindJSON:
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return value, nil
+	default:
+		return text, nil
+	}
+}
+
+// missing returns the flags of required properties arguments lacks
+func (f *toolFlags) missing(arguments map[string]interface{}) []string {
+	var missing []string
+	for _, flag := range f.flags {
+		if _, ok := arguments[flag.property]; flag.required && !ok {
+			missing = append(missing, flag.name)
+		}
+	}
+	return missing
+}
+
+// writeToolUsage writes help for one tool: its description and flags
+func writeToolUsage(out io.Writer, serverName string, tool *mcp.Tool, flags *toolFlags) {
+	fmt.Fprintf(out, "Usage:\n  mcp-cli-ent tool %s %s [flags]\n", serverName, tool.Name)
+	if tool.Description != "" {
+		fmt.Fprintf(out, "\n%s\n", strings.TrimSpace(tool.Description))
+	}
+	writeToolAnnotations(out, tool.Annotations)
+
+	// Only the tool's own flags; the global ones are in mcp-cli-ent --help
+	own := pflag.NewFlagSet("tool", pflag.ContinueOnError)
+	own.SortFlags = false
+	for _, flag := range flags.flags {
+		own.AddFlag(flags.set.Lookup(flag.name))
+	}
+	if own.HasFlags() {
+		fmt.Fprintf(out, "\nFlags:\n%s", own.FlagUsages())
+	} else {
+		fmt.Fprintln(out, "\nThe tool takes no arguments.")
+	}
+}
+
+// writeToolAnnotations writes every annotation the server gave the tool,
+// nothing if it gave none
+func writeToolAnnotations(out io.Writer, annotations *mcp.ToolAnnotations) {
+	if annotations == nil {
+		return
+	}
+	var lines []string
+	if annotations.Title != "" {
+		lines = append(lines, "title: "+annotations.Title)
+	}
+	for _, hint := range []struct {
+		name  string
+		value *bool
+	}{
+		{"read-only", annotations.ReadOnlyHint},
+		{"destructive", annotations.DestructiveHint},
+		{"idempotent", annotations.IdempotentHint},
+		{"open world", annotations.OpenWorldHint},
+	} {
+		if hint.value != nil {
+			lines = append(lines, fmt.Sprintf("%s: %t", hint.name, *hint.value))
+		}
+	}
+	if len(lines) > 0 {
+		fmt.Fprintf(out, "\nAnnotations (hints from the server):\n  %s\n", strings.Join(lines, "\n  "))
+	}
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// parseSchema decodes a JSON schema the way a tool list carries it
+func parseSchema(t *testing.T, text string) map[string]interface{} {
+	t.Helper()
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+// parseToolFlags parses args against a schema's flags and returns the
+// arguments they build
+func parseToolFlags(t *testing.T, schema string, args ...string) (map[string]interface{}, error) {
+	t.Helper()
+	flags := newToolFlags(parseSchema(t, schema), nil)
+	if err := flags.set.Parse(args); err != nil {
+		return nil, err
+	}
+	return flags.arguments()
+}
+
+func TestFlagName(t *testing.T) {
+	for property, want := range map[string]string{
+		"query":       "query",
+		"libraryId":   "library-id",
+		"library_id":  "library-id",
+		"LibraryID":   "library-id",
+		"maxTokens2":  "max-tokens2",
+		"HTTPTimeout": "http-timeout",
+		"page2Size":   "page2-size",
+	} {
+		if got := flagName(property); got != want {
+			t.Errorf("flagName(%q) = %q, want %q", property, got, want)
+		}
+	}
+}
+
+func TestToolFlagsScalarTypes(t *testing.T) {
+	schema := `{"type": "object", "properties": {
+		"query": {"type": "string"},
+		"maxResults": {"type": "integer"},
+		"threshold": {"type": "number"},
+		"exact": {"type": "boolean"},
+		"unused": {"type": "string"}
+	}}`
+	got, err := parseToolFlags(t, schema, "--query", "go mod", "--max-results", "5", "--threshold=0.5", "--exact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"query": "go mod", "maxResults": int64(5), "threshold": 0.5, "exact": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("arguments = %#v, want %#v", got, want)
+	}
+
+	if _, err := parseToolFlags(t, schema, "--max-results", "five"); err == nil {
+		t.Error("a non-integer for an integer property should be refused")
+	}
+}
+
+func TestToolFlagsRequired(t *testing.T) {
+	schema := `{"properties": {
+		"b": {"type": "string"},
+		"a": {"type": "string"},
+		"id": {"type": "string"}
+	}, "required": ["id"]}`
+	flags := newToolFlags(parseSchema(t, schema), nil)
+	var names []string
+	for _, flag := range flags.flags {
+		names = append(names, flag.name)
+	}
+	if strings.Join(names, ",") != "id,a,b" {
+		t.Errorf("flags in order %v, want the required one first", names)
+	}
+
+	if missing := flags.missing(map[string]interface{}{"a": "x"}); !reflect.DeepEqual(missing, []string{"id"}) {
+		t.Errorf("missing = %v, want [id]", missing)
+	}
+	if missing := flags.missing(map[string]interface{}{"id": "x"}); len(missing) != 0 {
+		t.Errorf("missing = %v, want none", missing)
+	}
+}
+
+func TestToolFlagsEnum(t *testing.T) {
+	schema := `{"properties": {"mode": {"enum": ["fast", "full"]}}}`
+	got, err := parseToolFlags(t, schema, "--mode", "full")
+	if err != nil || got["mode"] != "full" {
+		t.Errorf("arguments = %v, %v; want mode full", got, err)
+	}
+	if _, err := parseToolFlags(t, schema, "--mode", "slow"); err == nil || !strings.Contains(err.Error(), "fast, full") {
+		t.Errorf("a value outside the enum should be refused listing the choices, got %v", err)
+	}
+}
+
+func TestToolFlagsArrays(t *testing.T) {
+	schema := `{"properties": {
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"ids": {"type": "array", "items": {"type": "integer"}},
+		"filters": {"type": "array", "items": {"type": "object"}}
+	}}`
+	got, err := parseToolFlags(t, schema, "--tags", "a", "--tags", "b c", "--ids", "1", "--ids", "2", "--filters", `{"k": "v"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"tags":    []interface{}{"a", "b c"},
+		"ids":     []interface{}{int64(1), int64(2)},
+		"filters": []interface{}{map[string]interface{}{"k": "v"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("arguments = %#v, want %#v", got, want)
+	}
+
+	// A single JSON array gives the whole value
+	got, err = parseToolFlags(t, schema, "--ids", "[3, 4]")
+	if err != nil || !reflect.DeepEqual(got["ids"], []interface{}{3.0, 4.0}) {
+		t.Errorf("ids = %#v, %v; want [3 4]", got["ids"], err)
+	}
+	if _, err := parseToolFlags(t, schema, "--ids", "x"); err == nil {
+		t.Error("a non-integer item should be refused")
+	}
+}
+
+func TestToolFlagsJSONValues(t *testing.T) {
+	schema := `{"properties": {
+		"options": {"type": "object", "properties": {"depth": {"type": "integer"}}},
+		"anything": {},
+		"nullable": {"type": ["null", "integer"]}
+	}}`
+	flags := newToolFlags(parseSchema(t, schema), nil)
+	if typ := flags.set.Lookup("options").Value.Type(); typ != "json" {
+		t.Errorf("an object flag has type %s, want json", typ)
+	}
+	if typ := flags.set.Lookup("nullable").Value.Type(); typ != "int64" {
+		t.Errorf("a nullable integer flag has type %s, want int64", typ)
+	}
+
+	got, err := parseToolFlags(t, schema, "--options", `{"depth": 2}`, "--anything", `"text"`, "--nullable", "7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"options": map[string]interface{}{"depth": 2.0}, "anything": "text", "nullable": int64(7)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("arguments = %#v, want %#v", got, want)
+	}
+	if _, err := parseToolFlags(t, schema, "--options", "{depth: 2}"); err == nil || !strings.Contains(err.Error(), "--options") {
+		t.Errorf("invalid JSON should be refused naming the flag, got %v", err)
+	}
+}
+
+func TestToolFlagsNameClashes(t *testing.T) {
+	reserved := pflag.NewFlagSet("globals", pflag.ContinueOnError)
+	reserved.Int("timeout", 30, "")
+	schema := `{"properties": {
+		"timeout": {"type": "integer"},
+		"help": {"type": "string"},
+		"libraryId": {"type": "string"},
+		"library_id": {"type": "string"}
+	}}`
+	flags := newToolFlags(parseSchema(t, schema), reserved)
+	if err := flags.set.Parse([]string{"--arg-timeout", "5", "--timeout", "9", "--arg-help", "x", "--libraryId", "a", "--library_id", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := flags.arguments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"timeout": int64(5), "help": "x", "libraryId": "a", "library_id": "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("arguments = %#v, want %#v", got, want)
+	}
+	if global, _ := reserved.GetInt("timeout"); global != 9 {
+		t.Errorf("global --timeout = %d, want 9", global)
+	}
+}
+
+func TestWriteToolUsage(t *testing.T) {
+	reserved := pflag.NewFlagSet("globals", pflag.ContinueOnError)
+	reserved.Bool("verbose", false, "verbose output")
+	tool := &mcp.Tool{
+		Name:        "search",
+		Description: "Search the docs.",
+		InputSchema: parseSchema(t, `{"properties": {
+			"query": {"type": "string", "description": "What to look for"},
+			"limit": {"type": "integer", "default": 10},
+			"mode": {"type": "string", "enum": ["fast", "full"]}
+		}, "required": ["query"]}`),
+	}
+
+	var out bytes.Buffer
+	writeToolUsage(&out, "docs", tool, newToolFlags(tool.InputSchema, reserved))
+	help := out.String()
+	for _, want := range []string{
+		"mcp-cli-ent tool docs search [flags]",
+		"Search the docs.",
+		"--query string   (required) What to look for",
+		"--limit int",
+		"(default 10)",
+		"one of: fast, full",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help lacks %q:\n%s", want, help)
+		}
+	}
+	if strings.Contains(help, "--verbose") {
+		t.Errorf("help should list only the tool's flags:\n%s", help)
+	}
+
+	if strings.Contains(help, "Annotations") {
+		t.Errorf("help should show no annotations for a tool without any:\n%s", help)
+	}
+
+	out.Reset()
+	no, yes := false, true
+	ping := &mcp.Tool{Name: "ping", Annotations: &mcp.ToolAnnotations{Title: "Ping", ReadOnlyHint: &yes, OpenWorldHint: &no}}
+	writeToolUsage(&out, "docs", ping, newToolFlags(nil, reserved))
+	if !strings.Contains(out.String(), "takes no arguments") {
+		t.Errorf("help for a tool without arguments:\n%s", out.String())
+	}
+	if want := "Annotations (hints from the server):\n  title: Ping\n  read-only: true\n  open world: false\n"; !strings.Contains(out.String(), want) {
+		t.Errorf("help lacks the annotations %q:\n%s", want, out.String())
+	}
+}
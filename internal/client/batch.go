@@ -0,0 +1,175 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// SendBatch implements mcp.BatchCapable, sending requests as one JSON-RPC
+// batch. The requests are numbered afresh so their responses can be matched
+// up whatever order the server answers in, as JSON or as server-sent events. A server that answers a batch
+// with anything but an array is sent the requests one at a time instead,
+// then and for the rest of the client's life.
+func (c *HTTPClient) SendBatch(ctx context.Context, requests []*mcp.JSONRPCRequest) ([]mcp.BatchResult, error) {
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	if c.noBatches.Load() {
+		return c.sendSequentially(ctx, requests), nil
+	}
+
+	responses, err := c.postBatch(ctx, requests)
+	if err != nil {
+		return nil, c.redactor.Error(err)
+	}
+	if responses == nil {
+		c.noBatches.Store(true)
+		return c.sendSequentially(ctx, requests), nil
+	}
+
+	results := make([]mcp.BatchResult, len(requests))
+	answered := make([]bool, len(requests))
+	for _, resp := range responses {
+		i, ok := batchIndex(resp.ID, len(requests))
+		if !ok || answered[i] {
+			continue
+		}
+		answered[i] = true
+		if resp.Error != nil {
+			results[i].Err = c.redactor.Error(resp.Error)
+		} else {
+			results[i].Result = rpcResult(&resp)
+		}
+	}
+	for i := range results {
+		if !answered[i] {
+			results[i].Err = fmt.Errorf("no response to %s in the batch", requests[i].Method)
+		}
+	}
+	return results, nil
+}
+
+// postBatch sends requests as one batch and returns the server's responses,
+// or nil when the server does not take batches: it failed the request, or
+// answered with a single object. A server may stream the responses as
+// server-sent events instead, each holding one response or an array of them.
+func (c *HTTPClient) postBatch(ctx context.Context, requests []*mcp.JSONRPCRequest) ([]mcp.JSONRPCResponse, error) {
+	batch := make([]*mcp.JSONRPCRequest, len(requests))
+	for i, req := range requests {
+		batch[i] = mcp.NewRequest(i+1, req.Method, req.Params)
+	}
+	reqBytes, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %w", err)
+	}
+
+	ctx, cancel := requestContext(ctx, c.timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	c.setHeaders(httpReq)
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	c.rememberSession(resp)
+
+	body, err := readLimited(resp.Body, c.limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return eventResponses(body), nil
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '[' {
+		return nil, nil
+	}
+	var responses []mcp.JSONRPCResponse
+	if err := json.Unmarshal(body, &responses); err != nil {
+		return nil, nil
+	}
+	return responses, nil
+}
+
+// eventResponses returns the JSON-RPC responses in a stream of server-sent
+// events, skipping requests and notifications the server sent alongside
+// them, or nil if there are none. An error without an ID, such as one
+// refusing the batch, answers no request and is skipped too.
+func eventResponses(stream []byte) []mcp.JSONRPCResponse {
+	var responses []mcp.JSONRPCResponse
+	add := func(data []byte) {
+		var messages []struct {
+			mcp.JSONRPCResponse
+			Method string `json:"method"`
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) > 0 && data[0] != '[' {
+			data = append(append([]byte{'['}, data...), ']')
+		}
+		if json.Unmarshal(data, &messages) != nil {
+			return
+		}
+		for _, msg := range messages {
+			if msg.Method == "" && msg.ID != nil {
+				responses = append(responses, msg.JSONRPCResponse)
+			}
+		}
+	}
+
+	// An event's data lines are joined with newlines; a blank line ends it
+	var data []byte
+	for _, line := range strings.Split(string(stream), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			add(data)
+			data = nil
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if data != nil {
+				data = append(data, '\n')
+			}
+			data = append(data, strings.TrimPrefix(value, " ")...)
+		}
+	}
+	add(data)
+	return responses
+}
+
+// sendSequentially sends requests one after another, for servers that don't
+// take batches
+func (c *HTTPClient) sendSequentially(ctx context.Context, requests []*mcp.JSONRPCRequest) []mcp.BatchResult {
+	results := make([]mcp.BatchResult, len(requests))
+	for i, req := range requests {
+		results[i].Result, results[i].Err = c.sendRequest(ctx, req)
+	}
+	return results
+}
+
+// batchIndex returns the position in the batch of the request a response
+// with id answers
+func batchIndex(id interface{}, size int) (int, bool) {
+	n, ok := id.(float64) // IDs decode as JSON numbers
+	if !ok || n != float64(int(n)) || n < 1 || int(n) > size {
+		return 0, false
+	}
+	return int(n) - 1, true
+}
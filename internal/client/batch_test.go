@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// batchRequests asks for tools, resources and an unknown method
+func batchRequests() []*mcp.JSONRPCRequest {
+	return []*mcp.JSONRPCRequest{
+		mcp.NewRequest(1, "tools/list", nil),
+		mcp.NewRequest(1, "resources/list", nil),
+		mcp.NewRequest(1, "prompts/nope", nil),
+	}
+}
+
+// answer returns the response a test server gives to one request
+func answer(req mcp.JSONRPCRequest) *mcp.JSONRPCResponse {
+	switch req.Method {
+	case "tools/list":
+		return mcp.NewResponse(req.ID, json.RawMessage(`{"tools":[{"name":"navigate"}]}`))
+	case "resources/list":
+		return mcp.NewResponse(req.ID, json.RawMessage(`{"resources":[]}`))
+	}
+	return mcp.NewErrorResponse(req.ID, mcp.NewError(mcp.MethodNotFound, "method not found: "+req.Method, nil))
+}
+
+// checkBatchResults checks the results of batchRequests
+func checkBatchResults(t *testing.T, results []mcp.BatchResult) {
+	t.Helper()
+	if len(results) != 3 {
+		t.Fatalf("want 3 results, got %d", len(results))
+	}
+	if results[0].Err != nil || string(results[0].Result) != `{"tools":[{"name":"navigate"}]}` {
+		t.Errorf("tools/list: %s, %v", results[0].Result, results[0].Err)
+	}
+	if results[1].Err != nil || string(results[1].Result) != `{"resources":[]}` {
+		t.Errorf("resources/list: %s, %v", results[1].Result, results[1].Err)
+	}
+	if results[2].Err == nil || !strings.Contains(results[2].Err.Error(), "method not found: prompts/nope") {
+		t.Errorf("prompts/nope: want the server's error, got %s, %v", results[2].Result, results[2].Err)
+	}
+}
+
+func TestSendBatchCorrelatesResponsesByID(t *testing.T) {
+	var posts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		var batch []mcp.JSONRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("the request is not a batch: %v", err)
+		}
+		// Answered in reverse, as the spec allows
+		var responses []*mcp.JSONRPCResponse
+		for i := len(batch) - 1; i >= 0; i-- {
+			responses = append(responses, answer(batch[i]))
+		}
+		_ = json.NewEncoder(w).Encode(responses)
+	}))
+	t.Cleanup(server.Close)
+
+	c := NewHTTP(server.URL)
+	results, err := c.SendBatch(context.Background(), batchRequests())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBatchResults(t, results)
+	if n := posts.Load(); n != 1 {
+		t.Errorf("want one round trip, got %d", n)
+	}
+}
+
+func TestSendBatchReadsEventStreams(t *testing.T) {
+	var posts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		var batch []mcp.JSONRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("the request is not a batch: %v", err)
+		}
+		// One response per event, after a progress notification
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{}}\n\n")
+		for _, req := range batch {
+			data, _ := json.Marshal(answer(req))
+			_, _ = io.WriteString(w, "event: message\ndata: "+string(data)+"\n\n")
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	// The stream is an answer to the batch, so batches keep being sent
+	c := NewHTTP(server.URL)
+	for i := 0; i < 2; i++ {
+		results, err := c.SendBatch(context.Background(), batchRequests())
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkBatchResults(t, results)
+	}
+	if n := posts.Load(); n != 2 {
+		t.Errorf("want two round trips, got %d", n)
+	}
+}
+
+func TestSendBatchReportsMissingResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = io.WriteString(w, `[{"jsonrpc":"2.0","id":2,"result":{"resources":[]}}]`)
+	}))
+	t.Cleanup(server.Close)
+
+	results, err := NewHTTP(server.URL).SendBatch(context.Background(), batchRequests()[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "no response to tools/list") {
+		t.Errorf("want the unanswered request reported, got %v", results[0].Err)
+	}
+	if results[1].Err != nil {
+		t.Errorf("resources/list: %v", results[1].Err)
+	}
+}
+
+func TestSendBatchFallsBackToSequentialRequests(t *testing.T) {
+	cases := map[string]func(w http.ResponseWriter){
+		"single object": func(w http.ResponseWriter) {
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"batches are not supported"}}`)
+		},
+		"HTTP error": func(w http.ResponseWriter) {
+			http.Error(w, "unexpected array", http.StatusBadRequest)
+		},
+	}
+	for name, refuse := range cases {
+		t.Run(name, func(t *testing.T) {
+			var batches, singles atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if strings.HasPrefix(string(body), "[") {
+					batches.Add(1)
+					refuse(w)
+					return
+				}
+				singles.Add(1)
+				var req mcp.JSONRPCRequest
+				_ = json.Unmarshal(body, &req)
+				_ = json.NewEncoder(w).Encode(answer(req))
+			}))
+			t.Cleanup(server.Close)
+
+			c := NewHTTP(server.URL)
+			for i := 0; i < 2; i++ {
+				results, err := c.SendBatch(context.Background(), batchRequests())
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkBatchResults(t, results)
+			}
+			// The server is not sent a second batch
+			if batches.Load() != 1 || singles.Load() != 6 {
+				t.Errorf("want 1 batch and 6 single requests, got %d and %d", batches.Load(), singles.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/testharness"
+)
+
+// TestHarnessServer is the stdio server of testharness.StdioConfig
+func TestHarnessServer(t *testing.T) { testharness.ServeStdioHelper() }
+
+// dialHarness returns a dial function connecting to the server serve
+// configures
+func dialHarness(serve func(t *testing.T, script testharness.Script) config.ServerConfig) func(*testing.T, testharness.Script) mcp.MCPClient {
+	return func(t *testing.T, script testharness.Script) mcp.MCPClient {
+		c, err := NewMCPClientWithOptions(serve(t, script))
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		return c
+	}
+}
+
+func TestStdioConformance(t *testing.T) {
+	testharness.Run(t, testharness.Transport{
+		Name: "stdio",
+		Dial: dialHarness(func(_ *testing.T, script testharness.Script) config.ServerConfig {
+			return testharness.StdioConfig(script)
+		}),
+		Handshake: true,
+		Stream:    true,
+		Cancel:    true,
+	})
+}
+
+func TestHTTPConformance(t *testing.T) {
+	testharness.Run(t, testharness.Transport{
+		Name: "http",
+		Dial: dialHarness(func(t *testing.T, script testharness.Script) config.ServerConfig {
+			return testharness.StartHTTP(t, script)
+		}),
+		Handshake: true,
+		Cancel:    true,
+	})
+}
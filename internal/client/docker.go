@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// ContainerLabel marks the containers started for docker servers
+const ContainerLabel = "mcp-cli-ent.managed=true"
+
+// ContainerStopTimeout is how long a container gets to exit before it is killed
+const ContainerStopTimeout = 10 * time.Second
+
+// DockerClient runs a stdio MCP server in a container
+type DockerClient struct {
+	*StdioClient
+	runtime   string
+	container string
+}
+
+// NewDockerClient starts the server's image with "docker run -i --rm" (or the
+// configured runtime) and talks to it over stdio
+func NewDockerClient(serverConfig config.ServerConfig, opts ...Option) (*DockerClient, error) {
+	if err := serverConfig.CheckRuntime(); err != nil {
+		return nil, &ClientError{err.Error()}
+	}
+
+	container, err := newContainerName()
+	if err != nil {
+		return nil, fmt.Errorf("failed to name container: %w", err)
+	}
+
+	runtime := serverConfig.ContainerRuntime()
+	stdio, err := NewStdio(runtime, dockerRunArgs(serverConfig, container), append(serverOptions(serverConfig), opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DockerClient{StdioClient: stdio, runtime: runtime, container: container}, nil
+}
+
+// Close stops the container, then closes the stdio connection
+func (c *DockerClient) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ContainerStopTimeout+5*time.Second)
+	defer cancel()
+
+	seconds := strconv.Itoa(int(ContainerStopTimeout.Seconds()))
+	_ = exec.CommandContext(ctx, c.runtime, "stop", "-t", seconds, c.container).Run()
+
+	return c.StdioClient.Close()
+}
+
+// dockerRunArgs builds the "run" arguments for a docker server. Environment
+// values are passed through the runtime's own environment with bare -e flags,
+// so secrets never appear on the command line.
+func dockerRunArgs(serverConfig config.ServerConfig, container string) []string {
+	args := []string{"run", "-i", "--rm", "--name", container, "--label", ContainerLabel}
+
+	keys := make([]string, 0, len(serverConfig.Env))
+	for key := range serverConfig.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-e", key)
+	}
+
+	for _, volume := range serverConfig.Volumes {
+		args = append(args, "-v", volume)
+	}
+	if serverConfig.Network != "" {
+		args = append(args, "--network", serverConfig.Network)
+	}
+
+	args = append(args, serverConfig.DockerArgs...)
+	args = append(args, serverConfig.Image)
+	return append(args, serverConfig.Args...)
+}
+
+// newContainerName returns a unique name for a server container
+func newContainerName() (string, error) {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return "mcp-cli-ent-" + hex.EncodeToString(suffix), nil
+}
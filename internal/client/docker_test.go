@@ -0,0 +1,48 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestDockerRunArgs(t *testing.T) {
+	server := config.ServerConfig{
+		Type:       config.ServerTypeDocker,
+		Image:      "mcp/github:latest",
+		Args:       []string{"--read-only"},
+		DockerArgs: []string{"--memory", "512m"},
+		Volumes:    []string{"/home/me/src:/src:ro"},
+		Network:    "host",
+		Env:        map[string]string{"GITHUB_TOKEN": "secret", "GITHUB_HOST": "github.com"},
+	}
+
+	got := dockerRunArgs(server, "mcp-cli-ent-test")
+	want := []string{
+		"run", "-i", "--rm", "--name", "mcp-cli-ent-test", "--label", ContainerLabel,
+		"-e", "GITHUB_HOST", "-e", "GITHUB_TOKEN",
+		"-v", "/home/me/src:/src:ro",
+		"--network", "host",
+		"--memory", "512m",
+		"mcp/github:latest", "--read-only",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dockerRunArgs() = %q, want %q", got, want)
+	}
+
+	for _, arg := range got {
+		if arg == "secret" {
+			t.Fatal("environment values must not appear on the command line")
+		}
+	}
+}
+
+func TestNewMCPClientDockerRequiresRuntime(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	server := config.ServerConfig{Type: config.ServerTypeDocker, Image: "mcp/time", Runtime: "podman"}
+	if _, err := NewMCPClient(server); err == nil {
+		t.Fatal("expected an error when the container runtime is not installed")
+	}
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// ErrProfileInUse matches failures of browser servers whose profile another
+// browser holds, usually one a persistent session left running
+var ErrProfileInUse = errors.New("browser profile in use")
+
+// profileInUsePatterns are what browser servers say when their profile is
+// locked, matched case-insensitively
+var profileInUsePatterns = []string{"browser is already running", "chrome-profile"}
+
+// HTTPStatusError reports an HTTP server answering a request with a status
+// other than success
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP error: %d %s - %s", e.StatusCode, e.Status, e.Body)
+}
+
+// Temporary reports whether the status is one proxies and load balancers
+// answer while the server is unavailable, so a retry may succeed
+func (e *HTTPStatusError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// markServerFailure marks err with the sentinels text, the server's own
+// account of the failure, makes recognisable
+func markServerFailure(err error, text string) error {
+	text = strings.ToLower(text)
+	for _, pattern := range profileInUsePatterns {
+		if strings.Contains(text, pattern) {
+			return mcp.Mark(err, ErrProfileInUse)
+		}
+	}
+	return err
+}
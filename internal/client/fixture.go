@@ -0,0 +1,475 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/redact"
+)
+
+// FixtureMode says whether clients record their requests to fixture files or
+// answer them from those files instead of a server
+type FixtureMode int
+
+const (
+	FixturesOff FixtureMode = iota
+	FixturesRecord
+	FixturesReplay
+)
+
+// Fixture is one recorded request and how the server answered it. Fixture
+// files hold one each, as indented JSON with sorted keys, so they diff well.
+type Fixture struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"` // Normalized, with secrets redacted
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"` // The request failed with this message
+}
+
+var (
+	fixtureMutex sync.RWMutex
+	fixtureMode  FixtureMode
+	fixtureDir   string
+)
+
+// SetFixtures makes NewMCPClient record every client's requests to fixture
+// files in dir, or replay them from there without starting any server.
+// FixturesOff turns that off again.
+func SetFixtures(mode FixtureMode, dir string) {
+	fixtureMutex.Lock()
+	defer fixtureMutex.Unlock()
+	fixtureMode, fixtureDir = mode, dir
+}
+
+// FixturesActive reports whether NewMCPClient records or replays fixtures
+func FixturesActive() bool {
+	fixtureMutex.RLock()
+	defer fixtureMutex.RUnlock()
+	return fixtureMode != FixturesOff
+}
+
+// fixtureOptions returns the options SetFixtures asks for
+func fixtureOptions() []Option {
+	fixtureMutex.RLock()
+	defer fixtureMutex.RUnlock()
+	switch fixtureMode {
+	case FixturesRecord:
+		return []Option{WithRecording(fixtureDir)}
+	case FixturesReplay:
+		return []Option{WithReplay(fixtureDir)}
+	}
+	return nil
+}
+
+// WithRecording saves every request the client makes, with its response, to
+// fixture files in dir. Only NewMCPClientWithOptions applies it.
+func WithRecording(dir string) Option {
+	return func(o *options) {
+		o.fixtureMode, o.fixtureDir = FixturesRecord, dir
+	}
+}
+
+// WithReplay answers every request from the fixture files in dir instead of
+// starting or connecting to the server. Only NewMCPClientWithOptions applies it.
+func WithReplay(dir string) Option {
+	return func(o *options) {
+		o.fixtureMode, o.fixtureDir = FixturesReplay, dir
+	}
+}
+
+// fixtureServer identifies a server in fixture keys by how it is reached, so
+// recordings of several servers can share a directory
+func fixtureServer(serverConfig config.ServerConfig) string {
+	return serverConfig.GetServerDetails()
+}
+
+// fixtureStore reads and writes the fixture files of one server
+type fixtureStore struct {
+	dir    string
+	server string
+}
+
+// key returns the normalized params of a request, the secrets redacted
+// from them, and the name of its fixture file. Params are encoded with sorted
+// keys and secrets redacted, so the same request always has the same key.
+func (s *fixtureStore) key(method string, params interface{}) (json.RawMessage, []string, string, error) {
+	var normalized json.RawMessage
+	var secrets []string
+	if params != nil {
+		value, err := decodeGeneric(params)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to encode %s params: %w", method, err)
+		}
+		if normalized, err = json.Marshal(redactSecrets(value, &secrets)); err != nil {
+			return nil, nil, "", fmt.Errorf("failed to encode %s params: %w", method, err)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(s.server + "\n" + method + "\n" + string(normalized)))
+	name := strings.ReplaceAll(method, "/", "_") + "-" + hex.EncodeToString(sum[:8]) + ".json"
+	return normalized, secrets, name, nil
+}
+
+// decodeGeneric round-trips v through JSON into maps and slices, leaving v
+// itself alone
+func decodeGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+// save writes the fixture for a request and its outcome
+func (s *fixtureStore) save(method string, params interface{}, result interface{}, callErr error) error {
+	normalized, secrets, name, err := s.key(method, params)
+	if err != nil {
+		return err
+	}
+	fixture := Fixture{Method: method, Params: normalized}
+	if callErr != nil {
+		fixture.Error = scrubSecrets(callErr.Error(), secrets)
+	} else {
+		value, err := decodeGeneric(result)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s result: %w", method, err)
+		}
+		if fixture.Result, err = json.Marshal(redactSecrets(value, &secrets)); err != nil {
+			return fmt.Errorf("failed to encode %s result: %w", method, err)
+		}
+		fixture.Result = json.RawMessage(scrubSecrets(string(fixture.Result), jsonQuoted(secrets)))
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	return os.WriteFile(filepath.Join(s.dir, name), append(data, '\n'), 0644)
+}
+
+// load returns the recorded result of a request, or the error it failed
+// with. A request that was never recorded fails with a message showing its
+// params next to those of the recorded requests of the same method.
+func (s *fixtureStore) load(method string, params interface{}) (json.RawMessage, error) {
+	normalized, _, name, err := s.key(method, params)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, s.missing(method, normalized)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", name, err)
+	}
+	if fixture.Error != "" {
+		return nil, errors.New(fixture.Error)
+	}
+	return fixture.Result, nil
+}
+
+// missing describes a request that has no fixture
+func (s *fixtureStore) missing(method string, params json.RawMessage) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "no fixture for %s in %s\n", method, s.dir)
+	fmt.Fprintf(&b, "  requested: %s", orEmpty(params))
+
+	names, _ := filepath.Glob(filepath.Join(s.dir, strings.ReplaceAll(method, "/", "_")+"-*.json"))
+	var recorded []string
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		var fixture Fixture
+		if json.Unmarshal(data, &fixture) == nil && fixture.Method == method {
+			recorded = append(recorded, orEmpty(fixture.Params))
+		}
+	}
+	sort.Strings(recorded)
+	for _, params := range recorded {
+		fmt.Fprintf(&b, "\n  recorded:  %s", params)
+	}
+	return errors.New(b.String())
+}
+
+// orEmpty shows params on one line, and absent params as an empty object
+func orEmpty(params json.RawMessage) string {
+	var compact bytes.Buffer
+	if len(params) == 0 || json.Compact(&compact, params) != nil {
+		return "{}"
+	}
+	return compact.String()
+}
+
+// minSecretLength is the length below which a redacted value is not also
+// scrubbed from text, where it would match too much
+const minSecretLength = 4
+
+// redactSecrets replaces the string values of object members named like
+// secrets, adding the values it replaced to secrets
+func redactSecrets(value interface{}, secrets *[]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, member := range v {
+			if secret, isString := member.(string); isString && redact.IsSecretName(key) {
+				if len(secret) >= minSecretLength {
+					*secrets = append(*secrets, secret)
+				}
+				v[key] = config.RedactedValue
+				continue
+			}
+			v[key] = redactSecrets(member, secrets)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactSecrets(item, secrets)
+		}
+	}
+	return value
+}
+
+// scrubSecrets replaces every occurrence of the secrets in text, such as a
+// tool echoing its arguments
+func scrubSecrets(text string, secrets []string) string {
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, config.RedactedValue)
+	}
+	return text
+}
+
+// jsonQuoted returns the secrets as they appear inside JSON strings
+func jsonQuoted(secrets []string) []string {
+	quoted := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		data, _ := json.Marshal(secret)
+		quoted = append(quoted, string(data[1:len(data)-1]))
+	}
+	return quoted
+}
+
+// RecordingClient passes requests on to another client and saves each with
+// its response as a fixture. A request made again overwrites its fixture.
+type RecordingClient struct {
+	client mcp.MCPClient
+	store  *fixtureStore
+}
+
+// NewRecordingClient records the requests made through c to fixture files in
+// dir. server identifies the server in the fixtures' keys.
+func NewRecordingClient(c mcp.MCPClient, dir, server string) *RecordingClient {
+	return &RecordingClient{client: c, store: &fixtureStore{dir: dir, server: server}}
+}
+
+// record saves a fixture; a recording that cannot be saved fails the request
+func (c *RecordingClient) record(method string, params, result interface{}, err error) error {
+	if saveErr := c.store.save(method, params, result, err); saveErr != nil {
+		return fmt.Errorf("failed to record fixture: %w", saveErr)
+	}
+	return err
+}
+
+// Initialize implements mcp.MCPClient. The params are left out of the key,
+// since they name this client's version.
+func (c *RecordingClient) Initialize(ctx context.Context, params *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	result, err := c.client.Initialize(ctx, params)
+	return result, c.record("initialize", nil, result, err)
+}
+
+// ListTools implements mcp.MCPClient
+func (c *RecordingClient) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	tools, err := c.client.ListTools(ctx)
+	return tools, c.record("tools/list", nil, tools, err)
+}
+
+// CallTool implements mcp.MCPClient
+func (c *RecordingClient) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
+	result, err := c.client.CallTool(ctx, name, arguments)
+	var recorded interface{} = result
+	if err == nil && len(result.Raw) > 0 {
+		recorded = result.Raw
+	}
+	return result, c.record("tools/call", callToolParams(name, arguments), recorded, err)
+}
+
+// ListResources implements mcp.MCPClient
+func (c *RecordingClient) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	resources, err := c.client.ListResources(ctx)
+	return resources, c.record("resources/list", nil, resources, err)
+}
+
+// ListRoots implements mcp.MCPClient
+func (c *RecordingClient) ListRoots(ctx context.Context) ([]mcp.Root, error) {
+	roots, err := c.client.ListRoots(ctx)
+	return roots, c.record("roots/list", nil, roots, err)
+}
+
+// CreateMessage implements mcp.MCPClient
+func (c *RecordingClient) CreateMessage(ctx context.Context, request *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	result, err := c.client.CreateMessage(ctx, request)
+	return result, c.record("sampling/createMessage", request, result, err)
+}
+
+// RequestInput implements mcp.MCPClient
+func (c *RecordingClient) RequestInput(ctx context.Context, params *mcp.RequestInputParams) (*mcp.RequestInputResult, error) {
+	result, err := c.client.RequestInput(ctx, params)
+	return result, c.record("elicitation/create", params, result, err)
+}
+
+// NotifyRootsListChanged implements mcp.MCPClient; notifications are not recorded
+func (c *RecordingClient) NotifyRootsListChanged(roots []mcp.Root) error {
+	return c.client.NotifyRootsListChanged(roots)
+}
+
+// SendRequest implements mcp.RequestSender when the wrapped client does
+func (c *RecordingClient) SendRequest(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	sender, ok := c.client.(mcp.RequestSender)
+	if !ok {
+		return nil, fmt.Errorf("%s is not supported by this server connection", method)
+	}
+	result, err := sender.SendRequest(ctx, method, params)
+	return result, c.record(method, params, result, err)
+}
+
+// SetNotificationHandler implements mcp.NotificationSource when the wrapped client does
+func (c *RecordingClient) SetNotificationHandler(handler mcp.NotificationHandler) {
+	if source, ok := c.client.(mcp.NotificationSource); ok {
+		source.SetNotificationHandler(handler)
+	}
+}
+
+// Close implements mcp.MCPClient
+func (c *RecordingClient) Close() error {
+	return c.client.Close()
+}
+
+// ReplayClient answers requests from fixture files without a server.
+// Requests that were never recorded fail.
+type ReplayClient struct {
+	store *fixtureStore
+}
+
+// NewReplayClient answers requests from the fixture files in dir recorded
+// for server
+func NewReplayClient(dir, server string) *ReplayClient {
+	return &ReplayClient{store: &fixtureStore{dir: dir, server: server}}
+}
+
+// replay decodes the recorded result of a request into v
+func (c *ReplayClient) replay(method string, params, v interface{}) (json.RawMessage, error) {
+	result, err := c.store.load(method, params)
+	if err != nil {
+		return nil, err
+	}
+	if v != nil && len(result) > 0 {
+		if err := json.Unmarshal(result, v); err != nil {
+			return nil, fmt.Errorf("invalid %s fixture: %w", method, err)
+		}
+	}
+	return result, nil
+}
+
+// Initialize implements mcp.MCPClient
+func (c *ReplayClient) Initialize(ctx context.Context, params *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	var result mcp.InitializeResult
+	if _, err := c.replay("initialize", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListTools implements mcp.MCPClient
+func (c *ReplayClient) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	var tools []mcp.Tool
+	_, err := c.replay("tools/list", nil, &tools)
+	return tools, err
+}
+
+// CallTool implements mcp.MCPClient
+func (c *ReplayClient) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
+	var result mcp.ToolResult
+	raw, err := c.replay("tools/call", callToolParams(name, arguments), &result)
+	if err != nil {
+		return nil, err
+	}
+	result.Raw = raw
+	return &result, nil
+}
+
+// ListResources implements mcp.MCPClient
+func (c *ReplayClient) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	var resources []mcp.Resource
+	_, err := c.replay("resources/list", nil, &resources)
+	return resources, err
+}
+
+// ListRoots implements mcp.MCPClient
+func (c *ReplayClient) ListRoots(ctx context.Context) ([]mcp.Root, error) {
+	var roots []mcp.Root
+	_, err := c.replay("roots/list", nil, &roots)
+	return roots, err
+}
+
+// CreateMessage implements mcp.MCPClient
+func (c *ReplayClient) CreateMessage(ctx context.Context, request *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	var result mcp.CreateMessageResult
+	if _, err := c.replay("sampling/createMessage", request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RequestInput implements mcp.MCPClient
+func (c *ReplayClient) RequestInput(ctx context.Context, params *mcp.RequestInputParams) (*mcp.RequestInputResult, error) {
+	var result mcp.RequestInputResult
+	if _, err := c.replay("elicitation/create", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// NotifyRootsListChanged implements mcp.MCPClient; there is no server to notify
+func (c *ReplayClient) NotifyRootsListChanged(roots []mcp.Root) error {
+	return nil
+}
+
+// SendRequest implements mcp.RequestSender
+func (c *ReplayClient) SendRequest(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	return c.replay(method, params, nil)
+}
+
+// Close implements mcp.MCPClient
+func (c *ReplayClient) Close() error {
+	return nil
+}
+
+// callToolParams are the params of a tools/call request. No arguments are
+// keyed like empty ones.
+func callToolParams(name string, arguments map[string]interface{}) map[string]interface{} {
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	return map[string]interface{}{"name": name, "arguments": arguments}
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// echoClient answers tool calls with their arguments and fails calls to "fail"
+type echoClient struct {
+	countingClient
+	calls int
+}
+
+func (c *echoClient) ListTools(context.Context) ([]mcp.Tool, error) {
+	return []mcp.Tool{{Name: "echo", Description: "Echo the arguments"}}, nil
+}
+
+func (c *echoClient) CallTool(_ context.Context, name string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
+	c.calls++
+	if name == "fail" {
+		return nil, errors.New("JSON-RPC error -32602: unknown tool")
+	}
+	text, _ := json.Marshal(arguments)
+	return &mcp.ToolResult{Content: []interface{}{map[string]interface{}{"type": "text", "text": string(text)}}}, nil
+}
+
+func (c *echoClient) Initialize(context.Context, *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	return &mcp.InitializeResult{ProtocolVersion: "2025-06-18"}, nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	stub := &echoClient{countingClient: countingClient{closed: new(atomic.Int32)}}
+	recorder := NewRecordingClient(stub, dir, "echo-server")
+
+	args := map[string]interface{}{"text": "hello", "count": 2}
+	if _, err := recorder.CallTool(ctx, "echo", args); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.CallTool(ctx, "fail", nil); err == nil {
+		t.Fatal("expected the recorded call to fail")
+	}
+	if _, err := recorder.ListTools(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.Initialize(ctx, &mcp.InitializeParams{ProtocolVersion: "2025-06-18"}); err != nil {
+		t.Fatal(err)
+	}
+
+	replay := NewReplayClient(dir, "echo-server")
+	result, err := replay.CallTool(ctx, "echo", map[string]interface{}{"count": 2, "text": "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := result.Content[0].(map[string]interface{})
+	if block["text"] != `{"count":2,"text":"hello"}` || len(result.Raw) == 0 {
+		t.Errorf("replayed result = %+v", result)
+	}
+	if _, err := replay.CallTool(ctx, "fail", map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "unknown tool") {
+		t.Errorf("want the recorded error, got %v", err)
+	}
+	if tools, err := replay.ListTools(ctx); err != nil || len(tools) != 1 || tools[0].Name != "echo" {
+		t.Errorf("ListTools = %v, %v", tools, err)
+	}
+	// The client's own version doesn't change the key
+	if init, err := replay.Initialize(ctx, &mcp.InitializeParams{ProtocolVersion: "2024-11-05"}); err != nil || init.ProtocolVersion != "2025-06-18" {
+		t.Errorf("Initialize = %v, %v", init, err)
+	}
+	if stub.calls != 2 {
+		t.Errorf("replaying reached the server: %d calls", stub.calls)
+	}
+
+	// Another server's recordings don't answer
+	if _, err := NewReplayClient(dir, "other-server").ListTools(ctx); err == nil {
+		t.Error("expected no fixture for another server")
+	}
+}
+
+func TestReplayMissingFixture(t *testing.T) {
+	dir := t.TempDir()
+	stub := &echoClient{countingClient: countingClient{closed: new(atomic.Int32)}}
+	if _, err := NewRecordingClient(stub, dir, "echo-server").CallTool(context.Background(), "echo", map[string]interface{}{"text": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewReplayClient(dir, "echo-server").CallTool(context.Background(), "echo", map[string]interface{}{"text": "bye"})
+	if err == nil {
+		t.Fatal("expected an unrecorded request to fail")
+	}
+	for _, want := range []string{
+		"no fixture for tools/call in " + dir,
+		`  requested: {"arguments":{"text":"bye"},"name":"echo"}`,
+		`  recorded:  {"arguments":{"text":"hello"},"name":"echo"}`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%s", want, err)
+		}
+	}
+}
+
+func TestFixturesRedactSecrets(t *testing.T) {
+	dir := t.TempDir()
+	stub := &echoClient{countingClient: countingClient{closed: new(atomic.Int32)}}
+	args := map[string]interface{}{"query": "go", "apiKey": "sk-live-1234", "auth": map[string]interface{}{"Authorization": "Bearer abc"}}
+	if _, err := NewRecordingClient(stub, dir, "echo-server").CallTool(context.Background(), "echo", args); err != nil {
+		t.Fatal(err)
+	}
+	if args["apiKey"] != "sk-live-1234" {
+		t.Error("recording must not change the caller's arguments")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "tools_call-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("want one fixture file, got %v", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-live-1234") || strings.Contains(string(data), "Bearer abc") {
+		t.Errorf("fixture contains a secret:\n%s", data)
+	}
+	if !strings.Contains(string(data), config.RedactedValue) {
+		t.Errorf("fixture should show redacted values:\n%s", data)
+	}
+
+	// A different secret replays the same fixture
+	args["apiKey"] = "sk-test-5678"
+	if _, err := NewReplayClient(dir, "echo-server").CallTool(context.Background(), "echo", args); err != nil {
+		t.Errorf("replay with another secret failed: %v", err)
+	}
+}
+
+func TestNewMCPClientReplaysWithoutServer(t *testing.T) {
+	dir := t.TempDir()
+	serverConfig := config.ServerConfig{Command: "no-such-command-for-fixtures", Env: map[string]string{"TOKEN": "${UNSET_FIXTURE_TOKEN}"}}
+	stub := &echoClient{countingClient: countingClient{closed: new(atomic.Int32)}}
+	if _, err := NewRecordingClient(stub, dir, fixtureServer(serverConfig)).ListTools(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	SetFixtures(FixturesReplay, dir)
+	defer SetFixtures(FixturesOff, "")
+	if !FixturesActive() {
+		t.Fatal("expected fixtures to be active")
+	}
+
+	c, err := NewMCPClient(serverConfig)
+	if err != nil {
+		t.Fatalf("replaying should need neither the command nor its variables: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+	if tools, err := c.ListTools(context.Background()); err != nil || len(tools) != 1 {
+		t.Errorf("ListTools = %v, %v", tools, err)
+	}
+}
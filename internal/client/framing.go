@@ -0,0 +1,181 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// contentLengthHeader precedes each message under Content-Length framing
+const contentLengthHeader = "Content-Length"
+
+// framing delimits the messages exchanged with a stdio server
+type framing interface {
+	// readMessage returns the next message, which may be blank. A message
+	// over limit bytes is skipped and reported as a ResponseTooLargeError; a
+	// limit of zero or less reads messages of any size.
+	readMessage(r *bufio.Reader, limit int64) ([]byte, error)
+	// writeMessage writes msg; the caller flushes
+	writeMessage(w *bufio.Writer, msg []byte) error
+}
+
+// newFraming returns the framing a server's framing setting asks for
+func newFraming(name string) framing {
+	switch name {
+	case config.FramingContentLength:
+		return contentLengthFraming{}
+	case config.FramingAuto:
+		return &autoFraming{}
+	}
+	return ndjsonFraming{}
+}
+
+// ndjsonFraming puts one message on each line
+type ndjsonFraming struct{}
+
+func (ndjsonFraming) readMessage(r *bufio.Reader, limit int64) ([]byte, error) {
+	var msg []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		size := len(msg) + len(chunk)
+		if err == nil {
+			size-- // The newline is not part of the message
+		}
+		if limit > 0 && int64(size) > limit {
+			// Skip the rest of the line so the next message reads cleanly
+			for err == bufio.ErrBufferFull {
+				_, err = r.ReadSlice('\n')
+			}
+			if err != nil {
+				return nil, err
+			}
+			return nil, &ResponseTooLargeError{Limit: limit}
+		}
+		msg = append(msg, chunk...)
+		if err != bufio.ErrBufferFull {
+			return msg, err
+		}
+	}
+}
+
+func (ndjsonFraming) writeMessage(w *bufio.Writer, msg []byte) error {
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	return w.WriteByte('\n')
+}
+
+// contentLengthFraming precedes each message with headers giving its length,
+// as LSP does
+type contentLengthFraming struct{}
+
+func (contentLengthFraming) readMessage(r *bufio.Reader, limit int64) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if length < 0 {
+				continue // Blank lines between messages
+			}
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid message header %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), contentLengthHeader) {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s %q", contentLengthHeader, strings.TrimSpace(value))
+			}
+			length = n
+		}
+	}
+
+	if limit > 0 && int64(length) > limit {
+		if _, err := r.Discard(length); err != nil {
+			return nil, err
+		}
+		return nil, &ResponseTooLargeError{Limit: limit}
+	}
+
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (contentLengthFraming) writeMessage(w *bufio.Writer, msg []byte) error {
+	if _, err := fmt.Fprintf(w, "%s: %d\r\n\r\n", contentLengthHeader, len(msg)); err != nil {
+		return err
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+// autoFraming uses whichever framing the server's first output does. Until
+// the server has written anything, messages are written as ndjson, so a
+// server that waits for the initialize request before writing gets it as
+// ndjson; such a server needs content-length set explicitly. Probing with a
+// second initialize could be read twice by a slow ndjson server.
+type autoFraming struct {
+	detected atomic.Pointer[framing]
+}
+
+// current returns the detected framing, or ndjson before detection
+func (f *autoFraming) current() framing {
+	if detected := f.detected.Load(); detected != nil {
+		return *detected
+	}
+	return ndjsonFraming{}
+}
+
+func (f *autoFraming) readMessage(r *bufio.Reader, limit int64) ([]byte, error) {
+	if f.detected.Load() == nil {
+		detected, err := detectFraming(r)
+		if err != nil {
+			return nil, err
+		}
+		f.detected.Store(&detected)
+	}
+	return f.current().readMessage(r, limit)
+}
+
+func (f *autoFraming) writeMessage(w *bufio.Writer, msg []byte) error {
+	return f.current().writeMessage(w, msg)
+}
+
+// detectFraming looks at the server's first bytes, past any blank space, to
+// tell Content-Length headers from a JSON message
+func detectFraming(r *bufio.Reader) (framing, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			break
+		}
+		_, _ = r.ReadByte()
+	}
+
+	prefix, err := r.Peek(len(contentLengthHeader))
+	if err != nil && len(prefix) == 0 {
+		return nil, err
+	}
+	if strings.EqualFold(string(prefix), contentLengthHeader) {
+		return contentLengthFraming{}, nil
+	}
+	return ndjsonFraming{}, nil
+}
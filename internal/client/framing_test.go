@@ -0,0 +1,182 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// largeMessage returns a JSON message of about size bytes, well past
+// bufio's default 4096-byte buffer
+func largeMessage(t *testing.T, size int) []byte {
+	t.Helper()
+	msg, err := json.Marshal(map[string]string{"jsonrpc": "2.0", "method": "log", "text": strings.Repeat("é{}\n", size/5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestFramingsRoundTripLargeMessages(t *testing.T) {
+	messages := [][]byte{largeMessage(t, 1<<20), []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`), largeMessage(t, 10000)}
+	for _, name := range []string{config.FramingNDJSON, config.FramingContentLength} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := bufio.NewWriter(&buf)
+			f := newFraming(name)
+			for _, msg := range messages {
+				if err := f.writeMessage(w, msg); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			r := bufio.NewReader(&buf)
+			for i, want := range messages {
+				got, err := f.readMessage(r, 0)
+				if err != nil {
+					t.Fatalf("message %d: %v", i, err)
+				}
+				if !bytes.Equal(bytes.TrimSpace(got), want) {
+					t.Fatalf("message %d: read %d bytes, want %d", i, len(got), len(want))
+				}
+			}
+		})
+	}
+}
+
+func TestContentLengthFramingHeaders(t *testing.T) {
+	// Blank lines between messages, other headers and any header case
+	input := "\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\ncontent-length: 2\r\n\r\n{}" +
+		"Content-Length:13\r\n\r\n{\"id\":\"a\\nb\"}"
+	r := bufio.NewReader(strings.NewReader(input))
+	for _, want := range []string{`{}`, `{"id":"a\nb"}`} {
+		got, err := contentLengthFraming{}.readMessage(r, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("read %q, want %q", got, want)
+		}
+	}
+
+	for _, bad := range []string{"Content-Length: -1\r\n\r\n", "Content-Length: many\r\n\r\n", "{}\r\n\r\n"} {
+		if _, err := (contentLengthFraming{}).readMessage(bufio.NewReader(strings.NewReader(bad)), 0); err == nil {
+			t.Errorf("want an error reading %q", bad)
+		}
+	}
+}
+
+func TestAutoFramingDetectsServerFraming(t *testing.T) {
+	cases := map[string]struct {
+		input string
+		want  framing
+	}{
+		"content-length": {"Content-Length: 2\r\n\r\n{}", contentLengthFraming{}},
+		"ndjson":         {"\n{}\n", ndjsonFraming{}},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := &autoFraming{}
+			if _, ok := f.current().(ndjsonFraming); !ok {
+				t.Error("want ndjson written before the server has spoken")
+			}
+			got, err := f.readMessage(bufio.NewReader(strings.NewReader(c.input)), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bytes.TrimSpace(got)) != "{}" {
+				t.Errorf("read %q", got)
+			}
+			if f.current() != c.want {
+				t.Errorf("detected %T, want %T", f.current(), c.want)
+			}
+		})
+	}
+}
+
+// contentLengthServerEnv makes the test binary act as a server framing its
+// messages with Content-Length headers
+const contentLengthServerEnv = "MCP_CLI_TEST_CONTENT_LENGTH_SERVER"
+
+// TestContentLengthServerHelper is the server: it logs a message about each
+// tool call, then answers it with the call's text argument
+func TestContentLengthServerHelper(t *testing.T) {
+	if os.Getenv(contentLengthServerEnv) == "" {
+		t.Skip("helper process")
+	}
+
+	r := bufio.NewReader(os.Stdin)
+	w := bufio.NewWriter(os.Stdout)
+	send := func(v interface{}) {
+		data, _ := json.Marshal(v)
+		_ = contentLengthFraming{}.writeMessage(w, data)
+		_ = w.Flush()
+	}
+	for {
+		msg, err := contentLengthFraming{}.readMessage(r, 0)
+		if err != nil {
+			os.Exit(0)
+		}
+		var req struct {
+			ID     interface{}        `json:"id"`
+			Params mcp.CallToolParams `json:"params"`
+		}
+		if json.Unmarshal(msg, &req) != nil || req.ID == nil {
+			continue
+		}
+		send(mcp.NewNotification(mcp.LoggingMessage, mcp.LoggingMessageParams{Level: "info", Data: json.RawMessage(`"calling"`)}))
+		text, _ := req.Params.Arguments["text"].(string)
+		send(mcp.NewResponse(req.ID, mustMarshal(t, map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": text}},
+		})))
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestStdioContentLengthFraming(t *testing.T) {
+	t.Setenv(contentLengthServerEnv, "1")
+	c, err := NewStdio(os.Args[0], []string{"-test.run=^TestContentLengthServerHelper$"}, WithFraming(config.FramingContentLength))
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	var notified []string
+	c.SetNotificationHandler(func(method string, _ json.RawMessage) {
+		notified = append(notified, method)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	text := strings.Repeat("line\nof text ", 50000) // Far bigger than a bufio buffer
+	for i := 0; i < 2; i++ {
+		result, err := c.CallTool(ctx, "echo", map[string]interface{}{"text": text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if blocks := result.Blocks(); len(blocks) != 1 || blocks[0].Text != text {
+			t.Fatalf("call %d: the text did not round-trip", i)
+		}
+	}
+	if len(notified) != 2 || notified[0] != mcp.LoggingMessage {
+		t.Errorf("want a log message per call, got %v", notified)
+	}
+}
@@ -4,37 +4,59 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/redact"
 )
 
 // HTTPClient implements MCPClient for HTTP-based MCP servers
 type HTTPClient struct {
-	client  *http.Client
-	baseURL string
-	headers map[string]string
-	timeout time.Duration
+	client   *http.Client
+	baseURL  string
+	headers  map[string]string
+	timeout  time.Duration
+	limit    int64            // Bytes a response body may take
+	redactor *redact.Redactor // Scrubs the headers' secrets from errors
+
+	initialize initializeOptions // Added to the initialize request
+
+	sessionMutex sync.Mutex
+	sessionID    string // Assigned by the server on initialize, if it uses sessions
+
+	noBatches atomic.Bool // The server answered a batch with something else
 }
 
-// NewHTTPClient creates a new HTTP MCP client
+// NewHTTPClient creates a new HTTP MCP client. It is NewHTTP with the
+// timeout and headers given in config.
 func NewHTTPClient(url string, config *mcp.ClientConfig) *HTTPClient {
-	timeout := time.Duration(config.Timeout) * time.Second
-	if timeout <= 0 {
-		timeout = 30 * time.Second
-	}
+	return NewHTTP(url, WithTimeout(time.Duration(config.Timeout)*time.Second), WithHeaders(config.Headers))
+}
+
+// NewHTTP creates a client for the MCP server at url
+func NewHTTP(url string, opts ...Option) *HTTPClient {
+	o := newOptions(opts)
+
+	// Requests are bounded by their contexts, so a caller's deadline can
+	// outlast the timeout
+	httpClient := &http.Client{Transport: sharedTransport(o.tlsConfig)}
 
 	return &HTTPClient{
-		client: &http.Client{
-			Timeout: timeout,
-		},
-		baseURL: url,
-		headers: config.Headers,
-		timeout: timeout,
+		client:   httpClient,
+		baseURL:  url,
+		headers:  o.headers,
+		timeout:  o.timeout,
+		limit:    o.maxResponse,
+		redactor: o.redactor(),
+
+		initialize: o.initialize,
 	}
 }
 
@@ -51,14 +73,8 @@ func (c *HTTPClient) ListTools(ctx context.Context) ([]mcp.Tool, error) {
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var listResult mcp.ListToolsResult
-	if err := json.Unmarshal(resultBytes, &listResult); err != nil {
+	if err := json.Unmarshal(result, &listResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal tools list result: %w", err)
 	}
 
@@ -67,12 +83,8 @@ func (c *HTTPClient) ListTools(ctx context.Context) ([]mcp.Tool, error) {
 
 // CallTool executes a specific tool on the MCP server
 func (c *HTTPClient) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
-	params := &mcp.CallToolParams{
-		Name:      name,
-		Arguments: arguments,
-	}
-
-	req := mcp.NewRequest(2, "tools/call", params)
+	// No progress token: this client reads no notifications while a call runs
+	req := mcp.NewCallToolRequest(context.Background(), name, arguments)
 
 	result, err := c.sendRequest(ctx, req)
 	if err != nil {
@@ -83,17 +95,12 @@ func (c *HTTPClient) CallTool(ctx context.Context, name string, arguments map[st
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var toolResult mcp.ToolResult
-	if err := json.Unmarshal(resultBytes, &toolResult); err != nil {
+	if err := json.Unmarshal(result, &toolResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal tool result: %w", err)
 	}
 
+	toolResult.Raw = result
 	return &toolResult, nil
 }
 
@@ -110,14 +117,8 @@ func (c *HTTPClient) ListResources(ctx context.Context) ([]mcp.Resource, error)
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var listResult mcp.ListResourcesResult
-	if err := json.Unmarshal(resultBytes, &listResult); err != nil {
+	if err := json.Unmarshal(result, &listResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal resources list result: %w", err)
 	}
 
@@ -126,7 +127,7 @@ func (c *HTTPClient) ListResources(ctx context.Context) ([]mcp.Resource, error)
 
 // Initialize the MCP connection
 func (c *HTTPClient) Initialize(ctx context.Context, params *mcp.InitializeParams) (*mcp.InitializeResult, error) {
-	req := mcp.NewRequest(0, "initialize", params)
+	req := mcp.NewRequest(0, "initialize", c.initialize.apply(params))
 
 	result, err := c.sendRequest(ctx, req)
 	if err != nil {
@@ -137,16 +138,11 @@ func (c *HTTPClient) Initialize(ctx context.Context, params *mcp.InitializeParam
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var initResult mcp.InitializeResult
-	if err := json.Unmarshal(resultBytes, &initResult); err != nil {
+	if err := json.Unmarshal(result, &initResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal initialize result: %w", err)
 	}
+	initResult.Raw = result
 
 	return &initResult, nil
 }
@@ -164,14 +160,8 @@ func (c *HTTPClient) CreateMessage(ctx context.Context, request *mcp.CreateMessa
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var messageResult mcp.CreateMessageResult
-	if err := json.Unmarshal(resultBytes, &messageResult); err != nil {
+	if err := json.Unmarshal(result, &messageResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal message result: %w", err)
 	}
 
@@ -191,14 +181,8 @@ func (c *HTTPClient) RequestInput(ctx context.Context, params *mcp.RequestInputP
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var inputResult mcp.RequestInputResult
-	if err := json.Unmarshal(resultBytes, &inputResult); err != nil {
+	if err := json.Unmarshal(result, &inputResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal input result: %w", err)
 	}
 
@@ -218,16 +202,10 @@ func (c *HTTPClient) ListRoots(ctx context.Context) ([]mcp.Root, error) {
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var listResult struct {
 		Roots []mcp.Root `json:"roots"`
 	}
-	if err := json.Unmarshal(resultBytes, &listResult); err != nil {
+	if err := json.Unmarshal(result, &listResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal roots list result: %w", err)
 	}
 
@@ -247,7 +225,8 @@ func (c *HTTPClient) NotifyRootsListChanged(roots []mcp.Root) error {
 		return fmt.Errorf("failed to marshal notification: %w", err)
 	}
 
-	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return fmt.Errorf("failed to create HTTP request: %w", err)
@@ -255,9 +234,7 @@ func (c *HTTPClient) NotifyRootsListChanged(roots []mcp.Root) error {
 
 	// Set headers
 	httpReq.Header.Set("Content-Type", "application/json")
-	for key, value := range c.headers {
-		httpReq.Header.Set(key, value)
-	}
+	c.setHeaders(httpReq)
 
 	// Send notification (fire and forget)
 	resp, err := c.client.Do(httpReq)
@@ -269,18 +246,74 @@ func (c *HTTPClient) NotifyRootsListChanged(roots []mcp.Root) error {
 	return nil
 }
 
-// Close closes the HTTP client
+// SendRequest implements mcp.RequestSender
+func (c *HTTPClient) SendRequest(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	return c.sendRequest(ctx, mcp.NewRequest(0, method, params))
+}
+
+// Close ends the server session, if the server assigned one
 func (c *HTTPClient) Close() error {
-	// HTTP client doesn't need explicit closing
+	sessionID := c.session()
+	if sessionID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	c.setHeaders(httpReq)
+
+	// Servers may not allow clients to end sessions, which is fine
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil
+	}
+	_ = resp.Body.Close()
 	return nil
 }
 
+// setHeaders adds the configured headers, the pinned protocol version and
+// the session ID to a request
+func (c *HTTPClient) setHeaders(httpReq *http.Request) {
+	for key, value := range c.headers {
+		httpReq.Header.Set(key, value)
+	}
+	if version := c.initialize.protocolVersion; version != "" {
+		httpReq.Header.Set(mcp.ProtocolVersionHeader, version)
+	}
+	if sessionID := c.session(); sessionID != "" {
+		httpReq.Header.Set(mcp.SessionIDHeader, sessionID)
+	}
+}
+
+// rememberSession keeps the session the server assigned on initialize
+func (c *HTTPClient) rememberSession(resp *http.Response) {
+	if sessionID := resp.Header.Get(mcp.SessionIDHeader); sessionID != "" {
+		c.sessionMutex.Lock()
+		c.sessionID = sessionID
+		c.sessionMutex.Unlock()
+	}
+}
+
+// session returns the session ID assigned by the server
+func (c *HTTPClient) session() string {
+	c.sessionMutex.Lock()
+	defer c.sessionMutex.Unlock()
+	return c.sessionID
+}
+
 // sendRequest sends a JSON-RPC request to the MCP server
-func (c *HTTPClient) sendRequest(ctx context.Context, req *mcp.JSONRPCRequest) (interface{}, error) {
-	return c.sendRequestWithURL(ctx, req, c.baseURL, false)
+func (c *HTTPClient) sendRequest(ctx context.Context, req *mcp.JSONRPCRequest) (json.RawMessage, error) {
+	ctx, cancel := requestContext(ctx, c.timeout)
+	defer cancel()
+	result, err := c.sendRequestWithURL(ctx, req, c.baseURL, false)
+	return result, c.redactor.Error(err)
 }
 
-func (c *HTTPClient) sendRequestWithURL(ctx context.Context, req *mcp.JSONRPCRequest, urlStr string, triedFallback bool) (interface{}, error) {
+func (c *HTTPClient) sendRequestWithURL(ctx context.Context, req *mcp.JSONRPCRequest, urlStr string, triedFallback bool) (json.RawMessage, error) {
 	// Marshal the request
 	reqBytes, err := mcp.MarshalRequest(req)
 	if err != nil {
@@ -296,20 +329,26 @@ func (c *HTTPClient) sendRequestWithURL(ctx context.Context, req *mcp.JSONRPCReq
 	// Set headers
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "application/json, text/event-stream")
-
-	for key, value := range c.headers {
-		httpReq.Header.Set(key, value)
-	}
+	c.setHeaders(httpReq)
 
 	// Send request
 	resp, err := c.client.Do(httpReq)
 	if err != nil {
+		if ctx.Err() != nil {
+			c.notifyCancelled(req.ID)
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, mcp.Mark(fmt.Errorf("HTTP request failed: %w", err), mcp.ErrTimeout)
+		}
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	c.rememberSession(resp)
+
 	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	body, err := readLimited(resp.Body, c.limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
@@ -321,7 +360,7 @@ func (c *HTTPClient) sendRequestWithURL(ctx context.Context, req *mcp.JSONRPCReq
 				return c.sendRequestWithURL(ctx, req, fallbackURL, true)
 			}
 		}
-		return nil, fmt.Errorf("HTTP error: %d %s - %s", resp.StatusCode, resp.Status, string(body))
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
 	}
 
 	// Unmarshal JSON-RPC response
@@ -332,10 +371,30 @@ func (c *HTTPClient) sendRequestWithURL(ctx context.Context, req *mcp.JSONRPCReq
 
 	// Check for JSON-RPC error
 	if rpcResp.Error != nil {
-		return nil, fmt.Errorf("JSON-RPC error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+		return nil, rpcResp.Error
 	}
 
-	return rpcResp.Result, nil
+	return rpcResult(rpcResp), nil
+}
+
+// notifyCancelled tells the server to stop work on a request the caller gave
+// up on. It is best effort and waits at most cancelNotifyTimeout.
+func (c *HTTPClient) notifyCancelled(id interface{}) {
+	data, err := json.Marshal(mcp.NewNotification(mcp.Cancelled, &mcp.CancelledParams{RequestID: id, Reason: cancelReason}))
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cancelNotifyTimeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	c.setHeaders(httpReq)
+	if resp, err := c.client.Do(httpReq); err == nil {
+		_ = resp.Body.Close()
+	}
 }
 
 func httpFallbackURL(urlStr string) (string, bool) {
@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
+	"time"
 
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
 )
@@ -18,16 +18,17 @@ type HTTPProcessClient struct {
 
 // NewHTTPProcessClient creates a new HTTP MCP client backed by a local process.
 func NewHTTPProcessClient(command string, args []string, env map[string]string, url string, config *mcp.ClientConfig) (*HTTPProcessClient, error) {
-	cmd := exec.CommandContext(context.Background(), command, args...)
+	return NewHTTPProcess(command, args, url,
+		WithEnv(env), WithTimeout(time.Duration(config.Timeout)*time.Second), WithHeaders(config.Headers))
+}
 
-	if len(env) > 0 {
-		cmdEnv := os.Environ()
-		for k, v := range env {
-			cmdEnv = append(cmdEnv, fmt.Sprintf("%s=%s", k, v))
-		}
-		cmd.Env = cmdEnv
-	}
+// NewHTTPProcess starts command, a local HTTP MCP server, and returns a
+// client for it at url
+func NewHTTPProcess(command string, args []string, url string, opts ...Option) (*HTTPProcessClient, error) {
+	o := newOptions(opts)
 
+	cmd := exec.CommandContext(context.Background(), command, args...)
+	cmd.Env = o.commandEnv()
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
 
@@ -36,7 +37,7 @@ func NewHTTPProcessClient(command string, args []string, env map[string]string,
 	}
 
 	return &HTTPProcessClient{
-		HTTPClient: NewHTTPClient(url, config),
+		HTTPClient: NewHTTP(url, opts...),
 		cmd:        cmd,
 	}, nil
 }
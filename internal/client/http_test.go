@@ -0,0 +1,262 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// resultServer answers every request with result, spliced into the response
+// exactly as given
+func resultServer(t testing.TB, result string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":2,"result":`+result+`}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCallToolKeepsServerBytes(t *testing.T) {
+	// Key order, spacing and escapes that re-encoding would change
+	result := `{"isError": false, "content": [{"text": "café <b>", "type": "text"}], "_meta": {"z": 1, "a": 2}}`
+	c := NewHTTPClient(resultServer(t, result).URL, &mcp.ClientConfig{})
+
+	got, err := c.CallTool(context.Background(), "echo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Raw) != result {
+		t.Errorf("Raw = %s, want the server's bytes %s", got.Raw, result)
+	}
+	if blocks := got.Blocks(); len(blocks) != 1 || blocks[0].Text != "café <b>" {
+		t.Errorf("decoded content = %+v", blocks)
+	}
+}
+
+func TestInitializeKeepsServerBytes(t *testing.T) {
+	result := `{"protocolVersion": "2025-03-26", "capabilities": {"logging": {}, "tools": {"listChanged": true}}, "serverInfo": {"name": "docs", "version": "2.1"}, "instructions": "Search first"}`
+	c := NewHTTPClient(resultServer(t, result).URL, &mcp.ClientConfig{})
+
+	got, err := c.Initialize(context.Background(), &mcp.InitializeParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Raw) != result {
+		t.Errorf("Raw = %s, want the server's bytes %s", got.Raw, result)
+	}
+	if got.Capabilities.Logging == nil || got.Capabilities.Tools == nil || !got.Capabilities.Tools.ListChanged {
+		t.Errorf("decoded capabilities = %+v", got.Capabilities)
+	}
+}
+
+func TestNullResultIsNoResult(t *testing.T) {
+	c := NewHTTPClient(resultServer(t, "null").URL, &mcp.ClientConfig{})
+	if _, err := c.CallTool(context.Background(), "echo", nil); err == nil || !strings.Contains(err.Error(), "no result") {
+		t.Errorf("want a no result error, got %v", err)
+	}
+}
+
+// largeToolResult is a tool result with many text blocks, like a long page
+// or file listing
+func largeToolResult() []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"content":[`)
+	for i := 0; i < 2000; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `{"type":"text","text":"line %d: %s"}`, i, strings.Repeat("lorem ipsum ", 8))
+	}
+	b.WriteString(`],"structuredContent":{"total":2000}}`)
+	return b.Bytes()
+}
+
+// BenchmarkDecodeToolResult compares decoding a result straight from the
+// response bytes with the old path, which decoded it generically and then
+// re-encoded it before decoding it into mcp.ToolResult
+func BenchmarkDecodeToolResult(b *testing.B) {
+	response := []byte(`{"jsonrpc":"2.0","id":2,"result":` + string(largeToolResult()) + `}`)
+
+	b.Run("raw", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(response)))
+		for i := 0; i < b.N; i++ {
+			resp, err := mcp.UnmarshalResponse(response)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var result mcp.ToolResult
+			if err := json.Unmarshal(resp.Result, &result); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("remarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(response)))
+		for i := 0; i < b.N; i++ {
+			var resp struct {
+				Result interface{} `json:"result"`
+			}
+			if err := json.Unmarshal(response, &resp); err != nil {
+				b.Fatal(err)
+			}
+			data, err := json.Marshal(resp.Result)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var result mcp.ToolResult
+			if err := json.Unmarshal(data, &result); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkHTTPCallTool(b *testing.B) {
+	c := NewHTTPClient(resultServer(b, string(largeToolResult())).URL, &mcp.ClientConfig{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.CallTool(context.Background(), "read", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCancelledCallNotifiesServer(t *testing.T) {
+	cancelled := make(chan mcp.CancelledParams, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		if msg.Method == mcp.Cancelled {
+			var params mcp.CancelledParams
+			_ = json.Unmarshal(msg.Params, &params)
+			cancelled <- params
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		<-r.Context().Done() // The call never finishes
+	}))
+	t.Cleanup(server.Close)
+	c := NewHTTPClient(server.URL, &mcp.ClientConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := c.CallTool(ctx, "crawl", nil); err == nil {
+		t.Fatal("expected the call to be cancelled")
+	}
+
+	select {
+	case params := <-cancelled:
+		if params.RequestID != float64(2) || params.Reason == "" {
+			t.Errorf("cancellation = %+v, want the tools/call request's ID and a reason", params)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the server was not told the call was cancelled")
+	}
+}
+
+func TestInitializeSendsInitializeOptions(t *testing.T) {
+	options := map[string]interface{}{"workspace": map[string]interface{}{"roots": []interface{}{"/src"}}}
+	for _, tc := range []struct {
+		field string
+		path  []string // Where the options should arrive in the params
+	}{
+		{"", []string{"capabilities", "experimental", "workspace"}},
+		{config.InitializeOptionsExperimental, []string{"capabilities", "experimental", "workspace"}},
+		{"initializationOptions", []string{"initializationOptions", "workspace"}},
+	} {
+		var params map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Params map[string]interface{} `json:"params"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			params = req.Params
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":0,"result":{"protocolVersion":"2025-03-26"}}`)
+		}))
+
+		c := NewHTTP(server.URL, WithInitializeOptions(options, tc.field))
+		_, err := c.Initialize(context.Background(), &mcp.InitializeParams{ProtocolVersion: "2025-03-26"})
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got interface{} = params
+		for _, key := range tc.path {
+			object, _ := got.(map[string]interface{})
+			got = object[key]
+		}
+		if !reflect.DeepEqual(got, options["workspace"]) {
+			t.Errorf("field %q: params = %v, want the options at %v", tc.field, params, tc.path)
+		}
+		if params["protocolVersion"] != "2025-03-26" {
+			t.Errorf("field %q: the defined params should be kept, got %v", tc.field, params)
+		}
+	}
+}
+
+func TestHTTPSendsPinnedProtocolVersion(t *testing.T) {
+	var versions, headers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+			Params struct {
+				ProtocolVersion string `json:"protocolVersion"`
+			} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Method == "initialize" {
+			versions = append(versions, req.Params.ProtocolVersion)
+		}
+		headers = append(headers, r.Header.Get(mcp.ProtocolVersionHeader))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":0,"result":{"protocolVersion":"2024-11-05","tools":[]}}`)
+	}))
+	defer server.Close()
+
+	c := NewHTTP(server.URL, WithProtocolVersion("2024-11-05"))
+	if _, err := c.Initialize(context.Background(), &mcp.InitializeParams{ProtocolVersion: "2025-06-18"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListTools(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(versions, []string{"2024-11-05"}) {
+		t.Errorf("initialize asked for %v, want the pinned 2024-11-05", versions)
+	}
+	if !reflect.DeepEqual(headers, []string{"2024-11-05", "2024-11-05"}) {
+		t.Errorf("%s headers = %v, want the pinned version on every request", mcp.ProtocolVersionHeader, headers)
+	}
+
+	// Without a pin, the caller's version goes out and no header is added
+	versions, headers = nil, nil
+	if _, err := NewHTTP(server.URL).Initialize(context.Background(), &mcp.InitializeParams{ProtocolVersion: "2025-06-18"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(versions, []string{"2025-06-18"}) || headers[0] != "" {
+		t.Errorf("unpinned: versions %v, headers %q", versions, headers)
+	}
+}
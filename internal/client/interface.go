@@ -1,6 +1,7 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
@@ -11,23 +12,62 @@ import (
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
 )
 
-// NewMCPClient creates an appropriate MCP client based on server configuration
+// NewMCPClient creates an appropriate MCP client based on server
+// configuration, recording or replaying fixtures as SetFixtures says and
+// answering sampling requests as SetSamplingHandler says
 func NewMCPClient(serverConfig config.ServerConfig) (mcp.MCPClient, error) {
-	if serverConfig.Type == "http" || serverConfig.URL != "" {
-		// HTTP client
-		clientConfig := &mcp.ClientConfig{
-			Timeout: serverConfig.Timeout,
-			Headers: serverConfig.Headers,
+	return NewMCPClientWithOptions(serverConfig, append(fixtureOptions(), samplingOptions()...)...)
+}
+
+// NewMCPClientWithOptions creates the client serverConfig calls for. The
+// configuration's timeout, headers and environment are applied first, so
+// opts override them. With WithRetryPolicy the client retries requests;
+// WithRecording and WithReplay record or replay fixtures.
+func NewMCPClientWithOptions(serverConfig config.ServerConfig, opts ...Option) (mcp.MCPClient, error) {
+	opts = append(serverOptions(serverConfig), opts...)
+	o := newOptions(opts)
+	if version := o.initialize.protocolVersion; version != "" && version < mcp.OldestTestedProtocolVersion {
+		o.log().Warn("the configured protocol version is older than any this client is tested with",
+			"server", serverConfig.GetServerDetails(), "protocolVersion", version, "oldestTested", mcp.OldestTestedProtocolVersion)
+	}
+	if o.fixtureMode == FixturesReplay {
+		return NewReplayClient(o.fixtureDir, fixtureServer(serverConfig)), nil
+	}
+
+	c, err := newTransportClient(serverConfig, opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.fixtureMode == FixturesRecord {
+		c = NewRecordingClient(c, o.fixtureDir, fixtureServer(serverConfig))
+	}
+
+	if o.retry.Attempts() >= 2 {
+		return &RetryClient{client: c, serverName: serverConfig.GetServerDetails(), policy: o.retry, logger: o.logger, state: &retryState{client: c}}, nil
+	}
+	return c, nil
+}
+
+// newTransportClient creates the client for the server's transport
+func newTransportClient(serverConfig config.ServerConfig, opts []Option) (mcp.MCPClient, error) {
+	if serverConfig.IsDocker() {
+		if missing := unresolvedEnvVars(serverConfig.Env); len(missing) > 0 {
+			return nil, &ClientError{fmt.Sprintf("missing required environment variables: %s", strings.Join(missing, ", "))}
 		}
+		return NewDockerClient(serverConfig, opts...)
+	} else if serverConfig.IsSSH() {
+		return NewSSHClient(serverConfig, opts...)
+	} else if serverConfig.Type == "http" || serverConfig.URL != "" {
+		// HTTP client
 		if serverConfig.Command != "" {
 			if missing := unresolvedEnvVars(serverConfig.Env); len(missing) > 0 {
 				return nil, &ClientError{fmt.Sprintf("missing required environment variables: %s", strings.Join(missing, ", "))}
 			}
 			// Inject mcp-remote header if needed for HTTP process clients
 			args := injectMcpRemoteHeader(serverConfig.Command, serverConfig.Args)
-			return NewHTTPProcessClient(serverConfig.Command, args, serverConfig.Env, serverConfig.URL, clientConfig)
+			return NewHTTPProcess(serverConfig.Command, args, serverConfig.URL, opts...)
 		}
-		return NewHTTPClient(serverConfig.URL, clientConfig), nil
+		return NewHTTP(serverConfig.URL, opts...), nil
 	} else if serverConfig.Command != "" {
 		if missing := unresolvedEnvVars(serverConfig.Env); len(missing) > 0 {
 			return nil, &ClientError{fmt.Sprintf("missing required environment variables: %s", strings.Join(missing, ", "))}
@@ -35,7 +75,7 @@ func NewMCPClient(serverConfig config.ServerConfig) (mcp.MCPClient, error) {
 
 		// Stdio client - inject mcp-remote header if needed
 		args := injectMcpRemoteHeader(serverConfig.Command, serverConfig.Args)
-		return NewStdioClient(serverConfig.Command, args, serverConfig.Env)
+		return NewStdio(serverConfig.Command, args, opts...)
 	}
 
 	return nil, &ClientError{"invalid server configuration: neither URL nor command specified"}
@@ -137,3 +177,12 @@ func unresolvedEnvVars(env map[string]string) []string {
 	sort.Strings(missing)
 	return missing
 }
+
+// rpcResult returns a response's encoded result, or nil when the server sent
+// none or a null one
+func rpcResult(resp *mcp.JSONRPCResponse) json.RawMessage {
+	if string(resp.Result) == "null" {
+		return nil
+	}
+	return resp.Result
+}
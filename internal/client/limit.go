@@ -0,0 +1,85 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// DefaultMaxResponseMB bounds each message read from a server when the
+// configuration sets no limit
+const DefaultMaxResponseMB = 64
+
+// maxResponseMB is the limit SetMaxResponseSize set, or zero for the default
+var maxResponseMB atomic.Int64
+
+// ErrResponseTooLarge matches every ResponseTooLargeError, for errors.Is
+var ErrResponseTooLarge = errors.New("response too large")
+
+// ResponseTooLargeError is returned when a server sends a message bigger
+// than the client's limit. The message is discarded, so the client can go on
+// with its next request.
+type ResponseTooLargeError struct {
+	Limit int64 // In bytes
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response exceeds the %s limit (raise it with \"maxResponseMB\" in the server's configuration or at the top level)", formatLimit(e.Limit))
+}
+
+// Is makes errors.Is(err, ErrResponseTooLarge) hold
+func (e *ResponseTooLargeError) Is(target error) bool {
+	return target == ErrResponseTooLarge
+}
+
+// formatLimit describes a limit in MB when it is a whole number of them
+func formatLimit(limit int64) string {
+	if limit%(1<<20) == 0 {
+		return fmt.Sprintf("%d MB", limit>>20)
+	}
+	return fmt.Sprintf("%d bytes", limit)
+}
+
+// SetMaxResponseSize sets the limit, in MB, on messages read from servers by
+// clients created afterwards, unless their server sets its own. Zero or less
+// restores DefaultMaxResponseMB.
+func SetMaxResponseSize(mb int) {
+	maxResponseMB.Store(int64(mb))
+}
+
+// defaultMaxResponse returns the limit in bytes SetMaxResponseSize asks for
+func defaultMaxResponse() int64 {
+	mb := maxResponseMB.Load()
+	if mb <= 0 {
+		mb = DefaultMaxResponseMB
+	}
+	return mb << 20
+}
+
+// WithMaxResponseSize bounds each message read from the server, in bytes. A
+// bigger one fails its request with a ResponseTooLargeError. Zero or less
+// keeps the limit SetMaxResponseSize set.
+func WithMaxResponseSize(limit int64) Option {
+	return func(o *options) {
+		if limit > 0 {
+			o.maxResponse = limit
+		}
+	}
+}
+
+// readLimited reads r to the end, failing once it passes limit bytes. A
+// limit of zero or less reads without one.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, &ResponseTooLargeError{Limit: limit}
+	}
+	return data, nil
+}
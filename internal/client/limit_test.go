@@ -0,0 +1,112 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestFramingsSkipOversizedMessages(t *testing.T) {
+	oversized := largeMessage(t, 10000)
+	for _, name := range []string{config.FramingNDJSON, config.FramingContentLength} {
+		t.Run(name, func(t *testing.T) {
+			f := newFraming(name)
+			var buf strings.Builder
+			w := bufio.NewWriter(&buf)
+			for _, msg := range [][]byte{oversized, []byte(`{"id":1}`)} {
+				if err := f.writeMessage(w, msg); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_ = w.Flush()
+
+			r := bufio.NewReader(strings.NewReader(buf.String()))
+			_, err := f.readMessage(r, 1024)
+			var tooLarge *ResponseTooLargeError
+			if !errors.As(err, &tooLarge) || tooLarge.Limit != 1024 {
+				t.Fatalf("want a ResponseTooLargeError with the limit, got %v", err)
+			}
+			got, err := f.readMessage(r, 1024)
+			if err != nil || strings.TrimSpace(string(got)) != `{"id":1}` {
+				t.Fatalf("the next message should read cleanly, got %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestStdioRecoversFromOversizedResponse(t *testing.T) {
+	// The server answers the first call with a 100 KB text, the second briefly
+	script := `read line; printf '{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"%s"}]}}\n' "$(head -c 100000 /dev/zero | tr '\0' x)"
+read line; echo '{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"ok"}]}}'
+cat > /dev/null`
+	c, err := NewStdio("sh", []string{"-c", script}, WithMaxResponseSize(4096))
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = c.CallTool(ctx, "dump", nil)
+	if !errors.Is(err, ErrResponseTooLarge) || !strings.Contains(err.Error(), "4096 bytes") || !strings.Contains(err.Error(), "maxResponseMB") {
+		t.Fatalf("want the call to fail naming the limit and how to raise it, got %v", err)
+	}
+
+	result, err := c.CallTool(ctx, "echo", nil)
+	if err != nil {
+		t.Fatalf("the next call should succeed: %v", err)
+	}
+	if blocks := result.Blocks(); len(blocks) != 1 || blocks[0].Text != "ok" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestHTTPRecoversFromOversizedResponse(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		calls++
+		text := "ok"
+		if calls == 1 {
+			text = strings.Repeat("x", 100000)
+		}
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"`+text+`"}]}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	c := NewHTTP(server.URL, WithMaxResponseSize(4096))
+	if _, err := c.CallTool(context.Background(), "dump", nil); !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("want ErrResponseTooLarge, got %v", err)
+	}
+	result, err := c.CallTool(context.Background(), "echo", nil)
+	if err != nil {
+		t.Fatalf("the next call should succeed: %v", err)
+	}
+	if blocks := result.Blocks(); len(blocks) != 1 || blocks[0].Text != "ok" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestMaxResponseSizeDefaults(t *testing.T) {
+	t.Cleanup(func() { SetMaxResponseSize(0) })
+
+	if got := newOptions(nil).maxResponse; got != DefaultMaxResponseMB<<20 {
+		t.Errorf("default limit %d, want %d", got, DefaultMaxResponseMB<<20)
+	}
+	SetMaxResponseSize(8)
+	if got := newOptions(nil).maxResponse; got != 8<<20 {
+		t.Errorf("configured limit %d, want %d", got, 8<<20)
+	}
+	// A server's own limit wins
+	if got := newOptions(serverOptions(config.ServerConfig{MaxResponseMB: 2})).maxResponse; got != 2<<20 {
+		t.Errorf("server limit %d, want %d", got, 2<<20)
+	}
+}
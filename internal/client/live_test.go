@@ -0,0 +1,47 @@
+//go:build live
+
+package client
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/testharness"
+)
+
+// TestLiveConformance runs the conformance cases that hold for any server
+// against the reference server. Run it with -tags live on a machine with npx
+// and network access; the first run downloads the server.
+func TestLiveConformance(t *testing.T) {
+	if _, err := exec.LookPath("npx"); err != nil {
+		t.Skip("npx is not installed")
+	}
+	testharness.Run(t, testharness.Transport{
+		Name: "server-everything",
+		Dial: func(t *testing.T, _ testharness.Script) mcp.MCPClient {
+			c, err := NewStdio("npx", []string{"-y", "@modelcontextprotocol/server-everything"}, WithTimeout(2*time.Minute))
+			if err != nil {
+				t.Fatalf("failed to start the reference server: %v", err)
+			}
+			t.Cleanup(func() { _ = c.Close() })
+
+			// The reference server wants the handshake before anything else
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+			if _, err := c.Initialize(ctx, &mcp.InitializeParams{
+				ProtocolVersion: mcp.ProtocolVersion,
+				ClientInfo:      mcp.ClientInfo{Name: "testharness", Version: "1.0.0"},
+			}); err != nil {
+				t.Fatalf("failed to initialize the reference server: %v", err)
+			}
+			return c
+		},
+		Handshake: true,
+		Stream:    true,
+		Cancel:    true,
+		Live:      true,
+	})
+}
@@ -0,0 +1,298 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/redact"
+)
+
+// DefaultTimeout bounds each request when no timeout is configured
+const DefaultTimeout = 30 * time.Second
+
+// cancelNotifyTimeout bounds telling an HTTP server a request was cancelled
+const cancelNotifyTimeout = 2 * time.Second
+
+// cancelReason is the reason given to servers for a cancelled request
+const cancelReason = "request cancelled by the client"
+
+// Option configures a client created by NewStdio, NewHTTP or
+// NewMCPClientWithOptions. Options apply in order, so when two set the same
+// thing the later one wins.
+type Option func(*options)
+
+// options holds everything the client constructors can be configured with
+type options struct {
+	timeout   time.Duration       // Bounds each request
+	headers   map[string]string   // Sent with every HTTP request
+	tlsConfig *tls.Config         // Used for HTTPS servers
+	retry     *config.RetryConfig // Applied by NewMCPClientWithOptions
+	logger    *slog.Logger        // Nil logs to the shared logger
+	env       map[string]string   // Variables for a server process
+	envPolicy EnvPolicy
+	secretEnv []string // Env variables whose values are scrubbed from errors, besides those named like secrets
+	framing   string   // How messages to and from stdio servers are delimited
+
+	maxResponse int64 // Bytes a message read from the server may take
+
+	fixtureMode FixtureMode // Applied by NewMCPClientWithOptions
+	fixtureDir  string
+
+	sampling mcp.SamplingHandler // Answers stdio servers' sampling requests
+
+	initialize initializeOptions // Added to the initialize request
+}
+
+// EnvPolicy decides which environment a server process starts with
+type EnvPolicy int
+
+const (
+	// EnvInherit passes this process's environment on, with the server's own
+	// variables added. It is the default.
+	EnvInherit EnvPolicy = iota
+
+	// EnvIsolate passes only the server's own variables, plus the few every
+	// program needs to run (see baseEnvVars)
+	EnvIsolate
+)
+
+// baseEnvVars are kept by EnvIsolate so the server's command can still be
+// found and run
+var baseEnvVars = []string{"PATH", "HOME", "USER", "TMPDIR", "LANG", "SYSTEMROOT", "USERPROFILE", "APPDATA", "TEMP"}
+
+// WithTimeout bounds each request. Zero or less keeps DefaultTimeout. A
+// request whose context already has a deadline is bounded by that instead.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.timeout = timeout
+		}
+	}
+}
+
+// untimedKey marks a context whose requests run until it is cancelled
+type untimedKey struct{}
+
+// WithoutTimeout makes requests made with ctx run until ctx is cancelled
+// rather than for at most the client's timeout, for calls such as background
+// jobs that are stopped explicitly
+func WithoutTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, untimedKey{}, true)
+}
+
+// requestContext bounds ctx by timeout, unless the caller set a deadline of
+// its own or none at all with WithoutTimeout: a call given longer isn't cut
+// off at the server's per-request timeout
+func requestContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || ctx.Value(untimedKey{}) != nil {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// WithHeaders adds headers sent with every HTTP request. Headers from
+// several calls are merged; a later value for the same header wins.
+func WithHeaders(headers map[string]string) Option {
+	return func(o *options) {
+		if len(headers) == 0 {
+			return
+		}
+		if o.headers == nil {
+			o.headers = make(map[string]string, len(headers))
+		}
+		for key, value := range headers {
+			o.headers[key] = value
+		}
+	}
+}
+
+// WithTLSConfig sets the TLS configuration for HTTPS servers
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+// WithRetryPolicy retries requests that fail for transient reasons. Only
+// NewMCPClientWithOptions applies it, by wrapping the client in a RetryClient.
+func WithRetryPolicy(policy *config.RetryConfig) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+
+// WithLogger sets where the client logs. By default it uses the shared
+// logger of the logging package.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithEnv adds variables to a server process's environment. Variables from
+// several calls are merged; a later value for the same variable wins.
+func WithEnv(env map[string]string) Option {
+	return func(o *options) {
+		if len(env) == 0 {
+			return
+		}
+		if o.env == nil {
+			o.env = make(map[string]string, len(env))
+		}
+		for key, value := range env {
+			o.env[key] = value
+		}
+	}
+}
+
+// WithEnvPolicy decides whether a server process inherits this process's
+// environment
+func WithEnvPolicy(policy EnvPolicy) Option {
+	return func(o *options) {
+		o.envPolicy = policy
+	}
+}
+
+// WithSecretEnv marks env variables whose values are scrubbed from error
+// messages, as the values of headers and variables named like secrets
+// always are
+func WithSecretEnv(names []string) Option {
+	return func(o *options) {
+		o.secretEnv = append(o.secretEnv, names...)
+	}
+}
+
+// WithFraming sets how messages to and from a stdio server are delimited:
+// config.FramingNDJSON (the default), config.FramingContentLength or
+// config.FramingAuto
+func WithFraming(framing string) Option {
+	return func(o *options) {
+		o.framing = framing
+	}
+}
+
+// WithInitializeOptions sends values in the initialize request: merged into
+// the experimental capabilities when field is "" or
+// config.InitializeOptionsExperimental, or else as the top-level parameter
+// named field
+func WithInitializeOptions(values map[string]interface{}, field string) Option {
+	return func(o *options) {
+		o.initialize.values, o.initialize.field = values, field
+	}
+}
+
+// WithProtocolVersion claims version in the initialize request, and in the
+// protocol version header of HTTP requests, instead of the caller's choice.
+// An empty version keeps the caller's.
+func WithProtocolVersion(version string) Option {
+	return func(o *options) {
+		o.initialize.protocolVersion = version
+	}
+}
+
+// initializeOptions are the settings a client adds to its initialize request
+type initializeOptions struct {
+	values          map[string]interface{}
+	field           string
+	protocolVersion string // Replaces the requested protocol version
+}
+
+// apply returns params with the options added
+func (i initializeOptions) apply(params *mcp.InitializeParams) *mcp.InitializeParams {
+	if params == nil {
+		return params
+	}
+	if i.protocolVersion != "" && params.ProtocolVersion != i.protocolVersion {
+		pinned := *params
+		pinned.ProtocolVersion = i.protocolVersion
+		params = &pinned
+	}
+	if len(i.values) == 0 {
+		return params
+	}
+	if i.field == "" || i.field == config.InitializeOptionsExperimental {
+		return params.WithExperimental(i.values)
+	}
+	return params.WithField(i.field, i.values)
+}
+
+// InitializeParams returns the initialize request a client created for
+// serverConfig sends when asked to send params
+func InitializeParams(serverConfig config.ServerConfig, params *mcp.InitializeParams) *mcp.InitializeParams {
+	return newOptions(serverOptions(serverConfig)).initialize.apply(params)
+}
+
+// serverOptions translates a server's configuration into options. Every
+// constructor built from a ServerConfig goes through it. The retry policy is
+// left out: callers wrap clients with WithRetry, and a client must not be
+// wrapped twice.
+func serverOptions(serverConfig config.ServerConfig) []Option {
+	return []Option{
+		WithTimeout(time.Duration(serverConfig.Timeout) * time.Second),
+		WithHeaders(serverConfig.Headers),
+		WithEnv(serverConfig.Env),
+		WithSecretEnv(serverConfig.SecretEnv),
+		WithFraming(serverConfig.Framing),
+		WithMaxResponseSize(int64(serverConfig.MaxResponseMB) << 20),
+		WithInitializeOptions(serverConfig.InitializeOptions, serverConfig.InitializeOptionsTarget()),
+		WithProtocolVersion(serverConfig.ProtocolVersion),
+	}
+}
+
+// newOptions applies opts over the defaults
+func newOptions(opts []Option) *options {
+	o := &options{timeout: DefaultTimeout, maxResponse: defaultMaxResponse()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// log returns the configured logger, or the shared one. The shared logger is
+// looked up on each call since the CLI configures it after startup.
+func (o *options) log() *slog.Logger {
+	if o.logger != nil {
+		return o.logger
+	}
+	return logging.Logger()
+}
+
+// redactor returns what scrubs the secrets in the headers and env from
+// error messages
+func (o *options) redactor() *redact.Redactor {
+	return redact.New(append(redact.Values(o.headers), redact.Values(o.env, o.secretEnv...)...)...)
+}
+
+// commandEnv returns the environment for a server process, or nil to let it
+// inherit this process's environment unchanged
+func (o *options) commandEnv() []string {
+	var env []string
+	switch o.envPolicy {
+	case EnvIsolate:
+		for _, name := range baseEnvVars {
+			if value, ok := os.LookupEnv(name); ok {
+				env = append(env, name+"="+value)
+			}
+		}
+	default:
+		if len(o.env) == 0 {
+			return nil
+		}
+		env = os.Environ()
+	}
+
+	for key, value := range o.env {
+		env = append(env, fmt.Sprintf("%s=%s", key, value))
+	}
+	if env == nil {
+		env = []string{} // An empty, not inherited, environment
+	}
+	return env
+}
@@ -0,0 +1,253 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestWithTimeout(t *testing.T) {
+	if got := newOptions(nil).timeout; got != DefaultTimeout {
+		t.Errorf("default timeout = %v, want %v", got, DefaultTimeout)
+	}
+	if got := newOptions([]Option{WithTimeout(5 * time.Second)}).timeout; got != 5*time.Second {
+		t.Errorf("timeout = %v, want 5s", got)
+	}
+	if got := newOptions([]Option{WithTimeout(5 * time.Second), WithTimeout(0)}).timeout; got != 5*time.Second {
+		t.Errorf("a zero timeout should be ignored, got %v", got)
+	}
+
+	// Requests are bounded by their contexts rather than the http.Client
+	c := NewHTTP("http://localhost", WithTimeout(5*time.Second))
+	if c.timeout != 5*time.Second || c.client.Timeout != 0 {
+		t.Errorf("HTTP client timeouts = %v, %v; want 5s, 0s", c.timeout, c.client.Timeout)
+	}
+}
+
+func TestStdioHonorsTimeout(t *testing.T) {
+	// The server reads requests but never answers
+	c, err := NewStdio("sh", []string{"-c", "cat >/dev/null"}, WithTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	start := time.Now()
+	if _, err := c.ListTools(context.Background()); err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request took %v, want about 100ms", elapsed)
+	}
+
+	// A caller's deadline replaces the timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := c.ListTools(ctx); err == nil {
+		t.Fatal("expected the deadline to pass")
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("request given 500ms ended after %v, at the client's timeout", elapsed)
+	}
+}
+
+func TestHTTPCallerDeadlineOutlastsTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": {"tools": []}}`))
+	}))
+	t.Cleanup(server.Close)
+	c := NewHTTP(server.URL, WithTimeout(100*time.Millisecond))
+
+	if _, err := c.ListTools(context.Background()); err == nil {
+		t.Error("a slow answer should exceed the timeout")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Errorf("a call given 5s failed: %v", err)
+	}
+}
+
+func TestWithHeaders(t *testing.T) {
+	o := newOptions([]Option{
+		WithHeaders(map[string]string{"Authorization": "Bearer a", "X-Team": "core"}),
+		WithHeaders(nil),
+		WithHeaders(map[string]string{"Authorization": "Bearer b"}),
+	})
+	if o.headers["Authorization"] != "Bearer b" || o.headers["X-Team"] != "core" || len(o.headers) != 2 {
+		t.Errorf("headers = %v", o.headers)
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	if c := NewHTTP("https://localhost"); c.client.Transport != sharedTransport(nil) {
+		t.Error("without a TLS config the shared default transport should be used")
+	}
+
+	tlsConfig := &tls.Config{ServerName: "mcp.example.com"}
+	c := NewHTTP("https://localhost", WithTLSConfig(tlsConfig))
+	transport, ok := c.client.Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig != tlsConfig {
+		t.Fatalf("transport = %#v, want one using the TLS config", c.client.Transport)
+	}
+	if transport == http.DefaultTransport {
+		t.Error("the default transport must not be modified")
+	}
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	serverConfig := config.ServerConfig{URL: "http://localhost:8931/mcp"}
+
+	c, err := NewMCPClientWithOptions(serverConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.(*RetryClient); ok {
+		t.Error("without a retry policy the client should not be wrapped")
+	}
+
+	c, err = NewMCPClientWithOptions(serverConfig, WithRetryPolicy(&config.RetryConfig{MaxAttempts: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	retry, ok := c.(*RetryClient)
+	if !ok {
+		t.Fatalf("client = %T, want *RetryClient", c)
+	}
+	if retry.policy.MaxAttempts != 3 {
+		t.Errorf("retry policy = %+v", retry.policy)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c, err := NewStdio("sh", []string{"-c", "cat >/dev/null"}, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	_ = c.Close()
+	if !strings.Contains(buf.String(), "started server") {
+		t.Errorf("expected the start to be logged to the given logger, got %q", buf.String())
+	}
+
+	wrapped, err := NewMCPClientWithOptions(config.ServerConfig{URL: "http://localhost:8931/mcp"},
+		WithLogger(logger), WithRetryPolicy(&config.RetryConfig{MaxAttempts: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retry := wrapped.(*RetryClient); retry.log() != logger {
+		t.Error("the retry client should log to the given logger")
+	}
+}
+
+func TestOldProtocolVersionWarns(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	for version, warns := range map[string]bool{"2024-10-07": true, "2024-11-05": false, "2025-06-18": false, "": false} {
+		buf.Reset()
+		c, err := NewMCPClientWithOptions(config.ServerConfig{URL: "http://localhost:8931/mcp", ProtocolVersion: version}, WithLogger(logger))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = c.Close()
+		if got := strings.Contains(buf.String(), "older than any this client is tested with"); got != warns {
+			t.Errorf("protocol version %q: warned = %v, want %v (%q)", version, got, warns, buf.String())
+		}
+	}
+}
+
+func TestWithEnvPolicy(t *testing.T) {
+	t.Setenv("MCP_CLI_TEST_SECRET", "hunter2")
+
+	inherit := newOptions([]Option{WithEnv(map[string]string{"API_KEY": "k"})}).commandEnv()
+	if !hasEnv(inherit, "MCP_CLI_TEST_SECRET=hunter2") || !hasEnv(inherit, "API_KEY=k") {
+		t.Error("an inherited environment should keep this process's variables and add the server's")
+	}
+	if env := newOptions(nil).commandEnv(); env != nil {
+		t.Errorf("without server variables the environment should be inherited unchanged, got %d variables", len(env))
+	}
+
+	isolated := newOptions([]Option{WithEnv(map[string]string{"API_KEY": "k"}), WithEnvPolicy(EnvIsolate)}).commandEnv()
+	if hasEnv(isolated, "MCP_CLI_TEST_SECRET=hunter2") {
+		t.Error("an isolated environment should not pass this process's variables on")
+	}
+	if !hasEnv(isolated, "API_KEY=k") {
+		t.Error("an isolated environment should keep the server's variables")
+	}
+	if path, ok := lookupEnv(isolated, "PATH"); !ok || path == "" {
+		t.Error("an isolated environment should keep PATH")
+	}
+}
+
+func TestOptionPrecedence(t *testing.T) {
+	serverConfig := config.ServerConfig{
+		URL:     "http://localhost:8931/mcp",
+		Timeout: 10,
+		Headers: map[string]string{"Authorization": "Bearer config", "X-Team": "core"},
+	}
+
+	// Options given by the caller are applied after the server's own
+	c, err := NewMCPClientWithOptions(serverConfig,
+		WithTimeout(5*time.Second),
+		WithHeaders(map[string]string{"Authorization": "Bearer caller"}),
+		WithTimeout(7*time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient, ok := c.(*HTTPClient)
+	if !ok {
+		t.Fatalf("client = %T, want *HTTPClient", c)
+	}
+	if httpClient.timeout != 7*time.Second {
+		t.Errorf("timeout = %v, want the last one given (7s)", httpClient.timeout)
+	}
+	if httpClient.headers["Authorization"] != "Bearer caller" || httpClient.headers["X-Team"] != "core" {
+		t.Errorf("headers = %v, want the caller's Authorization and the configured X-Team", httpClient.headers)
+	}
+	if serverConfig.Headers["Authorization"] != "Bearer config" {
+		t.Error("the server's configuration must not be modified")
+	}
+
+	// Without caller options the configuration applies
+	c, err = NewMCPClientWithOptions(serverConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeout := c.(*HTTPClient).timeout; timeout != 10*time.Second {
+		t.Errorf("timeout = %v, want the configured 10s", timeout)
+	}
+}
+
+// hasEnv reports whether env contains the exact name=value entry
+func hasEnv(env []string, entry string) bool {
+	for _, e := range env {
+		if e == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupEnv finds a variable's value in env
+func lookupEnv(env []string, name string) (string, bool) {
+	for _, e := range env {
+		if value, ok := strings.CutPrefix(e, name+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Tuning of the transports HTTP clients share. Sessions, batch runs and the
+// aggregator call the same few servers over and over, so idle connections
+// are kept per host rather than the default two.
+const (
+	maxIdleConnsPerHost = 16
+	idleConnTimeout     = 90 * time.Second
+)
+
+var (
+	transportMutex sync.Mutex
+	transports     = make(map[*tls.Config]*http.Transport) // Keyed by TLS configuration; nil is the default
+)
+
+// sharedTransport returns the transport of every HTTP client using
+// tlsConfig, so they reuse each other's connections and TLS sessions. Only
+// the transport is shared: each client keeps its own server session.
+func sharedTransport(tlsConfig *tls.Config) *http.Transport {
+	transportMutex.Lock()
+	defer transportMutex.Unlock()
+	if transport, ok := transports[tlsConfig]; ok {
+		return transport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	transport.IdleConnTimeout = idleConnTimeout
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	transports[tlsConfig] = transport
+	return transport
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// poolServer is an MCP server that assigns a session, counting the
+// connections opened to it
+type poolServer struct {
+	*httptest.Server
+	connections atomic.Int32
+}
+
+func newPoolServer(tb testing.TB, tls bool) *poolServer {
+	tb.Helper()
+	s := &poolServer{}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			return
+		}
+		var req mcp.JSONRPCRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set(mcp.SessionIDHeader, "s1")
+		_ = json.NewEncoder(w).Encode(mcp.NewResponse(req.ID, json.RawMessage(`{"tools":[],"content":[]}`)))
+	}))
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			s.connections.Add(1)
+		}
+	}
+	if tls {
+		s.StartTLS()
+	} else {
+		s.Start()
+	}
+	tb.Cleanup(s.Close)
+	return s
+}
+
+func TestHTTPClientsKeepTheirOwnSessions(t *testing.T) {
+	// The server assigns a session on each initialize, and records the
+	// sessions other requests and deletes arrive with
+	var mutex sync.Mutex
+	var initializes int
+	used := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		sessionID := r.Header.Get(mcp.SessionIDHeader)
+		if r.Method == http.MethodDelete {
+			used[sessionID] += " delete"
+			return
+		}
+		var req mcp.JSONRPCRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Method == "initialize" {
+			initializes++
+			w.Header().Set(mcp.SessionIDHeader, fmt.Sprintf("s%d", initializes))
+		} else {
+			used[sessionID] += " " + req.Method
+		}
+		_ = json.NewEncoder(w).Encode(mcp.NewResponse(req.ID, json.RawMessage(`{"tools":[]}`)))
+	}))
+	defer server.Close()
+	serverConfig := config.ServerConfig{URL: server.URL}
+
+	first, _ := NewMCPClientWithOptions(serverConfig)
+	second, _ := NewMCPClientWithOptions(serverConfig)
+	if first == second {
+		t.Fatal("each caller should get a client of its own")
+	}
+	if first.(*HTTPClient).client.Transport != second.(*HTTPClient).client.Transport {
+		t.Error("clients for the same server should share a transport")
+	}
+
+	for _, c := range []mcp.MCPClient{first, second} {
+		if _, err := c.Initialize(context.Background(), &mcp.InitializeParams{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.ListTools(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = first.Close()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	want := map[string]string{"s1": " tools/list delete", "s2": " tools/list"}
+	if !reflect.DeepEqual(used, want) {
+		t.Errorf("requests by session = %q, want %q", used, want)
+	}
+}
+
+// sequentialTLSCalls makes calls, each with a client of its own, to a TLS
+// server and returns the connections the server saw opened
+func sequentialTLSCalls(tb testing.TB, calls int) int32 {
+	server := newPoolServer(tb, true)
+	tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+	serverConfig := config.ServerConfig{URL: server.URL}
+
+	for i := 0; i < calls; i++ {
+		c, err := NewMCPClientWithOptions(serverConfig, WithTLSConfig(tlsConfig))
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if _, err := c.CallTool(context.Background(), "echo", nil); err != nil {
+			tb.Fatal(err)
+		}
+		_ = c.Close()
+	}
+	return server.connections.Load()
+}
+
+func TestSequentialTLSCallsReuseConnections(t *testing.T) {
+	if handshakes := sequentialTLSCalls(t, 100); handshakes > 2 {
+		t.Errorf("100 sequential calls made %d TLS handshakes, want them to share a connection", handshakes)
+	}
+}
+
+func BenchmarkSequentialTLSCalls(b *testing.B) {
+	var handshakes int32
+	for i := 0; i < b.N; i++ {
+		handshakes += sequentialTLSCalls(b, 100)
+	}
+	b.ReportMetric(float64(handshakes)/float64(b.N), "handshakes/100calls")
+}
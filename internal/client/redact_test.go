@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// secretToken is the secret the servers in these tests leak
+const secretToken = "sk-live-4f9a8b7c6d5e"
+
+// checkNoSecret fails the test if secretToken appears in any of outputs
+func checkNoSecret(t *testing.T, outputs map[string]string) {
+	t.Helper()
+	for name, output := range outputs {
+		if strings.Contains(output, secretToken) {
+			t.Errorf("the secret leaked into the %s:\n%s", name, output)
+		}
+	}
+}
+
+func TestHTTPErrorsOmitSecrets(t *testing.T) {
+	cases := map[string]http.HandlerFunc{
+		"HTTP error": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "invalid credentials: "+r.Header.Get("Authorization"), http.StatusUnauthorized)
+		},
+		"JSON-RPC error": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32001,"message":"key `+r.Header.Get("X-Api-Key")+` is revoked"}}`)
+		},
+	}
+	for name, handler := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(handler)
+			t.Cleanup(server.Close)
+
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			c := NewHTTP(server.URL, WithLogger(logger), WithHeaders(map[string]string{
+				"Authorization": "Bearer " + secretToken,
+				"X-Api-Key":     secretToken,
+			}))
+
+			_, err := c.ListTools(context.Background())
+			if err == nil {
+				t.Fatal("expected the request to fail")
+			}
+			checkNoSecret(t, map[string]string{"error": err.Error(), "log": logs.String()})
+			if !strings.Contains(err.Error(), "[redacted ") {
+				t.Errorf("the error should show the secret's fingerprint: %v", err)
+			}
+		})
+	}
+}
+
+func TestStdioErrorsOmitSecrets(t *testing.T) {
+	// The server complains about its credentials on stderr and exits
+	script := `read line; echo "login failed for $SERVICE_DSN with $SEARCH_API_KEY" >&2; exit 1`
+	c, err := NewStdio("sh", []string{"-c", script},
+		WithEnv(map[string]string{"SERVICE_DSN": "postgres://admin:" + secretToken + "@db", "SEARCH_API_KEY": secretToken}),
+		WithSecretEnv([]string{"SERVICE_DSN"}))
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = c.CallTool(ctx, "search", nil)
+	if err == nil {
+		t.Fatal("expected the call to fail")
+	}
+	checkNoSecret(t, map[string]string{"error": err.Error(), "stderr": c.StderrTail()})
+	if !strings.Contains(err.Error(), "login failed") {
+		t.Errorf("the error should still quote the server: %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// RetryClient retries requests that fail for transient reasons, following
+// the server's retry policy. Read-only requests are retried for every
+// configured failure kind; tool calls, which may have side effects, only when
+// the request never left the client, because the connection couldn't be made.
+// A dropped connection or server error may come after the tool ran. Calls of
+// tools the server's tool list annotates as idempotent or read-only are
+// retried like read-only requests.
+type RetryClient struct {
+	client     mcp.MCPClient
+	serverName string
+	policy     *config.RetryConfig
+	logger     *slog.Logger // Nil logs to the shared logger
+	state      *retryState
+}
+
+// retryState is what a RetryClient learns about its server connection. It
+// belongs to the connection rather than the wrapper, so every wrapper of a
+// persistent session's client shares it.
+type retryState struct {
+	client     mcp.MCPClient // The connection the state describes
+	mutex      sync.Mutex
+	answered   bool            // A request has succeeded, so failures are no longer startup failures
+	idempotent map[string]bool // Tools safe to call again, from the last tool list
+}
+
+// sessionRetries holds the retry state of persistent sessions by server name
+var sessionRetries = struct {
+	sync.Mutex
+	states map[string]*retryState
+}{states: make(map[string]*retryState)}
+
+// knownTools is the lookup SetKnownTools set
+var knownTools atomic.Pointer[func(serverName string, serverConfig config.ServerConfig) []mcp.Tool]
+
+// SetKnownTools sets where retry wrappers created afterwards look up a
+// server's tools when the wrapper is built, so calls made without listing
+// tools first are still retried by the tools' annotations
+func SetKnownTools(lookup func(serverName string, serverConfig config.ServerConfig) []mcp.Tool) {
+	knownTools.Store(&lookup)
+}
+
+// KnownTools returns the server's tools from the SetKnownTools lookup, or nil
+// when there is none
+func KnownTools(serverName string, serverConfig config.ServerConfig) []mcp.Tool {
+	if lookup := knownTools.Load(); lookup != nil {
+		return (*lookup)(serverName, serverConfig)
+	}
+	return nil
+}
+
+// WithRetry wraps c in a RetryClient when the server's retry policy allows
+// retries, and returns c unchanged otherwise
+func WithRetry(serverName string, serverConfig config.ServerConfig, c mcp.MCPClient) mcp.MCPClient {
+	return withRetryState(serverName, serverConfig, c, &retryState{client: c})
+}
+
+// withSessionRetry is WithRetry for the client of a persistent session: the
+// wrapper continues from what earlier wrappers of the same client learned,
+// so a failure after the server first answered isn't taken for a startup
+// failure. A restarted session has a new client and starts over.
+func withSessionRetry(serverName string, serverConfig config.ServerConfig, c mcp.MCPClient) mcp.MCPClient {
+	sessionRetries.Lock()
+	state := sessionRetries.states[serverName]
+	if state == nil || state.client != c {
+		state = &retryState{client: c}
+		sessionRetries.states[serverName] = state
+	}
+	sessionRetries.Unlock()
+
+	return withRetryState(serverName, serverConfig, c, state)
+}
+
+// withRetryState wraps c in a RetryClient using state
+func withRetryState(serverName string, serverConfig config.ServerConfig, c mcp.MCPClient, state *retryState) mcp.MCPClient {
+	if serverConfig.Retry.Attempts() < 2 {
+		return c
+	}
+
+	state.mutex.Lock()
+	if state.idempotent == nil {
+		if tools := KnownTools(serverName, serverConfig); len(tools) > 0 {
+			state.idempotent = idempotentTools(tools)
+		}
+	}
+	state.mutex.Unlock()
+
+	return &RetryClient{client: c, serverName: serverName, policy: serverConfig.Retry, state: state}
+}
+
+// ClassifyFailure returns the retryable kind of a failed request: timeout,
+// connection, or "" for errors the server itself reported or anything else
+func ClassifyFailure(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var rpcErr *mcp.JSONRPCError
+	if errors.As(err, &rpcErr) {
+		return ""
+	}
+
+	var netErr net.Error
+	if errors.Is(err, mcp.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return config.RetryOnTimeout
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return config.RetryOnConnection
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.Temporary() {
+		return config.RetryOnConnection
+	}
+
+	return ""
+}
+
+// notSent reports whether err shows the request never reached the server:
+// the connection was refused or couldn't be dialled
+func notSent(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// failureKind classifies err for the retry policy. Any failure before the
+// server has answered a request counts as a startup failure when the policy
+// retries those.
+func (c *RetryClient) failureKind(err error) string {
+	kind := ClassifyFailure(err)
+
+	c.state.mutex.Lock()
+	answered := c.state.answered
+	c.state.mutex.Unlock()
+
+	var rpcErr *mcp.JSONRPCError
+	if !answered && c.policy.Retries(config.RetryOnStartup) && !errors.As(err, &rpcErr) {
+		return config.RetryOnStartup
+	}
+	return kind
+}
+
+// do runs op until it succeeds, fails for a reason that is not retried, or
+// runs out of attempts. sideEffects limits retries to failures where the
+// request was never sent.
+func (c *RetryClient) do(ctx context.Context, operation string, sideEffects bool, op func() error) error {
+	delay := c.policy.InitialDelay()
+	attempts := c.policy.Attempts()
+
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil {
+			c.state.mutex.Lock()
+			c.state.answered = true
+			c.state.mutex.Unlock()
+			return nil
+		}
+
+		// A tool call that timed out or lost its connection may still have
+		// run on the server
+		kind := c.failureKind(err)
+		if attempt >= attempts || kind == "" || !c.policy.Retries(kind) || (sideEffects && !notSent(err)) {
+			return err
+		}
+
+		c.log().Debug("retrying after "+kind+" failure", "server", c.serverName, "operation", operation,
+			"attempt", fmt.Sprintf("%d/%d", attempt+1, attempts), "error", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// log returns the client's logger
+func (c *RetryClient) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return logging.Logger()
+}
+
+// Initialize implements mcp.MCPClient
+func (c *RetryClient) Initialize(ctx context.Context, params *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	var result *mcp.InitializeResult
+	err := c.do(ctx, "initialize", false, func() (err error) {
+		result, err = c.client.Initialize(ctx, params)
+		return err
+	})
+	return result, err
+}
+
+// ListTools implements mcp.MCPClient
+func (c *RetryClient) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	var tools []mcp.Tool
+	err := c.do(ctx, "tools/list", false, func() (err error) {
+		tools, err = c.client.ListTools(ctx)
+		return err
+	})
+	if err == nil {
+		c.noteTools(tools)
+	}
+	return tools, err
+}
+
+// noteTools records which of the server's tools are safe to call again
+func (c *RetryClient) noteTools(tools []mcp.Tool) {
+	idempotent := idempotentTools(tools)
+	c.state.mutex.Lock()
+	c.state.idempotent = idempotent
+	c.state.mutex.Unlock()
+}
+
+// idempotentTools returns the names of the tools safe to call again
+func idempotentTools(tools []mcp.Tool) map[string]bool {
+	idempotent := make(map[string]bool)
+	for i := range tools {
+		if tools[i].IsIdempotent() {
+			idempotent[tools[i].Name] = true
+		}
+	}
+	return idempotent
+}
+
+// sideEffects reports whether calling the tool may have side effects, as
+// far as the server's annotations tell
+func (c *RetryClient) sideEffects(name string) bool {
+	c.state.mutex.Lock()
+	defer c.state.mutex.Unlock()
+	return !c.state.idempotent[name]
+}
+
+// CallTool implements mcp.MCPClient
+func (c *RetryClient) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
+	var result *mcp.ToolResult
+	err := c.do(ctx, "tool "+name, c.sideEffects(name), func() (err error) {
+		result, err = c.client.CallTool(ctx, name, arguments)
+		return err
+	})
+	return result, err
+}
+
+// ListResources implements mcp.MCPClient
+func (c *RetryClient) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	var resources []mcp.Resource
+	err := c.do(ctx, "resources/list", false, func() (err error) {
+		resources, err = c.client.ListResources(ctx)
+		return err
+	})
+	return resources, err
+}
+
+// ListRoots implements mcp.MCPClient
+func (c *RetryClient) ListRoots(ctx context.Context) ([]mcp.Root, error) {
+	var roots []mcp.Root
+	err := c.do(ctx, "roots/list", false, func() (err error) {
+		roots, err = c.client.ListRoots(ctx)
+		return err
+	})
+	return roots, err
+}
+
+// CreateMessage implements mcp.MCPClient; it is never retried
+func (c *RetryClient) CreateMessage(ctx context.Context, request *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	return c.client.CreateMessage(ctx, request)
+}
+
+// RequestInput implements mcp.MCPClient; it is never retried
+func (c *RetryClient) RequestInput(ctx context.Context, params *mcp.RequestInputParams) (*mcp.RequestInputResult, error) {
+	return c.client.RequestInput(ctx, params)
+}
+
+// NotifyRootsListChanged implements mcp.MCPClient
+func (c *RetryClient) NotifyRootsListChanged(roots []mcp.Root) error {
+	return c.client.NotifyRootsListChanged(roots)
+}
+
+// SendRequest implements mcp.RequestSender when the wrapped client does.
+// Arbitrary requests may have side effects, so they are retried like tool calls.
+func (c *RetryClient) SendRequest(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	sender, ok := c.client.(mcp.RequestSender)
+	if !ok {
+		return nil, fmt.Errorf("%s is not supported by this server connection", method)
+	}
+	var result json.RawMessage
+	err := c.do(ctx, method, true, func() (err error) {
+		result, err = sender.SendRequest(ctx, method, params)
+		return err
+	})
+	return result, err
+}
+
+// SetNotificationHandler implements mcp.NotificationSource when the wrapped client does
+func (c *RetryClient) SetNotificationHandler(handler mcp.NotificationHandler) {
+	if source, ok := c.client.(mcp.NotificationSource); ok {
+		source.SetNotificationHandler(handler)
+	}
+}
+
+// Close implements mcp.MCPClient
+func (c *RetryClient) Close() error {
+	return c.client.Close()
+}
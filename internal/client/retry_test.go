@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"stdio timeout", fmt.Errorf("failed to list tools: %w", fmt.Errorf("request timeout: %w", context.DeadlineExceeded)), config.RetryOnTimeout},
+		{"connection refused", fmt.Errorf("HTTP request failed: %w", &url.Error{Op: "Post", URL: "http://localhost:8931/mcp", Err: syscall.ECONNREFUSED}), config.RetryOnConnection},
+		{"server exited", fmt.Errorf("failed to read response: %w", io.EOF), config.RetryOnConnection},
+		{"broken pipe", fmt.Errorf("failed to write request: %w", syscall.EPIPE), config.RetryOnConnection},
+		{"marked timeout", mcp.Mark(errors.New("daemon error: request timeout"), mcp.ErrTimeout), config.RetryOnTimeout},
+		{"gateway unavailable", &HTTPStatusError{StatusCode: 503, Status: "503 Service Unavailable"}, config.RetryOnConnection},
+		{"server error", fmt.Errorf("failed to call tool navigate_page: %w", mcp.NewError(mcp.InvalidParams, "invalid params", nil)), ""},
+		{"client error", &HTTPStatusError{StatusCode: 401, Status: "401 Unauthorized"}, ""},
+		{"other", errors.New("failed to unmarshal tool result"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyFailure(tt.err); got != tt.want {
+				t.Errorf("ClassifyFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// flakyClient fails its first requests with the queued errors
+type flakyClient struct {
+	countingClient
+	errs  []error
+	calls int
+	tools []mcp.Tool // Listed instead of navigate_page when set
+}
+
+func (c *flakyClient) next() error {
+	c.calls++
+	if len(c.errs) == 0 {
+		return nil
+	}
+	err := c.errs[0]
+	c.errs = c.errs[1:]
+	return err
+}
+
+func (c *flakyClient) ListTools(context.Context) ([]mcp.Tool, error) {
+	if err := c.next(); err != nil {
+		return nil, err
+	}
+	if c.tools != nil {
+		return c.tools, nil
+	}
+	return []mcp.Tool{{Name: "navigate_page"}}, nil
+}
+
+func (c *flakyClient) CallTool(context.Context, string, map[string]interface{}) (*mcp.ToolResult, error) {
+	if err := c.next(); err != nil {
+		return nil, err
+	}
+	return &mcp.ToolResult{}, nil
+}
+
+func TestRetryClient(t *testing.T) {
+	timeout := fmt.Errorf("request timeout: %w", context.DeadlineExceeded)
+	refused := fmt.Errorf("HTTP request failed: %w", syscall.ECONNREFUSED)
+	invalid := mcp.NewError(mcp.InvalidParams, "invalid params", nil)
+
+	tests := []struct {
+		name      string
+		retry     *config.RetryConfig
+		callTool  bool
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"no policy", nil, false, []error{refused}, 1, true},
+		{"retries connection failures", &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1}, false, []error{refused, refused}, 3, false},
+		{"gives up after max attempts", &config.RetryConfig{MaxAttempts: 2, InitialDelayMs: 1}, false, []error{refused, refused}, 2, true},
+		{"skips kinds not listed", &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1, RetryOn: []string{"timeout"}}, false, []error{refused}, 1, true},
+		{"startup covers any first failure", &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1, RetryOn: []string{"startup"}}, false, []error{errors.New("browser not ready")}, 2, false},
+		{"never retries server errors", &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1}, false, []error{invalid}, 1, true},
+		{"retries listing timeouts", &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1}, false, []error{timeout}, 2, false},
+		{"never retries tool call timeouts", &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1}, true, []error{timeout}, 1, true},
+		{"retries refused tool calls", &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1}, true, []error{refused}, 2, false},
+		{"never retries tool calls that lost the connection", &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1}, true, []error{fmt.Errorf("failed to read response: %w", io.EOF)}, 1, true},
+		{"never retries tool calls the gateway failed", &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1}, true, []error{&HTTPStatusError{StatusCode: 502, Status: "502 Bad Gateway"}}, 1, true},
+		{"never retries tool calls that failed starting", &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1, RetryOn: []string{"startup"}}, true, []error{errors.New("browser not ready")}, 1, true},
+	}
+
+	t.Run("retries timeouts of tools annotated idempotent", func(t *testing.T) {
+		yes := true
+		flaky := &flakyClient{tools: []mcp.Tool{
+			{Name: "get_page", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: &yes}},
+			{Name: "set_title", Annotations: &mcp.ToolAnnotations{IdempotentHint: &yes}},
+			{Name: "click"},
+		}}
+		c := WithRetry("playwright", config.ServerConfig{Command: "npx", Retry: &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1}}, flaky)
+		if _, err := c.ListTools(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		for tool, wantCalls := range map[string]int{"get_page": 2, "set_title": 2, "click": 1} {
+			flaky.calls, flaky.errs = 0, []error{timeout}
+			_, _ = c.CallTool(context.Background(), tool, nil)
+			if flaky.calls != wantCalls {
+				t.Errorf("%s: expected %d attempts, got %d", tool, wantCalls, flaky.calls)
+			}
+		}
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flaky := &flakyClient{errs: tt.errs}
+			c := WithRetry("playwright", config.ServerConfig{Command: "npx", Retry: tt.retry}, flaky)
+
+			var err error
+			if tt.callTool {
+				_, err = c.CallTool(context.Background(), "browser_navigate", nil)
+			} else {
+				_, err = c.ListTools(context.Background())
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if flaky.calls != tt.wantCalls {
+				t.Errorf("expected %d attempts, got %d", tt.wantCalls, flaky.calls)
+			}
+		})
+	}
+}
+
+func TestSessionRetryKeepsStartupState(t *testing.T) {
+	serverConfig := config.ServerConfig{Command: "npx", Retry: &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1, RetryOn: []string{"startup"}}}
+	crashed := errors.New("browser crashed")
+
+	// Each command wraps the persistent session's client anew
+	flaky := &flakyClient{}
+	if _, err := withSessionRetry("session-retry", serverConfig, flaky).ListTools(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	flaky.calls, flaky.errs = 0, []error{crashed}
+	if _, err := withSessionRetry("session-retry", serverConfig, flaky).ListTools(context.Background()); err == nil {
+		t.Fatal("expected the failure of an established session to be returned")
+	}
+	if flaky.calls != 1 {
+		t.Errorf("expected 1 attempt once the session answered, got %d", flaky.calls)
+	}
+
+	// A restarted session has a new client, which may fail while starting
+	restarted := &flakyClient{errs: []error{crashed}}
+	if _, err := withSessionRetry("session-retry", serverConfig, restarted).ListTools(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if restarted.calls != 2 {
+		t.Errorf("expected the startup failure of a restarted session to be retried, got %d attempts", restarted.calls)
+	}
+}
+
+func TestRetryClientDoesNotReplayDroppedToolCalls(t *testing.T) {
+	// The server runs the tool, then drops the connection before answering
+	var runs atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		runs.Add(1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer server.Close()
+
+	serverConfig := config.ServerConfig{URL: server.URL, Retry: &config.RetryConfig{MaxAttempts: 3, InitialDelayMs: 1}}
+	c := WithRetry("payments", serverConfig, NewHTTPClient(server.URL, &mcp.ClientConfig{}))
+	if _, err := c.CallTool(context.Background(), "charge_card", nil); err == nil {
+		t.Fatal("expected the dropped call to fail")
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("expected the tool to run once, ran %d times", n)
+	}
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+var (
+	samplingMutex   sync.RWMutex
+	samplingHandler mcp.SamplingHandler
+)
+
+// SetSamplingHandler makes the clients NewMCPClient creates answer the
+// sampling requests servers send with handler. Only stdio servers, including
+// docker and ssh ones, can send them; see WithSamplingHandler. Nil turns
+// sampling off again.
+func SetSamplingHandler(handler mcp.SamplingHandler) {
+	samplingMutex.Lock()
+	defer samplingMutex.Unlock()
+	samplingHandler = handler
+}
+
+// samplingOptions returns the options SetSamplingHandler asks for
+func samplingOptions() []Option {
+	samplingMutex.RLock()
+	defer samplingMutex.RUnlock()
+	if samplingHandler == nil {
+		return nil
+	}
+	return []Option{WithSamplingHandler(samplingHandler)}
+}
+
+// WithSamplingHandler answers the sampling/createMessage requests a stdio
+// server sends while a request is in progress, and advertises sampling when
+// the client initializes. Without it such requests are refused. HTTP clients
+// ignore it: they read only the response to each request they post, so they
+// neither advertise sampling nor receive a server's requests.
+func WithSamplingHandler(handler mcp.SamplingHandler) Option {
+	return func(o *options) {
+		o.sampling = handler
+	}
+}
+
+// serverRequest is a request the server sends the client: a message with
+// both an id and a method
+type serverRequest struct {
+	ID     interface{}     `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// parseServerRequest returns line as a server request, or nil when it is a
+// response or notification
+func parseServerRequest(line []byte) *serverRequest {
+	var req serverRequest
+	if json.Unmarshal(line, &req) != nil || req.ID == nil || req.Method == "" {
+		return nil
+	}
+	return &req
+}
+
+// answerServerRequest works out the response to a server request
+func answerServerRequest(ctx context.Context, handler mcp.SamplingHandler, req *serverRequest) *mcp.JSONRPCResponse {
+	switch req.Method {
+	case "ping":
+		return mcp.NewResponse(req.ID, json.RawMessage(`{}`))
+	case "sampling/createMessage":
+		if handler == nil {
+			return mcp.NewErrorResponse(req.ID, mcp.NewError(mcp.MethodNotFound, "sampling is not configured", nil))
+		}
+		var request mcp.CreateMessageRequest
+		if err := json.Unmarshal(req.Params, &request); err != nil {
+			return mcp.NewErrorResponse(req.ID, mcp.NewError(mcp.InvalidParams, fmt.Sprintf("invalid sampling request: %v", err), nil))
+		}
+		result, err := handler.HandleSamplingRequest(ctx, &request)
+		if err != nil {
+			return mcp.NewErrorResponse(req.ID, mcp.NewError(mcp.InternalError, err.Error(), nil))
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return mcp.NewErrorResponse(req.ID, mcp.NewError(mcp.InternalError, err.Error(), nil))
+		}
+		return mcp.NewResponse(req.ID, data)
+	}
+	return mcp.NewErrorResponse(req.ID, mcp.NewError(mcp.MethodNotFound, "method not found: "+req.Method, nil))
+}
+
+// answer responds to a request the server sent while waiting for the
+// response to one of ours. The caller holds c.mutex.
+func (c *StdioClient) answer(ctx context.Context, req *serverRequest) error {
+	data, err := json.Marshal(answerServerRequest(ctx, c.sampling, req))
+	if err != nil {
+		return fmt.Errorf("failed to marshal response to %s: %w", req.Method, err)
+	}
+	if err := c.writeMessage(data); err != nil {
+		return c.serverExitError(fmt.Errorf("failed to write response to %s: %w", req.Method, err))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// echoSampler replies with the last message it was sent
+type echoSampler struct{ request *mcp.CreateMessageRequest }
+
+func (s *echoSampler) HandleSamplingRequest(_ context.Context, request *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	s.request = request
+	last := request.Messages[len(request.Messages)-1]
+	return &mcp.CreateMessageResult{Role: "assistant", Content: mcp.Content{Type: "text", Text: "echo: " + last.Content}, Model: "echo", StopReason: "endTurn"}, nil
+}
+
+// samplingServer starts a server that answers a tool call only after asking
+// the client for a message, saving the client's answer to a file
+func samplingServer(t *testing.T, opts ...Option) (*StdioClient, string) {
+	t.Helper()
+	answer := filepath.Join(t.TempDir(), "answer")
+	script := `read call
+echo '{"jsonrpc":"2.0","id":"s1","method":"sampling/createMessage","params":{"messages":[{"role":"user","content":{"type":"text","text":"hi"}}],"maxTokens":5}}'
+read reply
+echo "$reply" > ` + answer + `
+echo '{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"done"}]}}'
+cat > /dev/null`
+	c, err := NewStdio("sh", []string{"-c", script}, opts...)
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c, answer
+}
+
+func TestStdioAnswersSamplingRequests(t *testing.T) {
+	sampler := &echoSampler{}
+	c, answer := samplingServer(t, WithSamplingHandler(sampler))
+
+	result, err := c.CallTool(context.Background(), "summarize", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if text := result.Content[0].(map[string]interface{})["text"]; text != "done" {
+		t.Errorf("expected the tool's result, got %v", text)
+	}
+	if sampler.request == nil || sampler.request.MaxTokens != 5 || sampler.request.Messages[0].Content != "hi" {
+		t.Fatalf("expected the server's request handed to the sampler, got %+v", sampler.request)
+	}
+
+	data, _ := os.ReadFile(answer)
+	var resp struct {
+		ID     string                  `json:"id"`
+		Result mcp.CreateMessageResult `json:"result"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("invalid answer %q: %v", data, err)
+	}
+	if resp.ID != "s1" || resp.Result.Content.Text != "echo: hi" || resp.Result.Model != "echo" {
+		t.Errorf("unexpected answer %s", data)
+	}
+}
+
+func TestStdioRefusesSamplingWithoutHandler(t *testing.T) {
+	c, answer := samplingServer(t)
+	if _, err := c.CallTool(context.Background(), "summarize", nil); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	data, _ := os.ReadFile(answer)
+	if !strings.Contains(string(data), `"id":"s1","error":{"code":-32601`) {
+		t.Errorf("expected sampling to be refused, got %s", data)
+	}
+}
+
+func TestHTTPDoesNotOfferSampling(t *testing.T) {
+	var params mcp.InitializeParams
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params mcp.InitializeParams `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		params = req.Params
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":0,"result":{"protocolVersion":"2025-06-18","capabilities":{},"serverInfo":{"name":"http"}}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	// The handler has no way to hear from an HTTP server, so it isn't advertised
+	c := NewHTTP(server.URL, WithSamplingHandler(&echoSampler{}))
+	if _, err := c.Initialize(context.Background(), &mcp.InitializeParams{ProtocolVersion: "2025-06-18"}); err != nil {
+		t.Fatal(err)
+	}
+	if params.Capabilities.Sampling != nil {
+		t.Error("an HTTP client should not advertise sampling")
+	}
+}
+
+func TestAnswerServerRequest(t *testing.T) {
+	ping := answerServerRequest(context.Background(), nil, &serverRequest{ID: 7.0, Method: "ping"})
+	if ping.Error != nil || string(ping.Result) != "{}" {
+		t.Errorf("expected ping answered, got %+v", ping)
+	}
+	unknown := answerServerRequest(context.Background(), &echoSampler{}, &serverRequest{ID: 8.0, Method: "roots/list"})
+	if unknown.Error == nil || unknown.Error.Code != mcp.MethodNotFound {
+		t.Errorf("expected an unknown method refused, got %+v", unknown)
+	}
+	bad := answerServerRequest(context.Background(), &echoSampler{}, &serverRequest{ID: 9.0, Method: "sampling/createMessage", Params: json.RawMessage(`[]`)})
+	if bad.Error == nil || bad.Error.Code != mcp.InvalidParams {
+		t.Errorf("expected bad params refused, got %+v", bad)
+	}
+
+	for _, line := range []string{`{"jsonrpc":"2.0","id":1,"result":{}}`, `{"jsonrpc":"2.0","method":"notifications/progress"}`} {
+		if parseServerRequest([]byte(line)) != nil {
+			t.Errorf("%s is not a server request", line)
+		}
+	}
+}
+
+func TestSetSamplingHandler(t *testing.T) {
+	defer SetSamplingHandler(nil)
+	if len(samplingOptions()) != 0 {
+		t.Fatal("expected no sampling by default")
+	}
+	SetSamplingHandler(&echoSampler{})
+	if o := newOptions(samplingOptions()); o.sampling == nil {
+		t.Error("expected the handler passed to new clients")
+	}
+}
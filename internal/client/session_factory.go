@@ -2,8 +2,10 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
@@ -38,44 +40,59 @@ func (f *SessionAwareClientFactory) CreateClient(serverName string, serverConfig
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
-	// For stateless sessions, we need to handle client creation differently
+	// Stateless sessions hand out a fresh client that this wrapper owns
 	if sess.Type() == session.Stateless {
-		return f.createStatelessClient(serverConfig)
+		return f.createStatelessClient(serverName, serverConfig, sess)
 	}
 
 	// For persistent sessions, return the session's client
-	client := sess.Client()
-	if client == nil {
+	client, err := sess.Client()
+	if errors.Is(err, session.ErrNotStarted) {
 		// Try to start the session if it's not active
 		if err := sess.Start(); err != nil {
+			// Hybrid sessions degrade to a per-command client instead of failing
+			if session.CanFallback(serverConfig) {
+				if fallback, fallbackErr := f.sessionManager.FallbackToStateless(serverName, serverConfig, err); fallbackErr == nil {
+					return f.createStatelessClient(serverName, serverConfig, fallback)
+				}
+			}
 			// Check for browser profile conflicts and provide helpful message
-			if strings.Contains(err.Error(), "browser is already running") ||
-				strings.Contains(err.Error(), "chrome-profile") {
-				return nil, fmt.Errorf("browser profile conflict: %s\n\nSuggestion: Run 'mcp-cli-ent session cleanup' to clean up old sessions, or try again in a few moments", err.Error())
+			if errors.Is(err, ErrProfileInUse) {
+				return nil, fmt.Errorf("browser profile conflict: %w\n\nSuggestion: Run 'mcp-cli-ent session cleanup' to clean up old sessions, 'mcp-cli-ent session adopt --scan' to find a browser a crashed session left running, or try again in a few moments", err)
 			}
 			return nil, fmt.Errorf("failed to start session: %w", err)
 		}
-		client = sess.Client()
-		if client == nil {
-			return nil, fmt.Errorf("session client is nil after starting")
-		}
+		client, err = sess.Client()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session client: %w", err)
 	}
 
 	return &SessionAwareClient{
-		client:  client,
+		client:  withSessionRetry(serverName, serverConfig, client),
 		session: sess,
 	}, nil
 }
 
-// createStatelessClient creates a traditional stateless client
-func (f *SessionAwareClientFactory) createStatelessClient(serverConfig config.ServerConfig) (mcp.MCPClient, error) {
-	return NewMCPClient(serverConfig)
+// createStatelessClient creates a per-command client owned by the returned wrapper
+func (f *SessionAwareClientFactory) createStatelessClient(serverName string, serverConfig config.ServerConfig, sess session.Session) (mcp.MCPClient, error) {
+	client, err := sess.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionAwareClient{
+		client:  WithRetry(serverName, serverConfig, client),
+		session: sess,
+		owned:   true,
+	}, nil
 }
 
 // SessionAwareClient wraps an MCP client with session awareness
 type SessionAwareClient struct {
 	client  mcp.MCPClient
 	session session.Session
+	owned   bool // The client was created for this wrapper and is closed with it
 }
 
 // ListTools implements mcp.MCPClient
@@ -85,12 +102,14 @@ func (c *SessionAwareClient) ListTools(ctx context.Context) ([]mcp.Tool, error)
 
 // CallTool implements mcp.MCPClient
 func (c *SessionAwareClient) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
-	// Update session activity
+	start := time.Now()
+	result, err := c.client.CallTool(ctx, name, arguments)
+
+	// Record the call (and its activity) in the session metrics
 	if c.session != nil {
-		c.session.UpdateActivity()
+		c.session.RecordToolCall(name, time.Since(start), err)
 	}
 
-	result, err := c.client.CallTool(ctx, name, arguments)
 	if err != nil {
 		// Check if this is a session-related error and handle it
 		if c.session != nil && c.session.Type() == session.Persistent {
@@ -101,8 +120,7 @@ func (c *SessionAwareClient) CallTool(ctx context.Context, name string, argument
 					return nil, fmt.Errorf("client error: %w, session restart failed: %v", err, restartErr)
 				}
 				// Try the operation again with the restarted session
-				newClient := c.session.Client()
-				if newClient != nil {
+				if newClient, clientErr := c.session.Client(); clientErr == nil {
 					return newClient.CallTool(ctx, name, arguments)
 				}
 			}
@@ -168,15 +186,30 @@ func (c *SessionAwareClient) NotifyRootsListChanged(roots []mcp.Root) error {
 	return c.client.NotifyRootsListChanged(roots)
 }
 
+// SendRequest implements mcp.RequestSender when the session's client does
+func (c *SessionAwareClient) SendRequest(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	sender, ok := c.client.(mcp.RequestSender)
+	if !ok {
+		return nil, fmt.Errorf("%s is not supported by this server connection", method)
+	}
+
+	// Update session activity
+	if c.session != nil {
+		c.session.UpdateActivity()
+	}
+
+	return sender.SendRequest(ctx, method, params)
+}
+
 // Close implements mcp.MCPClient
 func (c *SessionAwareClient) Close() error {
-	// For persistent sessions, we don't close the client here
-	// The session manager handles session lifecycle
-	if c.session != nil && c.session.Type() == session.Persistent {
-		return nil // Don't close persistent clients
+	// Session clients belong to the session; the session manager handles
+	// their lifecycle
+	if !c.owned {
+		return nil
 	}
 
-	// For stateless sessions, close the client
+	// Stateless clients were created for this wrapper, so close them
 	return c.client.Close()
 }
 
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/session"
+)
+
+// countingClient is a fake MCP client that records Close calls
+type countingClient struct {
+	closed *atomic.Int32
+}
+
+func (c *countingClient) ListTools(context.Context) ([]mcp.Tool, error) { return nil, nil }
+func (c *countingClient) CallTool(context.Context, string, map[string]interface{}) (*mcp.ToolResult, error) {
+	return &mcp.ToolResult{}, nil
+}
+func (c *countingClient) ListResources(context.Context) ([]mcp.Resource, error) { return nil, nil }
+func (c *countingClient) Initialize(context.Context, *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	return &mcp.InitializeResult{}, nil
+}
+func (c *countingClient) CreateMessage(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	return &mcp.CreateMessageResult{}, nil
+}
+func (c *countingClient) RequestInput(context.Context, *mcp.RequestInputParams) (*mcp.RequestInputResult, error) {
+	return &mcp.RequestInputResult{}, nil
+}
+func (c *countingClient) ListRoots(context.Context) ([]mcp.Root, error) { return nil, nil }
+func (c *countingClient) NotifyRootsListChanged([]mcp.Root) error       { return nil }
+func (c *countingClient) Close() error {
+	c.closed.Add(1)
+	return nil
+}
+
+func TestSessionAwareClientClosesStatelessClients(t *testing.T) {
+	var created, closed atomic.Int32
+	factory := func(config.ServerConfig) (mcp.MCPClient, error) {
+		created.Add(1)
+		return &countingClient{closed: &closed}, nil
+	}
+
+	manager, err := session.NewManager(t.TempDir(), factory)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	defer manager.Close()
+	clients := NewSessionAwareClientFactory(manager)
+
+	stateless := config.ServerConfig{Command: "uvx", Args: []string{"mcp-server-time"}, Session: config.SessionConfig{Type: "stateless"}}
+	for i := 0; i < 3; i++ {
+		mcpClient, err := clients.CreateClient("time", stateless)
+		if err != nil {
+			t.Fatalf("CreateClient failed: %v", err)
+		}
+		if _, err := mcpClient.CallTool(context.Background(), "get_current_time", nil); err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		if err := mcpClient.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+
+	if created.Load() != 3 || closed.Load() != 3 {
+		t.Errorf("expected 3 stateless clients created and closed, got %d created and %d closed", created.Load(), closed.Load())
+	}
+
+	// Closing a wrapper must not close the persistent session's shared client
+	persistent := config.ServerConfig{Command: "npx", Args: []string{"-y", "chrome-devtools-mcp@latest"}}
+	mcpClient, err := clients.CreateClient("chrome-devtools", persistent)
+	if err != nil {
+		t.Fatalf("CreateClient failed: %v", err)
+	}
+	if err := mcpClient.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if closed.Load() != 3 {
+		t.Errorf("expected the persistent client to stay open, got %d closes", closed.Load())
+	}
+}
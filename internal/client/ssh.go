@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// sshConnectionFailed is the exit status ssh uses for its own errors, as
+// opposed to the exit status of the remote command
+const sshConnectionFailed = 255
+
+// SSHClient runs a stdio MCP server on a remote host through the ssh client
+type SSHClient struct {
+	*StdioClient
+	destination string
+
+	waitMutex sync.Mutex // Serializes the exit watcher's Wait with Close
+	closing   bool
+	exited    chan struct{}
+	exitCode  int
+}
+
+// NewSSHClient starts the server's remoteCommand with
+// "ssh -o BatchMode=yes host remoteCommand" and talks to it over stdio
+func NewSSHClient(serverConfig config.ServerConfig, opts ...Option) (*SSHClient, error) {
+	if err := serverConfig.CheckRuntime(); err != nil {
+		return nil, &ClientError{err.Error()}
+	}
+
+	stdio, err := NewStdio(config.SSHCommand, sshArgs(serverConfig), append(serverOptions(serverConfig), opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start ssh: %w", err)
+	}
+
+	client := &SSHClient{
+		StdioClient: stdio,
+		destination: serverConfig.SSHDestination(),
+		exited:      make(chan struct{}),
+	}
+	go client.watchExit()
+	return client, nil
+}
+
+// Initialize implements the MCPClient interface
+func (c *SSHClient) Initialize(ctx context.Context, params *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	result, err := c.StdioClient.Initialize(ctx, params)
+	return result, c.attributeError(err)
+}
+
+// ListTools implements the MCPClient interface
+func (c *SSHClient) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	tools, err := c.StdioClient.ListTools(ctx)
+	return tools, c.attributeError(err)
+}
+
+// CallTool implements the MCPClient interface
+func (c *SSHClient) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
+	result, err := c.StdioClient.CallTool(ctx, name, arguments)
+	return result, c.attributeError(err)
+}
+
+// ListResources implements the MCPClient interface
+func (c *SSHClient) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	resources, err := c.StdioClient.ListResources(ctx)
+	return resources, c.attributeError(err)
+}
+
+// Close stops ssh and the remote server with it
+func (c *SSHClient) Close() error {
+	c.waitMutex.Lock()
+	c.closing = true
+	c.waitMutex.Unlock()
+	return c.StdioClient.Close()
+}
+
+// watchExit records ssh's exit status once its stderr is drained
+func (c *SSHClient) watchExit() {
+	defer close(c.exited)
+
+	<-c.stderrDone
+
+	c.waitMutex.Lock()
+	defer c.waitMutex.Unlock()
+	if c.closing {
+		return
+	}
+
+	var exitErr *exec.ExitError
+	if err := c.cmd.Wait(); errors.As(err, &exitErr) {
+		c.exitCode = exitErr.ExitCode()
+	}
+}
+
+// attributeError tells ssh failures apart from errors of the remote server.
+// Errors from a still-running connection are returned unchanged.
+func (c *SSHClient) attributeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	select {
+	case <-c.exited:
+	case <-time.After(200 * time.Millisecond):
+		return err
+	}
+
+	c.waitMutex.Lock()
+	defer c.waitMutex.Unlock()
+
+	if c.exitCode == sshConnectionFailed {
+		reason := lastLine(c.StderrTail())
+		if reason == "" {
+			reason = "ssh exited with status 255"
+		}
+		return &ClientError{fmt.Sprintf("ssh connection to %s failed: %s", c.destination, reason)}
+	}
+	return fmt.Errorf("MCP server on %s exited with status %d: %w", c.destination, c.exitCode, err)
+}
+
+// sshArgs builds the ssh arguments for a remote server
+func sshArgs(serverConfig config.ServerConfig) []string {
+	args := []string{"-T", "-o", "BatchMode=yes"}
+	if serverConfig.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(serverConfig.Port))
+	}
+	if serverConfig.IdentityFile != "" {
+		args = append(args, "-i", serverConfig.IdentityFile)
+	}
+	for _, option := range serverConfig.SSHOptions {
+		args = append(args, "-o", option)
+	}
+
+	// The remote shell parses the command line, so args are quoted for it
+	remote := serverConfig.RemoteCommand
+	for _, arg := range serverConfig.Args {
+		remote += " " + shellQuote(arg)
+	}
+	return append(args, "--", serverConfig.SSHDestination(), remote)
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@,+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
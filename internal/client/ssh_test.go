@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestSSHArgs(t *testing.T) {
+	server := config.ServerConfig{
+		Type:          config.ServerTypeSSH,
+		Host:          "gpu-box",
+		User:          "mcp",
+		Port:          2222,
+		IdentityFile:  "~/.ssh/id_mcp",
+		RemoteCommand: "uvx mcp-server-fetch",
+		Args:          []string{"--user-agent", "it's me"},
+		SSHOptions:    []string{"ControlMaster=auto", "ControlPersist=10m"},
+	}
+
+	got := sshArgs(server)
+	want := []string{
+		"-T", "-o", "BatchMode=yes", "-p", "2222", "-i", "~/.ssh/id_mcp",
+		"-o", "ControlMaster=auto", "-o", "ControlPersist=10m",
+		"--", "mcp@gpu-box", `uvx mcp-server-fetch --user-agent 'it'\''s me'`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sshArgs() = %q, want %q", got, want)
+	}
+}
+
+// fakeSSH puts an "ssh" script running body first on PATH
+func fakeSSH(t *testing.T, body string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh needs a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestSSHClientAttributesErrors(t *testing.T) {
+	server := config.ServerConfig{Type: config.ServerTypeSSH, Host: "gpu-box", RemoteCommand: "uvx mcp-server-time"}
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"ssh failure", "echo 'mcp@gpu-box: Permission denied (publickey).' >&2; exit 255", "ssh connection to gpu-box failed: mcp@gpu-box: Permission denied (publickey)."},
+		{"server failure", "echo 'uvx: command not found' >&2; exit 127", "MCP server on gpu-box exited with status 127"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeSSH(t, tt.body)
+
+			client, err := NewMCPClient(server)
+			if err != nil {
+				t.Fatalf("NewMCPClient failed: %v", err)
+			}
+			defer func() { _ = client.Close() }()
+
+			_, err = client.ListTools(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
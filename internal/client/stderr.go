@@ -0,0 +1,116 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// maxStderrTail bounds the server stderr kept to explain failures
+const maxStderrTail = 4096
+
+// stderrExcerptLines is how many stderr lines an error message quotes
+const stderrExcerptLines = 5
+
+// ServerExitError reports a request that failed because the server process
+// went away, quoting the last lines it wrote to stderr
+type ServerExitError struct {
+	Stderr string // Last non-empty stderr lines, oldest first
+	Err    error  // The underlying read or write error
+}
+
+func (e *ServerExitError) Error() string {
+	lines := strings.Split(e.Stderr, "\n")
+	if len(lines) == 1 {
+		return "server exited: " + lines[0]
+	}
+	return "server exited:\n  " + strings.Join(lines, "\n  ")
+}
+
+func (e *ServerExitError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes a ServerExitError match mcp.ErrProcessExited
+func (e *ServerExitError) Is(target error) bool {
+	return target == mcp.ErrProcessExited
+}
+
+// stderrHints maps stderr patterns to advice on fixing the cause
+var stderrHints = []struct {
+	patterns []string
+	hint     string
+}{
+	{
+		patterns: []string{"api key", "api_key", "apikey", "access token", "unauthorized", "is not set", "environment variable", "credentials"},
+		hint:     "check the server's env settings and your .env file for missing or invalid variables",
+	},
+	{
+		patterns: []string{"enoent", "cannot find module", "module_not_found", "no such file", "command not found", "modulenotfounderror"},
+		hint:     "a file or package the server needs was not found; check its command and args, and that it is installed",
+	},
+}
+
+// Hint suggests a fix for failures the server's stderr makes recognisable
+func (e *ServerExitError) Hint() string {
+	stderr := strings.ToLower(e.Stderr)
+	for _, h := range stderrHints {
+		for _, pattern := range h.patterns {
+			if strings.Contains(stderr, pattern) {
+				return h.hint
+			}
+		}
+	}
+	return ""
+}
+
+// isServerGone reports whether err means the server closed its end of the pipes
+func isServerGone(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.ErrClosedPipe) ||
+		errors.Is(err, os.ErrClosed) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// lastLines returns the last n non-empty lines of s, trimmed
+func lastLines(s string, n int) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// lastLine returns the last non-empty line of s
+func lastLine(s string) string {
+	lines := lastLines(s, 1)
+	if len(lines) == 0 {
+		return ""
+	}
+	return lines[0]
+}
+
+// tailWriter keeps the last limit bytes written to it
+type tailWriter struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	if excess := w.buf.Len() - w.limit; excess > 0 {
+		w.buf.Next(excess)
+	}
+	return len(p), nil
+}
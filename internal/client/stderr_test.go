@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// startScriptedServer runs script with sh as a stdio MCP server
+func startScriptedServer(t *testing.T, script string) *StdioClient {
+	t.Helper()
+	c, err := NewStdioClient("sh", []string{"-c", script}, nil)
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestStdioClientServerExitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		wantMsg  string
+		wantHint string
+		wantKind error // Besides mcp.ErrProcessExited
+	}{
+		{
+			name:     "missing api key",
+			script:   `echo "starting server" >&2; echo "Error: OPENAI_API_KEY environment variable is not set" >&2; exit 1`,
+			wantMsg:  "server exited:\n  starting server\n  Error: OPENAI_API_KEY environment variable is not set",
+			wantHint: "env settings",
+		},
+		{
+			name:     "module not found",
+			script:   `echo "Error: Cannot find module '@acme/mcp-server'" >&2; exit 1`,
+			wantMsg:  "server exited: Error: Cannot find module '@acme/mcp-server'",
+			wantHint: "not found",
+		},
+		{
+			name:     "browser profile locked",
+			script:   `echo "Error: The browser is already running for /tmp/chrome-profile" >&2; exit 1`,
+			wantMsg:  "server exited: Error: The browser is already running for /tmp/chrome-profile",
+			wantKind: ErrProfileInUse,
+		},
+		{
+			name:    "unrecognised failure",
+			script:  `echo "panic: something broke" >&2; exit 2`,
+			wantMsg: "server exited: panic: something broke",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := startScriptedServer(t, tt.script)
+
+			_, err := c.ListTools(context.Background())
+			if err == nil {
+				t.Fatal("expected an error from an exited server")
+			}
+
+			var exitErr *ServerExitError
+			if !errors.As(err, &exitErr) {
+				t.Fatalf("error %q is not a *ServerExitError", err)
+			}
+			if !strings.HasSuffix(err.Error(), tt.wantMsg) {
+				t.Errorf("error = %q, want suffix %q", err, tt.wantMsg)
+			}
+			if !isServerGone(err) {
+				t.Errorf("error %q no longer unwraps to the pipe error", err)
+			}
+			if !errors.Is(err, mcp.ErrProcessExited) {
+				t.Errorf("error %q does not match mcp.ErrProcessExited", err)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("error %q does not match %v", err, tt.wantKind)
+			}
+			if tt.wantKind == nil && errors.Is(err, ErrProfileInUse) {
+				t.Errorf("error %q should not match ErrProfileInUse", err)
+			}
+
+			hint := exitErr.Hint()
+			if tt.wantHint == "" && hint != "" {
+				t.Errorf("Hint() = %q, want none", hint)
+			}
+			if !strings.Contains(hint, tt.wantHint) {
+				t.Errorf("Hint() = %q, want it to mention %q", hint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestStdioClientExitWithoutStderr(t *testing.T) {
+	c := startScriptedServer(t, `exit 1`)
+
+	_, err := c.ListTools(context.Background())
+	if err == nil {
+		t.Fatal("expected an error from an exited server")
+	}
+
+	var exitErr *ServerExitError
+	if errors.As(err, &exitErr) {
+		t.Errorf("error %q should not quote empty stderr", err)
+	}
+	if !errors.Is(err, io.EOF) && !isServerGone(err) {
+		t.Errorf("error %q should unwrap to the pipe error", err)
+	}
+	if !errors.Is(err, mcp.ErrProcessExited) {
+		t.Errorf("error %q does not match mcp.ErrProcessExited", err)
+	}
+}
+
+func TestStderrTailIsBounded(t *testing.T) {
+	c := startScriptedServer(t, `i=0; while [ $i -lt 2000 ]; do echo "log line $i" >&2; i=$((i+1)); done; echo "fatal: done" >&2`)
+	<-c.stderrDone
+
+	tail := c.StderrTail()
+	if len(tail) > maxStderrTail {
+		t.Errorf("stderr tail is %d bytes, want at most %d", len(tail), maxStderrTail)
+	}
+	if got := lastLine(tail); got != "fatal: done" {
+		t.Errorf("last stderr line = %q, want %q", got, "fatal: done")
+	}
+}
@@ -5,43 +5,59 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/redact"
 )
 
 // StdioClient implements MCPClient for stdio-based MCP servers
 type StdioClient struct {
-	cmd    *exec.Cmd
-	stdin  io.WriteCloser
-	stdout io.ReadCloser
-	stderr io.ReadCloser
-	reader *bufio.Reader
-	writer *bufio.Writer
-	closed bool
-	mutex  sync.Mutex
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	stdout   io.ReadCloser
+	stderr   io.ReadCloser
+	reader   *bufio.Reader
+	writer   *bufio.Writer
+	framing  framing          // Delimits the messages read and written
+	limit    int64            // Bytes a message read from the server may take
+	redactor *redact.Redactor // Scrubs the env's secrets from errors and stderr
+	closed   bool
+	mutex    sync.Mutex
+
+	timeout     time.Duration   // Bounds each request
+	pendingRead chan lineResult // A read still running from a request that gave up, guarded by mutex
+
+	notify   mcp.NotificationHandler // Receives notifications read while waiting for responses
+	sampling mcp.SamplingHandler     // Answers sampling requests read while waiting for responses
+
+	initialize initializeOptions // Added to the initialize request
+
+	stderrMutex sync.Mutex    // Guards stderrTail
+	stderrTail  bytes.Buffer  // Last output the server wrote to stderr
+	stderrDone  chan struct{} // Closed once stderr reaches EOF
 }
 
-// NewStdioClient creates a new stdio MCP client
+// NewStdioClient creates a new stdio MCP client. It is NewStdio with the
+// environment given directly.
 func NewStdioClient(command string, args []string, env map[string]string) (*StdioClient, error) {
-	ctx := context.Background()
+	return NewStdio(command, args, WithEnv(env))
+}
 
-	// Create the command
-	cmd := exec.CommandContext(ctx, command, args...)
+// NewStdio starts command and returns a client speaking JSON-RPC over its
+// stdin and stdout
+func NewStdio(command string, args []string, opts ...Option) (*StdioClient, error) {
+	o := newOptions(opts)
 
-	// Set up environment
-	if len(env) > 0 {
-		cmdEnv := os.Environ()
-		for k, v := range env {
-			cmdEnv = append(cmdEnv, fmt.Sprintf("%s=%s", k, v))
-		}
-		cmd.Env = cmdEnv
-	}
+	// Create the command
+	cmd := exec.CommandContext(context.Background(), command, args...)
+	cmd.Env = o.commandEnv()
 
 	// Create pipes for stdin/stdout/stderr
 	stdin, err := cmd.StdinPipe()
@@ -63,12 +79,21 @@ func NewStdioClient(command string, args []string, env map[string]string) (*Stdi
 	}
 
 	client := &StdioClient{
-		cmd:    cmd,
-		stdin:  stdin,
-		stdout: stdout,
-		stderr: stderr,
-		reader: bufio.NewReader(stdout),
-		writer: bufio.NewWriter(stdin),
+		cmd:      cmd,
+		stdin:    stdin,
+		stdout:   stdout,
+		stderr:   stderr,
+		reader:   bufio.NewReader(stdout),
+		writer:   bufio.NewWriter(stdin),
+		framing:  newFraming(o.framing),
+		limit:    o.maxResponse,
+		redactor: o.redactor(),
+		timeout:  o.timeout,
+
+		sampling:   o.sampling,
+		initialize: o.initialize,
+
+		stderrDone: make(chan struct{}),
 	}
 
 	// Start the command
@@ -77,9 +102,60 @@ func NewStdioClient(command string, args []string, env map[string]string) (*Stdi
 		return nil, fmt.Errorf("failed to start command: %w", err)
 	}
 
+	go client.captureStderr()
+	o.log().Debug("started server", "command", command, "pid", cmd.Process.Pid)
+
 	return client, nil
 }
 
+// captureStderr keeps the tail of the server's stderr so failures can be
+// explained; draining it also stops a chatty server blocking on a full pipe
+func (c *StdioClient) captureStderr() {
+	defer close(c.stderrDone)
+
+	tail := &tailWriter{buf: &c.stderrTail, limit: maxStderrTail}
+	buf := make([]byte, 4096)
+	for {
+		n, err := c.stderr.Read(buf)
+		if n > 0 {
+			c.stderrMutex.Lock()
+			_, _ = tail.Write(buf[:n])
+			c.stderrMutex.Unlock()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// StderrTail returns the last output the server wrote to stderr
+func (c *StdioClient) StderrTail() string {
+	c.stderrMutex.Lock()
+	defer c.stderrMutex.Unlock()
+	return c.redactor.String(c.stderrTail.String())
+}
+
+// serverExitError attaches the server's last stderr lines to err when the
+// request failed because the server went away
+func (c *StdioClient) serverExitError(err error) error {
+	if !isServerGone(err) {
+		return err
+	}
+
+	// Give the server's final output a moment to arrive
+	select {
+	case <-c.stderrDone:
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	lines := lastLines(c.StderrTail(), stderrExcerptLines)
+	if len(lines) == 0 {
+		return mcp.Mark(err, mcp.ErrProcessExited)
+	}
+	stderr := strings.Join(lines, "\n")
+	return markServerFailure(&ServerExitError{Stderr: stderr, Err: err}, stderr)
+}
+
 // ListTools retrieves available tools from the MCP server
 func (c *StdioClient) ListTools(ctx context.Context) ([]mcp.Tool, error) {
 	req := mcp.NewRequest(1, "tools/list", nil)
@@ -93,14 +169,8 @@ func (c *StdioClient) ListTools(ctx context.Context) ([]mcp.Tool, error) {
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var listResult mcp.ListToolsResult
-	if err := json.Unmarshal(resultBytes, &listResult); err != nil {
+	if err := json.Unmarshal(result, &listResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal tools list result: %w", err)
 	}
 
@@ -109,12 +179,7 @@ func (c *StdioClient) ListTools(ctx context.Context) ([]mcp.Tool, error) {
 
 // CallTool executes a specific tool on the MCP server
 func (c *StdioClient) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
-	params := &mcp.CallToolParams{
-		Name:      name,
-		Arguments: arguments,
-	}
-
-	req := mcp.NewRequest(2, "tools/call", params)
+	req := mcp.NewCallToolRequest(ctx, name, arguments)
 
 	result, err := c.sendRequest(ctx, req)
 	if err != nil {
@@ -125,17 +190,12 @@ func (c *StdioClient) CallTool(ctx context.Context, name string, arguments map[s
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var toolResult mcp.ToolResult
-	if err := json.Unmarshal(resultBytes, &toolResult); err != nil {
+	if err := json.Unmarshal(result, &toolResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal tool result: %w", err)
 	}
 
+	toolResult.Raw = result
 	return &toolResult, nil
 }
 
@@ -152,14 +212,8 @@ func (c *StdioClient) ListResources(ctx context.Context) ([]mcp.Resource, error)
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var listResult mcp.ListResourcesResult
-	if err := json.Unmarshal(resultBytes, &listResult); err != nil {
+	if err := json.Unmarshal(result, &listResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal resources list result: %w", err)
 	}
 
@@ -168,6 +222,12 @@ func (c *StdioClient) ListResources(ctx context.Context) ([]mcp.Resource, error)
 
 // Initialize the MCP connection
 func (c *StdioClient) Initialize(ctx context.Context, params *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	params = c.initialize.apply(params)
+	if c.sampling != nil && params != nil && params.Capabilities.Sampling == nil {
+		withSampling := *params
+		withSampling.Capabilities.Sampling = &mcp.SamplingCapability{}
+		params = &withSampling
+	}
 	req := mcp.NewRequest(0, "initialize", params)
 
 	result, err := c.sendRequest(ctx, req)
@@ -179,16 +239,11 @@ func (c *StdioClient) Initialize(ctx context.Context, params *mcp.InitializePara
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var initResult mcp.InitializeResult
-	if err := json.Unmarshal(resultBytes, &initResult); err != nil {
+	if err := json.Unmarshal(result, &initResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal initialize result: %w", err)
 	}
+	initResult.Raw = result
 
 	return &initResult, nil
 }
@@ -206,14 +261,8 @@ func (c *StdioClient) CreateMessage(ctx context.Context, request *mcp.CreateMess
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var messageResult mcp.CreateMessageResult
-	if err := json.Unmarshal(resultBytes, &messageResult); err != nil {
+	if err := json.Unmarshal(result, &messageResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal message result: %w", err)
 	}
 
@@ -233,14 +282,8 @@ func (c *StdioClient) RequestInput(ctx context.Context, params *mcp.RequestInput
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var inputResult mcp.RequestInputResult
-	if err := json.Unmarshal(resultBytes, &inputResult); err != nil {
+	if err := json.Unmarshal(result, &inputResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal input result: %w", err)
 	}
 
@@ -260,22 +303,29 @@ func (c *StdioClient) ListRoots(ctx context.Context) ([]mcp.Root, error) {
 		return nil, fmt.Errorf("no result received")
 	}
 
-	// Parse the result
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal result: %w", err)
-	}
-
 	var listResult struct {
 		Roots []mcp.Root `json:"roots"`
 	}
-	if err := json.Unmarshal(resultBytes, &listResult); err != nil {
+	if err := json.Unmarshal(result, &listResult); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal roots list result: %w", err)
 	}
 
 	return listResult.Roots, nil
 }
 
+// SendRequest implements mcp.RequestSender
+func (c *StdioClient) SendRequest(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	return c.sendRequest(ctx, mcp.NewRequest(0, method, params))
+}
+
+// SetNotificationHandler implements mcp.NotificationSource. Notifications
+// are seen when the server's output is read, i.e. during requests.
+func (c *StdioClient) SetNotificationHandler(handler mcp.NotificationHandler) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.notify = handler
+}
+
 // NotifyRootsListChanged sends notification about roots change
 func (c *StdioClient) NotifyRootsListChanged(roots []mcp.Root) error {
 	c.mutex.Lock()
@@ -296,12 +346,7 @@ func (c *StdioClient) NotifyRootsListChanged(roots []mcp.Root) error {
 		return fmt.Errorf("failed to marshal notification: %w", err)
 	}
 
-	// Add newline for JSON-RPC over stdio
-	reqBytes = append(reqBytes, '\n')
-
-	// Write to stdin
-	_, err = c.stdin.Write(reqBytes)
-	if err != nil {
+	if err := c.writeMessage(reqBytes); err != nil {
 		return fmt.Errorf("failed to write notification: %w", err)
 	}
 
@@ -339,17 +384,72 @@ func (c *StdioClient) Close() error {
 	return nil
 }
 
+// writeMessage writes msg framed as the server expects and flushes it. The
+// caller holds c.mutex.
+func (c *StdioClient) writeMessage(msg []byte) error {
+	if err := c.framing.writeMessage(c.writer, msg); err != nil {
+		return err
+	}
+	return c.writer.Flush()
+}
+
+// readLine reads the server's next message, giving up when ctx is done. A
+// read given up on is kept for the next request, so two reads never race on
+// the server's output. The caller holds c.mutex.
+func (c *StdioClient) readLine(ctx context.Context) ([]byte, error) {
+	if c.pendingRead == nil {
+		read := make(chan lineResult, 1)
+		c.pendingRead = read
+		go func() {
+			line, err := c.framing.readMessage(c.reader, c.limit)
+			if err != nil {
+				err = c.serverExitError(fmt.Errorf("failed to read response: %w", err))
+			}
+			read <- lineResult{line: line, err: err}
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, fmt.Errorf("request cancelled: %w", ctx.Err())
+		}
+		return nil, mcp.Mark(fmt.Errorf("request timeout: %w", ctx.Err()), mcp.ErrTimeout)
+	case result := <-c.pendingRead:
+		c.pendingRead = nil
+		return result.line, result.err
+	}
+}
+
+// notifyCancelled tells the server to stop work on a request the caller gave
+// up on. It is best effort: the server may have finished already. The caller
+// holds c.mutex.
+func (c *StdioClient) notifyCancelled(id interface{}) {
+	data, err := json.Marshal(mcp.NewNotification(mcp.Cancelled, &mcp.CancelledParams{RequestID: id, Reason: cancelReason}))
+	if err != nil {
+		return
+	}
+	_ = c.writeMessage(data)
+}
+
+// lineResult is the outcome of reading one message from the server
+type lineResult struct {
+	line []byte
+	err  error
+}
+
 // sendRequest sends a JSON-RPC request to the stdio server
-func (c *StdioClient) sendRequest(ctx context.Context, req *mcp.JSONRPCRequest) (interface{}, error) {
+func (c *StdioClient) sendRequest(ctx context.Context, req *mcp.JSONRPCRequest) (result json.RawMessage, err error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+	defer func() { err = c.redactor.Error(err) }()
 
 	if c.closed {
 		return nil, fmt.Errorf("client is closed")
 	}
 
-	// Create a context with timeout for the operation
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	// Bound the operation, unless the caller already did
+	ctx, cancel := requestContext(ctx, c.timeout)
 	defer cancel()
 
 	// Marshal the request
@@ -358,44 +458,17 @@ func (c *StdioClient) sendRequest(ctx context.Context, req *mcp.JSONRPCRequest)
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Add newline for JSON-RPC over stdio
-	reqBytes = append(reqBytes, '\n')
-
 	// Send request
-	if _, err := c.writer.Write(reqBytes); err != nil {
-		return nil, fmt.Errorf("failed to write request: %w", err)
-	}
-
-	if err := c.writer.Flush(); err != nil {
-		return nil, fmt.Errorf("failed to flush request: %w", err)
-	}
-
-	readLine := func(ctx context.Context) ([]byte, error) {
-		responseChan := make(chan []byte, 1)
-		errorChan := make(chan error, 1)
-
-		go func() {
-			line, err := c.reader.ReadBytes('\n')
-			if err != nil {
-				errorChan <- fmt.Errorf("failed to read response: %w", err)
-				return
-			}
-			responseChan <- line
-		}()
-
-		select {
-		case <-ctx.Done():
-			return nil, fmt.Errorf("request timeout: %w", ctx.Err())
-		case err := <-errorChan:
-			return nil, err
-		case line := <-responseChan:
-			return line, nil
-		}
+	if err := c.writeMessage(reqBytes); err != nil {
+		return nil, c.serverExitError(fmt.Errorf("failed to write request: %w", err))
 	}
 
 	for {
-		line, err := readLine(ctx)
+		line, err := c.readLine(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				c.notifyCancelled(req.ID)
+			}
 			return nil, err
 		}
 
@@ -409,15 +482,32 @@ func (c *StdioClient) sendRequest(ctx context.Context, req *mcp.JSONRPCRequest)
 			continue
 		}
 
-		// Skip notifications (no id field) -- they are not responses to our request
+		// Requests from the server are answered while ours waits
+		if serverReq := parseServerRequest(line); serverReq != nil {
+			if err := c.answer(ctx, serverReq); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Notifications (no id field) are not responses to our request
 		if rpcResp.ID == nil {
+			if c.notify != nil {
+				var notification struct {
+					Method string          `json:"method"`
+					Params json.RawMessage `json:"params"`
+				}
+				if json.Unmarshal(line, &notification) == nil && notification.Method != "" {
+					c.notify(notification.Method, notification.Params)
+				}
+			}
 			continue
 		}
 
 		if rpcResp.Error != nil {
-			return nil, fmt.Errorf("JSON-RPC error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+			return nil, markServerFailure(rpcResp.Error, rpcResp.Error.Message)
 		}
 
-		return rpcResp.Result, nil
+		return rpcResult(rpcResp), nil
 	}
 }
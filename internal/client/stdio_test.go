@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+func TestStdioCancelNotifiesServer(t *testing.T) {
+	// The server copies what it reads to a file and never answers
+	received := filepath.Join(t.TempDir(), "received")
+	c, err := NewStdio("sh", []string{"-c", "cat > " + received})
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := c.CallTool(ctx, "crawl", nil); err == nil {
+		t.Fatal("expected the call to be cancelled")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, _ := os.ReadFile(received)
+		if strings.Contains(string(data), mcp.Cancelled) {
+			if !strings.Contains(string(data), `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":2,`) {
+				t.Errorf("the notification should name the request and have no id:\n%s", data)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the server was not told the call was cancelled:\n%s", data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStdioCallToolReportsProgress(t *testing.T) {
+	// The server records the call, reports progress on it and answers
+	received := filepath.Join(t.TempDir(), "received")
+	script := `read line; echo "$line" > ` + received + `
+echo '{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"t1","progress":1,"total":2}}'
+echo '{"jsonrpc":"2.0","id":2,"result":{"content":[]}}'
+cat > /dev/null`
+	c, err := NewStdio("sh", []string{"-c", script})
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	var notified []string
+	c.SetNotificationHandler(func(method string, params json.RawMessage) {
+		notified = append(notified, method+" "+string(params))
+	})
+
+	ctx, cancel := context.WithTimeout(mcp.WithProgressToken(context.Background(), "t1"), 5*time.Second)
+	defer cancel()
+	if _, err := c.CallTool(ctx, "crawl", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(received)
+	if !strings.Contains(string(data), `"_meta":{"progressToken":"t1"}`) {
+		t.Errorf("the call should carry the progress token:\n%s", data)
+	}
+	want := mcp.Progress + ` {"progressToken":"t1","progress":1,"total":2}`
+	if len(notified) != 1 || notified[0] != want {
+		t.Errorf("notifications %q, want [%q]", notified, want)
+	}
+}
+
+func TestStdioInitializeSendsPinnedProtocolVersion(t *testing.T) {
+	// The server keeps the initialize request and answers it
+	received := filepath.Join(t.TempDir(), "received")
+	script := `IFS= read -r line; printf '%s\n' "$line" > ` + received + `
+echo '{"jsonrpc":"2.0","id":0,"result":{"protocolVersion":"2024-11-05","serverInfo":{"name":"old","version":"1"}}}'
+cat > /dev/null`
+	c, err := NewStdio("sh", []string{"-c", script}, WithProtocolVersion("2024-11-05"))
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	if _, err := c.Initialize(context.Background(), &mcp.InitializeParams{ProtocolVersion: "2025-06-18"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req struct {
+		Params mcp.InitializeParams `json:"params"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("bad request %s: %v", data, err)
+	}
+	if req.Params.ProtocolVersion != "2024-11-05" {
+		t.Errorf("protocolVersion on the wire = %q, want the pinned 2024-11-05", req.Params.ProtocolVersion)
+	}
+}
+
+func TestStdioCallToolSendsCallToolRequest(t *testing.T) {
+	// The server records the call and answers it
+	received := filepath.Join(t.TempDir(), "received")
+	script := `read line; echo "$line" > ` + received + `
+echo '{"jsonrpc":"2.0","id":2,"result":{"content":[]}}'
+cat > /dev/null`
+	c, err := NewStdio("sh", []string{"-c", script})
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	arguments := map[string]interface{}{"url": "https://example.com", "maxLength": 5000}
+	if _, err := c.CallTool(ctx, "fetch", arguments); err != nil {
+		t.Fatal(err)
+	}
+
+	// What call --print-request shows is what was sent
+	want, _ := mcp.MarshalRequest(mcp.NewCallToolRequest(ctx, "fetch", arguments))
+	if data, _ := os.ReadFile(received); strings.TrimSpace(string(data)) != string(want) {
+		t.Errorf("sent %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// VisibleTools drops the tools hidden by the server's disabledTools. Every
+// listing goes through it so they all agree on what is hidden.
+func VisibleTools(serverConfig config.ServerConfig, tools []mcp.Tool) []mcp.Tool {
+	if len(serverConfig.DisabledTools) == 0 {
+		return tools
+	}
+
+	visible := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if !serverConfig.IsToolHidden(tool.Name) {
+			visible = append(visible, tool)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+func TestVisibleTools(t *testing.T) {
+	tools := []mcp.Tool{
+		{Name: "navigate_page"},
+		{Name: "take_screenshot"},
+		{Name: "performance_start_trace"},
+		{Name: "performance_stop_trace"},
+		{Name: "emulate_cpu"},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{"no patterns", nil, []string{"navigate_page", "take_screenshot", "performance_start_trace", "performance_stop_trace", "emulate_cpu"}},
+		{"exact name", []string{"emulate_cpu"}, []string{"navigate_page", "take_screenshot", "performance_start_trace", "performance_stop_trace"}},
+		{"glob", []string{"performance_*"}, []string{"navigate_page", "take_screenshot", "emulate_cpu"}},
+		{"several patterns", []string{"*_trace", "emulate_?pu", "take_*"}, []string{"navigate_page"}},
+		{"no match", []string{"click"}, []string{"navigate_page", "take_screenshot", "performance_start_trace", "performance_stop_trace", "emulate_cpu"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := config.ServerConfig{Command: "npx", DisabledTools: tt.patterns}
+			var got []string
+			for _, tool := range VisibleTools(server, tools) {
+				got = append(got, tool.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("VisibleTools() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandSubstitutionTimeout bounds each $(command) run during resolution
+const CommandSubstitutionTimeout = 10 * time.Second
+
+// commandResult is the outcome of one $(command) substitution
+type commandResult struct {
+	output string
+	err    error
+}
+
+// commandCache keeps substitution results for the life of the process, so a
+// command referenced by several fields only runs once
+var commandCache = struct {
+	sync.Mutex
+	results map[string]commandResult
+}{results: make(map[string]commandResult)}
+
+// runSubstitution returns the output of command, running it at most once
+func runSubstitution(command string) (string, error) {
+	commandCache.Lock()
+	defer commandCache.Unlock()
+
+	if result, ok := commandCache.results[command]; ok {
+		return result.output, result.err
+	}
+
+	output, err := runSubstitutionCommand(command, CommandSubstitutionTimeout)
+	commandCache.results[command] = commandResult{output: output, err: err}
+	return output, err
+}
+
+// runSubstitutionCommand runs command through the platform shell and returns
+// its stdout without trailing newlines. A non-zero exit or timeout is an error
+// carrying the command's stderr.
+func runSubstitutionCommand(command string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.WaitDelay = time.Second // Don't wait on children that keep the output open
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("command %q timed out after %s", command, timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("command %q failed: %w: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("command %q failed: %w", command, err)
+	}
+
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// matchingParen returns the index of the ')' closing a $( whose command starts
+// at start. Quoted text, escapes and nested parentheses are skipped, so
+// $(printf ')') and $(echo $(whoami)) are read whole.
+func matchingParen(input string, start int) int {
+	depth := 0
+	for i := start; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			i++
+		case '\'':
+			end := strings.IndexByte(input[i+1:], '\'')
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+		case '"':
+			for i++; i < len(input) && input[i] != '"'; i++ {
+				if input[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(input) {
+				return -1
+			}
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
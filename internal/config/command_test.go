@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func skipWithoutShell(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("command substitution tests use sh syntax")
+	}
+}
+
+func TestMatchingParen(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"echo hi)", 7},
+		{"echo $(whoami))", 14},
+		{"printf ')')", 10},
+		{`printf ")")`, 10},
+		{`printf "\")")`, 12},
+		{`echo \))`, 7},
+		{"(cd /tmp && pwd))", 16},
+		{"echo hi", -1},
+		{"printf ')", -1},
+	}
+
+	for _, tt := range tests {
+		if got := matchingParen(tt.input, 0); got != tt.want {
+			t.Errorf("matchingParen(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestExpandWithCommands(t *testing.T) {
+	skipWithoutShell(t)
+	t.Setenv("MCP_TEST_TOKEN", "secret")
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"header value", "Bearer $(printf 'tok\\n\\n')", "Bearer tok"},
+		{"nested command", "$(echo $(echo nested))", "nested"},
+		{"quoted parenthesis", "$(printf ')')", ")"},
+		{"double-quoted parenthesis", `$(printf "%s" "a)b")`, "a)b"},
+		{"environment inside command", "$(echo $MCP_TEST_TOKEN)", "secret"},
+		{"mixed with variables", "${MCP_TEST_TOKEN}:$(echo cmd)", "secret:cmd"},
+		{"command in default", "${MCP_TEST_UNSET:-$(echo fallback)}", "fallback"},
+		{"escaped", "$$(echo hi)", "$(echo hi)"},
+		{"unterminated", "$(echo hi", "$(echo hi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandWithCommands(tt.input)
+			if err != nil {
+				t.Fatalf("ExpandWithCommands(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandWithCommands(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	// Without opt-in, commands are kept literally
+	if got, err := ExpandVariables("Bearer $(echo tok)"); err != nil || got != "Bearer $(echo tok)" {
+		t.Errorf("expected literal command without opt-in, got %q (%v)", got, err)
+	}
+}
+
+func TestExpandWithCommandsFailure(t *testing.T) {
+	skipWithoutShell(t)
+
+	_, err := ExpandWithCommands("Bearer $(echo 'vault locked' >&2; exit 3)")
+	if err == nil || !strings.Contains(err.Error(), "vault locked") {
+		t.Fatalf("expected error with stderr, got %v", err)
+	}
+
+	_, err = runSubstitutionCommand("sleep 5", 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
+
+func TestCommandSubstitutionIsCached(t *testing.T) {
+	skipWithoutShell(t)
+
+	counter := filepath.Join(t.TempDir(), "runs")
+	command := fmt.Sprintf("$(echo run >> %s; echo token)", counter)
+
+	for i := 0; i < 3; i++ {
+		if got, err := ExpandWithCommands(command); err != nil || got != "token" {
+			t.Fatalf("ExpandWithCommands failed: %q (%v)", got, err)
+		}
+	}
+
+	data, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := strings.Count(string(data), "run"); runs != 1 {
+		t.Errorf("expected the command to run once, ran %d times", runs)
+	}
+}
+
+func TestLoadConfigCommandSubstitutionOptIn(t *testing.T) {
+	skipWithoutShell(t)
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mcp_servers.json")
+	server := `{"type": "http", "url": "https://mcp.context7.com/mcp", "headers": {"Authorization": "Bearer $(echo opt-in-token)"}}`
+
+	writeFile(t, configPath, `{"mcpServers": {"context7": `+server+`}}`)
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := cfg.MCPServers["context7"].Headers["Authorization"]; got != "Bearer $(echo opt-in-token)" {
+		t.Errorf("expected command to be kept without opt-in, got %q", got)
+	}
+
+	cfg, err = LoadConfigWithOptions(configPath, LoadOptions{AllowExec: true})
+	if err != nil {
+		t.Fatalf("LoadConfigWithOptions failed: %v", err)
+	}
+	if got := cfg.MCPServers["context7"].Headers["Authorization"]; got != "Bearer opt-in-token" {
+		t.Errorf("expected --allow-exec to run the command, got %q", got)
+	}
+
+	writeFile(t, configPath, `{"allowCommandSubstitution": true, "mcpServers": {"context7": `+server+`}}`)
+	cfg, err = LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	context7 := cfg.MCPServers["context7"]
+	if got := context7.Headers["Authorization"]; got != "Bearer opt-in-token" {
+		t.Errorf("expected allowCommandSubstitution to run the command, got %q", got)
+	}
+	if got := context7.Unresolved().Headers["Authorization"]; got != "Bearer $(echo opt-in-token)" {
+		t.Errorf("expected the unresolved header to keep the command, got %q", got)
+	}
+
+	// A project-local config can't enable substitution or benefit from the global opt-in
+	localPath := filepath.Join(dir, LocalConfigFileName)
+	writeFile(t, localPath, `{"allowCommandSubstitution": true, "mcpServers": {"local": {"command": "$(echo local-command)"}}}`)
+	cfg, err = LoadConfigWithOptions(configPath, LoadOptions{LocalConfig: localPath})
+	if err != nil {
+		t.Fatalf("LoadConfigWithOptions failed: %v", err)
+	}
+	if got := cfg.MCPServers["context7"].Headers["Authorization"]; got != "Bearer $(echo opt-in-token)" {
+		t.Errorf("expected substitution to be disabled with a local config, got %q", got)
+	}
+	if len(cfg.Conflicts) == 0 || !strings.Contains(cfg.Conflicts[0], "--allow-exec") {
+		t.Errorf("expected a note about the ignored opt-in, got %q", cfg.Conflicts)
+	}
+}
@@ -3,10 +3,14 @@ package config
 import (
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"sort"
+	"strings"
 )
 
 // Embedded example configuration - keep in sync with root mcp_servers.example.json
@@ -14,8 +18,61 @@ import (
 //go:embed mcp_servers.example.json
 var exampleConfigJSON []byte
 
-// GetConfigDir returns the standard configuration directory for the current platform
+// ConfigDirEnv names the environment variable that overrides the configuration directory
+const ConfigDirEnv = "MCP_CLI_CONFIG_DIR"
+
+// GetConfigDir returns the directory holding the configuration, sessions and
+// daemon files: $MCP_CLI_CONFIG_DIR when set, otherwise mcp-cli-ent inside
+// the user configuration directory ($XDG_CONFIG_HOME or ~/.config on Linux
+// and macOS, %APPDATA% on Windows)
 func GetConfigDir() (string, error) {
+	if dir := os.Getenv(ConfigDirEnv); dir != "" {
+		return dir, nil
+	}
+
+	if base, err := userConfigDir(); err == nil {
+		return filepath.Join(base, "mcp-cli-ent"), nil
+	}
+
+	return fallbackConfigDir()
+}
+
+// DataDirEnv names the environment variable that moves what mcp-cli-ent
+// writes as it runs out of the configuration directory. The CLI sets it
+// from --data-dir, so the daemon it starts inherits it.
+const DataDirEnv = "MCP_CLI_DATA_DIR"
+
+// GetDataDir returns the directory holding sessions, caches, tool snapshots,
+// the audit log and the daemon's PID, log and socket files: $MCP_CLI_DATA_DIR
+// when set, otherwise the configuration directory
+func GetDataDir() (string, error) {
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return dir, nil
+	}
+	return GetConfigDir()
+}
+
+// userConfigDir returns the user configuration directory. Unlike
+// os.UserConfigDir, macOS uses $XDG_CONFIG_HOME or ~/.config rather than
+// ~/Library/Application Support, where the configuration has always lived.
+func userConfigDir() (string, error) {
+	if runtime.GOOS != "darwin" {
+		return os.UserConfigDir()
+	}
+
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config"), nil
+}
+
+// fallbackConfigDir derives the configuration directory from the profile
+// environment variables when the user configuration directory is unknown
+func fallbackConfigDir() (string, error) {
 	if runtime.GOOS == "windows" {
 		// Windows: %USERPROFILE%\AppData\Roaming\mcp-cli-ent
 		appData := os.Getenv("APPDATA")
@@ -113,37 +170,133 @@ func EnsureConfigDirectory() error {
 	return nil
 }
 
+// LoadOptions controls how LoadConfigWithOptions prepares the environment
+type LoadOptions struct {
+	EnvFile     string // Overrides the configuration's envFile when set
+	EnvOverride bool   // Let env file values replace existing environment variables
+	LocalConfig string // Project config merged over the main one, if set
+	AllowExec   bool   // Run $(command) substitutions regardless of the configuration
+}
+
 // LoadConfig loads configuration from a JSON file
 func LoadConfig(configPath string) (*Configuration, error) {
-	// Check if file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, &ConfigError{fmt.Sprintf("configuration file '%s' not found", configPath)}
-	}
+	return LoadConfigWithOptions(configPath, LoadOptions{})
+}
 
-	// Read file
-	data, err := os.ReadFile(configPath)
+// LoadConfigWithOptions loads configuration from a JSON file, merging the
+// local project config over it and loading the .env file (if any) before
+// environment variables are resolved
+func LoadConfigWithOptions(configPath string, opts LoadOptions) (*Configuration, error) {
+	file, err := readConfigFile(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+		return nil, err
 	}
 
-	// Parse JSON
-	var config Configuration
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse configuration file: %w", err)
+	var sources map[string]string
+	var conflicts []string
+	if opts.LocalConfig != "" && !sameFile(opts.LocalConfig, configPath) {
+		local, err := readConfigFile(opts.LocalConfig)
+		if err != nil {
+			return nil, err
+		}
+		if file, sources, conflicts, err = mergeConfigFiles(file, local); err != nil {
+			return nil, fmt.Errorf("failed to merge '%s': %w", opts.LocalConfig, err)
+		}
+	}
+
+	config := Configuration{
+		EnvFile:     file.EnvFile,
+		EnvOverride: file.EnvOverride,
+		Conflicts:   conflicts,
+
+		AllowCommandSubstitution: file.AllowCommandSubstitution,
+		ConfigWatch:              file.ConfigWatch,
+		ToolCacheTTL:             file.ToolCacheTTL,
+		ResultCacheMaxMB:         file.ResultCacheMaxMB,
+		MaxResponseMB:            file.MaxResponseMB,
+		Serve:                    file.Serve,
+		Exports:                  file.Exports,
+		Audit:                    file.Audit,
+		Daemon:                   file.Daemon,
+	}
+
+	for name, raw := range file.Templates {
+		var template ServerConfig
+		if err := json.Unmarshal(raw, &template); err != nil {
+			return nil, fmt.Errorf("failed to parse template '%s': %w", name, err)
+		}
+		if config.Templates == nil {
+			config.Templates = make(map[string]ServerConfig, len(file.Templates))
+		}
+		config.Templates[name] = template
+	}
+
+	// Servers are validated with their templates applied
+	servers, err := expandTemplates(file.MCPServers, file.Templates)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if file.MCPServers != nil {
+		config.MCPServers = make(map[string]ServerConfig, len(servers))
+	}
+	var issues []ValidationIssue
+	for name, raw := range servers {
+		var server ServerConfig
+		if err := json.Unmarshal(raw, &server); err != nil {
+			return nil, fmt.Errorf("failed to parse server '%s': %w", name, err)
+		}
+		for _, field := range unknownFields(raw, reflect.TypeOf(server)) {
+			issues = append(issues, ValidationIssue{Server: name, Field: field, Message: "unknown field"})
+		}
+		server.Source = configPath
+		if source, ok := sources[name]; ok {
+			server.Source = source
+		}
+		config.MCPServers[name] = server
 	}
 
-	// Validate configuration
-	if err := ValidateConfig(&config); err != nil {
+	// Validate configuration, reporting every problem at once
+	if config.MCPServers == nil {
+		return nil, fmt.Errorf("invalid configuration: %w", &ConfigError{"no MCP servers configured"})
+	}
+	issues = append(issues, validateServers(config.MCPServers)...)
+	issues = append(issues, config.settingsIssues()...)
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Server < issues[j].Server })
+	if err := validationError(issues); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	// Resolve environment variables in headers, env, and args
-	for name, server := range config.MCPServers {
-		server.ResolveHeaders()
-		server.ResolveEnv()
-		server.ResolveArgs()
+	// Load the .env file so its variables are available for resolution
+	if envFile := resolveEnvFilePath(opts.EnvFile, config.EnvFile, configPath); envFile != "" {
+		if _, err := LoadEnvFile(envFile, opts.EnvOverride || config.EnvOverride); err != nil {
+			return nil, err
+		}
+	}
+
+	// Resolve environment variables in headers, env, args and initialize
+	// options, collecting every required variable that is missing so they are
+	// reported at once
+	var errs []error
+	allowCommands := opts.AllowExec || config.AllowCommandSubstitution
+	for _, name := range config.GetServerNames() {
+		server := config.MCPServers[name]
+		server.allowCommands = allowCommands
+		server.checkRequires()
+		// A server missing required variables is disabled rather than fatal;
+		// any other failure to resolve its settings still is
+		if err := errors.Join(server.ResolveHeaders(), server.ResolveEnv(), server.ResolveArgs(), server.ResolveInitializeOptions()); err != nil {
+			errs = append(errs, fmt.Errorf("server '%s': %w", name, err))
+		}
 		config.MCPServers[name] = server
 	}
+	if token, err := expandValue(config.Serve.Token, allowCommands); err != nil {
+		errs = append(errs, fmt.Errorf("serve token: %w", err))
+	} else {
+		config.Serve.Token = token
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("missing required environment variables:\n%w", errors.Join(errs...))
+	}
 
 	return &config, nil
 }
@@ -178,19 +331,16 @@ func FindConfigFile() (string, error) {
 	return "", &ConfigError{"no configuration file found in standard locations"}
 }
 
-// ValidateConfig validates the entire configuration
+// ValidateConfig validates the entire configuration, returning a
+// *ValidationError that lists every problem found
 func ValidateConfig(config *Configuration) error {
 	if config.MCPServers == nil {
 		return &ConfigError{"no MCP servers configured"}
 	}
 
-	for name, server := range config.MCPServers {
-		if err := server.Validate(); err != nil {
-			return fmt.Errorf("server '%s': %w", name, err)
-		}
-	}
-
-	return nil
+	issues := validateServers(config.MCPServers)
+	issues = append(issues, config.settingsIssues()...)
+	return validationError(issues)
 }
 
 // GetServerNames returns a list of all configured server names
@@ -219,6 +369,45 @@ func (c *Configuration) GetServer(name string) (ServerConfig, bool) {
 	return server, exists
 }
 
+// GetTags returns the sorted, de-duplicated tags used by any server
+func (c *Configuration) GetTags() []string {
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, name := range sortedKeys(c.MCPServers) {
+		for _, tag := range c.MCPServers[name].Tags {
+			key := strings.ToLower(tag)
+			if !seen[key] {
+				seen[key] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// WithGroup returns a copy of the configuration restricted to servers tagged
+// with group. An unknown group is an error listing the available tags.
+func (c *Configuration) WithGroup(group string) (*Configuration, error) {
+	filtered := *c
+	filtered.MCPServers = make(map[string]ServerConfig)
+	for name, server := range c.MCPServers {
+		if server.HasTag(group) {
+			filtered.MCPServers[name] = server
+		}
+	}
+
+	if len(filtered.MCPServers) == 0 {
+		tags := c.GetTags()
+		if len(tags) == 0 {
+			return nil, &ConfigError{fmt.Sprintf("unknown group '%s': no servers have tags", group)}
+		}
+		return nil, &ConfigError{fmt.Sprintf("unknown group '%s' (available: %s)", group, strings.Join(tags, ", "))}
+	}
+
+	return &filtered, nil
+}
+
 // GetServerStatus returns the status of all servers
 func (c *Configuration) GetServerStatus() []ServerStatus {
 	statuses := make([]ServerStatus, 0, len(c.MCPServers))
@@ -228,12 +417,15 @@ func (c *Configuration) GetServerStatus() []ServerStatus {
 			Name:    name,
 			Type:    server.GetServerType(),
 			Details: server.GetServerDetails(),
+			Source:  server.Source,
+			Tags:    server.Tags,
 		}
 
 		if server.IsEnabled() {
 			status.Status = "enabled"
 		} else {
 			status.Status = "disabled"
+			status.Reason = server.DisabledReason()
 		}
 
 		statuses = append(statuses, status)
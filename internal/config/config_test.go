@@ -0,0 +1,188 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWithGroup(t *testing.T) {
+	cfg := &Configuration{MCPServers: map[string]ServerConfig{
+		"context7":        {URL: "https://mcp.context7.com/mcp", Tags: []string{"docs", "critical"}},
+		"deepwiki":        {URL: "https://mcp.deepwiki.com/mcp", Tags: []string{"Docs"}},
+		"chrome-devtools": {Command: "npx", Tags: []string{"browser"}},
+		"time":            {Command: "uvx"},
+	}}
+
+	if got := cfg.GetTags(); !reflect.DeepEqual(got, []string{"browser", "critical", "docs"}) {
+		t.Errorf("unexpected tags %v", got)
+	}
+
+	docs, err := cfg.WithGroup("docs")
+	if err != nil {
+		t.Fatalf("WithGroup failed: %v", err)
+	}
+	if got := sortedKeys(docs.MCPServers); !reflect.DeepEqual(got, []string{"context7", "deepwiki"}) {
+		t.Errorf("expected docs servers, got %v", got)
+	}
+	if len(cfg.MCPServers) != 4 {
+		t.Error("WithGroup modified the original configuration")
+	}
+
+	_, err = cfg.WithGroup("search")
+	if err == nil {
+		t.Fatal("expected an error for an unknown group")
+	}
+	if !strings.Contains(err.Error(), "browser") || !strings.Contains(err.Error(), "critical") {
+		t.Errorf("expected available tags in error, got %v", err)
+	}
+}
+
+func TestGetConfigDir(t *testing.T) {
+	override := t.TempDir()
+	t.Setenv(ConfigDirEnv, override)
+	if dir, err := GetConfigDir(); err != nil || dir != override {
+		t.Errorf("expected %s override to win, got %q (%v)", ConfigDirEnv, dir, err)
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("XDG and HOME handling is Unix-specific")
+	}
+	t.Setenv(ConfigDirEnv, "")
+
+	// XDG_CONFIG_HOME is honored, even without HOME
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("HOME", "")
+	if dir, err := GetConfigDir(); err != nil || dir != filepath.Join(xdg, "mcp-cli-ent") {
+		t.Errorf("expected XDG_CONFIG_HOME to be used, got %q (%v)", dir, err)
+	}
+
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", home)
+	if dir, err := GetConfigDir(); err != nil || dir != filepath.Join(home, ".config", "mcp-cli-ent") {
+		t.Errorf("expected ~/.config to be used, got %q (%v)", dir, err)
+	}
+
+	t.Setenv("HOME", "")
+	if dir, err := GetConfigDir(); err == nil {
+		t.Errorf("expected an error without HOME or XDG_CONFIG_HOME, got %q", dir)
+	}
+}
+
+func TestGetDataDir(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv(ConfigDirEnv, configDir)
+	t.Setenv(DataDirEnv, "")
+	if dir, err := GetDataDir(); err != nil || dir != configDir {
+		t.Errorf("without %s the data directory should be the configuration directory, got %q (%v)", DataDirEnv, dir, err)
+	}
+
+	dataDir := t.TempDir()
+	t.Setenv(DataDirEnv, dataDir)
+	if dir, err := GetDataDir(); err != nil || dir != dataDir {
+		t.Errorf("expected %s override to win, got %q (%v)", DataDirEnv, dir, err)
+	}
+	if dir, _ := GetConfigDir(); dir != configDir {
+		t.Errorf("%s should not move the configuration directory, got %q", DataDirEnv, dir)
+	}
+}
+
+func TestLoadConfigRequires(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mcp_servers.json")
+	writeFile(t, configPath, `{
+  "envFile": "servers.env",
+  "mcpServers": {
+    "context7": {
+      "url": "https://mcp.context7.com/mcp",
+      "headers": {"CONTEXT7_API_KEY": "${MCP_TEST_CONTEXT7_KEY:?set the Context7 key}"},
+      "requires": ["MCP_TEST_CONTEXT7_KEY"]
+    },
+    "deepwiki": {"url": "https://mcp.deepwiki.com/mcp", "requires": ["MCP_TEST_DEEPWIKI_TOKEN"]},
+    "time": {"command": "uvx", "args": ["mcp-server-time"], "enabled": false, "requires": ["MCP_TEST_DEEPWIKI_TOKEN"]}
+  }
+}`)
+	writeFile(t, filepath.Join(dir, "servers.env"), "MCP_TEST_DEEPWIKI_TOKEN=abc\n")
+	t.Setenv("MCP_TEST_CONTEXT7_KEY", "")
+	t.Setenv("MCP_TEST_DEEPWIKI_TOKEN", "")
+
+	// The missing key disables context7 instead of failing the whole load
+	cfg, err := LoadConfigWithOptions(configPath, LoadOptions{EnvOverride: true})
+	if err != nil {
+		t.Fatalf("LoadConfigWithOptions failed: %v", err)
+	}
+
+	context7 := cfg.MCPServers["context7"]
+	if context7.IsEnabled() {
+		t.Error("expected context7 to be disabled by its missing requirement")
+	}
+	if got := context7.MissingRequired(); !reflect.DeepEqual(got, []string{"MCP_TEST_CONTEXT7_KEY"}) {
+		t.Errorf("unexpected missing requirements: %v", got)
+	}
+
+	deepwiki := cfg.MCPServers["deepwiki"]
+	if !deepwiki.IsEnabled() || deepwiki.DisabledReason() != "" {
+		t.Errorf("expected deepwiki to be enabled by the env file, got reason %q", deepwiki.DisabledReason())
+	}
+
+	timeServer := cfg.MCPServers["time"]
+	if timeServer.IsEnabled() || timeServer.DisabledReason() != "disabled in config" {
+		t.Errorf("expected time to stay disabled in config, got reason %q", timeServer.DisabledReason())
+	}
+
+	reasons := make(map[string]string)
+	for _, status := range cfg.GetServerStatus() {
+		reasons[status.Name] = status.Status + ": " + status.Reason
+	}
+	want := map[string]string{
+		"context7": "disabled: missing MCP_TEST_CONTEXT7_KEY",
+		"deepwiki": "enabled: ",
+		"time":     "disabled: disabled in config",
+	}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("GetServerStatus() = %v, want %v", reasons, want)
+	}
+	if _, ok := cfg.GetEnabledServers()["context7"]; ok {
+		t.Error("expected context7 to be left out of the enabled servers")
+	}
+
+	// Only the required variables are excused; another missing one still fails the load
+	writeFile(t, configPath, `{
+  "mcpServers": {
+    "context7": {
+      "url": "https://mcp.context7.com/mcp",
+      "headers": {
+        "CONTEXT7_API_KEY": "${MCP_TEST_CONTEXT7_KEY:?set the Context7 key}",
+        "X-Team": "${MCP_TEST_CONTEXT7_TEAM:?set the Context7 team}"
+      },
+      "requires": ["MCP_TEST_CONTEXT7_KEY"]
+    }
+  }
+}`)
+	_, err = LoadConfigWithOptions(configPath, LoadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "set the Context7 team") || strings.Contains(err.Error(), "set the Context7 key") {
+		t.Errorf("want only the team variable reported, got %v", err)
+	}
+}
+
+func TestLoadConfigResolvesServeToken(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mcp_servers.json")
+	writeFile(t, configPath, `{
+  "mcpServers": {"time": {"command": "uvx", "args": ["mcp-server-time"]}},
+  "serve": {"token": "${MCP_TEST_SERVE_TOKEN}"}
+}`)
+	t.Setenv("MCP_TEST_SERVE_TOKEN", "s3cret")
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Serve.Token != "s3cret" {
+		t.Errorf("serve token = %q, want s3cret", cfg.Serve.Token)
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"path"
+	"time"
+)
+
+// ToolDefaultsWildcard keys the defaults applied to every tool of a server
+const ToolDefaultsWildcard = "*"
+
+// DefaultsFor returns the default arguments for toolName: the wildcard
+// defaults overlaid with the tool's own. It returns nil when none apply.
+func (c *ServerConfig) DefaultsFor(toolName string) map[string]interface{} {
+	wildcard := c.ToolDefaults[ToolDefaultsWildcard]
+	specific := c.ToolDefaults[toolName]
+	if len(wildcard) == 0 && len(specific) == 0 {
+		return nil
+	}
+	return deepMerge(wildcard, specific)
+}
+
+// ApplyToolDefaults merges the defaults for toolName under args. Nested
+// objects are merged recursively; arrays, scalars and nulls given in args
+// replace the defaults. args itself is not modified.
+func (c *ServerConfig) ApplyToolDefaults(toolName string, args map[string]interface{}) map[string]interface{} {
+	defaults := c.DefaultsFor(toolName)
+	if defaults == nil {
+		return args
+	}
+	return deepMerge(defaults, args)
+}
+
+// IsToolHidden reports whether toolName matches one of the disabledTools
+// glob patterns. Hidden tools are left out of listings but can still be called.
+func (c *ServerConfig) IsToolHidden(toolName string) bool {
+	for _, pattern := range c.DisabledTools {
+		if matched, _ := path.Match(pattern, toolName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ToolCacheConfig caches the results of a tool
+type ToolCacheConfig struct {
+	TTL string `json:"ttl"` // How long a result is reused, e.g. "1h" or "1d"
+}
+
+// ResultTTL returns how long results of toolName are cached, or zero if they
+// are not. A tool listed in cacheTools is cached as listed; the "*" entry
+// applies to the other tools only when readOnly, meaning the server
+// annotates the tool with readOnlyHint.
+func (c *ServerConfig) ResultTTL(toolName string, readOnly bool) time.Duration {
+	cache, listed := c.CacheTools[toolName]
+	if !listed {
+		if cache, listed = c.CacheTools[ToolDefaultsWildcard]; !listed || !readOnly {
+			return 0
+		}
+	}
+	ttl, err := ParseRetention(cache.TTL)
+	if err != nil {
+		return 0
+	}
+	return ttl
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApplyToolDefaults(t *testing.T) {
+	server := ServerConfig{
+		Command: "npx",
+		ToolDefaults: map[string]map[string]interface{}{
+			"*": {
+				"timeout": float64(30),
+				"options": map[string]interface{}{"headless": true, "viewport": "desktop"},
+			},
+			"take_screenshot": {
+				"format":  "png",
+				"options": map[string]interface{}{"fullPage": true},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			name: "wildcard only",
+			tool: "navigate_page",
+			args: map[string]interface{}{"url": "https://example.com"},
+			want: map[string]interface{}{
+				"url":     "https://example.com",
+				"timeout": float64(30),
+				"options": map[string]interface{}{"headless": true, "viewport": "desktop"},
+			},
+		},
+		{
+			name: "tool defaults merge over wildcard",
+			tool: "take_screenshot",
+			args: map[string]interface{}{},
+			want: map[string]interface{}{
+				"timeout": float64(30),
+				"format":  "png",
+				"options": map[string]interface{}{"headless": true, "viewport": "desktop", "fullPage": true},
+			},
+		},
+		{
+			name: "user values win and nested objects merge",
+			tool: "take_screenshot",
+			args: map[string]interface{}{
+				"format":  "jpeg",
+				"options": map[string]interface{}{"headless": false},
+			},
+			want: map[string]interface{}{
+				"timeout": float64(30),
+				"format":  "jpeg",
+				"options": map[string]interface{}{"headless": false, "viewport": "desktop", "fullPage": true},
+			},
+		},
+		{
+			name: "user scalar replaces default object",
+			tool: "navigate_page",
+			args: map[string]interface{}{"options": nil},
+			want: map[string]interface{}{
+				"timeout": float64(30),
+				"options": nil,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := server.ApplyToolDefaults(tt.tool, tt.args)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyToolDefaults() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	plain := ServerConfig{Command: "uvx"}
+	args := map[string]interface{}{"timezone": "UTC"}
+	if got := plain.ApplyToolDefaults("get_current_time", args); !reflect.DeepEqual(got, args) {
+		t.Errorf("expected args unchanged without defaults, got %v", got)
+	}
+	if got := plain.DefaultsFor("get_current_time"); got != nil {
+		t.Errorf("expected no defaults, got %v", got)
+	}
+}
+
+func TestResultTTL(t *testing.T) {
+	server := ServerConfig{
+		Command: "npx",
+		CacheTools: map[string]ToolCacheConfig{
+			"get-library-docs": {TTL: "1h"},
+			"*":                {TTL: "1d"},
+		},
+	}
+
+	tests := []struct {
+		tool     string
+		readOnly bool
+		want     time.Duration
+	}{
+		{"get-library-docs", false, time.Hour},
+		{"get-library-docs", true, time.Hour},
+		{"resolve-library-id", true, 24 * time.Hour},
+		{"resolve-library-id", false, 0},
+	}
+	for _, tt := range tests {
+		if got := server.ResultTTL(tt.tool, tt.readOnly); got != tt.want {
+			t.Errorf("ResultTTL(%s, %v) = %s, want %s", tt.tool, tt.readOnly, got, tt.want)
+		}
+	}
+
+	if got := (&ServerConfig{Command: "npx"}).ResultTTL("get-library-docs", true); got != 0 {
+		t.Errorf("without cacheTools nothing is cached, got %s", got)
+	}
+}
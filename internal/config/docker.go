@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// ServerTypeDocker is the type of servers run as containers
+const ServerTypeDocker = "docker"
+
+// DefaultContainerRuntime runs docker servers unless runtime says otherwise
+const DefaultContainerRuntime = "docker"
+
+// IsDocker reports whether the server runs in a container
+func (c *ServerConfig) IsDocker() bool {
+	return c.Type == ServerTypeDocker
+}
+
+// ContainerRuntime returns the container runtime binary for docker servers
+func (c *ServerConfig) ContainerRuntime() string {
+	if c.Runtime == "" {
+		return DefaultContainerRuntime
+	}
+	return c.Runtime
+}
+
+// CheckRuntime reports an error when the program a docker or ssh server is
+// launched with is not installed
+func (c *ServerConfig) CheckRuntime() error {
+	switch {
+	case c.IsDocker():
+		if _, err := exec.LookPath(c.ContainerRuntime()); err != nil {
+			return fmt.Errorf("container runtime '%s' not found in PATH", c.ContainerRuntime())
+		}
+	case c.IsSSH():
+		if _, err := exec.LookPath(SSHCommand); err != nil {
+			return fmt.Errorf("'%s' not found in PATH", SSHCommand)
+		}
+	}
+	return nil
+}
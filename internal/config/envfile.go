@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultEnvFile is loaded from the working directory when no env file is configured
+const DefaultEnvFile = ".env"
+
+// envKeyPattern matches valid environment variable names
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseEnvFile parses .env content. It supports comments, an optional
+// "export" prefix, and single- or double-quoted values; double-quoted values
+// understand \n, \t, \" and \\ escapes.
+func ParseEnvFile(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+
+		key = strings.TrimSpace(key)
+		if !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", lineNum, key)
+		}
+
+		parsed, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		values[key] = parsed
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	return values, nil
+}
+
+// parseEnvValue unquotes a value and strips trailing comments from unquoted ones
+func parseEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch value[0] {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		return value[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch c := value[i]; {
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(value[i])
+				}
+			case c == '"':
+				return b.String(), nil
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated quoted value")
+	}
+
+	// Unquoted values end at an inline comment
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = value[:idx]
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// LoadEnvFile sets environment variables from a .env file. Variables already
+// set in the process environment are kept unless override is true. It returns
+// the names of the variables that were set.
+func LoadEnvFile(path string, override bool) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	values, err := ParseEnvFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse env file '%s': %w", path, err)
+	}
+
+	var loaded []string
+	for key, value := range values {
+		if _, exists := os.LookupEnv(key); exists && !override {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		loaded = append(loaded, key)
+	}
+
+	sort.Strings(loaded)
+	return loaded, nil
+}
+
+// resolveEnvFilePath picks the env file to load: an explicit path wins over the
+// configuration's envFile (relative to the config file), which wins over a .env
+// in the working directory. It returns "" when the default file does not exist.
+func resolveEnvFilePath(explicit, configured, configPath string) string {
+	if explicit != "" {
+		return explicit
+	}
+
+	if configured != "" {
+		if !filepath.IsAbs(configured) {
+			configured = filepath.Join(filepath.Dir(configPath), configured)
+		}
+		return configured
+	}
+
+	if _, err := os.Stat(DefaultEnvFile); err == nil {
+		return DefaultEnvFile
+	}
+	return ""
+}
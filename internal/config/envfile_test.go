@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	content := `# Context7 credentials
+export CONTEXT7_API_KEY=ctx7sk-abc123
+PLAIN=value # trailing comment
+SINGLE='literal $HOME # not a comment'
+DOUBLE="line one\nline \"two\""
+EMPTY=
+
+  SPACED = padded
+`
+
+	got, err := ParseEnvFile(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseEnvFile failed: %v", err)
+	}
+
+	want := map[string]string{
+		"CONTEXT7_API_KEY": "ctx7sk-abc123",
+		"PLAIN":            "value",
+		"SINGLE":           "literal $HOME # not a comment",
+		"DOUBLE":           "line one\nline \"two\"",
+		"EMPTY":            "",
+		"SPACED":           "padded",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	for _, bad := range []string{"NO_EQUALS", "1BAD=x", `OPEN="unterminated`} {
+		if _, err := ParseEnvFile(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestLoadConfigResolvesFromEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MCP_TEST_SET_KEY", "from-process")
+
+	envPath := filepath.Join(dir, "secrets.env")
+	envContent := "MCP_TEST_FILE_KEY=from-file\nMCP_TEST_SET_KEY=from-file\n"
+	if err := os.WriteFile(envPath, []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Unset after the test; values loaded from the file are not tracked by t.Setenv
+	t.Setenv("MCP_TEST_FILE_KEY", "")
+	_ = os.Unsetenv("MCP_TEST_FILE_KEY")
+
+	configPath := filepath.Join(dir, "mcp_servers.json")
+	configJSON := `{"envFile": "secrets.env", "mcpServers": {"context7": {
+		"url": "https://mcp.context7.com/mcp",
+		"headers": {"A": "${MCP_TEST_FILE_KEY}", "B": "${MCP_TEST_SET_KEY}", "C": "${MCP_TEST_MISSING_KEY}"}
+	}}}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	headers := cfg.MCPServers["context7"].Headers
+	if headers["A"] != "from-file" || headers["B"] != "from-process" {
+		t.Errorf("expected file value without overriding the process, got %v", headers)
+	}
+
+	server := cfg.MCPServers["context7"]
+	if got := server.UnresolvedVariables(); !reflect.DeepEqual(got, []string{"MCP_TEST_MISSING_KEY"}) {
+		t.Errorf("expected MCP_TEST_MISSING_KEY to be unresolved, got %v", got)
+	}
+
+	// The override option lets the file win
+	cfg, err = LoadConfigWithOptions(configPath, LoadOptions{EnvFile: envPath, EnvOverride: true})
+	if err != nil {
+		t.Fatalf("LoadConfigWithOptions failed: %v", err)
+	}
+	if got := cfg.MCPServers["context7"].Headers["B"]; got != "from-file" {
+		t.Errorf("expected override to use the file value, got %q", got)
+	}
+}
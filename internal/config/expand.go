@@ -0,0 +1,238 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// expansion collects the outcome of expanding one or more values
+type expansion struct {
+	missing []string // Variables left unexpanded because they are unset or empty
+	errs    []error  // Failed ${VAR:?message} markers and $(command) substitutions
+
+	allowCommands bool     // Run $(command) substitutions instead of keeping them literally
+	excused       []string // Variables whose failed ${VAR:?message} markers aren't errors
+}
+
+// ExpandVariables substitutes environment variables in input, with shell-like
+// semantics:
+//
+//	$VAR, ${VAR}       value of VAR; kept literally when unset
+//	${VAR:-default}    default (itself expanded) when VAR is unset or empty
+//	${VAR:?message}    error with message when VAR is unset or empty
+//	$$                 a literal $
+//	%VAR%              value of VAR, as written on Windows
+//	%%                 a literal %
+//
+// Every failed ${VAR:?message} is reported, not just the first. $(command)
+// references are kept literally; see ExpandWithCommands. A %VAR% whose
+// variable is unset, or whose name is two hex digits, is kept literally and
+// not reported, so URL-encoded text such as %20 or %C3%A9 passes through.
+func ExpandVariables(input string) (string, error) {
+	return expandValue(input, false)
+}
+
+// ExpandWithCommands is ExpandVariables with command substitution: each
+// $(command) is run through the shell and replaced by its output, without
+// trailing newlines. A failing or timed out command is an error.
+func ExpandWithCommands(input string) (string, error) {
+	return expandValue(input, true)
+}
+
+// ExpandArguments returns a copy of a tool call's arguments with every string
+// value, however deeply nested in objects and arrays, expanded as
+// ExpandVariables does. Keys and other values are kept as they are. Every
+// failed ${VAR:?message} is reported.
+func ExpandArguments(args map[string]interface{}) (map[string]interface{}, error) {
+	var exp expansion
+	expanded, _ := mapStrings(args, exp.expand).(map[string]interface{})
+	return expanded, errors.Join(exp.errs...)
+}
+
+func expandValue(input string, allowCommands bool) (string, error) {
+	exp := expansion{allowCommands: allowCommands}
+	result := exp.expand(input)
+	return result, errors.Join(exp.errs...)
+}
+
+// expand performs the substitution, recording missing variables and failures
+func (exp *expansion) expand(input string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(input); i++ {
+		if input[i] == '%' {
+			i = exp.expandPercent(input, i, &b)
+			continue
+		}
+		if input[i] != '$' || i+1 == len(input) {
+			b.WriteByte(input[i])
+			continue
+		}
+
+		next := input[i+1]
+		switch {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := matchingBrace(input, i+2)
+			if end < 0 {
+				// Unterminated reference; keep the rest as-is
+				b.WriteString(input[i:])
+				return b.String()
+			}
+			b.WriteString(exp.expandBraced(input[i+2:end], input[i:end+1]))
+			i = end
+		case next == '(':
+			end := matchingParen(input, i+2)
+			if end < 0 {
+				b.WriteString(input[i:])
+				return b.String()
+			}
+			b.WriteString(exp.substituteCommand(input[i+2:end], input[i:end+1]))
+			i = end
+		case isNameStart(next):
+			end := i + 2
+			for end < len(input) && isNameChar(input[end]) {
+				end++
+			}
+			name := input[i+1 : end]
+			if value := getEnvWithFallback(name); value != "" {
+				b.WriteString(value)
+			} else {
+				exp.missing = append(exp.missing, name)
+				b.WriteString(input[i:end])
+			}
+			i = end - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+
+	return b.String()
+}
+
+// expandBraced expands the body of a ${...} reference; literal is the whole
+// reference, kept when the variable is unset and has no default
+func (exp *expansion) expandBraced(body, literal string) string {
+	name, operator, operand := body, "", ""
+	if idx := strings.Index(body, ":"); idx >= 0 && idx+1 < len(body) && (body[idx+1] == '-' || body[idx+1] == '?') {
+		name, operator, operand = body[:idx], body[idx:idx+2], body[idx+2:]
+	}
+
+	if value := getEnvWithFallback(name); value != "" {
+		return value
+	}
+
+	switch operator {
+	case ":-":
+		return exp.expand(operand)
+	case ":?":
+		message := exp.expand(operand)
+		if message == "" {
+			message = "parameter null or not set"
+		}
+		exp.missing = append(exp.missing, name)
+		if !slices.Contains(exp.excused, name) {
+			exp.errs = append(exp.errs, fmt.Errorf("%s: %s", name, message))
+		}
+		return literal
+	}
+
+	exp.missing = append(exp.missing, name)
+	return literal
+}
+
+// expandPercent handles the % at input[i], writing its expansion to b, and
+// returns the index of the last byte consumed
+func (exp *expansion) expandPercent(input string, i int, b *strings.Builder) int {
+	if i+1 < len(input) && input[i+1] == '%' {
+		b.WriteByte('%')
+		return i + 1
+	}
+
+	end := strings.IndexByte(input[i+1:], '%')
+	if end > 0 {
+		// Two hex digits are a URL-encoded byte, never a variable
+		name := input[i+1 : i+1+end]
+		if isName(name) && !isHexByte(name) {
+			if value := getEnvWithFallback(name); value != "" {
+				b.WriteString(value)
+				return i + 1 + end
+			}
+		}
+	}
+
+	// Not a set variable; the closing % may still start a reference
+	b.WriteByte('%')
+	return i
+}
+
+// substituteCommand returns the output of command, or literal (the whole
+// $(...) reference) when substitution is disabled or the command fails
+func (exp *expansion) substituteCommand(command, literal string) string {
+	if !exp.allowCommands {
+		return literal
+	}
+
+	output, err := runSubstitution(command)
+	if err != nil {
+		exp.errs = append(exp.errs, err)
+		return literal
+	}
+	return output
+}
+
+// matchingBrace returns the index of the '}' closing a ${ whose body starts
+// at start, allowing nested ${...} references in defaults and messages
+func matchingBrace(input string, start int) int {
+	depth := 0
+	for i := start; i < len(input); i++ {
+		switch {
+		case input[i] == '$' && i+1 < len(input) && input[i+1] == '$':
+			i++ // Escaped dollar
+		case input[i] == '$' && i+1 < len(input) && input[i+1] == '{':
+			depth++
+			i++
+		case input[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// isHexByte reports whether s is two hex digits, as in URL encoding
+func isHexByte(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 16, 8)
+	return err == nil
+}
+
+// isName reports whether s is a valid variable name
+func isName(s string) bool {
+	if s == "" || !isNameStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isNameChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
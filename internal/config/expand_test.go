@@ -0,0 +1,212 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandVariables(t *testing.T) {
+	t.Setenv("MCP_TEST_TOKEN", "secret")
+	t.Setenv("MCP_TEST_HOST", "example.com")
+	t.Setenv("MCP_TEST_EMPTY", "")
+	t.Setenv("ENT_MCP_TEST_PREFIXED", "from-ent")
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"braced", "Bearer ${MCP_TEST_TOKEN}", "Bearer secret"},
+		{"bare", "$MCP_TEST_TOKEN", "secret"},
+		{"bare unset kept", "$MCP_TEST_UNSET", "$MCP_TEST_UNSET"},
+		{"braced unset kept", "${MCP_TEST_UNSET}", "${MCP_TEST_UNSET}"},
+		{"ENT fallback", "${MCP_TEST_PREFIXED}", "from-ent"},
+		{"default unused", "${MCP_TEST_TOKEN:-fallback}", "secret"},
+		{"default when unset", "${MCP_TEST_UNSET:-fallback}", "fallback"},
+		{"default when empty", "${MCP_TEST_EMPTY:-fallback}", "fallback"},
+		{"empty default", "${MCP_TEST_UNSET:-}", ""},
+		{"default with spaces and colons", "${MCP_TEST_UNSET:-http://localhost:8080/mcp}", "http://localhost:8080/mcp"},
+		{"nested default", "${MCP_TEST_UNSET:-${MCP_TEST_HOST}}", "example.com"},
+		{"doubly nested default", "${MCP_TEST_UNSET:-${MCP_TEST_OTHER:-${MCP_TEST_TOKEN}}}", "secret"},
+		{"nested default in text", "https://${MCP_TEST_UNSET:-api.${MCP_TEST_HOST}}/v1", "https://api.example.com/v1"},
+		{"adjacent", "${MCP_TEST_HOST}${MCP_TEST_TOKEN}", "example.comsecret"},
+		{"adjacent bare", "$MCP_TEST_HOST:$MCP_TEST_TOKEN", "example.com:secret"},
+		{"escaped dollar", "price$$5", "price$5"},
+		{"escaped reference", "$${MCP_TEST_TOKEN}", "${MCP_TEST_TOKEN}"},
+		{"escaped bare reference", "$$MCP_TEST_TOKEN", "$MCP_TEST_TOKEN"},
+		{"escaped then reference", "$$$MCP_TEST_TOKEN", "$secret"},
+		{"escaped dollar in default", "${MCP_TEST_UNSET:-$$HOME}", "$HOME"},
+		{"lone dollar", "costs $ 5", "costs $ 5"},
+		{"trailing dollar", "abc$", "abc$"},
+		{"unterminated", "${MCP_TEST_TOKEN", "${MCP_TEST_TOKEN"},
+		{"no references", "plain", "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandVariables(tt.input)
+			if err != nil {
+				t.Fatalf("ExpandVariables(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandVariables(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandVariablesRequired(t *testing.T) {
+	t.Setenv("MCP_TEST_TOKEN", "secret")
+	t.Setenv("MCP_TEST_EMPTY", "")
+
+	if got, err := ExpandVariables("${MCP_TEST_TOKEN:?token required}"); err != nil || got != "secret" {
+		t.Errorf("expected set required variable to resolve, got %q (%v)", got, err)
+	}
+
+	_, err := ExpandVariables("${MCP_TEST_UNSET:?set MCP_TEST_UNSET to your API key}")
+	if err == nil || err.Error() != "MCP_TEST_UNSET: set MCP_TEST_UNSET to your API key" {
+		t.Errorf("expected message error, got %v", err)
+	}
+
+	_, err = ExpandVariables("${MCP_TEST_EMPTY:?}")
+	if err == nil || err.Error() != "MCP_TEST_EMPTY: parameter null or not set" {
+		t.Errorf("expected default message for empty variable, got %v", err)
+	}
+
+	// Every failure in one value is reported
+	_, err = ExpandVariables("${MCP_TEST_A:?first}-${MCP_TEST_B:?second for ${MCP_TEST_TOKEN}}")
+	if err == nil || err.Error() != "MCP_TEST_A: first\nMCP_TEST_B: second for secret" {
+		t.Errorf("expected both failures, got %v", err)
+	}
+}
+
+func TestLoadConfigReportsAllMissingVariables(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mcp_servers.json")
+	configJSON := `{"mcpServers": {
+		"context7": {"url": "https://mcp.context7.com/mcp", "headers": {"Authorization": "Bearer ${MCP_TEST_CONTEXT7:?get a key at context7.com}"}},
+		"github": {"command": "npx", "args": ["--token", "${MCP_TEST_GITHUB:?required}"], "env": {"LOG": "${MCP_TEST_LOG:-info}"}}
+	}}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig(configPath)
+	if err == nil {
+		t.Fatal("expected LoadConfig to fail on missing required variables")
+	}
+	for _, want := range []string{"MCP_TEST_CONTEXT7: get a key at context7.com", "MCP_TEST_GITHUB: required", "server 'context7'", "server 'github'"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got:\n%v", want, err)
+		}
+	}
+
+	t.Setenv("MCP_TEST_CONTEXT7", "ctx7")
+	t.Setenv("MCP_TEST_GITHUB", "ghp")
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	github := cfg.MCPServers["github"]
+	if !reflect.DeepEqual(github.Args, []string{"--token", "ghp"}) || github.Env["LOG"] != "info" {
+		t.Errorf("unexpected resolved server config %+v", github)
+	}
+	if missing := github.UnresolvedVariables(); len(missing) != 0 {
+		t.Errorf("expected defaults to count as resolved, got %v", missing)
+	}
+}
+
+func TestExpandVariablesPercent(t *testing.T) {
+	t.Setenv("MCP_TEST_APPDATA", `C:\Users\me\AppData\Roaming`)
+	t.Setenv("MCP_TEST_HOST", "example.com")
+	t.Setenv("MCP_TEST_EMPTY", "")
+	t.Setenv("C3", "should-not-appear")
+	t.Setenv("ENT_MCP_TEST_PREFIXED", "from-ent")
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"windows path", `%MCP_TEST_APPDATA%\mcp\cache`, `C:\Users\me\AppData\Roaming\mcp\cache`},
+		{"ENT fallback", "%MCP_TEST_PREFIXED%", "from-ent"},
+		{"unset kept", "%MCP_TEST_UNSET%", "%MCP_TEST_UNSET%"},
+		{"empty kept", "%MCP_TEST_EMPTY%", "%MCP_TEST_EMPTY%"},
+		{"adjacent", "%MCP_TEST_HOST%%MCP_TEST_HOST%", "example.comexample.com"},
+		{"unset then set", "%MCP_TEST_UNSET%MCP_TEST_HOST%", "%MCP_TEST_UNSETexample.com"},
+		{"mixed syntaxes", "%MCP_TEST_HOST%:${MCP_TEST_HOST}/$MCP_TEST_HOST", "example.com:example.com/example.com"},
+		{"percent in default", "${MCP_TEST_UNSET:-%MCP_TEST_HOST%}", "example.com"},
+		{"escaped percent", "100%%", "100%"},
+		{"escaped reference", "%%MCP_TEST_HOST%%", "%MCP_TEST_HOST%"},
+		{"url encoding", "https://example.com/search?q=a%20b%2Fc", "https://example.com/search?q=a%20b%2Fc"},
+		{"url encoding with name-like pair", "caf%C3%A9", "caf%C3%A9"},
+		{"date format", "+%Y-%m-%d", "+%Y-%m-%d"},
+		{"lone percent", "50% off", "50% off"},
+		{"trailing percent", "abc%", "abc%"},
+		{"empty name", "%%%", "%%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandVariables(tt.input)
+			if err != nil {
+				t.Fatalf("ExpandVariables(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandVariables(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandArguments(t *testing.T) {
+	t.Setenv("MCP_TEST_HOST", "example.com")
+	t.Setenv("MCP_TEST_TOKEN", "secret")
+
+	args := map[string]interface{}{
+		"url":    "https://${MCP_TEST_HOST}/a%20b",
+		"price":  "$5.00",
+		"escape": "$$MCP_TEST_HOST",
+		"region": "${MCP_TEST_UNSET:-eu}",
+		"count":  json.Number("3"),
+		"nested": map[string]interface{}{
+			"auth":  "Bearer %MCP_TEST_TOKEN%",
+			"tags":  []interface{}{"$MCP_TEST_HOST", true, nil},
+			"$HOME": "kept",
+		},
+	}
+	got, err := ExpandArguments(args)
+	if err != nil {
+		t.Fatalf("ExpandArguments failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"url":    "https://example.com/a%20b",
+		"price":  "$5.00",
+		"escape": "$MCP_TEST_HOST",
+		"region": "eu",
+		"count":  json.Number("3"),
+		"nested": map[string]interface{}{
+			"auth":  "Bearer secret",
+			"tags":  []interface{}{"example.com", true, nil},
+			"$HOME": "kept",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandArguments = %#v, want %#v", got, want)
+	}
+	if args["url"] != "https://${MCP_TEST_HOST}/a%20b" {
+		t.Error("ExpandArguments changed its input")
+	}
+
+	_, err = ExpandArguments(map[string]interface{}{
+		"a": "${MCP_TEST_UNSET:?set the first}",
+		"b": []interface{}{"${MCP_TEST_OTHER_UNSET:?set the second}"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "set the first") || !strings.Contains(err.Error(), "set the second") {
+		t.Errorf("want both failures reported, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// InitializeOptionsExperimental places a server's initializeOptions in the
+// experimental capabilities of its initialize request, the default
+const InitializeOptionsExperimental = "experimental"
+
+// reservedInitializeFields are the initialize parameters initializeOptionsField
+// cannot name
+var reservedInitializeFields = map[string]bool{"protocolVersion": true, "capabilities": true, "clientInfo": true}
+
+// InitializeOptionsTarget returns where the server's initializeOptions go:
+// InitializeOptionsExperimental, or the top-level initialize parameter it names
+func (c *ServerConfig) InitializeOptionsTarget() string {
+	if c.InitializeOptionsField == "" {
+		return InitializeOptionsExperimental
+	}
+	return c.InitializeOptionsField
+}
+
+// ResolveInitializeOptions resolves environment variables in the strings of
+// initializeOptions, however deeply they are nested
+func (c *ServerConfig) ResolveInitializeOptions() error {
+	c.recordTemplates()
+	if c.InitializeOptions == nil {
+		return nil
+	}
+
+	var errs []error
+	resolved := mapStrings(c.InitializeOptions, func(text string) string {
+		expanded, err := c.expand(text)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("initializeOptions: %w", err))
+		}
+		return expanded
+	})
+	c.InitializeOptions = resolved.(map[string]interface{})
+	return errors.Join(errs...)
+}
+
+// mapStrings returns a copy of a decoded JSON value with fn applied to every
+// string in it. Object keys are kept as they are.
+func mapStrings(value interface{}, fn func(string) string) interface{} {
+	switch value := value.(type) {
+	case string:
+		return fn(value)
+	case map[string]interface{}:
+		mapped := make(map[string]interface{}, len(value))
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys) // Report failures in a stable order
+		for _, key := range keys {
+			mapped[key] = mapStrings(value[key], fn)
+		}
+		return mapped
+	case []interface{}:
+		mapped := make([]interface{}, len(value))
+		for i, item := range value {
+			mapped[i] = mapStrings(item, fn)
+		}
+		return mapped
+	default:
+		return value
+	}
+}
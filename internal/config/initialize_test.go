@@ -0,0 +1,97 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigResolvesInitializeOptions(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeFile(t, configPath, `{"mcpServers": {"lsp": {
+  "command": "lsp-bridge",
+  "initializeOptions": {
+    "workspace": {"roots": ["${MCP_TEST_WORKSPACE}/a", "${MCP_TEST_WORKSPACE}/b"], "depth": 2},
+    "features": [{"name": "hover", "enabled": true}],
+    "token": "${MCP_TEST_INIT_TOKEN}"
+  },
+  "initializeOptionsField": "initializationOptions"
+}}}`)
+	t.Setenv("MCP_TEST_WORKSPACE", "/src")
+	t.Setenv("MCP_TEST_INIT_TOKEN", "s3cret")
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	server := cfg.MCPServers["lsp"]
+	want := map[string]interface{}{
+		"workspace": map[string]interface{}{"roots": []interface{}{"/src/a", "/src/b"}, "depth": 2.0},
+		"features":  []interface{}{map[string]interface{}{"name": "hover", "enabled": true}},
+		"token":     "s3cret",
+	}
+	if !reflect.DeepEqual(server.InitializeOptions, want) {
+		t.Errorf("initializeOptions = %#v, want %#v", server.InitializeOptions, want)
+	}
+	if server.InitializeOptionsTarget() != "initializationOptions" {
+		t.Errorf("target = %q", server.InitializeOptionsTarget())
+	}
+
+	// Written back to disk, the references come back instead of the secret
+	unresolved := server.Unresolved()
+	if unresolved.InitializeOptions["token"] != "${MCP_TEST_INIT_TOKEN}" {
+		t.Errorf("unresolved token = %v", unresolved.InitializeOptions["token"])
+	}
+
+	// The resolved options survive JSON, as the daemon receives them
+	data, err := json.Marshal(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ServerConfig
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.InitializeOptions, want) || decoded.InitializeOptionsField != server.InitializeOptionsField {
+		t.Errorf("round-tripped options = %#v in %q", decoded.InitializeOptions, decoded.InitializeOptionsField)
+	}
+}
+
+func TestResolvedInitializeOptionsAreRedacted(t *testing.T) {
+	// As the daemon receives a config: resolved, with no templates known
+	server := ServerConfig{
+		Command:           "lsp-bridge",
+		InitializeOptions: map[string]interface{}{"auth": map[string]interface{}{"apiToken": "lsp-s3cret-0123"}, "depth": 2.0},
+	}
+
+	unresolved := server.Unresolved()
+	want := map[string]interface{}{"auth": map[string]interface{}{"apiToken": RedactedValue}, "depth": 2.0}
+	if !reflect.DeepEqual(unresolved.InitializeOptions, want) {
+		t.Errorf("unresolved options = %#v, want %#v", unresolved.InitializeOptions, want)
+	}
+	if server.InitializeOptions["auth"].(map[string]interface{})["apiToken"] != "lsp-s3cret-0123" {
+		t.Error("Unresolved should not change the config it copies")
+	}
+
+	if got := server.Redactor().String("initialize failed: bad token lsp-s3cret-0123"); strings.Contains(got, "lsp-s3cret-0123") {
+		t.Errorf("redacted = %q, want the option's secret scrubbed", got)
+	}
+}
+
+func TestInitializeOptionsReportMissingVariables(t *testing.T) {
+	server := ServerConfig{
+		Command:           "lsp-bridge",
+		InitializeOptions: map[string]interface{}{"nested": []interface{}{map[string]interface{}{"key": "${MCP_TEST_UNSET_INIT_VAR:?set it}"}}},
+	}
+	if err := server.ResolveInitializeOptions(); err == nil {
+		t.Error("a failed ${VAR:?message} reference should be an error")
+	}
+	if got := server.UnresolvedVariables(); !reflect.DeepEqual(got, []string{"MCP_TEST_UNSET_INIT_VAR"}) {
+		t.Errorf("unresolved variables = %v", got)
+	}
+	if server.InitializeOptionsTarget() != InitializeOptionsExperimental {
+		t.Errorf("default target = %q", server.InitializeOptionsTarget())
+	}
+}
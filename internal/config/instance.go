@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DaemonNameEnv names the environment variable selecting the daemon instance.
+// The CLI sets it from --daemon-name or daemon.name, so the daemon it starts
+// and the processes that start inherit it.
+const DaemonNameEnv = "MCP_CLI_DAEMON_NAME"
+
+// maxDaemonNameLength keeps instance file names well inside platform limits
+const maxDaemonNameLength = 64
+
+// DaemonName returns the daemon instance this process uses; empty is the
+// shared instance
+func DaemonName() string {
+	return os.Getenv(DaemonNameEnv)
+}
+
+// ValidateDaemonName checks that name can namespace the daemon's files. It
+// follows the rule the caches use for server names in file names, except
+// that a daemon name has to be usable as it is: every character must be one
+// url.QueryEscape leaves alone (letters, digits, '-', '_', '.' and '~').
+func ValidateDaemonName(name string) error {
+	switch {
+	case name == "" || name == "." || name == "..":
+		return fmt.Errorf("invalid daemon name '%s'", name)
+	case len(name) > maxDaemonNameLength:
+		return fmt.Errorf("daemon name '%s' is longer than %d characters", name, maxDaemonNameLength)
+	case url.QueryEscape(name) != name:
+		return fmt.Errorf("invalid daemon name '%s': use only letters, digits, '-', '_', '.' and '~'", name)
+	}
+	return nil
+}
+
+// InstanceFile returns the name of a daemon instance's file or directory:
+// base itself for the shared instance, otherwise base with "-<name>" before
+// its extension, so daemon.pid becomes daemon-work.pid and sessions becomes
+// sessions-work
+func InstanceFile(base, name string) string {
+	if name == "" {
+		return base
+	}
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-" + name + ext
+}
+
+// InstanceName returns the instance name in a file name InstanceFile made
+// from base, and whether fileName is one; the shared instance's name is empty
+func InstanceName(base, fileName string) (string, bool) {
+	if fileName == base {
+		return "", true
+	}
+	ext := filepath.Ext(base)
+	name, ok := strings.CutPrefix(fileName, strings.TrimSuffix(base, ext)+"-")
+	if !ok {
+		return "", false
+	}
+	if name, ok = strings.CutSuffix(name, ext); !ok || ValidateDaemonName(name) != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// ReadDaemonName returns daemon.name as the configuration at configPath,
+// merged with opts.LocalConfig, sets it
+func ReadDaemonName(configPath string, opts LoadOptions) string {
+	return ReadDaemonSection(configPath, opts).Name
+}
+
+// ReadDaemonSection returns the daemon section of the configuration at
+// configPath, merged with opts.LocalConfig. Only that section is read, so
+// nothing in the files is resolved or run; unreadable files set nothing.
+func ReadDaemonSection(configPath string, opts LoadOptions) DaemonSection {
+	var section DaemonSection
+	for _, path := range []string{configPath, opts.LocalConfig} {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var file struct {
+			Daemon *DaemonSection `json:"daemon"`
+		}
+		if json.Unmarshal(data, &file) == nil && file.Daemon != nil {
+			section = *file.Daemon // The local file's section replaces the main one's
+		}
+	}
+	return section
+}
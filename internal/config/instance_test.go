@@ -0,0 +1,94 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateDaemonName(t *testing.T) {
+	for _, name := range []string{"work", "client-a", "proj_2", "v1.2", "~home"} {
+		if err := ValidateDaemonName(name); err != nil {
+			t.Errorf("%q: unexpected error %v", name, err)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "a/b", `a\b`, "my project", "a:b", "café", strings.Repeat("x", 65)} {
+		if err := ValidateDaemonName(name); err == nil {
+			t.Errorf("%q: expected an error", name)
+		}
+	}
+}
+
+func TestInstanceFile(t *testing.T) {
+	tests := []struct{ base, name, want string }{
+		{"daemon.pid", "", "daemon.pid"},
+		{"daemon.pid", "work", "daemon-work.pid"},
+		{"daemon-wsl.sock", "work", "daemon-wsl-work.sock"},
+		{"sessions", "work", "sessions-work"},
+		{"mcp-cli-ent-daemon", "v1.2", "mcp-cli-ent-daemon-v1.2"},
+	}
+	for _, tt := range tests {
+		got := InstanceFile(tt.base, tt.name)
+		if got != tt.want {
+			t.Errorf("InstanceFile(%q, %q) = %q, want %q", tt.base, tt.name, got, tt.want)
+		}
+		if name, ok := InstanceName(tt.base, got); tt.base != "mcp-cli-ent-daemon" && (!ok || name != tt.name) {
+			t.Errorf("InstanceName(%q, %q) = %q, %v", tt.base, got, name, ok)
+		}
+	}
+
+	for _, fileName := range []string{"daemon.json", "daemon-.pid", "daemon-a b.pid", "daemon-work.log"} {
+		if name, ok := InstanceName("daemon.pid", fileName); ok {
+			t.Errorf("InstanceName(daemon.pid, %q) = %q, want no instance", fileName, name)
+		}
+	}
+}
+
+func TestReadDaemonName(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "mcp_servers.json")
+	localPath := filepath.Join(dir, LocalConfigFileName)
+	writeFile(t, globalPath, `{"mcpServers": {"time": {"command": "$(date)"}}, "daemon": {"name": "shared"}}`)
+	writeFile(t, localPath, `{"daemon": {"name": "project"}}`)
+
+	if got := ReadDaemonName(globalPath, LoadOptions{}); got != "shared" {
+		t.Errorf("expected the main config's name, got %q", got)
+	}
+	if got := ReadDaemonName(globalPath, LoadOptions{LocalConfig: localPath}); got != "project" {
+		t.Errorf("expected the local config to win, got %q", got)
+	}
+	if got := ReadDaemonName(filepath.Join(dir, "missing.json"), LoadOptions{}); got != "" {
+		t.Errorf("expected no name without a config, got %q", got)
+	}
+
+	cfg, err := LoadConfigWithOptions(globalPath, LoadOptions{LocalConfig: localPath})
+	if err != nil {
+		t.Fatalf("LoadConfigWithOptions failed: %v", err)
+	}
+	if cfg.Daemon == nil || cfg.Daemon.Name != "project" {
+		t.Errorf("expected the merged daemon section to name the project, got %+v", cfg.Daemon)
+	}
+
+	writeFile(t, localPath, `{"daemon": {"name": "../escape"}}`)
+	if _, err := LoadConfigWithOptions(globalPath, LoadOptions{LocalConfig: localPath}); err == nil || !strings.Contains(err.Error(), "daemon.name") {
+		t.Errorf("expected an invalid daemon.name error, got %v", err)
+	}
+}
+
+func TestDaemonRouting(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "mcp_servers.json")
+	writeFile(t, globalPath, `{"mcpServers": {}, "daemon": {"routing": "always"}}`)
+
+	if section := ReadDaemonSection(globalPath, LoadOptions{}); section.RoutingMode() != DaemonRoutingAlways {
+		t.Errorf("expected the configured routing, got %+v", section)
+	}
+	if section := ReadDaemonSection(filepath.Join(dir, "missing.json"), LoadOptions{}); section.RoutingMode() != DaemonRoutingAuto {
+		t.Errorf("expected auto routing without a config, got %+v", section)
+	}
+
+	writeFile(t, globalPath, `{"mcpServers": {}, "daemon": {"routing": "sometimes"}}`)
+	if _, err := LoadConfigWithOptions(globalPath, LoadOptions{}); err == nil || !strings.Contains(err.Error(), "daemon.routing") {
+		t.Errorf("expected an invalid daemon.routing error, got %v", err)
+	}
+}
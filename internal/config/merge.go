@@ -0,0 +1,317 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// LocalConfigFileName is the project-local configuration merged over the main one
+const LocalConfigFileName = ".mcp_servers.json"
+
+// Server merge strategies for entries defined in both config files
+const (
+	MergeStrategyReplace = "replace" // The local entry replaces the global one (default)
+	MergeStrategyPatch   = "patch"   // Local fields override global ones, field by field
+)
+
+// configFile is a configuration file with its server entries left undecoded
+type configFile struct {
+	path        string
+	MCPServers  map[string]json.RawMessage `json:"mcpServers"`
+	Templates   map[string]json.RawMessage `json:"templates,omitempty"`
+	EnvFile     string                     `json:"envFile,omitempty"`
+	EnvOverride bool                       `json:"envOverride,omitempty"`
+
+	AllowCommandSubstitution bool   `json:"allowCommandSubstitution,omitempty"`
+	ConfigWatch              bool   `json:"configWatch,omitempty"`
+	ToolCacheTTL             string `json:"toolCacheTTL,omitempty"`
+	ResultCacheMaxMB         int    `json:"resultCacheMaxMB,omitempty"`
+	MaxResponseMB            int    `json:"maxResponseMB,omitempty"`
+
+	Serve   ServeConfig    `json:"serve,omitempty"`
+	Exports *ExportsConfig `json:"exports,omitempty"`
+	Audit   *AuditConfig   `json:"audit,omitempty"`
+	Daemon  *DaemonSection `json:"daemon,omitempty"`
+}
+
+// readConfigFile reads and parses a configuration file
+func readConfigFile(path string) (*configFile, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, &ConfigError{fmt.Sprintf("configuration file '%s' not found", path)}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	file := &configFile{path: path}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file '%s': %w", path, err)
+	}
+	return file, nil
+}
+
+// UntrustedLocalConfigError reports a project-local config that was found
+// but is not merged, because someone other than the user could have written it
+type UntrustedLocalConfigError struct {
+	Path   string
+	Reason string
+}
+
+func (e *UntrustedLocalConfigError) Error() string {
+	return fmt.Sprintf("ignoring %s: %s", e.Path, e.Reason)
+}
+
+// FindLocalConfigFile returns the project-local config: the one at the root
+// of the repository holding the working directory, or in the working
+// directory itself outside a repository. The search for the repository root
+// never climbs above the home directory it starts in. A file that isn't the
+// user's, or sits in or is itself writable by everyone, is refused with an
+// *UntrustedLocalConfigError: its servers' commands would run as the user.
+func FindLocalConfigFile() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	candidate := filepath.Join(projectRoot(dir), LocalConfigFileName)
+	if _, err := os.Stat(candidate); err != nil {
+		return "", &ConfigError{"no local configuration file found"}
+	}
+	if reason := untrustedReason(candidate); reason != "" {
+		return "", &UntrustedLocalConfigError{Path: candidate, Reason: reason}
+	}
+	return candidate, nil
+}
+
+// projectRoot returns the root of the repository holding dir, or dir when
+// there is none below the home directory (or the filesystem root outside it)
+func projectRoot(dir string) string {
+	home, _ := os.UserHomeDir()
+	underHome := home != "" && isWithin(dir, home)
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current || (underHome && sameFile(current, home)) {
+			return dir
+		}
+		current = parent
+	}
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// sameFile reports whether two paths refer to the same file
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// mergeConfigFiles merges local over base. Servers only in one file are kept
+// as-is; servers in both are replaced by the local entry, or patched field by
+// field when it sets mergeStrategy "patch". It returns the merged file, the
+// source path of each server, and a description of each conflict.
+func mergeConfigFiles(base, local *configFile) (*configFile, map[string]string, []string, error) {
+	merged := &configFile{
+		path:        base.path,
+		EnvFile:     base.EnvFile,
+		EnvOverride: base.EnvOverride || local.EnvOverride,
+		ConfigWatch: base.ConfigWatch || local.ConfigWatch,
+		Serve:       base.Serve,
+		Exports:     base.Exports,
+		Audit:       base.Audit,
+		Daemon:      base.Daemon,
+
+		ToolCacheTTL:     base.ToolCacheTTL,
+		ResultCacheMaxMB: base.ResultCacheMaxMB,
+		MaxResponseMB:    base.MaxResponseMB,
+	}
+	if local.ToolCacheTTL != "" {
+		merged.ToolCacheTTL = local.ToolCacheTTL
+	}
+	if local.ResultCacheMaxMB != 0 {
+		merged.ResultCacheMaxMB = local.ResultCacheMaxMB
+	}
+	if local.MaxResponseMB != 0 {
+		merged.MaxResponseMB = local.MaxResponseMB
+	}
+	merged.Exports = narrowExports(base.Exports, local.Exports)
+	if local.Daemon != nil {
+		merged.Daemon = local.Daemon // A project picks its own daemon instance
+	}
+	if local.Serve.NamespaceStyle != "" {
+		merged.Serve.NamespaceStyle = local.Serve.NamespaceStyle
+	}
+	if local.Serve.Separator != "" {
+		merged.Serve.Separator = local.Serve.Separator
+	}
+	if local.Serve.MaxSessions != 0 {
+		merged.Serve.MaxSessions = local.Serve.MaxSessions
+	}
+	if local.EnvFile != "" {
+		// Keep the env file relative to the file that names it
+		merged.EnvFile = local.EnvFile
+		if !filepath.IsAbs(merged.EnvFile) {
+			merged.EnvFile = filepath.Join(filepath.Dir(local.path), merged.EnvFile)
+		}
+	}
+
+	sources := make(map[string]string)
+	if base.MCPServers != nil || local.MCPServers != nil {
+		merged.MCPServers = make(map[string]json.RawMessage)
+	}
+	for name, raw := range base.MCPServers {
+		merged.MCPServers[name] = raw
+		sources[name] = base.path
+	}
+
+	// Local templates replace global ones of the same name
+	if base.Templates != nil || local.Templates != nil {
+		merged.Templates = make(map[string]json.RawMessage)
+	}
+	for name, raw := range base.Templates {
+		merged.Templates[name] = raw
+	}
+	for name, raw := range local.Templates {
+		merged.Templates[name] = raw
+	}
+
+	// Project files are untrusted: they can neither enable command
+	// substitution nor have their commands run by the global opt-in, and
+	// can't pick the token serve lets clients in with
+	var conflicts []string
+	if base.AllowCommandSubstitution {
+		conflicts = append(conflicts, fmt.Sprintf("allowCommandSubstitution in %s is ignored while %s is merged; pass --allow-exec to run commands", base.path, local.path))
+	}
+	if local.Serve.Token != "" {
+		conflicts = append(conflicts, fmt.Sprintf("serve.token in %s is ignored; set it in %s", local.path, base.path))
+	}
+	// A project may turn the audit log on, but never off or down
+	if local.Audit != nil && !reflect.DeepEqual(local.Audit, base.Audit) {
+		if local.Audit.IsEnabled() && !base.Audit.IsEnabled() {
+			merged.Audit = local.Audit
+		} else {
+			conflicts = append(conflicts, fmt.Sprintf("audit in %s is ignored; set it in %s", local.path, base.path))
+		}
+	}
+
+	for _, name := range sortedKeys(local.MCPServers) {
+		raw := local.MCPServers[name]
+		existing, exists := merged.MCPServers[name]
+		merged.MCPServers[name] = raw
+		sources[name] = local.path
+		if !exists {
+			continue
+		}
+
+		mergedRaw, fields, err := mergeServerEntry(existing, raw)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("server '%s': %w", name, err)
+		}
+		merged.MCPServers[name] = mergedRaw
+
+		if fields == nil {
+			conflicts = append(conflicts, fmt.Sprintf("server '%s' in %s replaces the definition in %s", name, local.path, base.path))
+		} else if len(fields) > 0 {
+			conflicts = append(conflicts, fmt.Sprintf("server '%s' in %s patches %s from %s", name, local.path, strings.Join(fields, ", "), base.path))
+		}
+	}
+
+	return merged, sources, conflicts, nil
+}
+
+// narrowExports returns the exports of the main config narrowed by those of
+// a project config: a project can only publish less. Without main exports,
+// which publishes everything, the project's apply as they are.
+func narrowExports(base, local *ExportsConfig) *ExportsConfig {
+	if local == nil {
+		return base
+	}
+	if base == nil {
+		return local
+	}
+	narrowed := *base
+	narrowed.Daemon = base.Daemon || local.Daemon
+	narrowed.Within = local
+	return &narrowed
+}
+
+// mergeServerEntry applies a local server entry over a global one. It returns
+// the merged entry and, for patches, the fields the local entry changed (nil
+// means the entry was replaced).
+func mergeServerEntry(base, local json.RawMessage) (json.RawMessage, []string, error) {
+	var localFields map[string]interface{}
+	if err := json.Unmarshal(local, &localFields); err != nil {
+		return nil, nil, fmt.Errorf("invalid server entry: %w", err)
+	}
+
+	strategy, _ := localFields["mergeStrategy"].(string)
+	if strategy != MergeStrategyPatch {
+		return local, nil, nil
+	}
+
+	var baseFields map[string]interface{}
+	if err := json.Unmarshal(base, &baseFields); err != nil {
+		return nil, nil, fmt.Errorf("invalid server entry: %w", err)
+	}
+
+	changed := []string{}
+	for _, key := range sortedKeys(localFields) {
+		if key == "mergeStrategy" {
+			continue
+		}
+		if previous, exists := baseFields[key]; exists && !reflect.DeepEqual(previous, localFields[key]) {
+			changed = append(changed, key)
+		}
+	}
+
+	merged, err := json.Marshal(deepMerge(baseFields, localFields))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to merge server entry: %w", err)
+	}
+	return merged, changed, nil
+}
+
+// deepMerge overlays patch on base: nested objects are merged recursively,
+// while arrays and scalar values from patch replace those in base
+func deepMerge(base, patch map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base)+len(patch))
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range patch {
+		patchObject, patchIsObject := value.(map[string]interface{})
+		baseObject, baseIsObject := result[key].(map[string]interface{})
+		if patchIsObject && baseIsObject {
+			result[key] = deepMerge(baseObject, patchObject)
+			continue
+		}
+		result[key] = value
+	}
+
+	return result
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
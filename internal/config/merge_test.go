@@ -0,0 +1,315 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMergeConfigFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		local     string
+		want      string // Merged entry for "server"
+		conflicts []string
+	}{
+		{
+			name:      "local entry replaces global by default",
+			base:      `{"command": "npx", "args": ["-y", "server"], "env": {"A": "1"}}`,
+			local:     `{"command": "uvx", "args": ["server"]}`,
+			want:      `{"command": "uvx", "args": ["server"]}`,
+			conflicts: []string{"server 'server' in local.json replaces the definition in global.json"},
+		},
+		{
+			name:      "patch overrides only the given fields",
+			base:      `{"command": "npx", "args": ["-y", "server"], "timeout": 30}`,
+			local:     `{"mergeStrategy": "patch", "timeout": 60}`,
+			want:      `{"command": "npx", "args": ["-y", "server"], "timeout": 60, "mergeStrategy": "patch"}`,
+			conflicts: []string{"server 'server' in local.json patches timeout from global.json"},
+		},
+		{
+			name:      "patch merges nested objects",
+			base:      `{"command": "npx", "env": {"A": "1", "B": "2"}, "headers": {"X-Team": "core"}}`,
+			local:     `{"mergeStrategy": "patch", "env": {"B": "3", "C": "4"}}`,
+			want:      `{"command": "npx", "env": {"A": "1", "B": "3", "C": "4"}, "headers": {"X-Team": "core"}, "mergeStrategy": "patch"}`,
+			conflicts: []string{"server 'server' in local.json patches env from global.json"},
+		},
+		{
+			name:      "patch replaces arrays",
+			base:      `{"command": "npx", "args": ["-y", "server", "--verbose"]}`,
+			local:     `{"mergeStrategy": "patch", "args": ["-y", "server"]}`,
+			want:      `{"command": "npx", "args": ["-y", "server"], "mergeStrategy": "patch"}`,
+			conflicts: []string{"server 'server' in local.json patches args from global.json"},
+		},
+		{
+			name:      "patch applies explicit false values",
+			base:      `{"command": "npx", "disabled": true}`,
+			local:     `{"mergeStrategy": "patch", "disabled": false}`,
+			want:      `{"command": "npx", "disabled": false, "mergeStrategy": "patch"}`,
+			conflicts: []string{"server 'server' in local.json patches disabled from global.json"},
+		},
+		{
+			name:  "identical patch reports no conflict",
+			base:  `{"command": "npx"}`,
+			local: `{"mergeStrategy": "patch", "command": "npx"}`,
+			want:  `{"command": "npx", "mergeStrategy": "patch"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &configFile{path: "global.json", MCPServers: map[string]json.RawMessage{
+				"server": json.RawMessage(tt.base),
+				"global": json.RawMessage(`{"command": "global"}`),
+			}}
+			local := &configFile{path: "local.json", MCPServers: map[string]json.RawMessage{
+				"server": json.RawMessage(tt.local),
+				"local":  json.RawMessage(`{"command": "local"}`),
+			}}
+
+			merged, sources, conflicts, err := mergeConfigFiles(base, local)
+			if err != nil {
+				t.Fatalf("mergeConfigFiles failed: %v", err)
+			}
+
+			var got, want map[string]interface{}
+			if err := json.Unmarshal(merged.MCPServers["server"], &got); err != nil {
+				t.Fatalf("invalid merged entry: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("invalid expected entry: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+
+			if !reflect.DeepEqual(conflicts, tt.conflicts) {
+				t.Errorf("expected conflicts %q, got %q", tt.conflicts, conflicts)
+			}
+
+			wantSources := map[string]string{"server": "local.json", "global": "global.json", "local": "local.json"}
+			if !reflect.DeepEqual(sources, wantSources) {
+				t.Errorf("expected sources %v, got %v", wantSources, sources)
+			}
+		})
+	}
+}
+
+func TestLoadConfigMergesLocalConfig(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "mcp_servers.json")
+	writeFile(t, globalPath, `{"mcpServers": {
+		"time": {"command": "uvx", "args": ["mcp-server-time"]},
+		"context7": {"type": "http", "url": "https://mcp.context7.com/mcp", "headers": {"X-Team": "core"}}
+	}}`)
+
+	projectDir := filepath.Join(dir, "project")
+	localPath := filepath.Join(projectDir, LocalConfigFileName)
+	writeFile(t, localPath, `{"mcpServers": {
+		"context7": {"mergeStrategy": "patch", "headers": {"X-Project": "demo"}},
+		"repo-tools": {"command": "./tools/mcp"}
+	}}`)
+
+	cfg, err := LoadConfigWithOptions(globalPath, LoadOptions{LocalConfig: localPath})
+	if err != nil {
+		t.Fatalf("LoadConfigWithOptions failed: %v", err)
+	}
+
+	if got := sortedKeys(cfg.MCPServers); !reflect.DeepEqual(got, []string{"context7", "repo-tools", "time"}) {
+		t.Fatalf("unexpected servers %v", got)
+	}
+
+	context7 := cfg.MCPServers["context7"]
+	if context7.URL != "https://mcp.context7.com/mcp" || context7.Headers["X-Team"] != "core" || context7.Headers["X-Project"] != "demo" {
+		t.Errorf("expected patched context7 entry, got %+v", context7)
+	}
+	if context7.Source != localPath || cfg.MCPServers["time"].Source != globalPath {
+		t.Errorf("unexpected sources: context7=%s time=%s", context7.Source, cfg.MCPServers["time"].Source)
+	}
+	if len(cfg.Conflicts) != 1 || !strings.Contains(cfg.Conflicts[0], "context7") {
+		t.Errorf("expected one context7 conflict, got %q", cfg.Conflicts)
+	}
+
+	// Without the local file only the global servers are loaded
+	cfg, err = LoadConfigWithOptions(globalPath, LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadConfigWithOptions failed: %v", err)
+	}
+	if _, ok := cfg.MCPServers["repo-tools"]; ok || len(cfg.Conflicts) != 0 {
+		t.Error("expected local servers to be ignored without LocalConfig")
+	}
+}
+
+func TestLocalConfigCannotSetServeToken(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "mcp_servers.json")
+	writeFile(t, globalPath, `{"serve": {"token": "global-token"}, "mcpServers": {"time": {"command": "uvx"}}}`)
+	localPath := filepath.Join(dir, "project", LocalConfigFileName)
+	writeFile(t, localPath, `{"serve": {"token": "project-token", "maxSessions": 5}, "mcpServers": {}}`)
+
+	cfg, err := LoadConfigWithOptions(globalPath, LoadOptions{LocalConfig: localPath})
+	if err != nil {
+		t.Fatalf("LoadConfigWithOptions failed: %v", err)
+	}
+	if cfg.Serve.Token != "global-token" || cfg.Serve.MaxSessions != 5 {
+		t.Errorf("want the global token and the local session limit, got %+v", cfg.Serve)
+	}
+	if len(cfg.Conflicts) != 1 || !strings.Contains(cfg.Conflicts[0], "serve.token in "+localPath+" is ignored") {
+		t.Errorf("expected a note about the ignored token, got %q", cfg.Conflicts)
+	}
+}
+
+func TestLocalConfigOnlyNarrowsExportsAndAudit(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "mcp_servers.json")
+	writeFile(t, globalPath, `{
+  "exports": {"tools": ["github/search_*"]},
+  "audit": {"includeArgs": true},
+  "mcpServers": {"github": {"command": "github-mcp"}}
+}`)
+	localPath := filepath.Join(dir, "project", LocalConfigFileName)
+	writeFile(t, localPath, `{
+  "exports": {"tools": ["*/*"], "daemon": true},
+  "audit": {"enabled": false},
+  "mcpServers": {}
+}`)
+
+	cfg, err := LoadConfigWithOptions(globalPath, LoadOptions{LocalConfig: localPath})
+	if err != nil {
+		t.Fatalf("LoadConfigWithOptions failed: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Exports.Tools, []string{"github/search_*"}) || !cfg.Exports.Daemon || cfg.Exports.Within == nil {
+		t.Errorf("want the global exports narrowed by the project's, got %+v", cfg.Exports)
+	}
+	if !cfg.Audit.IsEnabled() || !cfg.Audit.IncludeArgs {
+		t.Errorf("want the global audit settings kept, got %+v", cfg.Audit)
+	}
+	if len(cfg.Conflicts) != 1 || !strings.Contains(cfg.Conflicts[0], "audit in "+localPath+" is ignored") {
+		t.Errorf("expected a note about the ignored audit settings, got %q", cfg.Conflicts)
+	}
+
+	// A project can turn on an audit log the main config turned off
+	writeFile(t, globalPath, `{"audit": {"enabled": false}, "mcpServers": {"github": {"command": "github-mcp"}}}`)
+	writeFile(t, localPath, `{"audit": {"enabled": true}, "mcpServers": {}}`)
+	cfg, err = LoadConfigWithOptions(globalPath, LoadOptions{LocalConfig: localPath})
+	if err != nil {
+		t.Fatalf("LoadConfigWithOptions failed: %v", err)
+	}
+	if !cfg.Audit.IsEnabled() || cfg.Exports != nil {
+		t.Errorf("want the project's audit log on and nothing narrowed, got %+v, %+v", cfg.Audit, cfg.Exports)
+	}
+}
+
+func TestFindLocalConfigFileStopsAtRepositoryRoot(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	writeFile(t, filepath.Join(dir, LocalConfigFileName), `{"mcpServers": {}}`)
+
+	repo := filepath.Join(dir, "repo")
+	nested := filepath.Join(repo, "src", "pkg")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the repository root is looked in, not the directories on the way
+	chdir(t, nested)
+	writeFile(t, filepath.Join(nested, LocalConfigFileName), `{"mcpServers": {}}`)
+	if path, err := FindLocalConfigFile(); err == nil {
+		t.Fatalf("expected only the repository root to be searched, found %s", path)
+	}
+
+	writeFile(t, filepath.Join(repo, LocalConfigFileName), `{"mcpServers": {}}`)
+	path, err := FindLocalConfigFile()
+	if err != nil {
+		t.Fatalf("FindLocalConfigFile failed: %v", err)
+	}
+	if !sameFile(path, filepath.Join(repo, LocalConfigFileName)) {
+		t.Errorf("expected repository config, got %s", path)
+	}
+}
+
+func TestFindLocalConfigFileOutsideRepositories(t *testing.T) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	project := filepath.Join(home, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+
+	// A repository above the home directory is not looked for, nor are
+	// the files of the working directory's parents
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, LocalConfigFileName), `{"mcpServers": {}}`)
+	writeFile(t, filepath.Join(home, LocalConfigFileName), `{"mcpServers": {}}`)
+	chdir(t, project)
+	if path, err := FindLocalConfigFile(); err == nil {
+		t.Fatalf("expected no config outside the working directory, found %s", path)
+	}
+
+	writeFile(t, filepath.Join(project, LocalConfigFileName), `{"mcpServers": {}}`)
+	if path, err := FindLocalConfigFile(); err != nil || !sameFile(path, filepath.Join(project, LocalConfigFileName)) {
+		t.Errorf("expected the working directory's config, got %s, %v", path, err)
+	}
+}
+
+func TestFindLocalConfigFileRefusesWorldWritableFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not enforced on Windows")
+	}
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	localPath := filepath.Join(dir, LocalConfigFileName)
+	writeFile(t, localPath, `{"mcpServers": {}}`)
+	chdir(t, dir)
+
+	for name, chmod := range map[string]func() error{
+		"file":      func() error { return os.Chmod(localPath, 0666) },
+		"directory": func() error { return os.Chmod(dir, 0777) },
+	} {
+		if err := chmod(); err != nil {
+			t.Fatal(err)
+		}
+		var untrusted *UntrustedLocalConfigError
+		if _, err := FindLocalConfigFile(); !errors.As(err, &untrusted) || !strings.Contains(untrusted.Reason, "writable by every user") {
+			t.Errorf("%s writable by everyone: want the config refused, got %v", name, err)
+		}
+		_ = os.Chmod(localPath, 0600)
+		_ = os.Chmod(dir, 0700)
+	}
+}
+
+// writeFile writes content to path, creating parent directories
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// chdir changes the working directory for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(previous) })
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MCPRemotePackage is the npm package that bridges a remote MCP server to
+// stdio, which servers reached over HTTP have no need for
+const MCPRemotePackage = "mcp-remote"
+
+// RemoteServer is the remote server an mcp-remote bridge connects to
+type RemoteServer struct {
+	URL     string
+	Headers map[string]string
+}
+
+// mcpRemoteTransports are the --transport values the HTTP client can stand
+// in for; "sse-only" needs the legacy SSE transport
+var mcpRemoteTransports = map[string]bool{"http-only": true, "http-first": true, "sse-first": true}
+
+// mcpRemoteIgnored are mcp-remote options that change nothing a direct
+// connection needs
+var mcpRemoteIgnored = map[string]bool{"--allow-http": true, "--debug": true, "--silent": true}
+
+// envReference matches ${NAME} references to variables
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ParseMCPRemote recognises a server started as "npx mcp-remote <url>" and
+// returns the server it bridges to. ok is false for any other server. An
+// mcp-remote server whose options a direct connection cannot carry over,
+// such as OAuth or the legacy SSE transport, returns an error saying why.
+// References in --header values to variables of env are replaced by their
+// values, as the server's env is not there without the process.
+func ParseMCPRemote(command string, args []string, env map[string]string) (remote *RemoteServer, ok bool, err error) {
+	// Windows configurations run npx through cmd /c
+	if program(command) == "cmd" && len(args) > 0 && strings.EqualFold(args[0], "/c") {
+		if len(args) == 1 {
+			return nil, false, nil
+		}
+		command, args = args[1], args[2:]
+	}
+	if program(command) != "npx" {
+		return nil, false, nil
+	}
+
+	// npx's own options come before the package
+	rest, ok := npxPackageArgs(args)
+	if !ok {
+		return nil, false, nil
+	}
+
+	remote = &RemoteServer{}
+	for i := 0; i < len(rest); i++ {
+		arg := rest[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch {
+		case name == "--header" || name == "--transport":
+			if !hasValue {
+				if i+1 == len(rest) {
+					return nil, true, fmt.Errorf("%s needs a value", name)
+				}
+				i++
+				value = rest[i]
+			}
+			if name == "--transport" {
+				if !mcpRemoteTransports[value] {
+					return nil, true, fmt.Errorf("--transport %s needs the SSE transport, which has no native client", value)
+				}
+				continue
+			}
+			header, headerValue, found := strings.Cut(value, ":")
+			if header = strings.TrimSpace(header); !found || header == "" {
+				return nil, true, fmt.Errorf("invalid --header '%s'", value)
+			}
+			if remote.Headers == nil {
+				remote.Headers = make(map[string]string)
+			}
+			remote.Headers[header] = inlineEnv(strings.TrimSpace(headerValue), env)
+		case mcpRemoteIgnored[arg]:
+		case strings.HasPrefix(arg, "-"):
+			return nil, true, fmt.Errorf("unrecognised mcp-remote option %s", arg)
+		case remote.URL == "":
+			remote.URL = arg
+		default:
+			return nil, true, fmt.Errorf("the argument %s after the URL sets an OAuth callback port, and OAuth is not supported", arg)
+		}
+	}
+
+	if remote.URL == "" {
+		return nil, true, fmt.Errorf("no server URL")
+	}
+	parsed, err := url.Parse(remote.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, true, fmt.Errorf("'%s' is not an HTTP URL", remote.URL)
+	}
+	if strings.HasSuffix(strings.TrimSuffix(parsed.Path, "/"), "/sse") {
+		return nil, true, fmt.Errorf("%s is served with the SSE transport, which has no native client", remote.URL)
+	}
+	return remote, true, nil
+}
+
+// npxPackageArgs returns the arguments npx passes to mcp-remote, and false if
+// npx runs some other package
+func npxPackageArgs(args []string) ([]string, bool) {
+	runsRemote := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-y" || arg == "--yes" || arg == "-q" || arg == "--quiet":
+		case arg == "-p" || arg == "--package":
+			if i+1 < len(args) {
+				i++
+				runsRemote = runsRemote || isMCPRemote(args[i])
+			}
+		case strings.HasPrefix(arg, "--package="):
+			runsRemote = runsRemote || isMCPRemote(strings.TrimPrefix(arg, "--package="))
+		case strings.HasPrefix(arg, "-"):
+			return nil, false
+		default:
+			// With --package, the command is named without a version
+			if isMCPRemote(arg) || (runsRemote && arg == MCPRemotePackage) {
+				return args[i+1:], true
+			}
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// isMCPRemote reports whether an npm package spec names mcp-remote, at any
+// version
+func isMCPRemote(spec string) bool {
+	name, _, _ := strings.Cut(spec, "@")
+	return name == MCPRemotePackage
+}
+
+// program returns the name of command without its directory or a Windows
+// executable extension
+func program(command string) string {
+	name := strings.ToLower(filepath.Base(strings.ReplaceAll(command, `\`, "/")))
+	for _, ext := range []string{".cmd", ".exe", ".bat"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// inlineEnv replaces references to variables of env in value by their
+// values, leaving references to other variables
+func inlineEnv(value string, env map[string]string) string {
+	return envReference.ReplaceAllStringFunc(value, func(ref string) string {
+		if replacement, ok := env[envReference.FindStringSubmatch(ref)[1]]; ok {
+			return replacement
+		}
+		return ref
+	})
+}
+
+// mcpRemoteProcessFields are the settings of a server entry that belong to
+// the mcp-remote process and go when the entry connects over HTTP instead
+var mcpRemoteProcessFields = []string{"command", "args", "env", "secretEnv", "framing"}
+
+// MigrateMCPRemote rewrites a server entry of a configuration file, as
+// decoded JSON, that runs mcp-remote into one connecting to the remote
+// server over HTTP. The process's settings give way to type, url and
+// headers; all others are kept. ok and err are as for ParseMCPRemote.
+func MigrateMCPRemote(entry map[string]interface{}) (migrated map[string]interface{}, ok bool, err error) {
+	command, _ := entry["command"].(string)
+	args := stringList(entry["args"])
+	env := make(map[string]string)
+	if values, isMap := entry["env"].(map[string]interface{}); isMap {
+		for name, value := range values {
+			if text, isString := value.(string); isString {
+				env[name] = text
+			}
+		}
+	}
+	remote, ok, err := ParseMCPRemote(command, args, env)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+
+	migrated = make(map[string]interface{}, len(entry))
+	for key, value := range entry {
+		migrated[key] = value
+	}
+	for _, key := range mcpRemoteProcessFields {
+		delete(migrated, key)
+	}
+	migrated["type"] = "http"
+	migrated["url"] = remote.URL
+	if len(remote.Headers) > 0 {
+		headers := make(map[string]interface{})
+		if existing, isMap := entry["headers"].(map[string]interface{}); isMap {
+			for name, value := range existing {
+				headers[name] = value
+			}
+		}
+		for name, value := range remote.Headers {
+			headers[name] = value
+		}
+		migrated["headers"] = headers
+	}
+	return migrated, true, nil
+}
+
+// stringList returns the strings of a decoded JSON array
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if text, ok := item.(string); ok {
+			list = append(list, text)
+		}
+	}
+	return list
+}
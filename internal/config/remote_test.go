@@ -0,0 +1,143 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMCPRemote(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		env     map[string]string
+		want    *RemoteServer
+		wantErr string // Part of the error for mcp-remote servers left as they are
+	}{
+		{
+			name:    "example configuration",
+			command: "npx",
+			args:    []string{"-y", "mcp-remote", "https://mcp.deepwiki.com/mcp"},
+			want:    &RemoteServer{URL: "https://mcp.deepwiki.com/mcp"},
+		},
+		{
+			name:    "pinned version with a bearer header",
+			command: "npx",
+			args:    []string{"mcp-remote@0.1.16", "https://mcp.linear.app/mcp", "--header", "Authorization: Bearer ${LINEAR_TOKEN}"},
+			want:    &RemoteServer{URL: "https://mcp.linear.app/mcp", Headers: map[string]string{"Authorization": "Bearer ${LINEAR_TOKEN}"}},
+		},
+		{
+			name:    "header from the server's env, the layout mcp-remote documents",
+			command: "npx",
+			args:    []string{"-y", "mcp-remote@latest", "https://remote.mcp.server/mcp", "--header", "Authorization:${AUTH_HEADER}", "--transport", "http-only"},
+			env:     map[string]string{"AUTH_HEADER": "Bearer ${API_TOKEN}"},
+			want:    &RemoteServer{URL: "https://remote.mcp.server/mcp", Headers: map[string]string{"Authorization": "Bearer ${API_TOKEN}"}},
+		},
+		{
+			name:    "windows, through cmd /c",
+			command: "cmd",
+			args:    []string{"/c", "npx", "-y", "mcp-remote", "http://localhost:8080/mcp", "--allow-http", "--header=X-Team: core"},
+			want:    &RemoteServer{URL: "http://localhost:8080/mcp", Headers: map[string]string{"X-Team": "core"}},
+		},
+		{
+			name:    "package given with -p",
+			command: `C:\Program Files\nodejs\npx.cmd`,
+			args:    []string{"--yes", "-p", "mcp-remote@0.1.29", "mcp-remote", "https://mcp.context7.com/mcp", "--debug"},
+			want:    &RemoteServer{URL: "https://mcp.context7.com/mcp"},
+		},
+		{
+			name:    "legacy SSE endpoint",
+			command: "npx",
+			args:    []string{"-y", "mcp-remote", "https://mcp.example.com/sse"},
+			wantErr: "SSE transport",
+		},
+		{
+			name:    "SSE only",
+			command: "npx",
+			args:    []string{"mcp-remote", "https://mcp.example.com/mcp", "--transport", "sse-only"},
+			wantErr: "SSE transport",
+		},
+		{
+			name:    "OAuth callback port",
+			command: "npx",
+			args:    []string{"mcp-remote", "https://mcp.atlassian.com/v1/mcp", "3334"},
+			wantErr: "OAuth",
+		},
+		{
+			name:    "unrecognised option",
+			command: "npx",
+			args:    []string{"mcp-remote", "https://mcp.example.com/mcp", "--static-oauth-client-info", "@client.json"},
+			wantErr: "--static-oauth-client-info",
+		},
+		{
+			name:    "no URL",
+			command: "npx",
+			args:    []string{"-y", "mcp-remote"},
+			wantErr: "no server URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, ok, err := ParseMCPRemote(tt.command, tt.args, tt.env)
+			if !ok {
+				t.Fatal("not recognised as mcp-remote")
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(remote, tt.want) {
+				t.Errorf("remote = %+v, want %+v", remote, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMCPRemoteIgnoresOtherServers(t *testing.T) {
+	for _, command := range [][]string{
+		{"npx", "-y", "chrome-devtools-mcp@latest"},
+		{"npx", "-y", "@modelcontextprotocol/server-filesystem", "/tmp"},
+		{"uvx", "mcp-remote", "https://mcp.example.com/mcp"},
+		{"npx", "--node-options=--max-old-space-size=4096", "mcp-remote", "https://mcp.example.com/mcp"},
+		{"cmd", "/c"},
+	} {
+		if _, ok, _ := ParseMCPRemote(command[0], command[1:], nil); ok {
+			t.Errorf("%q should not be taken for mcp-remote", command)
+		}
+	}
+}
+
+func TestMigrateMCPRemote(t *testing.T) {
+	entry := map[string]interface{}{
+		"command":     "npx",
+		"args":        []interface{}{"-y", "mcp-remote", "https://mcp.deepwiki.com/mcp", "--header", "X-Client: cli"},
+		"env":         map[string]interface{}{"NODE_OPTIONS": "--no-warnings"},
+		"description": "Repository documentation",
+		"timeout":     30.0,
+		"headers":     map[string]interface{}{"User-Agent": "mcp-cli-ent"},
+	}
+	migrated, ok, err := MigrateMCPRemote(entry)
+	if !ok || err != nil {
+		t.Fatalf("ok %v, err %v", ok, err)
+	}
+	want := map[string]interface{}{
+		"type":        "http",
+		"url":         "https://mcp.deepwiki.com/mcp",
+		"description": "Repository documentation",
+		"timeout":     30.0,
+		"headers":     map[string]interface{}{"User-Agent": "mcp-cli-ent", "X-Client": "cli"},
+	}
+	if !reflect.DeepEqual(migrated, want) {
+		t.Errorf("migrated = %v, want %v", migrated, want)
+	}
+	if entry["command"] != "npx" {
+		t.Error("the original entry should be left unchanged")
+	}
+}
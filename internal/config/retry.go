@@ -0,0 +1,48 @@
+package config
+
+import "time"
+
+// Failure kinds a retry policy can retry
+const (
+	RetryOnStartup    = "startup"    // The server failed before answering any request
+	RetryOnTimeout    = "timeout"    // The request timed out
+	RetryOnConnection = "connection" // The connection was refused, reset or closed
+)
+
+// MaxRetryAttempts bounds retry.maxAttempts
+const MaxRetryAttempts = 10
+
+// DefaultRetryDelay is the delay before the first retry
+const DefaultRetryDelay = 500 * time.Millisecond
+
+// Attempts returns how many times a request may be tried
+func (r *RetryConfig) Attempts() int {
+	if r == nil || r.MaxAttempts < 1 {
+		return 1
+	}
+	return r.MaxAttempts
+}
+
+// InitialDelay returns the delay before the first retry
+func (r *RetryConfig) InitialDelay() time.Duration {
+	if r == nil || r.InitialDelayMs == 0 {
+		return DefaultRetryDelay
+	}
+	return time.Duration(r.InitialDelayMs) * time.Millisecond
+}
+
+// Retries reports whether failures of the given kind are retried
+func (r *RetryConfig) Retries(kind string) bool {
+	if r.Attempts() < 2 {
+		return false
+	}
+	if len(r.RetryOn) == 0 {
+		return true
+	}
+	for _, k := range r.RetryOn {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package config
+
+// ServerTypeSSH is the type of stdio servers run on a remote host over ssh
+const ServerTypeSSH = "ssh"
+
+// SSHCommand is the ssh client used to reach remote servers
+const SSHCommand = "ssh"
+
+// IsSSH reports whether the server runs on a remote host over ssh
+func (c *ServerConfig) IsSSH() bool {
+	return c.Type == ServerTypeSSH
+}
+
+// SSHDestination returns the ssh destination, user@host or host
+func (c *ServerConfig) SSHDestination() string {
+	if c.User == "" {
+		return c.Host
+	}
+	return c.User + "@" + c.Host
+}
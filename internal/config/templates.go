@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// expandTemplates applies the templates each server extends. Template fields
+// are copied into the server and then overridden by the server's own fields:
+// objects are merged key by key, while arrays and scalar values are replaced.
+// Templates may extend other templates.
+func expandTemplates(servers, templates map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	expanded := make(map[string]json.RawMessage, len(servers))
+	for _, name := range sortedKeys(servers) {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(servers[name], &fields); err != nil {
+			return nil, fmt.Errorf("failed to parse server '%s': %w", name, err)
+		}
+
+		if _, extends := fields["extends"]; !extends {
+			expanded[name] = servers[name]
+			continue
+		}
+
+		fields, err := applyTemplate(fields, templates, []string{name})
+		if err != nil {
+			return nil, fmt.Errorf("server '%s': %w", name, err)
+		}
+
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand server '%s': %w", name, err)
+		}
+		expanded[name] = raw
+	}
+	return expanded, nil
+}
+
+// applyTemplate merges fields over the template they extend, recursively.
+// chain holds the server name followed by the templates visited so far.
+func applyTemplate(fields map[string]interface{}, templates map[string]json.RawMessage, chain []string) (map[string]interface{}, error) {
+	parent, ok := fields["extends"]
+	if !ok {
+		return fields, nil
+	}
+
+	name, ok := parent.(string)
+	if !ok || name == "" {
+		return nil, &ConfigError{fmt.Sprintf("extends must be a template name (%s)", strings.Join(chain, " -> "))}
+	}
+
+	chain = append(chain, name)
+	for _, visited := range chain[1 : len(chain)-1] {
+		if visited == name {
+			return nil, &ConfigError{fmt.Sprintf("circular template reference: %s", strings.Join(chain, " -> "))}
+		}
+	}
+
+	raw, ok := templates[name]
+	if !ok {
+		return nil, &ConfigError{fmt.Sprintf("template '%s' not found: %s", name, strings.Join(chain, " -> "))}
+	}
+
+	var base map[string]interface{}
+	if err := json.Unmarshal(raw, &base); err != nil {
+		return nil, fmt.Errorf("failed to parse template '%s': %w", name, err)
+	}
+
+	base, err := applyTemplate(base, templates, chain)
+	if err != nil {
+		return nil, err
+	}
+	return deepMerge(base, fields), nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandTemplates(t *testing.T) {
+	templates := map[string]json.RawMessage{
+		"stdio":   json.RawMessage(`{"command": "npx", "timeout": 60, "env": {"LOG_LEVEL": "info"}}`),
+		"browser": json.RawMessage(`{"extends": "stdio", "args": ["-y", "chrome-devtools-mcp@latest"], "env": {"HEADLESS": "true"}, "tags": ["browser"]}`),
+		"loop-a":  json.RawMessage(`{"extends": "loop-b"}`),
+		"loop-b":  json.RawMessage(`{"extends": "loop-a"}`),
+		"self":    json.RawMessage(`{"extends": "self"}`),
+		"broken":  json.RawMessage(`{"extends": "missing"}`),
+	}
+
+	tests := []struct {
+		name   string
+		server string
+		want   string
+		err    string
+	}{
+		{
+			name:   "server without extends is unchanged",
+			server: `{"command": "uvx", "args": ["mcp-server-time"]}`,
+			want:   `{"command": "uvx", "args": ["mcp-server-time"]}`,
+		},
+		{
+			name:   "server fields override template fields",
+			server: `{"extends": "stdio", "args": ["-y", "server"], "timeout": 30}`,
+			want:   `{"extends": "stdio", "command": "npx", "args": ["-y", "server"], "timeout": 30, "env": {"LOG_LEVEL": "info"}}`,
+		},
+		{
+			name:   "nested extends merge maps and replace slices",
+			server: `{"extends": "browser", "args": ["-y", "chrome-devtools-mcp@latest", "--isolated"], "env": {"LOG_LEVEL": "debug"}}`,
+			want: `{"extends": "browser", "command": "npx", "timeout": 60, "tags": ["browser"],
+				"args": ["-y", "chrome-devtools-mcp@latest", "--isolated"],
+				"env": {"LOG_LEVEL": "debug", "HEADLESS": "true"}}`,
+		},
+		{
+			name:   "missing template",
+			server: `{"extends": "nope"}`,
+			err:    "template 'nope' not found: server -> nope",
+		},
+		{
+			name:   "missing nested template",
+			server: `{"extends": "broken"}`,
+			err:    "template 'missing' not found: server -> broken -> missing",
+		},
+		{
+			name:   "circular templates",
+			server: `{"extends": "loop-a"}`,
+			err:    "circular template reference: server -> loop-a -> loop-b -> loop-a",
+		},
+		{
+			name:   "template extending itself",
+			server: `{"extends": "self"}`,
+			err:    "circular template reference: server -> self -> self",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := map[string]json.RawMessage{"server": json.RawMessage(tt.server)}
+			expanded, err := expandTemplates(servers, templates)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandTemplates failed: %v", err)
+			}
+
+			var got, want map[string]interface{}
+			if err := json.Unmarshal(expanded["server"], &got); err != nil {
+				t.Fatalf("invalid expanded entry: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("invalid expected entry: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestLoadConfigValidatesExpandedServers(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mcp_servers.json")
+	writeFile(t, configPath, `{
+		"templates": {"python": {"command": "uvx", "env": {"TZ": "UTC"}}},
+		"mcpServers": {
+			"time": {"extends": "python", "args": ["mcp-server-time"]},
+			"fetch": {"extends": "python", "args": ["mcp-server-fetch"], "env": {"TZ": "Europe/Madrid"}}
+		}
+	}`)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	timeServer := cfg.MCPServers["time"]
+	if got := timeServer.GetServerDetails(); got != "uvx mcp-server-time" {
+		t.Errorf("expected expanded details, got %q", got)
+	}
+	if timeServer.Env["TZ"] != "UTC" || cfg.MCPServers["fetch"].Env["TZ"] != "Europe/Madrid" {
+		t.Errorf("unexpected env: time=%v fetch=%v", timeServer.Env, cfg.MCPServers["fetch"].Env)
+	}
+	if _, ok := cfg.Templates["python"]; !ok {
+		t.Error("expected templates to be loaded")
+	}
+
+	// A template without command or URL leaves the server invalid
+	writeFile(t, configPath, `{
+		"templates": {"base": {"timeout": 60}},
+		"mcpServers": {"time": {"extends": "base"}}
+	}`)
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("expected validation of the expanded server to fail")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package config
+
+// untrustedReason says why the file at path may have been written by
+// someone other than the user. Ownership and permission bits don't carry
+// over to Windows' ACLs, so nothing is refused there.
+func untrustedReason(path string) string {
+	return ""
+}
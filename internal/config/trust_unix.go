@@ -0,0 +1,32 @@
+//go:build unix
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// untrustedReason says why the file at path may have been written by
+// someone other than the user, or returns "" if it can't have been
+func untrustedReason(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err.Error()
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return "it is owned by another user"
+	}
+	if info.Mode().Perm()&0o002 != 0 {
+		return "it is writable by every user"
+	}
+	dirInfo, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return err.Error()
+	}
+	if dirInfo.Mode().Perm()&0o002 != 0 {
+		return "its directory is writable by every user"
+	}
+	return ""
+}
@@ -1,20 +1,48 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
-	"regexp"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/redact"
 )
 
 // Configuration represents the MCP servers configuration
 type Configuration struct {
-	MCPServers map[string]ServerConfig `json:"mcpServers"`
+	MCPServers  map[string]ServerConfig `json:"mcpServers"`
+	Templates   map[string]ServerConfig `json:"templates,omitempty"`   // Shared settings servers can extend
+	EnvFile     string                  `json:"envFile,omitempty"`     // .env file loaded before resolving variables (relative to the config file)
+	EnvOverride bool                    `json:"envOverride,omitempty"` // Let env file values replace variables already set in the environment
+
+	AllowCommandSubstitution bool `json:"allowCommandSubstitution,omitempty"` // Run $(command) references in headers, env and args
+	ConfigWatch              bool `json:"configWatch,omitempty"`              // Reload this file when it changes in long-lived modes (the daemon)
+
+	ToolCacheTTL     string `json:"toolCacheTTL,omitempty"`     // How long listed tools are cached on disk, e.g. "1h" (default 10m; "0" disables the cache)
+	ResultCacheMaxMB int    `json:"resultCacheMaxMB,omitempty"` // Size at which the least recently used cached tool results are evicted (default 50)
+	MaxResponseMB    int    `json:"maxResponseMB,omitempty"`    // Largest message read from any server (default 64)
+
+	Serve   ServeConfig    `json:"serve,omitempty"`   // Settings for serving the configuration as one MCP server
+	Exports *ExportsConfig `json:"exports,omitempty"` // The subset of tools, prompts and resources that serve publishes
+	Audit   *AuditConfig   `json:"audit,omitempty"`   // How tool calls are recorded in the audit log
+	Daemon  *DaemonSection `json:"daemon,omitempty"`  // Which daemon instance commands use
+
+	Conflicts []string `json:"-"` // How the local config overrode the main one, for verbose output
 }
 
 // ServerConfig represents configuration for a single MCP server
 type ServerConfig struct {
 	Enabled     *bool             `json:"enabled,omitempty"`
 	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Requires    []string          `json:"requires,omitempty"`
 	Type        string            `json:"type,omitempty"`
 	URL         string            `json:"url,omitempty"`
 	Command     string            `json:"command,omitempty"`
@@ -24,6 +52,189 @@ type ServerConfig struct {
 	Timeout     int               `json:"timeout,omitempty"`
 	Session     SessionConfig     `json:"session,omitempty"`
 	Persistent  bool              `json:"persistent,omitempty"`
+	Hooks       *HooksConfig      `json:"hooks,omitempty"`
+	Retry       *RetryConfig      `json:"retry,omitempty"`
+	Framing     string            `json:"framing,omitempty"` // How stdio messages are delimited: "ndjson" (default), "content-length" or "auto"
+
+	ProtocolVersion        string                 `json:"protocolVersion,omitempty"`        // MCP revision claimed in the handshake instead of the client's, e.g. "2024-11-05"
+	InitializeOptions      map[string]interface{} `json:"initializeOptions,omitempty"`      // Settings sent in the initialize request; environment variables are resolved in its strings
+	InitializeOptionsField string                 `json:"initializeOptionsField,omitempty"` // Where initializeOptions go: "experimental" (default, merged into capabilities.experimental) or a top-level parameter such as "initializationOptions"
+
+	MaxResponseMB int      `json:"maxResponseMB,omitempty"` // Largest message read from this server, overriding the top-level limit
+	SecretEnv     []string `json:"secretEnv,omitempty"`     // Env variables whose values are secret, besides those named like one (e.g. API_KEY)
+
+	ToolDefaults  map[string]map[string]interface{} `json:"toolDefaults,omitempty"`  // Arguments merged into tool calls, keyed by tool name or "*"
+	DisabledTools []string                          `json:"disabledTools,omitempty"` // Glob patterns of tools hidden from listings
+	ExportAs      map[string]string                 `json:"exportAs,omitempty"`      // Names the serve command exports tools and prompts under, keyed by their own name
+	CacheTools    map[string]ToolCacheConfig        `json:"cacheTools,omitempty"`    // Tools whose results are cached, keyed by tool name or "*" for read-only tools
+
+	Image      string   `json:"image,omitempty"`      // Container image run by docker servers
+	DockerArgs []string `json:"dockerArgs,omitempty"` // Extra options passed to "docker run" before the image
+	Volumes    []string `json:"volumes,omitempty"`    // Volume mounts, as host:container[:options]
+	Network    string   `json:"network,omitempty"`    // Network the container joins
+	Runtime    string   `json:"runtime,omitempty"`    // Container runtime: "docker" (default) or "podman"
+
+	Host          string   `json:"host,omitempty"`          // Remote host of ssh servers
+	User          string   `json:"user,omitempty"`          // Remote user (default: ssh's own choice)
+	Port          int      `json:"port,omitempty"`          // Remote ssh port (default 22)
+	IdentityFile  string   `json:"identityFile,omitempty"`  // Private key used to log in
+	RemoteCommand string   `json:"remoteCommand,omitempty"` // Command starting the MCP server on the remote host
+	SSHOptions    []string `json:"sshOptions,omitempty"`    // Extra ssh -o options, e.g. "ControlMaster=auto"
+
+	Extends       string `json:"extends,omitempty"`       // Template whose fields this server inherits
+	MergeStrategy string `json:"mergeStrategy,omitempty"` // How a local entry combines with a global one: "replace" (default) or "patch"
+	Source        string `json:"-"`                       // Config file the server was loaded from
+
+	templates       *templateValues // Values as written in the config file, before resolution
+	allowCommands   bool            // Run $(command) substitutions when resolving
+	missingRequired []string        // Required variables that were unset when the config was loaded
+}
+
+// Framings of the messages exchanged with stdio servers
+const (
+	FramingNDJSON        = "ndjson"         // One JSON message per line (default)
+	FramingContentLength = "content-length" // Messages preceded by a Content-Length header, as in LSP
+	FramingAuto          = "auto"           // Whichever the server's first output uses; ndjson until it has written anything
+)
+
+// Namespace styles for the tool and prompt names the serve command exports
+const (
+	NamespacePrefix = "prefix" // server__tool (default)
+	NamespaceSuffix = "suffix" // tool__server
+	NamespaceNone   = "none"   // tool, which must then be unique across servers
+)
+
+// DefaultNamespaceSeparator joins server and tool names in exported names
+const DefaultNamespaceSeparator = "__"
+
+// ServeConfig configures the serve command
+type ServeConfig struct {
+	Token          string `json:"token,omitempty"`          // Bearer token HTTP clients must send; environment variables are resolved
+	NamespaceStyle string `json:"namespaceStyle,omitempty"` // How exported names are qualified: "prefix" (default), "suffix" or "none"
+	Separator      string `json:"separator,omitempty"`      // Joins server and tool names (default "__")
+	MaxSessions    int    `json:"maxSessions,omitempty"`    // HTTP client sessions open at once (default 100)
+}
+
+// Validate reports invalid serve settings
+func (s ServeConfig) Validate() []ValidationIssue {
+	var issues []ValidationIssue
+	switch s.NamespaceStyle {
+	case "", NamespacePrefix, NamespaceSuffix, NamespaceNone:
+	default:
+		issues = append(issues, ValidationIssue{
+			Field:   "serve.namespaceStyle",
+			Message: fmt.Sprintf("invalid value %q (use %q, %q or %q)", s.NamespaceStyle, NamespacePrefix, NamespaceSuffix, NamespaceNone),
+		})
+	}
+	if s.MaxSessions < 0 {
+		issues = append(issues, ValidationIssue{Field: "serve.maxSessions", Message: "must not be negative"})
+	}
+	return issues
+}
+
+// ExportsConfig limits what the serve command, and optionally the daemon,
+// publish. Entries are "server/glob", where "*" matches any run of characters
+// (including "/" in resource URIs); kinds without entries export nothing.
+type ExportsConfig struct {
+	Tools     []string `json:"tools,omitempty"`     // server/toolGlob entries, e.g. "github/search_*"
+	Prompts   []string `json:"prompts,omitempty"`   // server/promptGlob entries
+	Resources []string `json:"resources,omitempty"` // server/uriGlob entries
+	Daemon    bool     `json:"daemon,omitempty"`    // Enforce the tool entries in the daemon API too
+
+	Within *ExportsConfig `json:"-"` // A project config's exports, which items must match as well
+}
+
+// Validate reports malformed export entries
+func (e *ExportsConfig) Validate() []ValidationIssue {
+	if e == nil {
+		return nil
+	}
+
+	var issues []ValidationIssue
+	check := func(field string, entries []string) {
+		for _, entry := range entries {
+			server, name, ok := strings.Cut(entry, "/")
+			if !ok || server == "" || name == "" {
+				issues = append(issues, ValidationIssue{
+					Field:   "exports." + field,
+					Message: fmt.Sprintf("invalid entry %q (use \"server/pattern\", e.g. \"github/search_*\")", entry),
+				})
+			}
+		}
+	}
+	check("tools", e.Tools)
+	check("prompts", e.Prompts)
+	check("resources", e.Resources)
+	return append(issues, e.Within.Validate()...)
+}
+
+// AuditConfig configures the audit log, which records every tool call made
+// through the CLI or the daemon
+type AuditConfig struct {
+	Enabled      *bool `json:"enabled,omitempty"`      // Defaults to true
+	IncludeArgs  bool  `json:"includeArgs,omitempty"`  // Record arguments in full, not only their hash
+	ExpandedArgs bool  `json:"expandedArgs,omitempty"` // Record arguments given --expand-env as expanded rather than as written
+	MaxSizeMB    int   `json:"maxSizeMB,omitempty"`    // Size at which the log rotates (default 10)
+}
+
+// RecordsExpandedArgs returns whether calls whose arguments had environment
+// variables expanded are recorded with the expanded values
+func (a *AuditConfig) RecordsExpandedArgs() bool {
+	return a != nil && a.ExpandedArgs
+}
+
+// IsEnabled returns whether tool calls are recorded; they are unless disabled
+func (a *AuditConfig) IsEnabled() bool {
+	return a == nil || a.Enabled == nil || *a.Enabled
+}
+
+// Validate reports invalid audit settings
+func (a *AuditConfig) Validate() []ValidationIssue {
+	if a == nil || a.MaxSizeMB >= 0 {
+		return nil
+	}
+	return []ValidationIssue{{Field: "audit.maxSizeMB", Message: "must not be negative"}}
+}
+
+// DaemonSection selects the daemon instance, so projects whose sessions must
+// not share state can each have their own, and which calls go through it
+type DaemonSection struct {
+	Name    string `json:"name,omitempty"`    // Namespaces the daemon's endpoint, PID and log files and sessions (default: the shared instance)
+	Routing string `json:"routing,omitempty"` // Which servers' calls go through the daemon: "auto", "always" or "never" (default "auto")
+}
+
+// How calls are routed through the daemon
+const (
+	DaemonRoutingAuto   = "auto"   // Persistent servers only
+	DaemonRoutingAlways = "always" // Every server, connecting directly only when the daemon cannot start
+	DaemonRoutingNever  = "never"  // No server
+)
+
+// RoutingMode returns daemon.routing, DaemonRoutingAuto when unset
+func (d *DaemonSection) RoutingMode() string {
+	if d == nil || d.Routing == "" {
+		return DaemonRoutingAuto
+	}
+	return d.Routing
+}
+
+// Validate reports an invalid daemon name or routing
+func (d *DaemonSection) Validate() []ValidationIssue {
+	if d == nil {
+		return nil
+	}
+	var issues []ValidationIssue
+	if d.Name != "" {
+		if err := ValidateDaemonName(d.Name); err != nil {
+			issues = append(issues, ValidationIssue{Field: "daemon.name", Message: err.Error()})
+		}
+	}
+	switch d.RoutingMode() {
+	case DaemonRoutingAuto, DaemonRoutingAlways, DaemonRoutingNever:
+	default:
+		issues = append(issues, ValidationIssue{Field: "daemon.routing", Message: fmt.Sprintf("must be %s, %s or %s, not '%s'", DaemonRoutingAuto, DaemonRoutingAlways, DaemonRoutingNever, d.Routing)})
+	}
+	return issues
 }
 
 // SessionConfig contains session-specific configuration for a server
@@ -31,8 +242,31 @@ type SessionConfig struct {
 	Type        string `json:"type,omitempty"`        // "persistent", "stateless", "hybrid"
 	AutoStart   bool   `json:"autoStart,omitempty"`   // Auto-start session on first use
 	Timeout     int    `json:"timeout,omitempty"`     // Session timeout in seconds
-	MaxIdle     int    `json:"maxIdle,omitempty"`     // Max idle time before auto-stop
+	MaxIdle     *int   `json:"maxIdle,omitempty"`     // Max idle time in seconds before auto-stop (0 or -1 never expires)
 	HealthCheck bool   `json:"healthCheck,omitempty"` // Enable periodic health checks
+	Strict      bool   `json:"strict,omitempty"`      // Disable hybrid fallback to stateless
+
+	HealthCheckInterval int  `json:"healthCheckInterval,omitempty"` // Seconds between background health checks (default 60)
+	RestartOnFailure    bool `json:"restartOnFailure,omitempty"`    // Restart the session when health checks keep failing
+
+	Retention      string `json:"retention,omitempty"`      // How long to keep idle session records, e.g. "72h" or "7d" (default 24h)
+	ErrorRetention string `json:"errorRetention,omitempty"` // How long to keep errored session records (default 1h)
+}
+
+// RetryConfig controls retries of requests that fail for transient reasons
+type RetryConfig struct {
+	MaxAttempts    int      `json:"maxAttempts,omitempty"`    // Attempts per request, including the first (default 1: no retries)
+	InitialDelayMs int      `json:"initialDelayMs,omitempty"` // Delay before the first retry, doubled for each further one (default 500)
+	RetryOn        []string `json:"retryOn,omitempty"`        // Failure kinds to retry: "startup", "timeout", "connection" (default all)
+}
+
+// HooksConfig contains commands run on session lifecycle events
+type HooksConfig struct {
+	OnStart   string `json:"onStart,omitempty"`   // Run after the session starts
+	OnStop    string `json:"onStop,omitempty"`    // Run when the session is being stopped
+	OnError   string `json:"onError,omitempty"`   // Run when the session enters an error state
+	OnRestart string `json:"onRestart,omitempty"` // Run when the session is restarted
+	Timeout   int    `json:"timeout,omitempty"`   // Hook timeout in seconds (default 30)
 }
 
 // ServerStatus represents the status of a server
@@ -42,6 +276,10 @@ type ServerStatus struct {
 	Status  string `json:"status"` // "enabled" or "disabled"
 	Details string `json:"details"`
 	Error   string `json:"error,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Reason  string `json:"reason,omitempty"` // Why the server is disabled
+
+	Tags []string `json:"tags,omitempty"`
 }
 
 // getEnvWithFallback retrieves an environment variable with an ENT_ prefix fallback.
@@ -60,76 +298,258 @@ func getEnvWithFallback(varName string) string {
 	return ""
 }
 
-// ResolveEnvironmentVariables substitutes environment variables in string values
-// Supports ${VAR_NAME} and $VAR_NAME formats.
+// ResolveEnvironmentVariables substitutes environment variables in string values.
+// Supports ${VAR_NAME}, $VAR_NAME and %VAR_NAME% formats plus the
+// ${VAR:-default}, ${VAR:?message}, $$ and %% forms described in
+// ExpandVariables; failed
+// ${VAR:?message} references are kept literally.
 // For each variable, it checks the unprefixed name first, then falls back to ENT_ prefixed.
 func ResolveEnvironmentVariables(input string) string {
-	// Match ${VAR_NAME} pattern
-	re := regexp.MustCompile(`\$\{([^}]+)\}`)
-	result := re.ReplaceAllStringFunc(input, func(match string) string {
-		varName := strings.Trim(match, "${}")
-		if value := getEnvWithFallback(varName); value != "" {
-			return value
-		}
-		return match // Keep original if environment variable not found
-	})
-
-	// Match $VAR_NAME pattern (simple variables)
-	re2 := regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
-	result = re2.ReplaceAllStringFunc(result, func(match string) string {
-		varName := strings.Trim(match, "$")
-		if value := getEnvWithFallback(varName); value != "" {
-			return value
-		}
-		return match // Keep original if environment variable not found
-	})
-
+	result, _ := ExpandVariables(input)
 	return result
 }
 
-// ResolveHeaders resolves environment variables in header values
-func (c *ServerConfig) ResolveHeaders() {
+// templateValues holds header, env, arg and initialize option values before
+// environment variable resolution
+type templateValues struct {
+	headers           map[string]string
+	env               map[string]string
+	args              []string
+	initializeOptions map[string]interface{}
+}
+
+// recordTemplates remembers the unresolved values before the first resolution
+func (c *ServerConfig) recordTemplates() {
+	if c.templates != nil {
+		return
+	}
+	c.templates = &templateValues{
+		headers:           c.Headers,
+		env:               c.Env,
+		args:              c.Args,
+		initializeOptions: c.InitializeOptions,
+	}
+}
+
+// MarkTemplates records the current header, env and arg values as their own
+// templates, for configs read back from disk that were never resolved
+func (c *ServerConfig) MarkTemplates() {
+	c.templates = nil
+	c.recordTemplates()
+}
+
+// Unresolved returns a copy of the configuration with environment variable
+// references restored, so it can be written to disk without the secrets they
+// resolve to. Header, env and initialize option values whose templates are
+// unknown are redacted, and so are the secrets in args: those the Redactor
+// knows, and the values of flags named like secrets.
+func (c ServerConfig) Unresolved() ServerConfig {
+	if c.templates != nil {
+		c.Headers = c.templates.headers
+		c.Env = c.templates.env
+		c.Args = c.templates.args
+		c.InitializeOptions = c.templates.initializeOptions
+		return c
+	}
+
+	c.Args = redactArgs(c.Args, c.Redactor())
+	c.Headers = redactValues(c.Headers)
+	c.Env = redactValues(c.Env)
+	if c.InitializeOptions != nil {
+		c.InitializeOptions = mapStrings(c.InitializeOptions, func(string) string { return RedactedValue }).(map[string]interface{})
+	}
+	return c
+}
+
+// Redactor returns what scrubs the server's secrets from error messages and
+// other output: the values of headers, env variables and initialize options
+// named like secrets, and of the variables listed in secretEnv
+func (c ServerConfig) Redactor() *redact.Redactor {
+	secrets := append(redact.Values(c.Headers), redact.Values(c.Env, c.SecretEnv...)...)
+	return redact.New(append(secrets, optionSecrets(c.InitializeOptions)...)...)
+}
+
+// optionSecrets returns the string values of the members of options, at any
+// depth, named like secrets
+func optionSecrets(options interface{}) []string {
+	var secrets []string
+	switch options := options.(type) {
+	case map[string]interface{}:
+		for key, value := range options {
+			if secret, ok := value.(string); ok && redact.IsSecretName(key) {
+				secrets = append(secrets, secret)
+				continue
+			}
+			secrets = append(secrets, optionSecrets(value)...)
+		}
+	case []interface{}:
+		for _, item := range options {
+			secrets = append(secrets, optionSecrets(item)...)
+		}
+	}
+	return secrets
+}
+
+// RedactedValue replaces secret values that cannot be written to disk
+const RedactedValue = "[REDACTED]"
+
+// redactValues returns a copy of values with every value redacted
+func redactValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+
+	redacted := make(map[string]string, len(values))
+	for key := range values {
+		redacted[key] = RedactedValue
+	}
+	return redacted
+}
+
+// redactArgs returns a copy of args with the secrets redactor knows replaced
+// by their fingerprints, as are the values of flags named like secrets, given
+// as "--api-key VALUE" or "--api-key=VALUE"
+func redactArgs(args []string, redactor *redact.Redactor) []string {
+	if args == nil {
+		return nil
+	}
+
+	redacted := make([]string, len(args))
+	valueIsSecret := false
+	for i, arg := range args {
+		flag, value, hasValue := strings.Cut(arg, "=")
+		switch {
+		case valueIsSecret && !strings.HasPrefix(arg, "-"):
+			redacted[i] = redact.Fingerprint(arg)
+		case hasValue && isSecretFlag(flag):
+			redacted[i] = flag + "=" + redact.Fingerprint(value)
+		default:
+			redacted[i] = redactor.String(arg)
+		}
+		valueIsSecret = !hasValue && isSecretFlag(arg)
+	}
+	return redacted
+}
+
+// isSecretFlag reports whether arg is a flag, such as --api-key, named like
+// a secret
+func isSecretFlag(arg string) bool {
+	return strings.HasPrefix(arg, "-") && redact.IsSecretName(strings.TrimLeft(arg, "-"))
+}
+
+// UnresolvedVariables returns the sorted names of variables that header, env,
+// arg and initialize option values reference but that are unset and have no
+// default
+func (c *ServerConfig) UnresolvedVariables() []string {
+	headers, env, args, initializeOptions := c.Headers, c.Env, c.Args, c.InitializeOptions
+	if c.templates != nil {
+		headers, env, args, initializeOptions = c.templates.headers, c.templates.env, c.templates.args, c.templates.initializeOptions
+	}
+
+	var exp expansion
+	for _, value := range headers {
+		exp.expand(value)
+	}
+	for _, value := range env {
+		exp.expand(value)
+	}
+	for _, arg := range args {
+		exp.expand(arg)
+	}
+	mapStrings(initializeOptions, exp.expand)
+
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, name := range exp.missing {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveHeaders resolves environment variables in header values, reporting
+// every failed ${VAR:?message} reference
+func (c *ServerConfig) ResolveHeaders() error {
+	c.recordTemplates()
 	if c.Headers == nil {
 		c.Headers = make(map[string]string)
-		return
+		return nil
 	}
 
+	var errs []error
 	resolved := make(map[string]string)
 	for key, value := range c.Headers {
-		resolved[key] = ResolveEnvironmentVariables(value)
+		expanded, err := c.expand(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("header %s: %w", key, err))
+		}
+		resolved[key] = expanded
 	}
 	c.Headers = resolved
+	return errors.Join(errs...)
 }
 
 // ResolveEnv resolves environment variables in env values
-func (c *ServerConfig) ResolveEnv() {
+func (c *ServerConfig) ResolveEnv() error {
+	c.recordTemplates()
 	if c.Env == nil {
 		c.Env = make(map[string]string)
-		return
+		return nil
 	}
 
+	var errs []error
 	resolved := make(map[string]string)
 	for key, value := range c.Env {
-		resolved[key] = ResolveEnvironmentVariables(value)
+		expanded, err := c.expand(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("env %s: %w", key, err))
+		}
+		resolved[key] = expanded
 	}
 	c.Env = resolved
+	return errors.Join(errs...)
+}
+
+// expand expands value as the server's settings are resolved. A variable the
+// server requires that is unset disables the server, so its ${VAR:?message}
+// markers are not errors as well.
+func (c *ServerConfig) expand(value string) (string, error) {
+	exp := expansion{allowCommands: c.allowCommands, excused: c.missingRequired}
+	result := exp.expand(value)
+	return result, errors.Join(exp.errs...)
 }
 
 // ResolveArgs resolves environment variables in args values
-func (c *ServerConfig) ResolveArgs() {
+func (c *ServerConfig) ResolveArgs() error {
+	c.recordTemplates()
 	if c.Args == nil {
-		return
+		return nil
 	}
 
+	var errs []error
 	resolved := make([]string, len(c.Args))
 	for i, arg := range c.Args {
-		resolved[i] = ResolveEnvironmentVariables(arg)
+		expanded, err := c.expand(arg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("arg %d: %w", i+1, err))
+		}
+		resolved[i] = expanded
 	}
 	c.Args = resolved
+	return errors.Join(errs...)
 }
 
 // GetServerType returns a human-readable type description
 func (c *ServerConfig) GetServerType() string {
+	if c.IsDocker() {
+		return "Docker"
+	}
+	if c.IsSSH() {
+		return "SSH"
+	}
 	if c.Type == "http" || c.URL != "" {
 		return "HTTP"
 	}
@@ -141,6 +561,19 @@ func (c *ServerConfig) GetServerType() string {
 
 // GetServerDetails returns a detailed description of the server configuration
 func (c *ServerConfig) GetServerDetails() string {
+	if c.IsDocker() {
+		if runtime := c.ContainerRuntime(); runtime != DefaultContainerRuntime {
+			return fmt.Sprintf("%s (%s)", c.Image, runtime)
+		}
+		return c.Image
+	}
+	if c.IsSSH() {
+		details := c.SSHDestination() + ": " + c.RemoteCommand
+		if len(c.Args) > 0 {
+			details += " " + strings.Join(c.Args, " ")
+		}
+		return details
+	}
 	if c.Type == "http" || c.URL != "" {
 		return c.URL
 	}
@@ -154,8 +587,23 @@ func (c *ServerConfig) GetServerDetails() string {
 	return "No configuration"
 }
 
-// IsEnabled returns whether the server is enabled
+// HasTag reports whether the server carries tag (case-insensitive)
+func (c *ServerConfig) HasTag(tag string) bool {
+	for _, t := range c.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEnabled returns whether the server is enabled. A server missing required
+// environment variables is treated as disabled.
 func (c *ServerConfig) IsEnabled() bool {
+	if len(c.missingRequired) > 0 {
+		return false
+	}
+
 	// Default to enabled if not explicitly set
 	if c.Enabled == nil {
 		return true
@@ -163,22 +611,227 @@ func (c *ServerConfig) IsEnabled() bool {
 	return *c.Enabled
 }
 
-// Validate validates the server configuration
-func (c *ServerConfig) Validate() error {
-	if c.Type == "http" || c.URL != "" {
+// MissingRequired returns the variables listed in requires that were unset
+// when the configuration was loaded
+func (c *ServerConfig) MissingRequired() []string {
+	return c.missingRequired
+}
+
+// DisabledReason explains why the server is disabled, or returns "" when it
+// is enabled
+func (c *ServerConfig) DisabledReason() string {
+	if len(c.missingRequired) > 0 {
+		return "missing " + strings.Join(c.missingRequired, ", ")
+	}
+	if !c.IsEnabled() {
+		return "disabled in config"
+	}
+	return ""
+}
+
+// checkRequires records which of the required variables are unset
+func (c *ServerConfig) checkRequires() {
+	c.missingRequired = nil
+	for _, name := range c.Requires {
+		if getEnvWithFallback(name) == "" {
+			c.missingRequired = append(c.missingRequired, name)
+		}
+	}
+}
+
+// Validate checks the server configuration and returns every problem found
+func (c *ServerConfig) Validate() []ValidationIssue {
+	var issues []ValidationIssue
+	add := func(field, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch c.Type {
+	case "", "http", "stdio", ServerTypeDocker, ServerTypeSSH:
+	default:
+		add("type", "invalid value %q (use \"http\", \"stdio\", \"docker\" or \"ssh\")", c.Type)
+	}
+
+	if c.IsDocker() {
+		if c.Image == "" {
+			add("image", "required for docker servers")
+		}
+		switch c.Runtime {
+		case "", "docker", "podman":
+		default:
+			add("runtime", "invalid value %q (use \"docker\" or \"podman\")", c.Runtime)
+		}
+	} else if c.IsSSH() {
+		if c.Host == "" {
+			add("host", "required for ssh servers")
+		}
+		if c.RemoteCommand == "" {
+			add("remoteCommand", "required for ssh servers")
+		}
+		if c.Port < 0 || c.Port > 65535 {
+			add("port", "must be between 1 and 65535, got %d", c.Port)
+		}
+	} else if c.Type == "http" || c.URL != "" {
 		if c.URL == "" {
-			return &ConfigError{"HTTP server type requires URL"}
+			add("url", "required for HTTP servers")
 		}
-	} else if c.Command != "" {
-		// Stdio server
-		if c.Command == "" {
-			return &ConfigError{"Stdio server type requires command"}
+	} else if c.Type == "stdio" && c.Command == "" {
+		add("command", "required for stdio servers")
+	} else if c.Command == "" {
+		add("", "server must have either url (for HTTP) or command (for stdio)")
+	}
+
+	switch c.Framing {
+	case "", FramingNDJSON, FramingContentLength, FramingAuto:
+	default:
+		add("framing", "invalid value %q (use %q, %q or %q)", c.Framing, FramingNDJSON, FramingContentLength, FramingAuto)
+	}
+
+	if c.MaxResponseMB < 0 {
+		add("maxResponseMB", "must not be negative")
+	}
+
+	if c.ProtocolVersion != "" && !slices.Contains(mcp.KnownProtocolVersions, c.ProtocolVersion) {
+		add("protocolVersion", "unknown revision %q (use one of %s)", c.ProtocolVersion, strings.Join(mcp.KnownProtocolVersions, ", "))
+	}
+	if reservedInitializeFields[c.InitializeOptionsField] {
+		add("initializeOptionsField", "%q is an initialize parameter of its own; use %q or another name", c.InitializeOptionsField, InitializeOptionsExperimental)
+	}
+
+	if c.Timeout < 0 || c.Timeout > MaxTimeout {
+		add("timeout", "must be between 0 and %d seconds, got %d", MaxTimeout, c.Timeout)
+	}
+	if c.Session.Timeout < 0 {
+		add("session.timeout", "must not be negative")
+	}
+	if c.Session.HealthCheckInterval < 0 {
+		add("session.healthCheckInterval", "must not be negative")
+	}
+	if c.Hooks != nil && c.Hooks.Timeout < 0 {
+		add("hooks.timeout", "must not be negative")
+	}
+
+	for _, tag := range c.Tags {
+		if strings.TrimSpace(tag) == "" {
+			add("tags", "must not be empty")
+			break
+		}
+	}
+
+	for toolName, cache := range c.CacheTools {
+		if ttl, err := ParseRetention(cache.TTL); err != nil || ttl <= 0 {
+			add("cacheTools."+toolName+".ttl", "must be a positive duration such as 10m or 1d, got %q", cache.TTL)
 		}
-	} else {
-		return &ConfigError{"Server must have either URL (for HTTP) or command (for stdio)"}
 	}
 
-	return nil
+	if c.Retry != nil {
+		if c.Retry.MaxAttempts < 0 || c.Retry.MaxAttempts > MaxRetryAttempts {
+			add("retry.maxAttempts", "must be between 1 and %d, got %d", MaxRetryAttempts, c.Retry.MaxAttempts)
+		}
+		if c.Retry.InitialDelayMs < 0 {
+			add("retry.initialDelayMs", "must not be negative")
+		}
+		for _, kind := range c.Retry.RetryOn {
+			switch kind {
+			case RetryOnStartup, RetryOnTimeout, RetryOnConnection:
+			default:
+				add("retry.retryOn", "invalid value %q (use %q, %q or %q)", kind, RetryOnStartup, RetryOnTimeout, RetryOnConnection)
+			}
+		}
+	}
+
+	for _, pattern := range c.DisabledTools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			add("disabledTools", "invalid pattern %q", pattern)
+		}
+	}
+
+	for name, exported := range c.ExportAs {
+		if strings.TrimSpace(exported) == "" {
+			add("exportAs", "empty name for %q", name)
+		}
+	}
+
+	for _, name := range c.Requires {
+		if !envKeyPattern.MatchString(name) {
+			add("requires", "invalid variable name %q", name)
+		}
+	}
+
+	switch c.MergeStrategy {
+	case "", MergeStrategyReplace, MergeStrategyPatch:
+	default:
+		add("mergeStrategy", "invalid value %q (use %q or %q)", c.MergeStrategy, MergeStrategyReplace, MergeStrategyPatch)
+	}
+
+	if _, err := ParseRetention(c.Session.Retention); err != nil {
+		add("session.retention", "%v", err)
+	}
+	if _, err := ParseRetention(c.Session.ErrorRetention); err != nil {
+		add("session.errorRetention", "%v", err)
+	}
+
+	return issues
+}
+
+// ParseRetention parses a retention duration. It accepts Go durations such as
+// "90m" or "72h" plus whole days such as "7d"; an empty value returns 0.
+func ParseRetention(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid day count %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("negative duration %q", value)
+	}
+	return duration, nil
+}
+
+// DefaultToolCacheTTL is how long listed tools are cached when toolCacheTTL is unset
+const DefaultToolCacheTTL = 10 * time.Minute
+
+// ToolCacheDuration returns how long listed tools are cached on disk; zero
+// disables the cache
+func (c *Configuration) ToolCacheDuration() time.Duration {
+	if c.ToolCacheTTL == "" {
+		return DefaultToolCacheTTL
+	}
+	ttl, err := ParseRetention(c.ToolCacheTTL)
+	if err != nil {
+		return DefaultToolCacheTTL
+	}
+	return ttl
+}
+
+// settingsIssues checks the top-level settings
+func (c *Configuration) settingsIssues() []ValidationIssue {
+	issues := c.Serve.Validate()
+	issues = append(issues, c.Exports.Validate()...)
+	issues = append(issues, c.Audit.Validate()...)
+	issues = append(issues, c.Daemon.Validate()...)
+	if c.ResultCacheMaxMB < 0 {
+		issues = append(issues, ValidationIssue{Field: "resultCacheMaxMB", Message: "must not be negative"})
+	}
+	if c.MaxResponseMB < 0 {
+		issues = append(issues, ValidationIssue{Field: "maxResponseMB", Message: "must not be negative"})
+	}
+	if _, err := ParseRetention(c.ToolCacheTTL); err != nil {
+		issues = append(issues, ValidationIssue{Field: "toolCacheTTL", Message: err.Error()})
+	}
+	return issues
 }
 
 // ConfigError represents a configuration validation error
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MaxTimeout is the largest request timeout a server may set, in seconds
+const MaxTimeout = 3600
+
+// ValidationIssue is a single problem found in a configuration
+type ValidationIssue struct {
+	Server  string // Server the problem belongs to
+	Field   string // Offending JSON field, e.g. "session.timeout"; empty for the whole entry
+	Message string
+}
+
+func (i ValidationIssue) Error() string {
+	var b strings.Builder
+	if i.Server != "" {
+		fmt.Fprintf(&b, "server '%s': ", i.Server)
+	}
+	if i.Field != "" {
+		fmt.Fprintf(&b, "%s: ", i.Field)
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// ValidationError reports every problem found in a configuration at once
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Issues) == 1 {
+		return e.Issues[0].Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d problems found:", len(e.Issues))
+	for _, issue := range e.Issues {
+		b.WriteString("\n  - ")
+		b.WriteString(issue.Error())
+	}
+	return b.String()
+}
+
+// validationError returns the issues as a ValidationError, or nil if there are none
+func validationError(issues []ValidationIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	return &ValidationError{Issues: issues}
+}
+
+// validateServers validates every server in name order
+func validateServers(servers map[string]ServerConfig) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, name := range sortedKeys(servers) {
+		server := servers[name]
+		for _, issue := range server.Validate() {
+			issue.Server = name
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// unknownFields returns the keys of the JSON object raw that typ does not
+// define, descending into nested objects such as "session"
+func unknownFields(raw json.RawMessage, typ reflect.Type) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+
+	known := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" || name == "" {
+			continue
+		}
+		known[name] = field.Type
+	}
+
+	var unknown []string
+	for _, key := range sortedKeys(fields) {
+		fieldType, ok := known[key]
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			for _, nested := range unknownFields(fields[key], fieldType) {
+				unknown = append(unknown, key+"."+nested)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
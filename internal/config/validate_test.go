@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		server ServerConfig
+		fields []string
+	}{
+		{"valid stdio", ServerConfig{Command: "uvx", Timeout: 30}, nil},
+		{"valid http", ServerConfig{Type: "http", URL: "https://mcp.context7.com/mcp"}, nil},
+		{"missing url", ServerConfig{Type: "http"}, []string{"url"}},
+		{"missing command", ServerConfig{Type: "stdio"}, []string{"command"}},
+		{"missing transport", ServerConfig{}, []string{""}},
+		{"invalid type", ServerConfig{Type: "sse", Command: "npx"}, []string{"type"}},
+		{"timeout too large", ServerConfig{Command: "npx", Timeout: MaxTimeout + 1}, []string{"timeout"}},
+		{"negative timeout", ServerConfig{Command: "npx", Timeout: -5}, []string{"timeout"}},
+		{"valid docker", ServerConfig{Type: ServerTypeDocker, Image: "mcp/time"}, nil},
+		{"docker without image", ServerConfig{Type: ServerTypeDocker, Runtime: "lxc"}, []string{"image", "runtime"}},
+		{"valid ssh", ServerConfig{Type: ServerTypeSSH, Host: "gpu-box", RemoteCommand: "uvx mcp-server-time"}, nil},
+		{"ssh without host", ServerConfig{Type: ServerTypeSSH, Port: 70000}, []string{"host", "remoteCommand", "port"}},
+		{"invalid disabled tool pattern", ServerConfig{Command: "npx", DisabledTools: []string{"performance_*", "[trace"}}, []string{"disabledTools"}},
+		{"invalid retry policy", ServerConfig{Command: "npx", Retry: &RetryConfig{MaxAttempts: 50, InitialDelayMs: -1, RetryOn: []string{"always"}}}, []string{"retry.maxAttempts", "retry.initialDelayMs", "retry.retryOn"}},
+		{"invalid required variable", ServerConfig{Command: "npx", Requires: []string{"CONTEXT7_API_KEY", "API-KEY"}}, []string{"requires"}},
+		{"empty export name", ServerConfig{Command: "npx", ExportAs: map[string]string{"search": " "}}, []string{"exportAs"}},
+		{"content-length framing", ServerConfig{Command: "npx", Framing: FramingContentLength}, nil},
+		{"invalid framing", ServerConfig{Command: "npx", Framing: "lsp"}, []string{"framing"}},
+		{"negative response limit", ServerConfig{Command: "npx", MaxResponseMB: -1}, []string{"maxResponseMB"}},
+		{"initialize options field", ServerConfig{Command: "npx", InitializeOptionsField: "initializationOptions"}, nil},
+		{"pinned protocol version", ServerConfig{Command: "npx", ProtocolVersion: "2024-11-05"}, nil},
+		{"invalid protocol version", ServerConfig{Command: "npx", ProtocolVersion: "v1"}, []string{"protocolVersion"}},
+		{"unknown protocol version", ServerConfig{Command: "npx", ProtocolVersion: "2025-01-01"}, []string{"protocolVersion"}},
+		{"reserved initialize options field", ServerConfig{Command: "npx", InitializeOptionsField: "capabilities"}, []string{"initializeOptionsField"}},
+		{"invalid cache ttl", ServerConfig{Command: "npx", CacheTools: map[string]ToolCacheConfig{"get-library-docs": {TTL: "1h"}, "*": {TTL: "0"}}}, []string{"cacheTools.*.ttl"}},
+		{
+			name: "several problems",
+			server: ServerConfig{
+				Type:          "websocket",
+				Timeout:       -1,
+				MergeStrategy: "merge",
+				Session:       SessionConfig{Retention: "soon"},
+				Hooks:         &HooksConfig{Timeout: -1},
+			},
+			fields: []string{"type", "", "timeout", "hooks.timeout", "mergeStrategy", "session.retention"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			for _, issue := range tt.server.Validate() {
+				fields = append(fields, issue.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("expected issues for fields %q, got %q", tt.fields, fields)
+			}
+		})
+	}
+}
+
+func TestServeConfigValidate(t *testing.T) {
+	for style, valid := range map[string]bool{"": true, "prefix": true, "suffix": true, "none": true, "infix": false} {
+		issues := ServeConfig{NamespaceStyle: style}.Validate()
+		if valid != (len(issues) == 0) {
+			t.Errorf("namespaceStyle %q: unexpected issues %v", style, issues)
+		}
+	}
+}
+
+func TestAuditConfig(t *testing.T) {
+	var unset *AuditConfig
+	if !unset.IsEnabled() || len(unset.Validate()) != 0 {
+		t.Error("auditing should be on, and valid, without an audit section")
+	}
+	disabled := false
+	if (&AuditConfig{Enabled: &disabled}).IsEnabled() {
+		t.Error("enabled: false should turn auditing off")
+	}
+	if issues := (&AuditConfig{MaxSizeMB: -1}).Validate(); len(issues) != 1 || issues[0].Field != "audit.maxSizeMB" {
+		t.Errorf("want an audit.maxSizeMB issue, got %v", issues)
+	}
+}
+
+func TestToolCacheDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", DefaultToolCacheTTL},
+		{"1h", time.Hour},
+		{"2d", 48 * time.Hour},
+		{"0", 0},
+		{"soon", DefaultToolCacheTTL},
+	}
+	for _, tt := range tests {
+		cfg := &Configuration{ToolCacheTTL: tt.value}
+		if got := cfg.ToolCacheDuration(); got != tt.want {
+			t.Errorf("ToolCacheDuration(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	cfg := &Configuration{MCPServers: map[string]ServerConfig{"time": {Command: "uvx"}}, ToolCacheTTL: "soon"}
+	var validationErr *ValidationError
+	if err := ValidateConfig(cfg); !errors.As(err, &validationErr) || validationErr.Issues[0].Field != "toolCacheTTL" {
+		t.Errorf("want a toolCacheTTL issue, got %v", err)
+	}
+}
+
+func TestLoadConfigReportsAllProblems(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "mcp_servers.json")
+	writeFile(t, configPath, `{"mcpServers": {
+		"time": {"command": "uvx", "args": ["mcp-server-time"]},
+		"context7": {"type": "http", "timeout": 99999},
+		"broken": {"comand": "npx", "session": {"autostart": true}}
+	}}`)
+
+	_, err := LoadConfig(configPath)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+
+	var got []string
+	for _, issue := range validationErr.Issues {
+		got = append(got, issue.Server+"/"+issue.Field)
+	}
+	want := []string{"broken/comand", "broken/session.autostart", "broken/", "context7/url", "context7/timeout"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected issues %q, got %q", want, got)
+	}
+
+	message := err.Error()
+	for _, expected := range []string{"5 problems found", "server 'broken': comand: unknown field", "server 'context7': url: required for HTTP servers"} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("expected error to contain %q, got:\n%s", expected, message)
+		}
+	}
+}
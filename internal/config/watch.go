@@ -0,0 +1,235 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long the watcher waits for writes to settle
+// before reloading, since editors often save in several steps
+const DefaultWatchDebounce = 250 * time.Millisecond
+
+// ConfigChange describes a configuration that was reloaded after an edit
+type ConfigChange struct {
+	Config  *Configuration
+	Added   []string // Servers that were not configured before
+	Removed []string // Servers that are no longer configured
+	Changed []string // Servers whose settings changed
+
+	ExportsChanged bool // The exports section changed
+}
+
+// Watcher reloads a configuration file when it changes on disk. Invalid
+// edits are reported and the previous configuration stays active.
+type Watcher struct {
+	path     string
+	opts     LoadOptions
+	files    map[string]bool // Absolute paths of the watched config files
+	debounce time.Duration
+	onChange func(ConfigChange)
+	onError  func(error)
+
+	mu      sync.RWMutex
+	current *Configuration
+
+	fsWatcher *fsnotify.Watcher
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// WatchConfig watches the configuration at path, plus opts.LocalConfig if set.
+// current is the configuration already in use; onChange is called after each
+// successful reload that changed something, and onError when a reload fails.
+func WatchConfig(path string, opts LoadOptions, current *Configuration, onChange func(ConfigChange), onError func(error)) (*Watcher, error) {
+	return watchConfig(path, opts, current, DefaultWatchDebounce, onChange, onError)
+}
+
+func watchConfig(path string, opts LoadOptions, current *Configuration, debounce time.Duration, onChange func(ConfigChange), onError func(error)) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config watcher: %w", err)
+	}
+
+	w := &Watcher{
+		path:      path,
+		opts:      opts,
+		files:     make(map[string]bool),
+		debounce:  debounce,
+		onChange:  onChange,
+		onError:   onError,
+		current:   current,
+		fsWatcher: fsWatcher,
+		done:      make(chan struct{}),
+	}
+
+	// Watch the directories rather than the files, so edits that replace the
+	// file (rename over it) are still seen
+	dirs := make(map[string]bool)
+	for _, file := range []string{path, opts.LocalConfig} {
+		if file == "" {
+			continue
+		}
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			_ = fsWatcher.Close()
+			return nil, fmt.Errorf("failed to resolve '%s': %w", file, err)
+		}
+		w.files[abs] = true
+
+		dir := filepath.Dir(abs)
+		if dirs[dir] {
+			continue
+		}
+		if err := fsWatcher.Add(dir); err != nil {
+			_ = fsWatcher.Close()
+			return nil, fmt.Errorf("failed to watch '%s': %w", dir, err)
+		}
+		dirs[dir] = true
+	}
+
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Config returns the configuration currently in effect
+func (w *Watcher) Config() *Configuration {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Close stops watching and waits for any reload in progress to finish
+func (w *Watcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+		err = w.fsWatcher.Close()
+	})
+	return err
+}
+
+// run reloads the configuration once events for the watched files settle
+func (w *Watcher) run() {
+	defer w.wg.Done()
+
+	var timer *time.Timer
+	var settled <-chan time.Time
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			if !w.files[filepath.Clean(event.Name)] || event.Op == fsnotify.Chmod {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(w.debounce)
+			settled = timer.C
+
+		case <-settled:
+			settled = nil
+			w.reload()
+
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			w.reportError(fmt.Errorf("config watcher error: %w", err))
+
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// reload loads and validates the configuration, keeping the previous one
+// when that fails
+func (w *Watcher) reload() {
+	next, err := LoadConfigWithOptions(w.path, w.opts)
+	if err != nil {
+		w.reportError(fmt.Errorf("config reload failed, keeping the previous configuration: %w", err))
+		return
+	}
+
+	w.mu.Lock()
+	previous := w.current
+	w.current = next
+	w.mu.Unlock()
+
+	added, removed, changed := DiffConfigs(previous, next)
+	var previousExports *ExportsConfig
+	if previous != nil {
+		previousExports = previous.Exports
+	}
+	exportsChanged := !reflect.DeepEqual(previousExports, next.Exports)
+	if len(added) == 0 && len(removed) == 0 && len(changed) == 0 && !exportsChanged {
+		return
+	}
+
+	if w.onChange != nil {
+		w.onChange(ConfigChange{Config: next, Added: added, Removed: removed, Changed: changed, ExportsChanged: exportsChanged})
+	}
+}
+
+func (w *Watcher) reportError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
+
+// DiffConfigs returns the sorted names of servers added, removed and changed
+// between two configurations
+func DiffConfigs(previous, next *Configuration) (added, removed, changed []string) {
+	var before, after map[string]ServerConfig
+	if previous != nil {
+		before = previous.MCPServers
+	}
+	if next != nil {
+		after = next.MCPServers
+	}
+
+	for name, server := range after {
+		old, exists := before[name]
+		switch {
+		case !exists:
+			added = append(added, name)
+		case !serverConfigEqual(old, server):
+			changed = append(changed, name)
+		}
+	}
+	for name := range before {
+		if _, exists := after[name]; !exists {
+			removed = append(removed, name)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// serverConfigEqual compares the effective settings of two servers
+func serverConfigEqual(a, b ServerConfig) bool {
+	a.templates, b.templates = nil, nil
+	a.allowCommands, b.allowCommands = false, false
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	previous := &Configuration{MCPServers: map[string]ServerConfig{
+		"time":     {Command: "uvx", Args: []string{"mcp-server-time"}},
+		"context7": {URL: "https://mcp.context7.com/mcp"},
+		"deepwiki": {URL: "https://mcp.deepwiki.com/mcp"},
+	}}
+	next := &Configuration{MCPServers: map[string]ServerConfig{
+		"time":     {Command: "uvx", Args: []string{"mcp-server-time", "--local-timezone=UTC"}},
+		"context7": {URL: "https://mcp.context7.com/mcp"},
+		"fetch":    {Command: "uvx", Args: []string{"mcp-server-fetch"}},
+	}}
+
+	added, removed, changed := DiffConfigs(previous, next)
+	if !reflect.DeepEqual(added, []string{"fetch"}) || !reflect.DeepEqual(removed, []string{"deepwiki"}) || !reflect.DeepEqual(changed, []string{"time"}) {
+		t.Errorf("unexpected diff: added=%v removed=%v changed=%v", added, removed, changed)
+	}
+}
+
+func TestWatcherReloadsConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mcp_servers.json")
+	writeFile(t, configPath, `{"mcpServers": {
+		"time": {"command": "uvx", "args": ["mcp-server-time"]},
+		"deepwiki": {"url": "https://mcp.deepwiki.com/mcp"}
+	}}`)
+
+	initial, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	changes := make(chan ConfigChange, 10)
+	errs := make(chan error, 10)
+	watcher, err := watchConfig(configPath, LoadOptions{}, initial, 20*time.Millisecond,
+		func(change ConfigChange) { changes <- change },
+		func(err error) { errs <- err })
+	if err != nil {
+		t.Fatalf("WatchConfig failed: %v", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	writeFile(t, configPath, `{"mcpServers": {
+		"time": {"command": "uvx", "args": ["mcp-server-time", "--local-timezone=UTC"]},
+		"fetch": {"command": "uvx", "args": ["mcp-server-fetch"]}
+	}}`)
+
+	select {
+	case change := <-changes:
+		if !reflect.DeepEqual(change.Added, []string{"fetch"}) || !reflect.DeepEqual(change.Removed, []string{"deepwiki"}) || !reflect.DeepEqual(change.Changed, []string{"time"}) {
+			t.Errorf("unexpected change: %+v", change)
+		}
+		if _, ok := watcher.Config().MCPServers["fetch"]; !ok {
+			t.Error("expected the reloaded config to be current")
+		}
+	case err := <-errs:
+		t.Fatalf("unexpected reload error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config change was not detected")
+	}
+
+	// An invalid edit keeps the previous configuration
+	writeFile(t, configPath, `{"mcpServers": {"broken": {}}}`)
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "keeping the previous configuration") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case change := <-changes:
+		t.Fatalf("invalid config was applied: %+v", change)
+	case <-time.After(5 * time.Second):
+		t.Fatal("invalid config was not reported")
+	}
+	if _, ok := watcher.Config().MCPServers["fetch"]; !ok {
+		t.Error("expected the previous config to stay active")
+	}
+
+	if err := watcher.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if err := watcher.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+}
+
+func TestWatcherReportsExportChanges(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mcp_servers.json")
+	writeFile(t, configPath, `{"mcpServers": {"time": {"command": "uvx", "args": ["mcp-server-time"]}}}`)
+
+	initial, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	changes := make(chan ConfigChange, 10)
+	watcher, err := watchConfig(configPath, LoadOptions{}, initial, 20*time.Millisecond,
+		func(change ConfigChange) { changes <- change },
+		func(err error) { t.Errorf("unexpected reload error: %v", err) })
+	if err != nil {
+		t.Fatalf("WatchConfig failed: %v", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	// Only the exports change; the servers are untouched
+	writeFile(t, configPath, `{"mcpServers": {"time": {"command": "uvx", "args": ["mcp-server-time"]}},
+		"exports": {"tools": ["time/get_*"]}}`)
+
+	select {
+	case change := <-changes:
+		if !change.ExportsChanged || len(change.Added)+len(change.Removed)+len(change.Changed) != 0 {
+			t.Errorf("want only an exports change, got %+v", change)
+		}
+		if change.Config.Exports == nil || !reflect.DeepEqual(change.Config.Exports.Tools, []string{"time/get_*"}) {
+			t.Errorf("unexpected exports: %+v", change.Config.Exports)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("exports change was not detected")
+	}
+}
@@ -0,0 +1,106 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// SessionBroker lets the session manager hand stdio server processes to the
+// daemon, so a session started by one CLI invocation survives it and can be
+// reattached by the next.
+type SessionBroker struct {
+	client *DaemonClient
+}
+
+// NewSessionBroker creates a broker backed by the local daemon
+func NewSessionBroker() *SessionBroker {
+	return &SessionBroker{
+		client: SharedDaemonClient(),
+	}
+}
+
+// Endpoint returns the daemon endpoint that owns brokered sessions
+func (b *SessionBroker) Endpoint() string {
+	return b.client.manager.GetEndpoint()
+}
+
+// Acquire starts the session in the daemon (starting the daemon if needed)
+// and returns a client that routes calls through it
+func (b *SessionBroker) Acquire(serverName string, serverConfig config.ServerConfig) (mcp.MCPClient, error) {
+	if err := b.client.StartDaemon(); err != nil {
+		return nil, fmt.Errorf("failed to start daemon: %w", err)
+	}
+
+	if info, err := b.client.findSession(serverName); err == nil && info != nil && info.Status == SessionStatusActive.String() {
+		return NewDaemonMCPClient(b.client, serverName), nil
+	}
+
+	if err := b.client.StartSession(context.Background(), serverName, serverConfig); err != nil {
+		return nil, fmt.Errorf("failed to start daemon session: %w", err)
+	}
+
+	timeout := time.Duration(serverConfig.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	if err := b.client.waitForSession(serverName, timeout); err != nil {
+		return nil, err
+	}
+
+	return NewDaemonMCPClient(b.client, serverName), nil
+}
+
+// Attach returns a client for a session the daemon is already running
+func (b *SessionBroker) Attach(serverName string) (mcp.MCPClient, error) {
+	if !b.client.IsDaemonRunning() {
+		return nil, errDaemonNotRunning
+	}
+
+	info, err := b.client.findSession(serverName)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fmt.Errorf("daemon has no session for %s", serverName)
+	}
+	if info.Status != SessionStatusActive.String() {
+		return nil, fmt.Errorf("daemon session %s is %s", serverName, info.Status)
+	}
+
+	return NewDaemonMCPClient(b.client, serverName), nil
+}
+
+// Release stops the session in the daemon
+func (b *SessionBroker) Release(serverName string) error {
+	if !b.client.IsDaemonRunning() {
+		return nil // Nothing to release
+	}
+	return b.client.StopSession(context.Background(), serverName)
+}
+
+// findSession returns the daemon's view of a session, or nil if it has none
+func (dc *DaemonClient) findSession(serverName string) (*SessionInfo, error) {
+	sessions, err := dc.ListSessions(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		if sessions[i].ServerName == serverName {
+			return &sessions[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// waitForSession polls the daemon until a session becomes active, fails, or times out
+func (dc *DaemonClient) waitForSession(serverName string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return dc.WaitForSession(ctx, serverName)
+}
@@ -1,16 +1,24 @@
 package daemon
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
 	"github.com/mcp-cli-ent/mcp-cli/internal/client"
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
 	"github.com/mcp-cli-ent/mcp-cli/pkg/version"
 )
@@ -22,18 +30,97 @@ type DaemonClient struct {
 	autoStart  bool
 }
 
+// transport carries every request to the daemon. Keep-alives let a CLI
+// invocation making many daemon calls reuse its connections instead of
+// setting up a new one for each call.
+var transport = &http.Transport{
+	DialContext:         dialDaemon,
+	MaxIdleConns:        16,
+	MaxIdleConnsPerHost: 16,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// Process-wide daemon client
+var (
+	sharedClient     *DaemonClient
+	sharedClientOnce sync.Once
+)
+
 // NewDaemonClient creates a new daemon client
 func NewDaemonClient() *DaemonClient {
 	return &DaemonClient{
 		manager:    NewDaemonManager(),
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		httpClient: &http.Client{Timeout: 30 * time.Second, Transport: transport},
 		autoStart:  true,
 	}
 }
 
+// SharedDaemonClient returns the process's daemon client. Helpers use it
+// rather than creating their own, so they share its connections.
+func SharedDaemonClient() *DaemonClient {
+	sharedClientOnce.Do(func() {
+		sharedClient = NewDaemonClient()
+	})
+	return sharedClient
+}
+
+// CloseIdleConnections closes the connections kept open to the daemon; call
+// it before the process exits
+func CloseIdleConnections() {
+	transport.CloseIdleConnections()
+}
+
+// dialDaemon connects to the daemon with Nagle's algorithm off: requests are
+// small and each one waits for its reply, so batching writes only adds latency
+func dialDaemon(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetNoDelay(true)
+	}
+	return conn, nil
+}
+
+// closeResponse reads what is left of a response body before closing it, so
+// the connection goes back to the pool instead of being dropped
+func closeResponse(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+// do sends a request to the daemon, JSON body and all, bounded by ctx as well
+// as the client's timeout. When ctx ends first, ctx's error is returned
+// rather than the transport's.
+func (dc *DaemonClient) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	return dc.send(ctx, dc.httpClient, method, url, body)
+}
+
+// send is do with client sending the request
+func (dc *DaemonClient) send(ctx context.Context, client *http.Client, method, url string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return resp, err
+}
+
 // IsDaemonRunning checks if the daemon is available
 func (dc *DaemonClient) IsDaemonRunning() bool {
-	running, _, err := isDaemonRunning()
+	running, _, err := isDaemonRunning(dc.manager.name)
 	return err == nil && running
 }
 
@@ -57,23 +144,24 @@ func (dc *DaemonClient) GetStatus() (*DaemonStatus, error) {
 	}
 
 	// Try to get detailed status from daemon
-	if isUnixSocket(dc.manager.endpoint) || isNamedPipe(dc.manager.endpoint) {
+	if endpoint := dc.manager.currentEndpoint(); isUnixSocket(endpoint) || isNamedPipe(endpoint) {
 		// For non-HTTP endpoints, return basic status
-		running, pid, _ := isDaemonRunning()
+		running, pid, _ := isDaemonRunning(dc.manager.name)
 		return &DaemonStatus{
 			Running:  running,
 			PID:      pid,
+			Name:     dc.manager.name,
 			Platform: dc.manager.platform,
-			Endpoint: dc.manager.endpoint,
+			Endpoint: endpoint,
 		}, nil
 	}
 
 	// For HTTP endpoints, get detailed status
-	resp, err := dc.httpClient.Get(dc.getHTTPURL())
+	resp, err := dc.do(context.Background(), http.MethodGet, dc.getHTTPURL()+"/status", nil)
 	if err != nil {
 		return &DaemonStatus{Running: false}, nil
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer closeResponse(resp)
 
 	if resp.StatusCode != http.StatusOK {
 		return &DaemonStatus{Running: false}, nil
@@ -84,7 +172,7 @@ func (dc *DaemonClient) GetStatus() (*DaemonStatus, error) {
 		return &DaemonStatus{Running: false}, nil
 	}
 
-	var apiResp APIResponse
+	var apiResp rawAPIResponse
 	if err := json.Unmarshal(body, &apiResp); err != nil {
 		return &DaemonStatus{Running: false}, nil
 	}
@@ -93,12 +181,8 @@ func (dc *DaemonClient) GetStatus() (*DaemonStatus, error) {
 		return &DaemonStatus{Running: false}, nil
 	}
 
-	data, err := json.Marshal(apiResp.Data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal response data: %w", err)
-	}
 	var status DaemonStatus
-	if err := json.Unmarshal(data, &status); err != nil {
+	if err := json.Unmarshal(apiResp.Data, &status); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal status: %w", err)
 	}
 
@@ -106,9 +190,15 @@ func (dc *DaemonClient) GetStatus() (*DaemonStatus, error) {
 }
 
 // StartSession starts a new persistent session
-func (dc *DaemonClient) StartSession(serverName string, serverConfig config.ServerConfig) error {
+func (dc *DaemonClient) StartSession(ctx context.Context, serverName string, serverConfig config.ServerConfig) error {
+	return dc.startSession(ctx, serverName, serverConfig, 0)
+}
+
+// startSession asks the daemon to start a session, waiting up to wait for it
+// to become active
+func (dc *DaemonClient) startSession(ctx context.Context, serverName string, serverConfig config.ServerConfig, wait time.Duration) error {
 	if !dc.IsDaemonRunning() {
-		return fmt.Errorf("daemon is not running")
+		return errDaemonNotRunning
 	}
 
 	req := struct {
@@ -122,114 +212,128 @@ func (dc *DaemonClient) StartSession(serverName string, serverConfig config.Serv
 		return err
 	}
 
-	resp, err := dc.httpClient.Post(
-		dc.getSessionURL(serverName, "start"),
-		"application/json",
-		bytes.NewBuffer(reqData),
-	)
+	resp, err := dc.do(ctx, http.MethodPost, dc.getSessionURL(serverName, "start")+waitForActiveQuery(wait), reqData)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer closeResponse(resp)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("daemon returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var apiResp APIResponse
+	var apiResp rawAPIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
 		return err
 	}
 
 	if !apiResp.Success {
-		return fmt.Errorf("daemon error: %s", apiResp.Error)
+		return apiResp.err()
 	}
 
 	return nil
 }
 
 // StopSession stops a persistent session
-func (dc *DaemonClient) StopSession(serverName string) error {
+func (dc *DaemonClient) StopSession(ctx context.Context, serverName string) error {
 	if !dc.IsDaemonRunning() {
-		return fmt.Errorf("daemon is not running")
-	}
-
-	req, err := http.NewRequest("DELETE", dc.getSessionURL(serverName, ""), nil)
-	if err != nil {
-		return err
+		return errDaemonNotRunning
 	}
 
-	resp, err := dc.httpClient.Do(req)
+	resp, err := dc.do(ctx, http.MethodDelete, dc.getSessionURL(serverName, ""), nil)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer closeResponse(resp)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("daemon returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var apiResp APIResponse
+	var apiResp rawAPIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
 		return err
 	}
 
 	if !apiResp.Success {
-		return fmt.Errorf("daemon error: %s", apiResp.Error)
+		return apiResp.err()
 	}
 
 	return nil
 }
 
+// ResetSessionStats clears a session's metrics, such as its restart count
+// and last error
+func (dc *DaemonClient) ResetSessionStats(ctx context.Context, serverName string) error {
+	if !dc.IsDaemonRunning() {
+		return errDaemonNotRunning
+	}
+
+	resp, err := dc.do(ctx, http.MethodPost, dc.getSessionURL(serverName, "reset-stats"), nil)
+	if err != nil {
+		return err
+	}
+	var info SessionInfo
+	return decodeData(resp, "session", &info)
+}
+
 // ListSessions lists all sessions
-func (dc *DaemonClient) ListSessions() ([]SessionInfo, error) {
+func (dc *DaemonClient) ListSessions(ctx context.Context) ([]SessionInfo, error) {
 	if !dc.IsDaemonRunning() {
 		return []SessionInfo{}, nil
 	}
 
-	resp, err := dc.httpClient.Get(dc.getSessionsURL())
+	resp, err := dc.do(ctx, http.MethodGet, dc.getSessionsURL(), nil)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer closeResponse(resp)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("daemon returned status %d", resp.StatusCode)
 	}
 
-	var apiResp APIResponse
+	var apiResp rawAPIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
 		return nil, err
 	}
 
 	if !apiResp.Success {
-		return nil, fmt.Errorf("daemon error: %s", apiResp.Error)
+		return nil, apiResp.err()
 	}
 
-	data, err := json.Marshal(apiResp.Data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal response data: %w", err)
-	}
 	var sessions []SessionInfo
-	if err := json.Unmarshal(data, &sessions); err != nil {
+	if err := json.Unmarshal(apiResp.Data, &sessions); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal sessions: %w", err)
 	}
 
 	return sessions, nil
 }
 
-// CallTool executes a tool via the daemon
-func (dc *DaemonClient) CallTool(serverName, toolName string, args map[string]interface{}) (*mcp.ToolResult, error) {
+// CallTool executes a tool via the daemon. With noCache the daemon calls the
+// server even if it has the result cached. ctx's deadline, if it has one,
+// is how long the daemon lets the call take; cancelling ctx disconnects,
+// which cancels the call.
+func (dc *DaemonClient) CallTool(ctx context.Context, serverName, toolName string, args map[string]interface{}, noCache bool) (*mcp.ToolResult, error) {
 	if !dc.IsDaemonRunning() {
-		return nil, fmt.Errorf("daemon is not running")
+		return nil, errDaemonNotRunning
 	}
 
+	// The CLI has already merged (or deliberately skipped) the tool defaults
 	req := struct {
-		Args map[string]interface{} `json:"args"`
+		Args           map[string]interface{} `json:"args"`
+		NoDefaults     bool                   `json:"noDefaults"`
+		NoCache        bool                   `json:"noCache,omitempty"`
+		TimeoutSeconds int                    `json:"timeoutSeconds,omitempty"`
+		AuditArgs      map[string]interface{} `json:"auditArgs,omitempty"`
 	}{
-		Args: args,
+		Args:           args,
+		NoDefaults:     true,
+		NoCache:        noCache,
+		TimeoutSeconds: toolCallTimeout(ctx),
+		AuditArgs:      audit.RecordedArgs(ctx, nil), // What the caller records, the daemon records
 	}
 
 	reqData, err := json.Marshal(req)
@@ -237,127 +341,511 @@ func (dc *DaemonClient) CallTool(serverName, toolName string, args map[string]in
 		return nil, err
 	}
 
-	resp, err := dc.httpClient.Post(
-		dc.getToolURL(serverName, toolName),
-		"application/json",
-		bytes.NewBuffer(reqData),
-	)
+	// A call given a deadline may outlast the client's timeout; ctx bounds it
+	httpClient := dc.httpClient
+	if req.TimeoutSeconds > 0 {
+		httpClient = &http.Client{Transport: dc.httpClient.Transport}
+	}
+	resp, err := dc.send(ctx, httpClient, http.MethodPost, dc.getToolURL(serverName, toolName), reqData)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer closeResponse(resp)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("daemon returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var apiResp APIResponse
+	var apiResp rawAPIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
 		return nil, err
 	}
 
 	if !apiResp.Success {
-		return nil, fmt.Errorf("daemon error: %s", apiResp.Error)
+		return nil, apiResp.err()
 	}
 
-	data, _ := json.Marshal(apiResp.Data)
 	var result mcp.ToolResult
-	if err := json.Unmarshal(data, &result); err != nil {
+	if err := json.Unmarshal(apiResp.Data, &result); err != nil {
 		return nil, err
 	}
+	result.Raw = apiResp.Data
 
 	return &result, nil
 }
 
-// ListTools lists tools for a session via the daemon
-func (dc *DaemonClient) ListTools(serverName string) ([]mcp.Tool, error) {
+// toolCallTimeout returns the whole seconds left before ctx's deadline,
+// rounded up, for the daemon to bound a tool call by; 0, the daemon's
+// default, when ctx has none
+func toolCallTimeout(ctx context.Context) int {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	return max(int((time.Until(deadline)+time.Second-1)/time.Second), 1)
+}
+
+// CallToolStream executes a tool via the daemon, streaming the server's
+// progress and log messages as they arrive. The channel ends with the
+// result or an error event and is then closed. Cancelling ctx disconnects,
+// which cancels the call.
+func (dc *DaemonClient) CallToolStream(ctx context.Context, serverName, toolName string, args map[string]interface{}, noCache bool) (<-chan StreamEvent, error) {
 	if !dc.IsDaemonRunning() {
-		return nil, fmt.Errorf("daemon is not running")
+		return nil, errDaemonNotRunning
+	}
+
+	// The CLI has already merged (or deliberately skipped) the tool defaults
+	req := struct {
+		Args           map[string]interface{} `json:"args"`
+		NoDefaults     bool                   `json:"noDefaults"`
+		NoCache        bool                   `json:"noCache,omitempty"`
+		TimeoutSeconds int                    `json:"timeoutSeconds,omitempty"`
+		AuditArgs      map[string]interface{} `json:"auditArgs,omitempty"`
+	}{
+		Args:           args,
+		NoDefaults:     true,
+		NoCache:        noCache,
+		TimeoutSeconds: toolCallTimeout(ctx),
+		AuditArgs:      audit.RecordedArgs(ctx, nil), // What the caller records, the daemon records
 	}
 
-	resp, err := dc.httpClient.Post(
-		dc.getSessionURL(serverName, "tools"),
-		"application/json",
-		bytes.NewBuffer([]byte("{}")),
-	)
+	reqData, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, dc.getToolURL(serverName, toolName)+"?stream=1", bytes.NewReader(reqData))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	// The stream lasts as long as the tool runs, so only ctx bounds it
+	streamClient := &http.Client{Transport: dc.httpClient.Transport}
+	resp, err := streamClient.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer closeResponse(resp)
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("daemon returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Requests refused before the call starts are answered as usual
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		defer closeResponse(resp)
+		var apiResp rawAPIResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+			return nil, err
+		}
+		return nil, apiResp.err()
+	}
+
+	events := make(chan StreamEvent)
+	go readStreamEvents(ctx, resp, events)
+	return events, nil
+}
+
+// readStreamEvents passes the events of a streamed tool call on to events,
+// closing it after the final one. A stream cut short ends with an error event
+// saying why.
+func readStreamEvents(ctx context.Context, resp *http.Response, events chan<- StreamEvent) {
+	defer close(events)
 	defer func() { _ = resp.Body.Close() }()
 
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:")); ok {
+			var event StreamEvent
+			if jsonErr := json.Unmarshal(bytes.TrimSpace(data), &event); jsonErr == nil {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+				if event.Type == StreamEventResult || event.Type == StreamEventError {
+					return
+				}
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			select {
+			case events <- StreamEvent{Type: StreamEventError, Error: err.Error(), err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+	}
+}
+
+// ListTools lists tools for a session via the daemon
+func (dc *DaemonClient) ListTools(ctx context.Context, serverName string) ([]mcp.Tool, error) {
+	if !dc.IsDaemonRunning() {
+		return nil, errDaemonNotRunning
+	}
+
+	resp, err := dc.do(ctx, http.MethodPost, dc.getSessionURL(serverName, "tools"), []byte("{}"))
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponse(resp)
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("daemon returned status %d", resp.StatusCode)
 	}
 
-	var apiResp APIResponse
+	var apiResp rawAPIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
 		return nil, err
 	}
 
 	if !apiResp.Success {
-		return nil, fmt.Errorf("daemon error: %s", apiResp.Error)
+		return nil, apiResp.err()
 	}
 
-	data, _ := json.Marshal(apiResp.Data)
 	var tools []mcp.Tool
-	if err := json.Unmarshal(data, &tools); err != nil {
+	if err := json.Unmarshal(apiResp.Data, &tools); err != nil {
 		return nil, err
 	}
 
 	return tools, nil
 }
 
+// SubmitJob queues a tool call in the daemon and returns the job running it.
+// The arguments are sent as given, without the server's toolDefaults.
+func (dc *DaemonClient) SubmitJob(serverName, toolName string, args map[string]interface{}) (*Job, error) {
+	if !dc.IsDaemonRunning() {
+		return nil, errDaemonNotRunning
+	}
+
+	// The CLI has already merged (or deliberately skipped) the tool defaults
+	req := struct {
+		Tool       string                 `json:"tool"`
+		Args       map[string]interface{} `json:"args"`
+		NoDefaults bool                   `json:"noDefaults"`
+	}{
+		Tool:       toolName,
+		Args:       args,
+		NoDefaults: true,
+	}
+
+	reqData, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := dc.do(context.Background(), http.MethodPost, dc.getSessionURL(serverName, "jobs"), reqData)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJob(resp)
+}
+
+// GetJob reports on a job, with its result once it has finished
+func (dc *DaemonClient) GetJob(ctx context.Context, id string) (*Job, error) {
+	if !dc.IsDaemonRunning() {
+		return nil, errDaemonNotRunning
+	}
+
+	resp, err := dc.do(ctx, http.MethodGet, dc.getJobURL(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJob(resp)
+}
+
+// CancelJob stops a queued or running job
+func (dc *DaemonClient) CancelJob(id string) (*Job, error) {
+	if !dc.IsDaemonRunning() {
+		return nil, errDaemonNotRunning
+	}
+
+	resp, err := dc.do(context.Background(), http.MethodDelete, dc.getJobURL(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJob(resp)
+}
+
+// WaitJob polls a job every interval until it finishes or ctx is done
+func (dc *DaemonClient) WaitJob(ctx context.Context, id string, interval time.Duration) (*Job, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		job, err := dc.GetJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job.State.Finished() {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// decodeJob reads a job from the daemon's response, closing it
+func decodeJob(resp *http.Response) (*Job, error) {
+	var job Job
+	if err := decodeData(resp, "job", &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// decodeData reads a successful API response's data into v, closing the
+// response; what names the data in errors
+func decodeData(resp *http.Response, what string, v interface{}) error {
+	defer closeResponse(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("daemon returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var apiResp rawAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return err
+	}
+
+	if !apiResp.Success {
+		return apiResp.err()
+	}
+
+	if err := json.Unmarshal(apiResp.Data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", what, err)
+	}
+	return nil
+}
+
+// ListSchedules returns the daemon's schedules and how their runs went
+func (dc *DaemonClient) ListSchedules() ([]ScheduleStatus, error) {
+	if !dc.IsDaemonRunning() {
+		return nil, errDaemonNotRunning
+	}
+
+	resp, err := dc.do(context.Background(), http.MethodGet, dc.getSchedulesURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var schedules []ScheduleStatus
+	if err := decodeData(resp, "schedules", &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// ReloadSchedules makes the daemon read schedules.json again
+func (dc *DaemonClient) ReloadSchedules() error {
+	if !dc.IsDaemonRunning() {
+		return errDaemonNotRunning
+	}
+
+	resp, err := dc.do(context.Background(), http.MethodPost, dc.getSchedulesURL(), nil)
+	if err != nil {
+		return err
+	}
+	var schedules []ScheduleStatus
+	return decodeData(resp, "schedules", &schedules)
+}
+
+// GetConfig returns the daemon configuration in effect
+func (dc *DaemonClient) GetConfig() (*LoadedConfig, error) {
+	return dc.config(http.MethodGet)
+}
+
+// ReloadConfig makes the daemon read daemon.json again, returning the
+// configuration then in effect
+func (dc *DaemonClient) ReloadConfig() (*LoadedConfig, error) {
+	return dc.config(http.MethodPost)
+}
+
+// config calls the daemon's config endpoint
+func (dc *DaemonClient) config(method string) (*LoadedConfig, error) {
+	if !dc.IsDaemonRunning() {
+		return nil, errDaemonNotRunning
+	}
+
+	resp, err := dc.do(context.Background(), method, dc.getConfigURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var loaded LoadedConfig
+	if err := decodeData(resp, "daemon config", &loaded); err != nil {
+		return nil, err
+	}
+	return &loaded, nil
+}
+
+// GetSchedule returns a schedule with the outcomes of its recent runs
+func (dc *DaemonClient) GetSchedule(name string) (*ScheduleStatus, error) {
+	if !dc.IsDaemonRunning() {
+		return nil, errDaemonNotRunning
+	}
+
+	resp, err := dc.do(context.Background(), http.MethodGet, dc.getSchedulesURL()+"/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	var status ScheduleStatus
+	if err := decodeData(resp, "schedule", &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// RunSchedule starts a schedule's call now; it runs in the background
+func (dc *DaemonClient) RunSchedule(name string) (*ScheduleStatus, error) {
+	if !dc.IsDaemonRunning() {
+		return nil, errDaemonNotRunning
+	}
+
+	resp, err := dc.do(context.Background(), http.MethodPost, dc.getSchedulesURL()+"/"+url.PathEscape(name)+"/run", nil)
+	if err != nil {
+		return nil, err
+	}
+	var status ScheduleStatus
+	if err := decodeData(resp, "schedule", &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
 // SmartClient provides automatic daemon usage with fallback
 type SmartClient struct {
 	daemonClient *DaemonClient
 	directClient func(config.ServerConfig) (mcp.MCPClient, error)
+	routing      string // A config.DaemonRouting mode
+}
+
+// routing is the mode new SmartClients route by
+var (
+	routingMutex sync.RWMutex
+	routing      = config.DaemonRoutingAuto
+)
+
+// SetRouting sets which servers' calls new SmartClients route through the
+// daemon, as one of the config.DaemonRouting modes
+func SetRouting(mode string) {
+	routingMutex.Lock()
+	defer routingMutex.Unlock()
+	routing = mode
 }
 
 // NewSmartClient creates a new smart client
 func NewSmartClient() *SmartClient {
+	routingMutex.RLock()
+	defer routingMutex.RUnlock()
 	return &SmartClient{
-		daemonClient: NewDaemonClient(),
+		daemonClient: SharedDaemonClient(),
 		directClient: client.NewMCPClient,
+		routing:      routing,
 	}
 }
 
-// ShouldUseDaemon determines if a server should use the daemon
+// ShouldUseDaemon determines if a server should use the daemon. Under
+// --verbose the decision is logged with its reason.
 func (sc *SmartClient) ShouldUseDaemon(serverName string, serverConfig config.ServerConfig) bool {
-	// Don't use daemon if explicitly disabled
-	if !serverConfig.Persistent {
-		return false
+	useDaemon, reason := sc.route(serverName, serverConfig)
+	logging.Debug("daemon routing", "server", serverName, "routing", sc.routing, "daemon", useDaemon, "reason", reason)
+	return useDaemon
+}
+
+// route decides whether a server's calls go through the daemon, starting
+// the daemon if they should, and says why
+func (sc *SmartClient) route(serverName string, serverConfig config.ServerConfig) (bool, string) {
+	// Fixtures are recorded and replayed by clients in this process
+	if client.FixturesActive() {
+		return false, "fixtures are recorded or replayed in this process"
+	}
+	if daemonFailed.Load() {
+		return false, "the daemon failed earlier in this process"
+	}
+
+	switch sc.routing {
+	case config.DaemonRoutingNever:
+		return false, "routing is never"
+	case config.DaemonRoutingAlways:
+	default:
+		if !serverConfig.Persistent {
+			return false, "the server is not persistent"
+		}
 	}
 
 	// Use daemon if it's running
 	if sc.daemonClient.IsDaemonRunning() {
-		return true
+		return true, "the daemon is running"
 	}
 
-	// Auto-start daemon for persistent servers
-	if serverConfig.Persistent {
-		if err := sc.daemonClient.StartDaemon(); err == nil {
-			return true
+	// Auto-start the daemon
+	if err := sc.daemonClient.StartDaemon(); err != nil {
+		if sc.routing == config.DaemonRoutingAlways {
+			logging.Warn("daemon unavailable, connecting directly", "server", serverName, "error", err)
 		}
+		return false, fmt.Sprintf("the daemon could not be started: %v", err)
 	}
-
-	return false
+	return true, "the daemon was started"
 }
 
-// CreateClient creates an MCP client, using daemon when appropriate
+// CreateClient creates an MCP client, using daemon when appropriate. A
+// daemon client falls back to a direct one if the daemon fails.
 func (sc *SmartClient) CreateClient(serverName string, serverConfig config.ServerConfig) (mcp.MCPClient, error) {
+	connectDirectly := func() (mcp.MCPClient, error) {
+		mcpClient, err := sc.directClient(serverConfig)
+		if err != nil {
+			return nil, err
+		}
+		return client.WithRetry(serverName, serverConfig, mcpClient), nil
+	}
+
 	if sc.ShouldUseDaemon(serverName, serverConfig) {
-		return NewDaemonMCPClient(sc.daemonClient, serverName), nil
+		dm := NewDaemonMCPClient(sc.daemonClient, serverName)
+		dm.connectDirectly = connectDirectly
+		dm.knownTools = func() []mcp.Tool { return client.KnownTools(serverName, serverConfig) }
+		return dm, nil
 	}
 
 	// Fall back to direct client
-	return sc.directClient(serverConfig)
+	return connectDirectly()
 }
 
 // DaemonMCPClient is an MCP client that communicates with the daemon
 type DaemonMCPClient struct {
 	daemonClient *DaemonClient
 	serverName   string
+	noCache      bool // Bypass the daemon's result cache
+
+	// progress receives the progress and log events of tool calls, which are
+	// streamed when it is set
+	progress func(StreamEvent)
+
+	// connectDirectly creates the client calls fall back to when the daemon
+	// fails; without it they fail too
+	connectDirectly func() (mcp.MCPClient, error)
+
+	// knownTools returns the server's cached tools, whose annotations tell
+	// which tool calls are safe to repeat over a direct client
+	knownTools func() []mcp.Tool
+
+	mutex  sync.Mutex
+	direct mcp.MCPClient // Set once calls have fallen back
+	tools  []mcp.Tool    // Last listed through the daemon
 }
 
 // NewDaemonMCPClient creates a new daemon MCP client
@@ -368,11 +856,23 @@ func NewDaemonMCPClient(daemonClient *DaemonClient, serverName string) *DaemonMC
 	}
 }
 
+// BypassResultCache makes the daemon call the server for every tool call,
+// as --no-cache asks
+func (dm *DaemonMCPClient) BypassResultCache() {
+	dm.noCache = true
+}
+
+// StreamProgress makes tool calls stream through the daemon, handing the
+// server's progress and log messages to handler as they arrive
+func (dm *DaemonMCPClient) StreamProgress(handler func(StreamEvent)) {
+	dm.progress = handler
+}
+
 // Initialize implements the MCPClient interface
 func (dm *DaemonMCPClient) Initialize(ctx context.Context, params *mcp.InitializeParams) (*mcp.InitializeResult, error) {
 	// Daemon doesn't need explicit initialization - sessions are started on demand
 	return &mcp.InitializeResult{
-		ProtocolVersion: "2024-11-05",
+		ProtocolVersion: mcp.ProtocolVersion,
 		Capabilities: mcp.ServerCapabilities{
 			Tools: &mcp.ToolsCapability{},
 		},
@@ -385,41 +885,119 @@ func (dm *DaemonMCPClient) Initialize(ctx context.Context, params *mcp.Initializ
 
 // ListTools implements the MCPClient interface
 func (dm *DaemonMCPClient) ListTools(ctx context.Context) ([]mcp.Tool, error) {
-	tools, err := dm.daemonClient.ListTools(dm.serverName)
-	if err != nil {
+	if direct := dm.directClient(); direct != nil {
+		return direct.ListTools(ctx)
+	}
+
+	tools, err := dm.listTools(ctx)
+	if err == nil {
+		dm.mutex.Lock()
+		dm.tools = tools
+		dm.mutex.Unlock()
+	}
+	if dm.canFallBack(err) {
+		direct, fallbackErr := dm.fallBack(err)
+		if fallbackErr != nil {
+			return nil, fallbackErr
+		}
+		return direct.ListTools(ctx)
+	}
+	return tools, classifyDaemonError(err)
+}
+
+// listTools lists the tools through the daemon
+func (dm *DaemonMCPClient) listTools(ctx context.Context) ([]mcp.Tool, error) {
+	tools, err := dm.daemonClient.ListTools(ctx, dm.serverName)
+	if errors.Is(err, mcp.ErrSessionNotActive) {
 		// Try to start the session if it doesn't exist
-		if config, loadErr := LoadMCPConfig(); loadErr == nil {
-			if serverConfig, exists := config.MCPServers[dm.serverName]; exists {
-				if startErr := dm.daemonClient.StartSession(dm.serverName, serverConfig); startErr == nil {
-					// Give it a moment to start
-					time.Sleep(1 * time.Second)
-					return dm.daemonClient.ListTools(dm.serverName)
-				}
+		if serverConfig, exists := dm.configuredServer(); exists {
+			if startErr := dm.daemonClient.StartSessionAndWait(ctx, dm.serverName, serverConfig); startErr != nil {
+				return nil, startErr
 			}
+			return dm.daemonClient.ListTools(ctx, dm.serverName)
 		}
 		return nil, err
 	}
-	return tools, nil
+	return tools, err
 }
 
 // CallTool implements the MCPClient interface
 func (dm *DaemonMCPClient) CallTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
-	result, err := dm.daemonClient.CallTool(dm.serverName, toolName, arguments)
-	if err != nil {
-		// Try to start the session if it doesn't exist
-		if config, loadErr := LoadMCPConfig(); loadErr == nil {
-			if serverConfig, exists := config.MCPServers[dm.serverName]; exists {
-				if startErr := dm.daemonClient.StartSession(dm.serverName, serverConfig); startErr == nil {
-					// Give it a moment to start
-					time.Sleep(1 * time.Second)
-					return dm.daemonClient.CallTool(dm.serverName, toolName, arguments)
-				}
+	if direct := dm.directClient(); direct != nil {
+		return direct.CallTool(ctx, toolName, arguments)
+	}
+
+	// A call the daemon received may have run even if the daemon then died,
+	// so only a call that never reached it, or one safe to repeat, falls back
+	result, err := dm.callTool(ctx, toolName, arguments)
+	if dm.canFallBack(err) && (neverSent(err) || dm.isIdempotent(toolName)) {
+		direct, fallbackErr := dm.fallBack(err)
+		if fallbackErr != nil {
+			return nil, fallbackErr
+		}
+		return direct.CallTool(ctx, toolName, arguments)
+	}
+	return result, classifyDaemonError(err)
+}
+
+// callTool calls the tool through the daemon
+func (dm *DaemonMCPClient) callTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
+	result, err := dm.callDaemon(ctx, toolName, arguments)
+	if errors.Is(err, mcp.ErrSessionNotActive) {
+		// Try to start the session if it isn't running
+		if serverConfig, exists := dm.configuredServer(); exists {
+			if startErr := dm.daemonClient.StartSessionAndWait(ctx, dm.serverName, serverConfig); startErr != nil {
+				return nil, startErr
 			}
+			return dm.callDaemon(ctx, toolName, arguments)
 		}
 	}
 	return result, err
 }
 
+// callDaemon makes one tool call through the daemon, streamed when progress
+// is wanted
+func (dm *DaemonMCPClient) callDaemon(ctx context.Context, toolName string, arguments map[string]interface{}) (*mcp.ToolResult, error) {
+	if dm.progress == nil {
+		return dm.daemonClient.CallTool(ctx, dm.serverName, toolName, arguments, dm.noCache)
+	}
+
+	events, err := dm.daemonClient.CallToolStream(ctx, dm.serverName, toolName, arguments, dm.noCache)
+	if err != nil {
+		return nil, err
+	}
+	for event := range events {
+		switch event.Type {
+		case StreamEventResult:
+			var result mcp.ToolResult
+			if err := json.Unmarshal(event.Result, &result); err != nil {
+				return nil, err
+			}
+			result.Raw = event.Result
+			return &result, nil
+		case StreamEventError:
+			if event.err != nil {
+				return nil, event.err
+			}
+			return nil, apiError(event.Error, event.Kind, event.RPCError)
+		default:
+			dm.progress(event)
+		}
+	}
+	return nil, ctx.Err() // Only a cancelled stream ends without a final event
+}
+
+// configuredServer returns the server's configuration, for starting its
+// session on demand
+func (dm *DaemonMCPClient) configuredServer() (config.ServerConfig, bool) {
+	cfg, err := LoadMCPConfig()
+	if err != nil {
+		return config.ServerConfig{}, false
+	}
+	serverConfig, exists := cfg.MCPServers[dm.serverName]
+	return serverConfig, exists
+}
+
 // ListResources implements the MCPClient interface
 func (dm *DaemonMCPClient) ListResources(ctx context.Context) ([]mcp.Resource, error) {
 	// Daemon doesn't support resources yet, fall back to empty list
@@ -452,24 +1030,25 @@ func (dm *DaemonMCPClient) NotifyRootsListChanged(roots []mcp.Root) error {
 
 // Close implements the MCPClient interface
 func (dm *DaemonMCPClient) Close() error {
-	// Daemon manages session lifecycle, nothing to close here
+	// Daemon manages session lifecycle; only a fallback client is ours
+	if direct := dm.directClient(); direct != nil {
+		return direct.Close()
+	}
 	return nil
 }
 
 // Helper methods for URL construction
 
 func (dc *DaemonClient) getHTTPURL() string {
-	if isUnixSocket(dc.manager.endpoint) || isNamedPipe(dc.manager.endpoint) {
-		return "http://localhost:8080" // Fallback for non-HTTP endpoints
+	endpoint := dc.manager.currentEndpoint()
+	if isUnixSocket(endpoint) || isNamedPipe(endpoint) {
+		return "http://" + httpAddress(dc.manager.name) // Fallback for non-HTTP endpoints
 	}
-	return "http://" + dc.manager.endpoint
+	return "http://" + endpoint
 }
 
 func (dc *DaemonClient) getSessionsURL() string {
-	if isUnixSocket(dc.manager.endpoint) || isNamedPipe(dc.manager.endpoint) {
-		return "http://localhost:8080/sessions"
-	}
-	return "http://" + dc.manager.endpoint + "/sessions"
+	return dc.getHTTPURL() + "/sessions"
 }
 
 func (dc *DaemonClient) getSessionURL(serverName, action string) string {
@@ -480,9 +1059,18 @@ func (dc *DaemonClient) getSessionURL(serverName, action string) string {
 	return base + "/" + serverName + "/" + action
 }
 
+func (dc *DaemonClient) getJobURL(id string) string {
+	return dc.getHTTPURL() + "/jobs/" + url.PathEscape(id)
+}
+
+func (dc *DaemonClient) getSchedulesURL() string {
+	return dc.getHTTPURL() + "/schedules"
+}
+
+func (dc *DaemonClient) getConfigURL() string {
+	return dc.getHTTPURL() + "/config"
+}
+
 func (dc *DaemonClient) getToolURL(serverName, toolName string) string {
-	if isUnixSocket(dc.manager.endpoint) || isNamedPipe(dc.manager.endpoint) {
-		return fmt.Sprintf("http://localhost:8080/sessions/%s/call-tool/%s", serverName, toolName)
-	}
-	return fmt.Sprintf("http://%s/sessions/%s/call-tool/%s", dc.manager.endpoint, serverName, toolName)
+	return fmt.Sprintf("%s/call-tool/%s", dc.getSessionURL(serverName, ""), toolName)
 }
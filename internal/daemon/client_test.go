@@ -0,0 +1,294 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// stubDaemon serves the tools endpoint like a daemon with one session and
+// counts the connections clients open to it
+type stubDaemon struct {
+	tb          testing.TB
+	server      *httptest.Server
+	connections atomic.Int64
+}
+
+func newStubDaemon(tb testing.TB) *stubDaemon {
+	tb.Setenv(config.ConfigDirEnv, tb.TempDir())
+
+	stub := &stubDaemon{tb: tb}
+	stub.server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ") // Like the daemon, leaving a trailing newline
+		_ = encoder.Encode(APIResponse{Success: true, Data: []mcp.Tool{{Name: "navigate"}}})
+	}))
+	stub.server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			stub.connections.Add(1)
+		}
+	}
+	stub.server.Start()
+	tb.Cleanup(stub.server.Close)
+	return stub
+}
+
+// client returns a daemon client for the stub using httpClient
+func (s *stubDaemon) client(httpClient *http.Client) *DaemonClient {
+	dc := newTestDaemonClient(s.tb, s.server)
+	dc.httpClient = httpClient
+	return dc
+}
+
+// newTestDaemonClient returns a client for server, standing in for the
+// daemon. DaemonClient checks the PID file before calling, so this process's
+// PID is written to the config directory, which the test must have set.
+func newTestDaemonClient(tb testing.TB, server *httptest.Server) *DaemonClient {
+	tb.Helper()
+	if err := os.WriteFile(getPIDFilePath(""), []byte(fmt.Sprint(os.Getpid())), 0644); err != nil {
+		tb.Fatal(err)
+	}
+	return &DaemonClient{
+		manager:    &DaemonManager{endpoint: strings.TrimPrefix(server.URL, "http://")},
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func TestDaemonClientReusesConnections(t *testing.T) {
+	stub := newStubDaemon(t)
+	dc := stub.client(&http.Client{Timeout: 5 * time.Second, Transport: transport})
+	defer CloseIdleConnections()
+
+	for i := 0; i < 50; i++ {
+		if _, err := dc.ListTools(context.Background(), "chrome"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := stub.connections.Load(); n != 1 {
+		t.Errorf("50 sequential calls opened %d connections, want 1", n)
+	}
+}
+
+func TestSharedDaemonClientIsReused(t *testing.T) {
+	if SharedDaemonClient() != SharedDaemonClient() {
+		t.Error("expected one daemon client per process")
+	}
+	if NewSmartClient().daemonClient != SharedDaemonClient() || NewSessionBroker().client != SharedDaemonClient() {
+		t.Error("helpers should use the shared daemon client")
+	}
+}
+
+// BenchmarkDaemonCalls makes 200 daemon calls per iteration over the shared
+// transport and, for comparison, over a new connection each time
+func BenchmarkDaemonCalls(b *testing.B) {
+	stub := newStubDaemon(b)
+	defer CloseIdleConnections()
+
+	run := func(b *testing.B, dc *DaemonClient) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for call := 0; call < 200; call++ {
+				if _, err := dc.ListTools(context.Background(), "chrome"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+
+	b.Run("shared", func(b *testing.B) {
+		run(b, stub.client(&http.Client{Timeout: 5 * time.Second, Transport: transport}))
+	})
+	b.Run("fresh", func(b *testing.B) {
+		run(b, stub.client(&http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{DisableKeepAlives: true}}))
+	})
+}
+
+// routingClient returns a SmartClient routing by mode, with the daemon
+// running or, since it may not auto-start, unable to run
+func routingClient(t *testing.T, mode string, running bool) *SmartClient {
+	t.Helper()
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	if running {
+		// This process stands in for the daemon
+		if err := os.WriteFile(getPIDFilePath(""), []byte(fmt.Sprint(os.Getpid())), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &SmartClient{
+		daemonClient: &DaemonClient{manager: &DaemonManager{}},
+		directClient: func(config.ServerConfig) (mcp.MCPClient, error) { return nil, nil },
+		routing:      mode,
+	}
+}
+
+func TestSmartClientRouting(t *testing.T) {
+	persistent := config.ServerConfig{Command: "npx", Persistent: true}
+	direct := config.ServerConfig{Command: "uvx"}
+	tests := []struct {
+		name       string
+		mode       string
+		running    bool
+		server     config.ServerConfig
+		wantDaemon bool
+		wantReason string
+	}{
+		{"auto persistent", config.DaemonRoutingAuto, true, persistent, true, "the daemon is running"},
+		{"auto direct", config.DaemonRoutingAuto, true, direct, false, "the server is not persistent"},
+		{"unset is auto", "", true, direct, false, "the server is not persistent"},
+		{"always", config.DaemonRoutingAlways, true, direct, true, "the daemon is running"},
+		{"always without a daemon", config.DaemonRoutingAlways, false, direct, false, "the daemon could not be started"},
+		{"never", config.DaemonRoutingNever, true, persistent, false, "routing is never"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			previous := logging.Logger()
+			logging.SetDefault(logging.New(&log, slog.LevelDebug))
+			defer logging.SetDefault(previous)
+
+			sc := routingClient(t, tt.mode, tt.running)
+			if got := sc.ShouldUseDaemon("server", tt.server); got != tt.wantDaemon {
+				t.Errorf("ShouldUseDaemon = %v, want %v", got, tt.wantDaemon)
+			}
+			if !strings.Contains(log.String(), tt.wantReason) {
+				t.Errorf("expected the reason %q logged, got:\n%s", tt.wantReason, log.String())
+			}
+		})
+	}
+}
+
+func TestSmartClientAlwaysFallsBackWithWarning(t *testing.T) {
+	var log bytes.Buffer
+	previous := logging.Logger()
+	logging.SetDefault(logging.New(&log, slog.LevelWarn))
+	defer logging.SetDefault(previous)
+
+	sc := routingClient(t, config.DaemonRoutingAlways, false)
+	mcpClient, err := sc.CreateClient("time", config.ServerConfig{Command: "uvx"})
+	if err != nil {
+		t.Fatalf("CreateClient failed: %v", err)
+	}
+	if _, viaDaemon := mcpClient.(*DaemonMCPClient); viaDaemon {
+		t.Error("expected a direct client when the daemon cannot start")
+	}
+	if !strings.Contains(log.String(), "daemon unavailable, connecting directly") {
+		t.Errorf("expected a warning, got %q", log.String())
+	}
+
+	// Auto routing falls back to direct clients quietly
+	log.Reset()
+	sc.routing = config.DaemonRoutingAuto
+	if sc.ShouldUseDaemon("chrome", config.ServerConfig{Command: "npx", Persistent: true}) || log.Len() > 0 {
+		t.Errorf("expected a quiet fallback, got %q", log.String())
+	}
+}
+
+func TestSetRouting(t *testing.T) {
+	defer SetRouting(config.DaemonRoutingAuto)
+	SetRouting(config.DaemonRoutingNever)
+	if got := NewSmartClient().routing; got != config.DaemonRoutingNever {
+		t.Errorf("expected new clients to route by the set mode, got %q", got)
+	}
+}
+
+// slowDaemon answers nothing until the request goes away, counting the
+// requests it got
+func slowDaemon(t *testing.T) (*DaemonClient, *atomic.Int32) {
+	t.Helper()
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	daemonFailed.Store(false)
+	t.Cleanup(func() { daemonFailed.Store(false) })
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body) // So the server notices the client hanging up
+		requests.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+	dc := newTestDaemonClient(t, server)
+	dc.httpClient.Transport = transport
+	return dc, &requests
+}
+
+func TestDaemonCallsStopWithTheirContext(t *testing.T) {
+	dc, requests := slowDaemon(t)
+
+	calls := map[string]func(ctx context.Context) error{
+		"call tool": func(ctx context.Context) error {
+			direct := &directServer{}
+			client := NewDaemonMCPClient(dc, "chrome")
+			client.connectDirectly = func() (mcp.MCPClient, error) { return direct, nil }
+			_, err := client.CallTool(ctx, "navigate", nil)
+			if direct.calls.Load() != 0 {
+				t.Error("a cancelled call should not fall back to a direct connection")
+			}
+			return err
+		},
+		"list tools": func(ctx context.Context) error {
+			_, err := NewDaemonMCPClient(dc, "chrome").ListTools(ctx)
+			return err
+		},
+		"list sessions": func(ctx context.Context) error {
+			_, err := dc.ListSessions(ctx)
+			return err
+		},
+		"stop session": func(ctx context.Context) error {
+			return dc.StopSession(ctx, "chrome")
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			before := requests.Load()
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				for requests.Load() == before {
+					time.Sleep(time.Millisecond)
+				}
+				cancel()
+			}()
+
+			start := time.Now()
+			err := call(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("want context.Canceled, got %v", err)
+			}
+			if errors.Is(err, mcp.ErrDaemonUnavailable) {
+				t.Errorf("cancelling is not the daemon failing: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("took %s to return after cancelling", elapsed)
+			}
+		})
+	}
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := dc.CallTool(ctx, "chrome", "navigate", nil, false)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("want context.DeadlineExceeded, got %v", err)
+		}
+	})
+}
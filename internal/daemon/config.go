@@ -0,0 +1,235 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/redact"
+)
+
+// logLevels are the values logLevel takes
+var logLevels = map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+
+// LoadedConfig is the daemon configuration in effect and where it came from
+type LoadedConfig struct {
+	Config   *DaemonConfig `json:"config"`
+	Path     string        `json:"path,omitempty"`    // The daemon.json read; empty when none was
+	Defaults bool          `json:"defaults"`          // The file was missing or invalid, so only the defaults apply
+	Warning  string        `json:"warning,omitempty"` // Why the file was not used
+	Restart  []string      `json:"restart,omitempty"` // Settings changed in the file that apply once the daemon restarts
+}
+
+// Validate reports settings the daemon cannot use
+func (c *DaemonConfig) Validate() error {
+	if c.LogLevel != "" && !logLevels[strings.ToLower(c.LogLevel)] {
+		return fmt.Errorf("logLevel must be debug, info, warn or error, not %q", c.LogLevel)
+	}
+	if c.MaxSessions < 0 {
+		return errors.New("maxSessions must not be negative")
+	}
+	if c.JobRetention < 0 {
+		return errors.New("jobRetention must not be negative")
+	}
+	if c.MaxToolTimeout < 0 {
+		return errors.New("maxToolTimeout must not be negative")
+	}
+	if c.Address != "" {
+		host, _, err := net.SplitHostPort(c.Address)
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", c.Address, err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return fmt.Errorf("address %q is not a loopback address", c.Address)
+		}
+	}
+	if c.Sampling != nil {
+		if err := c.Sampling.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redacted returns a copy of the configuration fit for an API response, with
+// credentials in the sampling endpoint or command scrubbed
+func (c *DaemonConfig) redacted() *DaemonConfig {
+	copied := *c
+	if c.Sampling != nil {
+		samplingConfig := *c.Sampling
+		if endpoint, err := url.Parse(samplingConfig.Endpoint); err == nil && endpoint.User != nil {
+			samplingConfig.Endpoint = endpoint.Redacted()
+		}
+		if samplingConfig.APIKeyEnv != "" {
+			samplingConfig.Command = redact.New(os.Getenv(samplingConfig.APIKeyEnv)).String(samplingConfig.Command)
+		}
+		copied.Sampling = &samplingConfig
+	}
+	return &copied
+}
+
+// parseDaemonConfig reads a daemon.json, leaving the settings it does not
+// give at their defaults
+func parseDaemonConfig(data []byte) (*DaemonConfig, error) {
+	config := DefaultDaemonConfig()
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid daemon config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid daemon config: %w", err)
+	}
+	return config, nil
+}
+
+// LoadDaemonConfig reads the daemon configuration at path. A file that is
+// missing or invalid gives the defaults, saying why.
+func LoadDaemonConfig(path string) *LoadedConfig {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return &LoadedConfig{Config: DefaultDaemonConfig(), Path: path, Defaults: true, Warning: err.Error()}
+	}
+	config, err := parseDaemonConfig(data)
+	if err != nil {
+		return &LoadedConfig{Config: DefaultDaemonConfig(), Path: path, Defaults: true, Warning: err.Error()}
+	}
+	return &LoadedConfig{Config: config, Path: path}
+}
+
+// SetDaemonConfigValue sets key, such as "maxIdleTime" or "sampling.model",
+// in a daemon.json's data (none for a new file) and returns the new file.
+// value is read as JSON, or else taken as a string; null drops the setting
+// so its default applies. Unknown settings and values the daemon would
+// reject are errors.
+func SetDaemonConfigValue(data []byte, key, value string) ([]byte, error) {
+	file := map[string]interface{}{}
+	if len(bytes.TrimSpace(data)) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber() // Numbers are written back as they were
+		if err := decoder.Decode(&file); err != nil {
+			return nil, fmt.Errorf("invalid daemon config: %w", err)
+		}
+	}
+
+	var parsed interface{} = value
+	if json.Valid([]byte(value)) {
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&parsed); err != nil {
+			return nil, err
+		}
+	}
+
+	// The key must name a setting and the value suit it; other keys the
+	// file has are left alone
+	path := strings.Split(key, ".")
+	var probe interface{} = parsed
+	for i := len(path) - 1; i >= 0; i-- {
+		probe = map[string]interface{}{path[i]: probe}
+	}
+	probeData, err := json.Marshal(probe)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(probeData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&DaemonConfig{}); err != nil {
+		return nil, fmt.Errorf("cannot set %s: %w", key, err)
+	}
+
+	section := file
+	for _, name := range path[:len(path)-1] {
+		next, ok := section[name].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			section[name] = next
+		}
+		section = next
+	}
+	if last := path[len(path)-1]; parsed == nil {
+		delete(section, last)
+	} else {
+		section[last] = parsed
+	}
+
+	updated, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseDaemonConfig(updated); err != nil {
+		return nil, fmt.Errorf("cannot set %s: %w", key, err)
+	}
+	return append(updated, '\n'), nil
+}
+
+// Config reports the daemon configuration in effect, with secrets redacted
+func (d *Daemon) Config() *LoadedConfig {
+	loaded := *d.config.Load()
+	loaded.Config = loaded.Config.redacted()
+	return &loaded
+}
+
+// daemonConfig returns the daemon configuration in effect
+func (d *Daemon) daemonConfig() *DaemonConfig {
+	return d.config.Load().Config
+}
+
+// applyLogLevel sets the shared logger to the configured logLevel, unless
+// the daemon was started to log verbosely
+func applyLogLevel(config *DaemonConfig) {
+	var level slog.Level
+	if os.Getenv(logging.VerboseEnv) == "true" || level.UnmarshalText([]byte(config.LogLevel)) != nil {
+		return
+	}
+	logging.SetLevel(level)
+}
+
+// ReloadConfig reads daemon.json again. Settings the daemon only reads at
+// startup keep their values and are listed as awaiting a restart; an invalid
+// file leaves the configuration as it was.
+func (d *Daemon) ReloadConfig() (*LoadedConfig, error) {
+	current := d.config.Load()
+	if current.Path == "" {
+		return nil, errors.New("the daemon was not started with a daemon.json to reload")
+	}
+
+	next := &LoadedConfig{Path: current.Path}
+	data, err := os.ReadFile(current.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		next.Config, next.Defaults, next.Warning = DefaultDaemonConfig(), true, err.Error()
+	case err != nil:
+		return nil, fmt.Errorf("failed to read daemon config: %w", err)
+	default:
+		if next.Config, err = parseDaemonConfig(data); err != nil {
+			return nil, err
+		}
+	}
+
+	// Compared with what the daemon started with, not the last reload
+	running := current.Config
+	if next.Config.Address != running.Address {
+		next.Restart = append(next.Restart, "address")
+		next.Config.Address = running.Address
+	}
+	if next.Config.JobSpillDir != running.JobSpillDir {
+		next.Restart = append(next.Restart, "jobSpillDir")
+		next.Config.JobSpillDir = running.JobSpillDir
+	}
+	if !reflect.DeepEqual(next.Config.Sampling, running.Sampling) {
+		next.Restart = append(next.Restart, "sampling")
+		next.Config.Sampling = running.Sampling
+	}
+
+	d.config.Store(next)
+	d.jobs.setRetention(next.Config)
+	applyLogLevel(next.Config)
+	return d.Config(), nil
+}
@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/testharness"
+)
+
+// TestHarnessServer is the stdio server of testharness.StdioConfig
+func TestHarnessServer(t *testing.T) { testharness.ServeStdioHelper() }
+
+// harnessSessions numbers the sessions dialProxy starts
+var harnessSessions atomic.Int32
+
+// dialProxy returns a dial function reaching the server serve configures
+// through a daemon's session for it, as the CLI does
+func dialProxy(serve func(t *testing.T, script testharness.Script) config.ServerConfig) func(*testing.T, testharness.Script) mcp.MCPClient {
+	return func(t *testing.T, script testharness.Script) mcp.MCPClient {
+		d, _ := newTestDaemon(t)
+		d.clientFactory = client.NewMCPClient
+
+		serverName := fmt.Sprintf("harness%d", harnessSessions.Add(1))
+		if err := d.StartSession(serverName, serve(t, script)); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = d.StopSession(serverName) })
+		waitForActive(t, d, serverName)
+
+		mux := http.NewServeMux()
+		d.setupRoutes(mux)
+		server := httptest.NewServer(mux)
+		t.Cleanup(server.Close)
+		return NewDaemonMCPClient(newTestDaemonClient(t, server), serverName)
+	}
+}
+
+func TestProxyConformance(t *testing.T) {
+	backends := map[string]func(t *testing.T, script testharness.Script) config.ServerConfig{
+		"stdio": func(_ *testing.T, script testharness.Script) config.ServerConfig {
+			return testharness.StdioConfig(script)
+		},
+		"http": func(t *testing.T, script testharness.Script) config.ServerConfig {
+			return testharness.StartHTTP(t, script)
+		},
+	}
+	for name, serve := range backends {
+		t.Run(name, func(t *testing.T) {
+			testharness.Run(t, testharness.Transport{
+				Name:   "daemon proxy to " + name,
+				Dial:   dialProxy(serve),
+				Stream: name == "stdio",
+			})
+		})
+	}
+}
@@ -3,6 +3,7 @@ package daemon
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,11 +11,20 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
 	"github.com/mcp-cli-ent/mcp-cli/internal/client"
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/exports"
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/resultcache"
+	"github.com/mcp-cli-ent/mcp-cli/internal/sampling"
+	"github.com/mcp-cli-ent/mcp-cli/internal/schedule"
+	"github.com/mcp-cli-ent/mcp-cli/internal/session"
+	"github.com/mcp-cli-ent/mcp-cli/internal/suggest"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolcache"
 	"github.com/mcp-cli-ent/mcp-cli/pkg/version"
 )
 
@@ -23,13 +33,27 @@ type Daemon struct {
 	httpServer    *http.Server
 	sessions      map[string]*PersistentSession
 	sessionMutex  sync.RWMutex
-	config        *DaemonConfig
+	config        atomic.Pointer[LoadedConfig] // daemon.json as loaded, or reloaded
 	clientFactory func(config.ServerConfig) (mcp.MCPClient, error)
 	startTime     time.Time
 	pid           int
 	platform      string
+	name          string // The daemon instance; empty is the shared one
 	endpoint      string
+	fixedEndpoint bool // Set by daemon.json's address rather than derived from the instance name
 	shutdownChan  chan struct{}
+	configWatcher *config.Watcher
+
+	exports   atomic.Pointer[exports.Policy]    // From the configuration
+	toolCache *toolcache.Cache                  // The CLI's on-disk tool lists, invalidated when a server's tools change
+	jobs      *jobStore                         // Tool calls running in the background
+	schedules *scheduler                        // Tool calls made on a schedule
+	streams   *streamHub                        // Tool calls streaming their servers' notifications
+	audit     atomic.Pointer[audit.Log]         // Where tool calls are recorded; nil records nothing
+	results   atomic.Pointer[resultcache.Cache] // Results of cacheTools tools, shared with the CLI
+
+	mcpConfig    atomic.Pointer[config.Configuration]                 // The watched configuration, if any
+	serverConfig func(serverName string) (config.ServerConfig, error) // Finds servers for sessions the daemon starts itself
 }
 
 // NewDaemon creates a new daemon instance
@@ -39,18 +63,39 @@ func NewDaemon(config *DaemonConfig) (*Daemon, error) {
 	}
 
 	platform := detectPlatform()
-	endpoint := getDaemonEndpoint(platform)
+	name := instanceName()
+	endpoint := getDaemonEndpoint(platform, name)
+	fixedEndpoint := config.Address != ""
+	if fixedEndpoint {
+		endpoint = config.Address
+	}
+
+	// The daemon only invalidates cached tools, so the TTL doesn't matter;
+	// without a config directory there is no cache to keep fresh
+	toolCache, _ := toolcache.Default(0)
+
+	// Without a config directory there are no schedules to run
+	schedulesPath, _ := schedule.DefaultPath()
 
 	daemon := &Daemon{
 		sessions:      make(map[string]*PersistentSession),
-		config:        config,
 		clientFactory: client.NewMCPClient,
 		startTime:     time.Now(),
 		pid:           os.Getpid(),
 		platform:      platform,
+		name:          name,
 		endpoint:      endpoint,
+		fixedEndpoint: fixedEndpoint,
 		shutdownChan:  make(chan struct{}),
+		toolCache:     toolCache,
+		jobs:          newJobStore(config),
+		streams:       newStreamHub(),
 	}
+	daemon.config.Store(&LoadedConfig{Config: config})
+	daemon.schedules = newScheduler(schedulesPath, daemon.runSchedule)
+	daemon.serverConfig = daemon.loadServerConfig
+	daemon.audit.Store(audit.Default(nil))
+	daemon.SetResultCache(0)
 
 	return daemon, nil
 }
@@ -69,9 +114,32 @@ func (d *Daemon) Start() error {
 		WriteTimeout: 30 * time.Second,
 	}
 
+	// Record, cache and export tool calls as the configuration says;
+	// watching it keeps this current
+	if err := d.applyStartupConfig(config.GetConfigPath("")); err != nil {
+		return err
+	}
+
+	applyLogLevel(d.daemonConfig())
+
+	// Stdio servers' sampling requests go to the configured LLM
+	if samplingConfig := d.daemonConfig().Sampling; samplingConfig != nil {
+		if handler, err := sampling.New(*samplingConfig); err != nil {
+			log.Printf("Warning: not answering sampling requests: %v", err)
+		} else {
+			client.SetSamplingHandler(handler)
+		}
+	}
+
 	// Start background cleanup routine
 	go d.cleanupRoutine()
 
+	// Run scheduled tool calls
+	if err := d.schedules.reload(time.Now()); err != nil {
+		log.Printf("Warning: not running schedules: %v", err)
+	}
+	go d.schedules.loop(d.shutdownChan)
+
 	// Start server based on endpoint type
 	var err error
 	if isUnixSocket(d.endpoint) {
@@ -94,11 +162,20 @@ func (d *Daemon) Start() error {
 func (d *Daemon) Stop() error {
 	log.Printf("Stopping MCP CLI daemon...")
 
+	// Stop reloading the config before sessions go away
+	if d.configWatcher != nil {
+		_ = d.configWatcher.Close()
+	}
+
+	// Cancel jobs while their servers can still be told
+	d.jobs.cancelAll()
+
 	// Stop all sessions
 	d.sessionMutex.Lock()
 	for serverName, session := range d.sessions {
 		if session.Client != nil {
 			log.Printf("Stopping session: %s", serverName)
+			d.runHook(serverName, session.Config, hookEventStop, nil)
 			_ = session.Client.Close()
 		}
 	}
@@ -114,6 +191,9 @@ func (d *Daemon) Stop() error {
 		}
 	}
 
+	// Let stop hooks spawn before the process exits
+	session.WaitForHooks()
+
 	// Signal shutdown
 	close(d.shutdownChan)
 
@@ -121,21 +201,28 @@ func (d *Daemon) Stop() error {
 	return nil
 }
 
-// StartSession starts a new persistent session for a server
+// StartSession starts a new persistent session for a server. A session
+// already starting is not started again: the caller shares that attempt, and
+// awaitSession waits for its outcome.
 func (d *Daemon) StartSession(serverName string, serverConfig config.ServerConfig) error {
 	d.sessionMutex.Lock()
 	defer d.sessionMutex.Unlock()
 
 	// Check if session already exists
-	if existing, exists := d.sessions[serverName]; exists {
+	existing, exists := d.sessions[serverName]
+	if exists {
 		if existing.Status == SessionStatusActive {
-			return fmt.Errorf("session %s already active", serverName)
+			return fmt.Errorf("session %s %w", serverName, errSessionActive)
 		}
 		if existing.Status == SessionStatusStarting {
-			return fmt.Errorf("session %s is already starting", serverName)
+			return nil
 		}
 	}
 
+	if limit := d.daemonConfig().MaxSessions; limit > 0 && d.liveSessions() >= limit {
+		return &SessionLimitError{Server: serverName, Limit: limit}
+	}
+
 	// Create new session
 	session := &PersistentSession{
 		ServerName: serverName,
@@ -143,55 +230,129 @@ func (d *Daemon) StartSession(serverName string, serverConfig config.ServerConfi
 		Config:     serverConfig,
 		StartTime:  time.Now(),
 		LastUsed:   time.Now(),
-		ToolCache:  make(map[string][]mcp.Tool),
+		ListCache:  newListCache(),
+		started:    make(chan struct{}),
+	}
+
+	// Starting a session that failed again is a restart; its metrics carry over
+	if exists {
+		session.SessionMetrics = existing.SessionMetrics
+		session.SessionMetrics.RecordRestart()
 	}
 
 	d.sessions[serverName] = session
 
 	// Start session in background to avoid blocking
-	go d.startSessionBackground(session)
+	go d.startSessionBackground(session, serverConfig)
 
 	return nil
 }
 
-// startSessionBackground starts a session in the background
-func (d *Daemon) startSessionBackground(session *PersistentSession) {
+// liveSessions counts the sessions that are starting or active. The caller
+// holds sessionMutex.
+func (d *Daemon) liveSessions() int {
+	live := 0
+	for _, session := range d.sessions {
+		if session.Status == SessionStatusActive || session.Status == SessionStatusStarting {
+			live++
+		}
+	}
+	return live
+}
+
+// startSessionBackground starts a session in the background. Only the session
+// it was given is updated: if that session was stopped or replaced meanwhile,
+// the outcome is dropped.
+func (d *Daemon) startSessionBackground(session *PersistentSession, serverConfig config.ServerConfig) {
 	log.Printf("Starting session: %s", session.ServerName)
+	defer close(session.started)
+
+	fail := func(errorMsg string) {
+		d.sessionMutex.Lock()
+		defer d.sessionMutex.Unlock()
+		if d.sessions[session.ServerName] == session {
+			d.failSession(session, errorMsg)
+		}
+	}
 
 	// Create MCP client
-	client, err := d.clientFactory(session.Config)
+	client, err := d.clientFactory(serverConfig)
 	if err != nil {
-		d.setSessionError(session.ServerName, fmt.Sprintf("failed to create client: %v", err))
+		fail(fmt.Sprintf("failed to create client: %v", err))
 		return
 	}
 
-	// Test connection with a simple health check
+	if source, ok := client.(mcp.NotificationSource); ok {
+		serverName := session.ServerName
+		source.SetNotificationHandler(func(method string, params json.RawMessage) {
+			if method == mcp.ToolsListChanged {
+				// Handlers must not block the client's read path
+				go d.toolsChanged(serverName)
+				return
+			}
+			d.streams.notify(serverName, method, params)
+		})
+	}
+
+	// Test connection with the cheapest probe the server answers
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	_, err = client.ListTools(ctx)
+	probe, err := probeServer(ctx, client)
 	if err != nil {
 		_ = client.Close()
-		d.setSessionError(session.ServerName, fmt.Sprintf("health check failed: %v", err))
+		fail(fmt.Sprintf("health check failed: %v", err))
 		return
 	}
 
 	// Session started successfully
 	d.sessionMutex.Lock()
-	if existingSession, exists := d.sessions[session.ServerName]; exists {
-		existingSession.Client = client
-		existingSession.Status = SessionStatusActive
-		existingSession.LastUsed = time.Now()
-		existingSession.Error = ""
-
-		// Try to get PID if it's a stdio session
-		if session.Config.Command != "" {
-			existingSession.PID = d.tryGetSessionPID(session.Config)
-		}
+	if d.sessions[session.ServerName] != session {
+		d.sessionMutex.Unlock()
+		_ = client.Close()
+		log.Printf("Session %s was stopped while starting", session.ServerName)
+		return
+	}
+	session.Client = client
+	session.Status = SessionStatusActive
+	session.LastUsed = time.Now()
+	session.Error = ""
+
+	// Try to get PID if it's a stdio session
+	if serverConfig.Command != "" {
+		session.PID = d.tryGetSessionPID(serverConfig)
 	}
 	d.sessionMutex.Unlock()
 
-	log.Printf("Session started successfully: %s", session.ServerName)
+	log.Printf("Session started successfully: %s (checked with %s)", session.ServerName, probe)
+	d.runHook(session.ServerName, serverConfig, hookEventStart, nil)
+}
+
+// ResetSessionStats clears a session's metrics, such as its restart count and
+// last error, which nothing else resets
+func (d *Daemon) ResetSessionStats(serverName string) error {
+	d.sessionMutex.Lock()
+	defer d.sessionMutex.Unlock()
+
+	session, exists := d.sessions[serverName]
+	if !exists {
+		return d.sessionNotFoundError(serverName)
+	}
+	session.SessionMetrics.Reset()
+	return nil
+}
+
+// toolsChanged forgets a server's tool lists after it announced new tools
+func (d *Daemon) toolsChanged(serverName string) {
+	d.sessionMutex.Lock()
+	if session, exists := d.sessions[serverName]; exists {
+		session.ListCache.clear()
+	}
+	d.sessionMutex.Unlock()
+
+	if err := d.toolCache.Invalidate(serverName); err != nil {
+		log.Printf("Failed to invalidate cached tools of %s: %v", serverName, err)
+	}
 }
 
 // StopSession stops a session
@@ -201,14 +362,15 @@ func (d *Daemon) StopSession(serverName string) error {
 
 	session, exists := d.sessions[serverName]
 	if !exists {
-		return fmt.Errorf("session %s not found", serverName)
+		return d.sessionNotFoundError(serverName)
 	}
 
 	if session.Status != SessionStatusActive {
-		return fmt.Errorf("session %s is not active", serverName)
+		return mcp.Mark(fmt.Errorf("session %s is not active", serverName), mcp.ErrSessionNotActive)
 	}
 
 	session.Status = SessionStatusStopping
+	d.runHook(serverName, session.Config, hookEventStop, nil)
 
 	if session.Client != nil {
 		_ = session.Client.Close()
@@ -228,16 +390,26 @@ func (d *Daemon) GetSession(serverName string) (*PersistentSession, error) {
 
 	session, exists := d.sessions[serverName]
 	if !exists {
-		return nil, fmt.Errorf("session %s not found", serverName)
+		return nil, d.sessionNotFoundError(serverName)
 	}
 
 	if session.Status != SessionStatusActive {
-		return nil, fmt.Errorf("session %s is not active (status: %s)", serverName, session.Status)
+		return nil, mcp.Mark(fmt.Errorf("session %s is not active (status: %s)", serverName, session.Status), mcp.ErrSessionNotActive)
 	}
 
 	return session, nil
 }
 
+// sessionNotFoundError reports an unknown session, suggesting the sessions
+// whose names are close to it. For callers it is a session not active. The caller holds sessionMutex.
+func (d *Daemon) sessionNotFoundError(serverName string) error {
+	names := make([]string, 0, len(d.sessions))
+	for name := range d.sessions {
+		names = append(names, name)
+	}
+	return mcp.Mark(suggest.Wrap(fmt.Errorf("session %s not found", serverName), serverName, names), mcp.ErrSessionNotActive)
+}
+
 // ListSessions returns information about all sessions
 func (d *Daemon) ListSessions() []SessionInfo {
 	d.sessionMutex.RLock()
@@ -246,14 +418,18 @@ func (d *Daemon) ListSessions() []SessionInfo {
 	var sessions []SessionInfo
 	for _, session := range d.sessions {
 		info := SessionInfo{
-			ServerName: session.ServerName,
-			Status:     session.Status.String(),
-			StartTime:  session.StartTime,
-			LastUsed:   session.LastUsed,
-			Duration:   time.Since(session.StartTime),
-			Error:      session.Error,
-			PID:        session.PID,
+			ServerName:     session.ServerName,
+			Status:         session.Status.String(),
+			StartTime:      session.StartTime,
+			LastUsed:       session.LastUsed,
+			Duration:       time.Since(session.StartTime),
+			Error:          session.Error,
+			PID:            session.PID,
+			ConfigOutdated: session.ConfigOutdated,
+			SessionMetrics: session.SessionMetrics,
+			ListCacheStats: session.ListCache.stats,
 		}
+		session.noteRunning(&info, time.Now())
 		sessions = append(sessions, info)
 	}
 
@@ -262,45 +438,114 @@ func (d *Daemon) ListSessions() []SessionInfo {
 
 // CallTool executes a tool in a persistent session
 func (d *Daemon) CallTool(serverName, toolName string, args map[string]interface{}) (*mcp.ToolResult, error) {
+	return d.callTool(serverName, toolName, args, true, true, audit.SourceDaemon)
+}
+
+// callTool executes a tool, merging the server's toolDefaults into args
+// unless applyDefaults is false. Tools the server's cacheTools covers are
+// answered from the result cache when useCache is true. The call may take
+// as long as toolTimeout allows by default. source says where the call came
+// from in the audit log.
+func (d *Daemon) callTool(serverName, toolName string, args map[string]interface{}, applyDefaults, useCache bool, source string) (*mcp.ToolResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.toolTimeout(0))
+	defer cancel()
+	return d.callToolContext(ctx, serverName, toolName, args, applyDefaults, useCache, source)
+}
+
+// defaultToolTimeout is how long a tool call may take when its caller
+// doesn't say
+const defaultToolTimeout = 60 * time.Second
+
+// toolTimeout returns how long a tool call may take when its caller asks
+// for seconds, 0 being the default, bounded by the daemon's maxToolTimeout
+func (d *Daemon) toolTimeout(seconds int) time.Duration {
+	timeout := defaultToolTimeout
+	if seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	if limit := time.Duration(d.daemonConfig().MaxToolTimeout) * time.Second; limit > 0 && timeout > limit {
+		timeout = limit
+	}
+	return timeout
+}
+
+// callToolContext is callTool bounded by ctx instead
+func (d *Daemon) callToolContext(ctx context.Context, serverName, toolName string, args map[string]interface{}, applyDefaults, useCache bool, source string) (*mcp.ToolResult, error) {
+	d.restartOutdatedSession(serverName)
+	d.waitIfStarting(ctx, serverName)
 	session, err := d.GetSession(serverName)
 	if err != nil {
 		return nil, err
 	}
 
 	// Update last used time
+	d.sessionMutex.Lock()
+	if applyDefaults {
+		args = session.Config.ApplyToolDefaults(toolName, args)
+	}
 	session.LastUsed = time.Now()
+	serverConfig := session.Config
+	ttl := serverConfig.ResultTTL(toolName, resultcache.ReadOnly(session.ListCache.peekTools(), toolName))
+	d.sessionMutex.Unlock()
+	transport := audit.Transport(serverConfig)
 
-	// Execute tool
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	var results *resultcache.Cache
+	if useCache && ttl > 0 {
+		results = d.results.Load()
+	}
+	if hit, ok := results.Get(serverName, serverConfig, toolName, args); ok {
+		d.audit.Load().RecordCached(ctx, source, transport, serverName, toolName, args, time.Now(), hit.Result)
+		return hit.Result, nil
+	}
+
+	// Execute tool; cancelling ctx cancels the call on the server too
+	start := time.Now()
+	call := &runningCall{tool: toolName, since: start}
+	d.sessionMutex.Lock()
+	session.running = append(session.running, call)
+	d.sessionMutex.Unlock()
 
 	result, err := session.Client.CallTool(ctx, toolName, args)
+	err = serverConfig.Redactor().Error(err) // Whatever the client, its errors don't give the server's secrets away
+
+	d.sessionMutex.Lock()
+	session.finishCall(call)
+	session.LastUsed = time.Now() // A long call leaves the session idle from when it ended
+	session.SessionMetrics.Record(toolName, time.Since(start), err)
+	d.sessionMutex.Unlock()
+	d.audit.Load().Record(ctx, source, transport, serverName, toolName, args, start, result, err)
+
 	if err != nil {
 		return nil, fmt.Errorf("tool call failed: %w", err)
 	}
+	if err := results.Put(serverName, serverConfig, toolName, args, ttl, result); err != nil {
+		log.Printf("Failed to cache the result of %s/%s: %v", serverName, toolName, err)
+	}
 
 	return result, nil
 }
 
 // ListTools lists tools for a persistent session
 func (d *Daemon) ListTools(serverName string) ([]mcp.Tool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	d.restartOutdatedSession(serverName)
+	d.waitIfStarting(ctx, serverName)
 	session, err := d.GetSession(serverName)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check cache first
-	d.sessionMutex.RLock()
-	if tools, cached := session.ToolCache["list"]; cached {
-		d.sessionMutex.RUnlock()
+	// Check cache first; counting the lookup needs the write lock
+	d.sessionMutex.Lock()
+	if tools, cached := session.ListCache.tools(); cached {
+		d.sessionMutex.Unlock()
 		return tools, nil
 	}
-	d.sessionMutex.RUnlock()
+	d.sessionMutex.Unlock()
 
 	// Fetch tools
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
 	tools, err := session.Client.ListTools(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tools: %w", err)
@@ -308,13 +553,25 @@ func (d *Daemon) ListTools(serverName string) ([]mcp.Tool, error) {
 
 	// Cache the result
 	d.sessionMutex.Lock()
-	session.ToolCache["list"] = tools
+	session.ListCache.put(toolsListKey, tools, time.Now())
 	session.LastUsed = time.Now()
 	d.sessionMutex.Unlock()
 
 	return tools, nil
 }
 
+// Health reports that the daemon is alive. It takes no locks, so probes are
+// answered even while sessions are busy.
+func (d *Daemon) Health() *HealthStatus {
+	return &HealthStatus{
+		OK:      true,
+		Version: version.Version,
+		Name:    d.name,
+		PID:     d.pid,
+		Uptime:  time.Since(d.startTime).Seconds(),
+	}
+}
+
 // GetStatus returns the overall daemon status
 func (d *Daemon) GetStatus() *DaemonStatus {
 	d.sessionMutex.RLock()
@@ -323,14 +580,18 @@ func (d *Daemon) GetStatus() *DaemonStatus {
 	var activeSessions []SessionInfo
 	for _, session := range d.sessions {
 		info := SessionInfo{
-			ServerName: session.ServerName,
-			Status:     session.Status.String(),
-			StartTime:  session.StartTime,
-			LastUsed:   session.LastUsed,
-			Duration:   time.Since(session.StartTime),
-			Error:      session.Error,
-			PID:        session.PID,
+			ServerName:     session.ServerName,
+			Status:         session.Status.String(),
+			StartTime:      session.StartTime,
+			LastUsed:       session.LastUsed,
+			Duration:       time.Since(session.StartTime),
+			Error:          session.Error,
+			PID:            session.PID,
+			ConfigOutdated: session.ConfigOutdated,
+			SessionMetrics: session.SessionMetrics,
+			ListCacheStats: session.ListCache.stats,
 		}
+		session.noteRunning(&info, time.Now())
 		activeSessions = append(activeSessions, info)
 	}
 
@@ -341,23 +602,48 @@ func (d *Daemon) GetStatus() *DaemonStatus {
 		SessionCount:   len(d.sessions),
 		ActiveSessions: activeSessions,
 		PID:            d.pid,
+		Name:           d.name,
 		Endpoint:       d.endpoint,
 		Platform:       d.platform,
+		Schedules:      d.schedules.list(),
 	}
 }
 
 // Helper methods
 
+// Lifecycle hook events, aliased because "session" is a common local name here
+const (
+	hookEventStart = session.HookEventStart
+	hookEventStop  = session.HookEventStop
+	hookEventError = session.HookEventError
+)
+
+// probeServer checks that a client's server answers, aliased like the hook
+// events
+var probeServer = session.Probe
+
+// runHook fires a server's lifecycle hook for a daemon-owned session
+func (d *Daemon) runHook(serverName string, serverConfig config.ServerConfig, event string, eventErr error) {
+	session.RunHook(serverName, serverConfig, event, "", eventErr)
+}
+
 func (d *Daemon) setSessionError(serverName, errorMsg string) {
 	d.sessionMutex.Lock()
 	defer d.sessionMutex.Unlock()
 
 	if session, exists := d.sessions[serverName]; exists {
-		session.Status = SessionStatusError
-		session.Error = errorMsg
+		d.failSession(session, errorMsg)
 	}
 }
 
+// failSession records why a session failed. The caller holds sessionMutex.
+func (d *Daemon) failSession(session *PersistentSession, errorMsg string) {
+	session.Status = SessionStatusError
+	session.Error = session.Config.Redactor().String(errorMsg)
+	session.SessionMetrics.RecordError(session.Error, time.Now())
+	d.runHook(session.ServerName, session.Config, hookEventError, errors.New(session.Error))
+}
+
 func (d *Daemon) cleanupRoutine() {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
@@ -366,32 +652,67 @@ func (d *Daemon) cleanupRoutine() {
 		select {
 		case <-ticker.C:
 			d.cleanupIdleSessions()
+			d.jobs.prune(time.Now())
 		case <-d.shutdownChan:
 			return
 		}
 	}
 }
 
+// maxParallelCloses bounds how many idle sessions cleanup stops at once
+const maxParallelCloses = 4
+
+// cleanupIdleSessions stops sessions idle for longer than their maxIdle; a
+// session with calls in flight, such as a long job, isn't idle. They are
+// removed under the lock and their clients closed after it is released,
+// concurrently, so slow servers don't stall the API.
 func (d *Daemon) cleanupIdleSessions() {
 	d.sessionMutex.Lock()
-	defer d.sessionMutex.Unlock()
-
 	now := time.Now()
-	maxIdle := time.Duration(d.config.MaxIdleTime) * time.Second
-
+	var idle []*PersistentSession
 	for serverName, session := range d.sessions {
-		if session.Status != SessionStatusActive {
+		if session.Status != SessionStatusActive || len(session.running) > 0 {
 			continue
 		}
 
+		maxIdle := d.maxIdleFor(session.Config)
+		if maxIdle <= 0 {
+			continue // Never expires
+		}
+
 		if now.Sub(session.LastUsed) > maxIdle {
 			log.Printf("Cleaning up idle session: %s", serverName)
-			if session.Client != nil {
-				_ = session.Client.Close()
-			}
+			d.runHook(serverName, session.Config, hookEventStop, nil)
 			delete(d.sessions, serverName)
+			idle = append(idle, session)
 		}
 	}
+	d.sessionMutex.Unlock()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxParallelCloses)
+	for _, session := range idle {
+		if session.Client == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(client mcp.MCPClient) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			_ = client.Close()
+		}(session.Client)
+	}
+	wg.Wait()
+}
+
+// maxIdleFor returns how long a session may sit idle before cleanup.
+// A per-server session.maxIdle overrides the daemon-wide maxIdleTime (both in seconds).
+func (d *Daemon) maxIdleFor(serverConfig config.ServerConfig) time.Duration {
+	if serverConfig.Session.MaxIdle != nil {
+		return time.Duration(session.GetSessionMaxIdle(serverConfig)) * time.Second
+	}
+	return time.Duration(d.daemonConfig().MaxIdleTime) * time.Second
 }
 
 func (d *Daemon) tryGetSessionPID(serverConfig config.ServerConfig) int {
@@ -402,6 +723,10 @@ func (d *Daemon) tryGetSessionPID(serverConfig config.ServerConfig) int {
 }
 
 func (d *Daemon) writeJSONResponse(w http.ResponseWriter, data interface{}) {
+	if resp, ok := data.(APIResponse); ok && !resp.Success {
+		data = d.redactResponse(resp)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
@@ -411,6 +736,43 @@ func (d *Daemon) writeJSONResponse(w http.ResponseWriter, data interface{}) {
 	}
 }
 
+// redactResponse scrubs secrets from the error resp reports
+func (d *Daemon) redactResponse(resp APIResponse) APIResponse {
+	resp.Error = d.redactSecrets(resp.Error)
+	if resp.RPCError != nil {
+		rpcErr := *resp.RPCError
+		rpcErr.Message = d.redactSecrets(rpcErr.Message)
+		if rpcErr.Data != nil {
+			if data, err := json.Marshal(rpcErr.Data); err == nil {
+				rpcErr.Data = json.RawMessage(d.redactSecrets(string(data)))
+			}
+		}
+		resp.RPCError = &rpcErr
+	}
+	return resp
+}
+
+// redactSecrets scrubs the secrets of every configured server and session
+// from text bound for an API response
+func (d *Daemon) redactSecrets(text string) string {
+	var configs []config.ServerConfig
+	if cfg := d.mcpConfig.Load(); cfg != nil {
+		for _, serverConfig := range cfg.MCPServers {
+			configs = append(configs, serverConfig)
+		}
+	}
+	d.sessionMutex.RLock()
+	for _, session := range d.sessions {
+		configs = append(configs, session.Config)
+	}
+	d.sessionMutex.RUnlock()
+
+	for _, serverConfig := range configs {
+		text = serverConfig.Redactor().String(text)
+	}
+	return text
+}
+
 // Platform detection helpers
 func detectPlatform() string {
 	if isWSL() {
@@ -0,0 +1,534 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/testharness"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolcache"
+)
+
+func TestCallToolUsesResultCache(t *testing.T) {
+	d, clients := newTestDaemon(t)
+	serverConfig := config.ServerConfig{
+		Command:    "npx",
+		Args:       []string{"-y", "@upstash/context7-mcp"},
+		CacheTools: map[string]config.ToolCacheConfig{"get-library-docs": {TTL: "1h"}},
+	}
+	if err := d.StartSession("docs", serverConfig); err != nil {
+		t.Fatal(err)
+	}
+	waitForActive(t, d, "docs")
+
+	args := map[string]interface{}{"libraryName": "react"}
+	for i := 0; i < 2; i++ {
+		if _, err := d.CallTool("docs", "get-library-docs", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls := clients()[0].callCount(); calls != 1 {
+		t.Errorf("want the second call answered from the cache, got %d server calls", calls)
+	}
+
+	// Uncached tools and calls bypassing the cache reach the server
+	if _, err := d.CallTool("docs", "resolve-library-id", args); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.callTool("docs", "get-library-docs", args, true, false, audit.SourceDaemon); err != nil {
+		t.Fatal(err)
+	}
+	if calls := clients()[0].callCount(); calls != 3 {
+		t.Errorf("want 3 server calls, got %d", calls)
+	}
+}
+
+// notifyingClient is a stub client whose server can send notifications
+type notifyingClient struct {
+	stubClient
+	mu      sync.Mutex
+	handler mcp.NotificationHandler
+}
+
+func (c *notifyingClient) SetNotificationHandler(handler mcp.NotificationHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = handler
+}
+
+func (c *notifyingClient) send(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler(method, nil)
+}
+
+func TestToolsListChangedInvalidatesCachedTools(t *testing.T) {
+	d, _ := newTestDaemon(t)
+	d.toolCache = toolcache.New(t.TempDir(), time.Hour)
+	client := &notifyingClient{}
+	d.clientFactory = func(config.ServerConfig) (mcp.MCPClient, error) { return client, nil }
+
+	serverConfig := config.ServerConfig{Command: "npx", Args: []string{"chrome-devtools-mcp"}}
+	if err := d.toolCache.Put("chrome", serverConfig, []mcp.Tool{{Name: "navigate_page"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.StartSession("chrome", serverConfig); err != nil {
+		t.Fatalf("StartSession failed: %v", err)
+	}
+	waitForActive(t, d, "chrome")
+
+	client.send(mcp.ToolsListChanged)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := d.toolCache.Get("chrome", serverConfig); !ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cached tools were not invalidated")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// slowCloseClient is a stub client whose server takes a while to stop
+type slowCloseClient struct {
+	stubClient
+}
+
+func (c *slowCloseClient) Close() error {
+	time.Sleep(200 * time.Millisecond)
+	return c.stubClient.Close()
+}
+
+func TestCleanupIdleSessionsClosesConcurrently(t *testing.T) {
+	d, _ := newTestDaemon(t)
+	d.clientFactory = func(config.ServerConfig) (mcp.MCPClient, error) { return &slowCloseClient{}, nil }
+
+	const servers = 5
+	for i := 0; i < servers; i++ {
+		name := fmt.Sprintf("server-%d", i)
+		if err := d.StartSession(name, config.ServerConfig{Command: "npx", Args: []string{name}}); err != nil {
+			t.Fatalf("StartSession failed: %v", err)
+		}
+		waitForActive(t, d, name)
+	}
+	d.sessionMutex.Lock()
+	for _, session := range d.sessions {
+		session.LastUsed = time.Now().Add(-1000 * time.Hour)
+	}
+	d.sessionMutex.Unlock()
+
+	done := make(chan time.Duration, 1)
+	go func() {
+		start := time.Now()
+		d.cleanupIdleSessions()
+		done <- time.Since(start)
+	}()
+
+	// The API stays responsive while the servers stop
+	time.Sleep(50 * time.Millisecond)
+	listed := make(chan []SessionInfo, 1)
+	go func() { listed <- d.ListSessions() }()
+	select {
+	case sessions := <-listed:
+		if len(sessions) != 0 {
+			t.Errorf("idle sessions should be gone before their clients close, got %d", len(sessions))
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("listing sessions waited for the clients to close")
+	}
+
+	// Five 200ms closes, four at a time, take two rounds rather than five
+	select {
+	case elapsed := <-done:
+		if elapsed > 600*time.Millisecond {
+			t.Errorf("cleanup took %v; the clients were not closed concurrently", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cleanup did not finish")
+	}
+}
+
+func TestUnknownNamesSuggestCloseOnes(t *testing.T) {
+	d, clients := newTestDaemon(t)
+	if err := d.StartSession("context7", config.ServerConfig{Command: "npx", Args: []string{"-y", "@upstash/context7-mcp"}}); err != nil {
+		t.Fatal(err)
+	}
+	waitForActive(t, d, "context7")
+	stub := clients()[0]
+	stub.mu.Lock()
+	stub.tools = []mcp.Tool{{Name: "resolve-library-id"}, {Name: "get-library-docs"}}
+	stub.mu.Unlock()
+
+	if _, err := d.CallTool("contex7", "get-library-docs", nil); err == nil || !strings.HasSuffix(err.Error(), "did you mean 'context7'?") {
+		t.Errorf("want a suggested session, got %v", err)
+	}
+
+	callTool := func(toolName string) APIResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/sessions/context7/call-tool/"+toolName, strings.NewReader(`{"args":{}}`))
+		rec := httptest.NewRecorder()
+		d.handleSessionAndToolActions(rec, req)
+		var resp APIResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+		}
+		return resp
+	}
+	if resp := callTool("get-library-doc"); resp.Success || !strings.HasSuffix(resp.Error, "did you mean 'get-library-docs'?") {
+		t.Errorf("want a suggested tool, got %+v", resp)
+	}
+	if resp := callTool("weather"); resp.Success || strings.Contains(resp.Error, "did you mean") {
+		t.Errorf("want no suggestion for a name nothing is close to, got %+v", resp)
+	}
+
+	// Tools the export policy hides are not suggested
+	d.applyConfigChange(config.ConfigChange{
+		Config: &config.Configuration{
+			MCPServers: map[string]config.ServerConfig{},
+			Exports:    &config.ExportsConfig{Tools: []string{"context7/get-library-doc", "context7/resolve-*"}, Daemon: true},
+		},
+		ExportsChanged: true,
+	})
+	if resp := callTool("get-library-doc"); resp.Success || strings.Contains(resp.Error, "did you mean") {
+		t.Errorf("want no suggestion of an unexported tool, got %+v", resp)
+	}
+}
+
+func TestSessionStartKeepsInitializeOptions(t *testing.T) {
+	d, _ := newTestDaemon(t)
+	received := make(chan config.ServerConfig, 1)
+	d.clientFactory = func(serverConfig config.ServerConfig) (mcp.MCPClient, error) {
+		received <- serverConfig
+		return &stubClient{}, nil
+	}
+	mux := http.NewServeMux()
+	d.setupRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sent := config.ServerConfig{
+		Command: "lsp-bridge",
+		InitializeOptions: map[string]interface{}{
+			"workspace": map[string]interface{}{"roots": []interface{}{"/src/a", "/src/b"}, "depth": 2.0},
+			"features":  []interface{}{map[string]interface{}{"name": "hover", "enabled": true}},
+		},
+		InitializeOptionsField: "initializationOptions",
+	}
+	body, err := json.Marshal(struct {
+		Config config.ServerConfig `json:"config"`
+	}{sent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := &DaemonClient{manager: &DaemonManager{endpoint: strings.TrimPrefix(server.URL, "http://")}, httpClient: server.Client()}
+	resp, err := http.Post(dc.getSessionURL("lsp", "start")+"?waitForActive=5s", "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	select {
+	case got := <-received:
+		if !reflect.DeepEqual(got.InitializeOptions, sent.InitializeOptions) || got.InitializeOptionsField != sent.InitializeOptionsField {
+			t.Errorf("the daemon's session got initialize options %#v in %q, want %#v in %q",
+				got.InitializeOptions, got.InitializeOptionsField, sent.InitializeOptions, sent.InitializeOptionsField)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the daemon never created the session's client")
+	}
+}
+
+func TestHealthzDoesNotWaitForSessions(t *testing.T) {
+	d, _ := newTestDaemon(t)
+	mux := http.NewServeMux()
+	d.setupRoutes(mux)
+	serve := func(path string) <-chan *httptest.ResponseRecorder {
+		done := make(chan *httptest.ResponseRecorder, 1)
+		go func() {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			done <- rec
+		}()
+		return done
+	}
+
+	// A session operation holds the mutex
+	d.sessionMutex.Lock()
+	status := serve("/status")
+	select {
+	case rec := <-serve("/healthz"):
+		var health HealthStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil || !health.OK || health.PID != d.pid || health.Version == "" {
+			t.Errorf("unexpected health %s (%v)", rec.Body, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("/healthz waited for the session mutex")
+	}
+	select {
+	case <-status:
+		t.Fatal("/status answered without the session mutex")
+	case <-time.After(50 * time.Millisecond):
+	}
+	d.sessionMutex.Unlock()
+
+	// The root path is still the detailed status
+	for _, done := range []<-chan *httptest.ResponseRecorder{status, serve("/")} {
+		var resp struct {
+			Success bool         `json:"success"`
+			Data    DaemonStatus `json:"data"`
+		}
+		rec := <-done
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !resp.Success || !resp.Data.Running || resp.Data.PID != d.pid {
+			t.Errorf("unexpected status %s (%v)", rec.Body, err)
+		}
+	}
+}
+
+func TestSessionRestartsAndErrorsAreCounted(t *testing.T) {
+	d, _ := newTestDaemon(t)
+	factory := d.clientFactory
+	var broken atomic.Bool
+	broken.Store(true)
+	d.clientFactory = func(serverConfig config.ServerConfig) (mcp.MCPClient, error) {
+		if broken.Load() {
+			return nil, fmt.Errorf("browser crashed")
+		}
+		return factory(serverConfig)
+	}
+
+	serverConfig := config.ServerConfig{Command: "browser"}
+	if err := d.StartSession("browser", serverConfig); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.awaitSession(ctx, "browser"); err == nil {
+		t.Fatal("the first start should fail")
+	}
+
+	// Starting the failed session again counts as a restart and keeps its error
+	broken.Store(false)
+	if err := d.StartSession("browser", serverConfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.awaitSession(ctx, "browser"); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	d.setupRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	dc := &DaemonClient{manager: &DaemonManager{endpoint: strings.TrimPrefix(server.URL, "http://")}, httpClient: server.Client()}
+
+	info, err := dc.getSession(ctx, "browser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Status != "active" || info.RestartCount != 1 || info.LastError != "failed to create client: browser crashed" || info.LastErrorTime == nil {
+		t.Errorf("session = %+v", info)
+	}
+
+	resp, err := http.Post(dc.getSessionURL("browser", "reset-stats"), "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reset := &SessionInfo{}
+	if err := decodeData(resp, "session", reset); err != nil {
+		t.Fatal(err)
+	}
+	if reset.RestartCount != 0 || reset.LastError != "" || reset.LastErrorTime != nil {
+		t.Errorf("session after reset-stats = %+v", reset)
+	}
+}
+
+// slowToolClient is a stub client whose tool runs until its context ends,
+// reporting the context's deadline and why it ended
+type slowToolClient struct {
+	stubClient
+	deadlines chan time.Time
+	ended     chan error
+}
+
+func newSlowToolClient() *slowToolClient {
+	return &slowToolClient{deadlines: make(chan time.Time, 1), ended: make(chan error, 1)}
+}
+
+func (c *slowToolClient) CallTool(ctx context.Context, _ string, _ map[string]interface{}) (*mcp.ToolResult, error) {
+	deadline, _ := ctx.Deadline()
+	c.deadlines <- deadline
+	<-ctx.Done()
+	c.ended <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+// newSlowToolDaemon serves a daemon whose "slow" session runs backend, with a
+// client for it
+func newSlowToolDaemon(t *testing.T, backend *slowToolClient) (*Daemon, *DaemonClient) {
+	t.Helper()
+	d, _ := newTestDaemon(t)
+	d.clientFactory = func(config.ServerConfig) (mcp.MCPClient, error) { return backend, nil }
+	if err := d.StartSession("slow", config.ServerConfig{Command: "slow"}); err != nil {
+		t.Fatal(err)
+	}
+	waitForActive(t, d, "slow")
+
+	mux := http.NewServeMux()
+	d.setupRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return d, newTestDaemonClient(t, server)
+}
+
+func TestCallToolDisconnectCancelsBackendCall(t *testing.T) {
+	backend := newSlowToolClient()
+	d, dc := newSlowToolDaemon(t, backend)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	called := make(chan error, 1)
+	go func() {
+		_, err := dc.CallTool(ctx, "slow", "crawl", nil, false)
+		called <- err
+	}()
+
+	select {
+	case deadline := <-backend.deadlines:
+		if until := time.Until(deadline); until <= 50*time.Second || until > defaultToolTimeout {
+			t.Errorf("a call without a timeout has %s left, want the default %s", until, defaultToolTimeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the tool was never called")
+	}
+
+	// While the call runs, the session says so
+	time.Sleep(20 * time.Millisecond)
+	info, err := d.sessionInfo("slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.RunningTool != "crawl" || info.RunningCalls != 1 || info.RunningFor < 20*time.Millisecond {
+		t.Errorf("session info while the call runs = %+v", info)
+	}
+	if listed := d.ListSessions(); len(listed) != 1 || listed[0].RunningTool != "crawl" {
+		t.Errorf("listed sessions = %+v", listed)
+	}
+
+	cancel()
+	select {
+	case err := <-backend.ended:
+		if err != context.Canceled {
+			t.Errorf("the backend call ended with %v, want it cancelled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the backend call was not cancelled after the client went away")
+	}
+	if err := <-called; err == nil {
+		t.Error("the cancelled call should fail")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		info, _ := d.sessionInfo("slow")
+		if info.RunningCalls == 0 && info.RunningTool == "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the finished call is still reported running: %+v", info)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCallToolTimeoutIsBoundedByMaxToolTimeout(t *testing.T) {
+	backend := newSlowToolClient()
+	d, dc := newSlowToolDaemon(t, backend)
+
+	// The client's deadline becomes the daemon's
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+	go func() { _, _ = dc.CallTool(ctx, "slow", "crawl", nil, false) }()
+	if until := time.Until(<-backend.deadlines); until <= 80*time.Second || until > 90*time.Second {
+		t.Errorf("a call given 90s has %s left", until)
+	}
+	cancel()
+	<-backend.ended
+
+	// Asking for more than maxToolTimeout gets maxToolTimeout
+	limited := DefaultDaemonConfig()
+	limited.MaxToolTimeout = 1
+	d.config.Store(&LoadedConfig{Config: limited})
+	resp, err := http.Post(dc.getToolURL("slow", "crawl"), "application/json", strings.NewReader(`{"args": {}, "timeoutSeconds": 3600}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if until := time.Until(<-backend.deadlines); until > time.Second {
+		t.Errorf("a call asking for an hour has %s left, want at most maxToolTimeout", until)
+	}
+	if err := <-backend.ended; err != context.DeadlineExceeded {
+		t.Errorf("the capped call ended with %v, want its deadline exceeded", err)
+	}
+
+	for _, tt := range []struct {
+		max, seconds int
+		want         time.Duration
+	}{
+		{600, 0, defaultToolTimeout},
+		{600, 120, 2 * time.Minute},
+		{600, 3600, 10 * time.Minute},
+		{30, 0, 30 * time.Second},
+		{0, 3600, time.Hour},
+	} {
+		cfg := DefaultDaemonConfig()
+		cfg.MaxToolTimeout = tt.max
+		d.config.Store(&LoadedConfig{Config: cfg})
+		if got := d.toolTimeout(tt.seconds); got != tt.want {
+			t.Errorf("toolTimeout(%d) with maxToolTimeout %d = %s, want %s", tt.seconds, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestCallToolTimeoutOutlastsServerTimeout(t *testing.T) {
+	d, _ := newTestDaemon(t)
+	d.clientFactory = client.NewMCPClient
+	serverConfig := testharness.StdioConfig(testharness.Script{
+		Tools: []testharness.Tool{{Name: "crawl", Delay: 1500 * time.Millisecond}},
+	})
+	serverConfig.Timeout = 1
+	if err := d.StartSession("crawler", serverConfig); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = d.StopSession("crawler") })
+	waitForActive(t, d, "crawler")
+
+	mux := http.NewServeMux()
+	d.setupRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	dc := newTestDaemonClient(t, server)
+
+	// The server's stdio client gives requests 1s, but the call asks for 10s
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := dc.CallTool(ctx, "crawler", "crawl", map[string]interface{}{"message": "crawled"}, false)
+	if err != nil {
+		t.Fatalf("a call given 10s failed at the server's timeout: %v", err)
+	}
+	if !strings.Contains(string(result.Raw), "Echo: crawled") {
+		t.Errorf("result = %s", result.Raw)
+	}
+}
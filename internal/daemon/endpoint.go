@@ -1,39 +1,69 @@
 package daemon
 
 import (
+	"hash/fnv"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// Ports the daemon listens on: the shared instance's, and the range named
+// instances' ports are derived into
+const (
+	defaultDaemonPort = 8080
+	namedPortBase     = 18080
+	namedPortRange    = 1000
 )
 
-// getDaemonEndpoint returns the appropriate daemon endpoint for the platform
-func getDaemonEndpoint(platform string) string {
+// getDaemonEndpoint returns the endpoint of the named daemon instance for the
+// platform; the shared instance's name is empty
+func getDaemonEndpoint(platform, name string) string {
 	switch platform {
 	case "windows":
-		return `\\.\pipe\mcp-cli-ent-daemon`
+		return `\\.\pipe\` + config.InstanceFile("mcp-cli-ent-daemon", name)
 	case "wsl":
 		// WSL uses Unix socket approach but with Windows path awareness
-		return getWSLEndpoint()
+		return getWSLEndpoint(name)
 	default: // linux, darwin
-		return getUnixSocketEndpoint()
+		return getUnixSocketEndpoint(name)
+	}
+}
+
+// daemonPort returns the port a daemon instance listens on. Named instances
+// get a port derived from their name, so each project's daemon is found
+// without recording where it listens.
+func daemonPort(name string) int {
+	if name == "" {
+		return defaultDaemonPort
 	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return namedPortBase + int(h.Sum32()%namedPortRange)
+}
+
+// httpAddress returns the loopback address a daemon instance listens on
+func httpAddress(name string) string {
+	return "127.0.0.1:" + strconv.Itoa(daemonPort(name))
 }
 
 // getUnixSocketEndpoint returns the Unix domain socket path
-func getUnixSocketEndpoint() string {
+func getUnixSocketEndpoint(name string) string {
 	// For testing, use HTTP instead of Unix socket to avoid socket issues
-	return "127.0.0.1:8080"
+	return httpAddress(name)
 
 	// Original Unix socket logic (commented out for testing)
 	/*
-		configDir, err := os.UserConfigDir()
+		daemonDir, err := config.GetDataDir()
 		if err != nil {
 			// Fallback to temp directory
 			return "/tmp/mcp-cli-ent.sock"
 		}
 
-		daemonDir := filepath.Join(configDir, "mcp-cli-ent")
 		if err := os.MkdirAll(daemonDir, 0755); err != nil {
 			// Fallback to temp directory
 			return "/tmp/mcp-cli-ent.sock"
@@ -44,19 +74,18 @@ func getUnixSocketEndpoint() string {
 }
 
 // getWSLEndpoint returns the endpoint for WSL
-func getWSLEndpoint() string {
+func getWSLEndpoint(name string) string {
 	// WSL can use Unix sockets, but we need to be careful about path handling
-	configDir, err := os.UserConfigDir()
+	daemonDir, err := config.GetDataDir()
 	if err != nil {
-		return "/tmp/mcp-cli-ent-wsl.sock"
+		return "/tmp/" + config.InstanceFile("mcp-cli-ent-wsl.sock", name)
 	}
 
-	daemonDir := filepath.Join(configDir, "mcp-cli-ent")
 	if err := os.MkdirAll(daemonDir, 0755); err != nil {
-		return "/tmp/mcp-cli-ent-wsl.sock"
+		return "/tmp/" + config.InstanceFile("mcp-cli-ent-wsl.sock", name)
 	}
 
-	return filepath.Join(daemonDir, "daemon-wsl.sock")
+	return filepath.Join(daemonDir, config.InstanceFile("daemon-wsl.sock", name))
 }
 
 // isUnixSocket checks if the endpoint is a Unix domain socket
@@ -70,48 +99,105 @@ func isNamedPipe(endpoint string) bool {
 	return len(endpoint) >= 9 && endpoint[:9] == `\\.\pipe\`
 }
 
-// getPIDFilePath returns the path to the daemon PID file
-func getPIDFilePath() string {
-	configDir, err := os.UserConfigDir()
+// getPIDFilePath returns the path to the PID file of the named daemon instance
+func getPIDFilePath(name string) string {
+	return instanceFilePath(config.InstanceFile("daemon.pid", name), config.InstanceFile("mcp-cli-ent-daemon.pid", name))
+}
+
+// getEndpointFilePath returns the path to the file in which the named daemon
+// instance records the endpoint it listens on
+func getEndpointFilePath(name string) string {
+	return instanceFilePath(config.InstanceFile("daemon.endpoint", name), config.InstanceFile("mcp-cli-ent-daemon.endpoint", name))
+}
+
+// writeEndpointFile records the endpoint the named daemon instance listens
+// on, which differs from the derived one after a port conflict
+func writeEndpointFile(name, endpoint string) error {
+	return os.WriteFile(getEndpointFilePath(name), []byte(endpoint+"\n"), 0644)
+}
+
+// removeEndpointFile removes the named instance's endpoint file
+func removeEndpointFile(name string) error {
+	return os.Remove(getEndpointFilePath(name))
+}
+
+// readEndpointFile returns the endpoint recorded in path, or "" if there is
+// none
+func readEndpointFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// instanceName returns this process's daemon instance
+func instanceName() string {
+	return config.DaemonName()
+}
+
+// GetLogFilePath returns the path to the log file of this process's daemon
+// instance
+func GetLogFilePath() string {
+	return logFilePath(instanceName())
+}
+
+// logFilePath returns the path to the log file of the named daemon instance
+func logFilePath(name string) string {
+	return instanceFilePath(config.InstanceFile("daemon.log", name), config.InstanceFile("mcp-cli-ent-daemon.log", name))
+}
+
+// instanceFilePath returns the path to fileName in the data directory, or
+// to tempName in the temp directory when the data directory is unusable
+func instanceFilePath(fileName, tempName string) string {
+	daemonDir, err := config.GetDataDir()
 	if err != nil {
 		// Fallback to temp directory
-		if runtime.GOOS == "windows" {
-			return filepath.Join(os.TempDir(), "mcp-cli-ent-daemon.pid")
-		}
-		return "/tmp/mcp-cli-ent-daemon.pid"
+		return tempFilePath(tempName)
 	}
 
-	daemonDir := filepath.Join(configDir, "mcp-cli-ent")
 	if err := os.MkdirAll(daemonDir, 0755); err != nil {
 		// Fallback to temp directory
-		if runtime.GOOS == "windows" {
-			return filepath.Join(os.TempDir(), "mcp-cli-ent-daemon.pid")
-		}
-		return "/tmp/mcp-cli-ent-daemon.pid"
+		return tempFilePath(tempName)
 	}
 
-	return filepath.Join(daemonDir, "daemon.pid")
+	return filepath.Join(daemonDir, fileName)
 }
 
-// GetLogFilePath returns the path to the daemon log file
-func GetLogFilePath() string {
-	configDir, err := os.UserConfigDir()
+// tempFilePath returns the path to fileName in the temp directory
+func tempFilePath(fileName string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.TempDir(), fileName)
+	}
+	return "/tmp/" + fileName
+}
+
+// Instances returns the names of the daemon instances that have a PID or log
+// file in the data directory, sorted, the shared instance's empty name
+// first
+func Instances() ([]string, error) {
+	daemonDir, err := config.GetDataDir()
 	if err != nil {
-		// Fallback to temp directory
-		if runtime.GOOS == "windows" {
-			return filepath.Join(os.TempDir(), "mcp-cli-ent-daemon.log")
-		}
-		return "/tmp/mcp-cli-ent-daemon.log"
+		return nil, err
+	}
+	entries, err := os.ReadDir(daemonDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
 	}
 
-	daemonDir := filepath.Join(configDir, "mcp-cli-ent")
-	if err := os.MkdirAll(daemonDir, 0755); err != nil {
-		// Fallback to temp directory
-		if runtime.GOOS == "windows" {
-			return filepath.Join(os.TempDir(), "mcp-cli-ent-daemon.log")
+	seen := make(map[string]bool)
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		for _, base := range []string{"daemon.pid", "daemon.log"} {
+			if name, ok := config.InstanceName(base, entry.Name()); ok && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
 		}
-		return "/tmp/mcp-cli-ent-daemon.log"
 	}
-
-	return filepath.Join(daemonDir, "daemon.log")
+	sort.Strings(names)
+	return names, nil
 }
@@ -0,0 +1,211 @@
+package daemon
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestDaemonPathsUseConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, dir)
+
+	paths := map[string]string{
+		"pid file":      getPIDFilePath(""),
+		"log file":      GetLogFilePath(),
+		"daemon config": GetDaemonConfigPath(),
+		"manager":       NewDaemonManager().getDaemonConfigPath(),
+	}
+	want := map[string]string{
+		"pid file":      filepath.Join(dir, "daemon.pid"),
+		"log file":      filepath.Join(dir, "daemon.log"),
+		"daemon config": filepath.Join(dir, "daemon.json"),
+		"manager":       filepath.Join(dir, "daemon.json"),
+	}
+
+	for name, path := range paths {
+		if path != want[name] {
+			t.Errorf("expected %s at %s, got %s", name, want[name], path)
+		}
+	}
+}
+
+func TestNamedInstancesHaveTheirOwnFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, dir)
+	t.Setenv(config.DaemonNameEnv, "work")
+
+	if got, want := getPIDFilePath("work"), filepath.Join(dir, "daemon-work.pid"); got != want {
+		t.Errorf("expected pid file at %s, got %s", want, got)
+	}
+	if got, want := GetLogFilePath(), filepath.Join(dir, "daemon-work.log"); got != want {
+		t.Errorf("expected log file at %s, got %s", want, got)
+	}
+	if got := NewDaemonManager().Name(); got != "work" {
+		t.Errorf("expected the manager to follow %s, got %q", config.DaemonNameEnv, got)
+	}
+
+	if got := getDaemonEndpoint("windows", "work"); got != `\\.\pipe\mcp-cli-ent-daemon-work` {
+		t.Errorf("unexpected pipe %s", got)
+	}
+	if got, want := getDaemonEndpoint("wsl", "work"), filepath.Join(dir, "daemon-wsl-work.sock"); got != want {
+		t.Errorf("expected socket %s, got %s", want, got)
+	}
+	if got := getDaemonEndpoint("linux", ""); got != "127.0.0.1:8080" {
+		t.Errorf("the default instance moved to %s", got)
+	}
+	work := getDaemonEndpoint("linux", "work")
+	if work == getDaemonEndpoint("linux", "") || work != getDaemonEndpoint("linux", "work") || work == getDaemonEndpoint("linux", "client-a") {
+		t.Errorf("named instances should get stable ports of their own, got %s", work)
+	}
+	if port := daemonPort("work"); port < namedPortBase || port >= namedPortBase+namedPortRange {
+		t.Errorf("port %d outside the named range", port)
+	}
+}
+
+func TestInstances(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, dir)
+	for _, name := range []string{"daemon.log", "daemon-work.pid", "daemon-work.log", "daemon-client-a.log", "daemon.json", "daemon-wsl.sock"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sessions-work"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := Instances()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"", "client-a", "work"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got instances %q, want %q", names, want)
+	}
+}
+
+// occupyPort listens on a free loopback port with something other than a
+// daemon, returning its address
+func occupyPort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.NotFoundHandler()}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+	return listener.Addr().String()
+}
+
+// startTestDaemon starts a daemon from daemonConfig, with endpoint replacing
+// the derived one when set
+func startTestDaemon(t *testing.T, daemonConfig *DaemonConfig, endpoint string) (*Daemon, error) {
+	t.Helper()
+	d, err := NewDaemon(daemonConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint != "" {
+		d.endpoint = endpoint
+	}
+	t.Cleanup(func() { _ = d.Stop() })
+	return d, d.Start()
+}
+
+func TestDerivedEndpointTakenFallsBackToFreePort(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	taken := occupyPort(t)
+
+	d, err := startTestDaemon(t, DefaultDaemonConfig(), taken)
+	if err != nil {
+		t.Fatalf("expected the daemon to move to a free port, got %v", err)
+	}
+	if d.endpoint == taken {
+		t.Fatalf("daemon still claims %s", taken)
+	}
+	if health, err := probeDaemon(d.endpoint); err != nil || health.PID != os.Getpid() {
+		t.Errorf("daemon not answering on %s: %v", d.endpoint, err)
+	}
+}
+
+func TestConfiguredAddressTakenIsAnError(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	taken := occupyPort(t)
+
+	daemonConfig := DefaultDaemonConfig()
+	daemonConfig.Address = taken
+	_, err := startTestDaemon(t, daemonConfig, "")
+	var running *RunningDaemonError
+	if err == nil || errors.As(err, &running) || !strings.Contains(err.Error(), "taken by another program") {
+		t.Errorf("expected the address to be reported taken by another program, got %v", err)
+	}
+}
+
+func TestRunningDaemonIsAdopted(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	first, err := startTestDaemon(t, DefaultDaemonConfig(), "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	daemonConfig := DefaultDaemonConfig()
+	daemonConfig.Address = first.endpoint
+	_, err = startTestDaemon(t, daemonConfig, "")
+	var running *RunningDaemonError
+	if !errors.As(err, &running) {
+		t.Fatalf("expected another daemon to be reported running, got %v", err)
+	}
+	if running.Health.PID != os.Getpid() || running.Endpoint != first.endpoint || !strings.Contains(err.Error(), "another mcp-cli-ent daemon") {
+		t.Errorf("unexpected report: %v", err)
+	}
+
+	manager := NewNamedDaemonManager("")
+	if err := manager.adopt(running); err != nil {
+		t.Fatal(err)
+	}
+	if alive, pid, _ := isDaemonRunning(""); !alive || pid != os.Getpid() {
+		t.Errorf("adopted daemon not recorded as running (pid %d)", pid)
+	}
+	if got := manager.currentEndpoint(); got != first.endpoint {
+		t.Errorf("clients would connect to %s, want %s", got, first.endpoint)
+	}
+}
+
+func TestOtherInstanceIsNotAdopted(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	t.Setenv(config.DaemonNameEnv, "work")
+	work, err := startTestDaemon(t, DefaultDaemonConfig(), "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The shared daemon's derived endpoint taken by "work" moves to a free port
+	t.Setenv(config.DaemonNameEnv, "")
+	shared, err := startTestDaemon(t, DefaultDaemonConfig(), work.endpoint)
+	if err != nil {
+		t.Fatalf("expected the daemon to move to a free port, got %v", err)
+	}
+	if shared.endpoint == work.endpoint {
+		t.Fatalf("daemon still claims %s", work.endpoint)
+	}
+	if health, err := probeDaemon(shared.endpoint); err != nil || health.Name != "" {
+		t.Errorf("shared daemon not answering on %s: %+v, %v", shared.endpoint, health, err)
+	}
+
+	// An address set in daemon.json that "work" holds is an error
+	daemonConfig := DefaultDaemonConfig()
+	daemonConfig.Address = work.endpoint
+	_, err = startTestDaemon(t, daemonConfig, "")
+	var running *RunningDaemonError
+	if err == nil || errors.As(err, &running) || !strings.Contains(err.Error(), `instance "work"`) {
+		t.Errorf("expected the address to be reported taken by the work instance, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// errDaemonNotRunning is returned for calls made when the daemon's process
+// is gone
+var errDaemonNotRunning = mcp.Mark(errors.New("daemon is not running"), mcp.ErrDaemonUnavailable)
+
+// daemonFailed is set once a daemon call has failed because the daemon did,
+// so the rest of the process connects directly instead
+var daemonFailed atomic.Bool
+
+// isDaemonFailure reports whether err shows the daemon itself failing: its
+// process gone, or its connection refused or dropped. Tool and session errors
+// come back in the daemon's answers, and timeouts may only mean a slow tool,
+// so neither counts.
+func isDaemonFailure(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, mcp.ErrDaemonUnavailable):
+		return true
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return !netErr.Timeout()
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// neverSent reports whether err, from a daemon call, shows the call never
+// reached the daemon: its process gone, or its connection refused or not
+// made
+func neverSent(err error) bool {
+	if errors.Is(err, errDaemonNotRunning) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// classifyDaemonError marks err, from a daemon call that could not fall back,
+// with the mcp sentinel for the daemon failing or the call timing out
+func classifyDaemonError(err error) error {
+	var netErr net.Error
+	switch {
+	case isDaemonFailure(err):
+		return mcp.Mark(err, mcp.ErrDaemonUnavailable)
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return mcp.Mark(err, mcp.ErrTimeout)
+	}
+	return err
+}
+
+// fallBack switches dm to a direct client after err, a daemon failure, and
+// returns that client. The daemon is not used again by this process.
+func (dm *DaemonMCPClient) fallBack(err error) (mcp.MCPClient, error) {
+	daemonFailed.Store(true)
+	logging.Warn("daemon failed, connecting directly", "server", dm.serverName, "error", err)
+
+	direct, directErr := dm.connectDirectly()
+	if directErr != nil {
+		return nil, fmt.Errorf("daemon failed (%v) and connecting directly failed: %w", err, directErr)
+	}
+
+	dm.mutex.Lock()
+	dm.direct = direct
+	dm.mutex.Unlock()
+	return direct, nil
+}
+
+// canFallBack reports whether err, from a daemon call, should be retried
+// over a direct client
+func (dm *DaemonMCPClient) canFallBack(err error) bool {
+	return dm.connectDirectly != nil && isDaemonFailure(err)
+}
+
+// isIdempotent reports whether the server annotates toolName as idempotent
+// or read-only, by the tools last listed through the daemon or else cached
+func (dm *DaemonMCPClient) isIdempotent(toolName string) bool {
+	dm.mutex.Lock()
+	tools := dm.tools
+	dm.mutex.Unlock()
+	if tools == nil && dm.knownTools != nil {
+		tools = dm.knownTools()
+	}
+
+	for i := range tools {
+		if tools[i].Name == toolName {
+			return tools[i].IsIdempotent()
+		}
+	}
+	return false
+}
+
+// directClient returns the client calls go to since a fallback, or nil
+func (dm *DaemonMCPClient) directClient() mcp.MCPClient {
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+	return dm.direct
+}
+
+// ViaDaemon reports whether calls still go through the daemon, which then
+// caches and audits them itself; after a fallback they no longer do
+func (dm *DaemonMCPClient) ViaDaemon() bool {
+	return dm.directClient() == nil
+}
@@ -0,0 +1,265 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// directServer stands in for a server connected to directly
+type directServer struct {
+	mcp.MCPClient // Unused methods panic
+
+	calls  atomic.Int32
+	closed atomic.Bool
+}
+
+func (d *directServer) CallTool(context.Context, string, map[string]interface{}) (*mcp.ToolResult, error) {
+	d.calls.Add(1)
+	return &mcp.ToolResult{Content: []interface{}{map[string]interface{}{"type": "text", "text": "direct"}}}, nil
+}
+
+func (d *directServer) ListTools(context.Context) ([]mcp.Tool, error) {
+	d.calls.Add(1)
+	return []mcp.Tool{{Name: "direct"}}, nil
+}
+
+func (d *directServer) Close() error {
+	d.closed.Store(true)
+	return nil
+}
+
+// dyingDaemon answers its first request with answer, then dies
+func dyingDaemon(t *testing.T, answer APIResponse) (*DaemonClient, *httptest.Server) {
+	t.Helper()
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	daemonFailed.Store(false)
+	t.Cleanup(func() { daemonFailed.Store(false) })
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(answer)
+		go server.Close() // Dies once this answer is out
+	}))
+	t.Cleanup(server.Close)
+	return newTestDaemonClient(t, server), server
+}
+
+// fallbackClient returns a SmartClient's client for the dying daemon, with
+// direct standing in for the direct connection
+func fallbackClient(t *testing.T, dc *DaemonClient, direct *directServer) *DaemonMCPClient {
+	t.Helper()
+	sc := &SmartClient{
+		daemonClient: dc,
+		directClient: func(config.ServerConfig) (mcp.MCPClient, error) { return direct, nil },
+		routing:      config.DaemonRoutingAuto,
+	}
+	mcpClient, err := sc.CreateClient("chrome", config.ServerConfig{Command: "npx", Persistent: true})
+	if err != nil {
+		t.Fatalf("CreateClient failed: %v", err)
+	}
+	return mcpClient.(*DaemonMCPClient)
+}
+
+// awaitClosed waits until the server stops accepting connections
+func awaitClosed(t *testing.T, server *httptest.Server) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			return
+		}
+		_ = resp.Body.Close()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("stub daemon did not die")
+}
+
+func TestDaemonClientFallsBackWhenDaemonDies(t *testing.T) {
+	dc, server := dyingDaemon(t, APIResponse{Success: true, Data: mcp.ToolResult{Content: []interface{}{map[string]interface{}{"type": "text", "text": "daemon"}}}})
+	direct := &directServer{}
+	dm := fallbackClient(t, dc, direct)
+
+	result, err := dm.CallTool(context.Background(), "navigate", nil)
+	if err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	if text := result.Content[0].(map[string]interface{})["text"]; text != "daemon" || !dm.ViaDaemon() {
+		t.Fatalf("expected the first call to go through the daemon, got %v", text)
+	}
+
+	awaitClosed(t, server)
+	result, err = dm.CallTool(context.Background(), "navigate", nil)
+	if err != nil {
+		t.Fatalf("expected the call to fall back, got %v", err)
+	}
+	if text := result.Content[0].(map[string]interface{})["text"]; text != "direct" || dm.ViaDaemon() {
+		t.Errorf("expected the direct client's answer, got %v", text)
+	}
+
+	// Later calls, and later clients, skip the daemon
+	if _, err := dm.ListTools(context.Background()); err != nil || direct.calls.Load() != 2 {
+		t.Errorf("expected later calls to go direct, got %d direct calls, %v", direct.calls.Load(), err)
+	}
+	sc := &SmartClient{daemonClient: dc, routing: config.DaemonRoutingAlways}
+	if sc.ShouldUseDaemon("chrome", config.ServerConfig{Persistent: true}) {
+		t.Error("expected the failed daemon to be skipped for the rest of the process")
+	}
+
+	_ = dm.Close()
+	if !direct.closed.Load() {
+		t.Error("expected Close to close the fallback client")
+	}
+}
+
+func TestDaemonClientKeepsToolErrors(t *testing.T) {
+	for _, message := range []string{"tool failed: no such page", "session not found: chrome"} {
+		dc, _ := dyingDaemon(t, APIResponse{Success: false, Error: message})
+		direct := &directServer{}
+		dm := fallbackClient(t, dc, direct)
+
+		_, err := dm.CallTool(context.Background(), "navigate", nil)
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("expected the daemon's error %q, got %v", message, err)
+		}
+		if direct.calls.Load() != 0 || !dm.ViaDaemon() || daemonFailed.Load() {
+			t.Errorf("%q must not fall back to a direct client", message)
+		}
+	}
+}
+
+// droppingDaemon lists tools, then drops the connection of every tool call
+// after forwarding it; calls counts the forwarded calls
+func droppingDaemon(t *testing.T, tools []mcp.Tool) (dc *DaemonClient, calls *atomic.Int32) {
+	t.Helper()
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	daemonFailed.Store(false)
+	t.Cleanup(func() { daemonFailed.Store(false) })
+
+	calls = &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/tools") {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(APIResponse{Success: true, Data: tools})
+			return
+		}
+		calls.Add(1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = conn.Close()
+	}))
+	t.Cleanup(server.Close)
+	return newTestDaemonClient(t, server), calls
+}
+
+func TestDaemonClientDoesNotRepeatForwardedCalls(t *testing.T) {
+	yes := true
+	tools := []mcp.Tool{{Name: "click"}, {Name: "get_page", Annotations: &mcp.ToolAnnotations{ReadOnlyHint: &yes}}}
+
+	t.Run("side effects", func(t *testing.T) {
+		dc, calls := droppingDaemon(t, tools)
+		direct := &directServer{}
+		dm := fallbackClient(t, dc, direct)
+
+		_, err := dm.CallTool(context.Background(), "click", nil)
+		if !errors.Is(err, mcp.ErrDaemonUnavailable) {
+			t.Errorf("want the daemon reported unavailable, got %v", err)
+		}
+		if calls.Load() != 1 || direct.calls.Load() != 0 {
+			t.Errorf("want the call made once, through the daemon; got %d daemon and %d direct calls", calls.Load(), direct.calls.Load())
+		}
+	})
+
+	t.Run("idempotent", func(t *testing.T) {
+		dc, _ := droppingDaemon(t, tools)
+		direct := &directServer{}
+		dm := fallbackClient(t, dc, direct)
+		if _, err := dm.ListTools(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := dm.CallTool(context.Background(), "get_page", nil); err != nil {
+			t.Fatalf("want the read-only call repeated directly, got %v", err)
+		}
+		if direct.calls.Load() != 1 {
+			t.Errorf("want 1 direct call, got %d", direct.calls.Load())
+		}
+	})
+}
+
+func TestDaemonClientRebuildsErrors(t *testing.T) {
+	rpcErr := mcp.NewError(mcp.InvalidParams, "url is required", map[string]interface{}{"field": "url"})
+	tests := []struct {
+		name     string
+		answer   APIResponse
+		wantKind error
+		wantCode int
+	}{
+		{"session", APIResponse{Error: "session chrome not found", Kind: "session_not_active"}, mcp.ErrSessionNotActive, 0},
+		{"tool", APIResponse{Error: "tool call failed: " + rpcErr.Error() + "; did you mean 'navigate_page'?", Kind: "tool_not_found", RPCError: rpcErr}, mcp.ErrToolNotFound, mcp.InvalidParams},
+		{"server error", APIResponse{Error: "tool call failed: " + rpcErr.Error(), RPCError: rpcErr}, nil, mcp.InvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc, _ := dyingDaemon(t, tt.answer)
+			dm := fallbackClient(t, dc, &directServer{})
+
+			_, err := dm.CallTool(context.Background(), "navigate", nil)
+			if err == nil || err.Error() != "daemon error: "+tt.answer.Error {
+				t.Fatalf("want the daemon's error, got %v", err)
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("want %v to match %v", err, tt.wantKind)
+			}
+			var got *mcp.JSONRPCError
+			if errors.As(err, &got) != (tt.wantCode != 0) || (got != nil && (got.Code != tt.wantCode || got.Data == nil)) {
+				t.Errorf("want JSON-RPC error code %d with its data, got %#v", tt.wantCode, got)
+			}
+			if errors.Is(err, mcp.ErrDaemonUnavailable) {
+				t.Errorf("%v must not count as the daemon being unavailable", err)
+			}
+		})
+	}
+}
+
+func TestIsDaemonFailure(t *testing.T) {
+	failures := []error{
+		errDaemonNotRunning,
+		fmt.Errorf("failed to start: %w", errDaemonNotRunning),
+		&netError{},
+		io.ErrUnexpectedEOF,
+	}
+	for _, err := range failures {
+		if !isDaemonFailure(err) {
+			t.Errorf("%v not recognized as a daemon failure", err)
+		}
+	}
+	for _, err := range []error{nil, context.DeadlineExceeded, &netError{timeout: true}, errors.New("daemon error: tool failed")} {
+		if isDaemonFailure(err) {
+			t.Errorf("%v must not count as a daemon failure", err)
+		}
+	}
+}
+
+// netError is a connection error, or a timeout
+type netError struct{ timeout bool }
+
+func (e *netError) Error() string   { return "connection refused" }
+func (e *netError) Timeout() bool   { return e.timeout }
+func (e *netError) Temporary() bool { return false }
@@ -0,0 +1,307 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+)
+
+// JobState is where a job is in its life
+type JobState string
+
+const (
+	JobQueued  JobState = "queued"
+	JobRunning JobState = "running"
+	JobDone    JobState = "done"
+	JobFailed  JobState = "failed"
+)
+
+// Finished reports whether the job has stopped for good
+func (s JobState) Finished() bool {
+	return s == JobDone || s == JobFailed
+}
+
+// maxRunningJobs bounds how many jobs call their tools at once; later ones
+// wait queued
+const maxRunningJobs = 4
+
+// defaultJobRetention is how long a finished job is kept when the daemon
+// config doesn't say
+const defaultJobRetention = time.Hour
+
+// jobCancelWait is how long cancelling a job waits for its tool call to stop
+const jobCancelWait = 5 * time.Second
+
+// errJobCancelled is a cancelled job's error
+var errJobCancelled = errors.New("cancelled")
+
+// Job is a tool call the daemon runs in the background
+type Job struct {
+	ID       string          `json:"id"`
+	Server   string          `json:"server"`
+	Tool     string          `json:"tool"`
+	State    JobState        `json:"state"`
+	Created  time.Time       `json:"created"`
+	Started  *time.Time      `json:"started,omitempty"`
+	Finished *time.Time      `json:"finished,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"` // The ToolResult, once done
+	Error    string          `json:"error,omitempty"`
+}
+
+// jobEntry is a job and what the store needs to run and keep it
+type jobEntry struct {
+	job     Job
+	cancel  context.CancelFunc
+	done    chan struct{} // Closed once the job has finished
+	spilled bool          // The result was written to the spill directory
+}
+
+// jobStore keeps jobs in memory until their retention runs out, optionally
+// writing finished jobs' results to disk so large results don't stay in memory
+type jobStore struct {
+	mu        sync.Mutex
+	jobs      map[string]*jobEntry
+	slots     chan struct{}
+	retention time.Duration
+	spillDir  string // Empty keeps results in memory
+}
+
+// newJobStore creates a job store with the daemon config's retention and
+// spill directory
+func newJobStore(config *DaemonConfig) *jobStore {
+	return &jobStore{
+		jobs:      make(map[string]*jobEntry),
+		slots:     make(chan struct{}, maxRunningJobs),
+		retention: jobRetention(config),
+		spillDir:  config.JobSpillDir,
+	}
+}
+
+// jobRetention returns how long the daemon config keeps finished jobs
+func jobRetention(config *DaemonConfig) time.Duration {
+	if retention := time.Duration(config.JobRetention) * time.Second; retention > 0 {
+		return retention
+	}
+	return defaultJobRetention
+}
+
+// setRetention applies a reloaded daemon config's retention
+func (s *jobStore) setRetention(config *DaemonConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retention = jobRetention(config)
+}
+
+// submit queues a job that runs call once a slot is free, and returns it
+func (s *jobStore) submit(server, tool string, call func(context.Context) (json.RawMessage, error)) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to create job ID: %w", err)
+	}
+	// A job runs until it finishes or is cancelled, however long the
+	// server's per-request timeout
+	ctx, cancel := context.WithCancel(client.WithoutTimeout(context.Background()))
+	entry := &jobEntry{
+		job:    Job{ID: id, Server: server, Tool: tool, State: JobQueued, Created: time.Now()},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = entry
+	job := entry.job
+	s.mu.Unlock()
+
+	go s.run(ctx, entry, call)
+	return job, nil
+}
+
+// run waits for a slot, calls the tool and records the outcome
+func (s *jobStore) run(ctx context.Context, entry *jobEntry, call func(context.Context) (json.RawMessage, error)) {
+	defer close(entry.done)
+	defer entry.cancel()
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		s.finish(entry, nil, errJobCancelled)
+		return
+	}
+
+	s.mu.Lock()
+	started := time.Now()
+	entry.job.State, entry.job.Started = JobRunning, &started
+	s.mu.Unlock()
+
+	result, err := call(ctx)
+	if ctx.Err() != nil {
+		err = errJobCancelled
+	}
+	s.finish(entry, result, err)
+}
+
+// finish records a job's outcome, spilling its result to disk if configured
+func (s *jobStore) finish(entry *jobEntry, result json.RawMessage, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	finished := time.Now()
+	entry.job.Finished = &finished
+	if err != nil {
+		entry.job.State, entry.job.Error = JobFailed, err.Error()
+		return
+	}
+	entry.job.State, entry.job.Result = JobDone, result
+
+	if s.spillDir == "" {
+		return
+	}
+	if err := s.spill(entry.job); err != nil {
+		log.Printf("Keeping the result of job %s in memory: %v", entry.job.ID, err)
+		return
+	}
+	entry.job.Result, entry.spilled = nil, true
+}
+
+// spill writes a finished job to the spill directory
+func (s *jobStore) spill(job Job) error {
+	if err := os.MkdirAll(s.spillDir, 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.spillPath(job.ID), data, 0600)
+}
+
+// spillPath is where a job's spilled result is written
+func (s *jobStore) spillPath(id string) string {
+	return filepath.Join(s.spillDir, "job-"+id+".json")
+}
+
+// get returns a job, reading its result back from disk if it was spilled
+func (s *jobStore) get(id string) (Job, error) {
+	s.mu.Lock()
+	entry, exists := s.jobs[id]
+	if !exists {
+		s.mu.Unlock()
+		return Job{}, fmt.Errorf("job %s not found", id)
+	}
+	job, spilled := entry.job, entry.spilled
+	s.mu.Unlock()
+
+	if spilled {
+		data, err := os.ReadFile(s.spillPath(id))
+		if err != nil {
+			return Job{}, fmt.Errorf("failed to read the result of job %s: %w", id, err)
+		}
+		var stored Job
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return Job{}, fmt.Errorf("failed to read the result of job %s: %w", id, err)
+		}
+		job.Result = stored.Result
+	}
+	return job, nil
+}
+
+// cancel stops a queued or running job. A running job's tool call is
+// cancelled, which tells the server to stop work on it.
+func (s *jobStore) cancel(id string) (Job, error) {
+	s.mu.Lock()
+	entry, exists := s.jobs[id]
+	if !exists {
+		s.mu.Unlock()
+		return Job{}, fmt.Errorf("job %s not found", id)
+	}
+	if entry.job.State.Finished() {
+		job := entry.job
+		s.mu.Unlock()
+		return job, fmt.Errorf("job %s already %s", id, job.State)
+	}
+	cancel, done := entry.cancel, entry.done
+	s.mu.Unlock()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(jobCancelWait):
+	}
+	return s.get(id)
+}
+
+// cancelAll stops every job, for when the daemon stops
+func (s *jobStore) cancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range s.jobs {
+		entry.cancel()
+	}
+}
+
+// prune forgets jobs that finished longer than the retention ago
+func (s *jobStore) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, entry := range s.jobs {
+		if entry.job.Finished == nil || now.Sub(*entry.job.Finished) <= s.retention {
+			continue
+		}
+		if entry.spilled {
+			_ = os.Remove(s.spillPath(id))
+		}
+		delete(s.jobs, id)
+	}
+}
+
+// newJobID returns a random job ID
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SubmitJob queues a tool call on a session and returns the job running it.
+// Jobs call the tool the way CallTool does, without its time limit.
+func (d *Daemon) SubmitJob(serverName, toolName string, args map[string]interface{}, applyDefaults bool) (Job, error) {
+	d.restartOutdatedSession(serverName)
+	if _, err := d.GetSession(serverName); err != nil {
+		return Job{}, err
+	}
+
+	return d.jobs.submit(serverName, toolName, func(ctx context.Context) (json.RawMessage, error) {
+		result, err := d.callToolContext(ctx, serverName, toolName, args, applyDefaults, true, audit.SourceJob)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Raw) > 0 {
+			return result.Raw, nil
+		}
+		return json.Marshal(result)
+	})
+}
+
+// GetJob returns a job with its result, if it has finished
+func (d *Daemon) GetJob(id string) (Job, error) {
+	return d.jobs.get(id)
+}
+
+// CancelJob stops a queued or running job
+func (d *Daemon) CancelJob(id string) (Job, error) {
+	return d.jobs.cancel(id)
+}
@@ -0,0 +1,302 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/testharness"
+)
+
+// blockingClient is a stub client whose tool calls run until released or
+// cancelled, counting how many run at once
+type blockingClient struct {
+	stubClient
+	release   chan struct{}
+	running   atomic.Int32
+	peak      atomic.Int32
+	cancelled atomic.Int32
+}
+
+func (c *blockingClient) CallTool(ctx context.Context, name string, _ map[string]interface{}) (*mcp.ToolResult, error) {
+	running := c.running.Add(1)
+	defer c.running.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if running <= peak || c.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+
+	select {
+	case <-c.release:
+		return &mcp.ToolResult{Content: []interface{}{map[string]interface{}{"type": "text", "text": "ran " + name}}}, nil
+	case <-ctx.Done():
+		c.cancelled.Add(1)
+		return nil, ctx.Err()
+	}
+}
+
+// newJobDaemon returns a daemon with an active session whose tool calls block
+func newJobDaemon(t *testing.T) (*Daemon, *blockingClient) {
+	t.Helper()
+	d, _ := newTestDaemon(t)
+	client := &blockingClient{release: make(chan struct{})}
+	d.clientFactory = func(config.ServerConfig) (mcp.MCPClient, error) { return client, nil }
+	if err := d.StartSession("slow", config.ServerConfig{Command: "slow"}); err != nil {
+		t.Fatalf("StartSession failed: %v", err)
+	}
+	waitForActive(t, d, "slow")
+	t.Cleanup(d.jobs.cancelAll)
+	return d, client
+}
+
+// waitForJob waits for a job to reach a state
+func waitForJob(t *testing.T, d *Daemon, id string, state JobState) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, err := d.GetJob(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State == state {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is %s, want %s", id, job.State, state)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJobRunsToolCall(t *testing.T) {
+	d, client := newJobDaemon(t)
+
+	job, err := d.SubmitJob("slow", "crawl", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID == "" || job.State != JobQueued {
+		t.Fatalf("submitted job = %+v, want a queued job with an ID", job)
+	}
+	waitForJob(t, d, job.ID, JobRunning)
+
+	close(client.release)
+	done := waitForJob(t, d, job.ID, JobDone)
+	var result mcp.ToolResult
+	if err := json.Unmarshal(done.Result, &result); err != nil || result.Blocks()[0].Text != "ran crawl" {
+		t.Errorf("result = %s (%v), want the tool's result", done.Result, err)
+	}
+	if done.Started == nil || done.Finished == nil {
+		t.Errorf("a finished job should say when it ran, got %+v", done)
+	}
+
+	if _, err := d.SubmitJob("missing", "crawl", nil, true); err == nil {
+		t.Error("a job on a server without a session should be refused")
+	}
+	if _, err := d.GetJob("nope"); err == nil {
+		t.Error("an unknown job should not be found")
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	d, client := newJobDaemon(t)
+
+	job, _ := d.SubmitJob("slow", "crawl", nil, true)
+	waitForJob(t, d, job.ID, JobRunning)
+
+	cancelled, err := d.CancelJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.State != JobFailed || cancelled.Error != "cancelled" {
+		t.Errorf("cancelled job = %+v, want failed as cancelled", cancelled)
+	}
+	if client.cancelled.Load() != 1 {
+		t.Error("cancelling a running job should cancel its tool call")
+	}
+	if _, err := d.CancelJob(job.ID); err == nil {
+		t.Error("cancelling a finished job should fail")
+	}
+}
+
+func TestJobsWaitForASlot(t *testing.T) {
+	d, client := newJobDaemon(t)
+
+	var ids []string
+	for i := 0; i < maxRunningJobs+2; i++ {
+		job, err := d.SubmitJob("slow", "crawl", nil, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, job.ID)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.running.Load() < maxRunningJobs && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	var queued []string
+	for _, id := range ids {
+		if job, _ := d.GetJob(id); job.State == JobQueued {
+			queued = append(queued, id)
+		}
+	}
+	if len(queued) != 2 || client.peak.Load() != maxRunningJobs {
+		t.Fatalf("%d jobs queued and %d ran at once, want 2 and %d", len(queued), client.peak.Load(), maxRunningJobs)
+	}
+
+	// A queued job is cancelled without ever running
+	cancelled, err := d.CancelJob(queued[0])
+	if err != nil || cancelled.State != JobFailed || cancelled.Started != nil {
+		t.Errorf("cancelled queued job = %+v, %v; want failed without starting", cancelled, err)
+	}
+
+	close(client.release)
+	for _, id := range ids {
+		if id != queued[0] {
+			waitForJob(t, d, id, JobDone)
+		}
+	}
+}
+
+func TestJobRetentionAndSpill(t *testing.T) {
+	d, client := newJobDaemon(t)
+	d.jobs.spillDir = t.TempDir()
+	close(client.release)
+
+	job, _ := d.SubmitJob("slow", "crawl", nil, true)
+	done := waitForJob(t, d, job.ID, JobDone)
+	if !strings.Contains(string(done.Result), "ran crawl") {
+		t.Errorf("a spilled result should be read back, got %s", done.Result)
+	}
+
+	d.jobs.mu.Lock()
+	entry := d.jobs.jobs[job.ID]
+	inMemory := entry.job.Result
+	d.jobs.mu.Unlock()
+	if !entry.spilled || inMemory != nil {
+		t.Error("the result should be on disk rather than in memory")
+	}
+	spillFile := d.jobs.spillPath(job.ID)
+	if _, err := os.Stat(spillFile); err != nil {
+		t.Fatal(err)
+	}
+
+	d.jobs.prune(time.Now())
+	if _, err := d.GetJob(job.ID); err != nil {
+		t.Error("a job should be kept within its retention")
+	}
+	d.jobs.prune(time.Now().Add(defaultJobRetention + time.Minute))
+	if _, err := d.GetJob(job.ID); err == nil {
+		t.Error("a job past its retention should be forgotten")
+	}
+	if _, err := os.Stat(spillFile); !os.IsNotExist(err) {
+		t.Errorf("the spilled result should be removed, got %v", err)
+	}
+}
+
+func TestJobAPI(t *testing.T) {
+	d, client := newJobDaemon(t)
+	close(client.release)
+
+	mux := http.NewServeMux()
+	d.setupRoutes(mux)
+	do := func(method, path, body string) rawAPIResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		var resp rawAPIResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+		}
+		return resp
+	}
+
+	resp := do(http.MethodPost, "/sessions/slow/jobs", `{"tool": "crawl", "args": {}}`)
+	var job Job
+	if err := json.Unmarshal(resp.Data, &job); err != nil || !resp.Success {
+		t.Fatalf("submitting a job: %+v", resp)
+	}
+	waitForJob(t, d, job.ID, JobDone)
+
+	resp = do(http.MethodGet, "/jobs/"+job.ID, "")
+	if err := json.Unmarshal(resp.Data, &job); err != nil || job.State != JobDone || len(job.Result) == 0 {
+		t.Errorf("job status = %s, want done with its result", resp.Data)
+	}
+	if resp := do(http.MethodDelete, "/jobs/"+job.ID, ""); resp.Success {
+		t.Error("cancelling a finished job should fail")
+	}
+	if resp := do(http.MethodPost, "/sessions/slow/jobs", `{"args": {}}`); resp.Success {
+		t.Error("a job without a tool should be refused")
+	}
+}
+
+func TestJobOutlastsServerTimeout(t *testing.T) {
+	d, _ := newTestDaemon(t)
+	d.clientFactory = client.NewMCPClient
+	serverConfig := testharness.StdioConfig(testharness.Script{
+		Tools: []testharness.Tool{{Name: "crawl", Delay: 1500 * time.Millisecond}},
+	})
+	serverConfig.Timeout = 1
+	if err := d.StartSession("crawler", serverConfig); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = d.StopSession("crawler") })
+	waitForActive(t, d, "crawler")
+	t.Cleanup(d.jobs.cancelAll)
+
+	job, err := d.SubmitJob("crawler", "crawl", map[string]interface{}{"message": "crawled"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A session running a job isn't idle, however long ago the job started
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		d.sessionMutex.Lock()
+		session := d.sessions["crawler"]
+		inFlight := len(session.running) > 0
+		if inFlight {
+			session.LastUsed = time.Now().Add(-1000 * time.Hour)
+		}
+		d.sessionMutex.Unlock()
+		if inFlight {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the job's call never started")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	d.cleanupIdleSessions()
+	if _, err := d.GetSession("crawler"); err != nil {
+		t.Fatalf("cleanup stopped the session running a job: %v", err)
+	}
+
+	// The call takes longer than the server's 1s timeout
+	for {
+		job, err = d.GetJob(job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State == JobDone || job.State == JobFailed || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job.State != JobDone || !strings.Contains(string(job.Result), "Echo: crawled") {
+		t.Errorf("job = %s %s, want done with the echoed message", job.State, job.Error)
+	}
+}
@@ -0,0 +1,149 @@
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// Limits of a session's list cache
+const (
+	maxListEntries = 32
+	maxListBytes   = 4 << 20 // 4 MiB of encoded lists
+)
+
+// Keys of the lists a session caches. Paginated or filtered views add their
+// cursor or filter to the key.
+const (
+	toolsListKey = "tools/list"
+)
+
+// listEntry is a list as a server last returned it
+type listEntry struct {
+	payload   interface{} // E.g. []mcp.Tool
+	fetchedAt time.Time
+	hash      string // Of the encoded payload, like an ETag
+	size      int    // Bytes of the encoded payload
+}
+
+// ListCacheStats counts the lookups answered from a session's list cache
+type ListCacheStats struct {
+	ListCacheHits   int64 `json:"listCacheHits,omitempty"`
+	ListCacheMisses int64 `json:"listCacheMisses,omitempty"`
+}
+
+// listCache holds the lists a session's server returned, such as its tools,
+// up to maxEntries entries and maxBytes of encoded payload, evicting the
+// oldest first. It is not safe for concurrent use; sessions guard it with
+// the daemon's sessionMutex.
+type listCache struct {
+	entries    map[string]*listEntry
+	bytes      int
+	maxEntries int
+	maxBytes   int
+	stats      ListCacheStats
+}
+
+// newListCache returns an empty cache with the default limits
+func newListCache() *listCache {
+	return &listCache{
+		entries:    make(map[string]*listEntry),
+		maxEntries: maxListEntries,
+		maxBytes:   maxListBytes,
+	}
+}
+
+// get returns the payload cached under key, counting the lookup
+func (c *listCache) get(key string) (interface{}, bool) {
+	entry, ok := c.entries[key]
+	if !ok {
+		c.stats.ListCacheMisses++
+		return nil, false
+	}
+	c.stats.ListCacheHits++
+	return entry.payload, true
+}
+
+// peek returns the payload cached under key without counting the lookup,
+// for the daemon's own use of a list
+func (c *listCache) peek(key string) (interface{}, bool) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return entry.payload, true
+}
+
+// put caches payload under key, evicting the oldest entries to stay within
+// the limits. A payload larger than the whole byte budget is not cached.
+func (c *listCache) put(key string, payload interface{}, now time.Time) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(encoded)
+	hash := hex.EncodeToString(sum[:])
+
+	if existing, ok := c.entries[key]; ok {
+		if existing.hash == hash {
+			existing.fetchedAt = now // Unchanged; only fresher
+			return
+		}
+		c.remove(key)
+	}
+	if len(encoded) > c.maxBytes {
+		return
+	}
+
+	for len(c.entries) >= c.maxEntries || c.bytes+len(encoded) > c.maxBytes {
+		c.remove(c.oldest())
+	}
+	c.entries[key] = &listEntry{payload: payload, fetchedAt: now, hash: hash, size: len(encoded)}
+	c.bytes += len(encoded)
+}
+
+// clear forgets every entry, keeping the counters
+func (c *listCache) clear() {
+	c.entries = make(map[string]*listEntry)
+	c.bytes = 0
+}
+
+// remove forgets the entry under key
+func (c *listCache) remove(key string) {
+	if entry, ok := c.entries[key]; ok {
+		c.bytes -= entry.size
+		delete(c.entries, key)
+	}
+}
+
+// oldest returns the key of the entry fetched longest ago
+func (c *listCache) oldest() string {
+	var oldestKey string
+	var oldestAt time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.fetchedAt.Before(oldestAt) {
+			oldestKey, oldestAt = key, entry.fetchedAt
+		}
+	}
+	return oldestKey
+}
+
+// tools returns the cached tool list, counting the lookup
+func (c *listCache) tools() ([]mcp.Tool, bool) {
+	payload, ok := c.get(toolsListKey)
+	if !ok {
+		return nil, false
+	}
+	tools, ok := payload.([]mcp.Tool)
+	return tools, ok
+}
+
+// peekTools returns the cached tool list without counting the lookup
+func (c *listCache) peekTools() []mcp.Tool {
+	payload, _ := c.peek(toolsListKey)
+	tools, _ := payload.([]mcp.Tool)
+	return tools
+}
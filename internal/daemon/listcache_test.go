@@ -0,0 +1,148 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+func TestListCacheEvictsOldestFirst(t *testing.T) {
+	cache := newListCache()
+	cache.maxEntries = 2
+	start := time.Now()
+
+	cache.put("tools/list", []mcp.Tool{{Name: "a"}}, start)
+	cache.put("resources/list", []mcp.Resource{{URI: "file:///a"}}, start.Add(time.Second))
+	cache.put("tools/list?cursor=2", []mcp.Tool{{Name: "b"}}, start.Add(2*time.Second))
+	if _, ok := cache.peek("tools/list"); ok {
+		t.Error("the oldest entry should have been evicted over the entry cap")
+	}
+	if _, ok := cache.peek("resources/list"); !ok {
+		t.Error("a newer entry was evicted")
+	}
+
+	// Refetching an unchanged list makes it fresh again
+	cache.put("resources/list", []mcp.Resource{{URI: "file:///a"}}, start.Add(3*time.Second))
+	cache.put("prompts/list", []mcp.Prompt{{Name: "review"}}, start.Add(4*time.Second))
+	if _, ok := cache.peek("tools/list?cursor=2"); ok {
+		t.Error("the entry fetched longest ago should have been evicted")
+	}
+	if _, ok := cache.peek("resources/list"); !ok {
+		t.Error("a refreshed entry was evicted")
+	}
+}
+
+func TestListCacheStaysWithinByteBudget(t *testing.T) {
+	cache := newListCache()
+	tools := []mcp.Tool{{Name: "navigate_page", Description: strings.Repeat("x", 100)}}
+	encoded, _ := json.Marshal(tools)
+	cache.maxBytes = 2*len(encoded) + len(encoded)/2
+	now := time.Now()
+
+	for i, key := range []string{"a", "b", "c"} {
+		cache.put(key, tools, now.Add(time.Duration(i)*time.Second))
+	}
+	if len(cache.entries) != 2 || cache.bytes != 2*len(encoded) {
+		t.Errorf("got %d entries of %d bytes, want 2 of %d", len(cache.entries), cache.bytes, 2*len(encoded))
+	}
+	if _, ok := cache.peek("a"); ok {
+		t.Error("the oldest entry should have made room")
+	}
+
+	cache.put("huge", []mcp.Tool{{Name: strings.Repeat("y", cache.maxBytes)}}, now.Add(time.Hour))
+	if _, ok := cache.peek("huge"); ok || len(cache.entries) != 2 {
+		t.Error("a list over the whole budget should not be cached, nor evict others")
+	}
+}
+
+func TestListCacheCountsLookups(t *testing.T) {
+	cache := newListCache()
+	if _, ok := cache.tools(); ok {
+		t.Fatal("empty cache returned tools")
+	}
+	cache.put(toolsListKey, []mcp.Tool{{Name: "a"}}, time.Now())
+	cache.tools()
+	cache.tools()
+	cache.peekTools()
+	if cache.stats != (ListCacheStats{ListCacheHits: 2, ListCacheMisses: 1}) {
+		t.Errorf("stats = %+v", cache.stats)
+	}
+	cache.clear()
+	if _, ok := cache.tools(); ok || cache.bytes != 0 || cache.stats.ListCacheHits != 2 {
+		t.Errorf("clear should drop entries and keep counters, got %+v", cache.stats)
+	}
+}
+
+func TestListToolsEndpointAnswersAlikeFromCache(t *testing.T) {
+	d, _ := newTestDaemon(t)
+	client := &stubClient{tools: []mcp.Tool{
+		{Name: "navigate_page", Description: "Go to a URL", InputSchema: map[string]interface{}{"type": "object"}},
+		{Name: "take_screenshot"},
+	}}
+	d.clientFactory = func(config.ServerConfig) (mcp.MCPClient, error) { return client, nil }
+	if err := d.StartSession("chrome", config.ServerConfig{Command: "npx", Args: []string{"chrome-devtools-mcp"}}); err != nil {
+		t.Fatal(err)
+	}
+	waitForActive(t, d, "chrome")
+
+	mux := http.NewServeMux()
+	d.setupRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	request := func(method, path string) string {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	want := `{
+  "success": true,
+  "data": [
+    {
+      "name": "navigate_page",
+      "description": "Go to a URL",
+      "inputSchema": {
+        "type": "object"
+      }
+    },
+    {
+      "name": "take_screenshot"
+    }
+  ]
+}
+`
+	fetched, cached := request(http.MethodPost, "/sessions/chrome/tools"), request(http.MethodPost, "/sessions/chrome/tools")
+	if fetched != want {
+		t.Errorf("got\n%s\nwant\n%s", fetched, want)
+	}
+	if cached != fetched {
+		t.Errorf("the cached answer differs:\n%s\nfrom\n%s", cached, fetched)
+	}
+
+	var sessions struct {
+		Data []SessionInfo `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(request(http.MethodGet, "/sessions")), &sessions); err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions.Data) != 1 || sessions.Data[0].ListCacheStats != (ListCacheStats{ListCacheHits: 1, ListCacheMisses: 1}) {
+		t.Errorf("want one hit and one miss reported, got %+v", sessions.Data)
+	}
+}
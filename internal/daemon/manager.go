@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -14,28 +15,87 @@ import (
 	"time"
 
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/sampling"
+	"github.com/mcp-cli-ent/mcp-cli/pkg/version"
 )
 
 // DaemonManager manages the daemon lifecycle
 type DaemonManager struct {
-	platform string
-	endpoint string
+	platform     string
+	name         string // The daemon instance; empty is the shared one
+	endpoint     string // Derived from the name
+	endpointFile string // Where the running daemon records its actual endpoint, if anywhere
+
+	watchConfigPath string             // MCP server config to hot-reload, if set
+	watchOptions    config.LoadOptions // How to load the watched config
+	watchArgs       []string           // CLI flags selecting the config, for background starts
 }
 
-// NewDaemonManager creates a new daemon manager
+// NewDaemonManager creates a new daemon manager for this process's daemon
+// instance
 func NewDaemonManager() *DaemonManager {
+	return NewNamedDaemonManager(config.DaemonName())
+}
+
+// NewNamedDaemonManager creates a new daemon manager for the named daemon
+// instance; the shared instance's name is empty
+func NewNamedDaemonManager(name string) *DaemonManager {
 	platform := detectPlatform()
 
 	return &DaemonManager{
-		platform: platform,
-		endpoint: getDaemonEndpoint(platform),
+		platform:     platform,
+		name:         name,
+		endpoint:     getDaemonEndpoint(platform, name),
+		endpointFile: getEndpointFilePath(name),
+	}
+}
+
+// currentEndpoint returns the endpoint the running daemon recorded, which
+// differs from the derived one after a port conflict or with an address in
+// daemon.json, or else the derived one
+func (dm *DaemonManager) currentEndpoint() string {
+	if dm.endpointFile != "" {
+		if endpoint := readEndpointFile(dm.endpointFile); endpoint != "" {
+			return endpoint
+		}
+	}
+	return dm.endpoint
+}
+
+// Name returns the daemon instance's name; empty is the shared instance
+func (dm *DaemonManager) Name() string {
+	return dm.name
+}
+
+// WatchConfig makes the started daemon reload the MCP server configuration at
+// configPath when it changes. args are the CLI flags that select the
+// configuration, passed on when the daemon starts in the background.
+func (dm *DaemonManager) WatchConfig(configPath string, opts config.LoadOptions, args []string) {
+	dm.watchConfigPath = configPath
+	dm.watchOptions = opts
+	dm.watchArgs = args
+}
+
+// daemonArgs returns the arguments that start the daemon in a child process
+func (dm *DaemonManager) daemonArgs() []string {
+	args := []string{"daemon", "start", "--foreground"}
+	if dm.watchConfigPath != "" {
+		args = append(args, "--watch-config")
+		args = append(args, dm.watchArgs...)
 	}
+	return args
+}
+
+// daemonEnv returns the environment of a daemon child process, which selects
+// this instance
+func (dm *DaemonManager) daemonEnv() []string {
+	return append(os.Environ(), config.DaemonNameEnv+"="+dm.name)
 }
 
 // Start starts the daemon
 func (dm *DaemonManager) Start(foreground bool) error {
 	// Check if daemon is already running
-	if running, pid, err := isDaemonRunning(); err != nil {
+	if running, pid, err := isDaemonRunning(dm.name); err != nil {
 		return fmt.Errorf("failed to check daemon status: %w", err)
 	} else if running {
 		return fmt.Errorf("daemon is already running (PID: %d)", pid)
@@ -52,33 +112,66 @@ func (dm *DaemonManager) Start(foreground bool) error {
 func (dm *DaemonManager) startForeground() error {
 	log.Printf("Starting daemon in foreground on %s", dm.endpoint)
 
+	// The daemon's sessions, and the servers it starts, belong to this instance
+	if err := os.Setenv(config.DaemonNameEnv, dm.name); err != nil {
+		return fmt.Errorf("failed to select daemon instance: %w", err)
+	}
+
 	// Setup logging
-	if err := setupLogging(); err != nil {
+	if err := setupLogging(dm.name); err != nil {
 		return fmt.Errorf("failed to setup logging: %w", err)
 	}
 
 	// Write PID file
-	if err := writePIDFile(); err != nil {
+	if err := writePIDFile(dm.name); err != nil {
 		return fmt.Errorf("failed to write PID file: %w", err)
 	}
+	adopted := false
 	defer func() {
-		if err := removePIDFile(); err != nil {
+		if adopted {
+			return // The files are the running daemon's now
+		}
+		if err := removePIDFile(dm.name); err != nil {
 			log.Printf("Warning: Failed to remove PID file: %v", err)
 		}
+		if err := removeEndpointFile(dm.name); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to remove endpoint file: %v", err)
+		}
 	}()
 
 	// Load daemon config
-	daemonConfig := dm.loadDaemonConfig()
+	loaded := LoadDaemonConfig(dm.getDaemonConfigPath())
+	if loaded.Defaults {
+		log.Printf("Using default daemon config: %s", loaded.Warning)
+	}
 
 	// Create and start daemon
-	daemon, err := NewDaemon(daemonConfig)
+	daemon, err := NewDaemon(loaded.Config)
 	if err != nil {
 		return fmt.Errorf("failed to create daemon: %w", err)
 	}
+	daemon.config.Store(loaded)
 
 	if err := daemon.Start(); err != nil {
+		var running *RunningDaemonError
+		if errors.As(err, &running) {
+			if err := dm.adopt(running); err != nil {
+				return fmt.Errorf("failed to start daemon: %w", err)
+			}
+			adopted = true
+			return nil
+		}
 		return fmt.Errorf("failed to start daemon: %w", err)
 	}
+	if err := writeEndpointFile(dm.name, daemon.endpoint); err != nil {
+		log.Printf("Warning: Failed to record endpoint %s: %v", daemon.endpoint, err)
+	}
+
+	if dm.watchConfigPath != "" {
+		if err := daemon.WatchConfig(dm.watchConfigPath, dm.watchOptions); err != nil {
+			log.Printf("Warning: not watching configuration: %v", err)
+		}
+	}
 
 	// Wait for shutdown signal
 	dm.waitForShutdown(daemon)
@@ -86,6 +179,19 @@ func (dm *DaemonManager) startForeground() error {
 	return nil
 }
 
+// adopt makes a daemon found running on this instance's endpoint, which this
+// instance's PID file did not know about, the instance's daemon
+func (dm *DaemonManager) adopt(running *RunningDaemonError) error {
+	if running.Health.Version != version.Version {
+		log.Printf("Warning: the daemon running on %s is version %s, this is %s", running.Endpoint, running.Health.Version, version.Version)
+	}
+	log.Printf("Using the daemon already running on %s (PID: %d)", running.Endpoint, running.Health.PID)
+	if err := os.WriteFile(getPIDFilePath(dm.name), []byte(fmt.Sprintf("%d\n", running.Health.PID)), 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	return writeEndpointFile(dm.name, running.Endpoint)
+}
+
 // startBackground starts the daemon in the background
 func (dm *DaemonManager) startBackground() error {
 	log.Printf("Starting daemon in background on %s", dm.endpoint)
@@ -107,7 +213,8 @@ func (dm *DaemonManager) startBackgroundUnix() error {
 	}
 
 	// Create the daemon command
-	cmd := exec.Command(execPath, "daemon", "start", "--foreground")
+	cmd := exec.Command(execPath, dm.daemonArgs()...)
+	cmd.Env = dm.daemonEnv()
 
 	// Note: Setsid would be set here for proper daemonization, but we'll skip for cross-platform compatibility
 	// cmd.SysProcAttr = &syscall.SysProcAttr{
@@ -134,7 +241,7 @@ func (dm *DaemonManager) startBackgroundUnix() error {
 
 	// Verify that the daemon started successfully
 	for i := 0; i < 10; i++ {
-		if running, pid, err := isDaemonRunning(); err == nil && running {
+		if running, pid, err := isDaemonRunning(dm.name); err == nil && running {
 			log.Printf("Daemon started successfully (PID: %d)", pid)
 			return nil
 		}
@@ -152,7 +259,8 @@ func (dm *DaemonManager) startBackgroundWindows() error {
 	}
 
 	// Create command to run in background
-	cmd := exec.Command(execPath, "daemon", "start", "--foreground")
+	cmd := exec.Command(execPath, dm.daemonArgs()...)
+	cmd.Env = dm.daemonEnv()
 	// Windows-specific process creation would go here, but for simplicity,
 	// we'll use the standard approach
 
@@ -171,7 +279,7 @@ func (dm *DaemonManager) startBackgroundWindows() error {
 
 	// Verify that the daemon started successfully
 	for i := 0; i < 10; i++ {
-		if running, pid, err := isDaemonRunning(); err == nil && running {
+		if running, pid, err := isDaemonRunning(dm.name); err == nil && running {
 			log.Printf("Daemon started successfully (PID: %d)", pid)
 			return nil
 		}
@@ -183,13 +291,13 @@ func (dm *DaemonManager) startBackgroundWindows() error {
 
 // Stop stops the daemon
 func (dm *DaemonManager) Stop() error {
-	running, pid, err := isDaemonRunning()
+	running, pid, err := isDaemonRunning(dm.name)
 	if err != nil {
 		return fmt.Errorf("failed to check daemon status: %w", err)
 	}
 
 	if !running {
-		return fmt.Errorf("daemon is not running")
+		return errDaemonNotRunning
 	}
 
 	log.Printf("Stopping daemon (PID: %d)", pid)
@@ -205,15 +313,15 @@ func (dm *DaemonManager) Stop() error {
 
 // stopGracefully attempts to stop the daemon via HTTP API
 func (dm *DaemonManager) stopGracefully() error {
-	client := &http.Client{Timeout: 5 * time.Second}
+	client := &http.Client{Timeout: 5 * time.Second, Transport: transport}
 
 	// The daemon doesn't have a dedicated stop endpoint yet,
 	// but we can check if it responds to health checks
-	resp, err := client.Get(dm.getHTTPURL())
+	resp, err := client.Get(dm.getHTTPURL() + "/healthz")
 	if err != nil {
 		return fmt.Errorf("daemon not responding: %w", err)
 	}
-	_ = resp.Body.Close()
+	closeResponse(resp)
 
 	// For now, we'll implement a simple sleep to give the daemon
 	// time to cleanup. In a full implementation, we'd have a
@@ -221,7 +329,7 @@ func (dm *DaemonManager) stopGracefully() error {
 	time.Sleep(1 * time.Second)
 
 	// Check if daemon is still running
-	running, _, _ := isDaemonRunning()
+	running, _, _ := isDaemonRunning(dm.name)
 	if !running {
 		log.Printf("Daemon stopped gracefully")
 		return nil
@@ -262,12 +370,13 @@ func (dm *DaemonManager) stopForcefully(pid int) error {
 
 // Status returns the status of the daemon
 func (dm *DaemonManager) Status() (*DaemonStatus, error) {
-	running, pid, err := isDaemonRunning()
+	running, pid, err := isDaemonRunning(dm.name)
 	if err != nil {
 		return &DaemonStatus{
 			Running:  false,
+			Name:     dm.name,
 			Platform: dm.platform,
-			Endpoint: dm.endpoint,
+			Endpoint: dm.currentEndpoint(),
 			Error:    fmt.Sprintf("Failed to check daemon status: %v", err),
 		}, nil
 	}
@@ -275,8 +384,9 @@ func (dm *DaemonManager) Status() (*DaemonStatus, error) {
 	if !running {
 		return &DaemonStatus{
 			Running:  false,
+			Name:     dm.name,
 			Platform: dm.platform,
-			Endpoint: dm.endpoint,
+			Endpoint: dm.currentEndpoint(),
 		}, nil
 	}
 
@@ -286,8 +396,9 @@ func (dm *DaemonManager) Status() (*DaemonStatus, error) {
 		return &DaemonStatus{
 			Running:  true,
 			PID:      pid,
+			Name:     dm.name,
 			Platform: dm.platform,
-			Endpoint: dm.endpoint,
+			Endpoint: dm.currentEndpoint(),
 			Error:    fmt.Sprintf("Failed to get detailed status: %v", err),
 		}, nil
 	}
@@ -300,7 +411,7 @@ func (dm *DaemonManager) Restart() error {
 	log.Printf("Restarting daemon...")
 
 	// Stop the daemon if it's running
-	if running, _, err := isDaemonRunning(); err == nil && running {
+	if running, _, err := isDaemonRunning(dm.name); err == nil && running {
 		if err := dm.Stop(); err != nil {
 			log.Printf("Warning: Failed to stop daemon gracefully: %v", err)
 		}
@@ -315,72 +426,56 @@ func (dm *DaemonManager) Restart() error {
 
 // GetEndpoint returns the daemon endpoint
 func (dm *DaemonManager) GetEndpoint() string {
-	return dm.endpoint
+	return dm.currentEndpoint()
 }
 
 // Helper methods
 
-func (dm *DaemonManager) loadDaemonConfig() *DaemonConfig {
-	// Try to load config file
-	configPath := dm.getDaemonConfigPath()
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		log.Printf("Using default daemon config (could not read %s): %v", configPath, err)
-		return DefaultDaemonConfig()
-	}
-
-	var config DaemonConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		log.Printf("Invalid daemon config, using defaults: %v", err)
-		return DefaultDaemonConfig()
-	}
-
-	return &config
-}
-
 func (dm *DaemonManager) getDaemonConfigPath() string {
-	configDir, err := os.UserConfigDir()
+	configDir, err := config.GetConfigDir()
 	if err != nil {
 		return "daemon.json"
 	}
-	return filepath.Join(configDir, "mcp-cli-ent", "daemon.json")
+	return filepath.Join(configDir, "daemon.json")
 }
 
 func (dm *DaemonManager) getHTTPURL() string {
-	if isUnixSocket(dm.endpoint) {
+	endpoint := dm.currentEndpoint()
+	if isUnixSocket(endpoint) {
 		// For Unix sockets, we can't make HTTP requests easily
 		// This is a fallback that won't actually work for Unix sockets
 		return "http://localhost" // This won't work, but keeps the interface consistent
 	}
 
-	if isNamedPipe(dm.endpoint) {
+	if isNamedPipe(endpoint) {
 		// Named pipes also don't work with HTTP
 		return "http://localhost" // Fallback
 	}
 
 	// Regular HTTP endpoint
-	return "http://" + dm.endpoint
+	return "http://" + endpoint
 }
 
 func (dm *DaemonManager) getDaemonStatusFromAPI() (*DaemonStatus, error) {
-	if isUnixSocket(dm.endpoint) || isNamedPipe(dm.endpoint) {
+	if endpoint := dm.currentEndpoint(); isUnixSocket(endpoint) || isNamedPipe(endpoint) {
 		// For non-HTTP endpoints, we'll need to implement a different client
 		// For now, return a basic status
-		running, pid, _ := isDaemonRunning()
+		running, pid, _ := isDaemonRunning(dm.name)
 		return &DaemonStatus{
 			Running:  running,
 			PID:      pid,
+			Name:     dm.name,
 			Platform: dm.platform,
-			Endpoint: dm.endpoint,
+			Endpoint: endpoint,
 		}, nil
 	}
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(dm.getHTTPURL())
+	client := &http.Client{Timeout: 5 * time.Second, Transport: transport}
+	resp, err := client.Get(dm.getHTTPURL() + "/status")
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer closeResponse(resp)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("daemon returned status %d", resp.StatusCode)
@@ -391,7 +486,7 @@ func (dm *DaemonManager) getDaemonStatusFromAPI() (*DaemonStatus, error) {
 		return nil, err
 	}
 
-	var apiResp APIResponse
+	var apiResp rawAPIResponse
 	if err := json.Unmarshal(body, &apiResp); err != nil {
 		return nil, err
 	}
@@ -400,13 +495,8 @@ func (dm *DaemonManager) getDaemonStatusFromAPI() (*DaemonStatus, error) {
 		return nil, fmt.Errorf("daemon API error: %s", apiResp.Error)
 	}
 
-	data, err := json.Marshal(apiResp.Data)
-	if err != nil {
-		return nil, err
-	}
-
 	var status DaemonStatus
-	if err := json.Unmarshal(data, &status); err != nil {
+	if err := json.Unmarshal(apiResp.Data, &status); err != nil {
 		return nil, err
 	}
 
@@ -427,11 +517,29 @@ func (dm *DaemonManager) waitForShutdown(daemon *Daemon) {
 
 // GetDaemonConfigPath returns the path to the daemon configuration file
 func GetDaemonConfigPath() string {
-	configDir, err := os.UserConfigDir()
+	configDir, err := config.GetConfigDir()
 	if err != nil {
 		return "daemon.json"
 	}
-	return filepath.Join(configDir, "mcp-cli-ent", "daemon.json")
+	return filepath.Join(configDir, "daemon.json")
+}
+
+// LoadSamplingConfig returns the sampling section of daemon.json, or nil
+// when there is none, so stdio clients the CLI starts directly answer
+// sampling requests the way the daemon's do
+func LoadSamplingConfig() (*sampling.Config, error) {
+	data, err := os.ReadFile(GetDaemonConfigPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var daemonConfig DaemonConfig
+	if err := json.Unmarshal(data, &daemonConfig); err != nil {
+		return nil, fmt.Errorf("invalid daemon config: %w", err)
+	}
+	return daemonConfig.Sampling, nil
 }
 
 // LoadMCPConfig loads the MCP server configuration
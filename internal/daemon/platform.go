@@ -1,6 +1,8 @@
 package daemon
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -10,6 +12,7 @@ import (
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 )
 
 // startUnixSocket starts the daemon on a Unix domain socket
@@ -48,29 +51,20 @@ func (d *Daemon) startNamedPipe() error {
 
 // startHTTPServer starts the daemon on an HTTP port
 func (d *Daemon) startHTTPServer() error {
-	// If endpoint contains a port, use it directly
-	if strings.Contains(d.endpoint, ":") {
-		listener, err := net.Listen("tcp", d.endpoint)
-		if err != nil {
-			return fmt.Errorf("failed to listen on %s: %w", d.endpoint, err)
-		}
-
-		go func() {
-			if err := d.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
-				log.Printf("HTTP server error: %v", err)
-			}
-		}()
-
-		return nil
+	address := d.endpoint
+	if !strings.Contains(address, ":") {
+		address = "127.0.0.1:0" // Any free port
 	}
 
-	// Find an available port (original logic)
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	listener, err := net.Listen("tcp", address)
+	if errors.Is(err, syscall.EADDRINUSE) {
+		listener, err = d.listenAfterConflict(err)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to find available port: %w", err)
+		return err
 	}
 
-	// Update endpoint with actual address
+	// Record the port actually listened on
 	d.endpoint = listener.Addr().String()
 
 	go func() {
@@ -82,23 +76,84 @@ func (d *Daemon) startHTTPServer() error {
 	return nil
 }
 
-// writePIDFile writes the daemon PID to a file
-func writePIDFile() error {
-	pidFile := getPIDFilePath()
+// RunningDaemonError reports that another mcp-cli-ent daemon already listens
+// on the endpoint a daemon was to start on
+type RunningDaemonError struct {
+	Endpoint string
+	Health   *HealthStatus // As the running daemon reported it
+}
+
+func (e *RunningDaemonError) Error() string {
+	return fmt.Sprintf("another mcp-cli-ent daemon (PID %d, version %s) is already running on %s", e.Health.PID, e.Health.Version, e.Endpoint)
+}
+
+// listenAfterConflict handles the daemon's endpoint being taken. If a
+// daemon of the same instance holds it, a RunningDaemonError says so.
+// Otherwise a derived endpoint gives way to any free port, while an address
+// set in daemon.json is an error.
+func (d *Daemon) listenAfterConflict(bindErr error) (net.Listener, error) {
+	holder := "another program, not an mcp-cli-ent daemon"
+	if health, err := probeDaemon(d.endpoint); err == nil {
+		if health.Name == d.name {
+			return nil, &RunningDaemonError{Endpoint: d.endpoint, Health: health}
+		}
+		// Its sessions and files are another instance's
+		holder = describeInstance(health.Name)
+	}
+	if d.fixedEndpoint {
+		return nil, fmt.Errorf("%s is taken by %s; free it or change address in daemon.json: %w", d.endpoint, holder, bindErr)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find available port: %w", err)
+	}
+	log.Printf("Warning: %s is taken by %s, listening on %s instead", d.endpoint, holder, listener.Addr())
+	return listener, nil
+}
+
+// describeInstance names a daemon instance for messages
+func describeInstance(name string) string {
+	if name == "" {
+		return "the shared mcp-cli-ent daemon"
+	}
+	return fmt.Sprintf("the mcp-cli-ent daemon instance %q", name)
+}
+
+// probeDaemon asks whatever listens on endpoint for its health, failing
+// unless it answers as an mcp-cli-ent daemon
+func probeDaemon(endpoint string) (*HealthStatus, error) {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get("http://" + endpoint + "/healthz")
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponse(resp)
+
+	var health HealthStatus
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil || !health.OK || health.Version == "" || health.PID <= 0 {
+		return nil, fmt.Errorf("%s is not an mcp-cli-ent daemon", endpoint)
+	}
+	return &health, nil
+}
+
+// writePIDFile writes the daemon PID to the named instance's PID file
+func writePIDFile(name string) error {
+	pidFile := getPIDFilePath(name)
 	pid := os.Getpid()
 
 	return os.WriteFile(pidFile, []byte(fmt.Sprintf("%d\n", pid)), 0644)
 }
 
-// removePIDFile removes the daemon PID file
-func removePIDFile() error {
-	pidFile := getPIDFilePath()
+// removePIDFile removes the named instance's PID file
+func removePIDFile(name string) error {
+	pidFile := getPIDFilePath(name)
 	return os.Remove(pidFile)
 }
 
-// isDaemonRunning checks if the daemon is already running
-func isDaemonRunning() (bool, int, error) {
-	pidFile := getPIDFilePath()
+// isDaemonRunning checks if the named daemon instance is already running
+func isDaemonRunning(name string) (bool, int, error) {
+	pidFile := getPIDFilePath(name)
 
 	// Read PID file
 	data, err := os.ReadFile(pidFile)
@@ -119,8 +174,9 @@ func isDaemonRunning() (bool, int, error) {
 		return true, pid, nil
 	}
 
-	// Process is dead, remove stale PID file
+	// Process is dead, remove stale PID and endpoint files
 	_ = os.Remove(pidFile)
+	_ = removeEndpointFile(name)
 	return false, 0, nil
 }
 
@@ -179,9 +235,9 @@ func findSubstring(s, substr string) bool {
 	return false
 }
 
-// setupLogging configures logging for the daemon
-func setupLogging() error {
-	logFile := GetLogFilePath()
+// setupLogging configures logging to the named daemon instance's log file
+func setupLogging(name string) error {
+	logFile := logFilePath(name)
 
 	// Open log file
 	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
@@ -0,0 +1,222 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// Waiting for a session to become active: servers such as browsers take
+// several seconds, so readiness is polled, with pauses growing from
+// firstSessionPoll to maxSessionPoll
+const (
+	firstSessionPoll   = 50 * time.Millisecond
+	maxSessionPoll     = 500 * time.Millisecond
+	defaultSessionWait = 30 * time.Second // When the caller sets no deadline
+	maxWaitForActive   = 25 * time.Second // Inside the server's write timeout
+)
+
+var errSessionActive = errors.New("already active")
+
+// SessionLimitError reports that a session was not started because the
+// daemon already runs the most sessions maxSessions allows
+type SessionLimitError struct {
+	Server string
+	Limit  int
+}
+
+func (e *SessionLimitError) Error() string {
+	return fmt.Sprintf("cannot start session %s: the daemon already runs %d sessions (maxSessions in daemon.json); stop one or raise the limit", e.Server, e.Limit)
+}
+
+// pollSession calls check, with growing pauses, until it reports done or
+// fails, or ctx is done
+func pollSession(ctx context.Context, check func() (bool, error)) error {
+	pause := firstSessionPoll
+	for {
+		done, err := check()
+		if done || err != nil {
+			return err
+		}
+
+		timer := time.NewTimer(pause)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		pause = min(2*pause, maxSessionPoll)
+	}
+}
+
+// sessionInfo reports on a session whatever its status
+func (d *Daemon) sessionInfo(serverName string) (SessionInfo, error) {
+	d.sessionMutex.RLock()
+	defer d.sessionMutex.RUnlock()
+
+	session, exists := d.sessions[serverName]
+	if !exists {
+		return SessionInfo{}, d.sessionNotFoundError(serverName)
+	}
+	info := SessionInfo{
+		ServerName: session.ServerName,
+		Status:     session.Status.String(),
+		StartTime:  session.StartTime,
+		LastUsed:   session.LastUsed,
+		Duration:   session.LastUsed.Sub(session.StartTime),
+		Error:      session.Error,
+		PID:        session.PID,
+
+		SessionMetrics: session.SessionMetrics,
+	}
+	session.noteRunning(&info, time.Now())
+	return info, nil
+}
+
+// awaitSession waits until a session is active, failing if it fails to start
+// or goes away, or ctx is done first. It waits on the session's start
+// attempt, so every caller learns that attempt's outcome.
+func (d *Daemon) awaitSession(ctx context.Context, serverName string) error {
+	for {
+		d.sessionMutex.RLock()
+		session, exists := d.sessions[serverName]
+		var status SessionStatus
+		var started chan struct{}
+		var sessionErr string
+		if exists {
+			status, started, sessionErr = session.Status, session.started, session.Error
+		}
+		d.sessionMutex.RUnlock()
+
+		switch {
+		case !exists:
+			return fmt.Errorf("session %s went away while starting", serverName)
+		case status == SessionStatusActive:
+			return nil
+		case status == SessionStatusError:
+			return fmt.Errorf("session %s failed: %s", serverName, sessionErr)
+		case status != SessionStatusStarting:
+			return mcp.Mark(fmt.Errorf("session %s is not active (status: %s)", serverName, status), mcp.ErrSessionNotActive)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for session %s", serverName)
+		case <-started:
+		}
+	}
+}
+
+// waitIfStarting waits, until ctx is done, for a session that is starting to
+// become active or fail, so requests arriving meanwhile are not turned away
+func (d *Daemon) waitIfStarting(ctx context.Context, serverName string) {
+	d.sessionMutex.RLock()
+	session, exists := d.sessions[serverName]
+	starting := exists && session.Status == SessionStatusStarting
+	d.sessionMutex.RUnlock()
+
+	if starting {
+		_ = d.awaitSession(ctx, serverName)
+	}
+}
+
+// parseWaitForActive reads the start endpoint's waitForActive parameter: a
+// duration, or true for the longest wait. Waits are capped at
+// maxWaitForActive; none is zero.
+func parseWaitForActive(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if wait, err := strconv.ParseBool(value); err == nil {
+		if wait {
+			return maxWaitForActive, nil
+		}
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(value)
+	if err != nil || wait < 0 {
+		return 0, fmt.Errorf("invalid waitForActive %q: use true or a duration such as 10s", value)
+	}
+	return min(wait, maxWaitForActive), nil
+}
+
+// StartSessionAndWait starts a session and waits for it to become active,
+// until ctx is done or, without a deadline, for defaultSessionWait. The
+// daemon does most of the waiting; the session is then polled for what
+// remains. A session that fails to start returns the error it recorded.
+func (dc *DaemonClient) StartSessionAndWait(ctx context.Context, serverName string, serverConfig config.ServerConfig) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultSessionWait)
+		defer cancel()
+	}
+	deadline, _ := ctx.Deadline()
+
+	wait := min(time.Until(deadline), maxWaitForActive)
+	if err := dc.startSession(ctx, serverName, serverConfig, wait); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out waiting for daemon session %s: %w", serverName, ctx.Err())
+		}
+		return err
+	}
+	return dc.WaitForSession(ctx, serverName)
+}
+
+// WaitForSession polls a session until it is active, failing with the
+// session's recorded error if it fails to start, or when ctx is done
+func (dc *DaemonClient) WaitForSession(ctx context.Context, serverName string) error {
+	err := pollSession(ctx, func() (bool, error) {
+		info, err := dc.getSession(ctx, serverName)
+		switch {
+		case err != nil:
+			return false, err
+		case info.Status == SessionStatusActive.String():
+			return true, nil
+		case info.Status == SessionStatusError.String():
+			if info.Error == "" {
+				return false, fmt.Errorf("daemon session %s failed to start", serverName)
+			}
+			return false, errors.New(info.Error)
+		}
+		return false, nil
+	})
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return fmt.Errorf("timed out waiting for daemon session %s: %w", serverName, err)
+	}
+	return err
+}
+
+// getSession reports on a session whatever its status
+func (dc *DaemonClient) getSession(ctx context.Context, serverName string) (*SessionInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dc.getSessionURL(serverName, ""), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := dc.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var info SessionInfo
+	if err := decodeData(resp, "session", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// waitForActiveQuery returns the start endpoint's query asking the daemon to
+// wait up to wait for the session; none for no wait
+func waitForActiveQuery(wait time.Duration) string {
+	if wait <= 0 {
+		return ""
+	}
+	return "?" + url.Values{"waitForActive": {wait.Round(time.Millisecond).String()}}.Encode()
+}
@@ -0,0 +1,238 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// newSlowDaemon serves a daemon whose sessions become active after the
+// server's delay, or fail with its error, with a client for it
+func newSlowDaemon(t *testing.T, delays map[string]time.Duration, failures map[string]string) *DaemonClient {
+	t.Helper()
+	d, _ := newTestDaemon(t)
+	d.clientFactory = func(serverConfig config.ServerConfig) (mcp.MCPClient, error) {
+		name := serverConfig.Command
+		time.Sleep(delays[name])
+		if failure, ok := failures[name]; ok {
+			return nil, errors.New(failure)
+		}
+		return &stubClient{tools: []mcp.Tool{{Name: "navigate"}}}, nil
+	}
+
+	mux := http.NewServeMux()
+	d.setupRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	// DaemonMCPClient finds the servers in the configuration
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers := make(map[string]config.ServerConfig)
+	for name := range delays {
+		servers[name] = config.ServerConfig{Command: name}
+	}
+	for name := range failures {
+		servers[name] = config.ServerConfig{Command: name}
+	}
+	data, _ := json.Marshal(map[string]interface{}{"mcpServers": servers})
+	if err := os.WriteFile(filepath.Join(configDir, "mcp_servers.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return newTestDaemonClient(t, server)
+}
+
+func TestDaemonMCPClientWaitsForSlowSessions(t *testing.T) {
+	delays := map[string]time.Duration{"fast": 0, "browser": 300 * time.Millisecond, "slower": 1200 * time.Millisecond}
+	dc := newSlowDaemon(t, delays, nil)
+
+	for name := range delays {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			tools, err := NewDaemonMCPClient(dc, name).ListTools(ctx)
+			if err != nil {
+				t.Fatalf("ListTools failed: %v", err)
+			}
+			if len(tools) != 1 || tools[0].Name != "navigate" {
+				t.Errorf("unexpected tools %+v", tools)
+			}
+		})
+	}
+}
+
+func TestDaemonMCPClientReportsSessionErrors(t *testing.T) {
+	dc := newSlowDaemon(t, map[string]time.Duration{"broken": 100 * time.Millisecond}, map[string]string{"broken": "browser crashed"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := NewDaemonMCPClient(dc, "broken").CallTool(ctx, "navigate", nil)
+	if err == nil || err.Error() != "failed to create client: browser crashed" {
+		t.Errorf("expected the session's recorded error, got %v", err)
+	}
+}
+
+func TestDaemonMCPClientHonorsDeadline(t *testing.T) {
+	dc := newSlowDaemon(t, map[string]time.Duration{"stuck": 3 * time.Second}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := NewDaemonMCPClient(dc, "stuck").ListTools(ctx)
+	if err == nil || !strings.Contains(err.Error(), "timed out waiting for daemon session stuck") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("waited %s past a 300ms deadline", elapsed)
+	}
+}
+
+func TestStartEndpointWaitsForActive(t *testing.T) {
+	dc := newSlowDaemon(t, map[string]time.Duration{"browser": 200 * time.Millisecond}, nil)
+
+	body := strings.NewReader(`{"config": {"command": "browser"}}`)
+	resp, err := http.Post(dc.getSessionURL("browser", "start")+"?waitForActive=5s", "application/json", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info SessionInfo
+	if err := decodeData(resp, "session", &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Status != "active" {
+		t.Errorf("expected the start endpoint to answer once active, got %s", info.Status)
+	}
+
+	// Waiting on a session that is already active is not an error
+	resp, err = http.Post(dc.getSessionURL("browser", "start")+"?waitForActive=true", "application/json", strings.NewReader(`{"config": {"command": "browser"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decodeData(resp, "session", &info); err != nil || info.Status != "active" {
+		t.Errorf("expected the active session, got %+v, %v", info, err)
+	}
+}
+
+func TestConcurrentStartsShareOneAttempt(t *testing.T) {
+	d, _ := newTestDaemon(t)
+	var constructed atomic.Int32
+	d.clientFactory = func(config.ServerConfig) (mcp.MCPClient, error) {
+		constructed.Add(1)
+		time.Sleep(300 * time.Millisecond)
+		return &stubClient{}, nil
+	}
+	serverConfig := config.ServerConfig{Command: "browser"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	const starters = 64
+	errs := make(chan error, 2*starters)
+	begin := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < starters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-begin
+			if err := d.StartSession("browser", serverConfig); err != nil && !errors.Is(err, errSessionActive) {
+				errs <- err
+				return
+			}
+			errs <- d.awaitSession(ctx, "browser")
+		}()
+	}
+	close(begin)
+
+	// Calls arriving while the session starts wait for it rather than failing
+	for {
+		if _, err := d.sessionInfo("browser"); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < starters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := d.CallTool("browser", "navigate", nil)
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if n := constructed.Load(); n != 1 {
+		t.Errorf("%d clients constructed, want 1", n)
+	}
+}
+
+func TestStoppedStartingSessionIsNotRevived(t *testing.T) {
+	d, clients := newTestDaemon(t)
+	factory := d.clientFactory
+	release := make(chan struct{})
+	d.clientFactory = func(serverConfig config.ServerConfig) (mcp.MCPClient, error) {
+		<-release
+		return factory(serverConfig)
+	}
+
+	if err := d.StartSession("browser", config.ServerConfig{Command: "browser"}); err != nil {
+		t.Fatal(err)
+	}
+	d.sessionMutex.Lock()
+	started := d.sessions["browser"].started
+	delete(d.sessions, "browser") // As if stopped while starting
+	d.sessionMutex.Unlock()
+
+	close(release)
+	<-started
+	if _, err := d.sessionInfo("browser"); err == nil {
+		t.Error("the start attempt brought back a session that was gone")
+	}
+	if got := clients(); len(got) != 1 || !got[0].isClosed() {
+		t.Error("the client of a session that was gone should be closed")
+	}
+}
+
+func TestParseWaitForActive(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"false", 0},
+		{"true", maxWaitForActive},
+		{"10s", 10 * time.Second},
+		{"5m", maxWaitForActive},
+	}
+	for _, tt := range tests {
+		if got, err := parseWaitForActive(tt.value); err != nil || got != tt.want {
+			t.Errorf("parseWaitForActive(%q) = %s, %v; want %s", tt.value, got, err, tt.want)
+		}
+	}
+	for _, value := range []string{"soon", "-1s"} {
+		if _, err := parseWaitForActive(value); err == nil {
+			t.Errorf("parseWaitForActive(%q): expected an error", value)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// leakyToken is the secret leakyClient's errors give away
+const leakyToken = "ghp-0123456789abcdef"
+
+// leakyClient is a stub client whose calls fail quoting its credentials
+type leakyClient struct {
+	stubClient
+}
+
+func (c *leakyClient) CallTool(context.Context, string, map[string]interface{}) (*mcp.ToolResult, error) {
+	return nil, fmt.Errorf("search failed: %w", mcp.NewError(-32001, fmt.Sprintf("401 Unauthorized: token %s has expired", leakyToken), map[string]interface{}{"token": leakyToken}))
+}
+
+func TestFailedCallsOmitSecrets(t *testing.T) {
+	d, _ := newTestDaemon(t)
+	d.clientFactory = func(config.ServerConfig) (mcp.MCPClient, error) { return &leakyClient{}, nil }
+	d.SetAudit(&config.AuditConfig{IncludeArgs: true})
+	serverConfig := config.ServerConfig{URL: "https://api.example.com/mcp", Headers: map[string]string{"Authorization": "token " + leakyToken}}
+	if err := d.StartSession("github", serverConfig); err != nil {
+		t.Fatal(err)
+	}
+	waitForActive(t, d, "github")
+
+	mux := http.NewServeMux()
+	d.setupRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	outputs := map[string]string{}
+	for _, query := range []string{"", "?stream=1"} {
+		resp, err := http.Post(server.URL+"/sessions/github/call-tool/search"+query, "application/json",
+			strings.NewReader(`{"args":{"q":"x","apiKey":"`+leakyToken+`"}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if !strings.Contains(string(body), "has expired") || !strings.Contains(string(body), `"rpcError"`) {
+			t.Fatalf("want the call's error in the response, got %s", body)
+		}
+		outputs["response"+query] = string(body)
+	}
+
+	d.setSessionError("github", "health check failed: bad token "+leakyToken)
+	outputs["session error"] = d.ListSessions()[0].Error
+
+	path, err := audit.DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs["audit log"] = string(log)
+
+	for name, output := range outputs {
+		if strings.Contains(output, leakyToken) {
+			t.Errorf("the secret leaked into the %s:\n%s", name, output)
+		}
+	}
+}
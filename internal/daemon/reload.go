@@ -0,0 +1,172 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/exports"
+	"github.com/mcp-cli-ent/mcp-cli/internal/resultcache"
+)
+
+// WatchConfig reloads the MCP server configuration at configPath whenever it
+// changes, until the daemon stops. Sessions of removed servers are stopped and
+// sessions of changed servers restart with the new settings on next use.
+func (d *Daemon) WatchConfig(configPath string, opts config.LoadOptions) error {
+	cfg, err := config.LoadConfigWithOptions(configPath, opts)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := d.SetExports(cfg.Exports); err != nil {
+		return err
+	}
+	d.mcpConfig.Store(cfg)
+	d.SetAudit(cfg.Audit)
+	d.SetResultCache(cfg.ResultCacheMaxMB)
+	client.SetMaxResponseSize(cfg.MaxResponseMB)
+
+	watcher, err := config.WatchConfig(configPath, opts, cfg, d.applyConfigChange, func(err error) {
+		log.Printf("%v", err)
+	})
+	if err != nil {
+		return err
+	}
+
+	d.configWatcher = watcher
+	log.Printf("Watching %s for configuration changes", configPath)
+	return nil
+}
+
+// applyConfigChange updates sessions after the configuration was reloaded
+func (d *Daemon) applyConfigChange(change config.ConfigChange) {
+	log.Printf("Configuration reloaded (added: %v, removed: %v, changed: %v)", change.Added, change.Removed, change.Changed)
+	d.mcpConfig.Store(change.Config)
+	d.SetAudit(change.Config.Audit)
+	d.SetResultCache(change.Config.ResultCacheMaxMB)
+	client.SetMaxResponseSize(change.Config.MaxResponseMB)
+
+	if change.ExportsChanged {
+		if err := d.SetExports(change.Config.Exports); err != nil {
+			log.Printf("Keeping the previous export policy: %v", err)
+		} else {
+			log.Printf("Export policy updated")
+		}
+	}
+
+	d.sessionMutex.Lock()
+	defer d.sessionMutex.Unlock()
+
+	for _, serverName := range change.Removed {
+		session, exists := d.sessions[serverName]
+		if !exists {
+			continue
+		}
+
+		log.Printf("Stopping session for removed server: %s", serverName)
+		d.runHook(serverName, session.Config, hookEventStop, nil)
+		if session.Client != nil {
+			_ = session.Client.Close()
+		}
+		delete(d.sessions, serverName)
+	}
+
+	for _, serverName := range change.Changed {
+		session, exists := d.sessions[serverName]
+		if !exists {
+			continue
+		}
+
+		session.Config = change.Config.MCPServers[serverName]
+		session.ConfigOutdated = true
+		log.Printf("Session %s has an outdated config and will restart on next use", serverName)
+	}
+}
+
+// applyStartupConfig applies the settings of the configuration at configPath
+// the daemon needs whether or not it watches the file. A configuration that
+// can't be loaded leaves the defaults, unless its exports are invalid: a
+// daemon that can't tell what it may export doesn't start.
+func (d *Daemon) applyStartupConfig(configPath string) error {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		var invalid *config.ValidationError
+		if errors.As(err, &invalid) {
+			for _, issue := range invalid.Issues {
+				if strings.HasPrefix(issue.Field, "exports.") {
+					return fmt.Errorf("invalid exports: %w", err)
+				}
+			}
+		}
+		return nil
+	}
+	if err := d.SetExports(cfg.Exports); err != nil {
+		return err
+	}
+	d.SetAudit(cfg.Audit)
+	d.SetResultCache(cfg.ResultCacheMaxMB)
+	client.SetMaxResponseSize(cfg.MaxResponseMB)
+	return nil
+}
+
+// SetExports replaces the export policy the API enforces when the
+// configuration sets exports.daemon
+func (d *Daemon) SetExports(cfg *config.ExportsConfig) error {
+	policy, err := exports.New(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid exports: %w", err)
+	}
+	d.exports.Store(policy)
+	return nil
+}
+
+// SetAudit replaces the audit settings tool calls are recorded with
+func (d *Daemon) SetAudit(cfg *config.AuditConfig) {
+	d.audit.Store(audit.Default(cfg))
+}
+
+// SetResultCache replaces the result cache with one holding at most maxMB
+// megabytes. Without a config directory nothing is cached.
+func (d *Daemon) SetResultCache(maxMB int) {
+	cache, _ := resultcache.Default(maxMB)
+	d.results.Store(cache)
+}
+
+// daemonExports returns the export policy when it applies to the API, or nil
+func (d *Daemon) daemonExports() *exports.Policy {
+	if policy := d.exports.Load(); policy.AppliesToDaemon() {
+		return policy
+	}
+	return nil
+}
+
+// restartOutdatedSession restarts an active session whose configuration
+// changed since it started, waiting for the new client to come up
+func (d *Daemon) restartOutdatedSession(serverName string) {
+	d.sessionMutex.Lock()
+	session, exists := d.sessions[serverName]
+	if !exists || !session.ConfigOutdated || session.Status != SessionStatusActive {
+		d.sessionMutex.Unlock()
+		return
+	}
+
+	oldClient := session.Client
+	serverConfig := session.Config
+	session.Client = nil
+	session.Status = SessionStatusStarting
+	session.started = make(chan struct{})
+	session.ConfigOutdated = false
+	session.SessionMetrics.RecordRestart()
+	session.ListCache.clear()
+	d.runHook(serverName, session.Config, hookEventStop, nil)
+	d.sessionMutex.Unlock()
+
+	log.Printf("Restarting session %s with updated config", serverName)
+	if oldClient != nil {
+		_ = oldClient.Close()
+	}
+	d.startSessionBackground(session, serverConfig)
+}
@@ -0,0 +1,259 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// stubClient is an MCP client that records whether it was closed and how
+// often tools were called
+type stubClient struct {
+	mu     sync.Mutex
+	args   []string
+	closed bool
+	calls  int
+	tools  []mcp.Tool // When set, calls of other tools fail
+}
+
+func (c *stubClient) Initialize(context.Context, *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	return &mcp.InitializeResult{}, nil
+}
+
+func (c *stubClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *stubClient) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *stubClient) ListTools(context.Context) ([]mcp.Tool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]mcp.Tool{}, c.tools...), nil
+}
+
+func (c *stubClient) CallTool(_ context.Context, toolName string, _ map[string]interface{}) (*mcp.ToolResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.tools != nil {
+		known := false
+		for _, tool := range c.tools {
+			known = known || tool.Name == toolName
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown tool: %s", toolName)
+		}
+	}
+	return &mcp.ToolResult{}, nil
+}
+
+func (c *stubClient) callCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func (c *stubClient) ListResources(context.Context) ([]mcp.Resource, error) { return nil, nil }
+
+func (c *stubClient) CreateMessage(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	return nil, nil
+}
+
+func (c *stubClient) RequestInput(context.Context, *mcp.RequestInputParams) (*mcp.RequestInputResult, error) {
+	return nil, nil
+}
+
+func (c *stubClient) ListRoots(context.Context) ([]mcp.Root, error) { return nil, nil }
+
+func (c *stubClient) NotifyRootsListChanged([]mcp.Root) error { return nil }
+
+// newTestDaemon returns a daemon whose clients are stubs, recording each one created
+func newTestDaemon(t *testing.T) (*Daemon, func() []*stubClient) {
+	t.Helper()
+	t.Setenv(config.ConfigDirEnv, t.TempDir()) // Keep the audit log and caches out of the real config directory
+	d, err := NewDaemon(nil)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	var clients []*stubClient
+	d.clientFactory = func(serverConfig config.ServerConfig) (mcp.MCPClient, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		client := &stubClient{args: serverConfig.Args}
+		clients = append(clients, client)
+		return client, nil
+	}
+
+	return d, func() []*stubClient {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*stubClient(nil), clients...)
+	}
+}
+
+// waitForActive waits for a session to finish starting
+func waitForActive(t *testing.T, d *Daemon, serverName string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := d.GetSession(serverName); err == nil {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("session %s did not become active", serverName)
+}
+
+func TestApplyConfigChangeRestartsOutdatedSessions(t *testing.T) {
+	d, clients := newTestDaemon(t)
+
+	timeServer := config.ServerConfig{Command: "uvx", Args: []string{"mcp-server-time"}}
+	fetchServer := config.ServerConfig{Command: "uvx", Args: []string{"mcp-server-fetch"}}
+	for name, serverConfig := range map[string]config.ServerConfig{"time": timeServer, "fetch": fetchServer} {
+		if err := d.StartSession(name, serverConfig); err != nil {
+			t.Fatalf("StartSession(%s) failed: %v", name, err)
+		}
+		waitForActive(t, d, name)
+	}
+
+	updated := config.ServerConfig{Command: "uvx", Args: []string{"mcp-server-time", "--local-timezone=UTC"}}
+	d.applyConfigChange(config.ConfigChange{
+		Config:  &config.Configuration{MCPServers: map[string]config.ServerConfig{"time": updated}},
+		Removed: []string{"fetch"},
+		Changed: []string{"time"},
+	})
+
+	if _, err := d.GetSession("fetch"); err == nil {
+		t.Error("expected the removed server's session to be stopped")
+	}
+
+	sessions := d.ListSessions()
+	if len(sessions) != 1 || !sessions[0].ConfigOutdated {
+		t.Fatalf("expected the time session to be marked outdated, got %+v", sessions)
+	}
+
+	// The next use restarts the session with the new settings
+	if _, err := d.ListTools("time"); err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+
+	created := clients()
+	if len(created) != 3 {
+		t.Fatalf("expected a new client for the restarted session, got %d clients", len(created))
+	}
+	latest := created[len(created)-1]
+	if len(latest.args) != 2 || latest.args[1] != "--local-timezone=UTC" {
+		t.Errorf("expected the restarted client to use the new config, got args %v", latest.args)
+	}
+	for _, client := range created[:2] {
+		if !client.isClosed() {
+			t.Error("expected replaced and removed clients to be closed")
+		}
+	}
+
+	session, err := d.GetSession("time")
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	if session.ConfigOutdated {
+		t.Error("expected the restarted session to be up to date")
+	}
+}
+
+func TestApplyConfigChangeUpdatesExports(t *testing.T) {
+	d, _ := newTestDaemon(t)
+	if err := d.StartSession("time", config.ServerConfig{Command: "uvx", Args: []string{"mcp-server-time"}}); err != nil {
+		t.Fatalf("StartSession failed: %v", err)
+	}
+	waitForActive(t, d, "time")
+
+	callTool := func(toolName string) APIResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/sessions/time/call-tool/"+toolName, strings.NewReader(`{"args":{}}`))
+		rec := httptest.NewRecorder()
+		d.handleSessionAndToolActions(rec, req)
+		var resp APIResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+		}
+		return resp
+	}
+
+	if resp := callTool("convert_time"); !resp.Success {
+		t.Fatalf("without exports every tool is callable, got %+v", resp)
+	}
+
+	d.applyConfigChange(config.ConfigChange{
+		Config: &config.Configuration{
+			MCPServers: map[string]config.ServerConfig{},
+			Exports:    &config.ExportsConfig{Tools: []string{"time/get_*"}, Daemon: true},
+		},
+		ExportsChanged: true,
+	})
+
+	if resp := callTool("convert_time"); resp.Success || !strings.Contains(resp.Error, "export policy") {
+		t.Errorf("want a denial naming the export policy, got %+v", resp)
+	}
+	if resp := callTool("get_current_time"); !resp.Success {
+		t.Errorf("exported tools stay callable, got %+v", resp)
+	}
+}
+
+func TestStartEnforcesExportsWithoutWatching(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, dir)
+	t.Setenv(config.DaemonNameEnv, "exports-test")
+	writeConfig := func(exports string) {
+		t.Helper()
+		data := `{"mcpServers": {"time": {"command": "uvx", "args": ["mcp-server-time"]}}, "exports": ` + exports + `}`
+		if err := os.WriteFile(filepath.Join(dir, "mcp_servers.json"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeConfig(`{"tools": ["time/get_*"], "daemon": true}`)
+	d, err := startTestDaemon(t, DefaultDaemonConfig(), "")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	resp, err := http.Post("http://"+d.endpoint+"/sessions/time/call-tool/convert_time", "application/json", strings.NewReader(`{"args": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var result APIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || !strings.Contains(result.Error, "export policy") {
+		t.Errorf("want a denial naming the export policy, got %+v", result)
+	}
+
+	// A daemon that can't tell what it may export doesn't start
+	t.Setenv(config.DaemonNameEnv, "exports-test-invalid")
+	writeConfig(`{"tools": ["get_current_time"], "daemon": true}`)
+	if _, err := startTestDaemon(t, DefaultDaemonConfig(), ""); err == nil || !strings.Contains(err.Error(), "invalid exports") {
+		t.Errorf("want invalid exports to fail the start, got %v", err)
+	}
+}
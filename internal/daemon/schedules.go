@@ -0,0 +1,345 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/schedule"
+	"github.com/mcp-cli-ent/mcp-cli/internal/suggest"
+)
+
+// scheduleHistorySize is how many outcomes are kept per schedule
+const scheduleHistorySize = 20
+
+// idleScheduleWait is how long the scheduler sleeps when nothing is due; a
+// reload wakes it sooner
+const idleScheduleWait = time.Hour
+
+// scheduleSessionWait bounds waiting for a schedule's session to start
+const scheduleSessionWait = 30 * time.Second
+
+// ScheduleRun is the outcome of one run of a schedule
+type ScheduleRun struct {
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Manual   bool          `json:"manual,omitempty"`  // Started with run-now
+	IsError  bool          `json:"isError,omitempty"` // The tool reported an error
+	Error    string        `json:"error,omitempty"`   // The call failed
+}
+
+// ScheduleStatus is a schedule as the daemon runs it
+type ScheduleStatus struct {
+	schedule.Schedule
+
+	Next       *time.Time      `json:"next,omitempty"` // Unset while disabled
+	Running    bool            `json:"running,omitempty"`
+	LastRun    *ScheduleRun    `json:"lastRun,omitempty"`
+	LastResult json.RawMessage `json:"lastResult,omitempty"` // The ToolResult of the last call that returned one
+	History    []ScheduleRun   `json:"history,omitempty"`    // Newest first, when asked for one schedule
+}
+
+// scheduleEntry is a schedule and what the scheduler knows about its runs
+type scheduleEntry struct {
+	schedule   schedule.Schedule
+	next       time.Time
+	running    bool
+	lastResult json.RawMessage
+	history    []ScheduleRun // Newest first
+}
+
+// scheduler runs the tool calls listed in schedules.json. Runs missed while
+// the daemon was down, or while the previous run was still going, are
+// skipped rather than made up.
+type scheduler struct {
+	mu      sync.Mutex
+	path    string // schedules.json; empty runs nothing
+	entries map[string]*scheduleEntry
+	wake    chan struct{}
+	call    func(schedule.Schedule) (*mcp.ToolResult, error)
+}
+
+// newScheduler creates a scheduler for the schedules at path that makes
+// calls with call
+func newScheduler(path string, call func(schedule.Schedule) (*mcp.ToolResult, error)) *scheduler {
+	return &scheduler{
+		path:    path,
+		entries: make(map[string]*scheduleEntry),
+		wake:    make(chan struct{}, 1),
+		call:    call,
+	}
+}
+
+// reload reads the schedules file again. Schedules that kept their
+// definition keep their next run; the outcomes of every kept schedule stay.
+func (s *scheduler) reload(now time.Time) error {
+	var schedules []schedule.Schedule
+	if s.path != "" {
+		var err error
+		if schedules, err = schedule.Load(s.path); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	entries := make(map[string]*scheduleEntry, len(schedules))
+	for _, sched := range schedules {
+		entry, exists := s.entries[sched.Name]
+		if !exists {
+			entry = &scheduleEntry{}
+		}
+		if !exists || !reflect.DeepEqual(entry.schedule, sched) {
+			entry.next = time.Time{}
+			if sched.IsEnabled() {
+				entry.next = sched.Next(now)
+			}
+		}
+		entry.schedule = sched
+		entries[sched.Name] = entry
+	}
+	s.entries = entries
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// loop runs schedules as they come due until shutdown is closed
+func (s *scheduler) loop(shutdown <-chan struct{}) {
+	for {
+		timer := time.NewTimer(s.untilNext(time.Now()))
+		select {
+		case <-timer.C:
+			s.runDue(time.Now())
+		case <-s.wake:
+			timer.Stop()
+		case <-shutdown:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// untilNext returns how long until the next schedule is due
+func (s *scheduler) untilNext(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wait := idleScheduleWait
+	for _, entry := range s.entries {
+		if entry.next.IsZero() {
+			continue
+		}
+		if until := entry.next.Sub(now); until < wait {
+			wait = until
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// runDue starts every schedule that is due. Each next run is counted from
+// now, so runs missed while the machine slept are skipped.
+func (s *scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, entry := range s.entries {
+		if entry.next.IsZero() || entry.next.After(now) {
+			continue
+		}
+		entry.next = entry.schedule.Next(now)
+		if entry.running {
+			log.Printf("Skipping schedule %s: its previous run has not finished", name)
+			continue
+		}
+		entry.running = true
+		go s.execute(entry, entry.schedule, false)
+	}
+}
+
+// runNow starts a schedule at once, whether or not it is enabled
+func (s *scheduler) runNow(name string) (ScheduleStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[name]
+	if !exists {
+		return ScheduleStatus{}, fmt.Errorf("schedule %s not found", name)
+	}
+	if entry.running {
+		return ScheduleStatus{}, fmt.Errorf("schedule %s is already running", name)
+	}
+	entry.running = true
+	go s.execute(entry, entry.schedule, true)
+	return entry.status(false), nil
+}
+
+// execute makes a schedule's call and records the outcome
+func (s *scheduler) execute(entry *scheduleEntry, sched schedule.Schedule, manual bool) {
+	run := ScheduleRun{Started: time.Now(), Manual: manual}
+	result, err := s.call(sched)
+	run.Duration = time.Since(run.Started)
+
+	var raw json.RawMessage
+	switch {
+	case err != nil:
+		run.Error = err.Error()
+		log.Printf("Schedule %s failed: %v", sched.Name, err)
+	default:
+		run.IsError = result.IsError
+		raw = result.Raw
+		if len(raw) == 0 {
+			raw, _ = json.Marshal(result)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.running = false
+	if raw != nil {
+		entry.lastResult = raw
+	}
+	entry.history = append([]ScheduleRun{run}, entry.history...)
+	if len(entry.history) > scheduleHistorySize {
+		entry.history = entry.history[:scheduleHistorySize]
+	}
+}
+
+// list returns every schedule, sorted by name, without their history
+func (s *scheduler) list() []ScheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]ScheduleStatus, 0, len(s.entries))
+	for _, entry := range s.entries {
+		statuses = append(statuses, entry.status(false))
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// get returns one schedule with its history
+func (s *scheduler) get(name string) (ScheduleStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[name]
+	if !exists {
+		return ScheduleStatus{}, fmt.Errorf("schedule %s not found", name)
+	}
+	return entry.status(true), nil
+}
+
+// status describes the entry; the caller holds the scheduler's lock
+func (e *scheduleEntry) status(withHistory bool) ScheduleStatus {
+	status := ScheduleStatus{
+		Schedule:   e.schedule,
+		Running:    e.running,
+		LastResult: e.lastResult,
+	}
+	if !e.next.IsZero() {
+		next := e.next
+		status.Next = &next
+	}
+	if len(e.history) > 0 {
+		last := e.history[0]
+		status.LastRun = &last
+	}
+	if withHistory {
+		status.History = append([]ScheduleRun(nil), e.history...)
+	}
+	return status
+}
+
+// runSchedule makes a schedule's call the way CallTool does, first starting
+// the server's session if the daemon has none. Scheduled calls always reach
+// the server rather than the result cache.
+func (d *Daemon) runSchedule(sched schedule.Schedule) (*mcp.ToolResult, error) {
+	if err := d.ensureSession(sched.Server); err != nil {
+		return nil, err
+	}
+	return d.callTool(sched.Server, sched.Tool, sched.Args, true, false, audit.SourceSchedule)
+}
+
+// ensureSession starts a session for the server from its configuration,
+// unless one is active, and waits for it
+func (d *Daemon) ensureSession(serverName string) error {
+	if _, err := d.GetSession(serverName); err == nil {
+		return nil
+	}
+
+	d.sessionMutex.RLock()
+	existing, exists := d.sessions[serverName]
+	starting := exists && existing.Status == SessionStatusStarting
+	d.sessionMutex.RUnlock()
+
+	if !starting {
+		serverConfig, err := d.serverConfig(serverName)
+		if err != nil {
+			return err
+		}
+		if err := d.StartSession(serverName, serverConfig); err != nil && !errors.Is(err, errSessionActive) {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), scheduleSessionWait)
+	defer cancel()
+	return d.awaitSession(ctx, serverName)
+}
+
+// loadServerConfig finds a server in the watched configuration, or else in
+// the configuration file the CLI would use
+func (d *Daemon) loadServerConfig(serverName string) (config.ServerConfig, error) {
+	cfg := d.mcpConfig.Load()
+	if cfg == nil {
+		var err error
+		if cfg, err = config.LoadConfig(config.GetConfigPath("")); err != nil {
+			return config.ServerConfig{}, fmt.Errorf("failed to load configuration: %w", err)
+		}
+	}
+
+	serverConfig, exists := cfg.GetServer(serverName)
+	if !exists {
+		return config.ServerConfig{}, mcp.Mark(suggest.Wrap(fmt.Errorf("server %s not found in configuration", serverName), serverName, cfg.GetServerNames()), mcp.ErrServerNotFound)
+	}
+	if !serverConfig.IsEnabled() {
+		return config.ServerConfig{}, fmt.Errorf("server %s is disabled", serverName)
+	}
+	return serverConfig, nil
+}
+
+// ListSchedules returns every schedule and how its runs went
+func (d *Daemon) ListSchedules() []ScheduleStatus {
+	return d.schedules.list()
+}
+
+// GetSchedule returns a schedule with the outcomes of its recent runs
+func (d *Daemon) GetSchedule(name string) (ScheduleStatus, error) {
+	return d.schedules.get(name)
+}
+
+// ReloadSchedules reads schedules.json again
+func (d *Daemon) ReloadSchedules() error {
+	return d.schedules.reload(time.Now())
+}
+
+// RunSchedule starts a schedule's call now, in the background
+func (d *Daemon) RunSchedule(name string) (ScheduleStatus, error) {
+	return d.schedules.runNow(name)
+}
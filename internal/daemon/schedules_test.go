@@ -0,0 +1,160 @@
+package daemon
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/schedule"
+)
+
+// newScheduleDaemon returns a test daemon running the given schedules, whose
+// servers are all configured
+func newScheduleDaemon(t *testing.T, schedules ...schedule.Schedule) *Daemon {
+	t.Helper()
+	d, _ := newTestDaemon(t)
+	path := filepath.Join(t.TempDir(), schedule.FileName)
+	if err := schedule.Save(path, schedules); err != nil {
+		t.Fatal(err)
+	}
+	d.schedules = newScheduler(path, d.runSchedule)
+	d.serverConfig = func(serverName string) (config.ServerConfig, error) {
+		if serverName == "missing" {
+			return config.ServerConfig{}, errors.New("server missing not found in configuration")
+		}
+		return config.ServerConfig{Command: serverName}, nil
+	}
+	if err := d.ReloadSchedules(); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// waitForRuns waits until a schedule has run n times
+func waitForRuns(t *testing.T, d *Daemon, name string, n int) ScheduleStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status, err := d.GetSchedule(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(status.History) >= n && !status.Running {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("schedule %s ran %d times, want %d", name, len(status.History), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRunScheduleStartsSessionAndRecordsResult(t *testing.T) {
+	d := newScheduleDaemon(t,
+		schedule.Schedule{Name: "digest", Server: "scraper", Tool: "summarize", Interval: "30m"},
+		schedule.Schedule{Name: "broken", Server: "missing", Tool: "summarize", Interval: "30m"},
+	)
+
+	if _, err := d.RunSchedule("digest"); err != nil {
+		t.Fatal(err)
+	}
+	status := waitForRuns(t, d, "digest", 1)
+	if status.LastRun == nil || status.LastRun.Error != "" || !status.LastRun.Manual {
+		t.Errorf("last run = %+v, want a successful manual run", status.LastRun)
+	}
+	if len(status.LastResult) == 0 {
+		t.Error("the call's result should be kept")
+	}
+	if _, err := d.GetSession("scraper"); err != nil {
+		t.Errorf("the schedule's session should have been started: %v", err)
+	}
+
+	if _, err := d.RunSchedule("broken"); err != nil {
+		t.Fatal(err)
+	}
+	status = waitForRuns(t, d, "broken", 1)
+	if !strings.Contains(status.LastRun.Error, "not found") || status.LastResult != nil {
+		t.Errorf("last run = %+v, want the missing server's error", status.LastRun)
+	}
+
+	if _, err := d.RunSchedule("nope"); err == nil {
+		t.Error("running an unknown schedule should fail")
+	}
+}
+
+func TestRunDueSkipsMissedRuns(t *testing.T) {
+	d := newScheduleDaemon(t, schedule.Schedule{Name: "digest", Server: "scraper", Tool: "summarize", Interval: "30m"})
+
+	before, _ := d.GetSchedule("digest")
+	if before.Next == nil {
+		t.Fatal("an enabled schedule should have a next run")
+	}
+
+	// The daemon slept through several runs: it runs once and plans from now
+	later := before.Next.Add(3 * time.Hour)
+	d.schedules.runDue(later)
+	status := waitForRuns(t, d, "digest", 1)
+	if want := later.Add(30 * time.Minute); !status.Next.Equal(want) {
+		t.Errorf("next run = %v, want %v", status.Next, want)
+	}
+	if len(status.History) != 1 {
+		t.Errorf("missed runs should be skipped, got %d runs", len(status.History))
+	}
+}
+
+func TestReloadSchedules(t *testing.T) {
+	disabled := false
+	d := newScheduleDaemon(t,
+		schedule.Schedule{Name: "digest", Server: "scraper", Tool: "summarize", Interval: "30m"},
+		schedule.Schedule{Name: "weekly", Server: "github", Tool: "report", Cron: "0 9 * * 1"},
+	)
+	if _, err := d.RunSchedule("digest"); err != nil {
+		t.Fatal(err)
+	}
+	waitForRuns(t, d, "digest", 1)
+	before, _ := d.GetSchedule("digest")
+
+	if err := schedule.Save(d.schedules.path, []schedule.Schedule{
+		{Name: "digest", Server: "scraper", Tool: "summarize", Interval: "30m"},
+		{Name: "nightly", Server: "github", Tool: "report", Cron: "0 2 * * *", Enabled: &disabled},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.ReloadSchedules(); err != nil {
+		t.Fatal(err)
+	}
+
+	schedules := d.ListSchedules()
+	if len(schedules) != 2 || schedules[0].Name != "digest" || schedules[1].Name != "nightly" {
+		t.Fatalf("schedules = %+v, want digest and nightly", schedules)
+	}
+	if !schedules[0].Next.Equal(*before.Next) || schedules[0].LastRun == nil {
+		t.Error("an unchanged schedule should keep its next run and outcomes")
+	}
+	if schedules[1].Next != nil {
+		t.Error("a disabled schedule should have no next run")
+	}
+	if schedules[0].History != nil {
+		t.Error("listing schedules should leave out their history")
+	}
+}
+
+func TestScheduleHistoryIsBounded(t *testing.T) {
+	s := newScheduler("", func(schedule.Schedule) (*mcp.ToolResult, error) {
+		return &mcp.ToolResult{IsError: true}, nil
+	})
+	entry := &scheduleEntry{schedule: schedule.Schedule{Name: "digest"}}
+	s.entries["digest"] = entry
+
+	for i := 0; i < scheduleHistorySize+5; i++ {
+		s.execute(entry, entry.schedule, false)
+	}
+	status, _ := s.get("digest")
+	if len(status.History) != scheduleHistorySize || !status.LastRun.IsError {
+		t.Errorf("history has %d runs, want %d with the tool's error flag", len(status.History), scheduleHistorySize)
+	}
+}
@@ -1,27 +1,56 @@
 package daemon
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/exports"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/suggest"
 )
 
 // setupRoutes configures the HTTP routes for the daemon
 func (d *Daemon) setupRoutes(mux *http.ServeMux) {
-	// Root endpoint for health check
-	mux.HandleFunc("/", d.handleHealth)
+	// Liveness, cheap enough to probe often, and the detailed status; the
+	// root path stays an alias of /status for one release
+	mux.HandleFunc("/healthz", d.handleHealthz)
+	mux.HandleFunc("/status", d.handleStatus)
+	mux.HandleFunc("/", d.handleStatus)
 
 	// Session management and tool execution endpoints (combined handler)
 	mux.HandleFunc("/sessions", d.handleSessionAndToolActions)
 	mux.HandleFunc("/sessions/", d.handleSessionAndToolActions)
+
+	// Background tool calls
+	mux.HandleFunc("/jobs/", d.handleJobAction)
+
+	// Scheduled tool calls
+	mux.HandleFunc("/schedules", d.handleSchedules)
+	mux.HandleFunc("/schedules/", d.handleScheduleAction)
+
+	// The daemon configuration in effect
+	mux.HandleFunc("/config", d.handleConfig)
+}
+
+// handleHealthz answers liveness probes without touching session state
+func (d *Daemon) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	d.writeJSONResponse(w, d.Health())
 }
 
-// handleHealth handles the health check endpoint
-func (d *Daemon) handleHealth(w http.ResponseWriter, r *http.Request) {
+// handleStatus reports the daemon's status with every session's
+func (d *Daemon) handleStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -109,6 +138,10 @@ func (d *Daemon) handleSessionAction(w http.ResponseWriter, r *http.Request, ser
 			d.handleStartSession(w, r, serverName)
 		case "tools":
 			d.handleListSessionTools(w, r, serverName)
+		case "jobs":
+			d.handleSubmitJob(w, r, serverName)
+		case "reset-stats":
+			d.handleResetSessionStats(w, r, serverName)
 		default:
 			http.Error(w, "Invalid session action", http.StatusBadRequest)
 		}
@@ -124,7 +157,9 @@ func (d *Daemon) handleSessionAction(w http.ResponseWriter, r *http.Request, ser
 	}
 }
 
-// handleStartSession starts a new session
+// handleStartSession starts a new session. With waitForActive it answers
+// once the session is active or has failed, or the wait is over, reporting
+// the session.
 func (d *Daemon) handleStartSession(w http.ResponseWriter, r *http.Request, serverName string) {
 	var req struct {
 		Config config.ServerConfig `json:"config"`
@@ -138,27 +173,38 @@ func (d *Daemon) handleStartSession(w http.ResponseWriter, r *http.Request, serv
 		return
 	}
 
+	wait, err := parseWaitForActive(r.URL.Query().Get("waitForActive"))
+	if err != nil {
+		d.writeJSONResponse(w, errorResponse(err))
+		return
+	}
+
 	if err := d.StartSession(serverName, req.Config); err != nil {
+		// Waiting for a session someone else started is as good as starting it
+		if wait == 0 || !errors.Is(err, errSessionActive) {
+			d.writeJSONResponse(w, errorResponse(err))
+			return
+		}
+	}
+
+	if wait == 0 {
 		d.writeJSONResponse(w, APIResponse{
-			Success: false,
-			Error:   err.Error(),
+			Success: true,
+			Data:    map[string]string{"message": "Session starting", "server": serverName},
 		})
 		return
 	}
 
-	d.writeJSONResponse(w, APIResponse{
-		Success: true,
-		Data:    map[string]string{"message": "Session starting", "server": serverName},
-	})
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	_ = d.awaitSession(ctx, serverName)
+	d.handleGetSession(w, r, serverName)
 }
 
 // handleStopSession stops a session
 func (d *Daemon) handleStopSession(w http.ResponseWriter, r *http.Request, serverName string) {
 	if err := d.StopSession(serverName); err != nil {
-		d.writeJSONResponse(w, APIResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		d.writeJSONResponse(w, errorResponse(err))
 		return
 	}
 
@@ -168,25 +214,21 @@ func (d *Daemon) handleStopSession(w http.ResponseWriter, r *http.Request, serve
 	})
 }
 
-// handleGetSession gets session information
-func (d *Daemon) handleGetSession(w http.ResponseWriter, r *http.Request, serverName string) {
-	session, err := d.GetSession(serverName)
-	if err != nil {
-		d.writeJSONResponse(w, APIResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+// handleResetSessionStats clears a session's metrics and reports the session
+func (d *Daemon) handleResetSessionStats(w http.ResponseWriter, r *http.Request, serverName string) {
+	if err := d.ResetSessionStats(serverName); err != nil {
+		d.writeJSONResponse(w, errorResponse(err))
 		return
 	}
+	d.handleGetSession(w, r, serverName)
+}
 
-	info := SessionInfo{
-		ServerName: session.ServerName,
-		Status:     session.Status.String(),
-		StartTime:  session.StartTime,
-		LastUsed:   session.LastUsed,
-		Duration:   session.LastUsed.Sub(session.StartTime),
-		Error:      session.Error,
-		PID:        session.PID,
+// handleGetSession gets session information, whatever the session's status
+func (d *Daemon) handleGetSession(w http.ResponseWriter, r *http.Request, serverName string) {
+	info, err := d.sessionInfo(serverName)
+	if err != nil {
+		d.writeJSONResponse(w, errorResponse(err))
+		return
 	}
 
 	d.writeJSONResponse(w, APIResponse{
@@ -199,28 +241,47 @@ func (d *Daemon) handleGetSession(w http.ResponseWriter, r *http.Request, server
 func (d *Daemon) handleListSessionTools(w http.ResponseWriter, r *http.Request, serverName string) {
 	tools, err := d.ListTools(serverName)
 	if err != nil {
-		d.writeJSONResponse(w, APIResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		d.writeJSONResponse(w, errorResponse(err))
 		return
 	}
 
+	if policy := d.daemonExports(); policy != nil {
+		exported := []mcp.Tool{}
+		for _, tool := range tools {
+			if policy.Allows(exports.KindTool, serverName, tool.Name) {
+				exported = append(exported, tool)
+			}
+		}
+		tools = exported
+	}
+
 	d.writeJSONResponse(w, APIResponse{
 		Success: true,
 		Data:    tools,
 	})
 }
 
-// handleToolCall handles tool execution operations
+// handleToolCall handles tool execution operations. With ?stream=1 the
+// call is answered with server-sent events instead.
 func (d *Daemon) handleToolCall(w http.ResponseWriter, r *http.Request, serverName, toolName string) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	if err := d.daemonExports().Check(exports.KindTool, serverName, toolName); err != nil {
+		d.writeJSONResponse(w, errorResponse(err))
+		return
+	}
+
 	var req struct {
-		Args map[string]interface{} `json:"args"`
+		Args       map[string]interface{} `json:"args"`
+		NoDefaults bool                   `json:"noDefaults,omitempty"` // Skip the server's toolDefaults
+		NoCache    bool                   `json:"noCache,omitempty"`    // Call the server even if the result is cached
+
+		TimeoutSeconds int `json:"timeoutSeconds,omitempty"` // How long the call may take, up to maxToolTimeout; 0 is the default
+
+		AuditArgs map[string]interface{} `json:"auditArgs,omitempty"` // Record the call with these, such as args before environment variables were expanded
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -231,17 +292,199 @@ func (d *Daemon) handleToolCall(w http.ResponseWriter, r *http.Request, serverNa
 		return
 	}
 
-	result, err := d.CallTool(serverName, toolName, req.Args)
+	if req.AuditArgs != nil {
+		r = r.WithContext(audit.WithRecordedArgs(r.Context(), req.AuditArgs))
+	}
+	timeout := d.toolTimeout(req.TimeoutSeconds)
+	if r.URL.Query().Get("stream") == "1" {
+		d.streamToolCall(w, r, serverName, toolName, req.Args, !req.NoDefaults, !req.NoCache, timeout)
+		return
+	}
+
+	// The client disconnecting cancels the call. The answer may come later
+	// than the server's write timeout allows, so allow for the call.
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 30*time.Second))
+
+	result, err := d.callToolContext(ctx, serverName, toolName, req.Args, !req.NoDefaults, !req.NoCache, audit.SourceDaemon)
 	if err != nil {
+		d.writeJSONResponse(w, errorResponse(d.suggestTool(serverName, toolName, err)))
+		return
+	}
+
+	// Pass the server's result on without re-encoding it
+	var data interface{} = result
+	if result != nil && len(result.Raw) > 0 {
+		data = result.Raw
+	}
+	d.writeJSONResponse(w, APIResponse{
+		Success: true,
+		Data:    data,
+	})
+}
+
+// suggestTool marks err, the failure of calling toolName, as the tool not
+// being found when the session has no such tool, adding a did-you-mean hint.
+// Only tools the export policy allows are suggested.
+func (d *Daemon) suggestTool(serverName, toolName string, err error) error {
+	tools, listErr := d.ListTools(serverName)
+	if listErr != nil {
+		return err
+	}
+	policy := d.daemonExports()
+	var names []string
+	for _, tool := range tools {
+		if tool.Name == toolName {
+			return err
+		}
+		if policy.Allows(exports.KindTool, serverName, tool.Name) {
+			names = append(names, tool.Name)
+		}
+	}
+	return mcp.Mark(suggest.Wrap(err, toolName, names), mcp.ErrToolNotFound)
+}
+
+// handleSubmitJob queues a tool call as a job
+func (d *Daemon) handleSubmitJob(w http.ResponseWriter, r *http.Request, serverName string) {
+	var req struct {
+		Tool       string                 `json:"tool"`
+		Args       map[string]interface{} `json:"args"`
+		NoDefaults bool                   `json:"noDefaults,omitempty"` // Skip the server's toolDefaults
+		NoCache    bool                   `json:"noCache,omitempty"`    // Call the server even if the result is cached
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		d.writeJSONResponse(w, APIResponse{
 			Success: false,
-			Error:   err.Error(),
+			Error:   fmt.Sprintf("Invalid request body: %v", err),
 		})
 		return
 	}
+	if req.Tool == "" {
+		d.writeJSONResponse(w, APIResponse{
+			Success: false,
+			Error:   "Invalid request body: no tool",
+		})
+		return
+	}
+
+	if err := d.daemonExports().Check(exports.KindTool, serverName, req.Tool); err != nil {
+		d.writeJSONResponse(w, errorResponse(err))
+		return
+	}
+
+	job, err := d.SubmitJob(serverName, req.Tool, req.Args, !req.NoDefaults)
+	if err != nil {
+		d.writeJSONResponse(w, errorResponse(err))
+		return
+	}
+
+	d.writeJSONResponse(w, APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// handleJobAction reports on a job (GET /jobs/{id}) or cancels it
+// (DELETE /jobs/{id})
+func (d *Daemon) handleJobAction(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/")
+	if id == "" || strings.Contains(id, "/") {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var job Job
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		job, err = d.GetJob(id)
+	case http.MethodDelete:
+		job, err = d.CancelJob(id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err != nil {
+		d.writeJSONResponse(w, errorResponse(err))
+		return
+	}
+
+	d.writeJSONResponse(w, APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// handleSchedules lists the schedules (GET /schedules) or reloads them from
+// schedules.json (POST /schedules)
+func (d *Daemon) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := d.ReloadSchedules(); err != nil {
+			d.writeJSONResponse(w, errorResponse(err))
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	d.writeJSONResponse(w, APIResponse{
+		Success: true,
+		Data:    d.ListSchedules(),
+	})
+}
+
+// handleConfig reports the daemon configuration in effect (GET /config) or
+// reloads it from daemon.json (POST /config)
+func (d *Daemon) handleConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		d.writeJSONResponse(w, APIResponse{Success: true, Data: d.Config()})
+	case http.MethodPost:
+		loaded, err := d.ReloadConfig()
+		if err != nil {
+			d.writeJSONResponse(w, errorResponse(err))
+			return
+		}
+		d.writeJSONResponse(w, APIResponse{Success: true, Data: loaded})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleScheduleAction reports on a schedule with its history
+// (GET /schedules/{name}) or runs it now (POST /schedules/{name}/run)
+func (d *Daemon) handleScheduleAction(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/schedules/"), "/"), "/")
+	if parts[0] == "" {
+		d.handleSchedules(w, r)
+		return
+	}
+
+	var status ScheduleStatus
+	var err error
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 1:
+		status, err = d.GetSchedule(parts[0])
+	case r.Method == http.MethodPost && len(parts) == 2 && parts[1] == "run":
+		status, err = d.RunSchedule(parts[0])
+	default:
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		d.writeJSONResponse(w, errorResponse(err))
+		return
+	}
 
 	d.writeJSONResponse(w, APIResponse{
 		Success: true,
-		Data:    result,
+		Data:    status,
 	})
 }
@@ -0,0 +1,206 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/audit"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// streamBuffer is how many events a stream holds for a client that has not
+// read them yet. A client further behind has its call cancelled.
+const streamBuffer = 64
+
+// errStreamOverflow fails a streamed call whose client stopped reading
+var errStreamOverflow = errors.New("client fell behind the tool's output stream")
+
+// toolStream carries the notifications of one streamed tool call
+type toolStream struct {
+	serverName string
+	token      string // The progress token the call was sent with
+	events     chan StreamEvent
+	cancel     context.CancelFunc // Cancels the call
+	overflowed atomic.Bool
+}
+
+// send queues event without blocking the session's read path. A full queue
+// means the client is not keeping up, so the call is cancelled.
+func (s *toolStream) send(event StreamEvent) {
+	select {
+	case s.events <- event:
+	default:
+		s.overflowed.Store(true)
+		s.cancel()
+	}
+}
+
+// streamHub routes servers' notifications to the tool calls streaming them
+type streamHub struct {
+	mu      sync.Mutex
+	next    uint64
+	streams map[string]*toolStream // By progress token
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{streams: make(map[string]*toolStream)}
+}
+
+// open registers a stream for a call to serverName; cancel cancels the call
+func (h *streamHub) open(serverName string, cancel context.CancelFunc) *toolStream {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.next++
+	stream := &toolStream{
+		serverName: serverName,
+		token:      fmt.Sprintf("daemon-stream-%d", h.next),
+		events:     make(chan StreamEvent, streamBuffer),
+		cancel:     cancel,
+	}
+	h.streams[stream.token] = stream
+	return stream
+}
+
+// close stops routing notifications to stream
+func (h *streamHub) close(stream *toolStream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.streams, stream.token)
+}
+
+// notify hands a server's notification to the streams it concerns: progress
+// to the call whose token it carries, log messages to every call streaming
+// from the server. It runs on the session's read path and never blocks.
+func (h *streamHub) notify(serverName, method string, params json.RawMessage) {
+	var event StreamEvent
+	switch method {
+	case mcp.Progress:
+		var progress mcp.ProgressParams
+		if json.Unmarshal(params, &progress) != nil {
+			return
+		}
+		event = StreamEvent{Type: StreamEventProgress, Progress: &progress}
+	case mcp.LoggingMessage:
+		var message mcp.LoggingMessageParams
+		if json.Unmarshal(params, &message) != nil {
+			return
+		}
+		event = StreamEvent{Type: StreamEventMessage, Message: &message}
+	default:
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if event.Progress != nil {
+		if stream, ok := h.streams[fmt.Sprint(event.Progress.ProgressToken)]; ok && stream.serverName == serverName {
+			stream.send(event)
+		}
+		return
+	}
+	for _, stream := range h.streams {
+		if stream.serverName == serverName {
+			stream.send(event)
+		}
+	}
+}
+
+// streamToolCall answers a tool call with server-sent events: the server's
+// progress and log messages as they arrive, then the result or the error.
+// The call is cancelled when the client disconnects or falls behind, or
+// after timeout.
+func (d *Daemon) streamToolCall(w http.ResponseWriter, r *http.Request, serverName, toolName string, args map[string]interface{}, applyDefaults, useCache bool, timeout time.Duration) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		d.writeJSONResponse(w, APIResponse{
+			Success: false,
+			Error:   "streaming is not supported on this connection",
+		})
+		return
+	}
+
+	// The stream lasts as long as the call, past the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	stream := d.streams.open(serverName, cancel)
+	defer d.streams.close(stream)
+
+	type outcome struct {
+		result *mcp.ToolResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := d.callToolContext(mcp.WithProgressToken(ctx, stream.token), serverName, toolName, args, applyDefaults, useCache, audit.SourceDaemon)
+		done <- outcome{result, err}
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case event := <-stream.events:
+			if err := writeStreamEvent(w, flusher, event); err != nil {
+				return // The client is gone; returning cancels the call
+			}
+
+		case out := <-done:
+			// Events sent before the result go out first
+			for pending := true; pending; {
+				select {
+				case event := <-stream.events:
+					if err := writeStreamEvent(w, flusher, event); err != nil {
+						return
+					}
+				default:
+					pending = false
+				}
+			}
+
+			final := StreamEvent{Type: StreamEventResult}
+			switch {
+			case out.err != nil && stream.overflowed.Load():
+				final = StreamEvent{Type: StreamEventError, Error: errStreamOverflow.Error()}
+			case out.err != nil:
+				resp := d.redactResponse(errorResponse(d.suggestTool(serverName, toolName, out.err)))
+				final = StreamEvent{Type: StreamEventError, Error: resp.Error, Kind: resp.Kind, RPCError: resp.RPCError}
+			case out.result != nil && len(out.result.Raw) > 0:
+				// Pass the server's result on without re-encoding it
+				final.Result = out.result.Raw
+			default:
+				if data, err := json.Marshal(out.result); err != nil {
+					final = StreamEvent{Type: StreamEventError, Error: fmt.Sprintf("Failed to encode result: %v", err)}
+				} else {
+					final.Result = data
+				}
+			}
+			_ = writeStreamEvent(w, flusher, final)
+			return
+		}
+	}
+}
+
+// writeStreamEvent writes event as a server-sent event and flushes it
+func writeStreamEvent(w http.ResponseWriter, flusher http.Flusher, event StreamEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
@@ -0,0 +1,206 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// progressClient is a stub client whose tool reports progress in stages,
+// each sent once the test releases it, and then waits for release before
+// answering
+type progressClient struct {
+	stubClient
+	stages    int
+	release   chan struct{}
+	cancelled atomic.Bool
+
+	mu      sync.Mutex
+	handler mcp.NotificationHandler
+}
+
+func (c *progressClient) SetNotificationHandler(handler mcp.NotificationHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = handler
+}
+
+func (c *progressClient) notify(method string, params interface{}) {
+	data, _ := json.Marshal(params)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler(method, data)
+}
+
+func (c *progressClient) CallTool(ctx context.Context, _ string, _ map[string]interface{}) (*mcp.ToolResult, error) {
+	token := mcp.ProgressToken(ctx)
+	c.notify(mcp.LoggingMessage, mcp.LoggingMessageParams{Level: "info", Data: json.RawMessage(`"crawling"`)})
+	for stage := 0; stage <= c.stages; stage++ {
+		select {
+		case <-c.release:
+		case <-ctx.Done():
+			c.cancelled.Store(true)
+			return nil, ctx.Err()
+		}
+		if stage < c.stages {
+			c.notify(mcp.Progress, mcp.ProgressParams{ProgressToken: token, Progress: float64(stage + 1), Total: float64(c.stages), Message: fmt.Sprintf("page %d", stage+1)})
+		}
+	}
+	return &mcp.ToolResult{Content: []interface{}{map[string]interface{}{"type": "text", "text": "done"}}}, nil
+}
+
+// newStreamingDaemon serves a daemon whose "crawler" session runs backend,
+// with a client for it
+func newStreamingDaemon(t *testing.T, backend *progressClient) *DaemonClient {
+	t.Helper()
+	d, _ := newTestDaemon(t)
+	d.clientFactory = func(config.ServerConfig) (mcp.MCPClient, error) { return backend, nil }
+	if err := d.StartSession("crawler", config.ServerConfig{Command: "crawler"}); err != nil {
+		t.Fatal(err)
+	}
+	waitForActive(t, d, "crawler")
+
+	mux := http.NewServeMux()
+	d.setupRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return newTestDaemonClient(t, server)
+}
+
+// nextEvent waits for the stream's next event
+func nextEvent(t *testing.T, events <-chan StreamEvent) StreamEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("stream closed early")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event within 5s")
+	}
+	return StreamEvent{}
+}
+
+func TestCallToolStreamDeliversStagedProgress(t *testing.T) {
+	backend := &progressClient{stages: 3, release: make(chan struct{})}
+	dc := newStreamingDaemon(t, backend)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	events, err := dc.CallToolStream(ctx, "crawler", "crawl", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if event := nextEvent(t, events); event.Type != StreamEventMessage || string(event.Message.Data) != `"crawling"` {
+		t.Errorf("want the log message first, got %+v", event)
+	}
+	// Each stage arrives before the backend moves on to the next
+	for stage := 1; stage <= 3; stage++ {
+		backend.release <- struct{}{}
+		event := nextEvent(t, events)
+		if event.Type != StreamEventProgress || event.Progress.Progress != float64(stage) || event.Progress.Total != 3 {
+			t.Fatalf("stage %d: unexpected event %+v", stage, event)
+		}
+		if want := fmt.Sprintf("page %d", stage); event.Progress.Message != want {
+			t.Errorf("stage %d: message %q, want %q", stage, event.Progress.Message, want)
+		}
+	}
+
+	backend.release <- struct{}{}
+	final := nextEvent(t, events)
+	if final.Type != StreamEventResult {
+		t.Fatalf("want the result last, got %+v", final)
+	}
+	var result mcp.ToolResult
+	if err := json.Unmarshal(final.Result, &result); err != nil || len(result.Blocks()) != 1 || result.Blocks()[0].Text != "done" {
+		t.Errorf("unexpected result %s (%v)", final.Result, err)
+	}
+	if _, ok := <-events; ok {
+		t.Error("stream should close after the result")
+	}
+}
+
+func TestDaemonMCPClientStreamsProgress(t *testing.T) {
+	backend := &progressClient{stages: 2, release: make(chan struct{}, 3)}
+	for i := 0; i < 3; i++ {
+		backend.release <- struct{}{}
+	}
+	dc := newStreamingDaemon(t, backend)
+
+	var seen []string
+	dm := NewDaemonMCPClient(dc, "crawler")
+	dm.StreamProgress(func(event StreamEvent) { seen = append(seen, event.Type) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := dm.CallTool(ctx, "crawl", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Blocks()) != 1 || result.Blocks()[0].Text != "done" || len(result.Raw) == 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if got := strings.Join(seen, ","); got != "message,progress,progress" {
+		t.Errorf("handler saw %s", got)
+	}
+}
+
+func TestCallToolStreamDisconnectCancelsCall(t *testing.T) {
+	backend := &progressClient{stages: 3, release: make(chan struct{})}
+	dc := newStreamingDaemon(t, backend)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := dc.CallToolStream(ctx, "crawler", "crawl", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nextEvent(t, events) // The call is running
+	cancel()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !backend.cancelled.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("the backend call was not cancelled after the client went away")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamHubCancelsCallsThatFallBehind(t *testing.T) {
+	hub := newStreamHub()
+	var cancelled atomic.Bool
+	stream := hub.open("crawler", func() { cancelled.Store(true) })
+	other := hub.open("other", func() { t.Error("another server's stream was cancelled") })
+
+	params, _ := json.Marshal(mcp.ProgressParams{ProgressToken: stream.token, Progress: 1})
+	for i := 0; i < streamBuffer; i++ {
+		hub.notify("crawler", mcp.Progress, params)
+	}
+	if cancelled.Load() {
+		t.Fatal("cancelled before the buffer was full")
+	}
+	hub.notify("crawler", mcp.Progress, params)
+	if !cancelled.Load() || !stream.overflowed.Load() {
+		t.Error("want the call cancelled once the client fell behind")
+	}
+
+	// Progress only reaches the stream its token names, on its own server
+	hub.notify("other", mcp.Progress, params)
+	if len(other.events) != 0 {
+		t.Errorf("other stream got %d events", len(other.events))
+	}
+	hub.close(stream)
+	hub.close(other)
+}
@@ -1,10 +1,15 @@
 package daemon
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/sampling"
+	"github.com/mcp-cli-ent/mcp-cli/internal/session"
 )
 
 // SessionStatus represents the current status of a daemon session
@@ -35,17 +40,76 @@ func (s SessionStatus) String() string {
 	}
 }
 
+// MarshalJSON encodes the status as its string name
+func (s SessionStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON decodes a status from its string name, or from the legacy integer encoding
+func (s *SessionStatus) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var legacy int
+		if legacyErr := json.Unmarshal(data, &legacy); legacyErr != nil {
+			return fmt.Errorf("invalid session status: %s", string(data))
+		}
+		*s = SessionStatus(legacy)
+		return nil
+	}
+
+	for candidate := SessionStatusInactive; candidate <= SessionStatusError; candidate++ {
+		if candidate.String() == name {
+			*s = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown session status: %s", name)
+}
+
 // PersistentSession represents a session managed by the daemon
 type PersistentSession struct {
-	ServerName string                `json:"serverName"`
-	Client     mcp.MCPClient         `json:"-"`
-	Status     SessionStatus         `json:"status"`
-	Config     config.ServerConfig   `json:"config"`
-	LastUsed   time.Time             `json:"lastUsed"`
-	StartTime  time.Time             `json:"startTime"`
-	Error      string                `json:"error,omitempty"`
-	ToolCache  map[string][]mcp.Tool `json:"-"`
-	PID        int                   `json:"pid,omitempty"`
+	ServerName string              `json:"serverName"`
+	Client     mcp.MCPClient       `json:"-"`
+	Status     SessionStatus       `json:"status"`
+	Config     config.ServerConfig `json:"config"`
+	LastUsed   time.Time           `json:"lastUsed"`
+	StartTime  time.Time           `json:"startTime"`
+	Error      string              `json:"error,omitempty"`
+	ListCache  *listCache          `json:"-"` // The server's lists, such as its tools
+	PID        int                 `json:"pid,omitempty"`
+
+	ConfigOutdated bool `json:"configOutdated,omitempty"` // Config changed on disk; restart on next use
+
+	started chan struct{}  // Closed once the current start attempt succeeded or failed
+	running []*runningCall // Tool calls in progress, oldest first
+
+	session.SessionMetrics
+}
+
+// runningCall is a tool call in progress
+type runningCall struct {
+	tool  string
+	since time.Time
+}
+
+// finishCall forgets a call that returned. The caller holds sessionMutex.
+func (s *PersistentSession) finishCall(call *runningCall) {
+	for i, running := range s.running {
+		if running == call {
+			s.running = append(s.running[:i], s.running[i+1:]...)
+			return
+		}
+	}
+}
+
+// noteRunning reports the session's calls in progress in info, naming the
+// longest-running one, so stuck calls show. The caller holds sessionMutex.
+func (s *PersistentSession) noteRunning(info *SessionInfo, now time.Time) {
+	info.RunningCalls = len(s.running)
+	if len(s.running) > 0 {
+		info.RunningTool = s.running[0].tool
+		info.RunningFor = now.Sub(s.running[0].since)
+	}
 }
 
 // SessionInfo represents session information for API responses
@@ -57,6 +121,24 @@ type SessionInfo struct {
 	Duration   time.Duration `json:"duration"`
 	Error      string        `json:"error,omitempty"`
 	PID        int           `json:"pid,omitempty"`
+
+	ConfigOutdated bool `json:"configOutdated,omitempty"`
+
+	RunningTool  string        `json:"runningTool,omitempty"`  // The longest-running tool call in progress
+	RunningFor   time.Duration `json:"runningFor,omitempty"`   // How long it has been running
+	RunningCalls int           `json:"runningCalls,omitempty"` // Tool calls in progress
+
+	session.SessionMetrics
+	ListCacheStats
+}
+
+// HealthStatus is the daemon's answer to GET /healthz
+type HealthStatus struct {
+	OK      bool    `json:"ok"`
+	Version string  `json:"version"`
+	Name    string  `json:"name"` // The daemon instance; empty is the shared one
+	PID     int     `json:"pid"`
+	Uptime  float64 `json:"uptime"` // Seconds
 }
 
 // DaemonStatus represents the overall daemon status
@@ -67,9 +149,12 @@ type DaemonStatus struct {
 	SessionCount   int           `json:"sessionCount"`
 	ActiveSessions []SessionInfo `json:"activeSessions"`
 	PID            int           `json:"pid"`
+	Name           string        `json:"name,omitempty"` // The daemon instance; empty is the shared one
 	Endpoint       string        `json:"endpoint"`
 	Platform       string        `json:"platform"`
 	Error          string        `json:"error,omitempty"`
+
+	Schedules []ScheduleStatus `json:"schedules,omitempty"`
 }
 
 // APIRequest represents a daemon API request
@@ -85,15 +170,94 @@ type APIResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+
+	// Kind and RPCError let clients rebuild the error; see errorResponse
+	Kind     string            `json:"kind,omitempty"`     // The mcp.Kind of the error
+	RPCError *mcp.JSONRPCError `json:"rpcError,omitempty"` // The server's own error, when it answered one
 }
 
+// errorResponse is the answer to a request that failed with err
+func errorResponse(err error) APIResponse {
+	resp := APIResponse{Success: false, Error: err.Error(), Kind: mcp.Kind(err)}
+	errors.As(err, &resp.RPCError)
+	return resp
+}
+
+// rawAPIResponse is how clients read an APIResponse: the data stays encoded
+// until it is decoded, once, into the type the caller expects
+type rawAPIResponse struct {
+	Success  bool              `json:"success"`
+	Data     json.RawMessage   `json:"data,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Kind     string            `json:"kind,omitempty"`
+	RPCError *mcp.JSONRPCError `json:"rpcError,omitempty"`
+}
+
+// err rebuilds the error a failed response reports
+func (r *rawAPIResponse) err() error {
+	return apiError(r.Error, r.Kind, r.RPCError)
+}
+
+// DaemonError is a failure the daemon reported. It unwraps to the server's
+// JSON-RPC error, if there was one, and matches the mcp sentinel the daemon's
+// own error did.
+type DaemonError struct {
+	Message  string
+	RPCError *mcp.JSONRPCError
+}
+
+func (e *DaemonError) Error() string {
+	return "daemon error: " + e.Message
+}
+
+func (e *DaemonError) Unwrap() error {
+	if e.RPCError == nil {
+		return nil
+	}
+	return e.RPCError
+}
+
+// apiError rebuilds an error the daemon reported from its parts
+func apiError(message, kind string, rpcErr *mcp.JSONRPCError) error {
+	return mcp.Mark(&DaemonError{Message: message, RPCError: rpcErr}, mcp.KindError(kind))
+}
+
+// StreamEvent is one event of a tool call streamed with ?stream=1: progress
+// and log messages as the server sends them, then the result or an error
+type StreamEvent struct {
+	Type     string                    `json:"type"` // One of the StreamEvent* types
+	Progress *mcp.ProgressParams       `json:"progress,omitempty"`
+	Message  *mcp.LoggingMessageParams `json:"message,omitempty"`
+	Result   json.RawMessage           `json:"result,omitempty"`
+	Error    string                    `json:"error,omitempty"`
+	Kind     string                    `json:"kind,omitempty"`     // Of the error, as in APIResponse
+	RPCError *mcp.JSONRPCError         `json:"rpcError,omitempty"` // Of the error, as in APIResponse
+
+	err error // Why the client lost the stream, if it did
+}
+
+// Stream event types
+const (
+	StreamEventProgress = "progress"
+	StreamEventMessage  = "message"
+	StreamEventResult   = "result"
+	StreamEventError    = "error"
+)
+
 // DaemonConfig represents daemon configuration
 type DaemonConfig struct {
 	Enabled     bool   `json:"enabled"`
 	AutoStart   bool   `json:"autoStart"`
 	LogLevel    string `json:"logLevel"`
-	MaxIdleTime int    `json:"maxIdleTime"`
+	MaxIdleTime int    `json:"maxIdleTime"` // Seconds; 0 or less never expires
 	MaxSessions int    `json:"maxSessions"`
+	Address     string `json:"address,omitempty"` // Loopback address to listen on instead of the one derived from the instance name
+
+	JobRetention   int    `json:"jobRetention"`   // Seconds a finished job is kept (default 3600)
+	MaxToolTimeout int    `json:"maxToolTimeout"` // Most seconds a tool call may ask for (default 600); 0 is no limit
+	JobSpillDir    string `json:"jobSpillDir"`    // Write finished jobs' results here instead of keeping them in memory
+
+	Sampling *sampling.Config `json:"sampling,omitempty"` // Answers servers' sampling requests with an LLM
 }
 
 // DefaultDaemonConfig returns default daemon configuration
@@ -104,5 +268,8 @@ func DefaultDaemonConfig() *DaemonConfig {
 		LogLevel:    "info",
 		MaxIdleTime: 3600, // 1 hour
 		MaxSessions: 10,
+
+		JobRetention:   3600, // 1 hour
+		MaxToolTimeout: 600,  // 10 minutes
 	}
 }
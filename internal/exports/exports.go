@@ -0,0 +1,121 @@
+// Package exports enforces the configuration's "exports" section: the subset
+// of tools, prompts and resources published to other agents. The serve
+// command and the daemon API share one Policy so they can't disagree.
+package exports
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// Kinds of exported items
+const (
+	KindTool     = "tool"
+	KindPrompt   = "prompt"
+	KindResource = "resource"
+)
+
+// Policy decides what is exported. A nil Policy exports everything.
+type Policy struct {
+	rules  map[string][]rule // Kind to the entries allowing it
+	daemon bool
+	within *Policy // A project config's narrower policy, also required
+}
+
+// rule is one compiled "server/glob" entry
+type rule struct {
+	server *regexp.Regexp
+	name   *regexp.Regexp
+}
+
+// DeniedError reports an item the export policy does not publish
+type DeniedError struct {
+	Kind   string
+	Server string
+	Name   string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("%s '%s/%s' is not exported by the export policy (the \"exports\" section of the configuration)", e.Kind, e.Server, e.Name)
+}
+
+// New compiles the configuration's exports section. Without one, it returns
+// nil, which exports everything. Items must also pass the exports a project
+// config narrowed it with, if any.
+func New(cfg *config.ExportsConfig) (*Policy, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	p := &Policy{rules: make(map[string][]rule), daemon: cfg.Daemon}
+	for kind, entries := range map[string][]string{
+		KindTool:     cfg.Tools,
+		KindPrompt:   cfg.Prompts,
+		KindResource: cfg.Resources,
+	} {
+		for _, entry := range entries {
+			server, name, ok := strings.Cut(entry, "/")
+			if !ok || server == "" || name == "" {
+				return nil, fmt.Errorf("invalid %s export %q: use \"server/pattern\"", kind, entry)
+			}
+			p.rules[kind] = append(p.rules[kind], rule{server: compileGlob(server), name: compileGlob(name)})
+		}
+	}
+	within, err := New(cfg.Within)
+	if err != nil {
+		return nil, err
+	}
+	p.within = within
+	return p, nil
+}
+
+// compileGlob turns a glob, where "*" matches any run of characters and "?"
+// any one character, into an anchored regular expression
+func compileGlob(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// Allows reports whether the server's item of the given kind is exported
+func (p *Policy) Allows(kind, server, name string) bool {
+	if p == nil {
+		return true
+	}
+	if !p.within.Allows(kind, server, name) {
+		return false
+	}
+	for _, r := range p.rules[kind] {
+		if r.server.MatchString(server) && r.name.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns a *DeniedError if the item is not exported
+func (p *Policy) Check(kind, server, name string) error {
+	if p.Allows(kind, server, name) {
+		return nil
+	}
+	return &DeniedError{Kind: kind, Server: server, Name: name}
+}
+
+// AppliesToDaemon reports whether the daemon API enforces the policy
+func (p *Policy) AppliesToDaemon() bool {
+	return p != nil && p.daemon
+}
@@ -0,0 +1,99 @@
+package exports
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestPolicyAllows(t *testing.T) {
+	policy, err := New(&config.ExportsConfig{
+		Tools:     []string{"github/search_*", "context7/*", "*/ping"},
+		Prompts:   []string{"github/review"},
+		Resources: []string{"docs/file:///srv/docs/*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		kind, server, name string
+		want               bool
+	}{
+		{KindTool, "github", "search_issues", true},
+		{KindTool, "github", "search_", true},
+		{KindTool, "github", "delete_repo", false},
+		{KindTool, "gitlab", "search_issues", false},
+		{KindTool, "context7", "resolve-library-id", true},
+		{KindTool, "anything", "ping", true},
+		{KindTool, "anything", "ping2", false},
+		{KindPrompt, "github", "review", true},
+		{KindPrompt, "github", "review-all", false},
+		{KindPrompt, "context7", "summarize", false}, // Tool entries don't export prompts
+		{KindResource, "docs", "file:///srv/docs/guides/intro.md", true},
+		{KindResource, "docs", "file:///etc/passwd", false},
+	}
+	for _, tt := range tests {
+		if got := policy.Allows(tt.kind, tt.server, tt.name); got != tt.want {
+			t.Errorf("Allows(%s, %s/%s) = %v, want %v", tt.kind, tt.server, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyWithin(t *testing.T) {
+	policy, err := New(&config.ExportsConfig{
+		Tools:  []string{"github/*"},
+		Within: &config.ExportsConfig{Tools: []string{"*/search_*", "context7/*"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"github/search_issues": true, "github/delete_repo": false, "context7/resolve": false} {
+		server, tool, _ := strings.Cut(name, "/")
+		if got := policy.Allows(KindTool, server, tool); got != want {
+			t.Errorf("Allows(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestNilPolicyExportsEverything(t *testing.T) {
+	policy, err := New(nil)
+	if err != nil || policy != nil {
+		t.Fatalf("New(nil) = %v, %v; want a nil policy", policy, err)
+	}
+	if !policy.Allows(KindTool, "github", "delete_repo") || policy.Check(KindResource, "docs", "x") != nil {
+		t.Error("a nil policy should export everything")
+	}
+	if policy.AppliesToDaemon() {
+		t.Error("a nil policy should not apply to the daemon")
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy, err := New(&config.ExportsConfig{Tools: []string{"github/search_*"}, Daemon: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !policy.AppliesToDaemon() {
+		t.Error("expected the policy to apply to the daemon")
+	}
+
+	err = policy.Check(KindTool, "github", "delete_repo")
+	var denied *DeniedError
+	if !errors.As(err, &denied) || denied.Name != "delete_repo" {
+		t.Fatalf("want a DeniedError for delete_repo, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "github/delete_repo") || !strings.Contains(err.Error(), "export policy") {
+		t.Errorf("error should name the tool and the export policy: %v", err)
+	}
+}
+
+func TestNewRejectsMalformedEntries(t *testing.T) {
+	for _, entry := range []string{"github", "/search", "github/"} {
+		if _, err := New(&config.ExportsConfig{Tools: []string{entry}}); err == nil {
+			t.Errorf("expected %q to be rejected", entry)
+		}
+	}
+}
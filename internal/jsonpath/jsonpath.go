@@ -0,0 +1,265 @@
+// Package jsonpath evaluates a small JSONPath-style language against decoded
+// JSON values: dot access (a.b), array indexes (a[0], a[-1]), wildcards
+// (a.*, a[*]) and quoted keys (a["b.c"]). A leading "$" is optional.
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned when a path does not match the value
+var ErrNotFound = errors.New("path not found")
+
+// segmentKind identifies the step a segment takes
+type segmentKind int
+
+const (
+	keySegment segmentKind = iota
+	indexSegment
+	wildcardSegment
+)
+
+// segment is one step of a path
+type segment struct {
+	kind  segmentKind
+	key   string
+	index int
+}
+
+// Path is a parsed path expression
+type Path struct {
+	expr     string
+	segments []segment
+}
+
+// String returns the expression the path was parsed from
+func (p Path) String() string {
+	return p.expr
+}
+
+// Parse parses a path expression
+func Parse(expr string) (Path, error) {
+	p := Path{expr: expr}
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+
+	first := true
+	for s != "" {
+		switch {
+		case s[0] == '.':
+			s = s[1:]
+			name, rest := splitName(s)
+			if name == "" {
+				return Path{}, fmt.Errorf("invalid path %q: expected a field name after '.'", expr)
+			}
+			p.segments = append(p.segments, nameSegment(name))
+			s = rest
+		case s[0] == '[':
+			seg, rest, err := parseBracket(s)
+			if err != nil {
+				return Path{}, fmt.Errorf("invalid path %q: %w", expr, err)
+			}
+			p.segments = append(p.segments, seg)
+			s = rest
+		case first:
+			// The first field may omit its leading dot
+			name, rest := splitName(s)
+			p.segments = append(p.segments, nameSegment(name))
+			s = rest
+		default:
+			return Path{}, fmt.Errorf("invalid path %q: unexpected %q", expr, s[:1])
+		}
+		first = false
+	}
+	return p, nil
+}
+
+// splitName splits a field name off the front of s
+func splitName(s string) (string, string) {
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+// nameSegment returns the segment for a dotted name, where "*" is a wildcard
+func nameSegment(name string) segment {
+	if name == "*" {
+		return segment{kind: wildcardSegment}
+	}
+	return segment{kind: keySegment, key: name}
+}
+
+// parseBracket parses a [index], [*] or ["key"] segment at the start of s
+func parseBracket(s string) (segment, string, error) {
+	if strings.HasPrefix(s, `["`) || strings.HasPrefix(s, `['`) {
+		quote := s[1]
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] != quote {
+				continue
+			}
+			if i+1 >= len(s) || s[i+1] != ']' {
+				return segment{}, "", fmt.Errorf("expected ']' after quoted key")
+			}
+			raw := s[2:i]
+			key := strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\'`, `'`).Replace(raw)
+			return segment{kind: keySegment, key: key}, s[i+2:], nil
+		}
+		return segment{}, "", fmt.Errorf("unterminated quoted key")
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return segment{}, "", fmt.Errorf("missing ']'")
+	}
+	inner := strings.TrimSpace(s[1:end])
+	if inner == "*" {
+		return segment{kind: wildcardSegment}, s[end+1:], nil
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return segment{}, "", fmt.Errorf("array index %q is not a number", inner)
+	}
+	return segment{kind: indexSegment, index: index}, s[end+1:], nil
+}
+
+// match is a value found while evaluating a path, with its location
+type match struct {
+	value    interface{}
+	location string
+}
+
+// Eval applies the path to value. Paths without wildcards return the single
+// matching value; paths with wildcards return a slice of every match, in
+// array order and sorted key order, skipping branches that don't match.
+func (p Path) Eval(value interface{}) (interface{}, error) {
+	matches := []match{{value: value, location: "$"}}
+	wildcard := false
+
+	for _, seg := range p.segments {
+		var next []match
+		for _, m := range matches {
+			found, err := step(m, seg)
+			if err != nil {
+				if wildcard {
+					continue // Only some branches need to match
+				}
+				return nil, err
+			}
+			next = append(next, found...)
+		}
+		if seg.kind == wildcardSegment {
+			wildcard = true
+		}
+		matches = next
+	}
+
+	if !wildcard {
+		return matches[0].value, nil
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: no values match %s", ErrNotFound, p.expr)
+	}
+	values := make([]interface{}, len(matches))
+	for i, m := range matches {
+		values[i] = m.value
+	}
+	return values, nil
+}
+
+// Get parses expr and applies it to value
+func Get(value interface{}, expr string) (interface{}, error) {
+	p, err := Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Eval(value)
+}
+
+// step applies one segment to a match
+func step(m match, seg segment) ([]match, error) {
+	switch seg.kind {
+	case keySegment:
+		object, ok := m.value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is %s, not an object", ErrNotFound, m.location, describe(m.value))
+		}
+		child, ok := object[seg.key]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s has no field %q", ErrNotFound, m.location, seg.key)
+		}
+		return []match{{value: child, location: m.location + keyLocation(seg.key)}}, nil
+
+	case indexSegment:
+		array, ok := m.value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is %s, not an array", ErrNotFound, m.location, describe(m.value))
+		}
+		index := seg.index
+		if index < 0 {
+			index += len(array)
+		}
+		if index < 0 || index >= len(array) {
+			return nil, fmt.Errorf("%w: index %d is out of range for %s (length %d)", ErrNotFound, seg.index, m.location, len(array))
+		}
+		return []match{{value: array[index], location: fmt.Sprintf("%s[%d]", m.location, index)}}, nil
+
+	default:
+		switch v := m.value.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			found := make([]match, len(keys))
+			for i, key := range keys {
+				found[i] = match{value: v[key], location: m.location + keyLocation(key)}
+			}
+			return found, nil
+		case []interface{}:
+			found := make([]match, len(v))
+			for i, item := range v {
+				found[i] = match{value: item, location: fmt.Sprintf("%s[%d]", m.location, i)}
+			}
+			return found, nil
+		default:
+			return nil, fmt.Errorf("%w: %s is %s, not an object or array", ErrNotFound, m.location, describe(m.value))
+		}
+	}
+}
+
+// keyLocation formats a key for error locations
+func keyLocation(key string) string {
+	if key != "" && !strings.ContainsAny(key, ".[]\"' ") {
+		return "." + key
+	}
+	return "[" + strconv.Quote(key) + "]"
+}
+
+// describe names the JSON type of v for error messages
+func describe(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	default:
+		return "a number"
+	}
+}
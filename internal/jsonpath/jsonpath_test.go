@@ -0,0 +1,155 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const document = `{
+	"libraryId": "/facebook/react",
+	"count": 3,
+	"ok": true,
+	"nothing": null,
+	"items": [
+		{"id": "a", "tags": ["x", "y"]},
+		{"id": "b", "tags": []},
+		{"name": "no id"}
+	],
+	"meta": {"b": 2, "a": 1},
+	"dotted.key": {"it's": "quoted"}
+}`
+
+func decode(t *testing.T) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(document), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"libraryId", "/facebook/react"},
+		{"$.libraryId", "/facebook/react"},
+		{".libraryId", "/facebook/react"},
+		{"count", 3.0},
+		{"ok", true},
+		{"nothing", nil},
+		{"items[0].id", "a"},
+		{"items[-1].name", "no id"},
+		{"items[0].tags[1]", "y"},
+		{"items[*].id", []interface{}{"a", "b"}},
+		{"items.*.id", []interface{}{"a", "b"}},
+		{"items[*].tags[*]", []interface{}{"x", "y"}},
+		{"meta.*", []interface{}{1.0, 2.0}},
+		{"*.a", []interface{}{1.0}},
+		{`["dotted.key"]["it's"]`, "quoted"},
+		{`['dotted.key']['it\'s']`, "quoted"},
+		{"meta", map[string]interface{}{"a": 1.0, "b": 2.0}},
+		{" items[ 1 ].id ", "b"},
+	}
+
+	root := decode(t)
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Get(root, tt.expr)
+			if err != nil {
+				t.Fatalf("Get(%q) failed: %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetWholeValue(t *testing.T) {
+	root := decode(t)
+	for _, expr := range []string{"", "$"} {
+		got, err := Get(root, expr)
+		if err != nil {
+			t.Fatalf("Get(%q) failed: %v", expr, err)
+		}
+		if !reflect.DeepEqual(got, root) {
+			t.Errorf("Get(%q) should return the whole value", expr)
+		}
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantMsg string
+	}{
+		{"missing", `$ has no field "missing"`},
+		{"items[3]", "index 3 is out of range for $.items (length 3)"},
+		{"items[-4]", "index -4 is out of range for $.items (length 3)"},
+		{"libraryId.name", "$.libraryId is a string, not an object"},
+		{"meta[0]", "$.meta is an object, not an array"},
+		{"items[2].id", `$.items[2] has no field "id"`},
+		{"count.*", "$.count is a number, not an object or array"},
+		{"nothing.x", "$.nothing is null, not an object"},
+		{"items[*].missing", "no values match items[*].missing"},
+		{`["dotted.key"].x`, `$["dotted.key"] has no field "x"`},
+	}
+
+	root := decode(t)
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Get(root, tt.expr)
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get(%q) error = %v, want ErrNotFound", tt.expr, err)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("Get(%q) error = %q, want it to contain %q", tt.expr, err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantMsg string
+	}{
+		{"a.", "expected a field name after '.'"},
+		{"a..b", "expected a field name after '.'"},
+		{"a[0", "missing ']'"},
+		{"a[x]", `array index "x" is not a number`},
+		{`a["b]`, "unterminated quoted key"},
+		{`a["b"c]`, "expected ']' after quoted key"},
+		{"a[0]b", `unexpected "b"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want error", tt.expr)
+			}
+			if errors.Is(err, ErrNotFound) {
+				t.Errorf("Parse(%q) syntax error should not be ErrNotFound", tt.expr)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("Parse(%q) error = %q, want it to contain %q", tt.expr, err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestPathString(t *testing.T) {
+	p, err := Parse("items[*].id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != "items[*].id" {
+		t.Errorf("String() = %q", p.String())
+	}
+}
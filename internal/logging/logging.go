@@ -0,0 +1,180 @@
+// Package logging provides the shared logger for warnings and debug output.
+// Records go to stderr (or a log file), never stdout, so they cannot mix
+// with machine-readable command output.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// VerboseEnv is set to "true" for child processes, such as the daemon, so
+// they log at the same level as the command that started them
+const VerboseEnv = "MCP_VERBOSE"
+
+// Options configures the shared logger
+type Options struct {
+	Verbose bool   // Log debug details
+	Quiet   bool   // Log errors only
+	File    string // Append records to this file instead of stderr
+}
+
+var current atomic.Pointer[slog.Logger]
+
+// level is the level of the shared logger Configure created, which SetLevel
+// changes in place
+var level = new(slog.LevelVar)
+
+// stderrTarget is where Stderr writes, normally os.Stderr
+var stderrTarget atomic.Value
+
+// Stderr forwards to os.Stderr, or to the writer given to RedirectStderr
+var Stderr io.Writer = stderrWriter{}
+
+func init() {
+	stderrTarget.Store(writerBox{os.Stderr})
+
+	level.Set(slog.LevelWarn)
+	if os.Getenv(VerboseEnv) == "true" {
+		level.Set(slog.LevelDebug)
+	}
+	current.Store(New(Stderr, level))
+}
+
+// Configure replaces the shared logger. The returned function closes the
+// log file, if one was opened.
+func Configure(opts Options) (func() error, error) {
+	level.Set(slog.LevelWarn)
+	switch {
+	case opts.Verbose:
+		level.Set(slog.LevelDebug)
+	case opts.Quiet:
+		level.Set(slog.LevelError)
+	}
+
+	if opts.File == "" {
+		SetDefault(New(Stderr, level))
+		return func() error { return nil }, nil
+	}
+
+	file, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	SetDefault(slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{Level: level})))
+	return file.Close, nil
+}
+
+// New creates a logger that writes terse, human-readable lines to w
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(&lineHandler{w: w, level: level, mu: &sync.Mutex{}})
+}
+
+// SetLevel changes the level of the shared logger Configure created
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// SetDefault replaces the shared logger
+func SetDefault(logger *slog.Logger) {
+	current.Store(logger)
+}
+
+// Logger returns the shared logger
+func Logger() *slog.Logger {
+	return current.Load()
+}
+
+// Verbose reports whether debug details are being logged
+func Verbose() bool {
+	return Logger().Enabled(context.Background(), slog.LevelDebug)
+}
+
+// Debug logs details shown only in verbose mode
+func Debug(msg string, args ...any) {
+	Logger().Debug(msg, args...)
+}
+
+// Warn logs a problem the command recovered from
+func Warn(msg string, args ...any) {
+	Logger().Warn(msg, args...)
+}
+
+// RedirectStderr sends output meant for stderr to w until restore is called,
+// so that log lines don't break a progress display drawn on stderr
+func RedirectStderr(w io.Writer) (restore func()) {
+	previous := stderrTarget.Swap(writerBox{w})
+	return func() { stderrTarget.Store(previous) }
+}
+
+// writerBox gives stderrTarget a single concrete type
+type writerBox struct{ io.Writer }
+
+type stderrWriter struct{}
+
+func (stderrWriter) Write(p []byte) (int, error) {
+	return stderrTarget.Load().(writerBox).Write(p)
+}
+
+// lineHandler formats records as "Warning: msg key=value" lines
+type lineHandler struct {
+	w     io.Writer
+	level slog.Leveler
+	attrs []slog.Attr
+	mu    *sync.Mutex
+}
+
+func (h *lineHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *lineHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString("Error: ")
+	case r.Level >= slog.LevelWarn:
+		b.WriteString("Warning: ")
+	}
+	b.WriteString(r.Message)
+
+	writeAttr := func(a slog.Attr) bool {
+		if a.Equal(slog.Attr{}) {
+			return true
+		}
+		value := a.Value.Resolve().String()
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		b.WriteString(" " + a.Key + "=" + value)
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *lineHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup is not supported; grouped attributes are logged ungrouped
+func (h *lineHandler) WithGroup(string) slog.Handler {
+	return h
+}
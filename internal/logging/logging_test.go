@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineHandler(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, slog.LevelWarn).With("server", "docs")
+
+	logger.Debug("hidden detail")
+	logger.Warn("failed to save session info", "error", errors.New("disk full"), "pid", 42)
+	logger.Error("giving up")
+
+	want := "Warning: failed to save session info server=docs error=\"disk full\" pid=42\n" +
+		"Error: giving up server=docs\n"
+	if got := out.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestConfigureLevels(t *testing.T) {
+	defer SetDefault(Logger())
+
+	tests := []struct {
+		opts        Options
+		wantVerbose bool
+		wantWarn    bool
+	}{
+		{Options{}, false, true},
+		{Options{Verbose: true}, true, true},
+		{Options{Quiet: true}, false, false},
+	}
+	for _, tt := range tests {
+		closeLog, err := Configure(tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Verbose(); got != tt.wantVerbose {
+			t.Errorf("%+v: Verbose() = %v, want %v", tt.opts, got, tt.wantVerbose)
+		}
+		if got := Logger().Enabled(context.Background(), slog.LevelWarn); got != tt.wantWarn {
+			t.Errorf("%+v: warnings enabled = %v, want %v", tt.opts, got, tt.wantWarn)
+		}
+		_ = closeLog()
+	}
+}
+
+func TestConfigureLogFile(t *testing.T) {
+	defer SetDefault(Logger())
+
+	path := filepath.Join(t.TempDir(), "mcp.log")
+	closeLog, err := Configure(Options{File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Warn("failed to load existing sessions", "error", "bad json")
+	if err := closeLog(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `level=WARN msg="failed to load existing sessions" error="bad json"`) {
+		t.Errorf("unexpected log file content: %s", data)
+	}
+}
+
+func TestRedirectStderr(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(Stderr, slog.LevelWarn)
+
+	restore := RedirectStderr(&out)
+	logger.Warn("redirected")
+	restore()
+
+	if out.String() != "Warning: redirected\n" {
+		t.Errorf("redirected output = %q", out.String())
+	}
+}
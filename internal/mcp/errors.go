@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors classify failures whichever layer they come from. They are
+// matched with errors.Is; the failures keep their own messages.
+var (
+	// ErrServerNotFound is a server missing from the configuration
+	ErrServerNotFound = errors.New("server not found")
+	// ErrToolNotFound is a tool the server does not offer
+	ErrToolNotFound = errors.New("tool not found")
+	// ErrProcessExited is a server process that went away during a request
+	ErrProcessExited = errors.New("server process exited")
+	// ErrTimeout is a request that ran out of time
+	ErrTimeout = errors.New("request timed out")
+	// ErrSessionNotActive is a session that is not running
+	ErrSessionNotActive = errors.New("session not active")
+	// ErrDaemonUnavailable is a daemon that is not running or not answering
+	ErrDaemonUnavailable = errors.New("daemon unavailable")
+)
+
+// kinds names the sentinels so they survive being sent across the daemon
+// API, in the order Kind checks them
+var kinds = []struct {
+	name string
+	err  error
+}{
+	{"daemon_unavailable", ErrDaemonUnavailable},
+	{"server_not_found", ErrServerNotFound},
+	{"tool_not_found", ErrToolNotFound},
+	{"session_not_active", ErrSessionNotActive},
+	{"process_exited", ErrProcessExited},
+	{"timeout", ErrTimeout},
+}
+
+// Kind returns the name of the sentinel err matches, or "" if it matches none
+func Kind(err error) string {
+	for _, kind := range kinds {
+		if errors.Is(err, kind.err) {
+			return kind.name
+		}
+	}
+	return ""
+}
+
+// KindError returns the sentinel Kind names name, or nil
+func KindError(name string) error {
+	for _, kind := range kinds {
+		if kind.name == name {
+			return kind.err
+		}
+	}
+	return nil
+}
+
+// Error implements error, so the errors servers answer can be returned as
+// they are and recovered with errors.As, code and data included
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// Mark returns err classified as kind too: errors.Is(Mark(err, kind), kind)
+// holds while the message, and the rest of the chain, stay err's
+func Mark(err, kind error) error {
+	if err == nil || kind == nil || errors.Is(err, kind) {
+		return err
+	}
+	return &markedError{err: err, kind: kind}
+}
+
+// markedError is an error with a sentinel added to its chain
+type markedError struct {
+	err  error
+	kind error
+}
+
+func (e *markedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *markedError) Unwrap() []error {
+	return []error{e.err, e.kind}
+}
@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMark(t *testing.T) {
+	cause := errors.New("session chrome not found")
+	err := fmt.Errorf("failed to list tools: %w", Mark(cause, ErrSessionNotActive))
+
+	if err.Error() != "failed to list tools: session chrome not found" {
+		t.Errorf("Mark changed the message: %q", err)
+	}
+	if !errors.Is(err, ErrSessionNotActive) || !errors.Is(err, cause) {
+		t.Errorf("want %v to match both the sentinel and its cause", err)
+	}
+	if errors.Is(err, ErrTimeout) {
+		t.Errorf("%v must not match other sentinels", err)
+	}
+	if Mark(nil, ErrTimeout) != nil {
+		t.Error("want marking nil to give nil")
+	}
+}
+
+func TestKindRoundTrip(t *testing.T) {
+	for _, kind := range kinds {
+		err := Mark(errors.New("failed"), kind.err)
+		if got := Kind(err); got != kind.name {
+			t.Errorf("Kind(%v) = %q, want %q", kind.err, got, kind.name)
+		}
+		if KindError(kind.name) != kind.err {
+			t.Errorf("KindError(%q) is not %v", kind.name, kind.err)
+		}
+	}
+	if Kind(errors.New("failed")) != "" || KindError("") != nil || KindError("unheard-of") != nil {
+		t.Error("want no kind for unclassified errors and unknown names")
+	}
+}
+
+func TestJSONRPCErrorIsAnError(t *testing.T) {
+	var err error = fmt.Errorf("failed to call tool: %w", NewError(InvalidParams, "url is required", map[string]interface{}{"field": "url"}))
+	if err.Error() != "failed to call tool: JSON-RPC error -32602: url is required" {
+		t.Errorf("unexpected message %q", err)
+	}
+	var rpcErr *JSONRPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != InvalidParams || rpcErr.Data == nil {
+		t.Errorf("want the JSON-RPC error with its code and data, got %#v", rpcErr)
+	}
+}
@@ -2,9 +2,76 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 )
 
+// SessionIDHeader carries the session a Streamable HTTP server assigns on
+// initialize; clients send it back with every later request
+const SessionIDHeader = "Mcp-Session-Id"
+
+// ProtocolVersion is the MCP protocol revision this client speaks
+const ProtocolVersion = "2024-11-05"
+
+// OldestTestedProtocolVersion is the oldest revision the conformance suite
+// checks; servers may be asked for older ones, but nothing vouches for them
+const OldestTestedProtocolVersion = "2024-11-05"
+
+// KnownProtocolVersions are the published MCP protocol revisions, oldest
+// first, which a server may be pinned to
+var KnownProtocolVersions = []string{"2024-11-05", "2025-03-26", "2025-06-18"}
+
+// ProtocolVersionHeader carries the protocol revision on HTTP requests
+const ProtocolVersionHeader = "MCP-Protocol-Version"
+
+// ToolsListChanged is the notification a server sends when its tools change
+const ToolsListChanged = "notifications/tools/list_changed"
+
+// Cancelled is the notification asking the other side to stop work on a
+// request it was sent
+const Cancelled = "notifications/cancelled"
+
+// Progress is the notification reporting progress on a request that asked
+// for it with a progress token
+const Progress = "notifications/progress"
+
+// LoggingMessage is the notification carrying a log message from the server
+const LoggingMessage = "notifications/message"
+
+// progressTokenKey is the context key of WithProgressToken
+type progressTokenKey struct{}
+
+// WithProgressToken returns a context whose tool calls ask the server to
+// report progress under token. Only clients that read the server's
+// notifications while a call runs send it.
+func WithProgressToken(ctx context.Context, token interface{}) context.Context {
+	return context.WithValue(ctx, progressTokenKey{}, token)
+}
+
+// ProgressToken returns the progress token ctx carries, or nil
+func ProgressToken(ctx context.Context) interface{} {
+	return ctx.Value(progressTokenKey{})
+}
+
+// NewCallToolParams returns the params of a tools/call request, with the
+// progress token ctx carries
+func NewCallToolParams(ctx context.Context, name string, arguments map[string]interface{}) *CallToolParams {
+	params := &CallToolParams{
+		Name:      name,
+		Arguments: arguments,
+	}
+	if token := ProgressToken(ctx); token != nil {
+		params.Meta = &RequestMeta{ProgressToken: token}
+	}
+	return params
+}
+
+// NewCallToolRequest returns the tools/call request clients send to call
+// name, so what --print-request shows is what goes on the wire
+func NewCallToolRequest(ctx context.Context, name string, arguments map[string]interface{}) *JSONRPCRequest {
+	return NewRequest(2, "tools/call", NewCallToolParams(ctx, name, arguments))
+}
+
 // MCPClient defines the interface for MCP clients
 type MCPClient interface {
 	// Core protocol
@@ -31,6 +98,37 @@ type MCPClient interface {
 	NotifyRootsListChanged(roots []Root) error
 }
 
+// RequestSender is implemented by clients that can send any request, for
+// methods MCPClient has no typed call for (prompts, resources/read, ping).
+// The result is returned as the server encoded it.
+type RequestSender interface {
+	SendRequest(ctx context.Context, method string, params interface{}) (json.RawMessage, error)
+}
+
+// BatchResult is the outcome of one request of a batch: the result as the
+// server encoded it, or why the request failed
+type BatchResult struct {
+	Result json.RawMessage
+	Err    error
+}
+
+// BatchCapable is implemented by clients that can send several requests in
+// one round trip. Results come back in the order of the requests; err is
+// only set when the batch as a whole failed.
+type BatchCapable interface {
+	SendBatch(ctx context.Context, requests []*JSONRPCRequest) ([]BatchResult, error)
+}
+
+// NotificationHandler receives a notification sent by a server
+type NotificationHandler func(method string, params json.RawMessage)
+
+// NotificationSource is implemented by clients that can report the
+// notifications a server sends. Handlers run on the client's read path and
+// must not call back into the client.
+type NotificationSource interface {
+	SetNotificationHandler(handler NotificationHandler)
+}
+
 // SamplingHandler defines how clients should handle sampling requests
 type SamplingHandler interface {
 	HandleSamplingRequest(ctx context.Context, request *CreateMessageRequest) (*CreateMessageResult, error)
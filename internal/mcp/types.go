@@ -12,10 +12,10 @@ type JSONRPCRequest struct {
 
 // JSONRPCResponse represents a JSON-RPC 2.0 response
 type JSONRPCResponse struct {
-	JSONRPC string        `json:"jsonrpc"`
-	ID      interface{}   `json:"id"`
-	Result  interface{}   `json:"result,omitempty"`
-	Error   *JSONRPCError `json:"error,omitempty"`
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"` // Kept encoded so callers decode it once, into the type they need
+	Error   *JSONRPCError   `json:"error,omitempty"`
 }
 
 // JSONRPCError represents a JSON-RPC 2.0 error
@@ -27,15 +27,93 @@ type JSONRPCError struct {
 
 // Tool represents an MCP tool definition
 type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description,omitempty"`
-	InputSchema map[string]interface{} `json:"inputSchema,omitempty"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description,omitempty"`
+	InputSchema  map[string]interface{} `json:"inputSchema,omitempty"`
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"` // Of the tool's structured result, if it declares one
+	Annotations  *ToolAnnotations       `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations are hints a server gives about a tool's behavior. They are
+// not guaranteed to be accurate.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`    // The tool does not modify its environment
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"` // The tool may perform destructive updates
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`  // Repeating a call with the same arguments has no further effect
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`   // The tool interacts with external entities
+}
+
+// IsReadOnly reports whether the server annotates the tool as read-only
+func (t *Tool) IsReadOnly() bool {
+	return t.Annotations != nil && t.Annotations.ReadOnlyHint != nil && *t.Annotations.ReadOnlyHint
+}
+
+// IsIdempotent reports whether the server annotates the tool as idempotent or
+// read-only, so that repeating a call is safe
+func (t *Tool) IsIdempotent() bool {
+	return t.IsReadOnly() || (t.Annotations != nil && t.Annotations.IdempotentHint != nil && *t.Annotations.IdempotentHint)
+}
+
+// IsDestructive reports whether the server annotates the tool as destructive.
+// Only an explicit destructiveHint on a tool not marked read-only counts.
+func (t *Tool) IsDestructive() bool {
+	return !t.IsReadOnly() && t.Annotations != nil && t.Annotations.DestructiveHint != nil && *t.Annotations.DestructiveHint
 }
 
 // ToolResult represents the result of calling a tool
 type ToolResult struct {
 	Content []interface{} `json:"content,omitempty"`
 	IsError bool          `json:"isError,omitempty"`
+
+	StructuredContent interface{} `json:"structuredContent,omitempty"` // Typed result matching the tool's outputSchema
+
+	Raw json.RawMessage `json:"-"` // The result exactly as the server sent it, when the client has it
+}
+
+// ContentBlock is a typed view of a tool result content entry: "text",
+// "image", "audio", "resource" or "resource_link"
+type ContentBlock struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"`     // Base64 data of image and audio blocks
+	MimeType string            `json:"mimeType,omitempty"` // Type of image, audio and resource link data
+	URI      string            `json:"uri,omitempty"`      // Target of resource links
+	Name     string            `json:"name,omitempty"`     // Name of resource links
+	Resource *EmbeddedResource `json:"resource,omitempty"` // Contents of embedded resources
+
+	Raw json.RawMessage `json:"-"` // The entry with every field the server sent, including those of unknown types
+}
+
+// EmbeddedResource is a resource included in a tool result
+type EmbeddedResource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"` // Base64 data of binary resources
+}
+
+// Blocks decodes the result content into typed blocks. Entries that aren't
+// objects become text blocks holding their JSON encoding.
+func (r *ToolResult) Blocks() []ContentBlock {
+	blocks := make([]ContentBlock, 0, len(r.Content))
+	for _, entry := range r.Content {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+
+		var block ContentBlock
+		if _, isObject := entry.(map[string]interface{}); !isObject || json.Unmarshal(data, &block) != nil {
+			block = ContentBlock{Type: "text", Text: string(data)}
+			if text, isString := entry.(string); isString {
+				block.Text = text
+			}
+		}
+		block.Raw = data
+		blocks = append(blocks, block)
+	}
+	return blocks
 }
 
 // Resource represents an MCP resource definition
@@ -58,6 +136,53 @@ type ListToolsResult struct {
 type CallToolParams struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments"`
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
+}
+
+// RequestMeta is the _meta a request carries. A progress token asks the
+// server to report progress on the request.
+type RequestMeta struct {
+	ProgressToken interface{} `json:"progressToken,omitempty"`
+}
+
+// ProgressParams represents the params of a progress notification
+type ProgressParams struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      float64     `json:"progress"`
+	Total         float64     `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
+}
+
+// LoggingMessageParams represents the params of a log message notification
+type LoggingMessageParams struct {
+	Level  string          `json:"level"`
+	Logger string          `json:"logger,omitempty"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Prompt represents an MCP prompt template
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument describes an argument a prompt template accepts
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// ListPromptsResult represents the result of prompts/list
+type ListPromptsResult struct {
+	Prompts []Prompt `json:"prompts"`
+}
+
+// CancelledParams represents parameters for notifications/cancelled
+type CancelledParams struct {
+	RequestID interface{} `json:"requestId"`
+	Reason    string      `json:"reason,omitempty"`
 }
 
 // ListResourcesParams represents parameters for resources/list
@@ -86,6 +211,31 @@ type Message struct {
 	Content string `json:"content"`
 }
 
+// UnmarshalJSON reads content given as a string or, as servers send it in
+// sampling requests, as a text content block
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.Role, m.Content = raw.Role, ""
+	if len(raw.Content) == 0 || string(raw.Content) == "null" {
+		return nil
+	}
+	if raw.Content[0] == '{' {
+		var block Content
+		if err := json.Unmarshal(raw.Content, &block); err != nil {
+			return err
+		}
+		m.Content = block.Text
+		return nil
+	}
+	return json.Unmarshal(raw.Content, &m.Content)
+}
+
 // ModelPreferences represents model selection hints and priorities
 type ModelPreferences struct {
 	Hints                []ModelHint `json:"hints,omitempty"`
@@ -158,6 +308,10 @@ type ServerCapabilities struct {
 	Sampling    *SamplingCapability    `json:"sampling,omitempty"`
 	Roots       *RootsCapability       `json:"roots,omitempty"`
 	Elicitation *ElicitationCapability `json:"elicit,omitempty"`
+
+	Prompts      *PromptsCapability     `json:"prompts,omitempty"`
+	Logging      *LoggingCapability     `json:"logging,omitempty"`
+	Experimental map[string]interface{} `json:"experimental,omitempty"`
 }
 
 // ToolsCapability represents tools capability
@@ -171,6 +325,15 @@ type ResourcesCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
 
+// PromptsCapability represents prompts capability
+type PromptsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// LoggingCapability represents logging capability: the server can send log
+// messages
+type LoggingCapability struct{}
+
 // SamplingCapability represents sampling capability
 type SamplingCapability struct{}
 
@@ -187,6 +350,84 @@ type InitializeParams struct {
 	ProtocolVersion string             `json:"protocolVersion"`
 	Capabilities    ClientCapabilities `json:"capabilities"`
 	ClientInfo      ClientInfo         `json:"clientInfo"`
+
+	Extra map[string]interface{} `json:"-"` // Further top-level fields, which some servers read settings from
+}
+
+// initializeParamsFields are the fields InitializeParams defines, which
+// Extra cannot replace
+var initializeParamsFields = map[string]bool{"protocolVersion": true, "capabilities": true, "clientInfo": true}
+
+// MarshalJSON writes the Extra fields beside the defined ones
+func (p InitializeParams) MarshalJSON() ([]byte, error) {
+	type plain InitializeParams
+	data, err := json.Marshal(plain(p))
+	if err != nil || len(p.Extra) == 0 {
+		return data, err
+	}
+
+	fields := make(map[string]interface{}, len(p.Extra)+len(initializeParamsFields))
+	for name, value := range p.Extra {
+		if !initializeParamsFields[name] {
+			fields[name] = value
+		}
+	}
+	var defined map[string]json.RawMessage
+	if err := json.Unmarshal(data, &defined); err != nil {
+		return nil, err
+	}
+	for name, value := range defined {
+		fields[name] = value
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON keeps the fields InitializeParams doesn't define in Extra
+func (p *InitializeParams) UnmarshalJSON(data []byte) error {
+	type plain InitializeParams
+	var params plain
+	if err := json.Unmarshal(data, &params); err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for name, value := range fields {
+		if !initializeParamsFields[name] {
+			if params.Extra == nil {
+				params.Extra = make(map[string]interface{})
+			}
+			params.Extra[name] = value
+		}
+	}
+	*p = InitializeParams(params)
+	return nil
+}
+
+// WithExperimental returns a copy of p with options merged into its
+// experimental capabilities, replacing those under the same keys
+func (p *InitializeParams) WithExperimental(options map[string]interface{}) *InitializeParams {
+	withOptions := *p
+	withOptions.Capabilities.Experimental = make(map[string]interface{}, len(p.Capabilities.Experimental)+len(options))
+	for key, value := range p.Capabilities.Experimental {
+		withOptions.Capabilities.Experimental[key] = value
+	}
+	for key, value := range options {
+		withOptions.Capabilities.Experimental[key] = value
+	}
+	return &withOptions
+}
+
+// WithField returns a copy of p with the top-level field name set to value
+func (p *InitializeParams) WithField(name string, value interface{}) *InitializeParams {
+	withField := *p
+	withField.Extra = make(map[string]interface{}, len(p.Extra)+1)
+	for key, extra := range p.Extra {
+		withField.Extra[key] = extra
+	}
+	withField.Extra[name] = value
+	return &withField
 }
 
 // ClientCapabilities represents client capabilities
@@ -207,6 +448,8 @@ type InitializeResult struct {
 	ProtocolVersion string             `json:"protocolVersion"`
 	Capabilities    ServerCapabilities `json:"capabilities"`
 	ServerInfo      ServerInfo         `json:"serverInfo"`
+
+	Raw json.RawMessage `json:"-"` // The result exactly as the server sent it, when the client has it
 }
 
 // ServerInfo represents server information
@@ -243,8 +486,25 @@ func NewRequest(id interface{}, method string, params interface{}) *JSONRPCReque
 	}
 }
 
-// NewResponse creates a new JSON-RPC response
-func NewResponse(id interface{}, result interface{}) *JSONRPCResponse {
+// JSONRPCNotification is a JSON-RPC message that expects no response, so it
+// has no id
+type JSONRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// NewNotification creates a new JSON-RPC notification
+func NewNotification(method string, params interface{}) *JSONRPCNotification {
+	return &JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	}
+}
+
+// NewResponse creates a new JSON-RPC response from an encoded result
+func NewResponse(id interface{}, result json.RawMessage) *JSONRPCResponse {
 	return &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestInitializeParamsExtraRoundTrips(t *testing.T) {
+	params := InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		ClientInfo:      ClientInfo{Name: "mcp-cli-ent", Version: "1.0"},
+		Extra: map[string]interface{}{
+			"initializationOptions": map[string]interface{}{
+				"workspace": map[string]interface{}{"roots": []interface{}{"/a", "/b"}, "depth": 3.0},
+				"features":  []interface{}{map[string]interface{}{"name": "hover", "enabled": true}},
+			},
+			"protocolVersion": "ignored", // Defined fields cannot be replaced
+		},
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["protocolVersion"] != ProtocolVersion {
+		t.Errorf("protocolVersion = %v, want %s", fields["protocolVersion"], ProtocolVersion)
+	}
+
+	var decoded InitializeParams
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"initializationOptions": params.Extra["initializationOptions"]}
+	if !reflect.DeepEqual(decoded.Extra, want) {
+		t.Errorf("Extra = %#v, want %#v", decoded.Extra, want)
+	}
+	if decoded.ProtocolVersion != ProtocolVersion || decoded.ClientInfo != params.ClientInfo {
+		t.Errorf("decoded params = %+v", decoded)
+	}
+
+	// Without Extra the params encode as before
+	plain, _ := json.Marshal(InitializeParams{ProtocolVersion: ProtocolVersion})
+	if string(plain) != `{"protocolVersion":"`+ProtocolVersion+`","capabilities":{},"clientInfo":{"name":"","version":""}}` {
+		t.Errorf("plain params = %s", plain)
+	}
+}
+
+func TestInitializeParamsWithOptions(t *testing.T) {
+	params := &InitializeParams{Capabilities: ClientCapabilities{Experimental: map[string]interface{}{"a": 1, "b": 2}}}
+	options := map[string]interface{}{"b": map[string]interface{}{"nested": true}, "c": 3}
+
+	merged := params.WithExperimental(options)
+	want := map[string]interface{}{"a": 1, "b": map[string]interface{}{"nested": true}, "c": 3}
+	if !reflect.DeepEqual(merged.Capabilities.Experimental, want) {
+		t.Errorf("experimental = %v, want %v", merged.Capabilities.Experimental, want)
+	}
+	if len(params.Capabilities.Experimental) != 2 || params.Capabilities.Experimental["b"] != 2 {
+		t.Errorf("WithExperimental changed the original: %v", params.Capabilities.Experimental)
+	}
+
+	withField := params.WithField("initializationOptions", options)
+	if !reflect.DeepEqual(withField.Extra["initializationOptions"], options) || params.Extra != nil {
+		t.Errorf("WithField = %v, original %v", withField.Extra, params.Extra)
+	}
+}
+
+func TestToolAnnotationHints(t *testing.T) {
+	var tools []Tool
+	err := json.Unmarshal([]byte(`[
+		{"name": "plain"},
+		{"name": "read", "annotations": {"title": "Read a file", "readOnlyHint": true, "destructiveHint": true}},
+		{"name": "put", "annotations": {"idempotentHint": true, "destructiveHint": false}},
+		{"name": "delete", "annotations": {"destructiveHint": true, "openWorldHint": false}}
+	]`), &tools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tools[0].Annotations != nil {
+		t.Errorf("a tool without annotations should have none, got %+v", tools[0].Annotations)
+	}
+	if tools[1].Annotations.Title != "Read a file" || tools[3].Annotations.OpenWorldHint == nil || *tools[3].Annotations.OpenWorldHint {
+		t.Errorf("annotations not parsed: %+v, %+v", tools[1].Annotations, tools[3].Annotations)
+	}
+
+	for i, want := range []struct{ readOnly, idempotent, destructive bool }{
+		{false, false, false},
+		{true, true, false}, // Read-only tools cannot be destructive
+		{false, true, false},
+		{false, false, true},
+	} {
+		tool := &tools[i]
+		if tool.IsReadOnly() != want.readOnly || tool.IsIdempotent() != want.idempotent || tool.IsDestructive() != want.destructive {
+			t.Errorf("%s: read-only %v, idempotent %v, destructive %v, want %+v",
+				tool.Name, tool.IsReadOnly(), tool.IsIdempotent(), tool.IsDestructive(), want)
+		}
+	}
+}
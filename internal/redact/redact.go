@@ -0,0 +1,180 @@
+// Package redact keeps the secrets in server configurations, such as API
+// keys in headers and env, out of error messages, logs and API responses.
+// A secret is replaced with a fingerprint that is the same wherever the
+// secret appears, so occurrences can still be told apart and correlated
+// without revealing it.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// secretName matches the names of headers, variables and object members
+// whose values are secret
+var secretName = regexp.MustCompile(`(?i)authorization|token|secret|passw(or)?d|api[-_]?key|credential|cookie`)
+
+// MinLength is the length below which a secret is not scrubbed from text,
+// where it would match too much
+const MinLength = 4
+
+// prefixLength is how many characters of a secret its fingerprint shows
+const prefixLength = 4
+
+// minPrefixedLength is the length below which a fingerprint shows none of
+// the secret, since a few characters would give away too much of it
+const minPrefixedLength = 12
+
+// IsSecretName reports whether a header, variable or member named name holds
+// a secret
+func IsSecretName(name string) bool {
+	return secretName.MatchString(name)
+}
+
+// Values returns the secret values among values: those named like secrets
+// and those named in names. The credential of a value with a scheme, such
+// as "Bearer <token>", is returned on its own as well.
+func Values(values map[string]string, names ...string) []string {
+	var secrets []string
+	for name, value := range values {
+		if !IsSecretName(name) && !contains(names, name) {
+			continue
+		}
+		secrets = append(secrets, value)
+		if _, credential, ok := strings.Cut(value, " "); ok {
+			secrets = append(secrets, strings.TrimSpace(credential))
+		}
+	}
+	return secrets
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Members returns a copy of values with the strings of members named like
+// secrets, at any depth, replaced by their fingerprints
+func Members(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	return members(values).(map[string]interface{})
+}
+
+func members(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, member := range v {
+			if secret, ok := member.(string); ok && IsSecretName(key) {
+				copied[key] = Fingerprint(secret)
+				continue
+			}
+			copied[key] = members(member)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = members(item)
+		}
+		return copied
+	}
+	return value
+}
+
+// Fingerprint returns what stands in for secret: its first characters, when
+// it is long enough to spare them, and a short hash of it
+func Fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	hash := hex.EncodeToString(sum[:4])
+	if utf8.RuneCountInString(secret) < minPrefixedLength {
+		return "[redacted " + hash + "]"
+	}
+	prefix := []rune(secret)[:prefixLength]
+	return "[redacted " + string(prefix) + "…" + hash + "]"
+}
+
+// Redactor replaces known secrets in text. A nil Redactor leaves text as it
+// is.
+type Redactor struct {
+	replacer *strings.Replacer
+}
+
+// New returns a Redactor for secrets, or nil when none is long enough to
+// scrub
+func New(secrets ...string) *Redactor {
+	seen := make(map[string]bool, len(secrets))
+	var kept []string
+	for _, secret := range secrets {
+		if len(secret) < MinLength || seen[secret] {
+			continue
+		}
+		seen[secret] = true
+		kept = append(kept, secret)
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	// Longer secrets first, so a token inside a header value doesn't leave
+	// the rest of the value behind
+	sort.Slice(kept, func(i, j int) bool { return len(kept[i]) > len(kept[j]) })
+	var pairs []string
+	for _, secret := range kept {
+		fingerprint := Fingerprint(secret)
+		pairs = append(pairs, secret, fingerprint)
+		// As it appears inside JSON strings, such as a response body
+		if quoted, err := json.Marshal(secret); err == nil {
+			if escaped := string(quoted[1 : len(quoted)-1]); escaped != secret {
+				pairs = append(pairs, escaped, fingerprint)
+			}
+		}
+	}
+	return &Redactor{replacer: strings.NewReplacer(pairs...)}
+}
+
+// String returns text with the secrets replaced by their fingerprints
+func (r *Redactor) String(text string) string {
+	if r == nil {
+		return text
+	}
+	return r.replacer.Replace(text)
+}
+
+// Error returns err with the secrets scrubbed from its message. The original
+// error stays reachable through errors.Is and errors.As.
+func (r *Redactor) Error(err error) error {
+	if r == nil || err == nil {
+		return err
+	}
+	msg := err.Error()
+	if scrubbed := r.String(msg); scrubbed != msg {
+		return &redactedError{msg: scrubbed, err: err}
+	}
+	return err
+}
+
+// redactedError is an error whose message had secrets replaced
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,86 @@
+package redact
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	long := Fingerprint("sk-live-0123456789")
+	if !strings.HasPrefix(long, "[redacted sk-l…") || strings.Contains(long, "0123456789") {
+		t.Errorf("fingerprint %q should show only the first characters", long)
+	}
+	if Fingerprint("sk-live-0123456789") != long {
+		t.Error("fingerprints should be stable")
+	}
+	if Fingerprint("sk-live-9876543210") == long {
+		t.Error("different secrets should have different fingerprints")
+	}
+	if short := Fingerprint("hunter2!"); strings.Contains(short, "hunt") {
+		t.Errorf("a short secret's fingerprint %q should show none of it", short)
+	}
+}
+
+func TestValues(t *testing.T) {
+	got := Values(map[string]string{
+		"Authorization": "Bearer tok-abcdef",
+		"Accept":        "application/json",
+		"REGION":        "eu-west-1",
+		"DSN":           "postgres://u:p@db",
+	}, "DSN")
+	sort.Strings(got)
+	want := []string{"Bearer tok-abcdef", "postgres://u:p@db", "tok-abcdef"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Values = %q, want %q", got, want)
+	}
+}
+
+func TestRedactorScrubsSecrets(t *testing.T) {
+	secret := `pa"ss\word-0123456789`
+	r := New("Bearer tok-abcdef", "tok-abcdef", secret, "abc")
+
+	text := r.String(`header "Bearer tok-abcdef", token tok-abcdef, body {"password":"pa\"ss\\word-0123456789"}, short abc`)
+	for _, leaked := range []string{"tok-abcdef", secret, `pa\"ss\\word`} {
+		if strings.Contains(text, leaked) {
+			t.Errorf("%q leaked into %s", leaked, text)
+		}
+	}
+	if !strings.Contains(text, Fingerprint(secret)) || !strings.Contains(text, "short abc") {
+		t.Errorf("unexpected redaction: %s", text)
+	}
+
+	cause := errors.New("unauthorized: tok-abcdef")
+	err := r.Error(cause)
+	if strings.Contains(err.Error(), "tok-abcdef") || !errors.Is(err, cause) {
+		t.Errorf("error %q should be scrubbed and wrap the original", err)
+	}
+	if clean := errors.New("not found"); r.Error(clean) != clean {
+		t.Error("errors without secrets should be returned as they are")
+	}
+
+	var none *Redactor = New("ab")
+	if none != nil || none.String("ab") != "ab" {
+		t.Error("a Redactor without secrets should leave text alone")
+	}
+}
+
+func TestMembers(t *testing.T) {
+	args := map[string]interface{}{
+		"query":   "weather",
+		"apiKey":  "k-0123456789abc",
+		"options": map[string]interface{}{"accessToken": "t-0123456789abc", "limit": 3.0},
+	}
+	got := Members(args)
+	if got["apiKey"] != Fingerprint("k-0123456789abc") || got["query"] != "weather" {
+		t.Errorf("unexpected members %v", got)
+	}
+	if options := got["options"].(map[string]interface{}); options["accessToken"] != Fingerprint("t-0123456789abc") || options["limit"] != 3.0 {
+		t.Errorf("nested members were not redacted: %v", options)
+	}
+	if args["apiKey"] != "k-0123456789abc" {
+		t.Error("the original arguments should be left alone")
+	}
+}
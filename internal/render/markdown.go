@@ -0,0 +1,224 @@
+// Package render turns Markdown tool output into ANSI-styled terminal text
+package render
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI escape sequences used by the renderer
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiDim       = "\x1b[2m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiCyan      = "\x1b[36m"
+)
+
+// codeIndent prefixes every line of a fenced code block
+const codeIndent = "    "
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletPattern    = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	orderedPattern   = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	quotePattern     = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	rulePattern      = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	inlineCode       = regexp.MustCompile("`([^`]+)`")
+	boldPattern      = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	italicPattern    = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*]*)\*`)
+	linkPattern      = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	ansiEscapePrefix = "\x1b["
+)
+
+// Markdown renders text as ANSI-styled output wrapped to width columns.
+// Headings are bold, fenced code blocks are indented and dimmed, and list
+// items are bulleted. A width of zero or less disables wrapping.
+func Markdown(text string, width int) string {
+	var out []string
+	inCode := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			out = append(out, ansiDim+codeIndent+strings.TrimRight(line, " \t")+ansiReset)
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			out = append(out, "")
+		case headingPattern.MatchString(trimmed):
+			m := headingPattern.FindStringSubmatch(trimmed)
+			style := ansiBold
+			if len(m[1]) == 1 {
+				style += ansiUnderline
+			}
+			for _, wrapped := range wrap(inline(m[2]), width, "", "") {
+				out = append(out, style+wrapped+ansiReset)
+			}
+		case rulePattern.MatchString(line):
+			out = append(out, ansiDim+strings.Repeat("─", ruleWidth(width))+ansiReset)
+		case bulletPattern.MatchString(line):
+			m := bulletPattern.FindStringSubmatch(line)
+			indent := listIndent(m[1])
+			out = append(out, wrap(inline(m[2]), width, indent+"• ", indent+"  ")...)
+		case orderedPattern.MatchString(line):
+			m := orderedPattern.FindStringSubmatch(line)
+			indent := listIndent(m[1])
+			marker := m[2] + " "
+			out = append(out, wrap(inline(m[3]), width, indent+marker, indent+strings.Repeat(" ", len(marker)))...)
+		case quotePattern.MatchString(line):
+			m := quotePattern.FindStringSubmatch(line)
+			prefix := ansiDim + "│ " + ansiReset
+			out = append(out, wrap(inline(m[1]), width, prefix, prefix)...)
+		default:
+			out = append(out, wrap(inline(trimmed), width, "", "")...)
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// inline applies code, link, bold and italic styling within a line
+func inline(s string) string {
+	// Protect code spans so their contents are not styled further
+	var spans []string
+	s = inlineCode.ReplaceAllStringFunc(s, func(m string) string {
+		spans = append(spans, ansiCyan+m[1:len(m)-1]+ansiReset)
+		return "\x00" + string(rune('a'+len(spans)-1)) + "\x00"
+	})
+
+	s = linkPattern.ReplaceAllString(s, ansiUnderline+"$1"+ansiReset+" ($2)")
+	s = boldPattern.ReplaceAllString(s, ansiBold+"$1$2"+ansiReset)
+	s = italicPattern.ReplaceAllString(s, "$1"+ansiItalic+"$2"+ansiReset)
+
+	for i, span := range spans {
+		s = strings.Replace(s, "\x00"+string(rune('a'+i))+"\x00", span, 1)
+	}
+	return s
+}
+
+// listIndent normalises leading whitespace of nested list items
+func listIndent(leading string) string {
+	leading = strings.ReplaceAll(leading, "\t", "    ")
+	return strings.Repeat("  ", len(leading)/2+1)
+}
+
+// ruleWidth returns the width of a horizontal rule
+func ruleWidth(width int) int {
+	if width <= 0 || width > 40 {
+		return 40
+	}
+	return width
+}
+
+// wrap splits s into lines no wider than width. The first line starts with
+// first and continuation lines with rest; words longer than the available
+// space are broken.
+func wrap(s string, width int, first, rest string) []string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return []string{strings.TrimRight(first, " ")}
+	}
+	if width <= 0 {
+		return []string{first + strings.Join(words, " ")}
+	}
+
+	var lines []string
+	prefix := first
+	current := prefix
+	currentLen := visibleLen(prefix)
+	empty := true
+
+	flush := func() {
+		lines = append(lines, current)
+		prefix = rest
+		current = prefix
+		currentLen = visibleLen(prefix)
+		empty = true
+	}
+
+	for _, word := range words {
+		wordLen := visibleLen(word)
+		if !empty && currentLen+1+wordLen > width {
+			flush()
+		}
+		// Hard-break words that cannot fit on a line of their own
+		for currentLen+wordLen > width && width-currentLen > 0 {
+			if !empty {
+				flush()
+				continue
+			}
+			head, tail := splitVisible(word, width-currentLen)
+			current += head
+			flush()
+			word = tail
+			wordLen = visibleLen(word)
+		}
+		if !empty {
+			current += " "
+			currentLen++
+		}
+		current += word
+		currentLen += wordLen
+		empty = false
+	}
+	if !empty {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// visibleLen returns the display width of s, ignoring ANSI escapes
+func visibleLen(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if skip := escapeLen(s[i:]); skip > 0 {
+			i += skip
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n++
+	}
+	return n
+}
+
+// splitVisible splits s after n visible characters, keeping escapes intact
+func splitVisible(s string, n int) (string, string) {
+	count := 0
+	for i := 0; i < len(s); {
+		if skip := escapeLen(s[i:]); skip > 0 {
+			i += skip
+			continue
+		}
+		if count == n {
+			return s[:i], s[i:]
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		count++
+	}
+	return s, ""
+}
+
+// escapeLen returns the length of the ANSI escape at the start of s, or 0
+func escapeLen(s string) int {
+	if !strings.HasPrefix(s, ansiEscapePrefix) {
+		return 0
+	}
+	for i := len(ansiEscapePrefix); i < len(s); i++ {
+		if s[i] >= '@' && s[i] <= '~' {
+			return i + 1
+		}
+	}
+	return 0
+}
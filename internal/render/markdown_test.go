@@ -0,0 +1,97 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "heading",
+			input: "## Results",
+			want:  "\x1b[1mResults\x1b[0m",
+		},
+		{
+			name:  "top level heading is underlined",
+			input: "# Title #",
+			want:  "\x1b[1m\x1b[4mTitle\x1b[0m",
+		},
+		{
+			name:  "bullets",
+			input: "- one\n* two\n  + nested",
+			want:  "  • one\n  • two\n    • nested",
+		},
+		{
+			name:  "ordered list",
+			input: "1. first\n2. second",
+			want:  "  1. first\n  2. second",
+		},
+		{
+			name:  "code block",
+			input: "before\n```go\nfmt.Println(\"**hi**\")\n```\nafter",
+			want:  "before\n\x1b[2m    fmt.Println(\"**hi**\")\x1b[0m\nafter",
+		},
+		{
+			name:  "inline styles",
+			input: "use **bold**, *em* and `x**y**`",
+			want:  "use \x1b[1mbold\x1b[0m, \x1b[3mem\x1b[0m and \x1b[36mx**y**\x1b[0m",
+		},
+		{
+			name:  "link",
+			input: "see [docs](https://example.com)",
+			want:  "see \x1b[4mdocs\x1b[0m (https://example.com)",
+		},
+		{
+			name:  "identifiers are not emphasised",
+			input: "snake_case_name and 2*3*4",
+			want:  "snake_case_name and 2*3*4",
+		},
+		{
+			name:  "blank lines preserved",
+			input: "a\n\nb",
+			want:  "a\n\nb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Markdown(tt.input, 80); got != tt.want {
+				t.Errorf("Markdown(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownWrapsToWidth(t *testing.T) {
+	input := "- " + strings.Repeat("word ", 30) + "\n" + strings.Repeat("x", 50)
+	got := Markdown(input, 20)
+
+	for _, line := range strings.Split(got, "\n") {
+		if n := visibleLen(line); n > 20 {
+			t.Errorf("line %q is %d columns wide, want at most 20", line, n)
+		}
+	}
+
+	lines := strings.Split(got, "\n")
+	if !strings.HasPrefix(lines[0], "  • word") {
+		t.Errorf("first line = %q, want bullet", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "    word") {
+		t.Errorf("continuation line = %q, want hanging indent", lines[1])
+	}
+	if !strings.Contains(got, strings.Repeat("x", 20)+"\n"+strings.Repeat("x", 20)+"\n"+strings.Repeat("x", 10)) {
+		t.Errorf("long word was not broken at the width:\n%s", got)
+	}
+}
+
+func TestMarkdownWrapIgnoresEscapes(t *testing.T) {
+	got := Markdown("**aaaa** **bbbb**", 9)
+	if got != "\x1b[1maaaa\x1b[0m \x1b[1mbbbb\x1b[0m" {
+		t.Errorf("styled words should fit on one line, got %q", got)
+	}
+}
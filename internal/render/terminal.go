@@ -0,0 +1,26 @@
+package render
+
+import (
+	"os"
+	"strconv"
+)
+
+// DefaultWidth is used when the terminal width cannot be detected
+const DefaultWidth = 80
+
+// IsTerminal reports whether f is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	_, ok := terminalSize(f)
+	return ok
+}
+
+// Width returns the terminal width of f, preferring $COLUMNS when set
+func Width(f *os.File) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if width, ok := terminalSize(f); ok && width > 0 {
+		return width
+	}
+	return DefaultWidth
+}
@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package render
+
+import "os"
+
+// terminalSize reports no terminal on platforms without terminal support
+func terminalSize(*os.File) (int, bool) {
+	return 0, false
+}
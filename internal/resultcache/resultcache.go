@@ -0,0 +1,319 @@
+// Package resultcache keeps tool results on disk, so a call repeated with the
+// same arguments within the tool's cacheTools TTL is answered without asking
+// the server. Entries are keyed by the server's name and configuration, the
+// tool and its arguments; the least recently used are evicted once the cache
+// outgrows its size cap.
+package resultcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/caches"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/toolcache"
+)
+
+// DirName is the cache directory inside the data directory
+const DirName = "result-cache"
+
+// DefaultMaxSize is the cache's size cap when resultCacheMaxMB is unset
+const DefaultMaxSize = 50 << 20
+
+// CacheName is the name the cache command knows the cache by
+const CacheName = "results"
+
+func init() {
+	caches.Register(CacheName, func(cfg *config.Configuration) (caches.Cache, error) {
+		return Default(cfg.ResultCacheMaxMB)
+	})
+}
+
+// Cache stores tool results as one file per call, in a directory per server
+type Cache struct {
+	dir     string
+	maxSize int64
+	now     func() time.Time
+}
+
+// entry is the content of one cache file
+type entry struct {
+	Server  string          `json:"server"`
+	Tool    string          `json:"tool"`
+	Stored  time.Time       `json:"stored"`
+	Expires time.Time       `json:"expires"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// Hit is a cached result
+type Hit struct {
+	Result *mcp.ToolResult
+	Stored time.Time // When the server returned the result
+}
+
+// New creates a cache in dir that holds at most maxSize bytes; zero or less
+// keeps DefaultMaxSize
+func New(dir string, maxSize int64) *Cache {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return &Cache{dir: dir, maxSize: maxSize, now: time.Now}
+}
+
+// Default creates the cache in the data directory, holding at most
+// maxMB megabytes as resultCacheMaxMB sets
+func Default(maxMB int) (*Cache, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return New(filepath.Join(dataDir, DirName), int64(maxMB)<<20), nil
+}
+
+// ReadOnly reports whether tools, a server's tool list, annotates toolName
+// as read-only. The "*" entry of cacheTools covers only such tools.
+func ReadOnly(tools []mcp.Tool, toolName string) bool {
+	for i := range tools {
+		if tools[i].Name == toolName {
+			return tools[i].IsReadOnly()
+		}
+	}
+	return false
+}
+
+// Key returns the hash identifying a call: the server, its configuration,
+// the tool and the arguments, whose object keys are encoded sorted
+func Key(serverName string, serverConfig config.ServerConfig, toolName string, args map[string]interface{}) string {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	data, _ := json.Marshal(args)
+	sum := sha256.Sum256([]byte(serverName + "\n" + toolcache.Key(serverConfig) + "\n" + toolName + "\n" + string(data)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached result of a call if there is one that has not
+// expired. A hit counts as a use for eviction.
+func (c *Cache) Get(serverName string, serverConfig config.ServerConfig, toolName string, args map[string]interface{}) (*Hit, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	path := c.path(serverName, Key(serverName, serverConfig, toolName, args))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false
+	}
+	now := c.now()
+	if now.After(e.Expires) {
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	var result mcp.ToolResult
+	if err := json.Unmarshal(e.Result, &result); err != nil {
+		return nil, false
+	}
+	result.Raw = e.Result
+	_ = os.Chtimes(path, now, now)
+	return &Hit{Result: &result, Stored: e.Stored}, true
+}
+
+// Put caches the result of a call for ttl, then evicts the least recently
+// used results if the cache has outgrown its cap. Results the tool reported
+// as failures are not cached. The file is written to a temporary name and
+// renamed into place, so concurrent processes never read a partial entry.
+func (c *Cache) Put(serverName string, serverConfig config.ServerConfig, toolName string, args map[string]interface{}, ttl time.Duration, result *mcp.ToolResult) error {
+	if c == nil || ttl <= 0 || result == nil || result.IsError {
+		return nil
+	}
+
+	raw := result.Raw
+	if len(raw) == 0 {
+		var err error
+		if raw, err = json.Marshal(result); err != nil {
+			return fmt.Errorf("failed to encode the result: %w", err)
+		}
+	}
+	now := c.now()
+	data, err := json.Marshal(entry{Server: serverName, Tool: toolName, Stored: now, Expires: now.Add(ttl), Result: raw})
+	if err != nil {
+		return fmt.Errorf("failed to encode the result: %w", err)
+	}
+
+	dir := c.serverDir(serverName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the result: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // No-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write the result: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the result: %w", err)
+	}
+	path := c.path(serverName, Key(serverName, serverConfig, toolName, args))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write the result: %w", err)
+	}
+	_ = os.Chtimes(path, now, now)
+
+	_, err = c.evict()
+	return err
+}
+
+// cachedFile is a cache file found while evicting
+type cachedFile struct {
+	path string
+	size int64
+	used time.Time
+}
+
+// Stats implements caches.Cache
+func (c *Cache) Stats() (caches.Stats, error) {
+	return caches.Scan(c.dir, caches.Stamp("stored"))
+}
+
+// GC implements caches.Cache, removing expired and unreadable results before
+// evicting as Put does
+func (c *Cache) GC() (int, error) {
+	removed, err := c.removeExpired()
+	if err != nil {
+		return removed, err
+	}
+	evicted, err := c.evict()
+	return removed + evicted, err
+}
+
+// removeExpired reads every result and removes those that expired or can't
+// be decoded, returning how many it removed
+func (c *Cache) removeExpired() (int, error) {
+	removed := 0
+	now := c.now()
+	err := walk(c.dir, func(path string, _ fs.DirEntry) error {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		var e entry
+		if err == nil && json.Unmarshal(data, &e) == nil && !now.After(e.Expires) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to remove expired results: %w", err)
+	}
+	return removed, nil
+}
+
+// evict removes the least recently used results until the cache fits its
+// cap, and returns how many it removed. It goes by the files' sizes and
+// modification times, which Get and Put set to the last use, without reading
+// them; expired results go when Get finds them or GC runs.
+func (c *Cache) evict() (int, error) {
+	var files []cachedFile
+	var total int64
+	err := walk(c.dir, func(path string, d fs.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			return nil // Removed by a concurrent eviction
+		}
+		files = append(files, cachedFile{path: path, size: info.Size(), used: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	removed := 0
+	sort.Slice(files, func(i, j int) bool { return files[i].used.Before(files[j].used) })
+	for _, file := range files {
+		if total <= c.maxSize {
+			break
+		}
+		if err := os.Remove(file.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to evict a cached result: %w", err)
+		}
+		total -= file.size
+		removed++
+	}
+	return removed, nil
+}
+
+// walk calls fn for each result file under dir
+func walk(dir string, fn func(path string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // Removed by a concurrent eviction
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+		return fn(path, d)
+	})
+}
+
+// Clear removes the cached results of serverName, or of every server if it
+// is empty, and returns how many were removed
+func (c *Cache) Clear(serverName string) (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+	dir := c.dir
+	if serverName != "" {
+		dir = c.serverDir(serverName)
+	}
+
+	removed := 0
+	err := walk(dir, func(path string, _ fs.DirEntry) error {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to remove cached results: %w", err)
+	}
+	return removed, nil
+}
+
+// serverDir returns the directory of a server's results; the name is
+// escaped so any server name makes a valid directory name
+func (c *Cache) serverDir(serverName string) string {
+	return filepath.Join(c.dir, url.QueryEscape(serverName))
+}
+
+// path returns the file of a cached call
+func (c *Cache) path(serverName, key string) string {
+	return filepath.Join(c.serverDir(serverName), key+".json")
+}
@@ -0,0 +1,174 @@
+package resultcache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+var docsServer = config.ServerConfig{Command: "npx", Args: []string{"-y", "@upstash/context7-mcp"}}
+
+func textResult(text string) *mcp.ToolResult {
+	return &mcp.ToolResult{Content: []interface{}{map[string]interface{}{"type": "text", "text": text}}}
+}
+
+func TestCacheGetPut(t *testing.T) {
+	cache := New(t.TempDir(), 0)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	args := map[string]interface{}{"libraryName": "react", "tokens": 5000}
+
+	if _, ok := cache.Get("docs", docsServer, "get-library-docs", args); ok {
+		t.Fatal("an empty cache should miss")
+	}
+	if err := cache.Put("docs", docsServer, "get-library-docs", args, time.Hour, textResult("React docs")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Arguments match whatever order they were given in
+	var reordered map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"tokens": 5000, "libraryName": "react"}`), &reordered)
+	hit, ok := cache.Get("docs", docsServer, "get-library-docs", reordered)
+	if !ok {
+		t.Fatal("want a hit for the same arguments")
+	}
+	block, _ := hit.Result.Content[0].(map[string]interface{})
+	if block["text"] != "React docs" || len(hit.Result.Raw) == 0 || !hit.Stored.Equal(now) {
+		t.Errorf("unexpected hit: %+v", hit)
+	}
+
+	// Other arguments, tools and configurations miss
+	if _, ok := cache.Get("docs", docsServer, "get-library-docs", map[string]interface{}{"libraryName": "vue"}); ok {
+		t.Error("other arguments should miss")
+	}
+	if _, ok := cache.Get("docs", docsServer, "resolve-library-id", args); ok {
+		t.Error("another tool should miss")
+	}
+	changed := docsServer
+	changed.Env = map[string]string{"DEFAULT_MINIMUM_TOKENS": "1000"}
+	if _, ok := cache.Get("docs", changed, "get-library-docs", args); ok {
+		t.Error("a changed configuration should miss")
+	}
+
+	// Expired results miss and are removed
+	now = now.Add(61 * time.Minute)
+	if _, ok := cache.Get("docs", docsServer, "get-library-docs", args); ok {
+		t.Error("an expired result should miss")
+	}
+	if files, _ := filepath.Glob(filepath.Join(cache.serverDir("docs"), "*.json")); len(files) != 0 {
+		t.Errorf("expired result not removed: %v", files)
+	}
+}
+
+func TestCacheSkipsErrors(t *testing.T) {
+	cache := New(t.TempDir(), 0)
+	failed := textResult("rate limited")
+	failed.IsError = true
+	if err := cache.Put("docs", docsServer, "get-library-docs", nil, time.Hour, failed); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("docs", docsServer, "get-library-docs", nil); ok {
+		t.Error("a failed result should not be cached")
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := New(t.TempDir(), 0)
+	entrySize := func() int64 {
+		files, _ := filepath.Glob(filepath.Join(cache.serverDir("docs"), "*.json"))
+		info, _ := os.Stat(files[0])
+		return info.Size()
+	}
+	start := time.Now()
+	put := func(library string, at time.Duration) {
+		cache.now = func() time.Time { return start.Add(at) }
+		if err := cache.Put("docs", docsServer, "get-library-docs", map[string]interface{}{"libraryName": library}, time.Hour, textResult(library)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	put("aaaa", 0)
+	cache.maxSize = 2*entrySize() + 1 // Room for two entries
+	put("bbbb", time.Minute)
+
+	// Using the first entry makes the second the least recently used
+	cache.now = func() time.Time { return start.Add(2 * time.Minute) }
+	if _, ok := cache.Get("docs", docsServer, "get-library-docs", map[string]interface{}{"libraryName": "aaaa"}); !ok {
+		t.Fatal("want a hit")
+	}
+	put("cccc", 3*time.Minute)
+
+	cache.now = func() time.Time { return start.Add(4 * time.Minute) }
+	for library, want := range map[string]bool{"aaaa": true, "bbbb": false, "cccc": true} {
+		if _, ok := cache.Get("docs", docsServer, "get-library-docs", map[string]interface{}{"libraryName": library}); ok != want {
+			t.Errorf("%s cached = %v, want %v", library, ok, want)
+		}
+	}
+}
+
+func TestCacheClear(t *testing.T) {
+	cache := New(t.TempDir(), 0)
+	for i, server := range []string{"docs", "docs", "odd/name:1"} {
+		if err := cache.Put(server, docsServer, "get-library-docs", map[string]interface{}{"page": i}, time.Hour, textResult("x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := cache.Clear("odd/name:1")
+	if err != nil || removed != 1 {
+		t.Fatalf("Clear(server) = %d, %v; want 1", removed, err)
+	}
+	if removed, err := cache.Clear("never-cached"); err != nil || removed != 0 {
+		t.Errorf("Clear(missing) = %d, %v", removed, err)
+	}
+	if removed, err := cache.Clear(""); err != nil || removed != 2 {
+		t.Errorf("Clear(all) = %d, %v; want 2", removed, err)
+	}
+
+	var nilCache *Cache
+	if _, ok := nilCache.Get("docs", docsServer, "get-library-docs", nil); ok {
+		t.Error("a nil cache should miss")
+	}
+	if err := nilCache.Put("docs", docsServer, "get-library-docs", nil, time.Hour, textResult("x")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGCRemovesCorruptEntries(t *testing.T) {
+	dir := t.TempDir()
+	cache := New(dir, 1<<20)
+	if err := cache.Put("srv", docsServer, "echo", nil, time.Hour, textResult("kept")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Put only looks at sizes and times, so within the cap it leaves the
+	// corrupt file and an expired result alone
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	if err := cache.Put("srv", docsServer, "echo", map[string]interface{}{"text": "old"}, time.Minute, textResult("old")); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+	if err := cache.Put("srv", docsServer, "echo", map[string]interface{}{"text": "new"}, time.Hour, textResult("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := cache.Stats()
+	if err != nil || stats.Entries != 3 || len(stats.Corrupt) != 1 {
+		t.Fatalf("Stats = %+v, %v; want 3 entries and 1 corrupt file", stats, err)
+	}
+	if removed, err := cache.GC(); err != nil || removed != 2 {
+		t.Errorf("GC = %d, %v; want the corrupt file and the expired result removed", removed, err)
+	}
+	if _, ok := cache.Get("srv", docsServer, "echo", nil); !ok {
+		t.Error("GC should keep live entries")
+	}
+}
@@ -0,0 +1,341 @@
+// Package sampling answers the sampling/createMessage requests servers send,
+// by handing the conversation to a language model: an external command such
+// as `llm -m gpt-4o-mini`, or an OpenAI-compatible HTTP endpoint.
+package sampling
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// Defaults for a Config that leaves them unset
+const (
+	DefaultMaxTokens = 4096
+	DefaultTimeout   = 120 * time.Second
+)
+
+// Stop reasons reported in a CreateMessageResult
+const (
+	StopEndTurn      = "endTurn"
+	StopMaxTokens    = "maxTokens"
+	StopStopSequence = "stopSequence"
+)
+
+// redacted stands in for prompts and replies in logs
+const redacted = "[redacted]"
+
+// Config is the sampling section of daemon.json. Exactly one of Command and
+// Endpoint is set.
+type Config struct {
+	Command    string `json:"command,omitempty"`    // Run with the prompt on stdin, printing the reply, e.g. "llm -m gpt-4o-mini"
+	Endpoint   string `json:"endpoint,omitempty"`   // OpenAI-compatible API base URL, e.g. "https://api.openai.com/v1"
+	Model      string `json:"model,omitempty"`      // Model asked of the endpoint (default: the server's first model hint)
+	APIKeyEnv  string `json:"apiKeyEnv,omitempty"`  // Environment variable holding the endpoint's API key
+	MaxTokens  int    `json:"maxTokens,omitempty"`  // Caps the tokens any request may ask for (default 4096)
+	Timeout    int    `json:"timeout,omitempty"`    // Seconds a reply may take (default 120)
+	LogPrompts bool   `json:"logPrompts,omitempty"` // Log prompts and replies, which are redacted by default
+}
+
+// Validate reports a configuration New would reject
+func (c *Config) Validate() error {
+	switch {
+	case c.Command == "" && c.Endpoint == "":
+		return errors.New("sampling needs a command or an endpoint")
+	case c.Command != "" && c.Endpoint != "":
+		return errors.New("sampling takes a command or an endpoint, not both")
+	case c.MaxTokens < 0:
+		return errors.New("sampling.maxTokens must not be negative")
+	case c.Timeout < 0:
+		return errors.New("sampling.timeout must not be negative")
+	}
+	return nil
+}
+
+// maxTokens returns the cap on a request's tokens
+func (c *Config) maxTokens() int {
+	if c.MaxTokens > 0 {
+		return c.MaxTokens
+	}
+	return DefaultMaxTokens
+}
+
+// timeout returns how long a reply may take
+func (c *Config) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return time.Duration(c.Timeout) * time.Second
+	}
+	return DefaultTimeout
+}
+
+// Handler answers sampling requests with the configured model. It
+// implements mcp.SamplingHandler.
+type Handler struct {
+	config     Config
+	httpClient *http.Client
+	logger     *slog.Logger // Nil logs to the shared logger
+}
+
+// New returns a handler for config
+func New(config Config) (*Handler, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &Handler{config: config, httpClient: &http.Client{}}, nil
+}
+
+// log returns the logger, looked up on each call since the CLI configures
+// the shared one after startup
+func (h *Handler) log() *slog.Logger {
+	if h.logger != nil {
+		return h.logger
+	}
+	return logging.Logger()
+}
+
+// HandleSamplingRequest implements mcp.SamplingHandler. The reply is cut at
+// the request's maxTokens, capped by the configuration.
+func (h *Handler) HandleSamplingRequest(ctx context.Context, request *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	if len(request.Messages) == 0 {
+		return nil, errors.New("sampling request has no messages")
+	}
+	maxTokens := h.config.maxTokens()
+	if request.MaxTokens > 0 {
+		maxTokens = min(request.MaxTokens, maxTokens)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.config.timeout())
+	defer cancel()
+
+	h.log().Debug("sampling request", "messages", len(request.Messages), "maxTokens", maxTokens, "prompt", h.loggable(renderPrompt(request)))
+	start := time.Now()
+	var result *mcp.CreateMessageResult
+	var err error
+	if h.config.Command != "" {
+		result, err = h.runCommand(ctx, request, maxTokens)
+	} else {
+		result, err = h.callEndpoint(ctx, request, maxTokens)
+	}
+	if err != nil {
+		h.log().Debug("sampling failed", "error", err)
+		return nil, fmt.Errorf("sampling failed: %w", err)
+	}
+	h.log().Debug("sampling reply", "model", result.Model, "stopReason", result.StopReason, "duration", time.Since(start).Round(time.Millisecond), "reply", h.loggable(result.Content.Text))
+	return result, nil
+}
+
+// loggable returns text for a log line: itself with logPrompts, else redacted
+func (h *Handler) loggable(text string) string {
+	if h.config.LogPrompts {
+		return text
+	}
+	return redacted
+}
+
+// renderPrompt writes the conversation as the plain text a command reads:
+// the system prompt and each message under a role heading. A lone user
+// message is passed on as it is.
+func renderPrompt(request *mcp.CreateMessageRequest) string {
+	if request.SystemPrompt == "" && len(request.Messages) == 1 && request.Messages[0].Role == "user" {
+		return request.Messages[0].Content
+	}
+
+	var b strings.Builder
+	if request.SystemPrompt != "" {
+		fmt.Fprintf(&b, "System: %s\n\n", request.SystemPrompt)
+	}
+	for i, message := range request.Messages {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		role := message.Role
+		if role != "" {
+			role = strings.ToUpper(role[:1]) + role[1:]
+		}
+		fmt.Fprintf(&b, "%s: %s", role, message.Content)
+	}
+	return b.String()
+}
+
+// runCommand passes the rendered prompt to the command on stdin and takes
+// its output as the reply. Tokens are counted as words, so the reply is cut
+// after maxTokens of them.
+func (h *Handler) runCommand(ctx context.Context, request *mcp.CreateMessageRequest, maxTokens int) (*mcp.CreateMessageResult, error) {
+	fields := strings.Fields(h.config.Command)
+	cmd := exec.CommandContext(ctx, fields[0], fields[1:]...)
+	cmd.Stdin = strings.NewReader(renderPrompt(request))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s: %w: %s", fields[0], err, message)
+		}
+		return nil, fmt.Errorf("%s: %w", fields[0], err)
+	}
+
+	reply := strings.TrimSpace(string(output))
+	stopReason := StopEndTurn
+	if cut, ok := cutAtStopSequence(reply, request.StopSequences); ok {
+		reply, stopReason = cut, StopStopSequence
+	}
+	if words := strings.Fields(reply); len(words) > maxTokens {
+		reply, stopReason = strings.Join(words[:maxTokens], " "), StopMaxTokens
+	}
+
+	model := h.config.Model
+	if model == "" {
+		model = h.config.Command
+	}
+	return &mcp.CreateMessageResult{
+		Role:       "assistant",
+		Content:    mcp.Content{Type: "text", Text: reply},
+		Model:      model,
+		StopReason: stopReason,
+	}, nil
+}
+
+// cutAtStopSequence cuts text at the first stop sequence it contains
+func cutAtStopSequence(text string, stopSequences []string) (string, bool) {
+	cut := -1
+	for _, stop := range stopSequences {
+		if i := strings.Index(text, stop); stop != "" && i >= 0 && (cut < 0 || i < cut) {
+			cut = i
+		}
+	}
+	if cut < 0 {
+		return text, false
+	}
+	return strings.TrimSpace(text[:cut]), true
+}
+
+// chatRequest is an OpenAI-compatible chat completion request
+type chatRequest struct {
+	Model     string        `json:"model"`
+	Messages  []chatMessage `json:"messages"`
+	MaxTokens int           `json:"max_tokens"`
+	Stop      []string      `json:"stop,omitempty"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatResponse is the part of a chat completion the reply is taken from
+type chatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// callEndpoint asks the endpoint's chat completions API for the reply
+func (h *Handler) callEndpoint(ctx context.Context, request *mcp.CreateMessageRequest, maxTokens int) (*mcp.CreateMessageResult, error) {
+	model := h.config.Model
+	if model == "" && request.ModelPreferences != nil && len(request.ModelPreferences.Hints) > 0 {
+		model = request.ModelPreferences.Hints[0].Name
+	}
+	if model == "" {
+		return nil, errors.New("no model: set sampling.model")
+	}
+
+	chat := chatRequest{Model: model, MaxTokens: maxTokens, Stop: request.StopSequences}
+	if request.SystemPrompt != "" {
+		chat.Messages = append(chat.Messages, chatMessage{Role: "system", Content: request.SystemPrompt})
+	}
+	for _, message := range request.Messages {
+		chat.Messages = append(chat.Messages, chatMessage{Role: message.Role, Content: message.Content})
+	}
+	body, err := json.Marshal(chat)
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSuffix(h.config.Endpoint, "/") + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if h.config.APIKeyEnv != "" {
+		if key := os.Getenv(h.config.APIKeyEnv); key != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+key)
+		}
+	}
+
+	resp, err := h.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var reply chatResponse
+	decodeErr := json.Unmarshal(data, &reply)
+	switch {
+	case resp.StatusCode != http.StatusOK && decodeErr == nil && reply.Error != nil:
+		return nil, fmt.Errorf("endpoint returned status %d: %s", resp.StatusCode, reply.Error.Message)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("endpoint returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	case decodeErr != nil:
+		return nil, fmt.Errorf("invalid endpoint response: %w", decodeErr)
+	case len(reply.Choices) == 0:
+		return nil, errors.New("endpoint returned no choices")
+	}
+
+	choice := reply.Choices[0]
+	result := &mcp.CreateMessageResult{
+		Role:       "assistant",
+		Content:    mcp.Content{Type: "text", Text: choice.Message.Content},
+		Model:      reply.Model,
+		StopReason: stopReason(choice.FinishReason),
+	}
+	if result.Model == "" {
+		result.Model = model
+	}
+	if reply.Usage != nil {
+		result.TokenUsage = &mcp.TokenUsage{
+			PromptTokens:     reply.Usage.PromptTokens,
+			CompletionTokens: reply.Usage.CompletionTokens,
+			TotalTokens:      reply.Usage.TotalTokens,
+		}
+	}
+	return result, nil
+}
+
+// stopReason maps a chat completion's finish reason to MCP's stop reasons;
+// others are passed on as they are
+func stopReason(finishReason string) string {
+	switch finishReason {
+	case "stop", "":
+		return StopEndTurn
+	case "length":
+		return StopMaxTokens
+	}
+	return finishReason
+}
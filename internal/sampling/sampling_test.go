@@ -0,0 +1,186 @@
+package sampling
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// llmScript writes a stand-in for an LLM command that saves its prompt and
+// prints reply
+func llmScript(t *testing.T, reply string) (command, prompt string) {
+	t.Helper()
+	dir := t.TempDir()
+	prompt = filepath.Join(dir, "prompt")
+	command = filepath.Join(dir, "llm")
+	script := "#!/bin/sh\ncat > " + prompt + "\necho '" + reply + "'\n"
+	if err := os.WriteFile(command, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return command, prompt
+}
+
+func conversation() *mcp.CreateMessageRequest {
+	return &mcp.CreateMessageRequest{
+		SystemPrompt: "Be brief.",
+		Messages: []mcp.Message{
+			{Role: "user", Content: "What is MCP?"},
+			{Role: "assistant", Content: "A protocol."},
+			{Role: "user", Content: "For what?"},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, cfg := range []Config{{}, {Command: "llm", Endpoint: "http://localhost"}, {Command: "llm", MaxTokens: -1}} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	command, prompt := llmScript(t, "Connecting models to tools.")
+	h, err := New(Config{Command: command + " -m mini"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := h.HandleSamplingRequest(context.Background(), conversation())
+	if err != nil {
+		t.Fatalf("HandleSamplingRequest failed: %v", err)
+	}
+	if result.Role != "assistant" || result.Content.Type != "text" || result.Content.Text != "Connecting models to tools." {
+		t.Errorf("unexpected reply %+v", result)
+	}
+	if result.StopReason != StopEndTurn || result.Model != command+" -m mini" {
+		t.Errorf("unexpected stop reason %q or model %q", result.StopReason, result.Model)
+	}
+
+	data, _ := os.ReadFile(prompt)
+	want := "System: Be brief.\n\nUser: What is MCP?\n\nAssistant: A protocol.\n\nUser: For what?"
+	if string(data) != want {
+		t.Errorf("prompt = %q, want %q", data, want)
+	}
+}
+
+func TestCommandEnforcesLimits(t *testing.T) {
+	command, _ := llmScript(t, "one two three four five END six")
+	h, err := New(Config{Command: command, MaxTokens: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := &mcp.CreateMessageRequest{Messages: []mcp.Message{{Role: "user", Content: "Count"}}, MaxTokens: 100}
+	result, err := h.HandleSamplingRequest(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Content.Text != "one two three" || result.StopReason != StopMaxTokens {
+		t.Errorf("expected the configured cap to win, got %q (%s)", result.Content.Text, result.StopReason)
+	}
+
+	request.StopSequences = []string{"END"}
+	request.MaxTokens = 0
+	h.config.MaxTokens = 0
+	result, err = h.HandleSamplingRequest(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Content.Text != "one two three four five" || result.StopReason != StopStopSequence {
+		t.Errorf("expected the reply cut at the stop sequence, got %q (%s)", result.Content.Text, result.StopReason)
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	var got chatRequest
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"model":"gpt-4o-mini-2024","choices":[{"message":{"role":"assistant","content":"Tools."},"finish_reason":"length"}],"usage":{"prompt_tokens":20,"completion_tokens":2,"total_tokens":22}}`))
+	}))
+	defer server.Close()
+	t.Setenv("SAMPLING_TEST_KEY", "sk-test")
+
+	h, err := New(Config{Endpoint: server.URL + "/v1/", APIKeyEnv: "SAMPLING_TEST_KEY", MaxTokens: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := conversation()
+	request.MaxTokens = 200
+	request.ModelPreferences = &mcp.ModelPreferences{Hints: []mcp.ModelHint{{Name: "gpt-4o-mini"}}}
+	result, err := h.HandleSamplingRequest(context.Background(), request)
+	if err != nil {
+		t.Fatalf("HandleSamplingRequest failed: %v", err)
+	}
+
+	if got.Model != "gpt-4o-mini" || got.MaxTokens != 50 || len(got.Messages) != 4 || got.Messages[0] != (chatMessage{Role: "system", Content: "Be brief."}) {
+		t.Errorf("unexpected request %+v", got)
+	}
+	if auth != "Bearer sk-test" {
+		t.Errorf("expected the API key sent, got %q", auth)
+	}
+	if result.Content.Text != "Tools." || result.Model != "gpt-4o-mini-2024" || result.StopReason != StopMaxTokens {
+		t.Errorf("unexpected reply %+v", result)
+	}
+	if result.TokenUsage == nil || result.TokenUsage.TotalTokens != 22 {
+		t.Errorf("expected token usage, got %+v", result.TokenUsage)
+	}
+}
+
+func TestEndpointError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"invalid api key"}}`))
+	}))
+	defer server.Close()
+
+	h, _ := New(Config{Endpoint: server.URL, Model: "mini"})
+	_, err := h.HandleSamplingRequest(context.Background(), conversation())
+	if err == nil || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("expected the endpoint's error, got %v", err)
+	}
+}
+
+func TestPromptsRedactedFromLogs(t *testing.T) {
+	command, _ := llmScript(t, "secret reply")
+	for _, logPrompts := range []bool{false, true} {
+		var logs bytes.Buffer
+		h, _ := New(Config{Command: command, LogPrompts: logPrompts})
+		h.logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		request := &mcp.CreateMessageRequest{Messages: []mcp.Message{{Role: "user", Content: "secret prompt"}}}
+		if _, err := h.HandleSamplingRequest(context.Background(), request); err != nil {
+			t.Fatal(err)
+		}
+		leaked := strings.Contains(logs.String(), "secret prompt") || strings.Contains(logs.String(), "secret reply")
+		if leaked != logPrompts {
+			t.Errorf("logPrompts=%v, but prompts logged: %v\n%s", logPrompts, leaked, logs.String())
+		}
+	}
+}
+
+func TestMessageContentBlocks(t *testing.T) {
+	var request mcp.CreateMessageRequest
+	data := `{"messages":[{"role":"user","content":{"type":"text","text":"hi"}},{"role":"assistant","content":"hello"}],"maxTokens":10}`
+	if err := json.Unmarshal([]byte(data), &request); err != nil {
+		t.Fatal(err)
+	}
+	if request.Messages[0].Content != "hi" || request.Messages[1].Content != "hello" {
+		t.Errorf("unexpected messages %+v", request.Messages)
+	}
+}
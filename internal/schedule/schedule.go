@@ -0,0 +1,169 @@
+// Package schedule describes tool calls the daemon makes on a schedule. The
+// entries live in schedules.json in the configuration directory: the CLI
+// edits the file and the daemon runs what it lists.
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// FileName is the schedules file inside the configuration directory
+const FileName = "schedules.json"
+
+// MinInterval is the shortest interval a schedule may run at
+const MinInterval = time.Minute
+
+// Schedule is a tool call made every interval or whenever a cron expression
+// matches
+type Schedule struct {
+	Name     string                 `json:"name"`
+	Server   string                 `json:"server"`
+	Tool     string                 `json:"tool"`
+	Args     map[string]interface{} `json:"args,omitempty"`
+	Interval string                 `json:"interval,omitempty"` // e.g. "30m" or "1d"
+	Cron     string                 `json:"cron,omitempty"`     // minute hour day-of-month month day-of-week
+	Enabled  *bool                  `json:"enabled,omitempty"`  // Defaults to true
+}
+
+// file is the content of schedules.json
+type file struct {
+	Schedules []Schedule `json:"schedules"`
+}
+
+// IsEnabled returns whether the schedule runs; it does unless disabled
+func (s *Schedule) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// Validate checks that the schedule names a call and exactly one of an
+// interval or a cron expression
+func (s *Schedule) Validate() error {
+	switch {
+	case s.Name == "":
+		return errors.New("schedule has no name")
+	case s.Server == "":
+		return fmt.Errorf("schedule %s has no server", s.Name)
+	case s.Tool == "":
+		return fmt.Errorf("schedule %s has no tool", s.Name)
+	case s.Interval == "" && s.Cron == "":
+		return fmt.Errorf("schedule %s needs an interval or a cron expression", s.Name)
+	case s.Interval != "" && s.Cron != "":
+		return fmt.Errorf("schedule %s has both an interval and a cron expression", s.Name)
+	}
+
+	if s.Cron != "" {
+		if _, err := ParseCron(s.Cron); err != nil {
+			return fmt.Errorf("schedule %s: %w", s.Name, err)
+		}
+		return nil
+	}
+	interval, err := config.ParseRetention(s.Interval)
+	if err != nil {
+		return fmt.Errorf("schedule %s: invalid interval: %w", s.Name, err)
+	}
+	if interval < MinInterval {
+		return fmt.Errorf("schedule %s: interval %s is shorter than %s", s.Name, s.Interval, MinInterval)
+	}
+	return nil
+}
+
+// Next returns when the schedule runs next after t, or the zero time for an
+// invalid schedule, which never runs
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.Cron != "" {
+		expr, err := ParseCron(s.Cron)
+		if err != nil {
+			return time.Time{}
+		}
+		return expr.Next(t)
+	}
+	interval, err := config.ParseRetention(s.Interval)
+	if err != nil || interval < MinInterval {
+		return time.Time{}
+	}
+	return t.Add(interval)
+}
+
+// Every describes how often the schedule runs
+func (s *Schedule) Every() string {
+	if s.Cron != "" {
+		return "cron " + s.Cron
+	}
+	return "every " + s.Interval
+}
+
+// DefaultPath returns schedules.json in the configuration directory
+func DefaultPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, FileName), nil
+}
+
+// Load reads the schedules at path, sorted by name. A missing file has none.
+func Load(path string) ([]Schedule, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(f.Schedules))
+	for i := range f.Schedules {
+		if err := f.Schedules[i].Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", path, err)
+		}
+		if seen[f.Schedules[i].Name] {
+			return nil, fmt.Errorf("invalid %s: schedule %s is defined twice", path, f.Schedules[i].Name)
+		}
+		seen[f.Schedules[i].Name] = true
+	}
+	sort.Slice(f.Schedules, func(i, j int) bool { return f.Schedules[i].Name < f.Schedules[j].Name })
+	return f.Schedules, nil
+}
+
+// Save writes the schedules to path, replacing the file in one step so the
+// daemon never reads a partial one
+func Save(path string, schedules []Schedule) error {
+	if schedules == nil {
+		schedules = []Schedule{}
+	}
+	data, err := json.MarshalIndent(file{Schedules: schedules}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), FileName+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
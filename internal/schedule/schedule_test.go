@@ -0,0 +1,104 @@
+package schedule
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2026, time.October, 14, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/30 * * * *", time.Date(2026, time.October, 14, 10, 30, 0, 0, time.UTC)},
+		{"* * * * *", time.Date(2026, time.October, 14, 10, 8, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, time.October, 15, 9, 0, 0, 0, time.UTC)},
+		{"15 9-18/2 * * *", time.Date(2026, time.October, 14, 11, 15, 0, 0, time.UTC)},
+		{"0 0 * * 1", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,20 * 5", time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)}, // Either day matches
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		expr, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got := expr.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5/2 * * * *", "10-5 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) should fail", expr)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Schedule{Name: "digest", Server: "scraper", Tool: "summarize", Interval: "30m"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid schedule rejected: %v", err)
+	}
+
+	tests := map[string]Schedule{
+		"no name":        {Server: "s", Tool: "t", Interval: "1h"},
+		"no schedule":    {Name: "n", Server: "s", Tool: "t"},
+		"both":           {Name: "n", Server: "s", Tool: "t", Interval: "1h", Cron: "* * * * *"},
+		"short interval": {Name: "n", Server: "s", Tool: "t", Interval: "10s"},
+		"bad cron":       {Name: "n", Server: "s", Tool: "t", Cron: "every day"},
+	}
+	for name, s := range tests {
+		if err := s.Validate(); err == nil {
+			t.Errorf("%s: Validate should fail", name)
+		}
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", FileName)
+	disabled := false
+	schedules := []Schedule{
+		{Name: "weekly", Server: "github", Tool: "report", Cron: "0 9 * * 1", Enabled: &disabled},
+		{Name: "digest", Server: "scraper", Tool: "summarize", Args: map[string]interface{}{"url": "https://example.com"}, Interval: "30m"},
+	}
+	if err := Save(path, schedules); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 2 || loaded[0].Name != "digest" || loaded[1].Name != "weekly" {
+		t.Fatalf("loaded %+v, want both schedules sorted by name", loaded)
+	}
+	if !loaded[0].IsEnabled() || loaded[1].IsEnabled() {
+		t.Error("enabled should default to true and keep an explicit false")
+	}
+	if loaded[0].Args["url"] != "https://example.com" {
+		t.Errorf("args = %v, want them kept", loaded[0].Args)
+	}
+
+	if missing, err := Load(filepath.Join(t.TempDir(), FileName)); err != nil || len(missing) != 0 {
+		t.Errorf("a missing file should have no schedules, got %v, %v", missing, err)
+	}
+
+	if err := Save(path, append(loaded, loaded[0])); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "defined twice") {
+		t.Errorf("a duplicate name should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package selftest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/pkg/version"
+)
+
+// EchoTool is the one tool of the built-in server. It answers its "message"
+// argument as text.
+const EchoTool = "echo"
+
+// echoServerName is the name the built-in server gives itself
+const echoServerName = "mcp-cli-ent-selftest"
+
+// maxMessageSize bounds the messages the built-in server reads
+const maxMessageSize = 1 << 20
+
+var echoTool = mcp.Tool{
+	Name:        EchoTool,
+	Description: "Echoes back the message",
+	InputSchema: map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"message": map[string]interface{}{"type": "string"}},
+		"required":   []string{"message"},
+	},
+}
+
+// message is an incoming JSON-RPC request or notification
+type message struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// isRequest reports whether the message expects a response
+func (m *message) isRequest() bool {
+	return m.Method != "" && len(m.ID) > 0 && string(m.ID) != "null"
+}
+
+// ServeEcho runs the built-in server on in and out, as a stdio server, until
+// in is closed. Each request's method is written to logw, so a client that
+// keeps the server's stderr can show what reached it.
+func ServeEcho(in io.Reader, out io.Writer, logw io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			fmt.Fprintf(logw, "selftest server: ignoring a message that is not JSON-RPC: %v\n", err)
+			continue
+		}
+		fmt.Fprintf(logw, "selftest server: %s\n", msg.Method)
+		if !msg.isRequest() {
+			continue
+		}
+		data, err := json.Marshal(answer(&msg))
+		if err != nil {
+			return fmt.Errorf("failed to encode the response: %w", err)
+		}
+		if _, err := out.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// answer is the built-in server's response to a request
+func answer(msg *message) *mcp.JSONRPCResponse {
+	var result interface{}
+	switch msg.Method {
+	case "initialize":
+		var params mcp.InitializeParams
+		_ = json.Unmarshal(msg.Params, &params)
+		protocolVersion := params.ProtocolVersion
+		if protocolVersion == "" {
+			protocolVersion = mcp.ProtocolVersion
+		}
+		result = &mcp.InitializeResult{
+			ProtocolVersion: protocolVersion,
+			Capabilities:    mcp.ServerCapabilities{Tools: &mcp.ToolsCapability{}},
+			ServerInfo:      mcp.ServerInfo{Name: echoServerName, Version: version.Version},
+		}
+	case "ping":
+		result = struct{}{}
+	case "tools/list":
+		result = &mcp.ListToolsResult{Tools: []mcp.Tool{echoTool}}
+	case "tools/call":
+		var params mcp.CallToolParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return mcp.NewErrorResponse(msg.ID, mcp.NewError(mcp.InvalidParams, "invalid tools/call parameters", nil))
+		}
+		if params.Name != EchoTool {
+			return mcp.NewErrorResponse(msg.ID, mcp.NewError(mcp.InvalidParams, "unknown tool: "+params.Name, nil))
+		}
+		text, _ := params.Arguments["message"].(string)
+		result = &mcp.ToolResult{Content: []interface{}{map[string]interface{}{"type": "text", "text": text}}}
+	default:
+		return mcp.NewErrorResponse(msg.ID, mcp.NewError(mcp.MethodNotFound, "method not found: "+msg.Method, nil))
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return mcp.NewErrorResponse(msg.ID, mcp.NewError(mcp.InternalError, "failed to encode result: "+err.Error(), nil))
+	}
+	return mcp.NewResponse(msg.ID, data)
+}
+
+// echoHTTP serves the built-in server over Streamable HTTP, answering each
+// request with a JSON body, and keeps a transcript of what it was sent
+type echoHTTP struct {
+	mutex      sync.Mutex
+	transcript []string
+}
+
+// ServeHTTP implements http.Handler
+func (s *echoHTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		s.note("DELETE")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		s.note(r.Method)
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var msg message
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMessageSize)).Decode(&msg); err != nil {
+		s.note("POST with an invalid body: " + err.Error())
+		http.Error(w, "invalid JSON-RPC message", http.StatusBadRequest)
+		return
+	}
+	s.note("POST " + msg.Method)
+	if msg.Method == "initialize" {
+		w.Header().Set(mcp.SessionIDHeader, "selftest")
+	}
+	if !msg.isRequest() {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(answer(&msg))
+}
+
+// note adds a line to the transcript
+func (s *echoHTTP) note(line string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.transcript = append(s.transcript, line)
+}
+
+// log returns the transcript, one request per line
+func (s *echoHTTP) log() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var b strings.Builder
+	for _, line := range s.transcript {
+		b.WriteString("selftest server: " + line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,401 @@
+// Package selftest checks, end to end, that the CLI's stack works on this
+// machine. A built-in MCP server with a single echo tool is reached over
+// stdio, over Streamable HTTP, through a persistent session and, when asked,
+// through a daemon started for the purpose. Each stage is timed and reports
+// what the parts involved logged when it fails.
+package selftest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/internal/session"
+	"github.com/mcp-cli-ent/mcp-cli/pkg/version"
+)
+
+// Stages, in the order they run
+const (
+	StageStdio       = "stdio"        // A stdio client starting the server
+	StageHTTP        = "http"         // An HTTP client of the server on a loopback port
+	StageSession     = "session"      // A persistent session reused across clients
+	StageDaemonStart = "daemon-start" // Starting a daemon in the background
+	StageDaemonCall  = "daemon-call"  // A tool call through the daemon
+	StageDaemonStop  = "daemon-stop"  // Stopping the daemon
+)
+
+// serverName is what the stages call the built-in server
+const serverName = "selftest"
+
+// logTailLines is how much of a log file a failed stage reports
+const logTailLines = 40
+
+// Options configures Run
+type Options struct {
+	Server     config.ServerConfig // Starts the built-in server over stdio, running ServeEcho
+	WithDaemon bool                // Also run the daemon stages
+	Timeout    time.Duration       // Of each stage (default 30s)
+}
+
+// Result is the outcome of one stage
+type Result struct {
+	Stage      string `json:"stage"`
+	OK         bool   `json:"ok"`
+	DurationMS int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+	Logs       string `json:"logs,omitempty"` // What the parts involved logged, when the stage failed
+}
+
+// Duration returns how long the stage took
+func (r *Result) Duration() time.Duration {
+	return time.Duration(r.DurationMS) * time.Millisecond
+}
+
+// stage is a step of the test. It writes what the parts involved logged to
+// logs, which is reported only if it fails.
+type stage struct {
+	name string
+	run  func(ctx context.Context, logs *strings.Builder) error
+}
+
+// Run runs the stages in order, handing each result to report as it
+// finishes, and returns them all. Sessions and daemon files go to a temporary
+// directory removed afterwards, and the daemon is an instance of its own, so
+// neither the configuration nor a daemon already running is touched. The
+// error is for failing to set the test up; failed stages are in the results.
+func Run(ctx context.Context, opts Options, report func(Result)) ([]Result, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	dir, err := os.MkdirTemp("", "mcp-cli-ent-selftest-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a directory for the test: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	restore := setenv(map[string]string{
+		config.ConfigDirEnv:  dir,
+		config.DataDirEnv:    dir,
+		config.DaemonNameEnv: fmt.Sprintf("selftest-%d", os.Getpid()),
+	})
+	defer restore()
+
+	r := &runner{opts: opts, dir: dir}
+	stages := []stage{
+		{StageStdio, r.stdioStage},
+		{StageHTTP, r.httpStage},
+		{StageSession, r.sessionStage},
+	}
+	if opts.WithDaemon {
+		r.manager = daemon.NewDaemonManager()
+		r.daemon = daemon.NewDaemonClient()
+		stages = append(stages,
+			stage{StageDaemonStart, r.daemonStartStage},
+			stage{StageDaemonCall, r.daemonCallStage},
+			stage{StageDaemonStop, r.daemonStopStage},
+		)
+	}
+
+	results := make([]Result, 0, len(stages))
+	started := false
+	for _, s := range stages {
+		var result Result
+		switch {
+		case ctx.Err() != nil:
+			result = Result{Stage: s.name, Error: "not run: " + ctx.Err().Error()}
+		case !started && (s.name == StageDaemonCall || s.name == StageDaemonStop):
+			result = Result{Stage: s.name, Error: "not run: the daemon did not start"}
+		default:
+			result = runStage(ctx, s, opts.Timeout)
+			started = started || (s.name == StageDaemonStart && result.OK)
+		}
+		results = append(results, result)
+		if report != nil {
+			report(result)
+		}
+	}
+
+	// A daemon left behind by an interrupted run would outlive the test
+	if started && ctx.Err() != nil {
+		_ = quietly(r.manager.Stop)
+	}
+	return results, nil
+}
+
+// runner holds what the stages share
+type runner struct {
+	opts Options
+	dir  string // Holds the sessions and daemon files
+
+	manager *daemon.DaemonManager // Of the daemon instance the daemon stages start
+	daemon  *daemon.DaemonClient
+}
+
+// runStage runs s bounded by timeout, timing it
+func runStage(ctx context.Context, s stage, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var logs strings.Builder
+	start := time.Now()
+	err := s.run(ctx, &logs)
+	result := Result{Stage: s.name, OK: err == nil, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+		result.Logs = strings.TrimRight(logs.String(), "\n")
+	}
+	return result
+}
+
+// stdioStage calls the server through a stdio client that starts it
+func (r *runner) stdioStage(ctx context.Context, logs *strings.Builder) error {
+	serverConfig := r.opts.Server
+	c, err := client.NewMCPClientWithOptions(serverConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create the client: %w", err)
+	}
+	defer c.Close()
+	defer logServer(serverConfig, c, logs)
+	return exercise(ctx, c, "hello over stdio")
+}
+
+// httpStage calls the server through an HTTP client, serving it on a
+// loopback port for the stage
+func (r *runner) httpStage(ctx context.Context, logs *strings.Builder) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen on a loopback port: %w", err)
+	}
+	handler := &echoHTTP{}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+	defer func() { logs.WriteString(handler.log()) }()
+
+	c, err := client.NewMCPClientWithOptions(config.ServerConfig{URL: "http://" + listener.Addr().String() + "/mcp"})
+	if err != nil {
+		return fmt.Errorf("failed to create the client: %w", err)
+	}
+	defer c.Close()
+	return exercise(ctx, c, "hello over HTTP")
+}
+
+// sessionStage starts the server as a persistent session, then checks that a
+// second client gets the same session rather than a new server
+func (r *runner) sessionStage(ctx context.Context, logs *strings.Builder) error {
+	serverConfig := r.opts.Server
+	manager, err := session.NewManager(r.dir, func(serverConfig config.ServerConfig) (mcp.MCPClient, error) {
+		return client.NewMCPClientWithOptions(serverConfig)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create the session manager: %w", err)
+	}
+	defer manager.Close()
+	defer logSessions(manager, logs)
+	fmt.Fprintf(logs, "server command: %s\n", strings.Join(append([]string{serverConfig.Command}, serverConfig.Args...), " "))
+
+	serverConfig.Session.Type = "persistent"
+	serverConfig.Session.Strict = true
+	defer func() { _ = manager.StopAllSessions() }() // Should a call fail
+
+	factory := client.NewSessionAwareClientFactory(manager)
+	var first session.Session
+	for i, text := range []string{"hello from a new session", "hello from the same session"} {
+		c, err := factory.CreateClient(serverName, serverConfig)
+		if err != nil {
+			return err
+		}
+		if err := call(ctx, c, text); err != nil {
+			return err
+		}
+		_ = c.Close()
+
+		sess, err := manager.GetSessionByName(serverName)
+		if err != nil {
+			return err
+		}
+		if sess.Type() != session.Persistent || sess.Status() != session.Active {
+			return fmt.Errorf("after call %d the session is %s and %s, want a persistent session that is active", i+1, sess.Type(), sess.Status())
+		}
+		if first == nil {
+			first = sess
+		} else if sess != first {
+			return errors.New("the second client got a new session instead of reusing the first")
+		}
+	}
+	return manager.StopSession(serverName)
+}
+
+// daemonStartStage starts the daemon in the background and waits until it
+// answers
+func (r *runner) daemonStartStage(ctx context.Context, logs *strings.Builder) error {
+	err := quietly(func() error { return r.manager.Start(false) }, logs)
+	if err == nil {
+		err = waitForDaemon(ctx, r.daemon)
+	}
+	if err != nil {
+		logDaemon(logs)
+	}
+	return err
+}
+
+// waitForDaemon waits until the daemon answers status requests
+func waitForDaemon(ctx context.Context, dc *daemon.DaemonClient) error {
+	for {
+		if status, err := dc.GetStatus(); err == nil && status.Running {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("the daemon did not answer: %w", ctx.Err())
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// daemonCallStage calls the server through the daemon, which starts it
+func (r *runner) daemonCallStage(ctx context.Context, logs *strings.Builder) error {
+	err := r.daemon.StartSession(ctx, serverName, r.opts.Server)
+	if err == nil {
+		var result *mcp.ToolResult
+		if result, err = r.daemon.CallTool(ctx, serverName, EchoTool, map[string]interface{}{"message": "hello through the daemon"}, true); err == nil {
+			err = checkEcho(result, "hello through the daemon")
+		}
+	}
+	if err != nil {
+		logDaemon(logs)
+	}
+	return err
+}
+
+// daemonStopStage stops the daemon and checks that it is gone
+func (r *runner) daemonStopStage(ctx context.Context, logs *strings.Builder) error {
+	err := quietly(r.manager.Stop, logs)
+	if err == nil && r.daemon.IsDaemonRunning() {
+		err = errors.New("the daemon is still running")
+	}
+	if err != nil {
+		logDaemon(logs)
+	}
+	return err
+}
+
+// exercise initializes c, lists its tools and calls the echo tool
+func exercise(ctx context.Context, c mcp.MCPClient, text string) error {
+	if _, err := c.Initialize(ctx, &mcp.InitializeParams{
+		ProtocolVersion: mcp.ProtocolVersion,
+		ClientInfo:      mcp.ClientInfo{Name: "mcp-cli-ent-selftest", Version: version.Version},
+	}); err != nil {
+		return err
+	}
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return err
+	}
+	if len(tools) != 1 || tools[0].Name != EchoTool {
+		return fmt.Errorf("the server lists %d tools, want only %s", len(tools), EchoTool)
+	}
+	return call(ctx, c, text)
+}
+
+// call calls the echo tool and checks its answer
+func call(ctx context.Context, c mcp.MCPClient, text string) error {
+	result, err := c.CallTool(ctx, EchoTool, map[string]interface{}{"message": text})
+	if err != nil {
+		return err
+	}
+	return checkEcho(result, text)
+}
+
+// checkEcho checks that the echo tool answered text
+func checkEcho(result *mcp.ToolResult, text string) error {
+	blocks := result.Blocks()
+	if result.IsError || len(blocks) != 1 || blocks[0].Text != text {
+		data, _ := json.Marshal(result)
+		return fmt.Errorf("the echo tool answered %s, want the text %q", data, text)
+	}
+	return nil
+}
+
+// logServer adds how the stdio server was started, and what it wrote to
+// stderr, to logs
+func logServer(serverConfig config.ServerConfig, c mcp.MCPClient, logs *strings.Builder) {
+	fmt.Fprintf(logs, "server command: %s\n", strings.Join(append([]string{serverConfig.Command}, serverConfig.Args...), " "))
+	if stdio, ok := c.(interface{ StderrTail() string }); ok {
+		logs.WriteString(stdio.StderrTail())
+	}
+}
+
+// logSessions adds the sessions manager knows about to logs
+func logSessions(manager *session.Manager, logs *strings.Builder) {
+	sessions, err := manager.ListSessions()
+	if err != nil {
+		fmt.Fprintf(logs, "failed to list sessions: %v\n", err)
+		return
+	}
+	data, _ := json.MarshalIndent(sessions, "", "  ")
+	fmt.Fprintf(logs, "sessions: %s\n", data)
+}
+
+// logDaemon adds the end of the daemon's log file to logs
+func logDaemon(logs *strings.Builder) {
+	path := daemon.GetLogFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(logs, "no daemon log: %v\n", err)
+		return
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > logTailLines {
+		lines = lines[len(lines)-logTailLines:]
+	}
+	fmt.Fprintf(logs, "%s:\n%s\n", path, strings.Join(lines, "\n"))
+}
+
+// quietly runs fn with what the daemon manager logs going to logs rather than
+// stderr, where it would be mixed into the report
+func quietly(fn func() error, logs ...*strings.Builder) error {
+	var captured strings.Builder
+	previous := log.Writer()
+	log.SetOutput(&captured)
+	defer func() {
+		log.SetOutput(previous)
+		for _, l := range logs {
+			l.WriteString(captured.String())
+		}
+	}()
+	return fn()
+}
+
+// setenv sets environment variables, returning a function restoring them
+func setenv(vars map[string]string) func() {
+	previous := make(map[string]*string, len(vars))
+	for name, value := range vars {
+		if old, set := os.LookupEnv(name); set {
+			previous[name] = &old
+		} else {
+			previous[name] = nil
+		}
+		_ = os.Setenv(name, value)
+	}
+	return func() {
+		for name, old := range previous {
+			if old == nil {
+				_ = os.Unsetenv(name)
+			} else {
+				_ = os.Setenv(name, *old)
+			}
+		}
+	}
+}
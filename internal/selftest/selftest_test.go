@@ -0,0 +1,102 @@
+package selftest
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// echoServerEnv makes the test binary run the built-in server; see
+// TestEchoServerHelper
+const echoServerEnv = "MCP_SELFTEST_ECHO_SERVER"
+
+// TestEchoServerHelper is the built-in server when echoServer starts the test
+// binary, and does nothing otherwise
+func TestEchoServerHelper(t *testing.T) {
+	if os.Getenv(echoServerEnv) == "" {
+		return
+	}
+	if err := ServeEcho(os.Stdin, os.Stdout, os.Stderr); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// echoServer starts the built-in server in a copy of the test binary
+func echoServer() config.ServerConfig {
+	return config.ServerConfig{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestEchoServerHelper$"},
+		Env:     map[string]string{echoServerEnv: "1"},
+	}
+}
+
+func TestRun(t *testing.T) {
+	dataDir := os.Getenv(config.DataDirEnv)
+	var reported []string
+	results, err := Run(context.Background(), Options{Server: echoServer(), Timeout: 10 * time.Second}, func(r Result) {
+		reported = append(reported, r.Stage)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(reported, ",") != "stdio,http,session" {
+		t.Errorf("reported stages %v, want stdio, http and session", reported)
+	}
+	for _, result := range results {
+		if !result.OK {
+			t.Errorf("%s failed: %s\n%s", result.Stage, result.Error, result.Logs)
+		}
+		if result.Logs != "" {
+			t.Errorf("%s passed but reported logs: %s", result.Stage, result.Logs)
+		}
+	}
+	if os.Getenv(config.DataDirEnv) != dataDir {
+		t.Error("Run left its data directory in the environment")
+	}
+}
+
+func TestRunReportsFailures(t *testing.T) {
+	server := echoServer()
+	server.Env = nil // The test binary runs its tests instead of serving
+	results, err := Run(context.Background(), Options{Server: server, Timeout: 5 * time.Second}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byStage := make(map[string]Result)
+	for _, result := range results {
+		byStage[result.Stage] = result
+	}
+	if stdio := byStage[StageStdio]; stdio.OK || stdio.Error == "" {
+		t.Errorf("stdio = %+v, want a failure", stdio)
+	}
+	if session := byStage[StageSession]; session.OK {
+		t.Errorf("session = %+v, want a failure", session)
+	}
+	if http := byStage[StageHTTP]; !http.OK {
+		t.Errorf("http does not need the stdio server: %+v", http)
+	}
+}
+
+func TestRunSkipsDaemonStagesWhenInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := Run(ctx, Options{Server: echoServer(), WithDaemon: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 6 {
+		t.Fatalf("got %d results, want all six stages", len(results))
+	}
+	for _, result := range results {
+		if result.OK || !strings.HasPrefix(result.Error, "not run") {
+			t.Errorf("%s = %+v, want it not run", result.Stage, result)
+		}
+	}
+}
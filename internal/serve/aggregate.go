@@ -0,0 +1,671 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/exports"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/pkg/version"
+)
+
+// ProtocolVersion is announced when the client does not ask for one
+const ProtocolVersion = mcp.ProtocolVersion
+
+// PermissionDenied is the JSON-RPC error code for requests the export policy refuses
+const PermissionDenied = -32001
+
+// Timing defaults
+const (
+	// DefaultPollInterval is how often backends that announce list changes
+	// are pinged, which reads any notifications they have queued
+	DefaultPollInterval = 30 * time.Second
+
+	// DefaultStartTimeout bounds how long a backend may take to initialize
+	DefaultStartTimeout = 60 * time.Second
+)
+
+// listChangedNotifications are the backend notifications forwarded upward
+var listChangedNotifications = map[string]bool{
+	"notifications/tools/list_changed":     true,
+	"notifications/resources/list_changed": true,
+	"notifications/prompts/list_changed":   true,
+}
+
+// exportsChangedNotifications tell the client to list again after the export
+// policy changes
+var exportsChangedNotifications = []string{
+	"notifications/tools/list_changed",
+	"notifications/resources/list_changed",
+	"notifications/prompts/list_changed",
+}
+
+// ClientFactory connects to one backend server
+type ClientFactory func(serverName string, serverConfig config.ServerConfig) (mcp.MCPClient, error)
+
+// settings configure a transport and every aggregate it creates
+type settings struct {
+	cfg          *config.Configuration
+	newClient    ClientFactory
+	pollInterval time.Duration
+	startTimeout time.Duration
+	policy       atomic.Pointer[exports.Policy] // Swapped when the exports section is reloaded
+}
+
+// newSettings returns the default settings for cfg
+func newSettings(cfg *config.Configuration, newClient ClientFactory) settings {
+	return settings{
+		cfg:          cfg,
+		newClient:    newClient,
+		pollInterval: DefaultPollInterval,
+		startTimeout: DefaultStartTimeout,
+	}
+}
+
+// loadExports compiles the configuration's exports section
+func (s *settings) loadExports() error {
+	return s.setExports(s.cfg.Exports)
+}
+
+// setExports replaces the export policy; running backends are kept
+func (s *settings) setExports(cfg *config.ExportsConfig) error {
+	policy, err := exports.New(cfg)
+	if err != nil {
+		return err
+	}
+	s.policy.Store(policy)
+	return nil
+}
+
+// aggregate is the routing core shared by the stdio and HTTP transports: one
+// set of backend connections and the requests answered against them
+type aggregate struct {
+	*settings
+	notify func(method string) // Passes a list_changed notification to the client
+	namer  namer
+
+	startOnce      sync.Once
+	mutex          sync.RWMutex
+	backends       []*backend          // Started backends, sorted by name
+	tools          nameTable           // Exported tool names, as last listed
+	prompts        nameTable           // Exported prompt names, as last listed
+	resourceOwners map[string]*backend // Resource URI to the backend that listed it
+	closed         bool
+
+	stop chan struct{}
+}
+
+// backend is a connected server
+type backend struct {
+	name   string
+	config config.ServerConfig
+	client mcp.MCPClient
+	caps   mcp.ServerCapabilities
+}
+
+// notification is an outgoing JSON-RPC notification, which has no id
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+}
+
+// newAggregate creates an aggregate whose backends start on the first request
+func newAggregate(s *settings, notify func(method string)) *aggregate {
+	return &aggregate{
+		settings:       s,
+		notify:         notify,
+		namer:          newNamer(s.cfg.Serve),
+		tools:          make(nameTable),
+		prompts:        make(nameTable),
+		resourceOwners: make(map[string]*backend),
+		stop:           make(chan struct{}),
+	}
+}
+
+// handle answers a request
+func (a *aggregate) handle(ctx context.Context, method string, params json.RawMessage) (interface{}, *mcp.JSONRPCError) {
+	if method == "ping" {
+		return struct{}{}, nil
+	}
+
+	a.startOnce.Do(a.startBackends)
+
+	switch method {
+	case "initialize":
+		return initialize(params), nil
+	case "tools/list":
+		return mcp.ListToolsResult{Tools: a.listTools(ctx)}, nil
+	case "tools/call":
+		return a.callTool(ctx, params)
+	case "resources/list":
+		return mcp.ListResourcesResult{Resources: a.listResources(ctx)}, nil
+	case "resources/read":
+		return a.readResource(ctx, params)
+	case "prompts/list":
+		return mcp.ListPromptsResult{Prompts: a.listPrompts(ctx)}, nil
+	case "prompts/get":
+		return a.getPrompt(ctx, params)
+	default:
+		return nil, mcp.NewError(mcp.MethodNotFound, "method not found: "+method, nil)
+	}
+}
+
+// start starts the backends and checks that the exported names of their tools
+// and prompts are unique, returning a *CollisionError if they are not
+func (a *aggregate) start(ctx context.Context) error {
+	a.startOnce.Do(a.startBackends)
+
+	_, toolCollisions := a.refreshTools(ctx)
+	_, promptCollisions := a.refreshPrompts(ctx)
+	if collisions := append(toolCollisions, promptCollisions...); len(collisions) > 0 {
+		return &CollisionError{Collisions: collisions}
+	}
+	return nil
+}
+
+// initialize describes the aggregate, echoing the client's protocol version
+func initialize(params json.RawMessage) *mcp.InitializeResult {
+	var request mcp.InitializeParams
+	_ = json.Unmarshal(params, &request)
+
+	protocolVersion := request.ProtocolVersion
+	if protocolVersion == "" {
+		protocolVersion = ProtocolVersion
+	}
+
+	return &mcp.InitializeResult{
+		ProtocolVersion: protocolVersion,
+		Capabilities: mcp.ServerCapabilities{
+			Tools:     &mcp.ToolsCapability{ListChanged: true},
+			Resources: &mcp.ResourcesCapability{ListChanged: true},
+			Prompts:   &mcp.PromptsCapability{ListChanged: true},
+		},
+		ServerInfo: mcp.ServerInfo{
+			Name:    "mcp-cli-ent",
+			Version: version.Version,
+		},
+	}
+}
+
+// startBackends connects to every enabled server. Servers that fail to start
+// are left out of the aggregate. Backends outlive the request that started
+// them, so they are not bound to its context.
+func (a *aggregate) startBackends() {
+	servers := a.cfg.GetEnabledServers()
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var started []*backend
+	for name, serverConfig := range servers {
+		wg.Add(1)
+		go func(name string, serverConfig config.ServerConfig) {
+			defer wg.Done()
+			b, err := a.startBackend(name, serverConfig)
+			if err != nil {
+				logging.Warn("leaving server out of the aggregate", "server", name, "error", err)
+				return
+			}
+			mutex.Lock()
+			started = append(started, b)
+			mutex.Unlock()
+		}(name, serverConfig)
+	}
+	wg.Wait()
+
+	sort.Slice(started, func(i, j int) bool { return started[i].name < started[j].name })
+
+	a.mutex.Lock()
+	if a.closed {
+		a.mutex.Unlock()
+		for _, b := range started {
+			_ = b.client.Close()
+		}
+		return
+	}
+	a.backends = started
+	a.mutex.Unlock()
+
+	go a.pollBackends()
+}
+
+// startBackend connects to and initializes one server
+func (a *aggregate) startBackend(name string, serverConfig config.ServerConfig) (*backend, error) {
+	mcpClient, err := a.newClient(name, serverConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.startTimeout)
+	defer cancel()
+
+	result, err := mcpClient.Initialize(ctx, &mcp.InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		ClientInfo: mcp.ClientInfo{
+			Name:    "mcp-cli-ent",
+			Version: version.Version,
+		},
+	})
+	if err != nil {
+		_ = mcpClient.Close()
+		return nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+
+	b := &backend{name: name, config: serverConfig, client: mcpClient, caps: result.Capabilities}
+	if source, ok := mcpClient.(mcp.NotificationSource); ok {
+		source.SetNotificationHandler(func(method string, _ json.RawMessage) {
+			if listChangedNotifications[method] {
+				logging.Debug("forwarding notification", "server", name, "method", method)
+				a.notify(method)
+			}
+		})
+	}
+	return b, nil
+}
+
+// pollBackends pings backends that announce list changes, so that the
+// notifications they queue between requests are read and forwarded
+func (a *aggregate) pollBackends() {
+	var polled []mcp.RequestSender
+	for _, b := range a.snapshot() {
+		sender, ok := b.client.(mcp.RequestSender)
+		if ok && b.announcesChanges() {
+			polled = append(polled, sender)
+		}
+	}
+	if len(polled) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(a.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			for _, sender := range polled {
+				_, _ = sender.SendRequest(context.Background(), "ping", nil)
+			}
+		}
+	}
+}
+
+// announcesChanges reports whether the backend sends list_changed notifications
+func (b *backend) announcesChanges() bool {
+	return (b.caps.Tools != nil && b.caps.Tools.ListChanged) ||
+		(b.caps.Resources != nil && b.caps.Resources.ListChanged) ||
+		(b.caps.Prompts != nil && b.caps.Prompts.ListChanged)
+}
+
+// snapshot returns the started backends
+func (a *aggregate) snapshot() []*backend {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.backends
+}
+
+// collect runs fetch on every backend concurrently and returns the results
+// in backend order, leaving out backends that fail
+func collect[T any](backends []*backend, what string, fetch func(*backend) ([]T, error)) [][]T {
+	results := make([][]T, len(backends))
+	var wg sync.WaitGroup
+	for i, b := range backends {
+		wg.Add(1)
+		go func(i int, b *backend) {
+			defer wg.Done()
+			items, err := fetch(b)
+			if err != nil {
+				logging.Warn("failed to list "+what, "server", b.name, "error", err)
+				return
+			}
+			results[i] = items
+		}(i, b)
+	}
+	wg.Wait()
+	return results
+}
+
+// listTools returns the visible tools of every backend under their exported
+// names. Tools whose name is already taken are left out with a warning.
+func (a *aggregate) listTools(ctx context.Context) []mcp.Tool {
+	tools, collisions := a.refreshTools(ctx)
+	warnCollisions(collisions)
+	return tools
+}
+
+// refreshTools lists the visible tools of every backend and updates the
+// exported tool names
+func (a *aggregate) refreshTools(ctx context.Context) ([]mcp.Tool, []Collision) {
+	backends := a.snapshot()
+	lists := collect(backends, "tools", func(b *backend) ([]mcp.Tool, error) {
+		tools, err := b.client.ListTools(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return client.VisibleTools(b.config, tools), nil
+	})
+
+	names := make([][]string, len(lists))
+	for i, list := range lists {
+		for _, tool := range list {
+			names[i] = append(names[i], tool.Name)
+		}
+	}
+	policy := a.policy.Load()
+	table, collisions := a.assignNames(exports.KindTool, policy, backends, names)
+
+	tools := []mcp.Tool{}
+	for i, list := range lists {
+		for _, tool := range list {
+			exported := a.namer.export(backends[i].name, backends[i].config, tool.Name)
+			if t := table[exported]; t.backend != backends[i] || t.name != tool.Name {
+				continue // The name went to an earlier server
+			}
+			if !policy.Allows(exports.KindTool, backends[i].name, tool.Name) {
+				continue
+			}
+			tool.Name = exported
+			tools = append(tools, tool)
+		}
+	}
+
+	a.mutex.Lock()
+	a.tools = table
+	a.mutex.Unlock()
+	return tools, collisions
+}
+
+// assignNames builds the name table for items listed per backend. Exported
+// items claim their names first, with collisions reported; items the export
+// policy leaves out fill only the names still free, so that calling them is
+// refused by the policy rather than reported as unknown.
+func (a *aggregate) assignNames(kind string, policy *exports.Policy, backends []*backend, names [][]string) (nameTable, []Collision) {
+	allowed := make([][]string, len(names))
+	for i, list := range names {
+		for _, name := range list {
+			if policy.Allows(kind, backends[i].name, name) {
+				allowed[i] = append(allowed[i], name)
+			}
+		}
+	}
+	table, collisions := buildNames(kind, a.namer, backends, allowed)
+
+	for i, list := range names {
+		for _, name := range list {
+			exported := a.namer.export(backends[i].name, backends[i].config, name)
+			if _, taken := table[exported]; !taken {
+				table[exported] = target{backend: backends[i], name: name}
+			}
+		}
+	}
+	return table, collisions
+}
+
+// checkExport returns a permission error if the export policy refuses the item
+func (a *aggregate) checkExport(kind, server, name string) *mcp.JSONRPCError {
+	if err := a.policy.Load().Check(kind, server, name); err != nil {
+		return mcp.NewError(PermissionDenied, err.Error(), nil)
+	}
+	return nil
+}
+
+// warnCollisions logs the exported names that were claimed more than once
+func warnCollisions(collisions []Collision) {
+	for _, c := range collisions {
+		logging.Warn("exported name is taken; later servers' "+c.Kind+"s are left out", "name", c.Name, "claimants", strings.Join(c.Sources, ","))
+	}
+}
+
+// lookup finds the target of an exported name in table, listing again if the
+// name is unknown (the client may not have listed yet)
+func (a *aggregate) lookup(ctx context.Context, table *nameTable, refresh func(context.Context), name string) (target, bool) {
+	a.mutex.RLock()
+	t, ok := (*table)[name]
+	a.mutex.RUnlock()
+	if ok {
+		return t, true
+	}
+
+	refresh(ctx)
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	t, ok = (*table)[name]
+	return t, ok
+}
+
+// callTool routes a tool call to its backend, applying the server's tool
+// defaults. Backend failures are reported as tool errors.
+func (a *aggregate) callTool(ctx context.Context, params json.RawMessage) (interface{}, *mcp.JSONRPCError) {
+	var call mcp.CallToolParams
+	if err := json.Unmarshal(params, &call); err != nil {
+		return nil, mcp.NewError(mcp.InvalidParams, "invalid tools/call parameters", nil)
+	}
+
+	t, ok := a.lookup(ctx, &a.tools, func(ctx context.Context) { a.listTools(ctx) }, call.Name)
+	if !ok {
+		return nil, mcp.NewError(mcp.InvalidParams, "unknown tool: "+call.Name, nil)
+	}
+	b, toolName := t.backend, t.name
+	if rpcErr := a.checkExport(exports.KindTool, b.name, toolName); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	arguments := b.config.ApplyToolDefaults(toolName, call.Arguments)
+	result, err := b.client.CallTool(ctx, toolName, arguments)
+	if err != nil {
+		return &mcp.ToolResult{
+			Content: []interface{}{map[string]interface{}{
+				"type": "text",
+				"text": fmt.Sprintf("%s failed: %v", b.name, err),
+			}},
+			IsError: true,
+		}, nil
+	}
+	if result != nil && len(result.Raw) > 0 {
+		return result.Raw, nil // Pass the backend's result on without re-encoding it
+	}
+	return result, nil
+}
+
+// listResources returns the resources of every backend and remembers which
+// backend owns each URI
+func (a *aggregate) listResources(ctx context.Context) []mcp.Resource {
+	var backends []*backend
+	for _, b := range a.snapshot() {
+		if b.caps.Resources != nil {
+			backends = append(backends, b)
+		}
+	}
+	lists := collect(backends, "resources", func(b *backend) ([]mcp.Resource, error) {
+		return b.client.ListResources(ctx)
+	})
+
+	policy := a.policy.Load()
+	resources := []mcp.Resource{}
+	owners := make(map[string]*backend)
+	for i, list := range lists {
+		for _, resource := range list {
+			if _, taken := owners[resource.URI]; taken {
+				continue // The first server listing a URI serves it
+			}
+			owners[resource.URI] = backends[i]
+			if policy.Allows(exports.KindResource, backends[i].name, resource.URI) {
+				resources = append(resources, resource)
+			}
+		}
+	}
+
+	a.mutex.Lock()
+	a.resourceOwners = owners
+	a.mutex.Unlock()
+	return resources
+}
+
+// readResource forwards resources/read to the backend that listed the URI
+func (a *aggregate) readResource(ctx context.Context, params json.RawMessage) (interface{}, *mcp.JSONRPCError) {
+	var read struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &read); err != nil || read.URI == "" {
+		return nil, mcp.NewError(mcp.InvalidParams, "invalid resources/read parameters", nil)
+	}
+
+	owner := a.resourceOwner(read.URI)
+	if owner == nil {
+		a.listResources(ctx) // The client may not have listed resources yet
+		if owner = a.resourceOwner(read.URI); owner == nil {
+			return nil, mcp.NewError(mcp.InvalidParams, "unknown resource: "+read.URI, nil)
+		}
+	}
+	if rpcErr := a.checkExport(exports.KindResource, owner.name, read.URI); rpcErr != nil {
+		return nil, rpcErr
+	}
+	return forward(ctx, owner, "resources/read", params)
+}
+
+// resourceOwner returns the backend that listed uri
+func (a *aggregate) resourceOwner(uri string) *backend {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.resourceOwners[uri]
+}
+
+// listPrompts returns the prompts of every backend under their exported
+// names. Prompts whose name is already taken are left out with a warning.
+func (a *aggregate) listPrompts(ctx context.Context) []mcp.Prompt {
+	prompts, collisions := a.refreshPrompts(ctx)
+	warnCollisions(collisions)
+	return prompts
+}
+
+// refreshPrompts lists the prompts of every backend and updates the exported
+// prompt names
+func (a *aggregate) refreshPrompts(ctx context.Context) ([]mcp.Prompt, []Collision) {
+	var backends []*backend
+	for _, b := range a.snapshot() {
+		if _, ok := b.client.(mcp.RequestSender); ok && b.caps.Prompts != nil {
+			backends = append(backends, b)
+		}
+	}
+	lists := collect(backends, "prompts", func(b *backend) ([]mcp.Prompt, error) {
+		raw, err := b.client.(mcp.RequestSender).SendRequest(ctx, "prompts/list", nil)
+		if err != nil {
+			return nil, err
+		}
+		var result mcp.ListPromptsResult
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode prompts: %w", err)
+		}
+		return result.Prompts, nil
+	})
+
+	names := make([][]string, len(lists))
+	for i, list := range lists {
+		for _, prompt := range list {
+			names[i] = append(names[i], prompt.Name)
+		}
+	}
+	policy := a.policy.Load()
+	table, collisions := a.assignNames(exports.KindPrompt, policy, backends, names)
+
+	prompts := []mcp.Prompt{}
+	for i, list := range lists {
+		for _, prompt := range list {
+			exported := a.namer.export(backends[i].name, backends[i].config, prompt.Name)
+			if t := table[exported]; t.backend != backends[i] || t.name != prompt.Name {
+				continue // The name went to an earlier server
+			}
+			if !policy.Allows(exports.KindPrompt, backends[i].name, prompt.Name) {
+				continue
+			}
+			prompt.Name = exported
+			prompts = append(prompts, prompt)
+		}
+	}
+
+	a.mutex.Lock()
+	a.prompts = table
+	a.mutex.Unlock()
+	return prompts, collisions
+}
+
+// getPrompt forwards prompts/get to the prompt's backend under its own name
+func (a *aggregate) getPrompt(ctx context.Context, params json.RawMessage) (interface{}, *mcp.JSONRPCError) {
+	var get map[string]interface{}
+	if err := json.Unmarshal(params, &get); err != nil {
+		return nil, mcp.NewError(mcp.InvalidParams, "invalid prompts/get parameters", nil)
+	}
+	name, _ := get["name"].(string)
+
+	t, ok := a.lookup(ctx, &a.prompts, func(ctx context.Context) { a.listPrompts(ctx) }, name)
+	if !ok {
+		return nil, mcp.NewError(mcp.InvalidParams, "unknown prompt: "+name, nil)
+	}
+	if rpcErr := a.checkExport(exports.KindPrompt, t.backend.name, t.name); rpcErr != nil {
+		return nil, rpcErr
+	}
+	get["name"] = t.name
+	return forward(ctx, t.backend, "prompts/get", get)
+}
+
+// forward sends a request the MCPClient interface has no typed call for
+func forward(ctx context.Context, b *backend, method string, params interface{}) (interface{}, *mcp.JSONRPCError) {
+	sender, ok := b.client.(mcp.RequestSender)
+	if !ok {
+		return nil, mcp.NewError(mcp.MethodNotFound, fmt.Sprintf("%s does not support %s through this connection", b.name, method), nil)
+	}
+	result, err := sender.SendRequest(ctx, method, params)
+	if err != nil {
+		// The backend's own error keeps its code and data
+		code, data := mcp.InternalError, interface{}(nil)
+		var rpcErr *mcp.JSONRPCError
+		if errors.As(err, &rpcErr) {
+			code, data = rpcErr.Code, rpcErr.Data
+		}
+		return nil, mcp.NewError(code, fmt.Sprintf("%s failed: %v", b.name, err), data)
+	}
+	return result, nil
+}
+
+// response builds the JSON-RPC response to a handled request. Results
+// forwarded from a backend are already encoded and are passed on as they are.
+func response(id json.RawMessage, result interface{}, rpcErr *mcp.JSONRPCError) *mcp.JSONRPCResponse {
+	if rpcErr != nil {
+		return mcp.NewErrorResponse(id, rpcErr)
+	}
+	encoded, ok := result.(json.RawMessage)
+	if !ok || len(encoded) == 0 {
+		var err error
+		if encoded, err = json.Marshal(result); err != nil {
+			return mcp.NewErrorResponse(id, mcp.NewError(mcp.InternalError, "failed to encode result: "+err.Error(), nil))
+		}
+	}
+	return mcp.NewResponse(id, encoded)
+}
+
+// close stops polling and closes the backends. It is safe to call more than once.
+func (a *aggregate) close() {
+	a.mutex.Lock()
+	if a.closed {
+		a.mutex.Unlock()
+		return
+	}
+	a.closed = true
+	backends := a.backends
+	a.mutex.Unlock()
+
+	close(a.stop)
+	for _, b := range backends {
+		_ = b.client.Close()
+	}
+}
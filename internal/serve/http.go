@@ -0,0 +1,422 @@
+package serve
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// HTTPPath is where the Streamable HTTP endpoint is served
+const HTTPPath = "/mcp"
+
+// HTTP transport defaults
+const (
+	// DefaultSessionIdleTimeout is how long a session may go unused before
+	// its backends are stopped
+	DefaultSessionIdleTimeout = 30 * time.Minute
+
+	// DefaultShutdownTimeout bounds how long shutdown waits for requests in
+	// flight, such as slow tool calls
+	DefaultShutdownTimeout = 2 * time.Minute
+
+	// DefaultMaxSessions is how many client sessions may be open at once
+	// when serve.maxSessions doesn't say
+	DefaultMaxSessions = 100
+
+	// maxRequestSize bounds the body of a POSTed message
+	maxRequestSize = 10 << 20
+
+	// eventBuffer is how many notifications a session queues for its client
+	eventBuffer = 16
+)
+
+// HTTPServer serves the aggregate over MCP's Streamable HTTP transport. Each
+// client session gets its own backend connections, so clients never share
+// the state of a stdio server; persistent servers are shared through the
+// daemon as usual.
+type HTTPServer struct {
+	settings
+	token       string
+	idleTimeout time.Duration
+	maxSessions int
+
+	mutex    sync.Mutex
+	sessions map[string]*httpSession
+	closing  chan struct{} // Closed on shutdown to end event streams
+}
+
+// httpSession is one client's aggregate
+type httpSession struct {
+	id        string
+	aggregate *aggregate
+	events    chan string   // Notifications waiting for the client's event stream
+	done      chan struct{} // Closed when the session ends
+
+	lastUsed  time.Time // Guarded by HTTPServer.mutex
+	streaming int       // Open event streams, guarded by HTTPServer.mutex
+}
+
+// errTooManySessions refuses a session beyond the limit
+var errTooManySessions = errors.New("too many sessions; end one or try again later")
+
+// NewHTTP creates a Streamable HTTP server for cfg's enabled servers. When
+// token is set, clients must send it as a bearer token.
+func NewHTTP(cfg *config.Configuration, newClient ClientFactory, token string) *HTTPServer {
+	maxSessions := cfg.Serve.MaxSessions
+	if maxSessions == 0 {
+		maxSessions = DefaultMaxSessions
+	}
+	return &HTTPServer{
+		settings:    newSettings(cfg, newClient),
+		token:       token,
+		idleTimeout: DefaultSessionIdleTimeout,
+		maxSessions: maxSessions,
+		sessions:    make(map[string]*httpSession),
+		closing:     make(chan struct{}),
+	}
+}
+
+// Serve accepts connections on listener until ctx is cancelled, then stops
+// accepting, waits for requests in flight and stops every session's backends.
+// The backends are first started once to check that exported names are
+// unique; Serve fails with a *CollisionError if they are not.
+func (s *HTTPServer) Serve(ctx context.Context, listener net.Listener) error {
+	if err := s.loadExports(); err != nil {
+		return err
+	}
+	probe := newAggregate(&s.settings, func(string) {})
+	err := probe.start(ctx)
+	probe.close()
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(HTTPPath, s)
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	server.RegisterOnShutdown(func() { close(s.closing) })
+	defer s.closeSessions()
+
+	stopExpiry := make(chan struct{})
+	defer close(stopExpiry)
+	go s.expireSessions(stopExpiry)
+
+	errs := make(chan error, 1)
+	go func() { errs <- server.Serve(listener) }()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("HTTP server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to finish requests in flight: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP implements the Streamable HTTP endpoint
+func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r)
+	case http.MethodGet:
+		s.handleStream(w, r)
+	case http.MethodDelete:
+		session, ok := s.session(w, r)
+		if !ok {
+			return
+		}
+		s.endSession(session)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// authorized checks the bearer token, if one is required
+func (s *HTTPServer) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) == 1
+}
+
+// handlePost answers a POSTed JSON-RPC message. Initialize starts a new
+// session; every other message must name an existing one.
+func (s *HTTPServer) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		writeJSON(w, http.StatusBadRequest, mcp.NewErrorResponse(nil, mcp.NewError(mcp.ParseError, "invalid JSON", nil)))
+		return
+	}
+
+	var session *httpSession
+	if msg.Method == "initialize" {
+		session, err = s.startSession()
+		if errors.Is(err, errTooManySessions) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(mcp.SessionIDHeader, session.id)
+	} else {
+		var ok bool
+		if session, ok = s.session(w, r); !ok {
+			return
+		}
+	}
+
+	// Notifications from the client and responses to requests we never send
+	if !msg.isRequest() {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	result, rpcErr := session.aggregate.handle(r.Context(), msg.Method, msg.Params)
+	writeJSON(w, http.StatusOK, response(msg.ID, result, rpcErr))
+}
+
+// handleStream sends the session's notifications as server-sent events until
+// the client disconnects or the server shuts down
+func (s *HTTPServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "GET requires Accept: text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	session, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	s.mutex.Lock()
+	session.streaming++
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		session.streaming--
+		session.lastUsed = time.Now()
+		s.mutex.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.closing:
+			return
+		case <-session.done:
+			return
+		case method := <-session.events:
+			data, _ := json.Marshal(notification{JSONRPC: "2.0", Method: method})
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// SetExports replaces the export policy of a running server, such as after
+// the configuration is reloaded, and tells every session's client to list
+// again. The backends keep running.
+func (s *HTTPServer) SetExports(cfg *config.ExportsConfig) error {
+	if err := s.setExports(cfg); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, session := range s.sessions {
+		for _, method := range exportsChangedNotifications {
+			session.queue(method)
+		}
+	}
+	return nil
+}
+
+// queue passes a notification to the session's event stream
+func (session *httpSession) queue(method string) {
+	select {
+	case session.events <- method:
+	default:
+		// Without a listening client the notification is dropped; the client
+		// will see the change when it next lists
+	}
+}
+
+// startSession creates a session with its own backends, unless as many
+// sessions as allowed are open
+func (s *HTTPServer) startSession() (*httpSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session ID: %w", err)
+	}
+
+	session := &httpSession{id: id, events: make(chan string, eventBuffer), done: make(chan struct{}), lastUsed: time.Now()}
+	session.aggregate = newAggregate(&s.settings, session.queue)
+
+	s.mutex.Lock()
+	if len(s.sessions) >= s.maxSessions {
+		s.mutex.Unlock()
+		return nil, errTooManySessions
+	}
+	s.sessions[id] = session
+	s.mutex.Unlock()
+	logging.Debug("started HTTP session", "session", id)
+	return session, nil
+}
+
+// session finds the session a request names, writing an error response if
+// there is none
+func (s *HTTPServer) session(w http.ResponseWriter, r *http.Request) (*httpSession, bool) {
+	id := r.Header.Get(mcp.SessionIDHeader)
+	if id == "" {
+		http.Error(w, "missing "+mcp.SessionIDHeader+" header; send initialize first", http.StatusBadRequest)
+		return nil, false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		// 404 tells the client to initialize a new session
+		http.Error(w, "unknown or expired session", http.StatusNotFound)
+		return nil, false
+	}
+	session.lastUsed = time.Now()
+	return session, true
+}
+
+// endSession forgets a session and stops its backends
+func (s *HTTPServer) endSession(session *httpSession) {
+	s.mutex.Lock()
+	_, active := s.sessions[session.id]
+	delete(s.sessions, session.id)
+	s.mutex.Unlock()
+
+	if active {
+		close(session.done)
+		session.aggregate.close()
+		logging.Debug("ended HTTP session", "session", session.id)
+	}
+}
+
+// expireSessions ends sessions left idle for longer than the idle timeout
+func (s *HTTPServer) expireSessions(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.idleTimeout / 10)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			var expired []*httpSession
+			s.mutex.Lock()
+			for _, session := range s.sessions {
+				if session.streaming == 0 && now.Sub(session.lastUsed) > s.idleTimeout {
+					expired = append(expired, session)
+				}
+			}
+			s.mutex.Unlock()
+
+			for _, session := range expired {
+				s.endSession(session)
+			}
+		}
+	}
+}
+
+// closeSessions ends every session
+func (s *HTTPServer) closeSessions() {
+	s.mutex.Lock()
+	sessions := make([]*httpSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	s.mutex.Unlock()
+
+	for _, session := range sessions {
+		s.endSession(session)
+	}
+}
+
+// newSessionID returns a random, unguessable session ID
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// writeJSON writes a JSON response body
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		logging.Warn("failed to encode message", "error", err)
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// IsLoopback reports whether addr (host:port) only accepts local connections
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,199 @@
+package serve
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// startHTTP serves a counter backend over HTTP with serveConfig's settings
+// and returns the endpoint URL and a function that shuts the server down,
+// returning Serve's error
+func startHTTP(t *testing.T, serveConfig config.ServeConfig) (*HTTPServer, string, func() error) {
+	t.Helper()
+	cfg := &config.Configuration{Serve: serveConfig, MCPServers: map[string]config.ServerConfig{
+		"counter": {Command: "sh", Args: []string{"testdata/counter.sh"}},
+	}}
+	server := NewHTTP(cfg, func(_ string, serverConfig config.ServerConfig) (mcp.MCPClient, error) {
+		return client.NewMCPClient(serverConfig)
+	}, serveConfig.Token)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, listener) }()
+
+	stopped := false
+	stop := func() error {
+		if stopped {
+			return nil
+		}
+		stopped = true
+		cancel()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(10 * time.Second):
+			t.Fatal("Serve did not return after shutdown")
+			return nil
+		}
+	}
+	t.Cleanup(func() { _ = stop() })
+	return server, "http://" + listener.Addr().String() + HTTPPath, stop
+}
+
+func newHTTPClient(t *testing.T, url string, headers map[string]string) *client.HTTPClient {
+	t.Helper()
+	c := client.NewHTTPClient(url, &mcp.ClientConfig{Headers: headers})
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func initializeHTTP(t *testing.T, c *client.HTTPClient) {
+	t.Helper()
+	if _, err := c.Initialize(context.Background(), &mcp.InitializeParams{ProtocolVersion: ProtocolVersion}); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+}
+
+func callText(t *testing.T, c *client.HTTPClient, tool string) string {
+	t.Helper()
+	result, err := c.CallTool(context.Background(), tool, nil)
+	if err != nil {
+		t.Fatalf("calling %s failed: %v", tool, err)
+	}
+	return result.Blocks()[0].Text
+}
+
+func TestHTTPSessionsAreIndependent(t *testing.T) {
+	_, url, _ := startHTTP(t, config.ServeConfig{})
+
+	first := newHTTPClient(t, url, nil)
+	initializeHTTP(t, first)
+	tools, err := first.ListTools(context.Background())
+	if err != nil || len(tools) != 2 || tools[0].Name != "counter__count" {
+		t.Fatalf("unexpected tools %+v (%v)", tools, err)
+	}
+	callText(t, first, "counter__count")
+	if got := callText(t, first, "counter__count"); got != "2" {
+		t.Errorf("first session's second call = %s, want 2", got)
+	}
+
+	// A second client gets its own backend process and so its own count
+	second := newHTTPClient(t, url, nil)
+	initializeHTTP(t, second)
+	if got := callText(t, second, "counter__count"); got != "1" {
+		t.Errorf("second session's first call = %s, want 1", got)
+	}
+}
+
+func TestHTTPRequiresToken(t *testing.T) {
+	_, url, _ := startHTTP(t, config.ServeConfig{Token: "s3cret"})
+
+	for _, headers := range []map[string]string{nil, {"Authorization": "Bearer wrong"}} {
+		c := newHTTPClient(t, url, headers)
+		_, err := c.Initialize(context.Background(), &mcp.InitializeParams{})
+		if err == nil || !strings.Contains(err.Error(), "401") {
+			t.Errorf("headers %v: want a 401 error, got %v", headers, err)
+		}
+	}
+
+	c := newHTTPClient(t, url, map[string]string{"Authorization": "Bearer s3cret"})
+	initializeHTTP(t, c)
+	if got := callText(t, c, "counter__count"); got != "1" {
+		t.Errorf("count = %s, want 1", got)
+	}
+}
+
+func TestHTTPLimitsSessions(t *testing.T) {
+	_, url, _ := startHTTP(t, config.ServeConfig{MaxSessions: 1})
+
+	first := newHTTPClient(t, url, nil)
+	initializeHTTP(t, first)
+	second := newHTTPClient(t, url, nil)
+	if _, err := second.Initialize(context.Background(), &mcp.InitializeParams{ProtocolVersion: ProtocolVersion}); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("want a 503 error beyond the limit, got %v", err)
+	}
+
+	// Ending a session makes room for another
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	initializeHTTP(t, newHTTPClient(t, url, nil))
+}
+
+func TestHTTPRejectsUnknownSessions(t *testing.T) {
+	_, url, _ := startHTTP(t, config.ServeConfig{})
+
+	for session, want := range map[string]int{"": http.StatusBadRequest, "nope": http.StatusNotFound} {
+		req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+		if session != "" {
+			req.Header.Set(mcp.SessionIDHeader, session)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("session %q: status %d, want %d", session, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestHTTPCloseEndsSession(t *testing.T) {
+	server, url, _ := startHTTP(t, config.ServeConfig{})
+
+	c := client.NewHTTPClient(url, &mcp.ClientConfig{})
+	initializeHTTP(t, c)
+	callText(t, c, "counter__count")
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if len(server.sessions) != 0 {
+		t.Errorf("%d sessions left after the client closed", len(server.sessions))
+	}
+}
+
+func TestHTTPShutdownFinishesToolCalls(t *testing.T) {
+	server, url, stop := startHTTP(t, config.ServeConfig{})
+
+	c := newHTTPClient(t, url, nil)
+	initializeHTTP(t, c)
+
+	type callResult struct {
+		result *mcp.ToolResult
+		err    error
+	}
+	results := make(chan callResult, 1)
+	go func() {
+		result, err := c.CallTool(context.Background(), "counter__slow", nil)
+		results <- callResult{result, err}
+	}()
+
+	time.Sleep(300 * time.Millisecond) // Let the call reach the backend
+	if err := stop(); err != nil {
+		t.Fatalf("Serve returned %v", err)
+	}
+
+	r := <-results
+	if r.err != nil || r.result.Blocks()[0].Text != "done" {
+		t.Errorf("in-flight call should finish during shutdown, got %+v (%v)", r.result, r.err)
+	}
+	if len(server.sessions) != 0 {
+		t.Errorf("%d sessions left after shutdown", len(server.sessions))
+	}
+}
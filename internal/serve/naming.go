@@ -0,0 +1,105 @@
+package serve
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// namer decides the names tools and prompts are exported under
+type namer struct {
+	style     string
+	separator string
+}
+
+// newNamer creates a namer for the configuration's serve settings
+func newNamer(settings config.ServeConfig) namer {
+	n := namer{style: settings.NamespaceStyle, separator: settings.Separator}
+	if n.style == "" {
+		n.style = config.NamespacePrefix
+	}
+	if n.separator == "" {
+		n.separator = config.DefaultNamespaceSeparator
+	}
+	return n
+}
+
+// export returns the exported name of a server's tool or prompt. An exportAs
+// rename is used as-is, without namespacing.
+func (n namer) export(serverName string, serverConfig config.ServerConfig, name string) string {
+	if renamed, ok := serverConfig.ExportAs[name]; ok {
+		return renamed
+	}
+	switch n.style {
+	case config.NamespaceSuffix:
+		return name + n.separator + serverName
+	case config.NamespaceNone:
+		return name
+	default:
+		return serverName + n.separator + name
+	}
+}
+
+// ExportName returns the name serve publishes a server's tool or prompt
+// under with the given serve settings
+func ExportName(settings config.ServeConfig, serverName string, serverConfig config.ServerConfig, name string) string {
+	return newNamer(settings).export(serverName, serverConfig, name)
+}
+
+// target is the backend and original name an exported name routes to
+type target struct {
+	backend *backend
+	name    string
+}
+
+// Collision is an exported name claimed by more than one tool or prompt
+type Collision struct {
+	Kind    string   // "tool" or "prompt"
+	Name    string   // The exported name
+	Sources []string // Each claimant as "server/name", in server order
+}
+
+// CollisionError lists the exported names claimed more than once
+type CollisionError struct {
+	Collisions []Collision
+}
+
+func (e *CollisionError) Error() string {
+	var b strings.Builder
+	b.WriteString("exported names collide; set serve.namespaceStyle or rename with exportAs:")
+	for _, c := range e.Collisions {
+		fmt.Fprintf(&b, "\n  %s %s: %s", c.Kind, c.Name, strings.Join(c.Sources, ", "))
+	}
+	return b.String()
+}
+
+// nameTable maps exported names to their targets
+type nameTable map[string]target
+
+// buildNames assigns the exported name of every item, listed per backend in
+// backend order. The first claimant of a name keeps it; the rest are
+// reported as collisions.
+func buildNames(kind string, n namer, backends []*backend, names [][]string) (nameTable, []Collision) {
+	table := make(nameTable)
+	sources := make(map[string][]string)
+	for i, b := range backends {
+		for _, name := range names[i] {
+			exported := n.export(b.name, b.config, name)
+			sources[exported] = append(sources[exported], b.name+"/"+name)
+			if _, taken := table[exported]; !taken {
+				table[exported] = target{backend: b, name: name}
+			}
+		}
+	}
+
+	var collisions []Collision
+	for exported, claimants := range sources {
+		if len(claimants) > 1 {
+			collisions = append(collisions, Collision{Kind: kind, Name: exported, Sources: claimants})
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool { return collisions[i].Name < collisions[j].Name })
+	return table, collisions
+}
@@ -0,0 +1,113 @@
+package serve
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestNamerExport(t *testing.T) {
+	renamed := config.ServerConfig{ExportAs: map[string]string{"search": "web_search"}}
+
+	tests := []struct {
+		name     string
+		settings config.ServeConfig
+		server   config.ServerConfig
+		tool     string
+		want     string
+	}{
+		{"default prefix", config.ServeConfig{}, config.ServerConfig{}, "search", "web__search"},
+		{"prefix", config.ServeConfig{NamespaceStyle: "prefix"}, config.ServerConfig{}, "search", "web__search"},
+		{"suffix", config.ServeConfig{NamespaceStyle: "suffix"}, config.ServerConfig{}, "search", "search__web"},
+		{"none", config.ServeConfig{NamespaceStyle: "none"}, config.ServerConfig{}, "search", "search"},
+		{"custom separator", config.ServeConfig{Separator: "."}, config.ServerConfig{}, "search", "web.search"},
+		{"suffix with separator", config.ServeConfig{NamespaceStyle: "suffix", Separator: "-"}, config.ServerConfig{}, "search", "search-web"},
+		{"exportAs is not namespaced", config.ServeConfig{}, renamed, "search", "web_search"},
+		{"exportAs under none", config.ServeConfig{NamespaceStyle: "none"}, renamed, "search", "web_search"},
+		{"other tools keep the style", config.ServeConfig{}, renamed, "fetch", "web__fetch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newNamer(tt.settings).export("web", tt.server, tt.tool); got != tt.want {
+				t.Errorf("export = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildNames(t *testing.T) {
+	alpha := &backend{name: "alpha"}
+	beta := &backend{name: "beta", config: config.ServerConfig{ExportAs: map[string]string{"search": "beta_search"}}}
+	gamma := &backend{name: "gamma", config: config.ServerConfig{ExportAs: map[string]string{"fetch": "search"}}}
+
+	tests := []struct {
+		name       string
+		style      string
+		backends   []*backend
+		names      [][]string
+		wantTable  map[string]string // exported name to server/name
+		collisions []Collision
+	}{
+		{
+			name:      "prefix keeps same-named tools apart",
+			style:     "prefix",
+			backends:  []*backend{alpha, gamma},
+			names:     [][]string{{"search"}, {"search"}},
+			wantTable: map[string]string{"alpha__search": "alpha/search", "gamma__search": "gamma/search"},
+		},
+		{
+			name:      "none with unique names",
+			style:     "none",
+			backends:  []*backend{alpha, gamma},
+			names:     [][]string{{"search"}, {"lookup"}},
+			wantTable: map[string]string{"search": "alpha/search", "lookup": "gamma/lookup"},
+		},
+		{
+			name:      "none collides and the first server wins",
+			style:     "none",
+			backends:  []*backend{alpha, gamma},
+			names:     [][]string{{"search", "read"}, {"search", "read"}},
+			wantTable: map[string]string{"search": "alpha/search", "read": "alpha/read"},
+			collisions: []Collision{
+				{Kind: "tool", Name: "read", Sources: []string{"alpha/read", "gamma/read"}},
+				{Kind: "tool", Name: "search", Sources: []string{"alpha/search", "gamma/search"}},
+			},
+		},
+		{
+			name:      "exportAs resolves a collision",
+			style:     "none",
+			backends:  []*backend{alpha, beta},
+			names:     [][]string{{"search"}, {"search"}},
+			wantTable: map[string]string{"search": "alpha/search", "beta_search": "beta/search"},
+		},
+		{
+			name:      "exportAs can cause a collision",
+			style:     "none",
+			backends:  []*backend{alpha, gamma},
+			names:     [][]string{{"search"}, {"fetch"}},
+			wantTable: map[string]string{"search": "alpha/search"},
+			collisions: []Collision{
+				{Kind: "tool", Name: "search", Sources: []string{"alpha/search", "gamma/fetch"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, collisions := buildNames("tool", newNamer(config.ServeConfig{NamespaceStyle: tt.style}), tt.backends, tt.names)
+
+			got := make(map[string]string)
+			for exported, target := range table {
+				got[exported] = target.backend.name + "/" + target.name
+			}
+			if !reflect.DeepEqual(got, tt.wantTable) {
+				t.Errorf("table = %v, want %v", got, tt.wantTable)
+			}
+			if !reflect.DeepEqual(collisions, tt.collisions) {
+				t.Errorf("collisions = %+v, want %+v", collisions, tt.collisions)
+			}
+		})
+	}
+}
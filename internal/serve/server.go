@@ -0,0 +1,157 @@
+// Package serve exposes every enabled server of a configuration as a single
+// MCP server, over stdio or Streamable HTTP. Tools and prompts are renamed
+// "server__name"; resources keep their URIs and are routed by them.
+package serve
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// Server serves the aggregate to one client speaking JSON-RPC over stdio
+type Server struct {
+	settings
+
+	outMutex sync.Mutex
+	out      io.Writer
+
+	aggregate *aggregate
+	requests  sync.WaitGroup
+}
+
+// message is an incoming JSON-RPC request, notification or response
+type message struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// isRequest reports whether the message expects a response
+func (m *message) isRequest() bool {
+	return m.Method != "" && len(m.ID) > 0 && string(m.ID) != "null"
+}
+
+// New creates an aggregating server for cfg's enabled servers
+func New(cfg *config.Configuration, newClient ClientFactory) *Server {
+	return &Server{settings: newSettings(cfg, newClient)}
+}
+
+// Serve starts the backends, then answers requests read from in on out until
+// in is closed or ctx is cancelled. It fails with a *CollisionError, before
+// answering anything, if two tools or prompts would be exported under the
+// same name.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	if err := s.loadExports(); err != nil {
+		return err
+	}
+	s.outMutex.Lock()
+	s.out = out
+	s.outMutex.Unlock()
+	s.aggregate = newAggregate(&s.settings, func(method string) {
+		s.send(notification{JSONRPC: "2.0", Method: method})
+	})
+	defer s.shutdown()
+
+	if err := s.aggregate.start(ctx); err != nil {
+		return err
+	}
+
+	// Reading blocks, so it runs apart from the loop to let ctx end serving
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line := <-lines:
+			s.dispatch(ctx, line)
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read request: %w", err)
+		}
+	}
+}
+
+// dispatch handles one incoming message; requests are answered concurrently
+func (s *Server) dispatch(ctx context.Context, line []byte) {
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		s.send(mcp.NewErrorResponse(nil, mcp.NewError(mcp.ParseError, "invalid JSON", nil)))
+		return
+	}
+
+	// Notifications from the client and responses to requests we never send
+	if !msg.isRequest() {
+		return
+	}
+
+	s.requests.Add(1)
+	go func() {
+		defer s.requests.Done()
+		result, rpcErr := s.aggregate.handle(ctx, msg.Method, msg.Params)
+		s.send(response(msg.ID, result, rpcErr))
+	}()
+}
+
+// send writes one JSON-RPC message line
+func (s *Server) send(msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logging.Warn("failed to encode message", "error", err)
+		return
+	}
+
+	s.outMutex.Lock()
+	defer s.outMutex.Unlock()
+	if s.out != nil {
+		_, _ = s.out.Write(append(data, '\n'))
+	}
+}
+
+// SetExports replaces the export policy of a running server, such as after
+// the configuration is reloaded, and tells the client to list again. The
+// backends keep running.
+func (s *Server) SetExports(cfg *config.ExportsConfig) error {
+	if err := s.setExports(cfg); err != nil {
+		return err
+	}
+	for _, method := range exportsChangedNotifications {
+		s.send(notification{JSONRPC: "2.0", Method: method})
+	}
+	return nil
+}
+
+// shutdown waits for requests in flight and closes the backends
+func (s *Server) shutdown() {
+	s.requests.Wait()
+	s.aggregate.close()
+}
@@ -0,0 +1,368 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// session drives a Server over pipes like an MCP client would
+type session struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Reader
+	done   chan error
+	nextID int
+
+	notifications []string
+}
+
+func testConfig() *config.Configuration {
+	disabled := false
+	return &config.Configuration{MCPServers: map[string]config.ServerConfig{
+		"alpha": {
+			Command:       "sh",
+			Args:          []string{"testdata/alpha.sh"},
+			DisabledTools: []string{"secret"},
+			ToolDefaults: map[string]map[string]interface{}{
+				"greet": {"lang": "en"},
+			},
+		},
+		"beta":   {Command: "sh", Args: []string{"testdata/beta.sh"}},
+		"broken": {Command: "sh", Args: []string{"-c", "echo 'Error: BROKEN_API_KEY is not set' >&2; exit 1"}},
+		"off":    {Command: "sh", Args: []string{"testdata/alpha.sh"}, Enabled: &disabled},
+	}}
+}
+
+func startSession(t *testing.T, server *Server) *session {
+	t.Helper()
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+
+	s := &session{t: t, in: inWriter, out: bufio.NewReader(outReader), done: make(chan error, 1)}
+	go func() {
+		err := server.Serve(context.Background(), inReader, outWriter)
+		_ = outWriter.Close()
+		s.done <- err
+	}()
+	t.Cleanup(s.close)
+	return s
+}
+
+// request sends a request and returns its response, recording notifications
+// that arrive first
+func (s *session) request(method string, params interface{}) mcp.JSONRPCResponse {
+	s.t.Helper()
+	s.nextID++
+	data, _ := json.Marshal(mcp.NewRequest(s.nextID, method, params))
+	if _, err := s.in.Write(append(data, '\n')); err != nil {
+		s.t.Fatalf("failed to send %s: %v", method, err)
+	}
+
+	for {
+		line, err := s.out.ReadBytes('\n')
+		if err != nil {
+			s.t.Fatalf("failed to read response to %s: %v", method, err)
+		}
+		var msg struct {
+			mcp.JSONRPCResponse
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal(line, &msg); err != nil {
+			s.t.Fatalf("invalid message %q: %v", line, err)
+		}
+		if msg.Method != "" {
+			s.notifications = append(s.notifications, msg.Method)
+			continue
+		}
+		if id, _ := msg.ID.(float64); int(id) != s.nextID {
+			s.t.Fatalf("response id %v, want %d", msg.ID, s.nextID)
+		}
+		return msg.JSONRPCResponse
+	}
+}
+
+// result decodes a successful response into v
+func (s *session) result(resp mcp.JSONRPCResponse, v interface{}) {
+	s.t.Helper()
+	if resp.Error != nil {
+		s.t.Fatalf("unexpected error response: %+v", resp.Error)
+	}
+	if err := json.Unmarshal(resp.Result, v); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+func (s *session) close() {
+	_ = s.in.Close()
+	go func() { _, _ = io.Copy(io.Discard, s.out) }() // Late notifications
+	select {
+	case err := <-s.done:
+		if err != nil {
+			s.t.Errorf("Serve returned %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		s.t.Error("Serve did not stop after stdin closed")
+	}
+}
+
+func newTestServer() *Server {
+	return New(testConfig(), func(_ string, serverConfig config.ServerConfig) (mcp.MCPClient, error) {
+		return client.NewMCPClient(serverConfig)
+	})
+}
+
+func TestServeAggregatesBackends(t *testing.T) {
+	s := startSession(t, newTestServer())
+
+	var initResult mcp.InitializeResult
+	s.result(s.request("initialize", map[string]interface{}{"protocolVersion": "2025-03-26"}), &initResult)
+	if initResult.ProtocolVersion != "2025-03-26" || initResult.Capabilities.Tools == nil || !initResult.Capabilities.Tools.ListChanged {
+		t.Errorf("unexpected initialize result: %+v", initResult)
+	}
+
+	// Tools of both working servers, renamed, without the hidden one; the
+	// broken and disabled servers are left out
+	var tools mcp.ListToolsResult
+	s.result(s.request("tools/list", nil), &tools)
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	if got := strings.Join(names, ","); got != "alpha__greet,beta__greet" {
+		t.Errorf("tools = %s, want alpha__greet,beta__greet", got)
+	}
+
+	// Calls reach the backend under the tool's own name, with defaults
+	var result mcp.ToolResult
+	s.result(s.request("tools/call", map[string]interface{}{"name": "alpha__greet", "arguments": map[string]interface{}{"who": "you"}}), &result)
+	text := result.Blocks()[0].Text
+	if !strings.Contains(text, `"name":"greet"`) || !strings.Contains(text, `"lang":"en"`) || !strings.Contains(text, `"who":"you"`) {
+		t.Errorf("alpha received %s", text)
+	}
+
+	for _, name := range []string{"alpha__secret", "broken__anything", "nope"} {
+		resp := s.request("tools/call", map[string]interface{}{"name": name})
+		if resp.Error == nil || resp.Error.Code != mcp.InvalidParams {
+			t.Errorf("calling %s: want an invalid params error, got %+v", name, resp)
+		}
+	}
+
+	// Resources and prompts come from the backend that supports them
+	var resources mcp.ListResourcesResult
+	s.result(s.request("resources/list", nil), &resources)
+	if len(resources.Resources) != 1 || resources.Resources[0].URI != "alpha://readme" {
+		t.Errorf("unexpected resources: %+v", resources)
+	}
+	var read struct {
+		Contents []struct{ Text string } `json:"contents"`
+	}
+	s.result(s.request("resources/read", map[string]interface{}{"uri": "alpha://readme"}), &read)
+	if len(read.Contents) != 1 || read.Contents[0].Text != "read me" {
+		t.Errorf("unexpected resource contents: %+v", read)
+	}
+
+	var prompts mcp.ListPromptsResult
+	s.result(s.request("prompts/list", nil), &prompts)
+	if len(prompts.Prompts) != 1 || prompts.Prompts[0].Name != "alpha__summarize" {
+		t.Errorf("unexpected prompts: %+v", prompts)
+	}
+	var prompt struct {
+		Messages []struct {
+			Content struct{ Text string } `json:"content"`
+		} `json:"messages"`
+	}
+	s.result(s.request("prompts/get", map[string]interface{}{"name": "alpha__summarize", "arguments": map[string]string{"text": "x"}}), &prompt)
+	if len(prompt.Messages) != 1 || !strings.Contains(prompt.Messages[0].Content.Text, `"name":"summarize"`) {
+		t.Errorf("prompt request was not renamed for the backend: %+v", prompt)
+	}
+
+	if resp := s.request("completion/complete", nil); resp.Error == nil || resp.Error.Code != mcp.MethodNotFound {
+		t.Errorf("unknown methods should fail with method not found, got %+v", resp)
+	}
+}
+
+func TestServeForwardsListChanged(t *testing.T) {
+	s := startSession(t, newTestServer())
+	s.request("initialize", nil)
+
+	var result mcp.ToolResult
+	s.result(s.request("tools/call", map[string]interface{}{"name": "beta__greet"}), &result)
+	if result.Blocks()[0].Text != "beta says hi" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(s.notifications) != 1 || s.notifications[0] != "notifications/tools/list_changed" {
+		t.Errorf("notifications = %v, want the backend's tools/list_changed", s.notifications)
+	}
+}
+
+func TestServePollsBackendsForChanges(t *testing.T) {
+	server := newTestServer()
+	server.pollInterval = 10 * time.Millisecond
+	s := startSession(t, server)
+	s.request("initialize", nil)
+
+	// beta announces a change on every ping; the next response carries it
+	time.Sleep(100 * time.Millisecond)
+	s.request("ping", nil)
+	if len(s.notifications) == 0 {
+		t.Error("expected list_changed notifications read by polling")
+	}
+}
+
+func TestServeBackendFailureIsToolError(t *testing.T) {
+	server := newTestServer()
+	s := startSession(t, server)
+	s.request("initialize", nil)
+
+	// Stop alpha behind the aggregate's back
+	for _, b := range server.aggregate.snapshot() {
+		if b.name == "alpha" {
+			_ = b.client.Close()
+		}
+	}
+
+	var result mcp.ToolResult
+	s.result(s.request("tools/call", map[string]interface{}{"name": "alpha__greet"}), &result)
+	if !result.IsError || !strings.HasPrefix(result.Blocks()[0].Text, "alpha failed:") {
+		t.Errorf("want a tool error naming the server, got %+v", result)
+	}
+
+	var tools mcp.ListToolsResult
+	s.result(s.request("tools/list", nil), &tools)
+	if len(tools.Tools) != 1 || tools.Tools[0].Name != "beta__greet" {
+		t.Errorf("tools of the failed server should be left out, got %+v", tools.Tools)
+	}
+}
+
+func TestServeFailsOnCollidingNames(t *testing.T) {
+	cfg := testConfig()
+	cfg.Serve.NamespaceStyle = "none"
+	server := New(cfg, newTestServer().newClient)
+
+	err := server.Serve(context.Background(), strings.NewReader(""), io.Discard)
+	var collisionErr *CollisionError
+	if !errors.As(err, &collisionErr) {
+		t.Fatalf("want a collision error, got %v", err)
+	}
+	want := []Collision{{Kind: "tool", Name: "greet", Sources: []string{"alpha/greet", "beta/greet"}}}
+	if !reflect.DeepEqual(collisionErr.Collisions, want) {
+		t.Errorf("collisions = %+v, want %+v", collisionErr.Collisions, want)
+	}
+}
+
+func TestServeStopsWhenCancelled(t *testing.T) {
+	inReader, inWriter := io.Pipe()
+	defer inWriter.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- newTestServer().Serve(ctx, inReader, io.Discard) }()
+
+	// stdin stays open, as when the client is still connected
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve returned %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Serve did not stop after its context was cancelled")
+	}
+}
+
+func TestServeExportAsRoutesToOriginalName(t *testing.T) {
+	cfg := testConfig()
+	cfg.Serve.NamespaceStyle = "none"
+	beta := cfg.MCPServers["beta"]
+	beta.ExportAs = map[string]string{"greet": "say_hi"}
+	cfg.MCPServers["beta"] = beta
+	s := startSession(t, New(cfg, newTestServer().newClient))
+	s.request("initialize", nil)
+
+	var tools mcp.ListToolsResult
+	s.result(s.request("tools/list", nil), &tools)
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	if got := strings.Join(names, ","); got != "greet,say_hi" {
+		t.Errorf("tools = %s, want greet,say_hi", got)
+	}
+
+	var result mcp.ToolResult
+	s.result(s.request("tools/call", map[string]interface{}{"name": "say_hi"}), &result)
+	if result.Blocks()[0].Text != "beta says hi" {
+		t.Errorf("say_hi should reach beta's greet, got %+v", result)
+	}
+	s.result(s.request("tools/call", map[string]interface{}{"name": "greet"}), &result)
+	if !strings.Contains(result.Blocks()[0].Text, `"name":"greet"`) {
+		t.Errorf("greet should reach alpha under its own name, got %+v", result)
+	}
+}
+
+func TestServeEnforcesExports(t *testing.T) {
+	cfg := testConfig()
+	cfg.Exports = &config.ExportsConfig{Tools: []string{"beta/*"}, Resources: []string{"alpha/alpha://*"}}
+	server := New(cfg, newTestServer().newClient)
+	s := startSession(t, server)
+	s.request("initialize", nil)
+
+	var tools mcp.ListToolsResult
+	s.result(s.request("tools/list", nil), &tools)
+	if len(tools.Tools) != 1 || tools.Tools[0].Name != "beta__greet" {
+		t.Errorf("only beta's tools should be listed, got %+v", tools.Tools)
+	}
+	var prompts mcp.ListPromptsResult
+	s.result(s.request("prompts/list", nil), &prompts)
+	if len(prompts.Prompts) != 0 {
+		t.Errorf("no prompts are exported, got %+v", prompts.Prompts)
+	}
+	var resources mcp.ListResourcesResult
+	s.result(s.request("resources/list", nil), &resources)
+	if len(resources.Resources) != 1 {
+		t.Errorf("alpha's resource should be listed, got %+v", resources.Resources)
+	}
+
+	for method, params := range map[string]map[string]interface{}{
+		"tools/call":  {"name": "alpha__greet"},
+		"prompts/get": {"name": "alpha__summarize"},
+	} {
+		resp := s.request(method, params)
+		if resp.Error == nil || resp.Error.Code != PermissionDenied || !strings.Contains(resp.Error.Message, "export policy") {
+			t.Errorf("%s %v: want a permission error naming the export policy, got %+v", method, params["name"], resp)
+		}
+	}
+
+	// A new policy takes effect at once, and the client is told to list again
+	updated := make(chan error, 1)
+	go func() { updated <- server.SetExports(&config.ExportsConfig{Tools: []string{"*/greet"}}) }()
+	for range exportsChangedNotifications {
+		if _, err := s.out.ReadBytes('\n'); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := <-updated; err != nil {
+		t.Fatal(err)
+	}
+
+	s.result(s.request("tools/list", nil), &tools)
+	if len(tools.Tools) != 2 {
+		t.Errorf("both greet tools should be listed after the update, got %+v", tools.Tools)
+	}
+	var result mcp.ToolResult
+	s.result(s.request("tools/call", map[string]interface{}{"name": "alpha__greet"}), &result)
+	if resp := s.request("resources/read", map[string]interface{}{"uri": "alpha://readme"}); resp.Error == nil || resp.Error.Code != PermissionDenied {
+		t.Errorf("the resource is no longer exported, got %+v", resp)
+	}
+}
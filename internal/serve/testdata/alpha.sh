@@ -0,0 +1,22 @@
+#!/bin/sh
+# Fake MCP server with tools, resources and prompts. Tool calls and prompts
+# echo the request line they received.
+while IFS= read -r line; do
+  escaped=$(printf '%s' "$line" | sed 's/\\/\\\\/g; s/"/\\"/g')
+  case "$line" in
+    *'"method":"initialize"'*)
+      echo '{"jsonrpc":"2.0","id":0,"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{},"resources":{},"prompts":{}},"serverInfo":{"name":"alpha","version":"1.0.0"}}}' ;;
+    *'"method":"tools/list"'*)
+      echo '{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"greet","description":"Say hello","inputSchema":{"type":"object"}},{"name":"secret","inputSchema":{"type":"object"}}]}}' ;;
+    *'"method":"tools/call"'*)
+      echo '{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"'"$escaped"'"}]}}' ;;
+    *'"method":"resources/list"'*)
+      echo '{"jsonrpc":"2.0","id":3,"result":{"resources":[{"uri":"alpha://readme","name":"readme"}]}}' ;;
+    *'"method":"resources/read"'*)
+      echo '{"jsonrpc":"2.0","id":0,"result":{"contents":[{"uri":"alpha://readme","text":"read me"}]}}' ;;
+    *'"method":"prompts/list"'*)
+      echo '{"jsonrpc":"2.0","id":0,"result":{"prompts":[{"name":"summarize","arguments":[{"name":"text","required":true}]}]}}' ;;
+    *'"method":"prompts/get"'*)
+      echo '{"jsonrpc":"2.0","id":0,"result":{"messages":[{"role":"user","content":{"type":"text","text":"'"$escaped"'"}}]}}' ;;
+  esac
+done
@@ -0,0 +1,17 @@
+#!/bin/sh
+# Fake MCP server whose tool list changes: every tool call is preceded by a
+# tools/list_changed notification.
+while IFS= read -r line; do
+  case "$line" in
+    *'"method":"initialize"'*)
+      echo '{"jsonrpc":"2.0","id":0,"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{"listChanged":true}},"serverInfo":{"name":"beta","version":"1.0.0"}}}' ;;
+    *'"method":"tools/list"'*)
+      echo '{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"greet","description":"Say hi","inputSchema":{"type":"object"}}]}}' ;;
+    *'"method":"tools/call"'*)
+      echo '{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}'
+      echo '{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"beta says hi"}]}}' ;;
+    *'"method":"ping"'*)
+      echo '{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}'
+      echo '{"jsonrpc":"2.0","id":0,"result":{}}' ;;
+  esac
+done
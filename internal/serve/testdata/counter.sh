@@ -0,0 +1,18 @@
+#!/bin/sh
+# Fake stateful MCP server: "count" returns how often it was called in this
+# process, and "slow" answers after a second.
+n=0
+while IFS= read -r line; do
+  case "$line" in
+    *'"method":"initialize"'*)
+      echo '{"jsonrpc":"2.0","id":0,"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"counter","version":"1.0.0"}}}' ;;
+    *'"method":"tools/list"'*)
+      echo '{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"count","inputSchema":{"type":"object"}},{"name":"slow","inputSchema":{"type":"object"}}]}}' ;;
+    *'"method":"tools/call"'*'"name":"slow"'*)
+      sleep 1
+      echo '{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"done"}]}}' ;;
+    *'"method":"tools/call"'*)
+      n=$((n + 1))
+      echo '{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"'"$n"'"}]}}' ;;
+  esac
+done
@@ -0,0 +1,189 @@
+// Package serverstate gathers what is known about each configured server in
+// one place: its configuration, its saved session and the daemon's session
+// of it. list-servers --state prints it; other views can reuse it.
+package serverstate
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/session"
+)
+
+// SchemaVersion is the version of the Report JSON. Fields may be added
+// within a version; renaming or removing one bumps it.
+const SchemaVersion = 1
+
+// Report is the state of the configured servers
+type Report struct {
+	Version int  `json:"version"` // SchemaVersion
+	State   bool `json:"state"`   // Sessions and the daemon were looked at; otherwise only the configuration was
+
+	DaemonRunning bool   `json:"daemonRunning"`
+	DaemonError   string `json:"daemonError,omitempty"` // Why the daemon's sessions could not be listed
+
+	Servers []Server `json:"servers"` // Sorted by name
+}
+
+// Server is the state of one configured server
+type Server struct {
+	Name           string   `json:"name"`
+	Enabled        bool     `json:"enabled"`
+	DisabledReason string   `json:"disabledReason,omitempty"`
+	Transport      string   `json:"transport"`   // stdio, http, docker, ssh or unknown
+	SessionType    string   `json:"sessionType"` // stateless, persistent or hybrid
+	Details        string   `json:"details"`
+	Description    string   `json:"description,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	Source         string   `json:"source,omitempty"`
+
+	Session      *Session     `json:"session,omitempty"`      // The server's saved session record, if any
+	Daemon       *DaemonState `json:"daemon,omitempty"`       // The daemon's session of the server, if it has one
+	LastActivity *time.Time   `json:"lastActivity,omitempty"` // The latest activity of either
+}
+
+// Session is a server's saved session record
+type Session struct {
+	ID           string    `json:"id"`
+	Status       string    `json:"status"`
+	Live         bool      `json:"live"` // Its process runs, or for daemon-owned sessions, the daemon has it
+	DaemonOwned  bool      `json:"daemonOwned,omitempty"`
+	PID          int       `json:"pid,omitempty"`
+	LastActivity time.Time `json:"lastActivity"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// DaemonState is the daemon's session of a server
+type DaemonState struct {
+	Status         string    `json:"status"`
+	PID            int       `json:"pid,omitempty"`
+	StartTime      time.Time `json:"startTime"`
+	LastUsed       time.Time `json:"lastUsed"`
+	Error          string    `json:"error,omitempty"`
+	ConfigOutdated bool      `json:"configOutdated,omitempty"`
+}
+
+// DaemonSessions lists the sessions of the running daemon; a
+// *daemon.DaemonClient is one
+type DaemonSessions interface {
+	IsDaemonRunning() bool
+	ListSessions(ctx context.Context) ([]daemon.SessionInfo, error)
+}
+
+// FromConfig reports the configured servers without looking at sessions or
+// the daemon
+func FromConfig(cfg *config.Configuration) *Report {
+	report := &Report{Version: SchemaVersion, Servers: make([]Server, 0, len(cfg.MCPServers))}
+	for _, status := range cfg.GetServerStatus() {
+		serverConfig, _ := cfg.GetServer(status.Name)
+		report.Servers = append(report.Servers, Server{
+			Name:           status.Name,
+			Enabled:        status.Status == "enabled",
+			DisabledReason: status.Reason,
+			Transport:      strings.ToLower(status.Type),
+			SessionType:    session.DetectSessionType(serverConfig).String(),
+			Details:        status.Details,
+			Description:    serverConfig.Description,
+			Tags:           status.Tags,
+			Source:         status.Source,
+		})
+	}
+	sort.Slice(report.Servers, func(i, j int) bool { return report.Servers[i].Name < report.Servers[j].Name })
+	return report
+}
+
+// Gather reports the configured servers with their saved sessions in store
+// and the sessions of the daemon, if it runs. Neither is changed: records of
+// dead sessions are reported as not live rather than cleaned up.
+func Gather(ctx context.Context, cfg *config.Configuration, store *session.FileStore, daemonSessions DaemonSessions) *Report {
+	report := FromConfig(cfg)
+	report.State = true
+
+	daemonByName := make(map[string]daemon.SessionInfo)
+	if daemonSessions != nil && daemonSessions.IsDaemonRunning() {
+		report.DaemonRunning = true
+		sessions, err := daemonSessions.ListSessions(ctx)
+		if err != nil {
+			report.DaemonError = err.Error()
+		}
+		for _, info := range sessions {
+			daemonByName[info.ServerName] = info
+		}
+	}
+
+	records := latestRecords(store)
+	processManager := session.NewProcessManager()
+	for i := range report.Servers {
+		server := &report.Servers[i]
+		if info, ok := daemonByName[server.Name]; ok {
+			server.Daemon = &DaemonState{
+				Status:         info.Status,
+				PID:            info.PID,
+				StartTime:      info.StartTime,
+				LastUsed:       info.LastUsed,
+				Error:          info.Error,
+				ConfigOutdated: info.ConfigOutdated,
+			}
+			server.noteActivity(info.LastUsed)
+		}
+		if record, ok := records[server.Name]; ok {
+			server.Session = savedSession(record, server.Daemon, processManager)
+			server.noteActivity(record.LastActivity)
+		}
+	}
+	return report
+}
+
+// latestRecords returns the most recently active saved session of each
+// server
+func latestRecords(store *session.FileStore) map[string]*session.SessionInfo {
+	latest := make(map[string]*session.SessionInfo)
+	if store == nil {
+		return latest
+	}
+	records, err := store.ListSessions()
+	if err != nil {
+		return latest
+	}
+	for _, record := range records {
+		if current, ok := latest[record.Name]; !ok || record.LastActivity.After(current.LastActivity) {
+			latest[record.Name] = record
+		}
+	}
+	return latest
+}
+
+// savedSession describes a session record. A daemon-owned session is live
+// while the daemon has it; any other while its process runs.
+func savedSession(record *session.SessionInfo, daemonState *DaemonState, processManager *session.ProcessManager) *Session {
+	saved := &Session{
+		ID:           record.SessionID,
+		Status:       record.Status.String(),
+		DaemonOwned:  record.ConnectionInfo != nil && record.ConnectionInfo.Type == session.BrokeredConnectionType,
+		PID:          record.PID,
+		LastActivity: record.LastActivity,
+		Error:        record.Error,
+	}
+	if record.Status == session.Active {
+		if saved.DaemonOwned {
+			saved.Live = daemonState != nil && daemonState.Status == "active"
+		} else {
+			saved.Live = processManager.IsProcessAlive(record.PID)
+		}
+	}
+	return saved
+}
+
+// noteActivity keeps the latest activity seen for the server
+func (s *Server) noteActivity(at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	if s.LastActivity == nil || at.After(*s.LastActivity) {
+		s.LastActivity = &at
+	}
+}
@@ -0,0 +1,184 @@
+package serverstate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/daemon"
+	"github.com/mcp-cli-ent/mcp-cli/internal/session"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// stubDaemon answers for a daemon with the given sessions
+type stubDaemon struct {
+	running  bool
+	sessions []daemon.SessionInfo
+	err      error
+}
+
+func (d *stubDaemon) IsDaemonRunning() bool { return d.running }
+
+func (d *stubDaemon) ListSessions(context.Context) ([]daemon.SessionInfo, error) {
+	return d.sessions, d.err
+}
+
+// daemonUsed is when the stub daemon's browser session was last used
+var daemonUsed = time.Date(2026, 10, 1, 11, 0, 0, 0, time.UTC)
+
+func runningDaemon() *stubDaemon {
+	return &stubDaemon{running: true, sessions: []daemon.SessionInfo{{
+		ServerName: "browser",
+		Status:     "active",
+		StartTime:  time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC),
+		LastUsed:   daemonUsed,
+	}}}
+}
+
+func testConfig() *config.Configuration {
+	disabled := false
+	return &config.Configuration{MCPServers: map[string]config.ServerConfig{
+		"browser": {Command: "npx", Args: []string{"-y", "chrome-devtools-mcp@latest"}, Description: "Chrome DevTools"},
+		"fetch":   {Command: "uvx", Args: []string{"mcp-server-fetch"}, Tags: []string{"web"}},
+		"docs":    {Type: "http", URL: "https://mcp.example.com/mcp"},
+		"legacy":  {Command: "legacy-server", Enabled: &disabled},
+	}}
+}
+
+// fixtureStore returns a file store holding copies of the session files in
+// testdata/sessions
+func fixtureStore(t *testing.T) *session.FileStore {
+	t.Helper()
+	dir := t.TempDir()
+	files, err := filepath.Glob(filepath.Join("testdata", "sessions", "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no session fixtures: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(file)), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return session.NewFileStore(dir)
+}
+
+func serverNamed(t *testing.T, report *Report, name string) Server {
+	t.Helper()
+	for _, server := range report.Servers {
+		if server.Name == name {
+			return server
+		}
+	}
+	t.Fatalf("no server %s in %+v", name, report.Servers)
+	return Server{}
+}
+
+func TestGatherCombinesSessionsAndDaemon(t *testing.T) {
+	report := Gather(context.Background(), testConfig(), fixtureStore(t), runningDaemon())
+	if !report.State || !report.DaemonRunning || report.Version != SchemaVersion {
+		t.Errorf("report = %+v", report)
+	}
+
+	browser := serverNamed(t, report, "browser")
+	if browser.SessionType != "persistent" || browser.Transport != "stdio" {
+		t.Errorf("browser = %+v", browser)
+	}
+	if browser.Session == nil || !browser.Session.Live || !browser.Session.DaemonOwned {
+		t.Errorf("browser's daemon-owned session should be live: %+v", browser.Session)
+	}
+	if browser.Daemon == nil || browser.Daemon.Status != "active" {
+		t.Errorf("browser's daemon session = %+v", browser.Daemon)
+	}
+	if browser.LastActivity == nil || !browser.LastActivity.Equal(daemonUsed) {
+		t.Errorf("browser's last activity = %v, want the daemon's %v", browser.LastActivity, daemonUsed)
+	}
+
+	// The newest of fetch's records counts; its process is gone
+	fetch := serverNamed(t, report, "fetch")
+	if fetch.Session == nil || fetch.Session.ID != "fetch-2026-10-01-10-00-00-new222" || fetch.Session.Live {
+		t.Errorf("fetch's session = %+v", fetch.Session)
+	}
+	if fetch.Daemon != nil {
+		t.Errorf("fetch has no daemon session, got %+v", fetch.Daemon)
+	}
+
+	if docs := serverNamed(t, report, "docs"); docs.Session != nil || docs.Daemon != nil || docs.LastActivity != nil {
+		t.Errorf("docs has no session, got %+v", docs)
+	}
+	if legacy := serverNamed(t, report, "legacy"); legacy.Enabled {
+		t.Errorf("legacy is disabled, got %+v", legacy)
+	}
+}
+
+func TestGatherWithoutDaemon(t *testing.T) {
+	store := fixtureStore(t)
+
+	// A session of this process's own is live
+	live := &session.SessionInfo{
+		SessionID:    "docs-live",
+		Name:         "docs",
+		Status:       session.Active,
+		PID:          os.Getpid(),
+		LastActivity: time.Now(),
+	}
+	if err := store.SaveSession(live); err != nil {
+		t.Fatal(err)
+	}
+
+	report := Gather(context.Background(), testConfig(), store, &stubDaemon{})
+	if report.DaemonRunning {
+		t.Error("the daemon is not running")
+	}
+	if browser := serverNamed(t, report, "browser"); browser.Session == nil || browser.Session.Live {
+		t.Errorf("a daemon-owned session is not live without the daemon: %+v", browser.Session)
+	}
+	if docs := serverNamed(t, report, "docs"); docs.Session == nil || !docs.Session.Live || docs.Session.PID != os.Getpid() {
+		t.Errorf("docs' session = %+v", docs.Session)
+	}
+
+	failing := &stubDaemon{running: true, err: errors.New("daemon not responding")}
+	if report := Gather(context.Background(), testConfig(), store, failing); report.DaemonError != "daemon not responding" {
+		t.Errorf("daemon error = %q", report.DaemonError)
+	}
+}
+
+func TestReportGoldenFormat(t *testing.T) {
+	report := Gather(context.Background(), testConfig(), fixtureStore(t), runningDaemon())
+	got, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "report.golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("report format changed; bump SchemaVersion if a field was renamed or removed:\n got: %s\nwant: %s", got, want)
+	}
+
+	// Without --state only the configuration is reported
+	static, _ := json.Marshal(FromConfig(testConfig()))
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(static, &decoded)
+	if decoded["state"] != false {
+		t.Errorf("static report = %s", static)
+	}
+}
@@ -0,0 +1,336 @@
+// Package service generates the definitions that start the daemon at login:
+// a systemd user unit on Linux, a launchd agent on macOS and a Task
+// Scheduler task on Windows. Each runs "mcp-cli-ent daemon start
+// --foreground" from the absolute path of the executable, with the user's
+// configuration directory.
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// Name identifies the service to systemd and Task Scheduler
+const Name = "mcp-cli-ent-daemon"
+
+// Label identifies the launchd agent
+const Label = "com.mcp-cli-ent.daemon"
+
+// Service managers
+const (
+	Systemd       = "systemd"
+	Launchd       = "launchd"
+	TaskScheduler = "Task Scheduler"
+)
+
+// Options are what a definition refers to
+type Options struct {
+	Executable string // Absolute path of the mcp-cli-ent binary
+	ConfigDir  string // The configuration directory the daemon uses
+	DataDir    string // The data directory the daemon uses, when not the configuration directory
+	HomeDir    string // Where the user's service definitions live
+	User       string // The account a Windows task runs as
+}
+
+// DefaultOptions returns the options for the running executable and the
+// current user
+func DefaultOptions() (Options, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return Options{}, fmt.Errorf("failed to find the executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return Options{}, err
+	}
+	if configDir, err = filepath.Abs(configDir); err != nil {
+		return Options{}, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return Options{}, fmt.Errorf("failed to find the home directory: %w", err)
+	}
+	opts := Options{Executable: executable, ConfigDir: configDir, HomeDir: homeDir}
+	if dataDir := os.Getenv(config.DataDirEnv); dataDir != "" {
+		if opts.DataDir, err = filepath.Abs(dataDir); err != nil {
+			return Options{}, err
+		}
+	}
+	if current, err := user.Current(); err == nil {
+		opts.User = current.Username
+	}
+	return opts, nil
+}
+
+// Definition is a service definition for one platform: the file to write
+// and the commands that register it with the service manager
+type Definition struct {
+	Manager    string
+	Path       string // Where the file is written
+	Content    []byte
+	Register   [][]string // Run after writing the file
+	Unregister [][]string // Run before removing the file
+	Cleanup    [][]string // Run after removing the file
+}
+
+// For returns the definition for goos, as runtime.GOOS names platforms
+func For(goos string, opts Options) (*Definition, error) {
+	switch goos {
+	case "linux":
+		return systemdDefinition(opts)
+	case "darwin":
+		return launchdDefinition(opts)
+	case "windows":
+		return taskDefinition(opts)
+	}
+	return nil, fmt.Errorf("installing the daemon as a service is not supported on %s", goos)
+}
+
+const systemdTemplate = `[Unit]
+Description=mcp-cli-ent daemon: persistent MCP server sessions
+
+[Service]
+Type=simple
+ExecStart={{quote .Executable}} daemon start --foreground{{if .DataDir}} --data-dir {{quote .DataDir}}{{end}}
+WorkingDirectory={{quote .ConfigDir}}
+Environment={{quote (print .ConfigDirEnv "=" .ConfigDir)}}
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=default.target
+`
+
+// systemdDefinition is a systemd user unit, in $XDG_CONFIG_HOME/systemd/user
+func systemdDefinition(opts Options) (*Definition, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(opts.HomeDir, ".config")
+	}
+	content, err := render(systemdTemplate, template.FuncMap{"quote": systemdQuote}, opts)
+	if err != nil {
+		return nil, err
+	}
+	unit := Name + ".service"
+	return &Definition{
+		Manager: Systemd,
+		Path:    filepath.Join(base, "systemd", "user", unit),
+		Content: content,
+		Register: [][]string{
+			{"systemctl", "--user", "daemon-reload"},
+			{"systemctl", "--user", "enable", "--now", unit},
+		},
+		Unregister: [][]string{
+			{"systemctl", "--user", "disable", "--now", unit},
+		},
+		Cleanup: [][]string{
+			{"systemctl", "--user", "daemon-reload"},
+		},
+	}, nil
+}
+
+// systemdQuote quotes a value for a unit file, where "%" starts a specifier
+func systemdQuote(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%").Replace(value)
+	return `"` + value + `"`
+}
+
+const launchdTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{escape .Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{escape .Executable}}</string>
+		<string>daemon</string>
+		<string>start</string>
+		<string>--foreground</string>{{if .DataDir}}
+		<string>--data-dir</string>
+		<string>{{escape .DataDir}}</string>{{end}}
+	</array>
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>{{escape .ConfigDirEnv}}</key>
+		<string>{{escape .ConfigDir}}</string>
+	</dict>
+	<key>WorkingDirectory</key>
+	<string>{{escape .ConfigDir}}</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+</dict>
+</plist>
+`
+
+// launchdDefinition is a launchd agent, in ~/Library/LaunchAgents
+func launchdDefinition(opts Options) (*Definition, error) {
+	content, err := render(launchdTemplate, template.FuncMap{"escape": xmlEscape}, opts)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(opts.HomeDir, "Library", "LaunchAgents", Label+".plist")
+	return &Definition{
+		Manager:    Launchd,
+		Path:       path,
+		Content:    content,
+		Register:   [][]string{{"launchctl", "load", "-w", path}},
+		Unregister: [][]string{{"launchctl", "unload", "-w", path}},
+	}, nil
+}
+
+const taskTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+  <RegistrationInfo>
+    <Description>mcp-cli-ent daemon: persistent MCP server sessions</Description>
+  </RegistrationInfo>
+  <Triggers>
+    <LogonTrigger>
+      <Enabled>true</Enabled>{{if .User}}
+      <UserId>{{escape .User}}</UserId>{{end}}
+    </LogonTrigger>
+  </Triggers>
+  <Principals>
+    <Principal id="Author">{{if .User}}
+      <UserId>{{escape .User}}</UserId>{{end}}
+      <LogonType>InteractiveToken</LogonType>
+      <RunLevel>LeastPrivilege</RunLevel>
+    </Principal>
+  </Principals>
+  <Settings>
+    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>
+    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>
+    <StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>
+    <ExecutionTimeLimit>PT0S</ExecutionTimeLimit>
+    <RestartOnFailure>
+      <Interval>PT1M</Interval>
+      <Count>3</Count>
+    </RestartOnFailure>
+  </Settings>
+  <Actions Context="Author">
+    <Exec>
+      <Command>{{escape .Executable}}</Command>
+      <Arguments>daemon start --foreground{{if .DataDir}} --data-dir "{{escape .DataDir}}"{{end}}</Arguments>
+      <WorkingDirectory>{{escape .ConfigDir}}</WorkingDirectory>
+    </Exec>
+  </Actions>
+</Task>
+`
+
+// taskDefinition is a Task Scheduler task that runs at logon. Tasks cannot
+// set environment variables, so the configuration directory is the task's
+// working directory and the file is kept there.
+func taskDefinition(opts Options) (*Definition, error) {
+	content, err := render(taskTemplate, template.FuncMap{"escape": xmlEscape}, opts)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(opts.ConfigDir, Name+".xml")
+	return &Definition{
+		Manager:    TaskScheduler,
+		Path:       path,
+		Content:    content,
+		Register:   [][]string{{"schtasks", "/Create", "/TN", Name, "/XML", path, "/F"}},
+		Unregister: [][]string{{"schtasks", "/Delete", "/TN", Name, "/F"}},
+	}, nil
+}
+
+// render fills in a template with the options
+func render(text string, funcs template.FuncMap, opts Options) ([]byte, error) {
+	tmpl, err := template.New("service").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	data := struct {
+		Options
+		Label        string
+		ConfigDirEnv string
+	}{opts, Label, config.ConfigDirEnv}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to generate the service definition: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// xmlEscape escapes text for XML content
+func xmlEscape(value string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(value))
+	return buf.String()
+}
+
+// Runner runs a service manager command
+type Runner func(name string, args ...string) error
+
+// Exec runs a command, returning its output with the error if it fails
+func Exec(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if text := strings.TrimSpace(string(output)); text != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, text)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Install writes the definition and registers it with run
+func (d *Definition) Install(run Runner) error {
+	if err := os.MkdirAll(filepath.Dir(d.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(d.Path), err)
+	}
+	if err := os.WriteFile(d.Path, d.Content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", d.Path, err)
+	}
+	if err := runAll(run, d.Register); err != nil {
+		return fmt.Errorf("failed to register the service with %s: %w", d.Manager, err)
+	}
+	return nil
+}
+
+// Uninstall unregisters the definition with run and removes its file. A
+// definition that is not installed is an error.
+func (d *Definition) Uninstall(run Runner) error {
+	if _, err := os.Stat(d.Path); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("the daemon is not installed as a service (no %s)", d.Path)
+	}
+	if err := runAll(run, d.Unregister); err != nil {
+		return fmt.Errorf("failed to unregister the service with %s: %w", d.Manager, err)
+	}
+	if err := os.Remove(d.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", d.Path, err)
+	}
+	if err := runAll(run, d.Cleanup); err != nil {
+		return fmt.Errorf("failed to unregister the service with %s: %w", d.Manager, err)
+	}
+	return nil
+}
+
+// runAll runs commands in order, stopping at the first that fails
+func runAll(run Runner, commands [][]string) error {
+	for _, command := range commands {
+		if err := run(command[0], command[1:]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
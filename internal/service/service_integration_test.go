@@ -0,0 +1,37 @@
+//go:build integration
+
+package service
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+// TestRegisterWithServiceManager installs the daemon with the platform's real
+// service manager and removes it again. Run it with -tags integration on a
+// machine where that is acceptable. The definition runs the test binary,
+// which exits at once; only registering and unregistering are checked.
+func TestRegisterWithServiceManager(t *testing.T) {
+	opts, err := DefaultOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	def, err := For(runtime.GOOS, opts)
+	if err != nil {
+		t.Skip(err)
+	}
+	if _, err := os.Stat(def.Path); err == nil {
+		t.Skipf("the daemon is already installed at %s", def.Path)
+	}
+
+	if err := def.Install(Exec); err != nil {
+		t.Fatal(err)
+	}
+	if err := def.Uninstall(Exec); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(def.Path); !os.IsNotExist(err) {
+		t.Errorf("%s was not removed", def.Path)
+	}
+}
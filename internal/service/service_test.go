@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// Paths with characters each format has to escape
+var testOptions = map[string]Options{
+	"linux":   {Executable: "/opt/mcp tools/mcp-cli-ent", ConfigDir: "/home/ana/.config/mcp-cli-ent", HomeDir: "/home/ana"},
+	"darwin":  {Executable: "/Applications/R&D/mcp-cli-ent", ConfigDir: "/Users/ana/.config/mcp-cli-ent", HomeDir: "/Users/ana"},
+	"windows": {Executable: `C:\Program Files\mcp-cli-ent\mcp-cli-ent.exe`, ConfigDir: `C:\Users\ana\AppData\Roaming\mcp-cli-ent`, HomeDir: `C:\Users\ana`, User: `DESKTOP\ana`},
+}
+
+func TestDefinitionGolden(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	for goos, golden := range map[string]string{"linux": "systemd.golden", "darwin": "launchd.golden", "windows": "task.golden"} {
+		t.Run(goos, func(t *testing.T) {
+			def, err := For(goos, testOptions[goos])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", golden)
+			if *updateGolden {
+				if err := os.WriteFile(path, def.Content, 0644); err != nil {
+					t.Fatalf("failed to update golden file: %v", err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+			if string(def.Content) != string(want) {
+				t.Errorf("definition mismatch\n--- got ---\n%s\n--- want ---\n%s", def.Content, want)
+			}
+		})
+	}
+}
+
+func TestDefinitionPassesDataDir(t *testing.T) {
+	for goos, want := range map[string]string{
+		"linux":   `--foreground --data-dir "/srv/mcp data"`,
+		"darwin":  "<string>--data-dir</string>\n\t\t<string>/srv/mcp data</string>",
+		"windows": `--foreground --data-dir "D:\mcp data"</Arguments>`,
+	} {
+		opts := testOptions[goos]
+		opts.DataDir = "/srv/mcp data"
+		if goos == "windows" {
+			opts.DataDir = `D:\mcp data`
+		}
+		def, err := For(goos, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(def.Content), want) {
+			t.Errorf("%s: definition lacks %q:\n%s", goos, want, def.Content)
+		}
+	}
+}
+
+func TestForSelectsPlatform(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	tests := []struct {
+		goos     string
+		manager  string
+		path     string
+		register []string
+	}{
+		{"linux", Systemd, filepath.Join("/home/ana", ".config", "systemd", "user", "mcp-cli-ent-daemon.service"), []string{"systemctl", "--user", "enable", "--now", "mcp-cli-ent-daemon.service"}},
+		{"darwin", Launchd, filepath.Join("/Users/ana", "Library", "LaunchAgents", "com.mcp-cli-ent.daemon.plist"), []string{"launchctl", "load", "-w", filepath.Join("/Users/ana", "Library", "LaunchAgents", "com.mcp-cli-ent.daemon.plist")}},
+		{"windows", TaskScheduler, filepath.Join(`C:\Users\ana\AppData\Roaming\mcp-cli-ent`, "mcp-cli-ent-daemon.xml"), []string{"schtasks", "/Create", "/TN", "mcp-cli-ent-daemon", "/XML", filepath.Join(`C:\Users\ana\AppData\Roaming\mcp-cli-ent`, "mcp-cli-ent-daemon.xml"), "/F"}},
+	}
+	for _, tt := range tests {
+		def, err := For(tt.goos, testOptions[tt.goos])
+		if err != nil {
+			t.Fatalf("%s: %v", tt.goos, err)
+		}
+		if def.Manager != tt.manager || def.Path != tt.path {
+			t.Errorf("%s: got %s at %s, want %s at %s", tt.goos, def.Manager, def.Path, tt.manager, tt.path)
+		}
+		if last := def.Register[len(def.Register)-1]; !reflect.DeepEqual(last, tt.register) {
+			t.Errorf("%s: registered with %q, want %q", tt.goos, last, tt.register)
+		}
+	}
+
+	// The systemd unit follows $XDG_CONFIG_HOME
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if def, _ := For("linux", testOptions["linux"]); def.Path != filepath.Join("/xdg", "systemd", "user", "mcp-cli-ent-daemon.service") {
+		t.Errorf("unit path ignores XDG_CONFIG_HOME: %s", def.Path)
+	}
+
+	if _, err := For("plan9", testOptions["linux"]); err == nil || !strings.Contains(err.Error(), "not supported on plan9") {
+		t.Errorf("want an unsupported platform error, got %v", err)
+	}
+}
+
+func TestInstallAndUninstall(t *testing.T) {
+	def := &Definition{
+		Manager:    Systemd,
+		Path:       filepath.Join(t.TempDir(), "user", "unit.service"),
+		Content:    []byte("[Unit]\n"),
+		Register:   [][]string{{"systemctl", "--user", "daemon-reload"}, {"systemctl", "--user", "enable", "unit.service"}},
+		Unregister: [][]string{{"systemctl", "--user", "disable", "unit.service"}},
+		Cleanup:    [][]string{{"systemctl", "--user", "daemon-reload"}},
+	}
+	var ran []string
+	run := func(name string, args ...string) error {
+		ran = append(ran, strings.Join(append([]string{name}, args...), " "))
+		return nil
+	}
+
+	if err := def.Install(run); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(def.Path); err != nil || string(data) != "[Unit]\n" {
+		t.Fatalf("definition not written: %q, %v", data, err)
+	}
+	if err := def.Uninstall(run); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(def.Path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("definition not removed: %v", err)
+	}
+	want := []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable unit.service",
+		"systemctl --user disable unit.service",
+		"systemctl --user daemon-reload",
+	}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+
+	if err := def.Uninstall(run); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("want a not-installed error, got %v", err)
+	}
+
+	failing := func(string, ...string) error { return errors.New("exit status 1") }
+	if err := def.Install(failing); err == nil || !strings.Contains(err.Error(), "failed to register the service with systemd") {
+		t.Errorf("want a registration error, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// AdoptedExtraKey marks in ConnectionInfo.Extra a session whose process was
+// adopted rather than started by a session
+const AdoptedExtraKey = "adopted"
+
+// AdoptProcess records the running process pid as serverName's session and
+// reattaches to it, for a server process a crashed session lost track of.
+// Only processes that can be reached again, such as local HTTP servers, can
+// be adopted: the stdio pipes of a lost process are gone with its parent.
+// When reattaching fails the record is dropped again and the error says why.
+func (m *Manager) AdoptProcess(serverName string, serverConfig config.ServerConfig, pid int) (*SessionInfo, error) {
+	m.mutex.RLock()
+	_, exists := m.sessions[serverName]
+	m.mutex.RUnlock()
+	if exists {
+		return nil, fmt.Errorf("server %s already has a session; stop it before adopting a process", serverName)
+	}
+
+	processInfo, err := m.processManager.FindProcess(pid)
+	if err != nil {
+		return nil, fmt.Errorf("cannot adopt process %d: %w", pid, err)
+	}
+
+	// Records of the sessions that lost the process are stale now
+	_ = m.fileStore.DeleteSessionsByName(serverName)
+	info := adoptedSessionInfo(m.fileStore.GenerateSessionID(serverName), serverName, serverConfig, processInfo)
+	if err := m.fileStore.SaveSession(info); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	session, err := LoadPersistentSession(info, m.clientFactory, m.fileStore)
+	if err != nil {
+		_ = m.fileStore.DeleteSession(info.SessionID)
+		return nil, err
+	}
+	if err := session.reattachAdopted(); err != nil {
+		_ = m.fileStore.DeleteSession(info.SessionID)
+		return nil, fmt.Errorf("cannot reattach to process %d: %w", pid, err)
+	}
+
+	m.mutex.Lock()
+	if _, exists := m.sessions[serverName]; exists {
+		m.mutex.Unlock()
+		_ = session.Stop()
+		return nil, fmt.Errorf("server %s got a session while adopting process %d", serverName, pid)
+	}
+	m.sessions[serverName] = session
+	m.startHealthMonitor(serverName, session)
+	m.mutex.Unlock()
+
+	adopted := session.GetInfo()
+	return &adopted, nil
+}
+
+// adoptedSessionInfo describes the session of an adopted process, with the
+// connection details the server's configuration gives
+func adoptedSessionInfo(sessionID, serverName string, serverConfig config.ServerConfig, processInfo *ProcessInfo) *SessionInfo {
+	connectionInfo := &ConnectionInfo{
+		Type: "stdio",
+		Extra: map[string]interface{}{
+			"command":       serverConfig.Command,
+			"args":          serverConfig.Unresolved().Args,
+			"timeout":       serverConfig.Timeout,
+			AdoptedExtraKey: true,
+		},
+	}
+	var endpoints []string
+	if serverConfig.Type == "http" {
+		connectionInfo = &ConnectionInfo{
+			Type: "http",
+			URL:  serverConfig.URL,
+			Extra: map[string]interface{}{
+				"timeout":       serverConfig.Timeout,
+				AdoptedExtraKey: true,
+			},
+		}
+		endpoints = []string{serverConfig.URL}
+	}
+
+	startTime := processInfo.CreateTime
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+	return &SessionInfo{
+		SessionID:      sessionID,
+		Name:           serverName,
+		Type:           DetectSessionType(serverConfig),
+		Status:         Active,
+		PID:            processInfo.PID,
+		ProcessPath:    processInfo.Executable,
+		ProcessArgs:    processInfo.Args,
+		ConnectionInfo: connectionInfo,
+		StartTime:      startTime,
+		LastActivity:   time.Now(),
+		Endpoints:      endpoints,
+		Config:         serverConfig,
+	}
+}
+
+// TerminateOrphan stops the process pid and its children, for a server
+// process no session can reattach to, and drops the session records that
+// still name it. It returns what the process was.
+func (m *Manager) TerminateOrphan(pid int) (*ProcessInfo, error) {
+	if pid == os.Getpid() {
+		return nil, fmt.Errorf("process %d is this command", pid)
+	}
+	processInfo, err := m.processManager.FindProcess(pid)
+	if err != nil {
+		return nil, fmt.Errorf("cannot terminate process %d: %w", pid, err)
+	}
+
+	if err := m.processManager.TerminateProcessTree(pid); err != nil {
+		return nil, err
+	}
+
+	if records, err := m.fileStore.ListSessions(); err == nil {
+		for _, record := range records {
+			if record.PID == pid {
+				_ = m.fileStore.DeleteSession(record.SessionID)
+			}
+		}
+	}
+	return processInfo, nil
+}
+
+// OrphanCandidates lists running processes that look like browser automation
+// servers, such as Chrome started with remote debugging or Playwright, and
+// that no saved session tracks: candidates to adopt or terminate
+func (m *Manager) OrphanCandidates() ([]*ProcessInfo, error) {
+	processes, err := m.processManager.FindBrowserProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := map[int]bool{os.Getpid(): true}
+	if records, err := m.fileStore.ListSessions(); err == nil {
+		for _, record := range records {
+			if record.PID > 0 {
+				tracked[record.PID] = true
+			}
+		}
+	}
+
+	// A process matching several patterns is listed once
+	var candidates []*ProcessInfo
+	for _, process := range processes {
+		if tracked[process.PID] {
+			continue
+		}
+		tracked[process.PID] = true
+		candidates = append(candidates, process)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].PID < candidates[j].PID })
+	return candidates, nil
+}
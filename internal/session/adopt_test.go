@@ -0,0 +1,144 @@
+package session
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// startDummyServer starts a process standing in for a server a crashed
+// session left behind; it runs a child, as browser servers do
+func startDummyServer(t *testing.T) (cmd *exec.Cmd, childPID int) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the dummy server is a shell script")
+	}
+	childFile := filepath.Join(t.TempDir(), "child")
+	// $0 names the process like a Playwright server, for --scan to find
+	cmd = exec.Command("sh", "-c", `sleep 60 & echo $! > "$1"; wait`, "playwright-mcp-dummy", childFile)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_, _ = cmd.Process.Wait()
+	})
+
+	waitFor(t, func() bool {
+		data, err := os.ReadFile(childFile)
+		if err != nil {
+			return false
+		}
+		childPID, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		return err == nil
+	})
+	t.Cleanup(func() {
+		if process, err := os.FindProcess(childPID); err == nil {
+			_ = process.Kill()
+		}
+	})
+	return cmd, childPID
+}
+
+// processGone reports whether pid has exited; a zombie nobody reaped yet
+// counts as gone
+func processGone(pid int) bool {
+	if runtime.GOOS == "linux" {
+		stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+		if err != nil {
+			return true
+		}
+		state := stat[bytes.LastIndexByte(stat, ')')+2]
+		return state == 'Z' || state == 'X'
+	}
+	return !NewProcessManager().IsProcessAlive(pid)
+}
+
+func TestAdoptProcessReattachesHTTPServer(t *testing.T) {
+	server, _ := startDummyServer(t)
+	var healthy atomic.Bool
+	var created atomic.Int32
+	healthy.Store(true)
+	manager := newMonitoredManager(t, fakeFactory(&healthy, &created))
+
+	serverConfig := config.ServerConfig{Type: "http", URL: "http://127.0.0.1:9222/mcp"}
+	info, err := manager.AdoptProcess("browser", serverConfig, server.Process.Pid)
+	if err != nil {
+		t.Fatalf("AdoptProcess failed: %v", err)
+	}
+	if info.PID != server.Process.Pid || info.Status != Active || info.ConnectionInfo.Extra[AdoptedExtraKey] != true {
+		t.Errorf("adopted session = %+v", info)
+	}
+	if created.Load() != 1 {
+		t.Errorf("reattaching created %d clients, want 1", created.Load())
+	}
+
+	// The record lets later commands reattach too
+	manager.Flush()
+	saved, err := manager.GetFileStore().FindExistingSession("browser")
+	if err != nil {
+		t.Fatalf("the adopted session was not saved: %v", err)
+	}
+	if saved.PID != server.Process.Pid {
+		t.Errorf("saved PID = %d, want %d", saved.PID, server.Process.Pid)
+	}
+
+	if _, err := manager.AdoptProcess("browser", serverConfig, server.Process.Pid); err == nil {
+		t.Error("adopting for a server that has a session should fail")
+	}
+}
+
+func TestAdoptStdioOrphanFailsAndTerminateStopsTree(t *testing.T) {
+	server, childPID := startDummyServer(t)
+	manager := newMonitoredManager(t, failingFactory())
+	pid := server.Process.Pid
+
+	candidates, err := manager.OrphanCandidates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, candidate := range candidates {
+		if candidate.PID == pid {
+			found = strings.Contains(candidate.CmdLine, "playwright-mcp-dummy")
+		}
+	}
+	if !found {
+		t.Errorf("scan did not list the orphan %d with its command line: %+v", pid, candidates)
+	}
+
+	// Nothing can reach a stdio server whose pipes are gone
+	serverConfig := config.ServerConfig{Command: "npx", Args: []string{"@playwright/mcp"}}
+	if _, err := manager.AdoptProcess("browser", serverConfig, pid); err == nil || !strings.Contains(err.Error(), "cannot reattach") {
+		t.Fatalf("want adopting a stdio orphan to fail, got %v", err)
+	}
+	manager.Flush()
+	if records, _ := manager.GetFileStore().ListSessions(); len(records) != 0 {
+		t.Errorf("a failed adoption left records: %+v", records)
+	}
+	if _, err := manager.GetSessionByName("browser"); err == nil {
+		t.Error("a failed adoption left a session")
+	}
+
+	info, err := manager.TerminateOrphan(pid)
+	if err != nil {
+		t.Fatalf("TerminateOrphan failed: %v", err)
+	}
+	if info.PID != pid {
+		t.Errorf("terminated %+v", info)
+	}
+	_, _ = server.Process.Wait()
+	waitFor(t, func() bool { return processGone(childPID) })
+
+	if _, err := manager.TerminateOrphan(os.Getpid()); err == nil {
+		t.Error("terminating this process should be refused")
+	}
+}
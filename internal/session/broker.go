@@ -0,0 +1,26 @@
+package session
+
+import (
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// ProcessBroker hands ownership of a stdio server process to a long-lived
+// owner (the daemon) so later CLI invocations can reattach to it instead of
+// respawning the server.
+type ProcessBroker interface {
+	// Endpoint returns where brokered sessions are served from
+	Endpoint() string
+
+	// Acquire starts (or reuses) a brokered session and returns a client for it
+	Acquire(serverName string, serverConfig config.ServerConfig) (mcp.MCPClient, error)
+
+	// Attach returns a client for an already running brokered session
+	Attach(serverName string) (mcp.MCPClient, error)
+
+	// Release stops a brokered session and its process
+	Release(serverName string) error
+}
+
+// BrokeredConnectionType marks ConnectionInfo for sessions owned by a ProcessBroker
+const BrokeredConnectionType = "daemon"
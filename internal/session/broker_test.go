@@ -0,0 +1,79 @@
+package session
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// fakeBroker records brokered sessions without spawning anything
+type fakeBroker struct {
+	running map[string]bool
+}
+
+func (b *fakeBroker) Endpoint() string { return "127.0.0.1:8080" }
+
+func (b *fakeBroker) Acquire(serverName string, _ config.ServerConfig) (mcp.MCPClient, error) {
+	b.running[serverName] = true
+	return nil, nil
+}
+
+func (b *fakeBroker) Attach(serverName string) (mcp.MCPClient, error) {
+	if !b.running[serverName] {
+		return nil, errNotRunning
+	}
+	return nil, nil
+}
+
+func (b *fakeBroker) Release(serverName string) error {
+	delete(b.running, serverName)
+	return nil
+}
+
+var errNotRunning = errors.New("not running")
+
+func TestPersistentSessionReattachesThroughBroker(t *testing.T) {
+	dir := t.TempDir()
+	broker := &fakeBroker{running: map[string]bool{}}
+	browser := config.ServerConfig{Command: "npx", Args: []string{"-y", "chrome-devtools-mcp@latest"}}
+
+	first, err := NewManager(dir, failingFactory())
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	first.SetProcessBroker(broker)
+
+	sess, err := first.GetSession("chrome-devtools", browser)
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	if !sess.(*PersistentSession).IsBrokered() {
+		t.Fatal("expected stdio persistent session to be owned by the broker")
+	}
+	first.GetFileStore().Flush()
+
+	// A later CLI invocation sees the daemon-owned session and reattaches
+	second, err := NewManager(dir, failingFactory())
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	second.SetProcessBroker(broker)
+
+	infos, err := second.ListSessions()
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(infos) != 1 || infos[0].ConnectionInfo == nil || infos[0].ConnectionInfo.Type != BrokeredConnectionType {
+		t.Fatalf("expected one daemon-owned session, got %+v", infos)
+	}
+
+	if err := second.StopSession("chrome-devtools"); err != nil {
+		t.Fatalf("StopSession failed: %v", err)
+	}
+	second.GetFileStore().Flush()
+	if broker.running["chrome-devtools"] {
+		t.Error("expected stopping the session to release it from the broker")
+	}
+}
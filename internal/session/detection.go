@@ -2,6 +2,7 @@ package session
 
 import (
 	"strings"
+	"time"
 
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
 )
@@ -13,8 +14,19 @@ func DetectSessionType(serverConfig config.ServerConfig) SessionType {
 		return Stateless
 	}
 
+	// Remote servers default to hybrid unless a session type is configured
+	if serverConfig.IsSSH() {
+		if sessionType, ok := configuredSessionType(serverConfig); ok {
+			return sessionType
+		}
+		return Hybrid
+	}
+
 	// Check for known browser-based servers that require persistent sessions
 	command := strings.ToLower(serverConfig.Command)
+	if serverConfig.IsDocker() {
+		command = strings.ToLower(serverConfig.Image)
+	}
 	args := strings.Join(serverConfig.Args, " ")
 	args = strings.ToLower(args)
 
@@ -36,21 +48,27 @@ func DetectSessionType(serverConfig config.ServerConfig) SessionType {
 	}
 
 	// Check for explicit session configuration
-	if serverConfig.Session.Type != "" {
-		switch serverConfig.Session.Type {
-		case "persistent":
-			return Persistent
-		case "stateless":
-			return Stateless
-		case "hybrid":
-			return Hybrid
-		}
+	if sessionType, ok := configuredSessionType(serverConfig); ok {
+		return sessionType
 	}
 
 	// Default stdio servers to hybrid (try persistent, fallback to stateless)
 	return Hybrid
 }
 
+// configuredSessionType returns the session type set in session.type, if any
+func configuredSessionType(serverConfig config.ServerConfig) (SessionType, bool) {
+	switch serverConfig.Session.Type {
+	case "persistent":
+		return Persistent, true
+	case "stateless":
+		return Stateless, true
+	case "hybrid":
+		return Hybrid, true
+	}
+	return Hybrid, false
+}
+
 // RequiresPersistentSession checks if a server definitely requires a persistent session
 func RequiresPersistentSession(serverConfig config.ServerConfig) bool {
 	return DetectSessionType(serverConfig) == Persistent
@@ -62,6 +80,12 @@ func SupportsPersistentSession(serverConfig config.ServerConfig) bool {
 	return sessionType == Persistent || sessionType == Hybrid
 }
 
+// CanFallback reports whether a failed persistent start may degrade to a
+// stateless session. Only hybrid sessions fall back, and session.strict disables it.
+func CanFallback(serverConfig config.ServerConfig) bool {
+	return DetectSessionType(serverConfig) == Hybrid && !serverConfig.Session.Strict
+}
+
 // ShouldAutoStart determines if a session should be automatically started
 func ShouldAutoStart(serverConfig config.ServerConfig) bool {
 	// Check explicit configuration
@@ -89,3 +113,51 @@ func GetSessionTimeout(serverConfig config.ServerConfig) int {
 		return 60 // 1 minute for stateless servers
 	}
 }
+
+// GetSessionMaxIdle returns the maximum idle time in seconds before a session
+// is stopped. An explicit session.maxIdle wins; 0 or a negative value means the
+// session never expires.
+func GetSessionMaxIdle(serverConfig config.ServerConfig) int {
+	if serverConfig.Session.MaxIdle != nil {
+		if *serverConfig.Session.MaxIdle <= 0 {
+			return 0
+		}
+		return *serverConfig.Session.MaxIdle
+	}
+
+	// Default idle limits based on session type
+	switch DetectSessionType(serverConfig) {
+	case Persistent:
+		return 1800 // 30 minutes for browser servers
+	case Hybrid:
+		return 600 // 10 minutes for hybrid servers
+	default:
+		return 0 // Stateless sessions hold no resources
+	}
+}
+
+// GetHealthCheckInterval returns the seconds between background health checks
+func GetHealthCheckInterval(serverConfig config.ServerConfig) int {
+	if serverConfig.Session.HealthCheckInterval > 0 {
+		return serverConfig.Session.HealthCheckInterval
+	}
+	return 60
+}
+
+// GetSessionRetention returns how long an idle session record is kept on disk
+// before cleanup removes it, falling back to defaultRetention when unset
+func GetSessionRetention(serverConfig config.ServerConfig, defaultRetention time.Duration) time.Duration {
+	if retention, err := config.ParseRetention(serverConfig.Session.Retention); err == nil && retention > 0 {
+		return retention
+	}
+	return defaultRetention
+}
+
+// GetErrorRetention returns how long an errored session record is kept on disk,
+// falling back to defaultRetention when unset
+func GetErrorRetention(serverConfig config.ServerConfig, defaultRetention time.Duration) time.Duration {
+	if retention, err := config.ParseRetention(serverConfig.Session.ErrorRetention); err == nil && retention > 0 {
+		return retention
+	}
+	return defaultRetention
+}
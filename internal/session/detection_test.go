@@ -0,0 +1,80 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestGetSessionMaxIdle(t *testing.T) {
+	browser := config.ServerConfig{Command: "npx", Args: []string{"-y", "chrome-devtools-mcp@latest"}}
+	hybrid := config.ServerConfig{Command: "uvx", Args: []string{"mcp-server-time"}}
+	httpServer := config.ServerConfig{Type: "http", URL: "https://example.com/mcp"}
+
+	explicit := hybrid
+	explicit.Session.MaxIdle = intPtr(45)
+
+	never := browser
+	never.Session.MaxIdle = intPtr(0)
+
+	negative := browser
+	negative.Session.MaxIdle = intPtr(-1)
+
+	tests := []struct {
+		name   string
+		config config.ServerConfig
+		want   int
+	}{
+		{"persistent default", browser, 1800},
+		{"hybrid default", hybrid, 600},
+		{"stateless default", httpServer, 0},
+		{"explicit seconds", explicit, 45},
+		{"zero never expires", never, 0},
+		{"negative never expires", negative, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetSessionMaxIdle(tt.config); got != tt.want {
+				t.Errorf("GetSessionMaxIdle() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsExpiredUsesSeconds(t *testing.T) {
+	sess := &PersistentSession{
+		status:       Active,
+		lastActivity: time.Now().Add(-90 * time.Second),
+	}
+
+	if !sess.IsExpired(60) {
+		t.Error("expected session idle for 90s to expire with maxIdle=60")
+	}
+	if sess.IsExpired(120) {
+		t.Error("expected session idle for 90s not to expire with maxIdle=120")
+	}
+	if sess.IsExpired(0) {
+		t.Error("expected maxIdle=0 to never expire")
+	}
+	if sess.IsExpired(-1) {
+		t.Error("expected maxIdle=-1 to never expire")
+	}
+}
+
+func TestDetectSessionTypeSSH(t *testing.T) {
+	remote := config.ServerConfig{Type: config.ServerTypeSSH, Host: "gpu-box", RemoteCommand: "npx", Args: []string{"chrome-devtools-mcp@latest"}}
+	if got := DetectSessionType(remote); got != Hybrid {
+		t.Errorf("expected ssh servers to default to hybrid, got %s", got)
+	}
+
+	remote.Session.Type = "persistent"
+	if got := DetectSessionType(remote); got != Persistent {
+		t.Errorf("expected session.type to win for ssh servers, got %s", got)
+	}
+}
@@ -8,13 +8,22 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
 )
 
 // FileStore handles file-based session persistence
 type FileStore struct {
 	sessionsDir    string
 	processManager *ProcessManager
+	pending        sync.WaitGroup
+	queueMutex     sync.Mutex
+	queued         map[string]*SessionInfo // Latest unsaved snapshot per session ID
+	writeMutex     sync.Mutex
+	migrateOnce    sync.Once
 }
 
 // NewFileStore creates a new file store
@@ -22,30 +31,151 @@ func NewFileStore(sessionsDir string) *FileStore {
 	return &FileStore{
 		sessionsDir:    sessionsDir,
 		processManager: NewProcessManager(),
+		queued:         make(map[string]*SessionInfo),
 	}
 }
 
-// SaveSession saves session metadata to disk
+// SaveSessionAsync saves session metadata in the background; use Flush to wait for it.
+// Snapshots queued for the same session are coalesced so the newest one always wins.
+func (fs *FileStore) SaveSessionAsync(sessionInfo *SessionInfo) {
+	fs.queueMutex.Lock()
+	fs.queued[sessionInfo.SessionID] = sessionInfo
+	fs.queueMutex.Unlock()
+
+	fs.pending.Add(1)
+	go func() {
+		defer fs.pending.Done()
+
+		fs.writeMutex.Lock()
+		defer fs.writeMutex.Unlock()
+
+		fs.queueMutex.Lock()
+		latest := fs.queued[sessionInfo.SessionID]
+		delete(fs.queued, sessionInfo.SessionID)
+		fs.queueMutex.Unlock()
+
+		if latest == nil {
+			return // An earlier save already wrote the newest snapshot
+		}
+
+		if err := fs.SaveSession(latest); err != nil {
+			logging.Debug("failed to save session metadata", "session", latest.Name, "error", err)
+		}
+	}()
+}
+
+// Flush waits for pending background saves to finish
+func (fs *FileStore) Flush() {
+	fs.pending.Wait()
+}
+
+// SaveSession saves session metadata to disk. The server config is written
+// unresolved and its secrets scrubbed from everything else, so secrets pulled
+// from the environment never reach the file.
 func (fs *FileStore) SaveSession(sessionInfo *SessionInfo) error {
-	if err := os.MkdirAll(fs.sessionsDir, 0755); err != nil {
+	if err := os.MkdirAll(fs.sessionsDir, 0700); err != nil {
 		return fmt.Errorf("failed to create sessions directory: %w", err)
 	}
 
-	filename := fs.sessionFilename(sessionInfo.Name)
-	data, err := json.MarshalIndent(sessionInfo, "", "  ")
+	if sessionInfo.SessionID == "" {
+		return fmt.Errorf("session ID is required")
+	}
+
+	stored := *sessionInfo
+	stored.Config = sessionInfo.Config.Unresolved()
+	stored.ConfigRedacted = true
+
+	// Resolved secrets may also show up in the process's arguments and errors
+	redactor := sessionInfo.Config.Redactor()
+	stored.Error = redactor.String(sessionInfo.Error)
+	stored.LastError = redactor.String(sessionInfo.LastError)
+	stored.FallbackReason = redactor.String(sessionInfo.FallbackReason)
+	if len(sessionInfo.ProcessArgs) > 0 {
+		stored.ProcessArgs = make([]string, len(sessionInfo.ProcessArgs))
+		for i, arg := range sessionInfo.ProcessArgs {
+			stored.ProcessArgs[i] = redactor.String(arg)
+		}
+	}
+
+	filename := fs.sessionFilename(sessionInfo.SessionID)
+	data, err := json.MarshalIndent(&stored, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal session info: %w", err)
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := os.WriteFile(filename, data, 0600); err != nil {
 		return fmt.Errorf("failed to write session file: %w", err)
 	}
+	// WriteFile keeps the mode of existing files
+	if err := os.Chmod(filename, 0600); err != nil {
+		return fmt.Errorf("failed to restrict session file permissions: %w", err)
+	}
 
 	return nil
 }
 
+// decodeSessionInfo parses a session file. Its config holds templates, so they
+// are kept as-is if the session is saved again.
+func decodeSessionInfo(data []byte) (*SessionInfo, error) {
+	var sessionInfo SessionInfo
+	if err := json.Unmarshal(data, &sessionInfo); err != nil {
+		return nil, err
+	}
+
+	sessionInfo.Config.MarkTemplates()
+	return &sessionInfo, nil
+}
+
+// migrateSessionFiles rewrites session files saved by older versions, which
+// stored resolved secrets in world-readable files
+func (fs *FileStore) migrateSessionFiles() {
+	files, err := os.ReadDir(fs.sessionsDir)
+	if err != nil {
+		return // Nothing to migrate yet
+	}
+
+	fs.writeMutex.Lock()
+	defer fs.writeMutex.Unlock()
+
+	if err := os.Chmod(fs.sessionsDir, 0700); err != nil {
+		logging.Debug("failed to restrict sessions directory permissions", "error", err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(fs.sessionsDir, file.Name()))
+		if err != nil {
+			continue
+		}
+
+		var sessionInfo SessionInfo
+		if err := json.Unmarshal(data, &sessionInfo); err != nil || sessionInfo.ConfigRedacted || sessionInfo.SessionID == "" {
+			continue
+		}
+
+		// The stored values were already resolved, so none of them are
+		// templates; the copy of the args reattachment keeps is redacted too
+		if info := sessionInfo.ConnectionInfo; info != nil && info.Extra["args"] != nil {
+			redacted := *info
+			redacted.Extra = make(map[string]interface{}, len(info.Extra))
+			for key, value := range info.Extra {
+				redacted.Extra[key] = value
+			}
+			redacted.Extra["args"] = sessionInfo.Config.Unresolved().Args
+			sessionInfo.ConnectionInfo = &redacted
+		}
+		if err := fs.SaveSession(&sessionInfo); err != nil {
+			logging.Debug("failed to migrate session", "session", sessionInfo.SessionID, "error", err)
+		}
+	}
+}
+
 // LoadSession loads session metadata from disk
 func (fs *FileStore) LoadSession(sessionID string) (*SessionInfo, error) {
+	fs.migrateOnce.Do(fs.migrateSessionFiles)
 	filename := fs.sessionFilename(sessionID)
 
 	data, err := os.ReadFile(filename)
@@ -56,39 +186,42 @@ func (fs *FileStore) LoadSession(sessionID string) (*SessionInfo, error) {
 		return nil, fmt.Errorf("failed to read session file: %w", err)
 	}
 
-	var sessionInfo SessionInfo
-	if err := json.Unmarshal(data, &sessionInfo); err != nil {
+	sessionInfo, err := decodeSessionInfo(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal session info: %w", err)
 	}
 
-	return &sessionInfo, nil
+	return sessionInfo, nil
 }
 
-// LoadSessionByName loads a session by server name
+// LoadSessionByName loads the most recently active session for a server name.
+// Session files are keyed by session ID, so this scans the sessions directory.
 func (fs *FileStore) LoadSessionByName(serverName string) (*SessionInfo, error) {
-	// Try exact name match first
-	sessionInfo, err := fs.LoadSession(serverName)
-	if err == nil {
-		return sessionInfo, nil
-	}
-
-	// If not found, try to find by scanning session files
 	sessions, err := fs.ListSessions()
 	if err != nil {
 		return nil, err
 	}
 
+	var latest *SessionInfo
 	for _, session := range sessions {
-		if session.Name == serverName {
-			return session, nil
+		if session.Name != serverName {
+			continue
+		}
+		if latest == nil || session.LastActivity.After(latest.LastActivity) {
+			latest = session
 		}
 	}
 
-	return nil, fmt.Errorf("session not found: %s", serverName)
+	if latest == nil {
+		return nil, mcp.Mark(fmt.Errorf("session not found: %s", serverName), mcp.ErrSessionNotActive)
+	}
+
+	return latest, nil
 }
 
 // ListSessions returns all sessions stored on disk
 func (fs *FileStore) ListSessions() ([]*SessionInfo, error) {
+	fs.migrateOnce.Do(fs.migrateSessionFiles)
 	if _, err := os.Stat(fs.sessionsDir); os.IsNotExist(err) {
 		return []*SessionInfo{}, nil
 	}
@@ -111,12 +244,12 @@ func (fs *FileStore) ListSessions() ([]*SessionInfo, error) {
 			continue // Skip unreadable files
 		}
 
-		var sessionInfo SessionInfo
-		if err := json.Unmarshal(data, &sessionInfo); err != nil {
+		sessionInfo, err := decodeSessionInfo(data)
+		if err != nil {
 			continue // Skip invalid files
 		}
 
-		sessions = append(sessions, &sessionInfo)
+		sessions = append(sessions, sessionInfo)
 	}
 
 	return sessions, nil
@@ -136,60 +269,117 @@ func (fs *FileStore) DeleteSession(sessionID string) error {
 	return nil
 }
 
-// CleanupStaleSessions removes sessions that are no longer valid
+// DeleteSessionsByName deletes every session file recorded for a server name
+func (fs *FileStore) DeleteSessionsByName(serverName string) error {
+	sessions, err := fs.ListSessions()
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.Name != serverName {
+			continue
+		}
+		if err := fs.DeleteSession(session.SessionID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Default cleanup retention for servers without session.retention or session.errorRetention
+const (
+	DefaultSessionRetention = 24 * time.Hour
+	DefaultErrorRetention   = 1 * time.Hour
+)
+
+// CleanupPolicy controls which session records cleanup removes
+type CleanupPolicy struct {
+	Retention      time.Duration // Used for servers without session.retention
+	ErrorRetention time.Duration // Used for servers without session.errorRetention
+	OlderThan      time.Duration // When set, overrides every per-server retention
+}
+
+// CleanupResult describes a session record removed by cleanup
+type CleanupResult struct {
+	SessionID string
+	Name      string
+	Reason    string
+}
+
+// CleanupStaleSessions removes sessions that are no longer valid, using
+// olderThan for servers that configure no retention of their own
 func (fs *FileStore) CleanupStaleSessions(olderThan time.Duration) error {
+	_, err := fs.CleanupWithPolicy(CleanupPolicy{
+		Retention:      olderThan,
+		ErrorRetention: DefaultErrorRetention,
+	})
+	return err
+}
+
+// CleanupWithPolicy removes dead and expired sessions, honoring each server's
+// retention settings, and reports what was removed and why
+func (fs *FileStore) CleanupWithPolicy(policy CleanupPolicy) ([]CleanupResult, error) {
 	sessions, err := fs.ListSessions()
 	if err != nil {
-		return fmt.Errorf("failed to list sessions: %w", err)
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
 	}
 
-	var toDelete []string
+	var removed []CleanupResult
 
 	for _, session := range sessions {
-		shouldDelete := false
-
-		// Check if session is too old
-		if !session.LastActivity.IsZero() {
-			if time.Since(session.LastActivity) > olderThan {
-				shouldDelete = true
-			}
-		} else if !session.StartTime.IsZero() {
-			if time.Since(session.StartTime) > olderThan {
-				shouldDelete = true
-			}
+		reason := fs.staleReason(session, policy)
+		if reason == "" {
+			continue
 		}
 
-		// Check if process is still alive for active sessions
-		if session.Status == Active && session.PID > 0 {
-			if !fs.processManager.IsProcessAlive(session.PID) {
-				shouldDelete = true
-			}
+		if err := fs.DeleteSession(session.SessionID); err != nil {
+			logging.Warn("failed to delete stale session", "session", session.SessionID, "error", err)
+			continue
 		}
+		removed = append(removed, CleanupResult{
+			SessionID: session.SessionID,
+			Name:      session.Name,
+			Reason:    reason,
+		})
+	}
 
-		// Check for error sessions
-		if session.Status == Error {
-			// Keep error sessions for a shorter time
-			errorExpiry := 1 * time.Hour
-			if !session.LastActivity.IsZero() {
-				if time.Since(session.LastActivity) > errorExpiry {
-					shouldDelete = true
-				}
-			}
-		}
+	return removed, nil
+}
 
-		if shouldDelete {
-			toDelete = append(toDelete, session.SessionID)
-		}
+// staleReason explains why a session should be removed, or returns "" to keep it
+func (fs *FileStore) staleReason(session *SessionInfo, policy CleanupPolicy) string {
+	// Check if process is still alive for active sessions
+	if session.Status == Active && session.PID > 0 && !fs.processManager.IsProcessAlive(session.PID) {
+		return fmt.Sprintf("process %d is no longer running", session.PID)
 	}
 
-	// Delete stale sessions
-	for _, sessionID := range toDelete {
-		if err := fs.DeleteSession(sessionID); err != nil {
-			fmt.Printf("Warning: failed to delete stale session %s: %v\n", sessionID, err)
-		}
+	lastSeen := session.LastActivity
+	if lastSeen.IsZero() {
+		lastSeen = session.StartTime
+	}
+	if lastSeen.IsZero() {
+		return ""
+	}
+	age := time.Since(lastSeen)
+
+	// Error sessions usually have a shorter retention
+	label := "retention"
+	retention := GetSessionRetention(session.Config, policy.Retention)
+	if session.Status == Error {
+		label = "error retention"
+		retention = GetErrorRetention(session.Config, policy.ErrorRetention)
+	}
+	if policy.OlderThan > 0 {
+		label = "--older-than"
+		retention = policy.OlderThan
 	}
 
-	return nil
+	if retention > 0 && age > retention {
+		return fmt.Sprintf("inactive for %s (%s %s)", age.Round(time.Minute), label, retention)
+	}
+	return ""
 }
 
 // ValidateSession checks if a session on disk is still valid
@@ -307,7 +497,7 @@ func (fs *FileStore) GenerateSessionID(serverName string) string {
 	return fmt.Sprintf("%s-%s-%s", serverName, timestamp, randomString(6))
 }
 
-// sessionFilename returns the filename for a session
+// sessionFilename returns the filename for a session, keyed by session ID
 func (fs *FileStore) sessionFilename(sessionID string) string {
 	return filepath.Join(fs.sessionsDir, sessionID+".json")
 }
@@ -0,0 +1,354 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestFileStoreSessionIDIsCanonicalKey(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+
+	sessionID := store.GenerateSessionID("chrome-devtools")
+	past := time.Now().Add(-time.Hour)
+	info := &SessionInfo{
+		SessionID:    sessionID,
+		Name:         "chrome-devtools",
+		Type:         Persistent,
+		Status:       Stopped,
+		StartTime:    past,
+		LastActivity: past,
+	}
+
+	if err := store.SaveSession(info); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	sessionFile := filepath.Join(store.sessionsDir, sessionID+".json")
+	if _, err := os.Stat(sessionFile); err != nil {
+		t.Fatalf("expected session file %s: %v", sessionFile, err)
+	}
+
+	if err := store.UpdateSessionActivity(sessionID); err != nil {
+		t.Fatalf("UpdateSessionActivity failed: %v", err)
+	}
+
+	loaded, err := store.LoadSession(sessionID)
+	if err != nil {
+		t.Fatalf("LoadSession failed: %v", err)
+	}
+	if !loaded.LastActivity.After(past) {
+		t.Errorf("expected LastActivity to be updated, got %v", loaded.LastActivity)
+	}
+
+	byName, err := store.LoadSessionByName("chrome-devtools")
+	if err != nil {
+		t.Fatalf("LoadSessionByName failed: %v", err)
+	}
+	if byName.SessionID != sessionID {
+		t.Errorf("expected session ID %s, got %s", sessionID, byName.SessionID)
+	}
+
+	files, err := os.ReadDir(store.sessionsDir)
+	if err != nil {
+		t.Fatalf("failed to read sessions dir: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected exactly one session file, got %d", len(files))
+	}
+}
+
+func TestFileStoreCleanupStaleSessionsRemovesFile(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+
+	sessionID := store.GenerateSessionID("playwright")
+	stale := time.Now().Add(-48 * time.Hour)
+	info := &SessionInfo{
+		SessionID:    sessionID,
+		Name:         "playwright",
+		Type:         Persistent,
+		Status:       Stopped,
+		StartTime:    stale,
+		LastActivity: stale,
+	}
+
+	if err := store.SaveSession(info); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	if err := store.CleanupStaleSessions(24 * time.Hour); err != nil {
+		t.Fatalf("CleanupStaleSessions failed: %v", err)
+	}
+
+	sessionFile := filepath.Join(store.sessionsDir, sessionID+".json")
+	if _, err := os.Stat(sessionFile); !os.IsNotExist(err) {
+		t.Errorf("expected stale session file to be removed, stat err: %v", err)
+	}
+}
+
+func TestFileStoreCleanupHonorsPerServerRetention(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+
+	save := func(name string, status SessionStatus, age time.Duration, session config.SessionConfig) string {
+		t.Helper()
+		seen := time.Now().Add(-age)
+		info := &SessionInfo{
+			SessionID:    store.GenerateSessionID(name),
+			Name:         name,
+			Type:         Persistent,
+			Status:       status,
+			StartTime:    seen,
+			LastActivity: seen,
+			Config:       config.ServerConfig{Command: name, Session: session},
+		}
+		if err := store.SaveSession(info); err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+		return info.SessionID
+	}
+
+	browser := save("chrome-devtools", Stopped, 72*time.Hour, config.SessionConfig{Retention: "7d"})
+	scratch := save("scratch", Stopped, 2*time.Hour, config.SessionConfig{Retention: "30m"})
+	defaulted := save("time", Stopped, 30*time.Hour, config.SessionConfig{})
+	failedKept := save("playwright", Error, 3*time.Hour, config.SessionConfig{ErrorRetention: "12h"})
+	failed := save("fetch", Error, 3*time.Hour, config.SessionConfig{})
+
+	removed, err := store.CleanupWithPolicy(CleanupPolicy{
+		Retention:      DefaultSessionRetention,
+		ErrorRetention: DefaultErrorRetention,
+	})
+	if err != nil {
+		t.Fatalf("CleanupWithPolicy failed: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, result := range removed {
+		got[result.SessionID] = result.Reason
+	}
+	for _, id := range []string{scratch, defaulted, failed} {
+		if got[id] == "" {
+			t.Errorf("expected %s to be removed, got %+v", id, removed)
+		}
+	}
+	for _, id := range []string{browser, failedKept} {
+		if _, ok := got[id]; ok {
+			t.Errorf("expected %s to be kept by its retention", id)
+		}
+	}
+	if !strings.Contains(got[failed], "error retention 1h0m0s") {
+		t.Errorf("expected error retention reason, got %q", got[failed])
+	}
+
+	// --older-than overrides the per-server retention
+	removed, err = store.CleanupWithPolicy(CleanupPolicy{OlderThan: 48 * time.Hour})
+	if err != nil {
+		t.Fatalf("CleanupWithPolicy failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0].SessionID != browser {
+		t.Fatalf("expected only the browser session to be removed, got %+v", removed)
+	}
+}
+
+func TestSavedSessionsDoNotContainSecrets(t *testing.T) {
+	const secret = "ctx7sk-0123456789abcdef"
+	t.Setenv("CONTEXT7_API_KEY", secret)
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mcp_servers.json")
+	configJSON := `{"mcpServers": {"context7": {
+		"command": "npx",
+		"args": ["-y", "@upstash/context7-mcp", "--api-key", "${CONTEXT7_API_KEY}"],
+		"env": {"CONTEXT7_API_KEY": "${CONTEXT7_API_KEY}"},
+		"session": {"type": "persistent"}
+	}}}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	serverConfig := cfg.MCPServers["context7"]
+
+	var healthy atomic.Bool
+	var created atomic.Int32
+	healthy.Store(true)
+	manager, err := NewManager(dir, fakeFactory(&healthy, &created))
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	sess, err := manager.GetSession("context7", serverConfig)
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	sess.RecordToolCall("resolve-library-id", time.Millisecond, nil)
+	_ = manager.Close() // Waits for background saves
+
+	files, err := filepath.Glob(filepath.Join(dir, "sessions", "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one session file, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Fatalf("session file contains the resolved secret:\n%s", data)
+	}
+	if !strings.Contains(string(data), "${CONTEXT7_API_KEY}") {
+		t.Errorf("expected the unresolved template in the session file:\n%s", data)
+	}
+	if runtime.GOOS != "windows" {
+		if stat, err := os.Stat(files[0]); err != nil || stat.Mode().Perm() != 0600 {
+			t.Errorf("expected 0600 session file, got %v (%v)", stat.Mode().Perm(), err)
+		}
+	}
+
+	// Reattachment still recognizes the stored templates
+	stored, err := manager.GetFileStore().LoadSessionByName("context7")
+	if err != nil {
+		t.Fatalf("LoadSessionByName failed: %v", err)
+	}
+	if !manager.configMatches(stored.Config, serverConfig) {
+		t.Error("expected stored templates to match the resolved config")
+	}
+}
+
+func TestSavedSessionErrorsDoNotContainSecrets(t *testing.T) {
+	const secret = "ctx7sk-0123456789abcdef"
+	store := NewFileStore(t.TempDir())
+	info := &SessionInfo{
+		SessionID:   store.GenerateSessionID("context7"),
+		Name:        "context7",
+		Type:        Persistent,
+		Status:      Error,
+		ProcessArgs: []string{"--api-key=" + secret},
+		Error:       "server exited: invalid key " + secret,
+		Config:      config.ServerConfig{Command: "npx", Env: map[string]string{"CONTEXT7_API_KEY": secret}},
+	}
+	if err := store.SaveSession(info); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	data, err := os.ReadFile(store.sessionFilename(info.SessionID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Fatalf("session file contains the secret:\n%s", data)
+	}
+	if info.Error != "server exited: invalid key "+secret {
+		t.Error("SaveSession should not change the session it saves")
+	}
+}
+
+func TestSavedResolvedInitializeOptionsDoNotContainSecrets(t *testing.T) {
+	const secret = "lsp-s3cret-0123456789"
+	store := NewFileStore(t.TempDir())
+	// The daemon receives configs resolved, with no templates known
+	info := &SessionInfo{
+		SessionID: store.GenerateSessionID("lsp"),
+		Name:      "lsp",
+		Type:      Persistent,
+		Status:    Error,
+		Error:     "initialize failed for token " + secret,
+		Config: config.ServerConfig{Command: "lsp-bridge", InitializeOptions: map[string]interface{}{
+			"auth": map[string]interface{}{"token": secret},
+		}},
+	}
+	if err := store.SaveSession(info); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	data, err := os.ReadFile(store.sessionFilename(info.SessionID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Fatalf("session file contains the secret:\n%s", data)
+	}
+}
+
+func TestFileStoreMigratesLegacySessionFiles(t *testing.T) {
+	const secret = "Bearer ctx7sk-0123456789abcdef"
+	dir := t.TempDir()
+
+	legacy := `{"sessionId": "context7-1-abc", "name": "context7", "type": "persistent", "status": "stopped",
+		"startTime": "2025-01-02T03:04:05Z", "lastActivity": "2025-01-02T03:04:05Z",
+		"config": {"url": "https://mcp.context7.com/mcp", "headers": {"Authorization": "` + secret + `"}}}`
+	legacyFile := filepath.Join(dir, "context7-1-abc.json")
+	if err := os.WriteFile(legacyFile, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewFileStore(dir)
+	sessions, err := store.ListSessions()
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("expected one migrated session, got %v (%v)", sessions, err)
+	}
+	if got := sessions[0].Config.Headers["Authorization"]; got != config.RedactedValue {
+		t.Errorf("expected redacted header, got %q", got)
+	}
+
+	data, err := os.ReadFile(legacyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Fatalf("migrated session file still contains the secret:\n%s", data)
+	}
+	if runtime.GOOS != "windows" {
+		if stat, err := os.Stat(legacyFile); err != nil || stat.Mode().Perm() != 0600 {
+			t.Errorf("expected 0600 session file, got %v (%v)", stat.Mode().Perm(), err)
+		}
+	}
+}
+
+func TestFileStoreMigrationRedactsArgs(t *testing.T) {
+	const secret = "ctx7sk-0123456789abcdef"
+	const flagSecret = "sk-live-abcdef0123456789"
+	dir := t.TempDir()
+
+	args := `["-y", "@upstash/context7-mcp", "--key=` + secret + `", "--api-key", "` + flagSecret + `"]`
+	legacy := `{"sessionId": "context7-1-abc", "name": "context7", "type": "persistent", "status": "stopped",
+		"startTime": "2025-01-02T03:04:05Z", "lastActivity": "2025-01-02T03:04:05Z",
+		"config": {"command": "npx", "args": ` + args + `, "env": {"CONTEXT7_API_KEY": "` + secret + `"}},
+		"connectionInfo": {"type": "stdio", "extra": {"command": "npx", "args": ` + args + `}}}`
+	legacyFile := filepath.Join(dir, "context7-1-abc.json")
+	if err := os.WriteFile(legacyFile, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewFileStore(dir)
+	if _, err := store.ListSessions(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(legacyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{secret, flagSecret} {
+		if strings.Contains(string(data), leaked) {
+			t.Fatalf("migrated session file still contains %s:\n%s", leaked, data)
+		}
+	}
+	if !strings.Contains(string(data), "@upstash/context7-mcp") {
+		t.Errorf("arguments that aren't secret should be kept:\n%s", data)
+	}
+}
+
+func TestDefaultSessionsDirUsesConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, dir)
+
+	if got, want := DefaultSessionsDir(), filepath.Join(dir, "sessions"); got != want {
+		t.Errorf("expected sessions in %s, got %s", want, got)
+	}
+}
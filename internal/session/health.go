@@ -0,0 +1,85 @@
+package session
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+)
+
+// healthCheckFailureThreshold is how many consecutive failed checks mark a session as errored
+const healthCheckFailureThreshold = 3
+
+// startHealthMonitor runs background health checks for sessions that enable them (must be called with lock held)
+func (m *Manager) startHealthMonitor(serverName string, session Session) {
+	persistentSession, ok := session.(*PersistentSession)
+	if !ok || !persistentSession.Config().Session.HealthCheck || m.closed {
+		return
+	}
+
+	if _, running := m.monitors[serverName]; running {
+		return
+	}
+
+	interval := m.healthCheckInterval
+	if interval <= 0 {
+		interval = time.Duration(GetHealthCheckInterval(persistentSession.Config())) * time.Second
+	}
+
+	stop := make(chan struct{})
+	m.monitors[serverName] = stop
+	m.monitorWG.Add(1)
+	go m.monitorHealth(persistentSession, interval, stop)
+}
+
+// stopHealthMonitor stops the background health checks for a session (must be called with lock held)
+func (m *Manager) stopHealthMonitor(serverName string) {
+	if stop, running := m.monitors[serverName]; running {
+		close(stop)
+		delete(m.monitors, serverName)
+	}
+}
+
+// monitorHealth checks the session on every tick until stopped, marking it as
+// errored after repeated failures and restarting it if configured to
+func (m *Manager) monitorHealth(session *PersistentSession, interval time.Duration, stop <-chan struct{}) {
+	defer m.monitorWG.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		// Only running sessions are checked
+		if session.Status() != Active {
+			failures = 0
+			continue
+		}
+
+		err := session.HealthCheck()
+		if err == nil {
+			failures = 0
+			continue
+		}
+
+		failures++
+		if failures < healthCheckFailureThreshold {
+			continue
+		}
+		failures = 0
+
+		session.markError(fmt.Sprintf("health check failed %d times: %v", healthCheckFailureThreshold, err))
+
+		if session.Config().Session.RestartOnFailure {
+			if restartErr := session.Restart(); restartErr != nil {
+				logging.Debug("failed to restart unhealthy session", "session", session.Name(), "error", restartErr)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// fakeClient is an MCP client whose health is controlled by the test
+type fakeClient struct {
+	healthy *atomic.Bool
+}
+
+func (c *fakeClient) Initialize(context.Context, *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	if !c.healthy.Load() {
+		return nil, errors.New("server not responding")
+	}
+	return &mcp.InitializeResult{}, nil
+}
+
+func (c *fakeClient) Close() error { return nil }
+
+func (c *fakeClient) ListTools(context.Context) ([]mcp.Tool, error) {
+	if !c.healthy.Load() {
+		return nil, errors.New("server not responding")
+	}
+	return []mcp.Tool{}, nil
+}
+
+func (c *fakeClient) CallTool(context.Context, string, map[string]interface{}) (*mcp.ToolResult, error) {
+	return &mcp.ToolResult{}, nil
+}
+
+func (c *fakeClient) ListResources(context.Context) ([]mcp.Resource, error) { return nil, nil }
+
+func (c *fakeClient) CreateMessage(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) RequestInput(context.Context, *mcp.RequestInputParams) (*mcp.RequestInputResult, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) ListRoots(context.Context) ([]mcp.Root, error) { return nil, nil }
+
+func (c *fakeClient) NotifyRootsListChanged([]mcp.Root) error { return nil }
+
+// fakeFactory returns a factory that counts created clients sharing one health flag
+func fakeFactory(healthy *atomic.Bool, created *atomic.Int32) ClientFactory {
+	return func(config.ServerConfig) (mcp.MCPClient, error) {
+		created.Add(1)
+		return &fakeClient{healthy: healthy}, nil
+	}
+}
+
+func monitoredConfig(restart bool) config.ServerConfig {
+	return config.ServerConfig{
+		Command: "npx",
+		Args:    []string{"-y", "chrome-devtools-mcp@latest"},
+		Session: config.SessionConfig{HealthCheck: true, RestartOnFailure: restart},
+	}
+}
+
+// waitFor polls cond until it holds or the deadline passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newMonitoredManager(t *testing.T, factory ClientFactory) *Manager {
+	t.Helper()
+	manager, err := NewManager(t.TempDir(), factory)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	manager.healthCheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() { _ = manager.Close() })
+	return manager
+}
+
+func TestHealthMonitorMarksErrorAfterRepeatedFailures(t *testing.T) {
+	var healthy atomic.Bool
+	var created atomic.Int32
+	healthy.Store(true)
+	manager := newMonitoredManager(t, fakeFactory(&healthy, &created))
+
+	sess, err := manager.GetSession("chrome-devtools", monitoredConfig(false))
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+
+	healthy.Store(false)
+	waitFor(t, func() bool { return sess.Status() == Error })
+
+	if created.Load() != 1 {
+		t.Errorf("expected no restart without restartOnFailure, got %d clients", created.Load())
+	}
+}
+
+func TestHealthMonitorRestartsOnFailure(t *testing.T) {
+	var healthy atomic.Bool
+	var created atomic.Int32
+	healthy.Store(true)
+	manager := newMonitoredManager(t, fakeFactory(&healthy, &created))
+
+	sess, err := manager.GetSession("chrome-devtools", monitoredConfig(true))
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+
+	healthy.Store(false)
+	waitFor(t, func() bool { return created.Load() >= 2 })
+	healthy.Store(true)
+	waitFor(t, func() bool { return sess.Status() == Active })
+
+	// The restart and the failure that caused it are on record
+	metrics := sess.(*PersistentSession).GetInfo().SessionMetrics
+	if metrics.RestartCount < 1 || !strings.Contains(metrics.LastError, "health check failed") || metrics.LastErrorTime == nil {
+		t.Errorf("metrics after an automatic restart: %+v", metrics)
+	}
+
+	// Close terminates the monitor, so no further restarts happen
+	if err := manager.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	healthy.Store(false)
+	restarts := created.Load()
+	time.Sleep(100 * time.Millisecond)
+	if created.Load() != restarts {
+		t.Error("expected no health checks after Close")
+	}
+}
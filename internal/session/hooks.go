@@ -0,0 +1,121 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// Hook events passed to lifecycle hooks in MCP_EVENT
+const (
+	HookEventStart   = "start"
+	HookEventStop    = "stop"
+	HookEventError   = "error"
+	HookEventRestart = "restart"
+)
+
+// defaultHookTimeout bounds how long a hook may run
+const defaultHookTimeout = 30 * time.Second
+
+// hooksLaunching tracks hooks that have been triggered but not yet spawned
+var hooksLaunching sync.WaitGroup
+
+// WaitForHooks waits until triggered hooks have been spawned, so they aren't
+// lost when the CLI exits. It does not wait for them to finish.
+func WaitForHooks() {
+	hooksLaunching.Wait()
+}
+
+// RunHook runs the server's hook for an event in the background. Hooks are
+// bounded by a timeout and their failures are only logged.
+func RunHook(serverName string, serverConfig config.ServerConfig, event, sessionID string, eventErr error) {
+	command := hookCommand(serverConfig.Hooks, event)
+	if command == "" {
+		return
+	}
+
+	timeout := defaultHookTimeout
+	if serverConfig.Hooks.Timeout > 0 {
+		timeout = time.Duration(serverConfig.Hooks.Timeout) * time.Second
+	}
+
+	env := []string{
+		"MCP_SERVER=" + serverName,
+		"MCP_SESSION_ID=" + sessionID,
+		"MCP_EVENT=" + event,
+	}
+	if eventErr != nil {
+		env = append(env, "MCP_ERROR="+eventErr.Error())
+	}
+
+	hooksLaunching.Add(1)
+	go func() {
+		if err := runHookCommand(command, env, timeout, hooksLaunching.Done); err != nil {
+			log.Printf("Hook %s for %s failed: %v", event, serverName, err)
+		}
+	}()
+}
+
+// hookCommand returns the configured command for an event
+func hookCommand(hooks *config.HooksConfig, event string) string {
+	if hooks == nil {
+		return ""
+	}
+
+	switch event {
+	case HookEventStart:
+		return hooks.OnStart
+	case HookEventStop:
+		return hooks.OnStop
+	case HookEventError:
+		return hooks.OnError
+	case HookEventRestart:
+		return hooks.OnRestart
+	default:
+		return ""
+	}
+}
+
+// runHookCommand runs a hook through the platform shell, calling launched once
+// the process has been spawned (or failed to spawn)
+func runHookCommand(command string, env []string, timeout time.Duration, launched func()) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), env...)
+	cmd.WaitDelay = time.Second // Don't wait on children that keep the output open
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Start()
+	launched()
+	if err != nil {
+		return fmt.Errorf("failed to start hook: %w", err)
+	}
+
+	err = cmd.Wait()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, output.String())
+	}
+
+	return nil
+}
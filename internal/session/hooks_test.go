@@ -0,0 +1,90 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+func TestLifecycleHooksRunWithEventEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook script uses sh")
+	}
+
+	dir := t.TempDir()
+	hook := func(event string) string {
+		return `echo "$MCP_EVENT $MCP_SERVER $MCP_SESSION_ID" >> ` + filepath.Join(dir, event+".marker")
+	}
+
+	var healthy atomic.Bool
+	var created atomic.Int32
+	healthy.Store(true)
+
+	serverConfig := config.ServerConfig{
+		Command: "npx",
+		Args:    []string{"-y", "chrome-devtools-mcp@latest"},
+		Hooks: &config.HooksConfig{
+			OnStart:   hook("start"),
+			OnStop:    hook("stop"),
+			OnRestart: hook("restart"),
+		},
+	}
+
+	sess, err := NewPersistentSessionWithFileStore("chrome-devtools", serverConfig, fakeFactory(&healthy, &created), NewFileStore(filepath.Join(dir, "sessions")))
+	if err != nil {
+		t.Fatalf("NewPersistentSessionWithFileStore failed: %v", err)
+	}
+
+	if err := sess.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := sess.Restart(); err != nil {
+		t.Fatalf("Restart failed: %v", err)
+	}
+	if err := sess.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	// Restart stops and starts the session, so those hooks run twice
+	sessionID := sess.GetInfo().SessionID
+	expected := map[string]int{"start": 2, "stop": 2, "restart": 1}
+	for event, count := range expected {
+		marker := filepath.Join(dir, event+".marker")
+		var lines []string
+		waitFor(t, func() bool {
+			data, err := os.ReadFile(marker)
+			lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+			return err == nil && len(lines) == count && strings.HasSuffix(string(data), "\n")
+		})
+
+		want := event + " chrome-devtools " + sessionID
+		for _, got := range lines {
+			if got != want {
+				t.Errorf("%s hook wrote %q, want %q", event, got, want)
+			}
+		}
+	}
+
+	sess.fileStore.Flush()
+}
+
+func TestHookTimeoutDoesNotHang(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook script uses sh")
+	}
+
+	start := time.Now()
+	err := runHookCommand("sleep 5", nil, 100*time.Millisecond, func() {})
+	if err == nil {
+		t.Fatal("expected hanging hook to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("hook timeout took %s", elapsed)
+	}
+}
@@ -1,14 +1,17 @@
 package session
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
 )
 
 // Manager manages MCP client sessions
@@ -20,14 +23,22 @@ type Manager struct {
 	clientFactory  ClientFactory
 	fileStore      *FileStore
 	processManager *ProcessManager
+	broker         ProcessBroker
+
+	pending             map[string]*pendingSession // Sessions being started outside the lock
+	monitors            map[string]chan struct{}   // Background health check stop channels by session name
+	monitorWG           sync.WaitGroup             // Health monitors and the startup cleanup
+	healthCheckInterval time.Duration              // Overrides the configured interval when set
+	healthCheckTimeout  time.Duration              // Overrides DefaultHealthCheckTimeout in cleanup when set
+	closed              bool
 }
 
 // NewManager creates a new session manager
 func NewManager(configDir string, clientFactory ClientFactory) (*Manager, error) {
-	sessionsDir := filepath.Join(configDir, "sessions")
+	sessionsDir := filepath.Join(configDir, config.InstanceFile("sessions", config.DaemonName()))
 
 	// Create sessions directory if it doesn't exist
-	if err := os.MkdirAll(sessionsDir, 0755); err != nil {
+	if err := os.MkdirAll(sessionsDir, 0700); err != nil {
 		return nil, fmt.Errorf("failed to create sessions directory: %w", err)
 	}
 
@@ -41,16 +52,20 @@ func NewManager(configDir string, clientFactory ClientFactory) (*Manager, error)
 		clientFactory:  clientFactory,
 		fileStore:      fileStore,
 		processManager: processManager,
+		monitors:       make(map[string]chan struct{}),
+		pending:        make(map[string]*pendingSession),
 	}
 
 	// Load existing sessions from disk
 	if err := manager.loadSessions(); err != nil {
 		// Log error but don't fail creation
-		fmt.Printf("Warning: Failed to load existing sessions: %v\n", err)
+		logging.Warn("failed to load existing sessions", "error", err)
 	}
 
 	// Clean up dead sessions on startup
+	manager.monitorWG.Add(1)
 	go func() {
+		defer manager.monitorWG.Done()
 		if err := manager.cleanupDeadSessions(); err != nil {
 			// Log error but don't fail initialization
 			_ = err
@@ -60,33 +75,84 @@ func NewManager(configDir string, clientFactory ClientFactory) (*Manager, error)
 	return manager, nil
 }
 
+// pendingSession tracks a session that is being started outside the manager
+// lock, so concurrent requests for the same server share one startup
+type pendingSession struct {
+	done    chan struct{}
+	session Session
+	err     error
+}
+
 // GetSession gets or creates a session for the given server
 func (m *Manager) GetSession(serverName string, serverConfig config.ServerConfig) (Session, error) {
 	m.mutex.Lock()
-	defer m.mutex.Unlock()
 
 	// Check if session already exists in memory
 	if session, exists := m.sessions[serverName]; exists {
+		m.mutex.Unlock()
+		// Sessions loaded from disk only know the config templates
+		if persistentSession, ok := session.(*PersistentSession); ok && m.configMatches(persistentSession.Config(), serverConfig) {
+			persistentSession.useConfig(serverConfig)
+		}
 		// Update activity time
 		session.UpdateActivity()
 		return session, nil
 	}
 
+	// Share a startup already in progress for this server
+	if pending, starting := m.pending[serverName]; starting {
+		m.mutex.Unlock()
+		<-pending.done
+		return pending.session, pending.err
+	}
+
+	pending := &pendingSession{done: make(chan struct{})}
+	m.pending[serverName] = pending
+	broker := m.broker
+	m.mutex.Unlock()
+
+	// Reattaching or starting can take a long time (e.g. spawning npx), so it
+	// happens without the lock to avoid stalling other servers
+	session, reattached, err := m.openSession(serverName, serverConfig, broker)
+
+	m.mutex.Lock()
+	delete(m.pending, serverName)
+	if err == nil {
+		m.sessions[serverName] = session
+		m.startHealthMonitor(serverName, session)
+	}
+	m.mutex.Unlock()
+
+	pending.session, pending.err = session, err
+	close(pending.done)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Save session info to disk
+	if !reattached {
+		if err := m.saveSession(session); err != nil {
+			// Log error but don't fail the operation
+			logging.Warn("failed to save session info", "session", serverName, "error", err)
+		}
+	}
+
+	return session, nil
+}
+
+// openSession reattaches to or creates and starts a session, reporting whether
+// it was reattached (called without the lock held)
+func (m *Manager) openSession(serverName string, serverConfig config.ServerConfig, broker ProcessBroker) (Session, bool, error) {
 	// Check if we can reattach to an existing persistent session
 	sessionType := DetectSessionType(serverConfig)
 	if sessionType == Persistent || sessionType == Hybrid {
-		var existingSession Session
-		var reattachErr error
-		existingSession, reattachErr = m.tryReattachSession(serverName, serverConfig)
+		existingSession, reattachErr := m.tryReattachSession(serverName, serverConfig, broker)
 		if reattachErr == nil {
-			m.sessions[serverName] = existingSession
-			return existingSession, nil
+			return existingSession, true, nil
 		}
 		// Reattachment failed, continue with creating new session
-		// Only show warning if MCP_VERBOSE environment variable is set
-		if os.Getenv("MCP_VERBOSE") == "true" {
-			fmt.Printf("Warning: Failed to reattach to existing session for %s: %v\n", serverName, reattachErr)
-		}
+		logging.Debug("failed to reattach to existing session", "session", serverName, "error", reattachErr)
 	}
 
 	// Create new session
@@ -100,28 +166,70 @@ func (m *Manager) GetSession(serverName string, serverConfig config.ServerConfig
 		session, err = NewStatelessSession(serverName, serverConfig, m.clientFactory)
 	case Persistent, Hybrid:
 		// Create persistent session with file store
-		session, err = NewPersistentSessionWithFileStore(serverName, serverConfig, m.clientFactory, m.fileStore)
+		var persistentSession *PersistentSession
+		persistentSession, err = NewPersistentSessionWithFileStore(serverName, serverConfig, m.clientFactory, m.fileStore)
+		if err == nil {
+			persistentSession.broker = broker
+		}
+		session = persistentSession
 	default:
-		return nil, fmt.Errorf("unsupported session type: %s", sessionType.String())
+		return nil, false, fmt.Errorf("unsupported session type: %s", sessionType.String())
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+		return nil, false, fmt.Errorf("failed to create session: %w", err)
 	}
 
 	// Auto-start persistent sessions if configured
 	if (sessionType == Persistent || sessionType == Hybrid) && ShouldAutoStart(serverConfig) {
 		if err := session.Start(); err != nil {
-			return nil, fmt.Errorf("failed to auto-start persistent session: %w", err)
+			if !CanFallback(serverConfig) {
+				return nil, false, fmt.Errorf("failed to auto-start persistent session: %w", err)
+			}
+			fallback, fallbackErr := newFallbackSession(serverName, serverConfig, m.clientFactory, err)
+			return fallback, false, fallbackErr
 		}
 	}
 
+	return session, false, nil
+}
+
+// FallbackToStateless replaces a hybrid session whose persistent start failed
+// with a stateless one, so commands keep working without a long-lived client
+func (m *Manager) FallbackToStateless(serverName string, serverConfig config.ServerConfig, reason error) (Session, error) {
+	if !CanFallback(serverConfig) {
+		return nil, fmt.Errorf("session %s cannot fall back to stateless: %w", serverName, reason)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.fallbackToStateless(serverName, serverConfig, reason)
+}
+
+// fallbackToStateless swaps in a stateless session (must be called with lock held)
+func (m *Manager) fallbackToStateless(serverName string, serverConfig config.ServerConfig, reason error) (Session, error) {
+	m.stopHealthMonitor(serverName)
+	if existing, exists := m.sessions[serverName]; exists {
+		_ = existing.Stop() // Ignore error
+	}
+
+	session, err := newFallbackSession(serverName, serverConfig, m.clientFactory, reason)
+	if err != nil {
+		return nil, err
+	}
+
 	m.sessions[serverName] = session
+	return session, nil
+}
 
-	// Save session info to disk
-	if err := m.saveSession(session); err != nil {
-		// Log error but don't fail the operation
-		fmt.Printf("Warning: Failed to save session info: %v\n", err)
+// newFallbackSession creates the stateless replacement for a failed hybrid session
+func newFallbackSession(serverName string, serverConfig config.ServerConfig, clientFactory ClientFactory, reason error) (Session, error) {
+	logging.Debug("persistent session failed, falling back to stateless", "session", serverName, "error", reason)
+
+	session, err := NewFallbackSession(serverName, serverConfig, clientFactory, reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fallback session: %w", err)
 	}
 
 	return session, nil
@@ -134,10 +242,15 @@ func (m *Manager) ListSessions() ([]SessionInfo, error) {
 
 	sessions := make([]SessionInfo, 0, len(m.sessions))
 	for _, session := range m.sessions {
-		if persistentSession, ok := session.(*PersistentSession); ok {
-			sessions = append(sessions, persistentSession.GetInfo())
+		switch sess := session.(type) {
+		case *PersistentSession:
+			sessions = append(sessions, sess.GetInfo())
+		case *StatelessSession:
+			// Reported too, for their activity, though there is nothing to stop
+			sessions = append(sessions, sess.GetInfo())
 		}
 	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Name < sessions[j].Name })
 
 	return sessions, nil
 }
@@ -149,7 +262,7 @@ func (m *Manager) GetSessionByName(serverName string) (Session, error) {
 
 	session, exists := m.sessions[serverName]
 	if !exists {
-		return nil, fmt.Errorf("session not found: %s", serverName)
+		return nil, mcp.Mark(fmt.Errorf("session not found: %s", serverName), mcp.ErrSessionNotActive)
 	}
 
 	return session, nil
@@ -162,16 +275,22 @@ func (m *Manager) StopSession(serverName string) error {
 
 	session, exists := m.sessions[serverName]
 	if !exists {
-		return fmt.Errorf("session not found: %s", serverName)
+		return mcp.Mark(fmt.Errorf("session not found: %s", serverName), mcp.ErrSessionNotActive)
+	}
+
+	// A stateless session has no process or files; forgetting it is enough
+	if _, ok := session.(*StatelessSession); ok {
+		delete(m.sessions, serverName)
+		return nil
 	}
 
+	m.stopHealthMonitor(serverName)
 	if err := session.Stop(); err != nil {
 		return fmt.Errorf("failed to stop session: %w", err)
 	}
 
-	// Remove session file
-	sessionFile := filepath.Join(m.sessionsDir, serverName+".json")
-	_ = os.Remove(sessionFile) // Ignore error
+	// Remove session files
+	_ = m.fileStore.DeleteSessionsByName(serverName) // Ignore error
 
 	// Remove from memory
 	delete(m.sessions, serverName)
@@ -187,13 +306,13 @@ func (m *Manager) StopAllSessions() error {
 	var errors []error
 
 	for name, session := range m.sessions {
+		m.stopHealthMonitor(name)
 		if err := session.Stop(); err != nil {
 			errors = append(errors, fmt.Errorf("failed to stop session %s: %w", name, err))
 		}
 
-		// Remove session file
-		sessionFile := filepath.Join(m.sessionsDir, name+".json")
-		_ = os.Remove(sessionFile) // Ignore error
+		// Remove session files
+		_ = m.fileStore.DeleteSessionsByName(name) // Ignore error
 	}
 
 	// Clear memory
@@ -218,54 +337,125 @@ func (m *Manager) RestartSession(serverName string) error {
 
 	session, exists := m.sessions[serverName]
 	if !exists {
-		return fmt.Errorf("session not found: %s", serverName)
+		return mcp.Mark(fmt.Errorf("session not found: %s", serverName), mcp.ErrSessionNotActive)
 	}
 
 	return session.Restart()
 }
 
-// cleanupDeadSessions removes dead or expired sessions
-func (m *Manager) cleanupDeadSessions() error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+// ResetStats clears a session's metrics, such as its restart count and last
+// error, which nothing else resets
+func (m *Manager) ResetStats(serverName string) error {
+	m.mutex.RLock()
+	session, exists := m.sessions[serverName]
+	m.mutex.RUnlock()
+	if !exists {
+		return mcp.Mark(fmt.Errorf("session not found: %s", serverName), mcp.ErrSessionNotActive)
+	}
 
-	var toDelete []string
+	resettable, ok := session.(interface{ ResetStats() })
+	if !ok {
+		return fmt.Errorf("session %s keeps no stats", serverName)
+	}
+	resettable.ResetStats()
+	return nil
+}
+
+// Cleanup health check bounds
+const (
+	// DefaultHealthCheckTimeout is how long a session's server has to answer
+	// a health check
+	DefaultHealthCheckTimeout = 10 * time.Second
+
+	// maxParallelHealthChecks bounds how many sessions cleanup checks at once
+	maxParallelHealthChecks = 4
+)
 
+// cleanupDeadSessions removes dead or expired sessions. Health checks run
+// concurrently without the manager lock, so slow servers don't stall
+// GetSession; a session replaced meanwhile is left alone.
+func (m *Manager) cleanupDeadSessions() error {
+	m.mutex.Lock()
+	dead := make(map[string]*PersistentSession)
+	toCheck := make(map[string]*PersistentSession)
 	for name, session := range m.sessions {
 		if persistentSession, ok := session.(*PersistentSession); ok {
 			// Check if session is expired
-			maxIdle := GetSessionTimeout(persistentSession.Config())
+			maxIdle := GetSessionMaxIdle(persistentSession.Config())
 			if persistentSession.IsExpired(maxIdle) {
-				toDelete = append(toDelete, name)
+				dead[name] = persistentSession
 				continue
 			}
 
-			// Perform health check for persistent sessions (or explicit opt-in)
-			if persistentSession.Status() == Active && shouldHealthCheck(persistentSession) {
-				if err := persistentSession.HealthCheck(); err != nil {
-					fmt.Printf("Health check failed for session %s: %v\n", name, err)
-					toDelete = append(toDelete, name)
-					continue
-				}
+			// Perform health check for persistent sessions (or explicit opt-in);
+			// daemon-owned sessions loaded from disk are checked once attached, and
+			// sessions with a background monitor are left to it
+			_, monitored := m.monitors[name]
+			if !monitored && persistentSession.Status() == Active && persistentSession.hasClient() && shouldHealthCheck(persistentSession) {
+				toCheck[name] = persistentSession
 			}
 		}
 	}
+	m.mutex.Unlock()
+
+	for name, session := range m.checkSessions(toCheck) {
+		dead[name] = session
+	}
+	if len(dead) == 0 {
+		return nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 
 	// Delete dead sessions
-	for _, name := range toDelete {
-		if session := m.sessions[name]; session != nil {
-			_ = session.Stop() // Ignore error
+	for name, session := range dead {
+		if current, exists := m.sessions[name]; !exists || current != Session(session) {
+			continue // Stopped or replaced while being checked
 		}
+
+		m.stopHealthMonitor(name)
+		_ = session.Stop() // Ignore error
 		delete(m.sessions, name)
 
-		// Remove session file
-		sessionFile := filepath.Join(m.sessionsDir, name+".json")
-		_ = os.Remove(sessionFile) // Ignore error
+		// Remove session files
+		_ = m.fileStore.DeleteSessionsByName(name) // Ignore error
 	}
 
 	return nil
 }
 
+// checkSessions health checks sessions concurrently, at most
+// maxParallelHealthChecks at a time, and returns those that failed
+func (m *Manager) checkSessions(sessions map[string]*PersistentSession) map[string]*PersistentSession {
+	timeout := m.healthCheckTimeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failed := make(map[string]*PersistentSession)
+	slots := make(chan struct{}, maxParallelHealthChecks)
+	for name, session := range sessions {
+		wg.Add(1)
+		go func(name string, session *PersistentSession) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if err := session.healthCheck(timeout); err != nil {
+				logging.Warn("health check failed", "session", name, "error", err)
+				mu.Lock()
+				failed[name] = session
+				mu.Unlock()
+			}
+		}(name, session)
+	}
+	wg.Wait()
+	return failed
+}
+
 func shouldHealthCheck(sess *PersistentSession) bool {
 	if sess == nil {
 		return false
@@ -280,17 +470,7 @@ func shouldHealthCheck(sess *PersistentSession) bool {
 func (m *Manager) saveSession(session Session) error {
 	if persistentSession, ok := session.(*PersistentSession); ok {
 		info := persistentSession.GetInfo()
-
-		sessionFile := filepath.Join(m.sessionsDir, session.Name()+".json")
-
-		data, err := json.MarshalIndent(info, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal session info: %w", err)
-		}
-
-		if err := os.WriteFile(sessionFile, data, 0644); err != nil {
-			return fmt.Errorf("failed to write session file: %w", err)
-		}
+		return m.fileStore.SaveSession(&info)
 	}
 
 	return nil
@@ -312,27 +492,36 @@ func (m *Manager) loadSessions() error {
 			invalidSessions++
 			// Clean up invalid session files silently
 			if deleteErr := m.fileStore.DeleteSession(sessionInfo.SessionID); deleteErr != nil {
-				fmt.Printf("Warning: Failed to delete invalid session %s: %v\n", sessionInfo.Name, deleteErr)
+				logging.Warn("failed to delete invalid session", "session", sessionInfo.Name, "error", deleteErr)
 			}
 			continue
 		}
 
 		validSessions++
-		// For now, don't load sessions into memory automatically
-		// They will be loaded on-demand when GetSession is called
+
+		// Daemon-owned sessions outlive the command that started them, so track
+		// them (without attaching) to keep list and cleanup accurate
+		if sessionInfo.ConnectionInfo != nil && sessionInfo.ConnectionInfo.Type == BrokeredConnectionType {
+			if persistentSession, err := LoadPersistentSession(sessionInfo, m.clientFactory, m.fileStore); err == nil {
+				m.sessions[sessionInfo.Name] = persistentSession
+			}
+			continue
+		}
+
+		// Other sessions are loaded on-demand when GetSession is called
 		// This prevents starting all sessions at startup
 	}
 
 	// Only report if we found sessions to process and verbose mode is enabled
-	if (validSessions > 0 || invalidSessions > 0) && os.Getenv("MCP_VERBOSE") == "true" {
-		fmt.Printf("Session cleanup: %d valid sessions found, %d invalid sessions removed\n", validSessions, invalidSessions)
+	if validSessions > 0 || invalidSessions > 0 {
+		logging.Debug("session cleanup", "valid", validSessions, "removed", invalidSessions)
 	}
 
 	return nil
 }
 
 // tryReattachSession attempts to reattach to an existing session
-func (m *Manager) tryReattachSession(serverName string, serverConfig config.ServerConfig) (Session, error) {
+func (m *Manager) tryReattachSession(serverName string, serverConfig config.ServerConfig, broker ProcessBroker) (Session, error) {
 	// Look for existing session in file store
 	sessionInfo, err := m.fileStore.FindExistingSession(serverName)
 	if err != nil {
@@ -344,11 +533,14 @@ func (m *Manager) tryReattachSession(serverName string, serverConfig config.Serv
 		return nil, fmt.Errorf("server configuration mismatch")
 	}
 
-	// Load the persistent session from session info
+	// Load the persistent session from session info, with the resolved config
+	// instead of the stored templates
+	sessionInfo.Config = serverConfig
 	session, err := LoadPersistentSession(sessionInfo, m.clientFactory, m.fileStore)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load persistent session: %w", err)
 	}
+	session.broker = broker
 
 	// Try to start the session (which will attempt reattachment)
 	if err := session.Start(); err != nil {
@@ -358,13 +550,29 @@ func (m *Manager) tryReattachSession(serverName string, serverConfig config.Serv
 	return session, nil
 }
 
-// configMatches checks if two server configs are compatible for reattachment
+// configMatches checks if two server configs are compatible for reattachment.
+// Stored configs hold unresolved templates, so both sides are compared that way.
 func (m *Manager) configMatches(existing, new config.ServerConfig) bool {
+	existing, new = existing.Unresolved(), new.Unresolved()
+
 	// For HTTP servers, just check URL matches
 	if existing.Type == "http" && new.Type == "http" {
 		return existing.URL == new.URL
 	}
 
+	// For docker servers, check the image and container args match
+	if existing.IsDocker() || new.IsDocker() {
+		return existing.Type == new.Type && existing.Image == new.Image &&
+			strings.Join(existing.Args, "\x00") == strings.Join(new.Args, "\x00")
+	}
+
+	// For ssh servers, check the remote command and args match
+	if existing.IsSSH() || new.IsSSH() {
+		return existing.Type == new.Type && existing.SSHDestination() == new.SSHDestination() &&
+			existing.RemoteCommand == new.RemoteCommand &&
+			strings.Join(existing.Args, "\x00") == strings.Join(new.Args, "\x00")
+	}
+
 	// For stdio servers, check command and args match
 	if existing.Command != "" && new.Command != "" {
 		if existing.Command != new.Command {
@@ -385,9 +593,52 @@ func (m *Manager) configMatches(existing, new config.ServerConfig) bool {
 	return false
 }
 
-// CleanupStaleSessions removes dead or expired sessions
-func (m *Manager) CleanupStaleSessions() error {
-	return m.fileStore.CleanupStaleSessions(24 * time.Hour) // Default to 24 hours
+// CleanupStaleSessions removes dead or expired session records from disk.
+// Each server's retention settings apply unless olderThan is set.
+func (m *Manager) CleanupStaleSessions(olderThan time.Duration) ([]CleanupResult, error) {
+	return m.fileStore.CleanupWithPolicy(CleanupPolicy{
+		Retention:      DefaultSessionRetention,
+		ErrorRetention: DefaultErrorRetention,
+		OlderThan:      olderThan,
+	})
+}
+
+// SetProcessBroker sets the broker that owns persistent stdio processes
+// across CLI invocations. Without one, sessions live only in this process.
+func (m *Manager) SetProcessBroker(broker ProcessBroker) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.broker = broker
+
+	// Sessions loaded from disk need the broker to reattach or release
+	for _, session := range m.sessions {
+		if persistentSession, ok := session.(*PersistentSession); ok {
+			persistentSession.SetProcessBroker(broker)
+		}
+	}
+}
+
+// Flush waits for pending session metadata saves, so nothing is lost when the process exits
+func (m *Manager) Flush() {
+	m.fileStore.Flush()
+}
+
+// Close stops background health checks, flushes pending saves and waits for
+// triggered hooks to spawn. Sessions themselves are left running so later
+// commands can reuse them.
+func (m *Manager) Close() error {
+	m.mutex.Lock()
+	m.closed = true
+	for name := range m.monitors {
+		m.stopHealthMonitor(name)
+	}
+	m.mutex.Unlock()
+
+	m.monitorWG.Wait()
+	m.Flush()
+	WaitForHooks()
+
+	return nil
 }
 
 // GetFileStore returns the file store for external access
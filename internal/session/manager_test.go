@@ -0,0 +1,334 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// failingFactory returns a client factory whose clients never start
+func failingFactory() ClientFactory {
+	return func(config.ServerConfig) (mcp.MCPClient, error) {
+		return nil, errors.New("server exited during startup")
+	}
+}
+
+func hybridConfig(strict bool) config.ServerConfig {
+	return config.ServerConfig{
+		Command: "uvx",
+		Args:    []string{"mcp-server-time"},
+		Session: config.SessionConfig{
+			Type:      "hybrid",
+			AutoStart: true,
+			Strict:    strict,
+		},
+	}
+}
+
+func TestGetSessionHybridFallsBackToStateless(t *testing.T) {
+	manager, err := NewManager(t.TempDir(), failingFactory())
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	sess, err := manager.GetSession("time", hybridConfig(false))
+	if err != nil {
+		t.Fatalf("expected hybrid fallback, got error: %v", err)
+	}
+
+	if sess.Type() != Stateless {
+		t.Fatalf("expected stateless fallback session, got %s", sess.Type())
+	}
+
+	infos, err := manager.ListSessions()
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(infos) != 1 || infos[0].FallbackReason == "" {
+		t.Fatalf("expected one session with a fallback reason, got %+v", infos)
+	}
+}
+
+func TestGetSessionHybridStrictFails(t *testing.T) {
+	manager, err := NewManager(t.TempDir(), failingFactory())
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	if _, err := manager.GetSession("time", hybridConfig(true)); err == nil {
+		t.Fatal("expected strict hybrid session to fail without fallback")
+	}
+
+	if _, err := manager.FallbackToStateless("time", hybridConfig(true), errors.New("boom")); err == nil {
+		t.Fatal("expected FallbackToStateless to refuse strict sessions")
+	}
+}
+
+func TestRecordToolCallPersistsMetrics(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	browser := config.ServerConfig{Command: "npx", Args: []string{"-y", "chrome-devtools-mcp@latest"}}
+
+	sess, err := NewPersistentSessionWithFileStore("chrome-devtools", browser, failingFactory(), store)
+	if err != nil {
+		t.Fatalf("NewPersistentSessionWithFileStore failed: %v", err)
+	}
+
+	sess.RecordToolCall("navigate_page", 150*time.Millisecond, nil)
+	sess.RecordToolCall("take_screenshot", 50*time.Millisecond, errors.New("timeout"))
+	store.Flush()
+
+	info := sess.GetInfo()
+	if info.ToolCallCount != 2 || info.ErrorCount != 1 || info.LastTool != "take_screenshot" || info.ToolTime != 200*time.Millisecond {
+		t.Fatalf("unexpected in-memory metrics: %+v", info.SessionMetrics)
+	}
+
+	// Metrics survive a save/load cycle, as on reattachment
+	loaded, err := store.LoadSession(info.SessionID)
+	if err != nil {
+		t.Fatalf("LoadSession failed: %v", err)
+	}
+	reloaded, err := LoadPersistentSession(loaded, failingFactory(), store)
+	if err != nil {
+		t.Fatalf("LoadPersistentSession failed: %v", err)
+	}
+	if got := reloaded.GetInfo().SessionMetrics; got != info.SessionMetrics {
+		t.Errorf("expected metrics %+v after reload, got %+v", info.SessionMetrics, got)
+	}
+}
+
+func TestRestartsAndErrorsPersistUntilReset(t *testing.T) {
+	var healthy atomic.Bool
+	var created atomic.Int32
+	healthy.Store(true)
+	manager, err := NewManager(t.TempDir(), fakeFactory(&healthy, &created))
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Close() })
+
+	sess, err := manager.GetSession("chrome-devtools", monitoredConfig(false))
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	persistent := sess.(*PersistentSession)
+	persistent.SetError(errors.New("browser crashed"))
+	if err := manager.RestartSession("chrome-devtools"); err != nil {
+		t.Fatalf("RestartSession failed: %v", err)
+	}
+	if err := manager.RestartSession("chrome-devtools"); err != nil {
+		t.Fatalf("RestartSession failed: %v", err)
+	}
+	manager.Flush()
+
+	// Counters survive a save/load cycle, and recovering keeps the last error
+	info := persistent.GetInfo()
+	loaded, err := manager.GetFileStore().LoadSession(info.SessionID)
+	if err != nil {
+		t.Fatalf("LoadSession failed: %v", err)
+	}
+	if loaded.RestartCount != 2 || loaded.LastError != "browser crashed" || loaded.LastErrorTime == nil || loaded.Status != Active {
+		t.Errorf("saved session = status %s, %+v", loaded.Status, loaded.SessionMetrics)
+	}
+
+	if err := manager.ResetStats("chrome-devtools"); err != nil {
+		t.Fatalf("ResetStats failed: %v", err)
+	}
+	manager.Flush()
+	loaded, err = manager.GetFileStore().LoadSession(info.SessionID)
+	if err != nil {
+		t.Fatalf("LoadSession failed: %v", err)
+	}
+	if loaded.RestartCount != 0 || loaded.LastError != "" || loaded.LastErrorTime != nil {
+		t.Errorf("saved metrics after reset: %+v", loaded.SessionMetrics)
+	}
+
+	if err := manager.ResetStats("missing"); !errors.Is(err, mcp.ErrSessionNotActive) {
+		t.Errorf("resetting an unknown session: %v", err)
+	}
+}
+
+func TestGetSessionSlowStartDoesNotBlockOtherServers(t *testing.T) {
+	release := make(chan struct{})
+	var created atomic.Int32
+	var healthy atomic.Bool
+	healthy.Store(true)
+
+	// The browser server blocks in startup until released
+	factory := func(serverConfig config.ServerConfig) (mcp.MCPClient, error) {
+		created.Add(1)
+		<-release
+		return &fakeClient{healthy: &healthy}, nil
+	}
+
+	manager, err := NewManager(t.TempDir(), factory)
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	browser := config.ServerConfig{Command: "npx", Args: []string{"-y", "chrome-devtools-mcp@latest"}}
+
+	var wg sync.WaitGroup
+	results := make([]Session, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sess, err := manager.GetSession("chrome-devtools", browser)
+			if err != nil {
+				t.Errorf("GetSession failed: %v", err)
+			}
+			results[i] = sess
+		}(i)
+	}
+
+	// Wait for the slow startup to begin, then use an unrelated server
+	waitFor(t, func() bool { return created.Load() == 1 })
+
+	fast := make(chan error, 1)
+	go func() {
+		_, err := manager.GetSession("context7", config.ServerConfig{Type: "http", URL: "https://example.com/mcp"})
+		fast <- err
+	}()
+
+	select {
+	case err := <-fast:
+		if err != nil {
+			t.Fatalf("GetSession for fast server failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("fast server was blocked by a slow session startup")
+	}
+
+	close(release)
+	wg.Wait()
+
+	if created.Load() != 1 {
+		t.Errorf("expected concurrent requests to share one startup, got %d", created.Load())
+	}
+	if results[0] == nil || results[0] != results[1] {
+		t.Error("expected both requests to receive the same session")
+	}
+	manager.Flush()
+}
+
+// hangingClient is a fake client whose health checks hang once stalled
+type hangingClient struct {
+	fakeClient
+	stalled *atomic.Bool
+}
+
+func (c *hangingClient) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	if c.stalled.Load() {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return c.fakeClient.ListTools(ctx)
+}
+
+func (c *hangingClient) Initialize(ctx context.Context, params *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	if c.stalled.Load() {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return c.fakeClient.Initialize(ctx, params)
+}
+
+func TestCleanupChecksSessionsConcurrently(t *testing.T) {
+	var healthy, stalled atomic.Bool
+	healthy.Store(true)
+	manager, err := NewManager(t.TempDir(), func(config.ServerConfig) (mcp.MCPClient, error) {
+		return &hangingClient{fakeClient: fakeClient{healthy: &healthy}, stalled: &stalled}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Close() })
+	manager.healthCheckTimeout = 200 * time.Millisecond
+
+	const servers = 5
+	for i := 0; i < servers; i++ {
+		serverConfig := config.ServerConfig{Command: "npx", Args: []string{fmt.Sprintf("server-%d", i)}, Session: config.SessionConfig{Type: "persistent"}}
+		if _, err := manager.GetSession(fmt.Sprintf("server-%d", i), serverConfig); err != nil {
+			t.Fatalf("GetSession failed: %v", err)
+		}
+	}
+
+	stalled.Store(true)
+	done := make(chan time.Duration, 1)
+	go func() {
+		start := time.Now()
+		_ = manager.CleanupSessions()
+		done <- time.Since(start)
+	}()
+
+	// The manager stays usable while the checks hang
+	time.Sleep(50 * time.Millisecond)
+	lookup := make(chan struct{})
+	go func() {
+		_, _ = manager.GetSessionByName("server-0")
+		close(lookup)
+	}()
+	select {
+	case <-lookup:
+	case <-time.After(100 * time.Millisecond):
+		t.Error("a session lookup waited for the health checks")
+	}
+
+	// Five checks of 200ms each, four at a time, take two rounds rather than five
+	select {
+	case elapsed := <-done:
+		if elapsed > 3*manager.healthCheckTimeout {
+			t.Errorf("cleanup took %v; the checks did not run concurrently", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cleanup did not finish")
+	}
+	if sessions, _ := manager.ListSessions(); len(sessions) != 0 {
+		t.Errorf("sessions that failed their health check should be removed, got %d", len(sessions))
+	}
+}
+
+func TestListSessionsIncludesStatelessSessions(t *testing.T) {
+	manager, err := NewManager(t.TempDir(), failingFactory())
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Close() })
+
+	remote := config.ServerConfig{Type: "http", URL: "https://mcp.context7.com/mcp"}
+	sess, err := manager.GetSession("context7", remote)
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	if sess.Type() != Stateless {
+		t.Fatalf("expected a stateless session, got %s", sess.Type())
+	}
+
+	infos, err := manager.ListSessions()
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(infos) != 1 {
+		t.Fatalf("expected the stateless session to be listed, got %+v", infos)
+	}
+	info := infos[0]
+	if info.Name != "context7" || info.Type != Stateless || info.Status != Active || info.LastActivity.IsZero() || info.FallbackReason != "" {
+		t.Errorf("unexpected session info %+v", info)
+	}
+	if len(info.Endpoints) != 1 || info.Endpoints[0] != remote.URL {
+		t.Errorf("expected the server URL as endpoint, got %v", info.Endpoints)
+	}
+
+	if err := manager.StopSession("context7"); err != nil {
+		t.Fatalf("StopSession failed: %v", err)
+	}
+	if infos, _ := manager.ListSessions(); len(infos) != 0 {
+		t.Errorf("expected the stopped session to be forgotten, got %+v", infos)
+	}
+}
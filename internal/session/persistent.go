@@ -2,6 +2,7 @@ package session
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
 )
 
@@ -25,6 +27,8 @@ type PersistentSession struct {
 	clientFactory  ClientFactory
 	fileStore      *FileStore
 	processManager *ProcessManager
+	broker         ProcessBroker
+	metrics        SessionMetrics
 	mutex          sync.RWMutex
 	startTime      time.Time
 	lastActivity   time.Time
@@ -42,16 +46,20 @@ func NewPersistentSession(name string, serverConfig config.ServerConfig, clientF
 	return NewPersistentSessionWithFileStore(name, serverConfig, clientFactory, nil)
 }
 
+// DefaultSessionsDir returns where sessions are stored when no file store is given
+func DefaultSessionsDir() string {
+	dataDir, _ := config.GetDataDir()
+	return filepath.Join(dataDir, config.InstanceFile("sessions", config.DaemonName()))
+}
+
 // NewPersistentSessionWithFileStore creates a new persistent session with file store
 func NewPersistentSessionWithFileStore(name string, serverConfig config.ServerConfig, clientFactory ClientFactory, fileStore *FileStore) (*PersistentSession, error) {
 	sessionType := DetectSessionType(serverConfig)
 
 	// Initialize file store if not provided
 	if fileStore == nil {
-		// Use default config directory
-		configDir, _ := os.UserConfigDir()
-		sessionsDir := filepath.Join(configDir, "mcp-cli-ent", "sessions")
-		fileStore = NewFileStore(sessionsDir)
+		// Use the default sessions directory
+		fileStore = NewFileStore(DefaultSessionsDir())
 	}
 
 	sessionID := fileStore.GenerateSessionID(name)
@@ -76,9 +84,7 @@ func NewPersistentSessionWithFileStore(name string, serverConfig config.ServerCo
 func LoadPersistentSession(sessionInfo *SessionInfo, clientFactory ClientFactory, fileStore *FileStore) (*PersistentSession, error) {
 	// Initialize file store if not provided
 	if fileStore == nil {
-		configDir, _ := os.UserConfigDir()
-		sessionsDir := filepath.Join(configDir, "mcp-cli-ent", "sessions")
-		fileStore = NewFileStore(sessionsDir)
+		fileStore = NewFileStore(DefaultSessionsDir())
 	}
 
 	session := &PersistentSession{
@@ -98,6 +104,7 @@ func LoadPersistentSession(sessionInfo *SessionInfo, clientFactory ClientFactory
 		connectionInfo: sessionInfo.ConnectionInfo,
 		endpoints:      sessionInfo.Endpoints,
 		error:          sessionInfo.Error,
+		metrics:        sessionInfo.SessionMetrics,
 	}
 
 	return session, nil
@@ -123,23 +130,43 @@ func (s *PersistentSession) Status() SessionStatus {
 }
 
 // Client returns the MCP client for this session
-func (s *PersistentSession) Client() mcp.MCPClient {
+func (s *PersistentSession) Client() (mcp.MCPClient, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
-	return s.client
+	if s.client == nil {
+		return nil, ErrNotStarted
+	}
+	return s.client, nil
+}
+
+// hasClient reports whether the session currently holds a client
+func (s *PersistentSession) hasClient() bool {
+	_, err := s.Client()
+	return err == nil
 }
 
 // Config returns the server configuration
 func (s *PersistentSession) Config() config.ServerConfig {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
 	return s.config
 }
 
+// useConfig replaces the config templates loaded from disk with the resolved
+// server config, so a restart gets real environment values
+func (s *PersistentSession) useConfig(serverConfig config.ServerConfig) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.config = serverConfig
+}
+
 // Start starts the persistent session
 func (s *PersistentSession) Start() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if s.status == Active {
+	// Sessions loaded from disk may report Active without a client yet
+	if s.status == Active && s.client != nil {
 		return nil // Already started
 	}
 
@@ -155,14 +182,14 @@ func (s *PersistentSession) Start() error {
 	s.error = ""
 
 	// Try to reattach to existing session if we have session metadata
-	if s.sessionID != "" && s.pid > 0 {
+	if s.sessionID != "" && (s.pid > 0 || s.isBrokered()) {
 		reattachErr := s.tryReattach()
 		if reattachErr == nil {
 			// Successfully reattached
 			return nil
 		}
 		// Reattachment failed, continue with creating new session
-		fmt.Printf("Warning: Failed to reattach to existing session: %v\n", reattachErr)
+		logging.Warn("failed to reattach to existing session", "session", s.name, "error", reattachErr)
 	}
 
 	// Create new session
@@ -171,6 +198,11 @@ func (s *PersistentSession) Start() error {
 
 // tryReattach attempts to reattach to an existing session
 func (s *PersistentSession) tryReattach() error {
+	// Brokered sessions live in the daemon, so reattach by asking it
+	if s.isBrokered() {
+		return s.reattachToBrokeredSession()
+	}
+
 	// Check if process is still alive
 	if !s.processManager.IsProcessAlive(s.pid) {
 		return fmt.Errorf("process %d is no longer alive", s.pid)
@@ -187,9 +219,76 @@ func (s *PersistentSession) tryReattach() error {
 		return s.reattachToHTTPSession()
 	}
 
-	// For stdio-based sessions, reattachment is more complex and may not be possible
-	// For now, we'll create a new session
-	return fmt.Errorf("reattachment to stdio sessions not yet supported")
+	// Stdio sessions can only be reattached when a broker owns the process
+	return fmt.Errorf("reattachment to stdio sessions requires the daemon")
+}
+
+// reattachAdopted reattaches to the process Manager.AdoptProcess gave the
+// session. Unlike Start, it never starts a server of its own, which would
+// be the duplicate adopting avoids.
+func (s *PersistentSession) reattachAdopted() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.clientFactory == nil {
+		return fmt.Errorf("client factory not initialized")
+	}
+
+	s.status = Starting
+	if err := s.tryReattach(); err != nil {
+		s.status = Inactive
+		return err
+	}
+
+	sessionInfo := s.buildSessionInfo()
+	s.saveToStoreAsyncWithInfo(&sessionInfo)
+	s.runHook(HookEventStart, nil)
+	return nil
+}
+
+// recordProbe notes in the connection info which probe reached the server,
+// for debugging (must be called with lock held). The info is copied, since
+// session info being saved may share it.
+func (s *PersistentSession) recordProbe(probe string) {
+	if s.connectionInfo == nil {
+		return
+	}
+	info := *s.connectionInfo
+	info.Extra = make(map[string]interface{}, len(s.connectionInfo.Extra)+1)
+	for key, value := range s.connectionInfo.Extra {
+		info.Extra[key] = value
+	}
+	info.Extra[ProbeExtraKey] = probe
+	s.connectionInfo = &info
+}
+
+// isBrokered reports whether the session process is owned by a broker (must be called with lock held)
+func (s *PersistentSession) isBrokered() bool {
+	return s.connectionInfo != nil && s.connectionInfo.Type == BrokeredConnectionType
+}
+
+// shouldBroker reports whether a new session should be handed to the broker
+func (s *PersistentSession) shouldBroker() bool {
+	return s.broker != nil && s.sessionType == Persistent && s.config.Type != "http" && s.config.Command != ""
+}
+
+// reattachToBrokeredSession reconnects to a session owned by the broker
+func (s *PersistentSession) reattachToBrokeredSession() error {
+	if s.broker == nil {
+		return fmt.Errorf("session is owned by the daemon but no daemon broker is available")
+	}
+
+	client, err := s.broker.Attach(s.name)
+	if err != nil {
+		return fmt.Errorf("failed to reattach through daemon: %w", err)
+	}
+
+	s.client = client
+	s.status = Active
+	s.lastActivity = time.Now()
+	s.error = ""
+
+	return nil
 }
 
 // reattachToHTTPSession attempts to reattach to an HTTP-based session
@@ -200,17 +299,18 @@ func (s *PersistentSession) reattachToHTTPSession() error {
 		return fmt.Errorf("failed to create client for reattachment: %w", err)
 	}
 
-	// Test the connection with a simple health check
+	// Test the connection with the cheapest probe the server answers
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	_, err = client.ListTools(ctx)
+	probe, err := Probe(ctx, client)
 	if err != nil {
 		_ = client.Close()
 		return fmt.Errorf("health check failed during reattachment: %w", err)
 	}
 
 	// Successfully reattached
+	s.recordProbe(probe)
 	s.client = client
 	s.status = Active
 	s.lastActivity = time.Now()
@@ -221,11 +321,21 @@ func (s *PersistentSession) reattachToHTTPSession() error {
 
 // createNewSession creates a brand new session
 func (s *PersistentSession) createNewSession() error {
+	// Prefer handing stdio processes to the daemon so they outlive this command
+	if s.shouldBroker() {
+		brokerErr := s.createBrokeredSession()
+		if brokerErr == nil {
+			return nil
+		}
+		logging.Debug("daemon unavailable, running session in-process", "session", s.name, "error", brokerErr)
+	}
+
 	// Create the MCP client using the factory
 	client, err := s.clientFactory(s.config)
 	if err != nil {
 		s.status = Error
-		s.error = fmt.Sprintf("failed to create client: %v", err)
+		s.recordError(fmt.Sprintf("failed to create client: %v", err))
+		s.runHook(HookEventError, err)
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
@@ -253,7 +363,7 @@ func (s *PersistentSession) createNewSession() error {
 			Type: "stdio",
 			Extra: map[string]interface{}{
 				"command": s.config.Command,
-				"args":    s.config.Args,
+				"args":    s.config.Unresolved().Args,
 				"timeout": s.config.Timeout,
 			},
 		}
@@ -270,6 +380,42 @@ func (s *PersistentSession) createNewSession() error {
 
 	// Save session metadata to file asynchronously
 	s.saveToStoreAsyncWithInfo(&sessionInfo)
+	s.runHook(HookEventStart, nil)
+
+	return nil
+}
+
+// createBrokeredSession starts the session inside the broker (must be called with lock held)
+func (s *PersistentSession) createBrokeredSession() error {
+	client, err := s.broker.Acquire(s.name, s.config)
+	if err != nil {
+		return err
+	}
+
+	// The daemon owns the server process, so there is no local PID to track
+	s.pid = 0
+	s.processPath = ""
+	s.processArgs = nil
+	s.connectionInfo = &ConnectionInfo{
+		Type: BrokeredConnectionType,
+		URL:  s.broker.Endpoint(),
+		Extra: map[string]interface{}{
+			"command": s.config.Command,
+			"args":    s.config.Unresolved().Args,
+			"timeout": s.config.Timeout,
+		},
+	}
+	s.endpoints = []string{s.broker.Endpoint()}
+
+	s.client = client
+	s.status = Active
+	s.startTime = time.Now()
+	s.lastActivity = time.Now()
+	s.error = ""
+
+	sessionInfo := s.buildSessionInfo()
+	s.saveToStoreAsyncWithInfo(&sessionInfo)
+	s.runHook(HookEventStart, nil)
 
 	return nil
 }
@@ -289,6 +435,7 @@ func (s *PersistentSession) buildSessionInfo() SessionInfo {
 		LastActivity:   s.lastActivity,
 		Endpoints:      s.endpoints,
 		Error:          s.error,
+		SessionMetrics: s.metrics,
 		Config:         s.config,
 	}
 }
@@ -298,13 +445,7 @@ func (s *PersistentSession) saveToStoreAsyncWithInfo(info *SessionInfo) {
 	if s.fileStore == nil {
 		return
 	}
-	go func() {
-		if err := s.fileStore.SaveSession(info); err != nil {
-			if os.Getenv("MCP_VERBOSE") == "true" {
-				fmt.Printf("Warning: Failed to save session metadata: %v\n", err)
-			}
-		}
-	}()
+	s.fileStore.SaveSessionAsync(info)
 }
 
 // Stop stops the session and cleans up resources
@@ -317,16 +458,25 @@ func (s *PersistentSession) Stop() error {
 	}
 
 	s.status = Stopping
+	s.runHook(HookEventStop, nil)
 
 	if s.client != nil {
 		if err := s.client.Close(); err != nil {
 			s.status = Error
-			s.error = fmt.Sprintf("failed to close client: %v", err)
+			s.recordError(fmt.Sprintf("failed to close client: %v", err))
+			s.runHook(HookEventError, err)
 			return fmt.Errorf("failed to close client: %w", err)
 		}
 		s.client = nil
 	}
 
+	// Ask the broker to stop the process it owns
+	if s.isBrokered() && s.broker != nil {
+		if err := s.broker.Release(s.name); err != nil {
+			logging.Debug("failed to stop daemon session", "session", s.name, "error", err)
+		}
+	}
+
 	s.status = Stopped
 	s.pid = 0
 	s.endpoints = nil
@@ -341,8 +491,13 @@ func (s *PersistentSession) Stop() error {
 	return nil
 }
 
-// Restart restarts the session
+// Restart restarts the session, counting the restart
 func (s *PersistentSession) Restart() error {
+	s.mutex.Lock()
+	s.metrics.RecordRestart()
+	s.runHook(HookEventRestart, nil)
+	s.mutex.Unlock()
+
 	if err := s.Stop(); err != nil {
 		return fmt.Errorf("failed to stop session: %w", err)
 	}
@@ -355,11 +510,16 @@ func (s *PersistentSession) Restart() error {
 
 // HealthCheck performs a health check on the session
 func (s *PersistentSession) HealthCheck() error {
+	return s.healthCheck(DefaultHealthCheckTimeout)
+}
+
+// healthCheck is HealthCheck with the time the server has to answer
+func (s *PersistentSession) healthCheck(timeout time.Duration) error {
 	s.mutex.RLock()
 
 	if s.status != Active {
 		s.mutex.RUnlock()
-		return fmt.Errorf("session is not active (status: %s)", s.status.String())
+		return mcp.Mark(fmt.Errorf("session is not active (status: %s)", s.status.String()), mcp.ErrSessionNotActive)
 	}
 
 	if s.client == nil {
@@ -374,7 +534,7 @@ func (s *PersistentSession) HealthCheck() error {
 		s.mutex.Lock()
 		s.status = Stopped
 		s.pid = 0
-		s.error = "process terminated"
+		s.recordError("process terminated")
 
 		// Capture session info before releasing the lock
 		sessionInfo := s.buildSessionInfo()
@@ -389,19 +549,26 @@ func (s *PersistentSession) HealthCheck() error {
 	client := s.client
 	s.mutex.RUnlock()
 
-	// Perform a simple health check by listing tools
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Perform a health check with the cheapest probe the server answers
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	_, err := client.ListTools(ctx)
+	// A single failure is recorded but left to the caller (or the background
+	// monitor) to turn into an Error status
+	probe, err := Probe(ctx, client)
 	if err != nil {
 		s.mutex.Lock()
-		s.status = Error
-		s.error = fmt.Sprintf("health check failed: %v", err)
+		s.recordError(fmt.Sprintf("health check failed: %v", err))
 		s.mutex.Unlock()
 		return fmt.Errorf("health check failed: %w", err)
 	}
 
+	// Clear any earlier transient failure
+	s.mutex.Lock()
+	s.error = ""
+	s.recordProbe(probe)
+	s.mutex.Unlock()
+
 	// Update last activity time on successful health check
 	s.UpdateActivity()
 
@@ -421,17 +588,65 @@ func (s *PersistentSession) UpdateActivity() {
 	defer s.mutex.Unlock()
 	s.lastActivity = time.Now()
 
-	// Update activity in file store asynchronously
-	go func() {
-		if s.fileStore != nil {
-			if err := s.fileStore.UpdateSessionActivity(s.sessionID); err != nil {
-				// Only show warning if MCP_VERBOSE environment variable is set
-				if os.Getenv("MCP_VERBOSE") == "true" {
-					fmt.Printf("Warning: Failed to update session activity: %v\n", err)
-				}
-			}
-		}
-	}()
+	// Save the full snapshot so in-memory metrics aren't overwritten by stale data
+	if s.sessionID != "" {
+		sessionInfo := s.buildSessionInfo()
+		s.saveToStoreAsyncWithInfo(&sessionInfo)
+	}
+}
+
+// markError moves the session to the Error status and persists it
+func (s *PersistentSession) markError(message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.status = Error
+	s.recordError(message)
+
+	sessionInfo := s.buildSessionInfo()
+	s.saveToStoreAsyncWithInfo(&sessionInfo)
+	s.runHook(HookEventError, errors.New(message))
+}
+
+// recordError sets the session's error and notes it in the metrics (must be
+// called with lock held)
+func (s *PersistentSession) recordError(message string) {
+	s.error = message
+	s.metrics.RecordError(message, time.Now())
+}
+
+// runHook fires the configured lifecycle hook for an event (must be called with lock held)
+func (s *PersistentSession) runHook(event string, eventErr error) {
+	RunHook(s.name, s.config, event, s.sessionID, eventErr)
+}
+
+// RecordToolCall updates the in-memory metrics and persists them with the
+// regular async save
+func (s *PersistentSession) RecordToolCall(toolName string, duration time.Duration, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.metrics.Record(toolName, duration, err)
+	s.lastActivity = time.Now()
+
+	if s.sessionID != "" {
+		sessionInfo := s.buildSessionInfo()
+		s.saveToStoreAsyncWithInfo(&sessionInfo)
+	}
+}
+
+// SetProcessBroker sets the broker used to own stdio processes across commands
+func (s *PersistentSession) SetProcessBroker(broker ProcessBroker) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.broker = broker
+}
+
+// IsBrokered reports whether the session's process is owned by the daemon
+func (s *PersistentSession) IsBrokered() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.isBrokered()
 }
 
 // GetInfo returns session information
@@ -452,11 +667,13 @@ func (s *PersistentSession) GetInfo() SessionInfo {
 		LastActivity:   s.lastActivity,
 		Endpoints:      s.endpoints,
 		Error:          s.error,
+		SessionMetrics: s.metrics,
 		Config:         s.config,
 	}
 }
 
-// IsExpired checks if the session has expired based on the maximum idle time
+// IsExpired checks if the session has been idle longer than maxIdleTime seconds.
+// A maxIdleTime of 0 or less disables expiry.
 func (s *PersistentSession) IsExpired(maxIdleTime int) bool {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -473,15 +690,34 @@ func (s *PersistentSession) IsExpired(maxIdleTime int) bool {
 	return idleTime > time.Duration(maxIdleTime)*time.Second
 }
 
-// SetError sets the session status to error with the given message
+// SetError sets the session status to error with the given message and
+// persists it
 func (s *PersistentSession) SetError(err error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	s.status = Error
 	if err != nil {
-		s.error = err.Error()
+		s.recordError(err.Error())
 	} else {
-		s.error = "unknown error"
+		s.recordError("unknown error")
+	}
+
+	if s.sessionID != "" {
+		sessionInfo := s.buildSessionInfo()
+		s.saveToStoreAsyncWithInfo(&sessionInfo)
+	}
+}
+
+// ResetStats clears the session's metrics, including its restart count and
+// last error, and persists them
+func (s *PersistentSession) ResetStats() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.metrics.Reset()
+	if s.sessionID != "" {
+		sessionInfo := s.buildSessionInfo()
+		s.saveToStoreAsyncWithInfo(&sessionInfo)
 	}
 }
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/pkg/version"
+)
+
+// Probes that show a server is alive, from the cheapest
+const (
+	ProbePing       = "ping"
+	ProbeInitialize = "initialize"
+	ProbeListTools  = "tools/list"
+)
+
+// ProbeExtraKey is where ConnectionInfo.Extra records the probe that last
+// reached a session's server
+const ProbeExtraKey = "probe"
+
+// ProbeOrder returns the probes that can check client's server, in the order
+// Probe tries them: ping, when the client can send arbitrary requests, then
+// initialize, which is cheap and idempotent, and last tools/list, which on
+// servers with hundreds of tools transfers them all
+func ProbeOrder(client mcp.MCPClient) []string {
+	if _, ok := client.(mcp.RequestSender); ok {
+		return []string{ProbePing, ProbeInitialize, ProbeListTools}
+	}
+	return []string{ProbeInitialize, ProbeListTools}
+}
+
+// Probe checks that client's server answers, trying ProbeOrder's probes until
+// one succeeds, and returns that probe. When none does, the last one's error
+// is returned.
+func Probe(ctx context.Context, client mcp.MCPClient) (string, error) {
+	var err error
+	for _, probe := range ProbeOrder(client) {
+		if err = runProbe(ctx, client, probe); err == nil {
+			return probe, nil
+		}
+		if ctx.Err() != nil {
+			break // Later probes would fail the same way
+		}
+	}
+	return "", err
+}
+
+// runProbe sends one probe
+func runProbe(ctx context.Context, client mcp.MCPClient, probe string) error {
+	switch probe {
+	case ProbePing:
+		_, err := client.(mcp.RequestSender).SendRequest(ctx, "ping", nil)
+		return err
+	case ProbeInitialize:
+		_, err := client.Initialize(ctx, &mcp.InitializeParams{
+			ProtocolVersion: mcp.ProtocolVersion,
+			ClientInfo: mcp.ClientInfo{
+				Name:    "mcp-cli-ent",
+				Version: version.Version,
+			},
+		})
+		return err
+	case ProbeListTools:
+		_, err := client.ListTools(ctx)
+		return err
+	default:
+		return fmt.Errorf("unknown probe %s", probe)
+	}
+}
@@ -0,0 +1,127 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// probedClient records the requests probes send, failing those in fail
+type probedClient struct {
+	fakeClient
+	fail map[string]bool
+	sent []string
+}
+
+func newProbedClient(fail ...string) *probedClient {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	c := &probedClient{fakeClient: fakeClient{healthy: &healthy}, fail: make(map[string]bool)}
+	for _, method := range fail {
+		c.fail[method] = true
+	}
+	return c
+}
+
+func (c *probedClient) answer(method string) error {
+	c.sent = append(c.sent, method)
+	if c.fail[method] {
+		return errors.New(method + " failed")
+	}
+	return nil
+}
+
+func (c *probedClient) Initialize(context.Context, *mcp.InitializeParams) (*mcp.InitializeResult, error) {
+	return &mcp.InitializeResult{}, c.answer(ProbeInitialize)
+}
+
+func (c *probedClient) ListTools(context.Context) ([]mcp.Tool, error) {
+	return nil, c.answer(ProbeListTools)
+}
+
+// pingingClient can also send ping
+type pingingClient struct {
+	*probedClient
+}
+
+func (c pingingClient) SendRequest(_ context.Context, method string, _ interface{}) (json.RawMessage, error) {
+	return json.RawMessage(`{}`), c.answer(method)
+}
+
+func TestProbeFallbackOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		canPing   bool
+		fail      []string
+		wantProbe string
+		wantSent  []string
+	}{
+		{"ping", true, nil, ProbePing, []string{"ping"}},
+		{"ping unsupported", true, []string{"ping"}, ProbeInitialize, []string{"ping", "initialize"}},
+		{"no arbitrary requests", false, nil, ProbeInitialize, []string{"initialize"}},
+		{"initialize refused", false, []string{"initialize"}, ProbeListTools, []string{"initialize", "tools/list"}},
+		{"only tools/list", true, []string{"ping", "initialize"}, ProbeListTools, []string{"ping", "initialize", "tools/list"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newProbedClient(tt.fail...)
+			var probed mcp.MCPClient = client
+			if tt.canPing {
+				probed = pingingClient{client}
+			}
+
+			probe, err := Probe(context.Background(), probed)
+			if err != nil {
+				t.Fatalf("Probe failed: %v", err)
+			}
+			if probe != tt.wantProbe || !reflect.DeepEqual(client.sent, tt.wantSent) {
+				t.Errorf("got %s after %q, want %s after %q", probe, client.sent, tt.wantProbe, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestProbeFailsWhenNothingAnswers(t *testing.T) {
+	client := newProbedClient("ping", "initialize", "tools/list")
+	if _, err := Probe(context.Background(), pingingClient{client}); err == nil || err.Error() != "tools/list failed" {
+		t.Errorf("expected the last probe's error, got %v", err)
+	}
+
+	// Once the deadline has passed, later probes are not sent
+	client = newProbedClient("ping")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Probe(ctx, pingingClient{client}); err == nil || len(client.sent) != 1 {
+		t.Errorf("expected one probe before giving up, sent %q (%v)", client.sent, err)
+	}
+}
+
+func TestHealthCheckRecordsProbe(t *testing.T) {
+	manager, err := NewManager(t.TempDir(), func(config.ServerConfig) (mcp.MCPClient, error) {
+		return pingingClient{newProbedClient("ping")}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+	t.Cleanup(func() { _ = manager.Close() })
+
+	sess, err := manager.GetSession("chrome-devtools", monitoredConfig(false))
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	persistent := sess.(*PersistentSession)
+	if err := persistent.HealthCheck(); err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+
+	info := persistent.GetInfo()
+	if info.ConnectionInfo == nil || info.ConnectionInfo.Extra[ProbeExtraKey] != ProbeInitialize {
+		t.Errorf("expected the initialize probe to be recorded, got %+v", info.ConnectionInfo)
+	}
+}
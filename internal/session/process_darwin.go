@@ -0,0 +1,74 @@
+//go:build darwin
+
+package session
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// nativeFindProcess reads process information via sysctl kern.proc
+func nativeFindProcess(pid int) (*ProcessInfo, error) {
+	kinfo, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query process %d: %w", pid, err)
+	}
+	if int(kinfo.Proc.P_pid) != pid {
+		return nil, fmt.Errorf("process %d not found", pid)
+	}
+
+	executable, args := processArgs(pid, kinfo)
+	sec, nsec := kinfo.Proc.P_starttime.Unix()
+
+	return &ProcessInfo{
+		PID:        pid,
+		Executable: executable,
+		Args:       args,
+		CmdLine:    strings.Join(args, " "),
+		ParentPID:  int(kinfo.Eproc.Ppid),
+		CreateTime: time.Unix(sec, nsec),
+	}, nil
+}
+
+// nativeProcessTable lists all processes via sysctl kern.proc.all
+func nativeProcessTable() ([]processEntry, error) {
+	kinfos, err := unix.SysctlKinfoProcSlice("kern.proc.all")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	table := make([]processEntry, 0, len(kinfos))
+	for i := range kinfos {
+		pid := int(kinfos[i].Proc.P_pid)
+		_, args := processArgs(pid, &kinfos[i])
+		table = append(table, processEntry{
+			PID:       pid,
+			ParentPID: int(kinfos[i].Eproc.Ppid),
+			CmdLine:   strings.Join(args, " "),
+		})
+	}
+
+	return table, nil
+}
+
+// processArgs reads a process's executable and arguments, falling back to its
+// short command name when kern.procargs2 is not permitted
+func processArgs(pid int, kinfo *unix.KinfoProc) (string, []string) {
+	if data, err := unix.SysctlRaw("kern.procargs2", pid); err == nil {
+		if executable, args, err := parseProcArgs2(data); err == nil {
+			return executable, args
+		}
+	}
+
+	comm := string(bytes.TrimRight(kinfo.Proc.P_comm[:], "\x00"))
+	return comm, []string{comm}
+}
+
+// nativeProcessAlive is not needed here; signal 0 already checks liveness natively
+func nativeProcessAlive(pid int) (bool, error) {
+	return false, errNativeUnsupported
+}
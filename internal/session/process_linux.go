@@ -0,0 +1,107 @@
+//go:build linux
+
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nativeFindProcess reads process information from /proc
+func nativeFindProcess(pid int) (*ProcessInfo, error) {
+	return findProcessProcFS("/proc", pid)
+}
+
+// nativeProcessTable lists all processes from /proc
+func nativeProcessTable() ([]processEntry, error) {
+	return processTableProcFS("/proc")
+}
+
+// findProcessProcFS reads process info from a /proc filesystem rooted at procRoot
+func findProcessProcFS(procRoot string, pid int) (*ProcessInfo, error) {
+	procDir := filepath.Join(procRoot, strconv.Itoa(pid))
+
+	// Get executable
+	execPath, err := os.Readlink(filepath.Join(procDir, "exe"))
+	if err != nil {
+		execPath = "unknown"
+	}
+
+	// Get command line
+	cmdlineBytes, err := os.ReadFile(filepath.Join(procDir, "cmdline"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cmdline: %w", err)
+	}
+	args := parseProcCmdline(cmdlineBytes)
+
+	// Get stat info for parent PID and creation time
+	statBytes, err := os.ReadFile(filepath.Join(procDir, "stat"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stat: %w", err)
+	}
+
+	parentPID, startTicks, err := parseProcStat(statBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var createTime time.Time
+	if statData, err := os.ReadFile(filepath.Join(procRoot, "stat")); err == nil {
+		if bootTime, err := parseBootTime(statData); err == nil {
+			createTime = bootTime.Add(time.Duration(startTicks) * time.Second / procClockTicks)
+		}
+	}
+
+	return &ProcessInfo{
+		PID:        pid,
+		Executable: execPath,
+		Args:       args,
+		CmdLine:    strings.Join(args, " "),
+		ParentPID:  parentPID,
+		CreateTime: createTime,
+	}, nil
+}
+
+// processTableProcFS lists processes from a /proc filesystem rooted at procRoot
+func processTableProcFS(procRoot string) ([]processEntry, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", procRoot, err)
+	}
+
+	var table []processEntry
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		// Processes can exit while we scan; skip any we can no longer read
+		statBytes, err := os.ReadFile(filepath.Join(procRoot, entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		parentPID, _, err := parseProcStat(statBytes)
+		if err != nil {
+			continue
+		}
+
+		cmdlineBytes, _ := os.ReadFile(filepath.Join(procRoot, entry.Name(), "cmdline"))
+		table = append(table, processEntry{
+			PID:       pid,
+			ParentPID: parentPID,
+			CmdLine:   strings.Join(parseProcCmdline(cmdlineBytes), " "),
+		})
+	}
+
+	return table, nil
+}
+
+// nativeProcessAlive is not needed here; signal 0 already checks liveness natively
+func nativeProcessAlive(pid int) (bool, error) {
+	return false, errNativeUnsupported
+}
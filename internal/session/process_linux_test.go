@@ -0,0 +1,65 @@
+//go:build linux
+
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeProcFixture creates a fake /proc entry for pid
+func writeProcFixture(t *testing.T, root string, pid, stat, cmdline string) {
+	t.Helper()
+	dir := filepath.Join(root, pid)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcFSFixture(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "stat"), []byte("cpu 1 2 3\nbtime 1700000000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeProcFixture(t, root, "100", "100 (mcp-cli) S 1 100 100 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 500 0 0\n", "mcp-cli\x00daemon\x00start\x00")
+	writeProcFixture(t, root, "200", "200 (node) S 100 200 100 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 900 0 0\n", "npx\x00-y\x00chrome-devtools-mcp@latest\x00")
+	if err := os.MkdirAll(filepath.Join(root, "self"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := findProcessProcFS(root, 200)
+	if err != nil {
+		t.Fatalf("findProcessProcFS failed: %v", err)
+	}
+	if info.ParentPID != 100 || info.CmdLine != "npx -y chrome-devtools-mcp@latest" || info.Executable != "unknown" {
+		t.Errorf("unexpected process info %+v", info)
+	}
+	if want := time.Unix(1700000000, 0).Add(9 * time.Second); !info.CreateTime.Equal(want) {
+		t.Errorf("expected create time %v, got %v", want, info.CreateTime)
+	}
+
+	if _, err := findProcessProcFS(root, 300); err == nil {
+		t.Error("expected error for missing process")
+	}
+
+	table, err := processTableProcFS(root)
+	if err != nil {
+		t.Fatalf("processTableProcFS failed: %v", err)
+	}
+	want := []processEntry{
+		{PID: 100, ParentPID: 1, CmdLine: "mcp-cli daemon start"},
+		{PID: 200, ParentPID: 100, CmdLine: "npx -y chrome-devtools-mcp@latest"},
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("expected %+v, got %+v", want, table)
+	}
+}
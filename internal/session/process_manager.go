@@ -1,6 +1,7 @@
 package session
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,6 +10,8 @@ import (
 	"strings"
 	"syscall"
 	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/logging"
 )
 
 // ProcessManager handles cross-platform process detection and management
@@ -62,14 +65,23 @@ func (pm *ProcessManager) isProcessAliveUnix(pid int) bool {
 
 // isProcessAliveWindows checks if process is alive on Windows
 func (pm *ProcessManager) isProcessAliveWindows(pid int) bool {
-	// On Windows, we can use tasklist command
+	if alive, err := nativeProcessAlive(pid); err == nil {
+		return alive
+	}
+
+	// Fall back to the tasklist command
 	cmd := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV")
 	output, err := cmd.Output()
 	if err != nil {
 		return false
 	}
 
-	return strings.Contains(string(output), strconv.Itoa(pid))
+	for _, listed := range parseTasklistPIDs(string(output)) {
+		if listed == pid {
+			return true
+		}
+	}
+	return false
 }
 
 // FindProcess finds a process by PID and returns detailed information
@@ -78,80 +90,29 @@ func (pm *ProcessManager) FindProcess(pid int) (*ProcessInfo, error) {
 		return nil, fmt.Errorf("process %d is not alive", pid)
 	}
 
+	// Prefer native inspection (/proc, sysctl, Windows API)
+	info, err := nativeFindProcess(pid)
+	if err == nil {
+		return info, nil
+	}
+
+	// Platform commands are a last resort
+	var fallback *ProcessInfo
+	var fallbackErr error
 	switch pm.platform {
 	case "windows":
-		return pm.findProcessWindows(pid)
+		fallback, fallbackErr = pm.findProcessWindows(pid)
 	default:
-		return pm.findProcessUnix(pid)
-	}
-}
-
-// findProcessUnix gets process information on Unix-like systems
-func (pm *ProcessManager) findProcessUnix(pid int) (*ProcessInfo, error) {
-	// Read from /proc filesystem if available
-	if _, err := os.Stat("/proc"); err == nil {
-		return pm.findProcessProcFS(pid)
-	}
-
-	// Fallback to ps command
-	return pm.findProcessPs(pid)
-}
-
-// findProcessProcFS reads process info from /proc filesystem
-func (pm *ProcessManager) findProcessProcFS(pid int) (*ProcessInfo, error) {
-	// Get executable
-	execPath, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
-	if err != nil {
-		execPath = "unknown"
-	}
-
-	// Get command line
-	cmdlineBytes, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
-	if err != nil {
-		return nil, fmt.Errorf("failed to read cmdline: %w", err)
-	}
-
-	cmdline := string(cmdlineBytes)
-	// Replace null bytes with spaces for readability
-	cmdline = strings.ReplaceAll(cmdline, "\x00", " ")
-	cmdline = strings.TrimSpace(cmdline)
-
-	// Get stat info for parent PID and creation time
-	statBytes, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
-	if err != nil {
-		return nil, fmt.Errorf("failed to read stat: %w", err)
-	}
-
-	statFields := strings.Fields(string(statBytes))
-	if len(statFields) < 4 {
-		return nil, fmt.Errorf("invalid stat format")
+		fallback, fallbackErr = pm.findProcessPs(pid)
 	}
-
-	parentPID := 0
-	if len(statFields) > 3 {
-		if ppid, err := strconv.Atoi(statFields[3]); err == nil {
-			parentPID = ppid
-		}
+	if fallbackErr == nil {
+		return fallback, nil
 	}
 
-	// Parse creation time (field 22 in stat)
-	var createTime time.Time
-	if len(statFields) > 21 {
-		if clockTicks, err := strconv.Atoi(statFields[21]); err == nil {
-			// Convert clock ticks to seconds (rough approximation)
-			secondsSinceBoot := clockTicks / 100 // Assuming 100Hz clock
-			createTime = time.Now().Add(-time.Duration(secondsSinceBoot) * time.Second)
-		}
+	if errors.Is(err, errNativeUnsupported) {
+		return nil, fallbackErr
 	}
-
-	return &ProcessInfo{
-		PID:        pid,
-		Executable: execPath,
-		Args:       strings.Fields(cmdline),
-		CmdLine:    cmdline,
-		ParentPID:  parentPID,
-		CreateTime: createTime,
-	}, nil
+	return nil, err
 }
 
 // findProcessPs uses ps command to get process info
@@ -253,6 +214,15 @@ func (pm *ProcessManager) findProcessWindows(pid int) (*ProcessInfo, error) {
 
 // GetProcessChildren finds all child processes of the given PID
 func (pm *ProcessManager) GetProcessChildren(pid int) ([]int, error) {
+	if table, err := nativeProcessTable(); err == nil {
+		children := childPIDs(table, pid)
+		if children == nil {
+			children = []int{}
+		}
+		return children, nil
+	}
+
+	// Fall back to platform commands
 	switch pm.platform {
 	case "windows":
 		return pm.getProcessChildrenWindows(pid)
@@ -334,7 +304,7 @@ func (pm *ProcessManager) TerminateProcessTree(pid int) error {
 	for _, childPID := range children {
 		if err := pm.TerminateProcessTree(childPID); err != nil {
 			// Log but continue with other children
-			fmt.Printf("Warning: failed to terminate child process %d: %v\n", childPID, err)
+			logging.Warn("failed to terminate child process", "pid", childPID, "error", err)
 		}
 	}
 
@@ -403,6 +373,25 @@ func (pm *ProcessManager) FindBrowserProcesses() ([]*ProcessInfo, error) {
 
 // findProcessesByPattern finds processes matching a pattern
 func (pm *ProcessManager) findProcessesByPattern(pattern string) ([]*ProcessInfo, error) {
+	if table, err := nativeProcessTable(); err == nil {
+		pids, err := matchingPIDs(table, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		processes := []*ProcessInfo{}
+		for _, pid := range pids {
+			if pid == os.Getpid() {
+				continue // Like pgrep, never match ourselves
+			}
+			if procInfo, err := pm.FindProcess(pid); err == nil {
+				processes = append(processes, procInfo)
+			}
+		}
+		return processes, nil
+	}
+
+	// Fall back to platform commands
 	switch pm.platform {
 	case "windows":
 		return pm.findProcessesByPatternWindows(pattern)
@@ -445,27 +434,10 @@ func (pm *ProcessManager) findProcessesByPatternWindows(pattern string) ([]*Proc
 		return []*ProcessInfo{}, nil
 	}
 
-	lines := strings.Split(string(output), "\n")
 	var processes []*ProcessInfo
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Parse CSV format: "process.exe","PID","session","session#","mem usage"
-		fields := strings.Split(line, ",")
-		if len(fields) < 2 {
-			continue
-		}
-
-		// Extract PID from second field, removing quotes
-		pidStr := strings.Trim(fields[1], "\"")
-		if pid, err := strconv.Atoi(pidStr); err == nil {
-			if procInfo, err := pm.FindProcess(pid); err == nil {
-				processes = append(processes, procInfo)
-			}
+	for _, pid := range parseTasklistPIDs(string(output)) {
+		if procInfo, err := pm.FindProcess(pid); err == nil {
+			processes = append(processes, procInfo)
 		}
 	}
 
@@ -0,0 +1,161 @@
+package session
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestParseProcStat(t *testing.T) {
+	// The command name contains spaces and parentheses
+	stat := []byte("4242 (node (mcp) srv) S 4100 4242 4100 0 -1 4194560 1024 0 0 0 12 3 0 0 20 0 11 0 987654 1234567 2048 18446744073709551615\n")
+
+	parentPID, startTicks, err := parseProcStat(stat)
+	if err != nil {
+		t.Fatalf("parseProcStat failed: %v", err)
+	}
+	if parentPID != 4100 || startTicks != 987654 {
+		t.Errorf("expected ppid 4100 and start 987654, got %d and %d", parentPID, startTicks)
+	}
+
+	if _, _, err := parseProcStat([]byte("4242 (truncated")); err == nil {
+		t.Error("expected error for malformed stat")
+	}
+}
+
+func TestParseProcCmdline(t *testing.T) {
+	got := parseProcCmdline([]byte("npx\x00-y\x00chrome-devtools-mcp@latest\x00"))
+	want := []string{"npx", "-y", "chrome-devtools-mcp@latest"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if got := parseProcCmdline(nil); got != nil {
+		t.Errorf("expected no args for kernel thread, got %v", got)
+	}
+}
+
+func TestParseBootTime(t *testing.T) {
+	stat := []byte("cpu  1 2 3 4\nintr 0\nbtime 1700000000\nprocesses 42\n")
+
+	got, err := parseBootTime(stat)
+	if err != nil {
+		t.Fatalf("parseBootTime failed: %v", err)
+	}
+	if !got.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected boot time %v", got)
+	}
+
+	if _, err := parseBootTime([]byte("cpu 1 2 3\n")); err == nil {
+		t.Error("expected error without btime")
+	}
+}
+
+func TestParseProcArgs2(t *testing.T) {
+	// argc=3, exec path, alignment padding, args, then environment
+	data := []byte{3, 0, 0, 0}
+	data = append(data, "/usr/local/bin/node\x00\x00\x00\x00"...)
+	data = append(data, "node\x00server.js\x00--stdio\x00PATH=/usr/bin\x00"...)
+
+	executable, args, err := parseProcArgs2(data)
+	if err != nil {
+		t.Fatalf("parseProcArgs2 failed: %v", err)
+	}
+	if executable != "/usr/local/bin/node" {
+		t.Errorf("unexpected executable %q", executable)
+	}
+	want := []string{"node", "server.js", "--stdio"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("expected %v, got %v", want, args)
+	}
+}
+
+func TestParseTasklistPIDs(t *testing.T) {
+	output := "\"node.exe\",\"1234\",\"Console\",\"1\",\"45,120 K\"\r\n" +
+		"\"npx.cmd\",\"56\",\"Console\",\"1\",\"1,024 K\"\r\n"
+
+	got := parseTasklistPIDs(output)
+	if !reflect.DeepEqual(got, []int{1234, 56}) {
+		t.Errorf("expected [1234 56], got %v", got)
+	}
+
+	// tasklist reports no matches as plain text
+	if got := parseTasklistPIDs("INFO: No tasks are running which match the specified criteria.\r\n"); got != nil {
+		t.Errorf("expected no PIDs, got %v", got)
+	}
+}
+
+func TestChildAndMatchingPIDs(t *testing.T) {
+	table := []processEntry{
+		{PID: 1, ParentPID: 0, CmdLine: "init"},
+		{PID: 10, ParentPID: 1, CmdLine: "mcp-cli daemon start"},
+		{PID: 11, ParentPID: 10, CmdLine: "npx -y chrome-devtools-mcp@latest"},
+		{PID: 12, ParentPID: 10, CmdLine: "uvx mcp-server-time"},
+	}
+
+	if got := childPIDs(table, 10); !reflect.DeepEqual(got, []int{11, 12}) {
+		t.Errorf("expected children [11 12], got %v", got)
+	}
+
+	got, err := matchingPIDs(table, "chrome-devtools-mcp")
+	if err != nil {
+		t.Fatalf("matchingPIDs failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []int{11}) {
+		t.Errorf("expected [11], got %v", got)
+	}
+
+	if _, err := matchingPIDs(table, "("); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestProcessManagerInspectsCurrentProcess(t *testing.T) {
+	pm := NewProcessManager()
+	pid := os.Getpid()
+
+	if !pm.IsProcessAlive(pid) {
+		t.Fatal("expected the test process to be alive")
+	}
+
+	info, err := pm.FindProcess(pid)
+	if err != nil {
+		t.Fatalf("FindProcess failed: %v", err)
+	}
+	if info.PID != pid || info.ParentPID != os.Getppid() {
+		t.Errorf("expected pid %d with parent %d, got %+v", pid, os.Getppid(), info)
+	}
+	if len(info.Args) == 0 {
+		t.Error("expected command line arguments")
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	child := exec.Command("sleep", "30")
+	if err := child.Start(); err != nil {
+		t.Skipf("cannot spawn child process: %v", err)
+	}
+	defer func() {
+		_ = child.Process.Kill()
+		_ = child.Wait()
+	}()
+
+	children, err := pm.GetProcessChildren(pid)
+	if err != nil {
+		t.Fatalf("GetProcessChildren failed: %v", err)
+	}
+	found := false
+	for _, c := range children {
+		if c == child.Process.Pid {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected child %d among %v", child.Process.Pid, children)
+	}
+}
@@ -0,0 +1,169 @@
+package session
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNativeUnsupported is returned when the platform has no native process inspection
+var errNativeUnsupported = errors.New("native process inspection not supported on this platform")
+
+// procClockTicks is USER_HZ, the unit of /proc/<pid>/stat start times on Linux
+const procClockTicks = 100
+
+// processEntry is a row of the system process table
+type processEntry struct {
+	PID       int
+	ParentPID int
+	CmdLine   string // Full command line, or the executable name if unavailable
+}
+
+// childPIDs returns the PIDs whose parent is pid
+func childPIDs(table []processEntry, pid int) []int {
+	var children []int
+	for _, entry := range table {
+		if entry.ParentPID == pid && entry.PID != pid {
+			children = append(children, entry.PID)
+		}
+	}
+	return children
+}
+
+// matchingPIDs returns the PIDs whose command line matches pattern, like pgrep -f
+func matchingPIDs(table []processEntry, pattern string) ([]int, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid process pattern: %w", err)
+	}
+
+	var pids []int
+	for _, entry := range table {
+		if re.MatchString(entry.CmdLine) {
+			pids = append(pids, entry.PID)
+		}
+	}
+	return pids, nil
+}
+
+// parseProcStat extracts the parent PID and start time (clock ticks after
+// boot) from the contents of /proc/<pid>/stat
+func parseProcStat(data []byte) (int, uint64, error) {
+	// The command name is wrapped in parentheses and may itself contain spaces
+	// or parentheses, so fields are counted from the last ')'
+	stat := string(data)
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, 0, fmt.Errorf("invalid stat format")
+	}
+
+	// fields[0] is the state (field 3), so field N lives at index N-3
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 20 {
+		return 0, 0, fmt.Errorf("invalid stat format")
+	}
+
+	parentPID, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid parent PID: %w", err)
+	}
+
+	startTicks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start time: %w", err)
+	}
+
+	return parentPID, startTicks, nil
+}
+
+// parseProcCmdline splits the NUL-separated contents of /proc/<pid>/cmdline
+func parseProcCmdline(data []byte) []string {
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return nil
+	}
+
+	parts := bytes.Split(data, []byte{0})
+	args := make([]string, 0, len(parts))
+	for _, part := range parts {
+		args = append(args, string(part))
+	}
+	return args
+}
+
+// parseBootTime extracts the boot time from the contents of /proc/stat
+func parseBootTime(data []byte) (time.Time, error) {
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "btime" {
+			seconds, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid btime: %w", err)
+			}
+			return time.Unix(seconds, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("btime not found")
+}
+
+// parseProcArgs2 decodes the kern.procargs2 sysctl used on macOS: a native
+// endian argc, the executable path, NUL padding, then argc NUL-terminated args
+func parseProcArgs2(data []byte) (string, []string, error) {
+	if len(data) < 4 {
+		return "", nil, fmt.Errorf("procargs too short")
+	}
+
+	argc := int(binary.LittleEndian.Uint32(data[:4]))
+	rest := data[4:]
+
+	end := bytes.IndexByte(rest, 0)
+	if end < 0 {
+		return "", nil, fmt.Errorf("unterminated executable path")
+	}
+	executable := string(rest[:end])
+	rest = bytes.TrimLeft(rest[end:], "\x00")
+
+	args := make([]string, 0, argc)
+	for len(args) < argc && len(rest) > 0 {
+		end = bytes.IndexByte(rest, 0)
+		if end < 0 {
+			end = len(rest)
+		}
+		args = append(args, string(rest[:end]))
+		if end == len(rest) {
+			break
+		}
+		rest = rest[end+1:]
+	}
+
+	return executable, args, nil
+}
+
+// parseTasklistPIDs extracts PIDs from `tasklist /FO CSV /NH` output
+func parseTasklistPIDs(output string) []int {
+	reader := csv.NewReader(strings.NewReader(output))
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil
+	}
+
+	var pids []int
+	for _, record := range records {
+		// "Image Name","PID","Session Name","Session#","Mem Usage"
+		if len(record) < 2 {
+			continue
+		}
+		if pid, err := strconv.Atoi(strings.TrimSpace(record[1])); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
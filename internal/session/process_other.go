@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+
+package session
+
+// nativeFindProcess is not available on this platform
+func nativeFindProcess(pid int) (*ProcessInfo, error) {
+	return nil, errNativeUnsupported
+}
+
+// nativeProcessTable is not available on this platform
+func nativeProcessTable() ([]processEntry, error) {
+	return nil, errNativeUnsupported
+}
+
+// nativeProcessAlive is not available on this platform
+func nativeProcessAlive(pid int) (bool, error) {
+	return false, errNativeUnsupported
+}
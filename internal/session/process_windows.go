@@ -0,0 +1,149 @@
+//go:build windows
+
+package session
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code Windows reports for running processes
+const stillActive = 259
+
+// nativeProcessAlive checks a process with OpenProcess/GetExitCodeProcess
+func nativeProcessAlive(pid int) (bool, error) {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// The process exists but belongs to someone we can't inspect
+		if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+			return true, nil
+		}
+		return false, nil
+	}
+	defer func() { _ = windows.CloseHandle(handle) }()
+
+	var exitCode uint32
+	if err := windows.GetExitCodeProcess(handle, &exitCode); err != nil {
+		return false, fmt.Errorf("failed to get exit code: %w", err)
+	}
+	return exitCode == stillActive, nil
+}
+
+// nativeFindProcess reads process information via the Windows API
+func nativeFindProcess(pid int) (*ProcessInfo, error) {
+	table, err := nativeProcessTable()
+	if err != nil {
+		return nil, err
+	}
+
+	parentPID := -1
+	for _, entry := range table {
+		if entry.PID == pid {
+			parentPID = entry.ParentPID
+			break
+		}
+	}
+	if parentPID < 0 {
+		return nil, fmt.Errorf("process %d not found", pid)
+	}
+
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open process %d: %w", pid, err)
+	}
+	defer func() { _ = windows.CloseHandle(handle) }()
+
+	executable, err := processImageName(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	cmdline, err := processCommandLine(handle)
+	if err != nil {
+		cmdline = executable // Requires Windows 8.1+
+	}
+
+	var createTime time.Time
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err == nil {
+		createTime = time.Unix(0, creation.Nanoseconds())
+	}
+
+	return &ProcessInfo{
+		PID:        pid,
+		Executable: executable,
+		Args:       strings.Fields(cmdline),
+		CmdLine:    cmdline,
+		ParentPID:  parentPID,
+		CreateTime: createTime,
+	}, nil
+}
+
+// nativeProcessTable lists all processes with a toolhelp snapshot
+func nativeProcessTable() ([]processEntry, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot processes: %w", err)
+	}
+	defer func() { _ = windows.CloseHandle(snapshot) }()
+
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	if err := windows.Process32First(snapshot, &entry); err != nil {
+		return nil, fmt.Errorf("failed to read process snapshot: %w", err)
+	}
+
+	var table []processEntry
+	for {
+		table = append(table, processEntry{
+			PID:       int(entry.ProcessID),
+			ParentPID: int(entry.ParentProcessID),
+			CmdLine:   windows.UTF16ToString(entry.ExeFile[:]),
+		})
+
+		if err := windows.Process32Next(snapshot, &entry); err != nil {
+			if errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+				break
+			}
+			return nil, fmt.Errorf("failed to read process snapshot: %w", err)
+		}
+	}
+
+	return table, nil
+}
+
+// processImageName returns the full executable path of a process
+func processImageName(handle windows.Handle) (string, error) {
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(handle, 0, &buf[0], &size); err != nil {
+		return "", fmt.Errorf("failed to query executable: %w", err)
+	}
+	return windows.UTF16ToString(buf[:size]), nil
+}
+
+// processCommandLine returns the command line of a process
+func processCommandLine(handle windows.Handle) (string, error) {
+	buf := make([]byte, 1024)
+	for {
+		var needed uint32
+		err := windows.NtQueryInformationProcess(handle, windows.ProcessCommandLineInformation,
+			unsafe.Pointer(&buf[0]), uint32(len(buf)), &needed)
+		if err == nil {
+			break
+		}
+		if (errors.Is(err, windows.STATUS_INFO_LENGTH_MISMATCH) || errors.Is(err, windows.STATUS_BUFFER_TOO_SMALL) ||
+			errors.Is(err, windows.STATUS_BUFFER_OVERFLOW)) && int(needed) > len(buf) {
+			buf = make([]byte, needed)
+			continue
+		}
+		return "", fmt.Errorf("failed to query command line: %w", err)
+	}
+
+	return (*windows.NTUnicodeString)(unsafe.Pointer(&buf[0])).String(), nil
+}
@@ -12,13 +12,15 @@ import (
 
 // StatelessSession represents a stateless session that creates clients on demand
 type StatelessSession struct {
-	name          string
-	config        config.ServerConfig
-	sessionType   SessionType
-	status        SessionStatus
-	clientFactory ClientFactory
-	mutex         sync.RWMutex
-	lastActivity  time.Time
+	name           string
+	config         config.ServerConfig
+	sessionType    SessionType
+	status         SessionStatus
+	clientFactory  ClientFactory
+	mutex          sync.RWMutex
+	lastActivity   time.Time
+	fallbackReason string
+	metrics        SessionMetrics
 }
 
 // NewStatelessSession creates a new stateless session
@@ -35,6 +37,23 @@ func NewStatelessSession(name string, serverConfig config.ServerConfig, clientFa
 	return session, nil
 }
 
+// NewFallbackSession creates a stateless session standing in for a hybrid
+// session whose persistent start failed
+func NewFallbackSession(name string, serverConfig config.ServerConfig, clientFactory ClientFactory, reason error) (*StatelessSession, error) {
+	session, err := NewStatelessSession(name, serverConfig, clientFactory)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason != nil {
+		session.fallbackReason = reason.Error()
+	} else {
+		session.fallbackReason = "persistent session unavailable"
+	}
+
+	return session, nil
+}
+
 // Name returns the session name
 func (s *StatelessSession) Name() string {
 	return s.name
@@ -54,22 +73,22 @@ func (s *StatelessSession) Status() SessionStatus {
 	return s.status
 }
 
-// Client returns the MCP client for this session
-func (s *StatelessSession) Client() mcp.MCPClient {
-	// For stateless sessions, we create a new client each time
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+// Client creates a new MCP client for this session; the caller must close it
+func (s *StatelessSession) Client() (mcp.MCPClient, error) {
+	s.mutex.RLock()
+	factory := s.clientFactory
+	s.mutex.RUnlock()
 
-	if s.clientFactory == nil {
-		return nil // Return nil on error, caller should handle this
+	if factory == nil {
+		return nil, fmt.Errorf("no client factory for session %s", s.name)
 	}
 
-	client, err := s.clientFactory(s.config)
+	client, err := factory(s.config)
 	if err != nil {
-		return nil // Return nil on error, caller should handle this
+		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
 
-	return client
+	return client, nil
 }
 
 // Config returns the server configuration
@@ -97,18 +116,17 @@ func (s *StatelessSession) Restart() error {
 
 // HealthCheck performs a health check by creating a temporary client
 func (s *StatelessSession) HealthCheck() error {
-	client := s.Client()
-	if client == nil {
-		return fmt.Errorf("failed to create client for health check")
+	client, err := s.Client()
+	if err != nil {
+		return fmt.Errorf("failed to create client for health check: %w", err)
 	}
 	defer func() { _ = client.Close() }()
 
-	// Perform a simple health check by listing tools
+	// Perform a health check with the cheapest probe the server answers
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	_, err := client.ListTools(ctx)
-	if err != nil {
+	if _, err := Probe(ctx, client); err != nil {
 		return fmt.Errorf("health check failed: %w", err)
 	}
 
@@ -131,3 +149,45 @@ func (s *StatelessSession) UpdateActivity() {
 	defer s.mutex.Unlock()
 	s.lastActivity = time.Now()
 }
+
+// RecordToolCall updates the in-memory metrics (stateless sessions are not persisted)
+func (s *StatelessSession) RecordToolCall(toolName string, duration time.Duration, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.metrics.Record(toolName, duration, err)
+	s.lastActivity = time.Now()
+}
+
+// ResetStats clears the in-memory metrics
+func (s *StatelessSession) ResetStats() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.metrics.Reset()
+}
+
+// FallbackReason returns why a hybrid session was downgraded to stateless, if it was
+func (s *StatelessSession) FallbackReason() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.fallbackReason
+}
+
+// GetInfo returns session information
+func (s *StatelessSession) GetInfo() SessionInfo {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	info := SessionInfo{
+		Name:           s.name,
+		Type:           s.sessionType,
+		Status:         s.status,
+		LastActivity:   s.lastActivity,
+		FallbackReason: s.fallbackReason,
+		SessionMetrics: s.metrics,
+		Config:         s.config,
+	}
+	if s.config.URL != "" {
+		info.Endpoints = []string{s.config.URL}
+	}
+	return info
+}
@@ -1,12 +1,19 @@
 package session
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/mcp-cli-ent/mcp-cli/internal/config"
 	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
 )
 
+// ErrNotStarted is returned by Session.Client when the session has no client
+// yet. It matches mcp.ErrSessionNotActive.
+var ErrNotStarted = mcp.Mark(errors.New("session not started"), mcp.ErrSessionNotActive)
+
 // SessionType represents the type of session
 type SessionType int
 
@@ -71,6 +78,60 @@ func (ss SessionStatus) String() string {
 	}
 }
 
+// MarshalJSON encodes the session type as its string name
+func (st SessionType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(st.String())
+}
+
+// UnmarshalJSON decodes a session type from its string name, or from the
+// legacy integer encoding used by older session files
+func (st *SessionType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var legacy int
+		if legacyErr := json.Unmarshal(data, &legacy); legacyErr != nil {
+			return fmt.Errorf("invalid session type: %s", string(data))
+		}
+		*st = SessionType(legacy)
+		return nil
+	}
+
+	for _, candidate := range []SessionType{Stateless, Persistent, Hybrid} {
+		if candidate.String() == name {
+			*st = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown session type: %s", name)
+}
+
+// MarshalJSON encodes the session status as its string name
+func (ss SessionStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ss.String())
+}
+
+// UnmarshalJSON decodes a session status from its string name, or from the
+// legacy integer encoding used by older session files
+func (ss *SessionStatus) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var legacy int
+		if legacyErr := json.Unmarshal(data, &legacy); legacyErr != nil {
+			return fmt.Errorf("invalid session status: %s", string(data))
+		}
+		*ss = SessionStatus(legacy)
+		return nil
+	}
+
+	for _, candidate := range []SessionStatus{Inactive, Starting, Active, Error, Stopping, Stopped} {
+		if candidate.String() == name {
+			*ss = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown session status: %s", name)
+}
+
 // Session represents a managed MCP client session
 type Session interface {
 	// Name returns the session name (same as server name)
@@ -82,8 +143,10 @@ type Session interface {
 	// Status returns the current session status
 	Status() SessionStatus
 
-	// Client returns the MCP client for this session
-	Client() mcp.MCPClient
+	// Client returns the MCP client for this session, or ErrNotStarted if a
+	// persistent session has no client. Stateless sessions create a new client
+	// on every call, which the caller owns and must close.
+	Client() (mcp.MCPClient, error)
 
 	// Config returns the server configuration
 	Config() config.ServerConfig
@@ -105,6 +168,9 @@ type Session interface {
 
 	// UpdateActivity updates the last activity time
 	UpdateActivity()
+
+	// RecordToolCall records a tool call's outcome in the session metrics
+	RecordToolCall(toolName string, duration time.Duration, err error)
 }
 
 // SessionInfo contains metadata about a session
@@ -121,7 +187,50 @@ type SessionInfo struct {
 	LastActivity   time.Time           `json:"lastActivity"`
 	Endpoints      []string            `json:"endpoints,omitempty"`
 	Error          string              `json:"error,omitempty"`
+	FallbackReason string              `json:"fallbackReason,omitempty"`
 	Config         config.ServerConfig `json:"config"`
+	ConfigRedacted bool                `json:"configRedacted,omitempty"` // Config holds templates, not resolved secrets
+
+	SessionMetrics
+}
+
+// SessionMetrics tracks tool usage and failures for a session. The counts
+// are only reset on request, so a server that keeps failing shows it.
+type SessionMetrics struct {
+	ToolCallCount int64         `json:"toolCallCount,omitempty"`
+	ErrorCount    int64         `json:"errorCount,omitempty"`
+	LastTool      string        `json:"lastTool,omitempty"`
+	ToolTime      time.Duration `json:"toolTime,omitempty"` // Cumulative time spent in tool calls
+
+	RestartCount  int64      `json:"restartCount,omitempty"`
+	LastError     string     `json:"lastError,omitempty"` // The session's latest error, kept after it recovers
+	LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+}
+
+// Record adds a tool call to the metrics
+func (m *SessionMetrics) Record(toolName string, duration time.Duration, err error) {
+	m.ToolCallCount++
+	if err != nil {
+		m.ErrorCount++
+	}
+	m.LastTool = toolName
+	m.ToolTime += duration
+}
+
+// RecordRestart counts a restart of the session
+func (m *SessionMetrics) RecordRestart() {
+	m.RestartCount++
+}
+
+// RecordError notes an error of the session itself, rather than of a tool call
+func (m *SessionMetrics) RecordError(message string, at time.Time) {
+	m.LastError = message
+	m.LastErrorTime = &at
+}
+
+// Reset clears the metrics
+func (m *SessionMetrics) Reset() {
+	*m = SessionMetrics{}
 }
 
 // ConnectionInfo contains connection details for session reattachment
@@ -0,0 +1,74 @@
+package session
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func TestSessionInfoGoldenFormat(t *testing.T) {
+	started := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	info := SessionInfo{
+		SessionID:    "chrome-devtools-1735787045-abc123",
+		Name:         "chrome-devtools",
+		Type:         Persistent,
+		Status:       Active,
+		PID:          4242,
+		StartTime:    started,
+		LastActivity: started.Add(time.Minute),
+		Config: config.ServerConfig{
+			Command: "npx",
+			Args:    []string{"-y", "chrome-devtools-mcp@latest"},
+		},
+	}
+
+	// Same encoding as FileStore.SaveSession
+	got, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to marshal session info: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "session_info.golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("session info format changed:\n got: %s\nwant: %s", got, want)
+	}
+
+	var decoded SessionInfo
+	if err := json.Unmarshal(want, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal golden file: %v", err)
+	}
+	if decoded.Type != Persistent || decoded.Status != Active {
+		t.Errorf("expected persistent/active, got %s/%s", decoded.Type, decoded.Status)
+	}
+}
+
+func TestSessionInfoAcceptsLegacyIntegers(t *testing.T) {
+	var decoded SessionInfo
+	if err := json.Unmarshal([]byte(`{"name":"time","type":2,"status":5}`), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal legacy session info: %v", err)
+	}
+	if decoded.Type != Hybrid || decoded.Status != Stopped {
+		t.Errorf("expected hybrid/stopped, got %s/%s", decoded.Type, decoded.Status)
+	}
+
+	if err := json.Unmarshal([]byte(`{"type":"bogus"}`), &decoded); err == nil {
+		t.Error("expected unknown session type to be rejected")
+	}
+}
@@ -0,0 +1,123 @@
+// Package suggest finds the names closest to one that was mistyped, so an
+// unknown server or tool can be answered with "did you mean 'context7'?"
+// instead of a flat not-found error.
+package suggest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxCandidates is how many names a suggestion offers at most
+const MaxCandidates = 3
+
+// Closest returns up to MaxCandidates of names that are a likely typo of
+// name, closest first. A name is close when its edit distance, ignoring
+// case, is at most one per three characters of name (and at least one), or
+// when it starts with name, as "github" does "github-enterprise". name
+// itself is never suggested.
+func Closest(name string, names []string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+	limit := maxDistance(name)
+	lower := strings.ToLower(name)
+
+	var candidates []candidate
+	seen := make(map[string]bool, len(names))
+	for _, other := range names {
+		if other == name || other == "" || seen[other] {
+			continue
+		}
+		seen[other] = true
+		otherLower := strings.ToLower(other)
+		distance := Distance(lower, otherLower)
+		prefix := len([]rune(name)) >= 3 && strings.HasPrefix(otherLower, lower)
+		if distance <= limit || prefix {
+			candidates = append(candidates, candidate{name: other, distance: distance})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	if len(candidates) > MaxCandidates {
+		candidates = candidates[:MaxCandidates]
+	}
+	var closest []string
+	for _, c := range candidates {
+		closest = append(closest, c.name)
+	}
+	return closest
+}
+
+// maxDistance returns how many edits a typo of name may be
+func maxDistance(name string) int {
+	if limit := len([]rune(name)) / 3; limit > 1 {
+		return limit
+	}
+	return 1
+}
+
+// Distance returns the number of single-character insertions, deletions,
+// substitutions and swaps of adjacent characters that turn a into b
+func Distance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// Three rows of the distance matrix: two back, the previous and this one
+	before := make([]int, len(t)+1)
+	previous := make([]int, len(t)+1)
+	current := make([]int, len(t)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		current[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				current[j] = min(current[j], before[j-2]+1)
+			}
+		}
+		before, previous, current = previous, current, before
+	}
+	return previous[len(t)]
+}
+
+// DidYouMean returns "did you mean 'a'?", or "did you mean 'a', 'b' or
+// 'c'?", for the names closest to name; it is empty when none is close
+func DidYouMean(name string, names []string) string {
+	closest := Closest(name, names)
+	if len(closest) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(closest))
+	for i, c := range closest {
+		quoted[i] = "'" + c + "'"
+	}
+	text := quoted[len(quoted)-1]
+	if len(quoted) > 1 {
+		text = strings.Join(quoted[:len(quoted)-1], ", ") + " or " + text
+	}
+	return "did you mean " + text + "?"
+}
+
+// Wrap appends DidYouMean to err's message when a name is close, keeping
+// err in the chain; otherwise it returns err
+func Wrap(err error, name string, names []string) error {
+	if err == nil {
+		return nil
+	}
+	if hint := DidYouMean(name, names); hint != "" {
+		return fmt.Errorf("%w; %s", err, hint)
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package suggest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"context7", "context7", 0},
+		{"contex7", "context7", 1},   // Deletion
+		{"contexxt7", "context7", 1}, // Insertion
+		{"cantext7", "context7", 1},  // Substitution
+		{"cnotext7", "context7", 1},  // Swap
+		{"github", "gitlab", 2},
+		{"", "docs", 4},
+		{"dépôt", "depot", 2}, // Runes, not bytes
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClosestThreshold(t *testing.T) {
+	servers := []string{"context7", "github", "filesystem", "playwright", "gh", "Docs"}
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"contex7", []string{"context7"}},
+		{"cnotext7", []string{"context7"}},
+		{"ctx7", nil}, // Four edits away, only two allowed
+		{"filesytem", []string{"filesystem"}},
+		{"fylesistem", []string{"filesystem"}}, // Two edits in ten characters
+		{"playrite", nil},                      // Three edits in eight characters
+		{"gj", []string{"gh"}},                 // Short names still allow one edit
+		{"docs", []string{"Docs"}},             // Case is ignored
+		{"git", []string{"github"}},            // A prefix is close at any length
+		{"zzz", nil},
+		{"context7", nil}, // The name itself is not a suggestion
+	}
+	for _, tt := range tests {
+		if got := Closest(tt.name, servers); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Closest(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClosestCandidates(t *testing.T) {
+	tools := []string{"search", "search_code", "searches", "fetch", "reach", "serch", "search"}
+
+	// Closest first, ties by name, at most three: searches and search_code
+	// are further than reach, and fetch is too far
+	got := Closest("serach", tools)
+	want := []string{"search", "serch", "reach"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Closest = %q, want %q", got, want)
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{"contex7", []string{"context7", "github"}, "did you mean 'context7'?"},
+		{"db", []string{"da", "dc"}, "did you mean 'da' or 'dc'?"},
+		{"db", []string{"da", "dc", "dd", "de"}, "did you mean 'da', 'dc' or 'dd'?"},
+		{"weather", []string{"context7"}, ""},
+		{"anything", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := DidYouMean(tt.name, tt.names); got != tt.want {
+			t.Errorf("DidYouMean(%q, %q) = %q, want %q", tt.name, tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	notFound := errors.New("server 'contex7' not found")
+	err := Wrap(notFound, "contex7", []string{"context7"})
+	if err.Error() != "server 'contex7' not found; did you mean 'context7'?" {
+		t.Errorf("unexpected message: %v", err)
+	}
+	if !errors.Is(err, notFound) {
+		t.Error("the wrapped error should stay in the chain")
+	}
+	if got := Wrap(notFound, "weather", []string{"context7"}); got != notFound {
+		t.Errorf("without a suggestion Wrap = %v, want the error unchanged", got)
+	}
+	if Wrap(nil, "contex7", []string{"context7"}) != nil {
+		t.Error("Wrap(nil) should be nil")
+	}
+}
@@ -0,0 +1,313 @@
+package testharness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// Transport is a way of reaching a server that Run checks
+type Transport struct {
+	Name string
+	// Dial returns a client of a server following script, closed when the
+	// test ends
+	Dial func(t *testing.T, script Script) mcp.MCPClient
+
+	// Handshake is set when Initialize reaches the server and the client
+	// sends requests such as ping
+	Handshake bool
+	// Stream is set when the server's messages besides the response reach
+	// the client, so the stdio server's violations apply
+	Stream bool
+	// Cancel is set when calls honour their context
+	Cancel bool
+	// Live is set when the server is the reference server rather than this
+	// package's. Dial then ignores the script and only the cases the
+	// reference server answers alike run.
+	Live bool
+}
+
+// Case is a conformance case
+type Case struct {
+	Name   string
+	Script Script
+
+	// Needs* say what of a Transport the case needs
+	NeedsHandshake bool
+	NeedsStream    bool
+	NeedsCancel    bool
+	// Live is set when the reference server answers the case like the script
+	Live bool
+
+	Run func(t *testing.T, c mcp.MCPClient)
+}
+
+// applies reports whether the case runs on transport
+func (c *Case) applies(transport Transport) bool {
+	return (!c.NeedsHandshake || transport.Handshake) &&
+		(!c.NeedsStream || transport.Stream) &&
+		(!c.NeedsCancel || transport.Cancel) &&
+		(!transport.Live || c.Live)
+}
+
+// echoTool is the tool of the scripts, named and behaving like the reference
+// server's echo tool
+var echoTool = Tool{Name: "echo", Description: "Echoes back the input"}
+
+// callTimeout bounds each call of the cases
+const callTimeout = 10 * time.Second
+
+// Run runs every case of Cases that applies to transport as a subtest
+func Run(t *testing.T, transport Transport) {
+	for _, c := range Cases() {
+		c := c
+		if !c.applies(transport) {
+			continue
+		}
+		t.Run(c.Name, func(t *testing.T) {
+			c.Run(t, transport.Dial(t, c.Script))
+		})
+	}
+}
+
+// Cases returns the conformance cases
+func Cases() []Case {
+	return []Case{
+		{
+			Name:           "handshake",
+			Script:         Script{Name: "conformance", Tools: []Tool{echoTool}},
+			NeedsHandshake: true,
+			Live:           true,
+			Run: func(t *testing.T, c mcp.MCPClient) {
+				ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+				defer cancel()
+				result, err := c.Initialize(ctx, &mcp.InitializeParams{
+					ProtocolVersion: mcp.ProtocolVersion,
+					ClientInfo:      mcp.ClientInfo{Name: "testharness", Version: "1.0.0"},
+				})
+				if err != nil {
+					t.Fatalf("initialize failed: %v", err)
+				}
+				if result.ProtocolVersion == "" || result.ServerInfo.Name == "" {
+					t.Errorf("want a protocol version and server name, got %+v", result)
+				}
+				if sender, ok := c.(mcp.RequestSender); ok {
+					if _, err := sender.SendRequest(ctx, "ping", nil); err != nil {
+						t.Errorf("ping failed: %v", err)
+					}
+				}
+			},
+		},
+		{
+			Name:   "list tools",
+			Script: Script{Tools: []Tool{echoTool, {Name: "add"}}},
+			Live:   true,
+			Run: func(t *testing.T, c mcp.MCPClient) {
+				tools := listTools(t, c)
+				echo, ok := findTool(tools, "echo")
+				if !ok {
+					t.Fatalf("want the echo tool listed, got %v", tools)
+				}
+				if echo.InputSchema["type"] != "object" {
+					t.Errorf("want the echo tool's input schema, got %v", echo.InputSchema)
+				}
+			},
+		},
+		{
+			Name:   "call",
+			Script: Script{Tools: []Tool{echoTool}},
+			Live:   true,
+			Run: func(t *testing.T, c mcp.MCPClient) {
+				for _, message := range []string{"hello", "second call"} {
+					checkEcho(t, c, message)
+				}
+			},
+		},
+		{
+			Name:   "unknown tool",
+			Script: Script{Tools: []Tool{echoTool}},
+			Live:   true,
+			Run: func(t *testing.T, c mcp.MCPClient) {
+				ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+				defer cancel()
+				result, err := c.CallTool(ctx, "no-such-tool", nil)
+				if err == nil && !result.IsError {
+					t.Fatalf("want calling an unknown tool to fail, got %+v", result)
+				}
+				checkEcho(t, c, "still there") // The failure leaves the client usable
+			},
+		},
+		{
+			Name: "tool error",
+			Script: Script{Tools: []Tool{echoTool, {
+				Name:  "fail",
+				Error: mcp.NewError(mcp.InternalError, "the database is down", map[string]interface{}{"retryAfter": float64(30)}),
+			}}},
+			Run: func(t *testing.T, c mcp.MCPClient) {
+				ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+				defer cancel()
+				_, err := c.CallTool(ctx, "fail", nil)
+				if err == nil || !strings.Contains(err.Error(), "the database is down") {
+					t.Fatalf("want the server's error, got %v", err)
+				}
+				var rpcErr *mcp.JSONRPCError
+				if !errors.As(err, &rpcErr) || rpcErr.Code != mcp.InternalError {
+					t.Errorf("want the server's JSON-RPC error and code kept, got %#v", err)
+				} else if data, _ := rpcErr.Data.(map[string]interface{}); data["retryAfter"] != float64(30) {
+					t.Errorf("want the error's data kept, got %v", rpcErr.Data)
+				}
+				checkEcho(t, c, "after the error")
+			},
+		},
+		{
+			Name: "content",
+			Script: Script{Tools: []Tool{{
+				Name: "render",
+				Result: json.RawMessage(`{"content":[
+					{"type":"text","text":"caption"},
+					{"type":"image","data":"iVBORw0KGgo=","mimeType":"image/png"},
+					{"type":"resource","resource":{"uri":"file:///notes.md","mimeType":"text/markdown","text":"# Notes"}}
+				],"structuredContent":{"rows":2},"isError":true}`),
+			}}},
+			Run: func(t *testing.T, c mcp.MCPClient) {
+				ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+				defer cancel()
+				result, err := c.CallTool(ctx, "render", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				blocks := result.Blocks()
+				if len(blocks) != 3 || blocks[0].Text != "caption" || blocks[1].Type != "image" || blocks[1].MimeType != "image/png" ||
+					blocks[2].Resource == nil || blocks[2].Resource.URI != "file:///notes.md" {
+					t.Errorf("the content blocks did not survive: %+v", blocks)
+				}
+				if !result.IsError {
+					t.Error("want isError kept")
+				}
+				if structured, _ := result.StructuredContent.(map[string]interface{}); structured["rows"] != float64(2) {
+					t.Errorf("want the structured content kept, got %v", result.StructuredContent)
+				}
+				if len(result.Raw) == 0 {
+					t.Error("want the raw result kept")
+				}
+			},
+		},
+		{
+			Name:        "server request reusing the request's ID",
+			Script:      Script{Tools: []Tool{echoTool}, Violations: []Violation{ReuseID}},
+			NeedsStream: true,
+			Run: func(t *testing.T, c mcp.MCPClient) {
+				checkEcho(t, c, "one")
+				checkEcho(t, c, "two")
+			},
+		},
+		{
+			Name:        "notifications before responses",
+			Script:      Script{Tools: []Tool{echoTool}, Violations: []Violation{NotifyFirst}},
+			NeedsStream: true,
+			Run: func(t *testing.T, c mcp.MCPClient) {
+				var notified []string
+				if source, ok := c.(mcp.NotificationSource); ok {
+					source.SetNotificationHandler(func(method string, _ json.RawMessage) {
+						notified = append(notified, method)
+					})
+				}
+				ctx, cancel := context.WithTimeout(mcp.WithProgressToken(context.Background(), "p1"), callTimeout)
+				defer cancel()
+				result, err := c.CallTool(ctx, "echo", map[string]interface{}{"message": "hi"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if blocks := result.Blocks(); len(blocks) != 1 || blocks[0].Text != "Echo: hi" {
+					t.Errorf("want the response, not a notification, got %+v", blocks)
+				}
+				if _, ok := c.(mcp.NotificationSource); ok && (len(notified) != 2 || notified[0] != mcp.LoggingMessage || notified[1] != mcp.Progress) {
+					t.Errorf("want the log message and progress delivered, got %v", notified)
+				}
+			},
+		},
+		{
+			Name:        "noise on the wire",
+			Script:      Script{Tools: []Tool{echoTool}, Violations: []Violation{Noise}},
+			NeedsStream: true,
+			Run: func(t *testing.T, c mcp.MCPClient) {
+				listTools(t, c)
+				checkEcho(t, c, "through the noise")
+			},
+		},
+		{
+			Name:   "huge payload",
+			Script: Script{Tools: []Tool{echoTool}, Violations: []Violation{HugePayload}},
+			Run: func(t *testing.T, c mcp.MCPClient) {
+				ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+				defer cancel()
+				_, err := c.CallTool(ctx, "echo", map[string]interface{}{"message": "big"})
+				if err == nil || !strings.Contains(err.Error(), "maxResponseMB") {
+					t.Fatalf("want the call refused for its size, got %v", err)
+				}
+				listTools(t, c) // Small responses still get through
+			},
+		},
+		{
+			Name: "cancelled call",
+			Script: Script{Tools: []Tool{echoTool, {
+				Name:   "slow",
+				Result: json.RawMessage(`{"content":[{"type":"text","text":"too late"}]}`),
+				Delay:  5 * time.Second,
+			}}},
+			NeedsCancel: true,
+			Run: func(t *testing.T, c mcp.MCPClient) {
+				ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+				defer cancel()
+				_, err := c.CallTool(ctx, "slow", nil)
+				if err == nil || !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, mcp.ErrTimeout) {
+					t.Fatalf("want the call to time out, got %v", err)
+				}
+				// The server heard of the cancellation, so the next call gets
+				// its own answer rather than the late one
+				checkEcho(t, c, "next")
+			},
+		},
+	}
+}
+
+// listTools lists c's tools, failing the test if that fails
+func listTools(t *testing.T, c mcp.MCPClient) []mcp.Tool {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("listing tools failed: %v", err)
+	}
+	return tools
+}
+
+// findTool returns the tool called name
+func findTool(tools []mcp.Tool, name string) (mcp.Tool, bool) {
+	for _, tool := range tools {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return mcp.Tool{}, false
+}
+
+// checkEcho calls the echo tool with message and checks it came back
+func checkEcho(t *testing.T, c mcp.MCPClient, message string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	result, err := c.CallTool(ctx, "echo", map[string]interface{}{"message": message})
+	if err != nil {
+		t.Fatalf("echo failed: %v", err)
+	}
+	if blocks := result.Blocks(); len(blocks) == 0 || blocks[0].Text != "Echo: "+message {
+		t.Fatalf("want %q echoed, got %+v", message, blocks)
+	}
+}
@@ -0,0 +1,108 @@
+package testharness
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// sessionID is the session the HTTP server assigns every client
+const sessionID = "testharness-session"
+
+// HTTPServer serves a Script over Streamable HTTP, answering each request
+// with a JSON body. The violations that need more than one message per
+// response (ReuseID, NotifyFirst and Noise) are not applied.
+type HTTPServer struct {
+	script Script
+
+	mutex    sync.Mutex
+	inFlight map[string][]context.CancelFunc // Calls waiting out a delay, by request ID
+}
+
+// NewHTTP creates a Streamable HTTP server following script
+func NewHTTP(script Script) *HTTPServer {
+	return &HTTPServer{script: script, inFlight: make(map[string][]context.CancelFunc)}
+}
+
+// StartHTTP starts a Streamable HTTP server following script for the rest of
+// the test and returns its configuration
+func StartHTTP(tb testing.TB, script Script) config.ServerConfig {
+	tb.Helper()
+	server := httptest.NewServer(NewHTTP(script))
+	tb.Cleanup(server.Close)
+	return config.ServerConfig{URL: server.URL + "/mcp", MaxResponseMB: MaxResponseMB}
+}
+
+// ServeHTTP implements http.Handler
+func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var msg message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, "invalid JSON-RPC message", http.StatusBadRequest)
+		return
+	}
+	if !msg.isRequest() {
+		if msg.Method == mcp.Cancelled {
+			s.cancel(&msg)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	resp, delay := s.script.answer(&msg)
+	if delay > 0 {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		s.track(string(msg.ID), cancel)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return // Cancelled requests are not answered
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(mcp.SessionIDHeader, sessionID)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// track records a call waiting out its delay, so its cancellation can end
+// the wait
+func (s *HTTPServer) track(id string, cancel context.CancelFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.inFlight[id] = append(s.inFlight[id], cancel)
+}
+
+// cancel ends the waits of the calls a cancellation names
+func (s *HTTPServer) cancel(msg *message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for id, cancels := range s.inFlight {
+		if !msg.cancels(json.RawMessage(id)) {
+			continue
+		}
+		for _, cancel := range cancels {
+			cancel()
+		}
+		delete(s.inFlight, id)
+	}
+}
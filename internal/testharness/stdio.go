@@ -0,0 +1,201 @@
+package testharness
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// ScriptEnv carries the script to the stdio server StdioConfig starts
+const ScriptEnv = "MCP_TESTHARNESS_SCRIPT"
+
+// HelperTest is the test StdioConfig runs to start the server; see the
+// package documentation
+const HelperTest = "TestHarnessServer"
+
+// pingTimeout bounds the wait for the client to answer ReuseID's ping
+const pingTimeout = 5 * time.Second
+
+// Server serves a Script to one client over stdio
+type Server struct {
+	script Script
+
+	outMutex sync.Mutex
+	out      io.Writer
+}
+
+// New creates a server following script
+func New(script Script) *Server {
+	return &Server{script: script}
+}
+
+// StdioConfig returns the configuration of a stdio server following script,
+// which runs in the test binary; see the package documentation
+func StdioConfig(script Script) config.ServerConfig {
+	data, _ := json.Marshal(script)
+	return config.ServerConfig{
+		Command:       os.Args[0],
+		Args:          []string{"-test.run=^" + HelperTest + "$"},
+		Env:           map[string]string{ScriptEnv: base64.StdEncoding.EncodeToString(data)},
+		MaxResponseMB: MaxResponseMB,
+	}
+}
+
+// ServeStdioHelper serves the script StdioConfig passed on stdin and stdout,
+// then exits. Outside a process StdioConfig started it returns at once.
+func ServeStdioHelper() {
+	encoded := os.Getenv(ScriptEnv)
+	if encoded == "" {
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	var script Script
+	if err == nil {
+		err = json.Unmarshal(data, &script)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "testharness: invalid script: %v\n", err)
+		os.Exit(2)
+	}
+	if err := New(script).Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "testharness: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// Serve answers the requests read from in on out, one message per line,
+// until in ends. Requests are answered one at a time, in order.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	s.outMutex.Lock()
+	s.out = out
+	s.outMutex.Unlock()
+
+	// Reading runs apart from answering, so a cancellation or the answer to
+	// a ping is seen while a request waits
+	messages := make(chan *message)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(messages)
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				var msg message
+				if json.Unmarshal(line, &msg) == nil {
+					messages <- &msg
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					readErr <- err
+				}
+				return
+			}
+		}
+	}()
+
+	for msg := range messages {
+		if msg.isRequest() {
+			if err := s.handle(msg, messages); err != nil {
+				return err
+			}
+		}
+	}
+	select {
+	case err := <-readErr:
+		return err
+	default:
+		return nil
+	}
+}
+
+// handle answers req, applying the script's violations. Messages read
+// meanwhile come from messages.
+func (s *Server) handle(req *message, messages <-chan *message) error {
+	if s.script.has(Noise) {
+		if err := s.writeLine([]byte("testharness: not a JSON-RPC message")); err != nil {
+			return err
+		}
+	}
+	if s.script.has(NotifyFirst) {
+		data, _ := json.Marshal("answering " + req.Method)
+		if err := s.send(notification{JSONRPC: "2.0", Method: mcp.LoggingMessage, Params: mcp.LoggingMessageParams{Level: "info", Data: data}}); err != nil {
+			return err
+		}
+		if token := progressToken(req); token != nil {
+			if err := s.send(notification{JSONRPC: "2.0", Method: mcp.Progress, Params: mcp.ProgressParams{ProgressToken: token, Progress: 1, Total: 2}}); err != nil {
+				return err
+			}
+		}
+	}
+
+	resp, delay := s.script.answer(req)
+	if s.script.has(ReuseID) {
+		if err := s.send(notification{JSONRPC: "2.0", ID: req.ID, Method: "ping"}); err != nil {
+			return err
+		}
+		answered, cancelled := s.await(messages, req.ID, pingTimeout, true)
+		if cancelled {
+			return nil
+		}
+		if !answered {
+			resp = &response{JSONRPC: "2.0", ID: req.ID, Error: mcp.NewError(mcp.InvalidRequest, "the ping sent with the request's ID went unanswered", nil)}
+		}
+	}
+	if delay > 0 {
+		if _, cancelled := s.await(messages, req.ID, delay, false); cancelled {
+			return nil // Cancelled requests are not answered
+		}
+	}
+	return s.send(resp)
+}
+
+// await reads messages for up to d, until the request id is cancelled or,
+// with untilAnswer, the client answers the server's request id
+func (s *Server) await(messages <-chan *message, id json.RawMessage, d time.Duration, untilAnswer bool) (answered, cancelled bool) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return false, false
+		case msg, ok := <-messages:
+			if !ok {
+				return false, true // The client is gone; nobody would read an answer
+			}
+			if msg.cancels(id) {
+				return false, true
+			}
+			if untilAnswer && msg.Method == "" && string(msg.ID) == string(id) {
+				return msg.Error == nil && msg.Result != nil, false
+			}
+		}
+	}
+}
+
+// send writes msg as one line
+func (s *Server) send(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return s.writeLine(data)
+}
+
+// writeLine writes line and a newline
+func (s *Server) writeLine(line []byte) error {
+	s.outMutex.Lock()
+	defer s.outMutex.Unlock()
+	_, err := s.out.Write(append(line, '\n'))
+	return err
+}
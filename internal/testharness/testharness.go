@@ -0,0 +1,238 @@
+// Package testharness is a scriptable MCP server for testing clients against.
+// A Script gives it canned tools, delays and protocol violations; the server
+// speaks it over stdio (New and Serve, or StdioConfig for a server the client
+// starts itself) or Streamable HTTP (StartHTTP). Run drives the conformance
+// cases of Cases through any client, so every transport is held to the same
+// behaviour.
+//
+// Clients starting the stdio server run the test binary again. A package
+// using StdioConfig must therefore define the test the child process runs:
+//
+//	func TestHarnessServer(t *testing.T) { testharness.ServeStdioHelper() }
+//
+// The package is for this repository's tests only. Its API is written in
+// terms of internal/mcp and internal/config, so code outside the module
+// can't import it; it will move under pkg/ once those types are public, and
+// until then it changes with the client without notice.
+package testharness
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// Violation is a way the server breaks the protocol, or bends it further than
+// clients expect
+type Violation string
+
+const (
+	// ReuseID has the server ping the client, using the ID of the request it
+	// is answering, before it answers. IDs are scoped to their sender, so the
+	// client must answer the ping rather than take it for its response. The
+	// server answers with an error if the ping goes unanswered.
+	ReuseID Violation = "reuse-id"
+	// NotifyFirst sends a log message, and progress when the request asked
+	// for it, before each response
+	NotifyFirst Violation = "notify-first"
+	// Noise writes a line that isn't JSON-RPC before each response, as
+	// servers logging to stdout do
+	Noise Violation = "noise"
+	// HugePayload pads each tool result with a text block of PayloadSize
+	// bytes
+	HugePayload Violation = "huge-payload"
+)
+
+// streamViolations are the violations that put messages besides the response
+// on the wire. Only the stdio server applies them: a Streamable HTTP
+// response carries just one message.
+var streamViolations = map[Violation]bool{ReuseID: true, NotifyFirst: true, Noise: true}
+
+// Tool is a tool the server offers
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Result is the tools/call result answered. Without one the tool echoes
+	// its "message" argument as "Echo: <message>", as the reference server's
+	// echo tool does.
+	Result json.RawMessage `json:"result,omitempty"`
+	// Error is answered instead of a result
+	Error *mcp.JSONRPCError `json:"error,omitempty"`
+	// Delay is waited before answering. A call cancelled meanwhile is not
+	// answered at all.
+	Delay time.Duration `json:"delay,omitempty"`
+}
+
+// Script says how the server behaves
+type Script struct {
+	Name            string      `json:"name,omitempty"`            // Reported in serverInfo; "testharness" by default
+	ProtocolVersion string      `json:"protocolVersion,omitempty"` // Answered to initialize; mcp.ProtocolVersion by default
+	Tools           []Tool      `json:"tools,omitempty"`
+	Violations      []Violation `json:"violations,omitempty"`
+	PayloadSize     int         `json:"payloadSize,omitempty"` // Bytes HugePayload adds; DefaultPayloadSize by default
+}
+
+// MaxResponseMB caps the responses of clients of the servers StdioConfig and
+// StartHTTP configure, so HugePayload needn't send a huge amount to exceed it
+const MaxResponseMB = 1
+
+// DefaultPayloadSize is what HugePayload adds without a PayloadSize: twice
+// MaxResponseMB
+const DefaultPayloadSize = 2 * MaxResponseMB << 20
+
+// has reports whether the script calls for violation
+func (s *Script) has(violation Violation) bool {
+	for _, v := range s.Violations {
+		if v == violation {
+			return true
+		}
+	}
+	return false
+}
+
+// tool returns the tool called name
+func (s *Script) tool(name string) (Tool, bool) {
+	for _, tool := range s.Tools {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return Tool{}, false
+}
+
+// message is a JSON-RPC request, notification or response read by the server
+type message struct {
+	ID     json.RawMessage   `json:"id,omitempty"`
+	Method string            `json:"method,omitempty"`
+	Params json.RawMessage   `json:"params,omitempty"`
+	Result json.RawMessage   `json:"result,omitempty"`
+	Error  *mcp.JSONRPCError `json:"error,omitempty"`
+}
+
+// isRequest reports whether the message expects a response
+func (m *message) isRequest() bool {
+	return m.Method != "" && len(m.ID) > 0 && string(m.ID) != "null"
+}
+
+// cancels reports whether the message is a cancellation of the request id
+func (m *message) cancels(id json.RawMessage) bool {
+	if m.Method != mcp.Cancelled {
+		return false
+	}
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	return json.Unmarshal(m.Params, &params) == nil && string(params.RequestID) == string(id)
+}
+
+// response is a JSON-RPC response the server writes
+type response struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Result  interface{}       `json:"result,omitempty"`
+	Error   *mcp.JSONRPCError `json:"error,omitempty"`
+}
+
+// notification is a JSON-RPC notification or request the server writes
+type notification struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  interface{}     `json:"params,omitempty"`
+}
+
+// answer works out the response to req, and the delay before sending it
+func (s *Script) answer(req *message) (*response, time.Duration) {
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	switch req.Method {
+	case "initialize":
+		name, version := s.Name, s.ProtocolVersion
+		if name == "" {
+			name = "testharness"
+		}
+		if version == "" {
+			version = mcp.ProtocolVersion
+		}
+		resp.Result = mcp.InitializeResult{
+			ProtocolVersion: version,
+			Capabilities:    mcp.ServerCapabilities{Tools: &mcp.ToolsCapability{}, Logging: &mcp.LoggingCapability{}},
+			ServerInfo:      mcp.ServerInfo{Name: name, Version: "1.0.0"},
+		}
+	case "ping":
+		resp.Result = struct{}{}
+	case "tools/list":
+		tools := make([]mcp.Tool, 0, len(s.Tools))
+		for _, tool := range s.Tools {
+			tools = append(tools, mcp.Tool{
+				Name:        tool.Name,
+				Description: tool.Description,
+				InputSchema: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"message": map[string]interface{}{"type": "string"}},
+				},
+			})
+		}
+		resp.Result = mcp.ListToolsResult{Tools: tools}
+	case "tools/call":
+		return s.call(req, resp)
+	case "resources/list":
+		resp.Result = mcp.ListResourcesResult{Resources: []mcp.Resource{}}
+	default:
+		resp.Error = mcp.NewError(mcp.MethodNotFound, fmt.Sprintf("Method not found: %s", req.Method), nil)
+	}
+	return resp, 0
+}
+
+// call answers a tools/call request
+func (s *Script) call(req *message, resp *response) (*response, time.Duration) {
+	var params mcp.CallToolParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		resp.Error = mcp.NewError(mcp.InvalidParams, fmt.Sprintf("invalid params: %v", err), nil)
+		return resp, 0
+	}
+	tool, ok := s.tool(params.Name)
+	if !ok {
+		resp.Error = mcp.NewError(mcp.InvalidParams, fmt.Sprintf("Unknown tool: %s", params.Name), nil)
+		return resp, 0
+	}
+	if tool.Error != nil {
+		resp.Error = tool.Error
+		return resp, tool.Delay
+	}
+
+	result := map[string]interface{}{}
+	if tool.Result != nil {
+		if err := json.Unmarshal(tool.Result, &result); err != nil {
+			resp.Error = mcp.NewError(mcp.InternalError, fmt.Sprintf("invalid canned result: %v", err), nil)
+			return resp, 0
+		}
+	} else {
+		message, _ := params.Arguments["message"].(string)
+		result["content"] = []interface{}{map[string]interface{}{"type": "text", "text": "Echo: " + message}}
+	}
+	if s.has(HugePayload) {
+		size := s.PayloadSize
+		if size <= 0 {
+			size = DefaultPayloadSize
+		}
+		content, _ := result["content"].([]interface{})
+		result["content"] = append(content, map[string]interface{}{"type": "text", "text": strings.Repeat("x", size)})
+	}
+	resp.Result = result
+	return resp, tool.Delay
+}
+
+// progressToken returns the progress token a request carries, if any
+func progressToken(req *message) interface{} {
+	var params struct {
+		Meta *mcp.RequestMeta `json:"_meta"`
+	}
+	if json.Unmarshal(req.Params, &params) != nil || params.Meta == nil {
+		return nil
+	}
+	return params.Meta.ProgressToken
+}
@@ -0,0 +1,50 @@
+package testharness
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnansweredPingFailsTheRequest(t *testing.T) {
+	script := Script{Tools: []Tool{echoTool}, Violations: []Violation{ReuseID}}
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	go func() { _ = New(script).Serve(serverIn, serverOut) }()
+	t.Cleanup(func() { _ = clientOut.Close() })
+
+	_, _ = io.WriteString(clientOut, `{"jsonrpc":"2.0","id":7,"method":"tools/list"}`+"\n")
+	lines := bufio.NewScanner(clientIn)
+	if !lines.Scan() || !strings.Contains(lines.Text(), `"id":7,"method":"ping"`) {
+		t.Fatalf("want a ping reusing the request's ID, got %s", lines.Text())
+	}
+
+	// A response to the ping's ID that is an error does not count as an answer
+	_, _ = io.WriteString(clientOut, `{"jsonrpc":"2.0","id":7,"error":{"code":-32601,"message":"no"}}`+"\n")
+	done := make(chan string, 1)
+	go func() {
+		lines.Scan()
+		done <- lines.Text()
+	}()
+	select {
+	case line := <-done:
+		var resp response
+		if err := json.Unmarshal([]byte(line), &resp); err != nil || resp.Error == nil || string(resp.ID) != "7" {
+			t.Errorf("want request 7 failed, got %s", line)
+		}
+	case <-time.After(pingTimeout + time.Second):
+		t.Fatal("the request went unanswered")
+	}
+}
+
+func TestScriptAnswersEchoLikeTheReferenceServer(t *testing.T) {
+	script := Script{Tools: []Tool{echoTool}}
+	resp, _ := script.answer(&message{ID: json.RawMessage(`1`), Method: "tools/call", Params: json.RawMessage(`{"name":"echo","arguments":{"message":"hi"}}`)})
+	data, _ := json.Marshal(resp.Result)
+	if string(data) != `{"content":[{"text":"Echo: hi","type":"text"}]}` {
+		t.Errorf("unexpected echo result %s", data)
+	}
+}
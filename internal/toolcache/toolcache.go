@@ -0,0 +1,222 @@
+// Package toolcache keeps each server's tool list on disk, so repeated
+// commands need not start the server just to learn its tools. Entries are
+// keyed by a hash of the server's configuration: editing a server's entry
+// invalidates its cached tools.
+package toolcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/caches"
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// DirName is the cache directory inside the data directory
+const DirName = "tool-cache"
+
+// CacheName is the name the cache command knows the cache by
+const CacheName = "tool-lists"
+
+func init() {
+	caches.Register(CacheName, func(cfg *config.Configuration) (caches.Cache, error) {
+		c, err := Default(cfg.ToolCacheDuration())
+		if err != nil {
+			return nil, err
+		}
+		return managed{c}, nil
+	})
+}
+
+// Cache stores tool lists as one file per server
+type Cache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// entry is the content of one server's cache file
+type entry struct {
+	Key     string     `json:"key"` // Hash of the server configuration the tools were listed with
+	Updated time.Time  `json:"updated"`
+	Tools   []mcp.Tool `json:"tools"`
+}
+
+// New creates a cache in dir whose entries expire after ttl. A ttl of zero
+// or less disables the cache: nothing is read or written.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// Default creates a cache in the data directory
+func Default(ttl time.Duration) (*Cache, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return nil, err
+	}
+	return New(filepath.Join(dataDir, DirName), ttl), nil
+}
+
+// Key returns the hash identifying a server configuration
+func Key(serverConfig config.ServerConfig) string {
+	data, _ := json.Marshal(serverConfig)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the server's cached tools if they were listed with the same
+// configuration and have not expired
+func (c *Cache) Get(serverName string, serverConfig config.ServerConfig) ([]mcp.Tool, bool) {
+	if c == nil || c.ttl <= 0 {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(serverName))
+	if err != nil {
+		return nil, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false
+	}
+	if e.Key != Key(serverConfig) || c.now().Sub(e.Updated) > c.ttl {
+		return nil, false
+	}
+	return e.Tools, true
+}
+
+// Put stores the server's tools. The file is written to a temporary name and
+// renamed into place, so concurrent processes never read a partial entry.
+func (c *Cache) Put(serverName string, serverConfig config.ServerConfig, tools []mcp.Tool) error {
+	if c == nil || c.ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(entry{Key: Key(serverConfig), Updated: c.now(), Tools: tools})
+	if err != nil {
+		return fmt.Errorf("failed to encode cached tools: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cached tools: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // No-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write cached tools: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cached tools: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(serverName)); err != nil {
+		return fmt.Errorf("failed to write cached tools: %w", err)
+	}
+	return nil
+}
+
+// Invalidate removes the server's cached tools
+func (c *Cache) Invalidate(serverName string) error {
+	if c == nil {
+		return nil
+	}
+	if err := os.Remove(c.path(serverName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove cached tools: %w", err)
+	}
+	return nil
+}
+
+// Clear removes every cached tool list and returns how many were removed
+func (c *Cache) Clear() (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+	files, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	removed := 0
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, file.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to remove cached tools: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// path returns the server's cache file; the name is escaped so any server
+// name makes a valid file name
+func (c *Cache) path(serverName string) string {
+	return filepath.Join(c.dir, url.QueryEscape(serverName)+".json")
+}
+
+// managed is the Cache as the cache command manages it
+type managed struct {
+	c *Cache
+}
+
+func (m managed) Stats() (caches.Stats, error) {
+	return caches.Scan(m.c.dir, caches.Stamp("updated"))
+}
+
+func (m managed) Clear(serverName string) (int, error) {
+	if serverName == "" {
+		return m.c.Clear()
+	}
+	if _, err := os.Stat(m.c.path(serverName)); err != nil {
+		return 0, nil
+	}
+	if err := m.c.Invalidate(serverName); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// GC removes the entries that have expired or cannot be read. With the cache
+// disabled, that is all of them.
+func (m managed) GC() (int, error) {
+	files, err := os.ReadDir(m.c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	removed := 0
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(m.c.dir, file.Name())
+		var e entry
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &e) == nil && m.c.ttl > 0 && m.c.now().Sub(e.Updated) <= m.c.ttl {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to remove cached tools: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
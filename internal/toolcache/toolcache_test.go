@@ -0,0 +1,140 @@
+package toolcache
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+var timeServer = config.ServerConfig{Command: "uvx", Args: []string{"mcp-server-time"}}
+
+func TestCacheGetPut(t *testing.T) {
+	cache := New(t.TempDir(), 10*time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	if _, ok := cache.Get("time", timeServer); ok {
+		t.Fatal("an empty cache should miss")
+	}
+
+	tools := []mcp.Tool{{Name: "get_current_time"}}
+	if err := cache.Put("time", timeServer, tools); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := cache.Get("time", timeServer)
+	if !ok || len(got) != 1 || got[0].Name != "get_current_time" {
+		t.Fatalf("Get = %v, %v; want the stored tools", got, ok)
+	}
+
+	// A changed server entry misses
+	changed := timeServer
+	changed.Args = []string{"mcp-server-time", "--local-timezone=UTC"}
+	if _, ok := cache.Get("time", changed); ok {
+		t.Error("a changed configuration should miss")
+	}
+
+	// So does an expired entry
+	now = now.Add(11 * time.Minute)
+	if _, ok := cache.Get("time", timeServer); ok {
+		t.Error("an expired entry should miss")
+	}
+}
+
+func TestCacheInvalidateAndClear(t *testing.T) {
+	dir := t.TempDir()
+	cache := New(dir, time.Hour)
+	for _, name := range []string{"time", "fetch", "odd/name:1"} {
+		if err := cache.Put(name, timeServer, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := cache.Invalidate("time"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("time", timeServer); ok {
+		t.Error("an invalidated entry should miss")
+	}
+	if err := cache.Invalidate("never-cached"); err != nil {
+		t.Errorf("invalidating a missing entry should succeed, got %v", err)
+	}
+
+	removed, err := cache.Clear()
+	if err != nil || removed != 2 {
+		t.Fatalf("Clear = %d, %v; want 2 entries removed", removed, err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("cache directory not empty: %v", files)
+	}
+}
+
+func TestDisabledCacheStoresNothing(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	cache := New(dir, 0)
+	if err := cache.Put("time", timeServer, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("a disabled cache should not create its directory")
+	}
+}
+
+func TestConcurrentPutsLeaveValidEntries(t *testing.T) {
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Separate caches stand in for separate processes
+			cache := New(dir, time.Hour)
+			_ = cache.Put("time", timeServer, []mcp.Tool{{Name: "get_current_time"}})
+			if _, ok := cache.Get("time", timeServer); !ok {
+				t.Error("read a missing or partial entry")
+			}
+		}()
+	}
+	wg.Wait()
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("want only the entry left behind, got %v", files)
+	}
+}
+
+func TestManagedGCRemovesExpiredAndCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	cache := New(dir, time.Hour)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	for _, name := range []string{"time", "fetch"} {
+		if err := cache.Put(name, timeServer, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := managed{cache}
+	stats, err := m.Stats()
+	if err != nil || stats.Entries != 2 || len(stats.Corrupt) != 1 {
+		t.Fatalf("Stats = %+v, %v; want 2 entries and 1 corrupt file", stats, err)
+	}
+	if removed, err := m.GC(); err != nil || removed != 1 {
+		t.Errorf("GC = %d, %v; want the corrupt file removed", removed, err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if removed, err := m.Clear("time"); err != nil || removed != 1 {
+		t.Errorf("Clear(time) = %d, %v; want 1", removed, err)
+	}
+	if removed, err := m.GC(); err != nil || removed != 1 {
+		t.Errorf("GC = %d, %v; want the expired entry removed", removed, err)
+	}
+}
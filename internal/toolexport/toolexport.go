@@ -0,0 +1,248 @@
+// Package toolexport renders tools' schemas as a JSON Schema bundle or an
+// OpenAPI document, for generating typed wrappers from them. The output is
+// the same for the same tools whatever order they were listed in, so
+// exports committed to a repository diff cleanly.
+package toolexport
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// Export formats
+const (
+	FormatJSONSchema = "jsonschema"
+	FormatOpenAPI    = "openapi"
+)
+
+// SchemaDialect is the JSON Schema version of the exported schemas
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// OutputSuffix names a tool's output schema after its input schema
+const OutputSuffix = ".output"
+
+// Tool is a server's tool and the name it is exported under
+type Tool struct {
+	Name   string // Exported, as serve publishes it
+	Server string
+	Tool   mcp.Tool
+}
+
+// Bundle is the JSON Schema export: each tool's input schema under its
+// exported name, and its output schema, if it has one, under the name with
+// OutputSuffix
+type Bundle struct {
+	Schema string                            `json:"$schema"`
+	Defs   map[string]map[string]interface{} `json:"$defs"`
+}
+
+// Document is the OpenAPI export. Each tool is a POST operation on
+// /tools/{name} taking its input schema as the request body.
+type Document struct {
+	OpenAPI           string                          `json:"openapi"`
+	Info              Info                            `json:"info"`
+	JSONSchemaDialect string                          `json:"jsonSchemaDialect"`
+	Paths             map[string]map[string]Operation `json:"paths"`
+	Components        Components                      `json:"components"`
+}
+
+// Info describes the OpenAPI document
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Operation is a tool as an OpenAPI operation
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags"`
+	RequestBody Body                 `json:"requestBody"`
+	Responses   map[string]Body      `json:"responses"`
+	Server      string               `json:"x-mcp-server"`
+	Tool        string               `json:"x-mcp-tool"`
+	Annotations *mcp.ToolAnnotations `json:"x-mcp-annotations,omitempty"`
+}
+
+// Body is an OpenAPI request body or response
+type Body struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body's content
+type MediaType struct {
+	Schema map[string]string `json:"schema"`
+}
+
+// Components holds the OpenAPI document's schemas, named as in the Bundle
+type Components struct {
+	Schemas map[string]map[string]interface{} `json:"schemas"`
+}
+
+// Render exports tools in format as indented JSON ending in a newline
+func Render(format string, tools []Tool) ([]byte, error) {
+	var export interface{}
+	var err error
+	switch format {
+	case FormatJSONSchema:
+		export, err = NewBundle(tools)
+	case FormatOpenAPI:
+		export, err = NewDocument(tools)
+	default:
+		return nil, fmt.Errorf("unknown export format %q: use %s or %s", format, FormatJSONSchema, FormatOpenAPI)
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the export: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// NewBundle exports tools as a JSON Schema bundle
+func NewBundle(tools []Tool) (*Bundle, error) {
+	defs, err := schemas(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &Bundle{Schema: SchemaDialect, Defs: defs}, nil
+}
+
+// NewDocument exports tools as an OpenAPI 3.1 document
+func NewDocument(tools []Tool) (*Document, error) {
+	defs, err := schemas(tools)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{
+		OpenAPI:           "3.1.0",
+		Info:              Info{Title: "MCP tools", Version: "1.0.0", Description: "Tools of MCP servers, exported by mcp-cli-ent"},
+		JSONSchemaDialect: SchemaDialect,
+		Paths:             make(map[string]map[string]Operation, len(tools)),
+		Components:        Components{Schemas: defs},
+	}
+	for _, tool := range tools {
+		op := Operation{
+			OperationID: tool.Name,
+			Description: tool.Tool.Description,
+			Tags:        []string{tool.Server},
+			RequestBody: Body{Required: true, Content: jsonContent(tool.Name)},
+			Responses:   map[string]Body{"200": {Description: "The tool's result"}},
+			Server:      tool.Server,
+			Tool:        tool.Tool.Name,
+			Annotations: tool.Tool.Annotations,
+		}
+		if tool.Tool.Annotations != nil {
+			op.Summary = tool.Tool.Annotations.Title
+		}
+		if tool.Tool.OutputSchema != nil {
+			op.Responses["200"] = Body{Description: "The tool's structured result", Content: jsonContent(tool.Name + OutputSuffix)}
+		}
+		doc.Paths["/tools/"+url.PathEscape(tool.Name)] = map[string]Operation{"post": op}
+	}
+	return doc, nil
+}
+
+// jsonContent is JSON content of the named component schema
+func jsonContent(name string) map[string]MediaType {
+	ref := "#/components/schemas/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+	return map[string]MediaType{"application/json": {Schema: map[string]string{"$ref": ref}}}
+}
+
+// schemas returns the named schemas of tools, failing if two tools, or a
+// tool and another's output schema, would share a name
+func schemas(tools []Tool) (map[string]map[string]interface{}, error) {
+	sorted := append([]Tool(nil), tools...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	defs := make(map[string]map[string]interface{}, len(sorted))
+	owners := make(map[string]Tool, len(sorted))
+	claim := func(name string, tool Tool, schema map[string]interface{}) error {
+		if owner, taken := owners[name]; taken {
+			return fmt.Errorf("%s/%s and %s/%s are both exported as %s; set serve.namespaceStyle or rename one with exportAs",
+				owner.Server, owner.Tool.Name, tool.Server, tool.Tool.Name, name)
+		}
+		owners[name] = tool
+		defs[name] = schema
+		return nil
+	}
+
+	for _, tool := range sorted {
+		input := embed(tool.Name, tool.Tool.InputSchema)
+		if input["type"] == nil && input["$ref"] == nil {
+			input["type"] = "object" // MCP tools take an object of arguments
+		}
+		setDefault(input, "title", tool.Name)
+		setDefault(input, "description", tool.Tool.Description)
+		input["x-mcp-server"] = tool.Server
+		input["x-mcp-tool"] = tool.Tool.Name
+		if err := claim(tool.Name, tool, input); err != nil {
+			return nil, err
+		}
+
+		if tool.Tool.OutputSchema != nil {
+			output := embed(tool.Name+OutputSuffix, tool.Tool.OutputSchema)
+			setDefault(output, "title", tool.Name+OutputSuffix)
+			if err := claim(tool.Name+OutputSuffix, tool, output); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return defs, nil
+}
+
+// embed copies a schema for embedding in the export. A schema with its own
+// definitions, local references or dialect gets an $id, so its references
+// resolve against it rather than against the export, and its $schema is
+// allowed to differ from the export's.
+func embed(name string, schema map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(schema)+4)
+	for key, value := range schema {
+		copied[key] = value
+	}
+	if copied["$defs"] != nil || copied["definitions"] != nil || copied["$schema"] != nil || hasLocalRef(schema) {
+		setDefault(copied, "$id", name)
+	}
+	return copied
+}
+
+// hasLocalRef reports whether a $ref anywhere in value points into the
+// schema it is part of, such as "#" or "#/properties/node"
+func hasLocalRef(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+			return true
+		}
+		for _, member := range v {
+			if hasLocalRef(member) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if hasLocalRef(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setDefault sets key in schema unless it is set or value is empty
+func setDefault(schema map[string]interface{}, key, value string) {
+	if _, set := schema[key]; !set && value != "" {
+		schema[key] = value
+	}
+}
@@ -0,0 +1,122 @@
+package toolexport
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// fixtureTools reads the tool set in testdata/tools.json
+func fixtureTools(t *testing.T) []Tool {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "tools.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tools []Tool
+	if err := json.Unmarshal(data, &tools); err != nil {
+		t.Fatalf("invalid fixture: %v", err)
+	}
+	return tools
+}
+
+func TestRenderGolden(t *testing.T) {
+	tools := fixtureTools(t)
+	for _, format := range []string{FormatJSONSchema, FormatOpenAPI} {
+		t.Run(format, func(t *testing.T) {
+			got, err := Render(format, tools)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", format+".golden.json")
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatalf("failed to update golden file: %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("export mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+			}
+
+			// Listing order doesn't matter
+			reversed := make([]Tool, len(tools))
+			for i, tool := range tools {
+				reversed[len(tools)-1-i] = tool
+			}
+			again, err := Render(format, reversed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != string(got) {
+				t.Error("the export changed with the order of the tools")
+			}
+		})
+	}
+}
+
+func TestRenderKeepsSchemasIntact(t *testing.T) {
+	tools := fixtureTools(t)
+	bundle, err := NewBundle(tools)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Local references resolve against the tool's own schema
+	search := bundle.Defs["docs__search"]
+	if search["$id"] != "docs__search" || search["$schema"] != "http://json-schema.org/draft-07/schema#" {
+		t.Errorf("docs__search = %v", search)
+	}
+	if outline := bundle.Defs["docs__outline"]; outline["$id"] != "docs__outline" {
+		t.Errorf("a schema referring to itself with \"#\" needs an $id: %v", outline)
+	}
+	if current := bundle.Defs["time__get_current_time"]; current["$id"] != nil {
+		t.Errorf("a schema without local references got an $id: %v", current)
+	}
+	if _, ok := tools[1].Tool.InputSchema["$schema"]; !ok {
+		t.Error("exporting changed the tool's schema")
+	}
+
+	if _, ok := bundle.Defs["time__get_current_time"+OutputSuffix]; !ok {
+		t.Error("the output schema is missing")
+	}
+	if _, ok := bundle.Defs["time__convert_time"+OutputSuffix]; ok {
+		t.Error("a tool without an output schema got one")
+	}
+	if ping := bundle.Defs["ping"]; ping["type"] != "object" {
+		t.Errorf("a tool without an input schema takes an object: %v", ping)
+	}
+}
+
+func TestRenderRefusesCollisions(t *testing.T) {
+	tools := []Tool{
+		{Name: "search", Server: "docs", Tool: mcp.Tool{Name: "search"}},
+		{Name: "search", Server: "web", Tool: mcp.Tool{Name: "search"}},
+	}
+	if _, err := Render(FormatOpenAPI, tools); err == nil || !strings.Contains(err.Error(), "docs/search and web/search") {
+		t.Errorf("want a collision error, got %v", err)
+	}
+
+	tools = []Tool{
+		{Name: "fetch", Server: "web", Tool: mcp.Tool{Name: "fetch", OutputSchema: map[string]interface{}{"type": "object"}}},
+		{Name: "fetch" + OutputSuffix, Server: "web", Tool: mcp.Tool{Name: "fetch" + OutputSuffix}},
+	}
+	if _, err := Render(FormatJSONSchema, tools); err == nil {
+		t.Error("a tool named like another's output schema should collide")
+	}
+
+	if _, err := Render("yaml", nil); err == nil {
+		t.Error("an unknown format should fail")
+	}
+}
@@ -0,0 +1,286 @@
+// Package toolsnapshot saves a server's tool list as a canonical JSON file
+// and compares listings against it, so a renamed tool or a changed schema is
+// noticed before agents break on it.
+package toolsnapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+// DirName is the snapshot directory inside the data directory
+const DirName = "tool-snapshots"
+
+// Snapshot is a server's tool set at one point
+type Snapshot struct {
+	Server string `json:"server"`
+	Tools  []Tool `json:"tools"` // Sorted by name
+}
+
+// Tool is the part of a tool agents depend on
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema,omitempty"`
+}
+
+// New takes a snapshot of tools
+func New(serverName string, tools []mcp.Tool) *Snapshot {
+	s := &Snapshot{Server: serverName, Tools: make([]Tool, 0, len(tools))}
+	for _, tool := range tools {
+		s.Tools = append(s.Tools, Tool{Name: tool.Name, Description: tool.Description, InputSchema: tool.InputSchema})
+	}
+	sort.Slice(s.Tools, func(i, j int) bool { return s.Tools[i].Name < s.Tools[j].Name })
+	return s
+}
+
+// DefaultPath returns where a server's snapshot is kept in the configuration
+// directory; the name is escaped so any server name makes a valid file name
+func DefaultPath(serverName string) (string, error) {
+	dataDir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, DirName, url.QueryEscape(serverName)+".json"), nil
+}
+
+// Load reads a snapshot file
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid snapshot '%s': %w", path, err)
+	}
+	return &s, nil
+}
+
+// Save writes the snapshot to path as indented JSON with sorted keys, so
+// snapshots committed to a repository diff cleanly. The file is written to a
+// temporary name and renamed into place.
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	data = append(data, '\n')
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // No-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Kinds of change
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Diff lists how one tool set differs from another
+type Diff struct {
+	Added   []string     `json:"added,omitempty"`   // Names of new tools
+	Removed []string     `json:"removed,omitempty"` // Names of tools that are gone
+	Changed []ToolChange `json:"changed,omitempty"` // Tools whose description or schema changed
+}
+
+// ToolChange is how a tool present in both sets changed
+type ToolChange struct {
+	Name        string         `json:"name"`
+	Description *FieldChange   `json:"description,omitempty"`
+	Schema      []SchemaChange `json:"schema,omitempty"`
+}
+
+// FieldChange is a value that changed
+type FieldChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// SchemaChange is one field of an input schema that was added, removed or
+// changed. Path is the field's dotted location in the schema; arrays, such
+// as required, are compared as a whole.
+type SchemaChange struct {
+	Path string      `json:"path"`
+	Kind string      `json:"kind"` // Added, Removed or Changed
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Compare returns how current differs from baseline
+func Compare(baseline, current *Snapshot) Diff {
+	var d Diff
+	before := make(map[string]Tool, len(baseline.Tools))
+	for _, tool := range baseline.Tools {
+		before[tool.Name] = tool
+	}
+	after := make(map[string]Tool, len(current.Tools))
+	for _, tool := range current.Tools {
+		after[tool.Name] = tool
+	}
+
+	for _, tool := range current.Tools {
+		old, existed := before[tool.Name]
+		if !existed {
+			d.Added = append(d.Added, tool.Name)
+			continue
+		}
+		change := ToolChange{Name: tool.Name}
+		if old.Description != tool.Description {
+			change.Description = &FieldChange{Old: old.Description, New: tool.Description}
+		}
+		change.Schema = compareValues("", normalize(old.InputSchema), normalize(tool.InputSchema))
+		if change.Description != nil || len(change.Schema) > 0 {
+			d.Changed = append(d.Changed, change)
+		}
+	}
+	for _, tool := range baseline.Tools {
+		if _, exists := after[tool.Name]; !exists {
+			d.Removed = append(d.Removed, tool.Name)
+		}
+	}
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].Name < d.Changed[j].Name })
+	return d
+}
+
+// normalize turns a schema into plain decoded JSON, so values read from a
+// snapshot file and from a server compare equal
+func normalize(schema map[string]interface{}) interface{} {
+	if schema == nil {
+		return nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return schema
+	}
+	var value interface{}
+	_ = json.Unmarshal(data, &value)
+	return value
+}
+
+// compareValues returns the changes from old to new below path, descending
+// into objects present on both sides
+func compareValues(path string, old, new interface{}) []SchemaChange {
+	oldObject, oldIsObject := old.(map[string]interface{})
+	newObject, newIsObject := new.(map[string]interface{})
+	if !oldIsObject || !newIsObject {
+		if reflect.DeepEqual(old, new) {
+			return nil
+		}
+		switch {
+		case old == nil:
+			return []SchemaChange{{Path: path, Kind: Added, New: new}}
+		case new == nil:
+			return []SchemaChange{{Path: path, Kind: Removed, Old: old}}
+		}
+		return []SchemaChange{{Path: path, Kind: Changed, Old: old, New: new}}
+	}
+
+	keys := make(map[string]bool, len(oldObject)+len(newObject))
+	for key := range oldObject {
+		keys[key] = true
+	}
+	for key := range newObject {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var changes []SchemaChange
+	for _, key := range sorted {
+		changes = append(changes, compareValues(joinPath(path, key), oldObject[key], newObject[key])...)
+	}
+	return changes
+}
+
+// joinPath appends key to a dotted path, quoting keys that contain dots
+func joinPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]\"") {
+		key = fmt.Sprintf("[%q]", key)
+		return path + key
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Empty reports whether the tool sets are the same
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Write prints the differences for people: "+" for added tools and fields,
+// "-" for removed ones and "~" for changes
+func (d Diff) Write(w io.Writer) {
+	for _, name := range d.Added {
+		fmt.Fprintf(w, "+ %s\n", name)
+	}
+	for _, name := range d.Removed {
+		fmt.Fprintf(w, "- %s\n", name)
+	}
+	for _, change := range d.Changed {
+		fmt.Fprintf(w, "~ %s\n", change.Name)
+		if change.Description != nil {
+			fmt.Fprintf(w, "    ~ description: %s -> %s\n", compact(change.Description.Old), compact(change.Description.New))
+		}
+		for _, field := range change.Schema {
+			path := field.Path
+			if path == "" {
+				path = "(schema)"
+			}
+			switch field.Kind {
+			case Added:
+				fmt.Fprintf(w, "    + %s: %s\n", path, compact(field.New))
+			case Removed:
+				fmt.Fprintf(w, "    - %s: %s\n", path, compact(field.Old))
+			default:
+				fmt.Fprintf(w, "    ~ %s: %s -> %s\n", path, compact(field.Old), compact(field.New))
+			}
+		}
+	}
+}
+
+// compact renders a value as one line of JSON
+func compact(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,93 @@
+package toolsnapshot
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func TestCompareGolden(t *testing.T) {
+	for _, name := range []string{"unchanged", "renamed", "schema"} {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join("testdata", name)
+			baseline, err := Load(filepath.Join(dir, "baseline.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			current, err := Load(filepath.Join(dir, "current.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			diff := Compare(baseline, current)
+			var out bytes.Buffer
+			diff.Write(&out)
+			if diff.Empty() != (out.Len() == 0) {
+				t.Errorf("Empty() = %v but the diff prints %q", diff.Empty(), out.String())
+			}
+
+			golden := filepath.Join(dir, "diff.golden")
+			if *updateGolden {
+				if err := os.WriteFile(golden, out.Bytes(), 0644); err != nil {
+					t.Fatalf("failed to update golden file: %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+			if out.String() != string(want) {
+				t.Errorf("diff mismatch\n--- got ---\n%s\n--- want ---\n%s", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	tools := []mcp.Tool{
+		{Name: "resolve-library-id", InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{"libraryName": map[string]interface{}{"type": "string"}}}},
+		{Name: "get-library-docs", Description: "Fetch documentation"},
+	}
+	snapshot := New("context7", tools)
+	if snapshot.Tools[0].Name != "get-library-docs" {
+		t.Errorf("tools should be sorted by name: %+v", snapshot.Tools)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshots", "context7.json")
+	if err := snapshot.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Compare(loaded, New("context7", tools)).Empty() {
+		t.Errorf("a saved snapshot should match the listing it was taken from: %+v", Compare(loaded, snapshot))
+	}
+
+	// Saving again gives the same bytes, so committed snapshots don't churn
+	first, _ := os.ReadFile(path)
+	if err := New("context7", []mcp.Tool{tools[1], tools[0]}).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := os.ReadFile(path); !bytes.Equal(first, second) {
+		t.Errorf("snapshot is not canonical:\n%s\n%s", first, second)
+	}
+}
+
+func TestCompareNestedKeys(t *testing.T) {
+	baseline := &Snapshot{Tools: []Tool{{Name: "fetch", InputSchema: map[string]interface{}{"properties": map[string]interface{}{"a.b": map[string]interface{}{"type": "string"}}}}}}
+	current := &Snapshot{Tools: []Tool{{Name: "fetch", InputSchema: map[string]interface{}{"properties": map[string]interface{}{"a.b": map[string]interface{}{"type": "number"}}}}}}
+
+	want := []SchemaChange{{Path: `properties["a.b"].type`, Kind: Changed, Old: "string", New: "number"}}
+	if got := Compare(baseline, current).Changed[0].Schema; !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %+v, want %+v", got, want)
+	}
+}
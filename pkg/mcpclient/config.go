@@ -0,0 +1,49 @@
+package mcpclient
+
+import (
+	"github.com/mcp-cli-ent/mcp-cli/internal/config"
+)
+
+// Configuration types
+type (
+	// Configuration is a loaded mcp_servers.json, with any project-local file
+	// merged in and variables resolved
+	Configuration = config.Configuration
+
+	// ServerConfig describes how to reach one server
+	ServerConfig = config.ServerConfig
+
+	// LoadOptions controls how a configuration is loaded
+	LoadOptions = config.LoadOptions
+)
+
+// ConfigDir returns the directory holding the configuration, and unless
+// DataDir says otherwise sessions and caches: $MCP_CLI_CONFIG_DIR when set,
+// otherwise the platform's default
+func ConfigDir() (string, error) {
+	return config.GetConfigDir()
+}
+
+// DataDir returns the directory holding sessions and caches:
+// $MCP_CLI_DATA_DIR when set, otherwise the configuration directory
+func DataDir() (string, error) {
+	return config.GetDataDir()
+}
+
+// FindConfig returns the path of the configuration the CLI would use
+// without --config
+func FindConfig() (string, error) {
+	return config.FindConfigFile()
+}
+
+// LoadConfig loads the configuration at path, loading its env file before
+// variables are resolved
+func LoadConfig(path string) (*Configuration, error) {
+	return config.LoadConfig(path)
+}
+
+// LoadConfigWithOptions loads the configuration at path, merging
+// opts.LocalConfig over it when set
+func LoadConfigWithOptions(path string, opts LoadOptions) (*Configuration, error) {
+	return config.LoadConfigWithOptions(path, opts)
+}
@@ -0,0 +1,24 @@
+// Package mcpclient lets Go programs use mcp-cli-ent's MCP client, server
+// configuration and content types directly, instead of running the CLI.
+//
+// Load a configuration, then dial one of its servers:
+//
+//	cfg, err := mcpclient.LoadConfig(path)
+//	...
+//	c, err := mcpclient.DialServer(ctx, cfg, "context7")
+//	...
+//	defer c.Close()
+//	tools, err := c.ListTools(ctx)
+//
+// The types are aliases of the ones the CLI itself uses, and the CLI loads
+// its configuration through this package, so the two cannot drift apart.
+//
+// # Compatibility
+//
+// This package follows semantic versioning with the module. Within a major
+// version, exported names are not removed or renamed and function signatures
+// do not change. Struct types may gain fields and Client may gain methods in
+// a minor version, so compose a Client from one this package returns rather
+// than implementing the interface from scratch. Packages under internal/ make
+// no such promise.
+package mcpclient
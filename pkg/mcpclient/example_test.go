@@ -0,0 +1,35 @@
+package mcpclient_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mcp-cli-ent/mcp-cli/pkg/mcpclient"
+)
+
+func Example() {
+	path, err := mcpclient.FindConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg, err := mcpclient.LoadConfig(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	c, err := mcpclient.DialServer(ctx, cfg, "context7")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	result, err := c.CallTool(ctx, "resolve-library-id", map[string]interface{}{"libraryName": "react"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, block := range result.Blocks() {
+		fmt.Println(block.Text)
+	}
+}
@@ -0,0 +1,107 @@
+package mcpclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mcp-cli-ent/mcp-cli/internal/client"
+	"github.com/mcp-cli-ent/mcp-cli/internal/mcp"
+	"github.com/mcp-cli-ent/mcp-cli/pkg/version"
+)
+
+// ProtocolVersion is the MCP protocol revision the client speaks
+const ProtocolVersion = mcp.ProtocolVersion
+
+// Client is a connection to one MCP server
+type Client = mcp.MCPClient
+
+// Protocol and content types
+type (
+	Tool               = mcp.Tool
+	ToolResult         = mcp.ToolResult
+	ContentBlock       = mcp.ContentBlock
+	Resource           = mcp.Resource
+	Prompt             = mcp.Prompt
+	Root               = mcp.Root
+	InitializeParams   = mcp.InitializeParams
+	InitializeResult   = mcp.InitializeResult
+	ClientInfo         = mcp.ClientInfo
+	ClientCapabilities = mcp.ClientCapabilities
+	ServerCapabilities = mcp.ServerCapabilities
+	JSONRPCRequest     = mcp.JSONRPCRequest
+	JSONRPCError       = mcp.JSONRPCError
+	BatchResult        = mcp.BatchResult
+)
+
+// Errors the client's failures match with errors.Is. A server's own error
+// answer is a *JSONRPCError, found with errors.As.
+var (
+	ErrServerNotFound    = mcp.ErrServerNotFound
+	ErrToolNotFound      = mcp.ErrToolNotFound
+	ErrProcessExited     = mcp.ErrProcessExited
+	ErrTimeout           = mcp.ErrTimeout
+	ErrSessionNotActive  = mcp.ErrSessionNotActive
+	ErrDaemonUnavailable = mcp.ErrDaemonUnavailable
+)
+
+// Optional interfaces a Client may implement
+type (
+	// RequestSender sends requests Client has no typed method for, such as
+	// prompts/get and resources/read
+	RequestSender = mcp.RequestSender
+
+	// BatchCapable sends several requests in one round trip
+	BatchCapable = mcp.BatchCapable
+
+	// NotificationSource reports the notifications a server sends
+	NotificationSource = mcp.NotificationSource
+
+	// NotificationHandler receives a server notification. It runs on the
+	// client's read path and must not block or call back into the client.
+	NotificationHandler = mcp.NotificationHandler
+)
+
+// New creates a client for serverConfig without connecting: stdio, SSH and
+// Docker servers are started, and HTTP servers contacted, by Initialize.
+// Requests are retried following the server's retry policy.
+func New(serverConfig ServerConfig) (Client, error) {
+	return client.NewMCPClientWithOptions(serverConfig, client.WithRetryPolicy(serverConfig.Retry))
+}
+
+// Dial creates a client for serverConfig and completes the MCP initialize
+// handshake. The caller must Close the client.
+func Dial(ctx context.Context, serverConfig ServerConfig) (Client, error) {
+	c, err := New(serverConfig)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.Initialize(ctx, DefaultInitializeParams()); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+	return c, nil
+}
+
+// DialServer dials the named server of cfg. Disabled servers, including
+// those missing required environment variables, are refused.
+func DialServer(ctx context.Context, cfg *Configuration, name string) (Client, error) {
+	serverConfig, ok := cfg.GetServer(name)
+	if !ok {
+		return nil, mcp.Mark(fmt.Errorf("server '%s' not found in configuration", name), mcp.ErrServerNotFound)
+	}
+	if !serverConfig.IsEnabled() {
+		return nil, fmt.Errorf("server '%s' is disabled", name)
+	}
+	return Dial(ctx, serverConfig)
+}
+
+// DefaultInitializeParams returns the initialize request Dial sends
+func DefaultInitializeParams() *InitializeParams {
+	return &InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		ClientInfo: ClientInfo{
+			Name:    "mcp-cli-ent",
+			Version: version.Version,
+		},
+	}
+}
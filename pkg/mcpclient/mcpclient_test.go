@@ -0,0 +1,78 @@
+package mcpclient_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcp-cli-ent/mcp-cli/pkg/mcpclient"
+)
+
+// fakeServer answers initialize, tools/list and tools/call like a minimal MCP server
+const fakeServer = `while IFS= read -r line; do
+  case "$line" in
+    *'"initialize"'*) echo '{"jsonrpc":"2.0","id":0,"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"fake","version":"1.0.0"}}}' ;;
+    *'"tools/list"'*) echo '{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"echo","description":"Echo text","inputSchema":{"type":"object"}}]}}' ;;
+    *'"tools/call"'*) echo '{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"hello"}]}}' ;;
+  esac
+done`
+
+func writeConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mcp_servers.json")
+	script := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(fakeServer)
+	data := `{"mcpServers": {
+		"fake": {"command": "sh", "args": ["-c", "` + script + `"]},
+		"off": {"command": "sh", "enabled": false}
+	}}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDialServer(t *testing.T) {
+	cfg, err := mcpclient.LoadConfig(writeConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c, err := mcpclient.DialServer(ctx, cfg, "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	tools, err := c.ListTools(ctx)
+	if err != nil || len(tools) != 1 || tools[0].Name != "echo" {
+		t.Fatalf("ListTools = %+v, %v", tools, err)
+	}
+	result, err := c.CallTool(ctx, "echo", map[string]interface{}{"text": "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocks := result.Blocks(); len(blocks) != 1 || blocks[0].Text != "hello" {
+		t.Errorf("CallTool content = %+v", blocks)
+	}
+}
+
+func TestDialServerRefusesUnknownAndDisabled(t *testing.T) {
+	cfg, err := mcpclient.LoadConfig(writeConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"missing": "not found", "off": "disabled"} {
+		if _, err := mcpclient.DialServer(context.Background(), cfg, name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("DialServer(%s) = %v, want an error containing %q", name, err, want)
+		}
+	}
+	if _, err := mcpclient.DialServer(context.Background(), cfg, "missing"); !errors.Is(err, mcpclient.ErrServerNotFound) {
+		t.Errorf("DialServer(missing) = %v, want it to match ErrServerNotFound", err)
+	}
+}